@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// apiClient calls the admin API of a running instance.
+type apiClient struct {
+	baseURL string
+	token   string
+	tenant  string
+	http    *http.Client
+}
+
+// newAPIClient configures a client from ARDANOTIF_URL, ARDANOTIF_TOKEN and
+// ARDANOTIF_TENANT. There is no request timeout: purge and replay last as long
+// as the work they do; interrupt to cancel.
+func newAPIClient() (*apiClient, error) {
+	c := &apiClient{
+		baseURL: strings.TrimSuffix(os.Getenv("ARDANOTIF_URL"), "/"),
+		token:   os.Getenv("ARDANOTIF_TOKEN"),
+		tenant:  os.Getenv("ARDANOTIF_TENANT"),
+		http:    &http.Client{},
+	}
+	if c.baseURL == "" {
+		c.baseURL = "http://localhost:8090"
+	}
+	if c.token == "" {
+		return nil, fmt.Errorf("ARDANOTIF_TOKEN is required")
+	}
+	return c, nil
+}
+
+func (c *apiClient) newRequest(ctx context.Context, method, path string, body any) (*http.Request, error) {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, r)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Internal-Token", c.token)
+	if c.tenant != "" {
+		req.Header.Set("X-Tenant-ID", c.tenant)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+// do sends a JSON request and returns the response body. Responses other than
+// 2xx are returned as errors carrying the status and the API's message.
+func (c *apiClient) do(ctx context.Context, method, path string, body any) (json.RawMessage, error) {
+	req, err := c.newRequest(ctx, method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return raw, &apiError{Status: resp.StatusCode, Message: apiMessage(raw)}
+	}
+	return raw, nil
+}
+
+// apiError is a non-2xx response.
+type apiError struct {
+	Status  int
+	Message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s (HTTP %d)", e.Message, e.Status)
+}
+
+// apiMessage extracts the message of an error response, or returns it whole.
+func apiMessage(raw []byte) string {
+	var body struct {
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}
+	if json.Unmarshal(raw, &body) == nil {
+		if body.Message != "" {
+			return body.Message
+		}
+		if body.Error != "" {
+			return body.Error
+		}
+	}
+	return strings.TrimSpace(string(raw))
+}
+
+// printJSON writes v to stdout, indented.
+func printJSON(v any) error {
+	if raw, ok := v.(json.RawMessage); ok {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, raw, "", "  "); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+		_, err := buf.WriteTo(os.Stdout)
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
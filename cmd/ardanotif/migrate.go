@@ -0,0 +1,25 @@
+package main
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"vn.io.arda/notification/internal/cli"
+	"vn.io.arda/notification/internal/config"
+)
+
+// runMigrate connects to the database configured by the DB_* environment, like
+// the service, and runs the shared migrate subcommand.
+func runMigrate(ctx context.Context, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	pool, err := pgxpool.New(ctx, cfg.Database.DSN())
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+	return cli.Migrate(ctx, pool, "ardanotif", args)
+}
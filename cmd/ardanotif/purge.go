@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"net/http"
+)
+
+const purgeUsage = `usage: ardanotif purge [flags]
+
+Runs the notification part of the retention job on a running instance:
+notifications past their retention policy (or the TTL) and past their expiry.
+
+flags:
+  -dry-run     count what would be deleted, delete nothing
+  -days N      TTL for notifications without a retention policy
+               (default: the instance's ARDA_NOTIF_TTL_RETENTION_DAYS)`
+
+func runPurge(ctx context.Context, args []string) error {
+	fs := newFlagSet("purge", purgeUsage)
+	var (
+		dryRun bool
+		days   int
+	)
+	fs.BoolVar(&dryRun, "dry-run", false, "")
+	fs.IntVar(&days, "days", 0, "")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	api, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+	body := map[string]any{"dry_run": dryRun}
+	if days > 0 {
+		body["older_than_days"] = days
+	}
+	raw, err := api.do(ctx, http.MethodPost, "/notifications/admin/purge", body)
+	if err != nil {
+		return err
+	}
+	return printJSON(raw)
+}
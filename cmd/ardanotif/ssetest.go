@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const sseTestUsage = `usage: ardanotif sse-test [flags]
+
+Opens the notification stream of the token's user and prints every event with
+the time since the stream opened, until -duration elapses or the stream ends.
+
+flags:
+  -duration D   how long to listen (default 30s)
+  -send         once connected, send the user a test notification
+                (POST /notifications/test) and report how long its push took`
+
+func runSSETest(ctx context.Context, args []string) error {
+	fs := newFlagSet("sse-test", sseTestUsage)
+	var (
+		duration time.Duration
+		send     bool
+	)
+	fs.DurationVar(&duration, "duration", 30*time.Second, "")
+	fs.BoolVar(&send, "send", false, "")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	api, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	req, err := api.newRequest(ctx, http.MethodGet, "/notifications/stream", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	start := time.Now()
+	resp, err := api.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var buf [512]byte
+		n, _ := resp.Body.Read(buf[:])
+		return &apiError{Status: resp.StatusCode, Message: apiMessage(buf[:n])}
+	}
+
+	var (
+		event   string
+		sentAt  time.Time
+		testIDs = make(map[string]bool)
+	)
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data := strings.TrimPrefix(line, "data: ")
+			fmt.Fprintf(os.Stdout, "%8s  %-20s %s\n", time.Since(start).Round(time.Millisecond), event, data)
+			if event == "connected" && send && sentAt.IsZero() {
+				sentAt = time.Now()
+				id, err := sendTestNotification(ctx, api)
+				if err != nil {
+					return err
+				}
+				testIDs[id] = true
+			}
+			if event == "notification" && !sentAt.IsZero() {
+				var n struct {
+					ID string `json:"id"`
+				}
+				if json.Unmarshal([]byte(data), &n) == nil && testIDs[n.ID] {
+					fmt.Fprintf(os.Stdout, "test notification pushed %s after it was sent\n", time.Since(sentAt).Round(time.Millisecond))
+					delete(testIDs, n.ID)
+				}
+			}
+		case line == "":
+			event = ""
+		}
+	}
+	err = scanner.Err()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = nil
+	}
+	if len(testIDs) > 0 {
+		return fmt.Errorf("the test notification was not pushed within %s", duration)
+	}
+	return err
+}
+
+// sendTestNotification sends the caller a test notification and returns its ID.
+func sendTestNotification(ctx context.Context, api *apiClient) (string, error) {
+	raw, err := api.do(ctx, http.MethodPost, "/notifications/test", nil)
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		Data struct {
+			Notification *struct {
+				ID string `json:"id"`
+			} `json:"notification"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return "", err
+	}
+	if resp.Data.Notification == nil {
+		return "", fmt.Errorf("the test notification was muted by the user's SYSTEM preference")
+	}
+	return resp.Data.Notification.ID, nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+const statsUsage = `usage: ardanotif stats
+
+Prints the fan-out counters, Kafka event handler health, SSE delivery latency
+and IAM cache stats of the instance ARDANOTIF_URL reaches. Behind a load
+balancer every call may reach another instance.`
+
+// statsEndpoints are the admin endpoints stats reads, by section.
+var statsEndpoints = []struct{ section, path string }{
+	{"fanout", "/notifications/admin/fanout/stats"},
+	{"handlers", "/notifications/admin/handlers/health"},
+	{"sse_latency", "/notifications/admin/sse/latency"},
+	{"iam_cache", "/notifications/admin/iam/cache"},
+}
+
+func runStats(ctx context.Context, args []string) error {
+	if err := newFlagSet("stats", statsUsage).Parse(args); err != nil {
+		return err
+	}
+	api, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+	out := make(map[string]json.RawMessage, len(statsEndpoints))
+	for _, ep := range statsEndpoints {
+		raw, err := api.do(ctx, http.MethodGet, ep.path, nil)
+		var apiErr *apiError
+		if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+			// Not enabled on the instance, e.g. an uncached IAM resolver.
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		out[ep.section] = raw
+	}
+	return printJSON(out)
+}
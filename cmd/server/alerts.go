@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"vn.io.arda/notification/internal/config"
+	"vn.io.arda/notification/internal/domain"
+	"vn.io.arda/notification/internal/infrastructure/alerting"
+)
+
+// newAlerter builds the operator alert dispatcher from ALERT_* settings.
+func newAlerter(cfg *config.Config) (*alerting.Dispatcher, error) {
+	severity := domain.AlertSeverity(cfg.Alert.MinSeverity)
+	if !severity.Valid() {
+		return nil, fmt.Errorf("unknown alert severity %q", cfg.Alert.MinSeverity)
+	}
+	timeout := time.Duration(cfg.Alert.TimeoutSeconds) * time.Second
+	client := &http.Client{Timeout: timeout}
+
+	var backends []domain.Alerter
+	for _, name := range cfg.Alert.Backends {
+		switch strings.TrimSpace(name) {
+		case "log":
+			backends = append(backends, alerting.LogAlerter{})
+		case "webhook":
+			if cfg.Alert.WebhookURL == "" {
+				return nil, fmt.Errorf("alert backend webhook requires ALERT_WEBHOOK_URL")
+			}
+			backends = append(backends, alerting.NewWebhookAlerter(client, cfg.Alert.WebhookURL))
+		case "pagerduty":
+			if cfg.Alert.PagerDutyRoutingKey == "" {
+				return nil, fmt.Errorf("alert backend pagerduty requires ALERT_PAGERDUTY_ROUTING_KEY")
+			}
+			backends = append(backends, alerting.NewPagerDutyAlerter(client, cfg.Alert.PagerDutyRoutingKey))
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown alert backend %q", name)
+		}
+	}
+
+	instance, _ := os.Hostname()
+	return alerting.NewDispatcher(alerting.Config{
+		Environment: cfg.Server.Env,
+		Instance:    instance,
+		MinSeverity: severity,
+		Cooldown:    time.Duration(cfg.Alert.CooldownSeconds) * time.Second,
+		Timeout:     timeout,
+	}, backends...), nil
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package main
+
+import (
+	"context"
+
+	transporthttp "vn.io.arda/notification/internal/transport/http"
+)
+
+// dumpOnSignal is a no-op: SIGUSR1 does not exist on this platform. Use
+// GET /notifications/admin/sse/clients instead.
+func dumpOnSignal(context.Context, *transporthttp.Hub) {}
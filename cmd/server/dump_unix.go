@@ -0,0 +1,30 @@
+//go:build unix
+
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/rs/zerolog/log"
+
+	transporthttp "vn.io.arda/notification/internal/transport/http"
+)
+
+// dumpOnSignal logs the SSE hub state on every SIGUSR1 until ctx is cancelled.
+func dumpOnSignal(ctx context.Context, hub *transporthttp.Hub) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGUSR1)
+	defer signal.Stop(sig)
+	log.Info().Msg("send SIGUSR1 to dump SSE hub state")
+	for {
+		select {
+		case <-sig:
+			hub.LogState()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -2,24 +2,39 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/signal"
-	"strconv"
+	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
+	_ "time/tzdata" // the scratch image has no zoneinfo for ttl.purge_timezone
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
 	"vn.io.arda/notification/internal/application"
+	"vn.io.arda/notification/internal/cli"
 	"vn.io.arda/notification/internal/config"
 	"vn.io.arda/notification/internal/domain"
+	"vn.io.arda/notification/internal/infrastructure/chat"
+	"vn.io.arda/notification/internal/infrastructure/crypto"
 	"vn.io.arda/notification/internal/infrastructure/email"
 	"vn.io.arda/notification/internal/infrastructure/keycloak"
+	"vn.io.arda/notification/internal/infrastructure/ldap"
+	"vn.io.arda/notification/internal/infrastructure/opa"
 	"vn.io.arda/notification/internal/infrastructure/postgres"
+	"vn.io.arda/notification/internal/infrastructure/redis"
+	"vn.io.arda/notification/internal/infrastructure/static"
+	"vn.io.arda/notification/internal/infrastructure/webhook"
 	kafkaconsumer "vn.io.arda/notification/internal/kafka"
+	"vn.io.arda/notification/internal/kafka/registry"
+	"vn.io.arda/notification/internal/kafka/serde"
+	"vn.io.arda/notification/internal/scheduler"
 	transporthttp "vn.io.arda/notification/internal/transport/http"
+	"vn.io.arda/notification/internal/transport/mw"
 )
 
 func main() {
@@ -39,20 +54,20 @@ func main() {
 		zerolog.SetGlobalLevel(zerolog.DebugLevel)
 	}
 
+	log.Logger = log.With().Str("region", cfg.Server.Region).Logger()
 	log.Info().Str("env", cfg.Server.Env).Str("port", cfg.Server.Port).Msg("starting arda-notification")
 
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
 	// ── Database ──────────────────────────────────────────────────────────────
-	dsn := "host=" + cfg.Database.Host +
-		" port=" + strconv.Itoa(cfg.Database.Port) +
-		" dbname=" + cfg.Database.Name +
-		" user=" + cfg.Database.User +
-		" password=" + cfg.Database.Password +
-		" sslmode=disable"
-
-	pool, err := pgxpool.New(ctx, dsn)
+	poolCfg, err := poolConfig(cfg.Database, cfg.Database.DSN())
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid postgres config")
+	}
+	queryTracer := postgres.NewQueryTracer(time.Duration(cfg.Database.SlowQueryMS) * time.Millisecond)
+	poolCfg.ConnConfig.Tracer = queryTracer
+	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to connect to postgres")
 	}
@@ -63,24 +78,199 @@ func main() {
 	}
 	log.Info().Msg("postgres connected")
 
+	var registry postgres.TenantRegistry
+	if cfg.Database.TenantRegistryFile != "" {
+		if registry, err = postgres.LoadTenantRegistry(cfg.Database.TenantRegistryFile); err != nil {
+			log.Fatal().Err(err).Msg("invalid tenant registry")
+		}
+	}
+	tenantDBs, err := postgres.NewTenantRouter(ctx, pool, cfg.Database.DSN(), registry, func(dsn string) (*pgxpool.Config, error) {
+		c, err := poolConfig(cfg.Database, dsn)
+		if err != nil {
+			return nil, err
+		}
+		c.ConnConfig.Tracer = queryTracer
+		return c, nil
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to connect to tenant databases")
+	}
+	defer tenantDBs.Close()
+	if targets := tenantDBs.Targets(); len(targets) > 1 {
+		log.Info().Strs("targets", targets[1:]).Int("tenants", len(registry.Tenants)).Msg("tenant database routing enabled")
+	}
+
+	// ── Schema Migrations ─────────────────────────────────────────────────────
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		for i, p := range tenantDBs.Pools() {
+			log.Info().Str("target", tenantDBs.Targets()[i]).Msg("migrating")
+			if err := cli.Migrate(ctx, p, "arda-notification", os.Args[2:]); err != nil {
+				log.Fatal().Err(err).Str("target", tenantDBs.Targets()[i]).Msg("migrate failed")
+			}
+		}
+		return
+	}
+	if cfg.Database.AutoMigrate {
+		for i, p := range tenantDBs.Pools() {
+			if err := cli.MigrateUp(ctx, p); err != nil {
+				log.Fatal().Err(err).Str("target", tenantDBs.Targets()[i]).Msg("database migration failed")
+			}
+		}
+	}
+
 	// ── Repository & SSE Hub ─────────────────────────────────────────────────
-	repo := postgres.New(pool)
+	pgRepo := postgres.New(pool)
+	pgRepo.SetCopyThreshold(cfg.Fanout.CopyThreshold)
+	pgRepo.SetPurgeBatchSize(cfg.TTL.PurgeBatchSize)
+	if err := domain.SetIDFormat(domain.IDFormat(cfg.ID.Format)); err != nil {
+		log.Fatal().Err(err).Msg("invalid ID format")
+	}
+	idGen, err := domain.NewIDGenerator(cfg.ID.Generator)
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid ID generator")
+	}
+	pgRepo.SetIDGenerator(idGen)
+	if cfg.Database.ReplicaDSN != "" {
+		// Connections are opened lazily; an unreachable replica only sends reads to the primary.
+		replicaCfg, err := poolConfig(cfg.Database, cfg.Database.ReplicaDSN)
+		if err != nil {
+			log.Fatal().Err(err).Msg("invalid read replica config")
+		}
+		replicaCfg.ConnConfig.Tracer = queryTracer
+		replica, err := pgxpool.NewWithConfig(ctx, replicaCfg)
+		if err != nil {
+			log.Fatal().Err(err).Msg("invalid read replica config")
+		}
+		defer replica.Close()
+		pgRepo.SetReadReplica(replica, time.Duration(cfg.Database.ReplicaRetrySeconds)*time.Second)
+		log.Info().Msg("inbox reads routed to read replica")
+	}
+	if cfg.Database.RLS {
+		log.Info().Msg("row-level security: connections are scoped to the request tenant")
+	}
+	var repo domain.Repository = pgRepo
+	var (
+		stateEvents domain.StateEventRepository = postgres.NewStateEventRepo(pool)
+		contentRepo domain.ContentRepository    = postgres.NewContentRepo(pool)
+		auditRepo   domain.AuditRepository      = postgres.NewAuditRepo(pool)
+		escalations domain.EscalationRepository = postgres.NewEscalationRepo(pool)
+		statsRepo   domain.StatsRepository      = postgres.NewStatsRepo(pool)
+	)
+	if len(tenantDBs.Pools()) > 1 {
+		repo = postgres.NewRoutedRepository(tenantDBs, func(p *pgxpool.Pool) *postgres.Repository {
+			if p == pool {
+				return pgRepo
+			}
+			r := postgres.New(p)
+			r.SetCopyThreshold(cfg.Fanout.CopyThreshold)
+			r.SetPurgeBatchSize(cfg.TTL.PurgeBatchSize)
+			r.SetIDGenerator(idGen)
+			return r
+		})
+		stateEvents = postgres.NewRoutedStateEventRepo(tenantDBs)
+		contentRepo = postgres.NewRoutedContentRepo(tenantDBs)
+		auditRepo = postgres.NewRoutedAuditRepo(tenantDBs)
+		escalations = postgres.NewRoutedEscalationRepo(tenantDBs)
+		statsRepo = postgres.NewRoutedStatsRepo(tenantDBs)
+	}
 	prefRepo := postgres.NewPreferenceRepo(pool)
 	templateRepo := postgres.NewTemplateRepo(pool)
-	hub := transporthttp.NewHub()
+	reactionRepo := postgres.NewReactionRepo(pool)
+	rolloutRepo := postgres.NewRolloutRepo(pool)
+	policyRepo := postgres.NewPolicyRepo(pool)
+	keyRepo := postgres.NewEncryptionKeyRepo(pool)
+
+	// ── Content Encryption (BYOK) ─────────────────────────────────────────────
+	var (
+		keyProvider domain.KeyProvider
+		keyRotator  domain.KeyRotator
+	)
+	if cfg.Encryption.Keys != "" {
+		staticKeys, err := crypto.ParseStaticKeys(cfg.Encryption.Keys)
+		if err != nil {
+			log.Fatal().Err(err).Msg("invalid encryption keys")
+		}
+		keyProvider = staticKeys
+		cipher := crypto.NewCipher(keyRepo, keyProvider, cfg.Encryption.DefaultKeyRef, time.Duration(cfg.Encryption.CacheSeconds)*time.Second)
+		cipher.SetSensitiveTenants(cfg.Encryption.SensitiveTenants)
+		if cfg.Encryption.DefaultKeyRef != "" {
+			if err := cipher.CheckKey(ctx, cfg.Encryption.DefaultKeyRef); err != nil {
+				log.Fatal().Err(err).Msg("invalid default encryption key")
+			}
+		}
+		repo = crypto.NewRepository(repo, cipher)
+		keyRotator = crypto.NewRotator(contentRepo, cipher)
+		log.Info().Str("default_key_ref", cfg.Encryption.DefaultKeyRef).Msg("notification content encryption enabled")
+	}
+
+	hub := transporthttp.NewHub(transporthttp.HubConfig{
+		HeartbeatInterval: time.Duration(cfg.SSE.HeartbeatSeconds) * time.Second,
+		IdleTimeout:       time.Duration(cfg.SSE.IdleTimeoutSeconds) * time.Second,
+		MaxConnsPerUser:   cfg.SSE.MaxConnsPerUser,
+		DropPolicy:        transporthttp.DropPolicy(cfg.SSE.DropPolicy),
+		SpillLimit:        cfg.SSE.SpillLimit,
+		ReauthLead:        time.Duration(cfg.SSE.ReauthLeadSeconds) * time.Second,
+		ReauthGrace:       time.Duration(cfg.SSE.ReauthGraceSeconds) * time.Second,
+	})
+	go hub.RunReaper(ctx)
+	go dumpOnSignal(ctx, hub)
 
 	// ── Template Engine ────────────────────────────────────────────────────────
-	templateEngine := application.NewTemplateEngine(templateRepo, "vi")
-
-	// ── IAM Resolver (Keycloak Admin API) ─────────────────────────────────────
-	iamResolver := keycloak.New(
-		cfg.Keycloak.BaseURL,
-		cfg.Keycloak.AdminRealm,
-		cfg.Keycloak.AdminClientID,
-		cfg.Keycloak.AdminClientSecret,
+	templateEngine := application.NewTemplateEngine(templateRepo, cfg.Template.DefaultLocale)
+	templateEngine.SetOverrides(postgres.NewTemplateOverrideRepo(pool))
+
+	// ── IAM Resolver ──────────────────────────────────────────────────────────
+	var (
+		iamResolver application.IAMResolver
+		iamProbe    transporthttp.Probe
 	)
-	// Wire dev-fallback credentials into the resolver (used when AdminClientSecret is empty).
-	iamResolver.SetPasswordFallback(cfg.Keycloak.AdminUser, cfg.Keycloak.AdminPassword)
+	switch cfg.IAM.Provider {
+	case "ldap":
+		iamResolver = ldap.New(ldap.Config{
+			URL:             cfg.IAM.LDAP.URL,
+			BindDN:          cfg.IAM.LDAP.BindDN,
+			BindPassword:    cfg.IAM.LDAP.BindPassword,
+			StartTLS:        cfg.IAM.LDAP.StartTLS,
+			UserBaseDN:      cfg.IAM.LDAP.UserBaseDN,
+			UserFilter:      cfg.IAM.LDAP.UserFilter,
+			IDAttribute:     cfg.IAM.LDAP.IDAttribute,
+			GroupBaseDN:     cfg.IAM.LDAP.GroupBaseDN,
+			RoleBaseDN:      cfg.IAM.LDAP.RoleBaseDN,
+			MemberAttribute: cfg.IAM.LDAP.MemberAttribute,
+			Tenants:         cfg.IAM.LDAP.Tenants,
+		})
+	case "db":
+		iamResolver = postgres.NewUserDirectory(pool)
+	case "static":
+		staticResolver, err := static.Load(cfg.IAM.StaticFile)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to load static user directory")
+		}
+		iamResolver = staticResolver
+	case "keycloak", "":
+		// Keycloak Admin API.
+		keycloakResolver := keycloak.New(
+			cfg.Keycloak.BaseURL,
+			cfg.Keycloak.AdminRealm,
+			cfg.Keycloak.AdminClientID,
+			cfg.Keycloak.AdminClientSecret,
+		)
+		// Wire dev-fallback credentials into the resolver (used when AdminClientSecret is empty).
+		keycloakResolver.SetPasswordFallback(cfg.Keycloak.AdminUser, cfg.Keycloak.AdminPassword)
+		keycloakResolver.SetCache(keycloak.CacheConfig{
+			TTL:         time.Duration(cfg.Keycloak.CacheSeconds) * time.Second,
+			TenantTTL:   time.Duration(cfg.Keycloak.TenantCacheSeconds) * time.Second,
+			RoleTTL:     time.Duration(cfg.Keycloak.RoleCacheSeconds) * time.Second,
+			PlatformTTL: time.Duration(cfg.Keycloak.PlatformCacheSeconds) * time.Second,
+			NegativeTTL: time.Duration(cfg.Keycloak.NegativeCacheSeconds) * time.Second,
+			MaxEntries:  cfg.Keycloak.CacheMaxEntries,
+		})
+		iamResolver = keycloakResolver
+		iamProbe = keycloakResolver.Ping
+	default:
+		log.Fatal().Str("provider", cfg.IAM.Provider).Msg("unknown IAM provider")
+	}
+	log.Info().Str("provider", cfg.IAM.Provider).Msg("IAM resolver configured")
 
 	// ── Email Sender ──────────────────────────────────────────────────────────
 	var emailSender domain.EmailSender
@@ -96,41 +286,364 @@ func main() {
 		emailSender = email.NewLogSender()
 	}
 
+	// ── Operator Alerts ───────────────────────────────────────────────────────
+	alerter, err := newAlerter(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid alert configuration")
+	}
+	log.Info().Strs("backends", cfg.Alert.Backends).Msg("operator alerts configured")
+
 	// ── Application Service ───────────────────────────────────────────────────
-	svc := application.NewService(repo, prefRepo, hub, iamResolver, emailSender, templateEngine)
+	throttleTypes, err := application.ParseThrottleRules(cfg.Throttle.Types)
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid THROTTLE_TYPES")
+	}
+	var redactor *application.Redactor
+	if cfg.Redaction.Enabled {
+		if redactor, err = application.NewRedactor(application.RedactionConfig{
+			Patterns: cfg.Redaction.Patterns,
+			Custom:   strings.Fields(cfg.Redaction.CustomPatterns),
+			Keys:     cfg.Redaction.Keys,
+			Mode:     application.RedactionMode(cfg.Redaction.Mode),
+		}); err != nil {
+			log.Fatal().Err(err).Msg("invalid redaction config")
+		}
+		log.Info().Strs("patterns", cfg.Redaction.Patterns).Str("mode", cfg.Redaction.Mode).Msg("metadata PII redaction enabled")
+	}
+	quotaDefaults := application.QuotaConfig{MonthlyLimit: cfg.Quota.MonthlyLimit, Overage: domain.QuotaOverage(cfg.Quota.Overage)}
+	if !quotaDefaults.Overage.Valid() || quotaDefaults.MonthlyLimit < 0 {
+		log.Fatal().Str("overage", cfg.Quota.Overage).Int64("monthly_limit", cfg.Quota.MonthlyLimit).Msg("invalid QUOTA_OVERAGE or QUOTA_MONTHLY_LIMIT")
+	}
+	svcOpts := []application.Option{
+		application.WithPreferences(prefRepo),
+		application.WithReactions(reactionRepo),
+		application.WithActions(postgres.NewActionRepo(pool)),
+		application.WithAudit(auditRepo),
+		application.WithConsumerPauses(postgres.NewConsumerPauseRepo(pool)),
+		application.WithEmailSender(emailSender),
+		application.WithTemplateEngine(templateEngine, cfg.Template.Mode),
+		application.WithRollouts(rolloutRepo),
+		application.WithFanout(cfg.Fanout.ChunkSize, map[domain.TargetScope]domain.FanoutStrategy{
+			domain.ScopeTenant:   domain.FanoutStrategy(cfg.Fanout.TenantStrategy),
+			domain.ScopePlatform: domain.FanoutStrategy(cfg.Fanout.PlatformStrategy),
+		}),
+		application.WithRecipientCap(application.RecipientCapConfig{
+			Max:       cfg.Fanout.MaxRecipients,
+			Policy:    application.RecipientCapPolicy(cfg.Fanout.OverCapPolicy),
+			AlertRole: cfg.Fanout.CapAlertRole,
+		}),
+		application.WithCustomTypes(postgres.NewCustomTypeRepo(pool)),
+		application.WithRateLimit(rateLimit(cfg)),
+		application.WithThrottle(application.ThrottleConfig{
+			Default: application.ThrottleRule{
+				DedupWindow: time.Duration(cfg.Throttle.DedupWindowSeconds) * time.Second,
+				PerMinute:   cfg.Throttle.UserPerMinute,
+			},
+			Types: throttleTypes,
+		}),
+		application.WithTraceRepo(postgres.NewTraceRepo(pool)),
+		application.WithStateEvents(stateEvents),
+		application.WithWebhooks(postgres.NewWebhookRepo(pool), webhook.NewSender(time.Duration(cfg.Webhook.TimeoutSeconds)*time.Second, cfg.Webhook.AllowPrivate), application.WebhookConfig{
+			PollInterval: time.Duration(cfg.Webhook.PollIntervalMS) * time.Millisecond,
+			BatchSize:    cfg.Webhook.BatchSize,
+			Lease:        time.Duration(cfg.Webhook.LeaseSeconds) * time.Second,
+			MaxAttempts:  cfg.Webhook.MaxAttempts,
+			Backoff:      time.Duration(cfg.Webhook.BackoffSeconds) * time.Second,
+			MaxBackoff:   time.Duration(cfg.Webhook.BackoffMaxSeconds) * time.Second,
+			AllowHTTP:    cfg.Webhook.AllowHTTP,
+			AllowPrivate: cfg.Webhook.AllowPrivate,
+		}),
+		application.WithChatConnectors(postgres.NewChatConnectorRepo(pool), chat.NewPoster(time.Duration(cfg.Chat.TimeoutSeconds)*time.Second)),
+		application.WithTenantActivity(postgres.NewTenantActivityRepo(pool), time.Duration(cfg.Tenant.IdleAfterHours)*time.Hour),
+		application.WithPolicyEngine(policyRepo, opa.NewEvaluator(time.Duration(cfg.Policy.EvalTimeoutMS)*time.Millisecond), cfg.Policy.FailClosed),
+		application.WithEventDefaults(postgres.NewEventDefaultsRepo(pool)),
+		application.WithRetentionPolicies(postgres.NewRetentionPolicyRepo(pool)),
+		application.WithStats(statsRepo),
+		application.WithQuotas(postgres.NewQuotaRepo(pool), quotaDefaults),
+		application.WithAnnouncements(postgres.NewAnnouncementRepo(pool)),
+		application.WithEscalations(escalations),
+		application.WithMaintenance(postgres.NewMaintenanceRepo(pool)),
+		application.WithDirectMessages(postgres.NewDirectMessageRuleRepo(pool), domain.DirectMessageRule{
+			Enabled:       cfg.Direct.Enabled,
+			MaxRecipients: max(cfg.Direct.MaxRecipients, 1),
+			PerMinute:     max(cfg.Direct.PerMinute, 1),
+		}),
+		application.WithAlerter(alerter),
+	}
+	if redactor != nil {
+		svcOpts = append(svcOpts, application.WithRedactor(redactor))
+	}
+	switch cfg.Metadata.OversizePolicy {
+	case "truncate", "reject":
+		svcOpts = append(svcOpts, application.WithMetadataLimits(application.MetadataLimits{
+			MaxBytes: cfg.Metadata.MaxBytes,
+			Truncate: cfg.Metadata.OversizePolicy == "truncate",
+		}))
+	default:
+		log.Fatal().Str("policy", cfg.Metadata.OversizePolicy).Msg("invalid METADATA_OVERSIZE_POLICY: want truncate or reject")
+	}
+	if keyProvider != nil {
+		svcOpts = append(svcOpts, application.WithEncryptionKeys(keyRepo, keyProvider), application.WithKeyRotator(keyRotator))
+	}
+	if cfg.Counters.Enabled {
+		ttl := time.Duration(cfg.Counters.TTLSeconds) * time.Second
+		if cfg.Redis.Addr != "" {
+			redisClient := redis.NewClient(redis.Options{
+				Addr:     cfg.Redis.Addr,
+				Password: cfg.Redis.Password,
+				DB:       cfg.Redis.DB,
+				TLS:      cfg.Redis.TLS,
+				Timeout:  time.Duration(cfg.Redis.TimeoutMS) * time.Millisecond,
+			})
+			defer redisClient.Close()
+			// Unreachable Redis is not fatal: counts fall back to Postgres per request.
+			if err := redisClient.Ping(ctx); err != nil {
+				log.Warn().Err(err).Str("addr", cfg.Redis.Addr).Msg("redis unreachable, unread counts will be read from the database")
+			}
+			svcOpts = append(svcOpts, application.WithCounterStore(redis.NewCounterStore(redisClient, "", ttl)))
+			log.Info().Str("addr", cfg.Redis.Addr).Msg("unread count cache: redis")
+		} else {
+			svcOpts = append(svcOpts, application.WithCounterStore(application.NewMemoryCounterStore(ttl)))
+			log.Info().Msg("unread count cache: in-memory")
+		}
+	}
+	svc := application.NewService(repo, hub, iamResolver, svcOpts...)
+	// Partitions for the coming months must exist before fan-out writes into them.
+	svc.EnsurePartitions(ctx, cfg.TTL.PartitionMonthsAhead)
+
+	// ── Kafka Producer (reactions, actions, lifecycle events) ────────────────
+	producer, err := kafkaconsumer.NewProducer(cfg.Kafka.Brokers, kafkaconsumer.ProducerTopics{
+		Reactions:     cfg.Kafka.ReactionTopic,
+		Actions:       cfg.Kafka.ActionTopic,
+		Lifecycle:     cfg.Kafka.LifecycleTopic,
+		DeadLetter:    cfg.Kafka.DLQTopic,
+		Notifications: cfg.Kafka.NotificationEventTopic,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to create kafka producer")
+	}
+	defer producer.Close()
+	svc.SetReactionPublisher(producer)
+	svc.SetActionPublisher(producer)
+	svc.SetNotificationEventPublisher(producer)
+
+	// ── Kafka Event Routing ──────────────────────────────────────────────────
+	decoder := setupEventRouting(cfg)
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		if err := runReplay(ctx, cfg, svc, decoder, os.Args[2:]); err != nil {
+			log.Fatal().Err(err).Msg("replay failed")
+		}
+		return
+	}
+
+	// ── Delivery Outbox Dispatcher ───────────────────────────────────────────
+	go svc.RunOutboxDispatcher(ctx, application.OutboxConfig{
+		PollInterval: time.Duration(cfg.Outbox.PollIntervalMS) * time.Millisecond,
+		BatchSize:    cfg.Outbox.BatchSize,
+		Lease:        time.Duration(cfg.Outbox.LeaseSeconds) * time.Second,
+	})
+
+	// ── Webhook Dispatcher ───────────────────────────────────────────────────
+	go svc.RunWebhookDispatcher(ctx)
+
+	// ── Idle Tenant Tracking ─────────────────────────────────────────────────
+	go svc.RunTenantActivity(ctx, application.ActivityConfig{
+		FlushInterval:   time.Duration(cfg.Tenant.ActivityFlushSeconds) * time.Second,
+		ReclaimInterval: time.Duration(cfg.Tenant.ReclaimMinutes) * time.Minute,
+	})
 
 	// ── HTTP Server ───────────────────────────────────────────────────────────
 	handler := transporthttp.NewHandler(svc, hub)
-	router := transporthttp.NewRouter(handler, cfg.Keycloak.BaseURL)
+	if cfg.Widget.TokenSecret != "" {
+		if cfg.Widget.IssuerRole == "" {
+			log.Fatal().Msg("WIDGET_ISSUER_ROLE is required when WIDGET_TOKEN_SECRET enables the widget")
+		}
+		handler.SetWidgetTokens(mw.NewWidgetTokens(cfg.Widget.TokenSecret, time.Duration(cfg.Widget.MaxTTLSeconds)*time.Second), cfg.Widget.IssuerRole)
+	}
+	if len(cfg.Service.Clients) > 0 {
+		handler.SetServiceAccounts(mw.NewServiceAccounts(serviceAccounts(cfg)))
+	}
+	handler.SetRegion(cfg.Server.Region)
+	handler.SetQueryStats(queryTracer)
+	handler.SetReplayer(func(ctx context.Context, rc kafkaconsumer.ReplayConfig) (kafkaconsumer.ReplayStats, error) {
+		rc.Brokers = cfg.Kafka.Brokers
+		return kafkaconsumer.Replay(ctx, rc, svc, decoder)
+	})
+	handler.SetProbeOptions(time.Duration(cfg.Server.ProbeTimeoutMS)*time.Millisecond, time.Duration(cfg.Server.ProbeCacheSeconds)*time.Second)
+	handler.AddProbe("postgres", pool.Ping)
+	if iamProbe != nil {
+		handler.AddProbe("keycloak", iamProbe)
+	}
+	security, err := httpSecurity(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid auth config")
+	}
+	if security.TrustedHeaders {
+		log.Info().Int("trusted_proxies", len(security.TrustedProxies)).Msg("authenticating from gateway identity headers")
+	}
+	router := transporthttp.NewRouter(handler, cfg.Keycloak.BaseURL, security)
 
 	// ── Kafka Consumer ────────────────────────────────────────────────────────
-	consumer, err := kafkaconsumer.New(
-		cfg.Kafka.Brokers,
-		cfg.Kafka.ConsumerGroupID,
-		cfg.Kafka.Topics,
-		svc,
-	)
+	consumer, err := kafkaconsumer.New(kafkaconsumer.Config{
+		Brokers:       cfg.Kafka.Brokers,
+		GroupID:       cfg.Kafka.ConsumerGroupID,
+		Topics:        cfg.Kafka.Topics,
+		ExcludeTopics: []string{cfg.Kafka.DLQTopic, cfg.Kafka.ReactionTopic, cfg.Kafka.ActionTopic, cfg.Kafka.LifecycleTopic},
+		Workers:       cfg.Kafka.Workers,
+		MaxInFlight:   cfg.Kafka.MaxInFlight,
+		MaxRetries:    cfg.Kafka.MaxRetries,
+	}, svc)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to create kafka consumer")
 	}
+	if cfg.Kafka.DLQTopic != "" {
+		consumer.SetDeadLetterSink(producer)
+	}
+	consumer.SetDeadLetterAlert(alerter, cfg.Alert.DLQThreshold, time.Duration(cfg.Alert.DLQWindowSeconds)*time.Second)
+	if decoder != nil {
+		consumer.SetDecoder(decoder)
+	}
+	handler.AddProbe("kafka", consumer.Ping)
+
+	handler.SetProbeAlerter(alerter)
+	if cfg.Alert.ProbeIntervalSeconds > 0 {
+		go handler.WatchProbes(ctx, time.Duration(cfg.Alert.ProbeIntervalSeconds)*time.Second)
+	}
+
+	// Apply admin pauses before the first poll, then follow changes made on any instance.
+	if err := consumer.SyncPauses(ctx); err != nil {
+		log.Error().Err(err).Msg("failed to load kafka consumer pauses")
+	}
+	go consumer.WatchPauses(ctx, time.Duration(max(cfg.Kafka.PauseSyncSeconds, 1))*time.Second)
+	handler.SetKafkaConsumer(consumer)
 
 	// Start Kafka consumer in background
 	go consumer.Start(ctx)
 	log.Info().Strs("topics", cfg.Kafka.Topics).Msg("kafka consumer started")
 
-	// ── TTL Purge Job (every 24h) ─────────────────────────────────────────────
-	go func() {
-		ticker := time.NewTicker(24 * time.Hour)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ticker.C:
-				svc.PurgeTTL(context.Background(), cfg.TTL.RetentionDays)
-			case <-ctx.Done():
-				return
+	// ── Config Hot Reload ────────────────────────────────────────────────────
+	// Consumed topics, retention and rate limits follow config file changes;
+	// everything else needs a restart.
+	var runtimeCfg atomic.Pointer[config.Config]
+	runtimeCfg.Store(cfg)
+	if config.Watch(func(next *config.Config) {
+		runtimeCfg.Store(next)
+		svc.SetRateLimit(rateLimit(next))
+		queryTracer.SetSlowThreshold(time.Duration(next.Database.SlowQueryMS) * time.Millisecond)
+		if err := consumer.SetTopics(next.Kafka.Topics); err != nil {
+			log.Warn().Err(err).Msg("kafka topics not reloaded")
+		}
+	}) {
+		log.Info().Msg("watching config file for changes")
+	}
+	handler.SetRetentionDays(func() int { return runtimeCfg.Load().TTL.RetentionDays })
+
+	// ── Scheduled Retention Job (compaction + TTL purge) ─────────────────────
+	purgeSchedule, err := scheduler.ParseSchedule(cfg.TTL.PurgeSchedule)
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid TTL purge schedule")
+	}
+	purgeTZ, err := time.LoadLocation(cfg.TTL.PurgeTimezone)
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid TTL purge timezone")
+	}
+	jobRuns := postgres.NewJobRunRepo(pool)
+	jobs := scheduler.New(jobRuns, purgeTZ)
+	jobs.Add(scheduler.Job{
+		Name:     "retention",
+		Schedule: purgeSchedule,
+		Jitter:   time.Duration(cfg.TTL.PurgeJitterSeconds) * time.Second,
+		Run: func(ctx context.Context) {
+			ttl := runtimeCfg.Load().TTL
+			if ttl.CompactionEnabled {
+				svc.Compact(ctx, ttl.CompactionAfterDays, ttl.CompactionMinRun)
 			}
+			svc.EnsurePartitions(ctx, ttl.PartitionMonthsAhead)
+			svc.PurgeTTL(ctx, ttl.RetentionDays)
+			svc.PurgeAudit(ctx, ttl.AuditRetentionDays)
+		},
+	})
+	go jobs.Run(ctx)
+	log.Info().Str("schedule", cfg.TTL.PurgeSchedule).Str("timezone", purgeTZ.String()).Msg("retention job scheduled")
+
+	// ── Scheduled Engagement Stats Rollup ────────────────────────────────────
+	if cfg.Stats.Enabled {
+		statsSchedule, err := scheduler.ParseSchedule(cfg.Stats.Schedule)
+		if err != nil {
+			log.Fatal().Err(err).Msg("invalid stats schedule")
 		}
-	}()
+		statsTZ, err := time.LoadLocation(cfg.Stats.Timezone)
+		if err != nil {
+			log.Fatal().Err(err).Msg("invalid stats timezone")
+		}
+		statsJobs := scheduler.New(jobRuns, statsTZ)
+		statsJobs.Add(scheduler.Job{
+			Name:     "stats_rollup",
+			Schedule: statsSchedule,
+			Jitter:   time.Duration(cfg.TTL.PurgeJitterSeconds) * time.Second,
+			Run: func(ctx context.Context) {
+				svc.RollupStats(ctx, max(cfg.Stats.LookbackDays, 1), statsTZ)
+			},
+		})
+		go statsJobs.Run(ctx)
+		log.Info().Str("schedule", cfg.Stats.Schedule).Str("timezone", statsTZ.String()).Msg("stats rollup job scheduled")
+	}
+
+	// ── Singleton Jobs (run by the elected leader only) ──────────────────────
+	leader := scheduler.NewLeader(jobRuns, "leader", time.Duration(cfg.Leader.CheckSeconds)*time.Second)
+	if cfg.TTL.ArchiveHotLimit > 0 {
+		leader.Add(scheduler.Task{
+			Name: "archive",
+			Run: scheduler.Every(time.Duration(max(cfg.TTL.ArchiveIntervalMinutes, 1))*time.Minute, func(ctx context.Context) {
+				svc.ArchiveOverflow(ctx, cfg.TTL.ArchiveHotLimit, cfg.TTL.ArchiveBatchSize)
+			}),
+		})
+	}
+	if cfg.TTL.MailboxMax > 0 {
+		leader.Add(scheduler.Task{
+			Name: "mailbox_cap",
+			Run: scheduler.Every(time.Duration(max(cfg.TTL.MailboxIntervalMinutes, 1))*time.Minute, func(ctx context.Context) {
+				svc.EvictOverCap(ctx, cfg.TTL.MailboxMax, cfg.TTL.MailboxBatchSize)
+			}),
+		})
+	}
+	leader.Add(scheduler.Task{
+		Name: "expiry_sweep",
+		Run:  scheduler.Every(time.Duration(max(cfg.TTL.ExpirySweepMinutes, 1))*time.Minute, svc.PurgeExpired),
+	})
+	leader.Add(scheduler.Task{Name: "rollout_release", Run: scheduler.Every(time.Minute, svc.ReleaseDueRollouts)})
+	leader.Add(scheduler.Task{Name: "maintenance_flush", Run: scheduler.Every(time.Minute, svc.FlushMaintenanceWindows)})
+	leader.Add(scheduler.Task{
+		Name: "quota_release",
+		Run:  scheduler.Every(time.Duration(max(cfg.Quota.ReleaseIntervalSeconds, 1))*time.Second, svc.ReleaseQuotaHeld),
+	})
+	counterReconcile := application.CounterReconcileConfig{
+		Interval:  time.Duration(max(cfg.Counters.ReconcileIntervalSeconds, 1)) * time.Second,
+		BatchSize: max(cfg.Counters.ReconcileBatchSize, 1),
+	}
+	if cfg.Redis.Addr != "" {
+		// One replica is enough to reconcile a shared cache.
+		leader.Add(scheduler.Task{
+			Name: "counter_reconcile",
+			Run:  func(ctx context.Context) { svc.RunCounterReconciler(ctx, counterReconcile) },
+		})
+	} else {
+		// Each replica reconciles its own in-memory cache.
+		go svc.RunCounterReconciler(ctx, counterReconcile)
+	}
+	go leader.Run(ctx)
+
+	// ── Snooze Wake-up Scheduler (SKIP LOCKED, runs on every replica) ────────
+	go svc.RunSnoozeWaker(ctx, application.SnoozeConfig{
+		Interval:  time.Duration(max(cfg.Snooze.WakeIntervalSeconds, 1)) * time.Second,
+		BatchSize: max(cfg.Snooze.BatchSize, 1),
+	})
+
+	// ── Escalation Scheduler (SKIP LOCKED, runs on every replica) ────────────
+	go svc.RunEscalations(ctx, application.EscalationConfig{
+		Interval:  time.Duration(max(cfg.Escalation.IntervalSeconds, 1)) * time.Second,
+		BatchSize: max(cfg.Escalation.BatchSize, 1),
+	})
 
 	// ── Start HTTP Server ─────────────────────────────────────────────────────
 	go func() {
@@ -144,12 +657,157 @@ func main() {
 	<-ctx.Done()
 	log.Info().Msg("shutting down gracefully...")
 
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	// Drain: fail readiness and announce to integrators before dropping connections.
+	handler.SetDraining()
+	instance, _ := os.Hostname()
+	drain := time.Duration(cfg.Server.DrainSeconds) * time.Second
+	announceCtx, cancelAnnounce := context.WithTimeout(context.Background(), 2*time.Second)
+	if err := producer.PublishLifecycle(announceCtx, kafkaconsumer.LifecycleDraining, kafkaconsumer.LifecycleEvent{
+		Instance:     instance,
+		Region:       cfg.Server.Region,
+		DrainSeconds: cfg.Server.DrainSeconds,
+		At:           time.Now(),
+	}); err != nil {
+		log.Warn().Err(err).Msg("failed to publish service.draining event")
+	}
+	cancelAnnounce()
+	time.Sleep(drain)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Server.ShutdownTimeoutSeconds)*time.Second)
 	defer cancel()
 
+	// Polling stopped with ctx; let the in-flight batch finish and commit while
+	// SSE streams are still open so its notifications reach connected users.
+	if err := consumer.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("kafka consumer did not stop in time")
+	} else {
+		log.Info().Msg("kafka consumer stopped, offsets committed")
+	}
+	log.Info().Int("closed", hub.Shutdown()).Msg("SSE streams closed")
+
 	if err := router.Shutdown(shutdownCtx); err != nil {
 		log.Error().Err(err).Msg("HTTP server shutdown error")
 	}
 
 	log.Info().Msg("arda-notification stopped")
 }
+
+// setupEventRouting configures how Kafka events reach their handlers (error
+// budget, header metadata, schema validation) and returns the decoder of
+// non-JSON topics, or nil when every topic is JSON.
+func setupEventRouting(cfg *config.Config) kafkaconsumer.RecordDecoder {
+	registry.SetErrorBudget(cfg.Kafka.HandlerErrorBudget, time.Duration(cfg.Kafka.HandlerWindowMinutes)*time.Minute)
+	registry.SetMetadataHeaders(cfg.Kafka.MetadataHeaders)
+	if cfg.Kafka.SchemaValidation {
+		if err := registry.SetSchemaCompiler(func(schema []byte) (registry.Validator, error) {
+			return opa.CompileSchema(schema)
+		}); err != nil {
+			log.Fatal().Err(err).Msg("failed to compile event schemas")
+		}
+	}
+	if cfg.Kafka.TopicFormats == "" {
+		return nil
+	}
+	serdeCfg := serde.Config{Formats: cfg.Kafka.TopicFormats, DescriptorSet: cfg.Kafka.ProtoDescriptorSet}
+	if cfg.Kafka.SchemaRegistryURL != "" {
+		serdeCfg.Registry = serde.NewSchemaRegistry(cfg.Kafka.SchemaRegistryURL,
+			cfg.Kafka.SchemaRegistryUsername, cfg.Kafka.SchemaRegistryPassword, 10*time.Second)
+	}
+	decoder, err := serde.New(serdeCfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid KAFKA_TOPIC_FORMATS")
+	}
+	return decoder
+}
+
+// poolConfig returns the pgxpool config of dsn with the pool settings of db; unset
+// settings keep the pgxpool defaults.
+func poolConfig(db config.DatabaseConfig, dsn string) (*pgxpool.Config, error) {
+	poolCfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if db.MaxConns > 0 {
+		poolCfg.MaxConns = db.MaxConns
+	}
+	if db.MinConns > 0 {
+		poolCfg.MinConns = db.MinConns
+	}
+	if db.MaxConnLifetimeSeconds > 0 {
+		poolCfg.MaxConnLifetime = time.Duration(db.MaxConnLifetimeSeconds) * time.Second
+	}
+	if db.MaxConnIdleSeconds > 0 {
+		poolCfg.MaxConnIdleTime = time.Duration(db.MaxConnIdleSeconds) * time.Second
+	}
+	if db.HealthCheckPeriodSeconds > 0 {
+		poolCfg.HealthCheckPeriod = time.Duration(db.HealthCheckPeriodSeconds) * time.Second
+	}
+	if db.RLS {
+		postgres.EnableTenantScope(poolCfg)
+	}
+	if poolCfg.MinConns > poolCfg.MaxConns {
+		return nil, fmt.Errorf("DB_MIN_CONNS (%d) exceeds DB_MAX_CONNS (%d)", poolCfg.MinConns, poolCfg.MaxConns)
+	}
+	return poolCfg, nil
+}
+
+// httpSecurity returns the auth mode and the CORS, security header and rate
+// limit settings of cfg.
+func httpSecurity(cfg *config.Config) (transporthttp.SecurityConfig, error) {
+	var trusted bool
+	switch cfg.Auth.Mode {
+	case "", "jwt":
+	case "headers":
+		trusted = true
+	default:
+		return transporthttp.SecurityConfig{}, fmt.Errorf("AUTH_MODE %q: want jwt or headers", cfg.Auth.Mode)
+	}
+	proxies, err := mw.ParseTrustedProxies(cfg.Auth.TrustedProxies)
+	if err != nil {
+		return transporthttp.SecurityConfig{}, fmt.Errorf("AUTH_TRUSTED_PROXIES: %w", err)
+	}
+	return transporthttp.SecurityConfig{
+		TrustedHeaders:    trusted,
+		TrustedProxies:    proxies,
+		AdminRole:         cfg.Auth.AdminRole,
+		AuditorRole:       cfg.Auth.AuditorRole,
+		PlatformAdminRole: cfg.Auth.PlatformAdminRole,
+		CORS:              cfg.HTTP.CORSEnabled,
+		CORSOrigins:       cfg.HTTP.CORSOrigins,
+		SecureHeaders:     cfg.HTTP.SecureHeaders,
+		HSTSMaxAge:        cfg.HTTP.HSTSMaxAge,
+		RatePerSecond:     cfg.HTTP.RatePerSecond,
+		RateBurst:         cfg.HTTP.RateBurst,
+	}, nil
+}
+
+// serviceAccounts returns how client-credentials tokens of other services are
+// verified: against the JWKS of the configured Keycloak realm.
+func serviceAccounts(cfg *config.Config) mw.ServiceAccountConfig {
+	realmURL := strings.TrimRight(cfg.Keycloak.BaseURL, "/") + "/realms/" + cfg.Service.Realm
+	issuer := cfg.Service.Issuer
+	switch issuer {
+	case "":
+		issuer = realmURL
+	case "-":
+		issuer = ""
+	}
+	return mw.ServiceAccountConfig{
+		JWKSURL:  realmURL + "/protocol/openid-connect/certs",
+		Issuer:   issuer,
+		Audience: cfg.Service.Audience,
+		Clients:  cfg.Service.Clients,
+	}
+}
+
+// rateLimit returns the fan-out rate limits of cfg.
+func rateLimit(cfg *config.Config) application.RateLimitConfig {
+	return application.RateLimitConfig{
+		TenantRate:  cfg.RateLimit.TenantPerSecond,
+		TenantBurst: cfg.RateLimit.TenantBurst,
+		TopicRate:   cfg.RateLimit.TopicPerSecond,
+		TopicBurst:  cfg.RateLimit.TopicBurst,
+		Policy:      application.RateLimitPolicy(cfg.RateLimit.Policy),
+		MaxWait:     time.Duration(cfg.RateLimit.MaxWaitMS) * time.Millisecond,
+	}
+}
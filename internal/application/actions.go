@@ -0,0 +1,82 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// EventNotificationAction is pushed to the user's other streams when an action is chosen.
+const EventNotificationAction = "notification_action"
+
+// SetActionPublisher enables emitting chosen command actions to the originating
+// service. Without it, command actions are only recorded.
+func (s *Service) SetActionPublisher(p domain.ActionPublisher) {
+	s.actionPub = p
+}
+
+// TakeAction records the action button actionID chosen by the user on a notification.
+// The notification is marked read, and command actions are published when a
+// publisher is configured. A user chooses at most one action per notification.
+func (s *Service) TakeAction(ctx context.Context, idStr, tenantKey, userID, actionID string, input ActionInput) (*domain.ActionRecord, error) {
+	id, err := domain.ParseID(idStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notification id: %w", err)
+	}
+
+	n, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("notification not found: %w", err)
+	}
+	if n.TenantKey != tenantKey || n.UserID != userID {
+		return nil, fmt.Errorf("notification does not belong to user")
+	}
+	action, ok := n.FindAction(actionID)
+	if !ok {
+		return nil, fmt.Errorf("notification has no action %q", actionID)
+	}
+	kind := action.Kind()
+	switch kind {
+	case domain.ActionLink, domain.ActionHTTP, domain.ActionCommand:
+	default:
+		return nil, fmt.Errorf("unsupported action type %q", kind)
+	}
+
+	saved, err := s.actionRepo.Create(ctx, domain.ActionRecord{
+		NotificationID: id,
+		TenantKey:      tenantKey,
+		UserID:         userID,
+		ActionID:       actionID,
+		Type:           kind,
+		Command:        action.Command,
+		Payload:        action.Payload,
+		Comment:        input.Comment,
+		SourceEventID:  n.SourceEventID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("save action: %w", err)
+	}
+	if saved == nil {
+		return nil, fmt.Errorf("an action was already chosen on this notification")
+	}
+
+	if err := s.repo.MarkRead(ctx, id, tenantKey, userID); err == nil {
+		s.adjustUnread(ctx, readDelta(tenantKey, userID, 1))
+		go s.pushUnreadCount(tenantKey, userID)
+	}
+	go s.hub.BroadcastEventExcept(tenantKey, userID, originClient(ctx), EventNotificationAction,
+		map[string]any{"ids": []string{domain.FormatID(id)}, "action": actionID})
+
+	if kind == domain.ActionCommand && s.actionPub != nil {
+		go func(r domain.ActionRecord) {
+			if err := s.actionPub.PublishAction(context.Background(), r); err != nil {
+				log.Error().Err(err).Str("id", r.ID.String()).Msg("failed to publish notification action")
+			}
+		}(*saved)
+	}
+
+	log.Info().Str("id", id.String()).Str("action", actionID).Str("type", string(kind)).Msg("notification action chosen")
+	return saved, nil
+}
@@ -0,0 +1,147 @@
+package application
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// TenantReclaimer is implemented by collaborators that hold per-tenant in-memory
+// state (IAM caches, hub metrics, compiled policies). ReclaimTenants drops the state
+// of tenants for which isIdle returns true and reports how many tenants it released.
+type TenantReclaimer interface {
+	ReclaimTenants(isIdle func(tenantKey string) bool) int
+}
+
+// ActivityConfig tunes RunTenantActivity.
+type ActivityConfig struct {
+	// FlushInterval is how often recorded activity is written to the repository.
+	FlushInterval time.Duration
+	// ReclaimInterval is how often idle tenants' in-memory state is dropped.
+	ReclaimInterval time.Duration
+}
+
+// tenantActivity buffers activity in memory between flushes.
+type tenantActivity struct {
+	repo      domain.TenantActivityRepository
+	idleAfter time.Duration
+
+	mu      sync.Mutex
+	pending map[string]time.Time
+}
+
+// SetTenantActivity enables idle-tenant tracking. Tenants without activity for
+// idleAfter are skipped by compaction and have their cached state reclaimed.
+func (s *Service) SetTenantActivity(repo domain.TenantActivityRepository, idleAfter time.Duration) {
+	if repo == nil || idleAfter <= 0 {
+		s.activity = nil
+		return
+	}
+	s.activity = &tenantActivity{repo: repo, idleAfter: idleAfter, pending: make(map[string]time.Time)}
+}
+
+// TouchTenant records user-facing activity for a tenant. Cheap: only updates memory.
+func (s *Service) TouchTenant(tenantKey string) {
+	if s.activity == nil || tenantKey == "" {
+		return
+	}
+	s.activity.mu.Lock()
+	s.activity.pending[tenantKey] = s.clock.Now()
+	s.activity.mu.Unlock()
+}
+
+// RunTenantActivity flushes recorded activity and reclaims idle tenants until ctx
+// is cancelled, then flushes once more.
+func (s *Service) RunTenantActivity(ctx context.Context, cfg ActivityConfig) {
+	if s.activity == nil {
+		return
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = time.Minute
+	}
+	if cfg.ReclaimInterval <= 0 {
+		cfg.ReclaimInterval = time.Hour
+	}
+
+	flush := time.NewTicker(cfg.FlushInterval)
+	defer flush.Stop()
+	reclaim := time.NewTicker(cfg.ReclaimInterval)
+	defer reclaim.Stop()
+
+	for {
+		select {
+		case <-flush.C:
+			s.flushActivity(ctx)
+		case <-reclaim.C:
+			s.ReclaimIdleTenants(ctx)
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			s.flushActivity(flushCtx)
+			cancel()
+			return
+		}
+	}
+}
+
+func (s *Service) flushActivity(ctx context.Context) {
+	s.activity.mu.Lock()
+	pending := s.activity.pending
+	s.activity.pending = make(map[string]time.Time, len(pending))
+	s.activity.mu.Unlock()
+
+	if err := s.activity.repo.Touch(ctx, pending); err != nil {
+		log.Warn().Err(err).Int("tenants", len(pending)).Msg("failed to flush tenant activity")
+		// Keep the entries for the next flush unless newer activity replaced them.
+		s.activity.mu.Lock()
+		for tk, at := range pending {
+			if _, ok := s.activity.pending[tk]; !ok {
+				s.activity.pending[tk] = at
+			}
+		}
+		s.activity.mu.Unlock()
+	}
+}
+
+// activeTenants returns the tenants active within idleAfter, across instances.
+// A nil map means tracking is disabled or unavailable; callers treat every tenant as active.
+func (s *Service) activeTenants(ctx context.Context) map[string]bool {
+	if s.activity == nil {
+		return nil
+	}
+	tenants, err := s.activity.repo.ActiveSince(ctx, s.clock.Now().Add(-s.activity.idleAfter))
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to load tenant activity, treating all tenants as active")
+		return nil
+	}
+	active := make(map[string]bool, len(tenants))
+	for _, tk := range tenants {
+		active[tk] = true
+	}
+	s.activity.mu.Lock()
+	for tk := range s.activity.pending {
+		active[tk] = true
+	}
+	s.activity.mu.Unlock()
+	return active
+}
+
+// ReclaimIdleTenants drops in-memory state held for idle tenants by the IAM resolver,
+// the SSE hub and the policy evaluator.
+func (s *Service) ReclaimIdleTenants(ctx context.Context) {
+	active := s.activeTenants(ctx)
+	if active == nil {
+		return
+	}
+	isIdle := func(tenantKey string) bool { return !active[tenantKey] }
+
+	reclaimed := 0
+	for _, c := range []any{s.resolver, s.hub, s.policyEval} {
+		if r, ok := c.(TenantReclaimer); ok {
+			reclaimed += r.ReclaimTenants(isIdle)
+		}
+	}
+	log.Info().Int("active_tenants", len(active)).Int("reclaimed", reclaimed).Msg("idle tenant state reclaimed")
+}
@@ -0,0 +1,51 @@
+package application
+
+import (
+	"context"
+
+	"vn.io.arda/notification/internal/domain"
+)
+
+// Background job names used in alert keys ("job.<name>").
+const (
+	jobPurgeTTL         = "purge_ttl"
+	jobPurgeExpired     = "purge_expired"
+	jobArchive          = "archive"
+	jobCompaction       = "compaction"
+	jobRolloutRelease   = "rollout_release"
+	jobOutboxDispatch   = "outbox_dispatch"
+	jobWebhookDispatch  = "webhook_dispatch"
+	jobSnoozeWake       = "snooze_wake"
+	jobPurgeAudit       = "purge_audit"
+	jobPartitions       = "partitions"
+	jobCounterReconcile = "counter_reconcile"
+	jobMailboxCap       = "mailbox_cap"
+	jobEscalation       = "escalation"
+	jobMaintenanceFlush = "maintenance_flush"
+	jobStatsRollup      = "stats_rollup"
+	jobQuotaRelease     = "quota_release"
+)
+
+// SetAlerter reports background job failures to operators. Without it failures are only logged.
+func (s *Service) SetAlerter(a domain.Alerter) {
+	if a == nil {
+		a = noopAlerter{}
+	}
+	s.alerter = a
+}
+
+// jobFailed raises a critical alert for a failed background job run.
+func (s *Service) jobFailed(ctx context.Context, job string, err error) {
+	s.alerter.Alert(ctx, domain.Alert{
+		Key:      "job." + job,
+		Severity: domain.AlertCritical,
+		Source:   "job." + job,
+		Summary:  "background job " + job + " failed",
+		Details:  map[string]any{"error": err.Error()},
+	})
+}
+
+// jobSucceeded resolves a previous failure alert of job, if any.
+func (s *Service) jobSucceeded(ctx context.Context, job string) {
+	s.alerter.Alert(ctx, domain.Alert{Key: "job." + job, Source: "job." + job, Resolved: true})
+}
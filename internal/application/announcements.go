@@ -0,0 +1,171 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// SetAnnouncements enables tenant and platform banners.
+func (s *Service) SetAnnouncements(repo domain.AnnouncementRepository) {
+	s.announcements = repo
+}
+
+func (s *Service) requireAnnouncements() error {
+	if s.announcements == nil {
+		return fmt.Errorf("announcements not configured")
+	}
+	return nil
+}
+
+// ListAnnouncements returns the banners a user should see now: active, of the
+// user's tenant or the whole platform, and not dismissed.
+func (s *Service) ListAnnouncements(ctx context.Context, tenantKey, userID string) ([]domain.Announcement, error) {
+	if err := s.requireAnnouncements(); err != nil {
+		return nil, err
+	}
+	return s.announcements.ListActive(ctx, tenantKey, userID, s.clock.Now())
+}
+
+// DismissAnnouncement hides a banner for a user and tells the user's other
+// devices to hide it too.
+func (s *Service) DismissAnnouncement(ctx context.Context, tenantKey, userID, idStr string) error {
+	if err := s.requireAnnouncements(); err != nil {
+		return err
+	}
+	a, err := s.announcement(ctx, idStr)
+	if err != nil {
+		return err
+	}
+	if a.TenantKey != "" && a.TenantKey != tenantKey {
+		return fmt.Errorf("announcement not found")
+	}
+	if err := s.announcements.Dismiss(ctx, a.ID, tenantKey, userID); err != nil {
+		return err
+	}
+	s.hub.BroadcastEventExcept(tenantKey, userID, originClient(ctx), EventAnnouncementRemoved, map[string]any{"id": a.ID})
+	return nil
+}
+
+// --- Announcement admin ---
+
+// ListAllAnnouncements returns the announcements affecting tenantKey (its own
+// and platform-wide ones), or every announcement when tenantKey is empty,
+// including scheduled and expired ones.
+func (s *Service) ListAllAnnouncements(ctx context.Context, tenantKey string) ([]domain.Announcement, error) {
+	if err := s.requireAnnouncements(); err != nil {
+		return nil, err
+	}
+	return s.announcements.List(ctx, tenantKey)
+}
+
+// GetAnnouncement returns a banner.
+func (s *Service) GetAnnouncement(ctx context.Context, idStr string) (*domain.Announcement, error) {
+	if err := s.requireAnnouncements(); err != nil {
+		return nil, err
+	}
+	return s.announcement(ctx, idStr)
+}
+
+// CreateAnnouncement stores a banner and pushes it to the connected users of its
+// scope. A zero StartsAt starts it now; clients hold back a banner pushed ahead
+// of its StartsAt.
+func (s *Service) CreateAnnouncement(ctx context.Context, a domain.Announcement) (*domain.Announcement, error) {
+	if err := s.requireAnnouncements(); err != nil {
+		return nil, err
+	}
+	if a.StartsAt.IsZero() {
+		a.StartsAt = s.clock.Now()
+	}
+	if err := validateAnnouncement(a); err != nil {
+		return nil, err
+	}
+	saved, err := s.announcements.Create(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+	s.pushAnnouncement(saved)
+	return saved, nil
+}
+
+// UpdateAnnouncement changes the text, severity or schedule of a banner and
+// pushes the new version; clients replace the banner with the same id.
+func (s *Service) UpdateAnnouncement(ctx context.Context, a domain.Announcement) (*domain.Announcement, error) {
+	if err := s.requireAnnouncements(); err != nil {
+		return nil, err
+	}
+	current, err := s.announcements.Get(ctx, a.ID)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, fmt.Errorf("announcement not found")
+	}
+	if a.StartsAt.IsZero() {
+		a.StartsAt = current.StartsAt
+	}
+	if err := validateAnnouncement(a); err != nil {
+		return nil, err
+	}
+	saved, err := s.announcements.Update(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+	s.pushAnnouncement(saved)
+	return saved, nil
+}
+
+// DeleteAnnouncement removes a banner and withdraws it from connected clients.
+func (s *Service) DeleteAnnouncement(ctx context.Context, idStr string) error {
+	if err := s.requireAnnouncements(); err != nil {
+		return err
+	}
+	a, err := s.announcement(ctx, idStr)
+	if err != nil {
+		return err
+	}
+	if err := s.announcements.Delete(ctx, a.ID); err != nil {
+		return err
+	}
+	s.hub.BroadcastScopeEvent(a.TenantKey, EventAnnouncementRemoved, map[string]any{"id": a.ID})
+	return nil
+}
+
+// pushAnnouncement sends a banner to its scope unless it has already ended.
+func (s *Service) pushAnnouncement(a *domain.Announcement) {
+	if a.EndsAt != nil && !s.clock.Now().Before(*a.EndsAt) {
+		s.hub.BroadcastScopeEvent(a.TenantKey, EventAnnouncementRemoved, map[string]any{"id": a.ID})
+		return
+	}
+	s.hub.BroadcastScopeEvent(a.TenantKey, EventAnnouncement, a)
+}
+
+func (s *Service) announcement(ctx context.Context, idStr string) (*domain.Announcement, error) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid announcement id: %w", err)
+	}
+	a, err := s.announcements.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if a == nil {
+		return nil, fmt.Errorf("announcement not found")
+	}
+	return a, nil
+}
+
+func validateAnnouncement(a domain.Announcement) error {
+	switch {
+	case strings.TrimSpace(a.Title) == "":
+		return fmt.Errorf("title is required")
+	case !a.Severity.Valid():
+		return fmt.Errorf("unknown severity %q: use info, warning or critical", a.Severity)
+	case a.EndsAt != nil && !a.EndsAt.After(a.StartsAt):
+		return fmt.Errorf("ends_at must be after starts_at")
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"vn.io.arda/notification/internal/domain"
+	"vn.io.arda/notification/internal/testsupport"
+)
+
+func TestAnnouncements(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	hub := testsupport.NewHub()
+	s := NewService(testsupport.NewRepository(), hub, nil,
+		WithAnnouncements(testsupport.NewAnnouncements()), WithClock(domain.NewManualClock(now)))
+
+	platform, err := s.CreateAnnouncement(ctx, domain.Announcement{Title: "maintenance tonight", Severity: domain.SeverityWarning})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !platform.StartsAt.Equal(now) {
+		t.Fatalf("starts_at = %v, want now", platform.StartsAt)
+	}
+	if events := hub.Events(); len(events) != 1 || events[0].Name != EventAnnouncement || events[0].TenantKey != "" {
+		t.Fatalf("events = %+v, want one platform announcement event", events)
+	}
+	globex, err := s.CreateAnnouncement(ctx, domain.Announcement{TenantKey: "globex", Title: "new billing", Severity: domain.SeverityInfo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	later := now.Add(time.Hour)
+	if _, err := s.CreateAnnouncement(ctx, domain.Announcement{Title: "scheduled", Severity: domain.SeverityInfo, StartsAt: later}); err != nil {
+		t.Fatal(err)
+	}
+
+	visible := func(tenantKey, userID string) int {
+		t.Helper()
+		as, err := s.ListAnnouncements(ctx, tenantKey, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(as)
+	}
+	if n := visible("acme", "u1"); n != 1 {
+		t.Fatalf("acme sees %d announcements, want the platform one", n)
+	}
+	if n := visible("globex", "v1"); n != 2 {
+		t.Fatalf("globex sees %d announcements, want 2", n)
+	}
+
+	if err := s.DismissAnnouncement(ctx, "acme", "u1", globex.ID.String()); err == nil {
+		t.Fatal("dismissed another tenant's announcement")
+	}
+	if err := s.DismissAnnouncement(ctx, "acme", "u1", platform.ID.String()); err != nil {
+		t.Fatal(err)
+	}
+	if visible("acme", "u1") != 0 || visible("acme", "u2") != 1 {
+		t.Fatal("dismissal must hide the banner for that user only")
+	}
+
+	if _, err := s.CreateAnnouncement(ctx, domain.Announcement{Title: "x", Severity: domain.SeverityInfo, EndsAt: &now}); err == nil {
+		t.Fatal("accepted ends_at before starts_at")
+	}
+	if _, err := s.CreateAnnouncement(ctx, domain.Announcement{Title: "x", Severity: "loud"}); err == nil {
+		t.Fatal("accepted an unknown severity")
+	}
+
+	hub.Reset()
+	if err := s.DeleteAnnouncement(ctx, globex.ID.String()); err != nil {
+		t.Fatal(err)
+	}
+	if events := hub.Events(); len(events) != 1 || events[0].Name != EventAnnouncementRemoved || events[0].TenantKey != "globex" {
+		t.Fatalf("events = %+v, want one removal for globex", events)
+	}
+}
@@ -0,0 +1,97 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// maxAuditPage caps the page size of the audit log API.
+const maxAuditPage = 500
+
+// SetAudit enables the notification audit log. Created, read, deleted and
+// purged entries are written by the database either way; without it delivery
+// is not recorded and the audit API is unavailable.
+func (s *Service) SetAudit(repo domain.AuditRepository) {
+	s.auditRepo = repo
+}
+
+// audit records entries. Best-effort: failures are logged and never affect delivery.
+func (s *Service) audit(ctx context.Context, entries ...domain.AuditEntry) {
+	if s.auditRepo == nil || len(entries) == 0 {
+		return
+	}
+	if err := s.auditRepo.Record(ctx, entries); err != nil {
+		log.Warn().Err(err).Int("entries", len(entries)).Msg("failed to record notification audit")
+	}
+}
+
+// deliveredEntry is the audit entry of n reaching its user over channel.
+func deliveredEntry(n *domain.Notification, channel domain.Channel) domain.AuditEntry {
+	return domain.AuditEntry{
+		NotificationID: n.ID,
+		TenantKey:      n.TenantKey,
+		UserID:         n.UserID,
+		Action:         domain.AuditDelivered,
+		Actor:          domain.AuditActorSystem,
+		Channel:        channel,
+		SourceEventID:  n.SourceEventID,
+	}
+}
+
+// EventCreated reports whether the audit log records notifications created in
+// tenantKey from sourceEventID, even if they were deleted since. Always false
+// without an audit log.
+func (s *Service) EventCreated(ctx context.Context, tenantKey, sourceEventID string) (bool, error) {
+	if s.auditRepo == nil || sourceEventID == "" {
+		return false, nil
+	}
+	entries, err := s.auditRepo.List(ctx, domain.AuditFilter{
+		TenantKey:     tenantKey,
+		SourceEventID: sourceEventID,
+		Action:        domain.AuditCreated,
+		Limit:         1,
+	})
+	if err != nil {
+		return false, err
+	}
+	return len(entries) > 0, nil
+}
+
+// ListAudit returns the audit entries matching f, newest first. requester is
+// logged, as the audit log serves compliance requests.
+func (s *Service) ListAudit(ctx context.Context, f domain.AuditFilter, requester string) ([]domain.AuditEntry, error) {
+	if s.auditRepo == nil {
+		return nil, fmt.Errorf("notification audit not configured")
+	}
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	if f.Limit <= 0 || f.Limit > maxAuditPage {
+		f.Limit = maxAuditPage
+	}
+	log.Info().
+		Str("tenant", f.TenantKey).
+		Str("user", f.UserID).
+		Str("requester", requester).
+		Msg("notification audit query")
+	return s.auditRepo.List(ctx, f)
+}
+
+// PurgeAudit deletes audit entries older than days; 0 keeps them forever.
+// Called by a background scheduler.
+func (s *Service) PurgeAudit(ctx context.Context, days int) {
+	if s.auditRepo == nil || days <= 0 {
+		return
+	}
+	count, err := s.auditRepo.PurgeBefore(ctx, s.clock.Now().AddDate(0, 0, -days))
+	if err != nil {
+		log.Error().Err(err).Msg("notification audit purge failed")
+		s.jobFailed(ctx, jobPurgeAudit, err)
+		return
+	}
+	s.jobSucceeded(ctx, jobPurgeAudit)
+	log.Info().Int64("deleted", count).Int("older_than_days", days).Msg("notification audit purge completed")
+}
@@ -0,0 +1,101 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// SetFanoutStrategy selects fan-out on write (default) or on read for TENANT and
+// PLATFORM scopes. Other scopes always fan out on write.
+func (s *Service) SetFanoutStrategy(scope domain.TargetScope, strategy domain.FanoutStrategy) {
+	if scope != domain.ScopeTenant && scope != domain.ScopePlatform {
+		return
+	}
+	if s.strategies == nil {
+		s.strategies = make(map[domain.TargetScope]domain.FanoutStrategy)
+	}
+	s.strategies[scope] = strategy
+}
+
+// fanoutOnRead reports whether input is stored once as a broadcast. Staged
+// rollouts and composite targets need per-user rows, so they always fan out on write.
+func (s *Service) fanoutOnRead(input domain.FanoutInput) bool {
+	return s.strategies[input.TargetScope] == domain.FanoutOnRead && input.Rollout == nil && !input.IsComposite()
+}
+
+// broadcast stores a TENANT/PLATFORM notification once and pushes it to connected
+// clients in scope. Recipients, read state and in-app mutes are resolved at query time.
+// Email is not sent for broadcasts.
+func (s *Service) broadcast(ctx context.Context, input domain.FanoutInput) error {
+	title, body := s.storedText(ctx, input)
+	bi := domain.BroadcastInput{
+		Type:          input.Type,
+		Category:      input.Category,
+		Priority:      input.Priority,
+		Title:         title,
+		Body:          body,
+		Metadata:      input.Metadata,
+		SourceEventID: input.SourceEventID,
+	}
+	if input.TargetScope == domain.ScopeTenant {
+		bi.TenantKey = input.TenantKey
+
+		// Platform-wide broadcasts have no single tenant, so only tenant broadcasts
+		// are subject to delivery policies.
+		if s.policyRepo != nil && s.policyEval != nil {
+			decision, evaluated, err := s.evaluatePolicy(ctx, input, input.TenantKey, 0)
+			switch {
+			case err != nil:
+				log.Error().Err(err).Str("tenant", input.TenantKey).Msg("delivery policy evaluation failed")
+				if s.policyFailClosed {
+					return nil
+				}
+			case evaluated && !decision.Allow:
+				log.Info().Str("tenant", input.TenantKey).Str("reason", decision.Reason).
+					Str("source_event_id", input.SourceEventID).Msg("broadcast denied by delivery policy")
+				return nil
+			case evaluated && decision.Channels != nil:
+				bi.Metadata = domain.WithChannels(input.Metadata, decision.Channels)
+			}
+		}
+	}
+
+	n, err := s.repo.CreateBroadcast(ctx, bi)
+	if err != nil {
+		s.Trace(ctx, input.SourceEventID, domain.TraceFailed, map[string]any{"stage": "broadcast_insert", "error": err.Error()})
+		return fmt.Errorf("create broadcast: %w", err)
+	}
+	s.Trace(ctx, input.SourceEventID, domain.TraceBroadcastStored, map[string]any{
+		"tenant":    bi.TenantKey,
+		"duplicate": n == nil,
+	})
+	if n == nil {
+		log.Debug().Str("source_event_id", input.SourceEventID).Msg("duplicate broadcast skipped")
+		return nil
+	}
+
+	// A broadcast counts as unread for every user of the tenant (all tenants when platform-wide).
+	s.invalidateTenantUnread(ctx, bi.TenantKey)
+
+	s.renderNotifications(ctx, "", []*domain.Notification{n})
+	if n.AllowsChannel(domain.ChannelInApp) {
+		go s.hub.BroadcastScope(bi.TenantKey, n)
+	}
+	// A platform-wide broadcast goes to the matching connectors of every tenant.
+	s.forwardToChat(ctx, bi.TenantKey, n)
+	if bi.TenantKey != "" {
+		// Shared row: no user_id. Platform-wide broadcasts belong to no tenant's webhooks.
+		s.emitEvents(ctx, domain.WebhookEventInput{TenantKey: bi.TenantKey,
+			Event: domain.WebhookNotificationCreated, Payload: notificationWebhookPayload(n)})
+	}
+
+	log.Info().
+		Str("scope", string(input.TargetScope)).
+		Str("tenant", bi.TenantKey).
+		Str("id", n.ID.String()).
+		Msg("broadcast notification stored (fan-out on read)")
+	return nil
+}
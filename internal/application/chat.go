@@ -0,0 +1,186 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// chatPostTimeout bounds the posts of one forwarded notification.
+const chatPostTimeout = 30 * time.Second
+
+// Default message formats when a connector has no template.
+var defaultChatTemplates = map[domain.ChatProvider]string{
+	domain.ChatSlack: "*{{title}}*\n{{body}}",
+	domain.ChatTeams: "**{{title}}**\n\n{{body}}",
+}
+
+// SetChatConnectors enables forwarding notifications to tenant Slack / Teams connectors.
+func (s *Service) SetChatConnectors(repo domain.ChatConnectorRepository, poster domain.ChatPoster) {
+	s.chatConnectors, s.chatPoster = repo, poster
+}
+
+// forwardToChat posts n to the connectors of tenantKey subscribed to its type, or
+// to those of every tenant when tenantKey is empty. Posting is best-effort and
+// asynchronous, like email: failures are logged and never retried.
+func (s *Service) forwardToChat(ctx context.Context, tenantKey string, n *domain.Notification) {
+	if s.chatConnectors == nil || !n.AllowsChannel(domain.ChannelChat) {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		ctx, cancel := context.WithTimeout(ctx, chatPostTimeout)
+		defer cancel()
+
+		connectors, err := s.chatConnectors.ListForType(ctx, tenantKey, n.Type)
+		if err != nil {
+			log.Error().Err(err).Str("tenant", tenantKey).Msg("failed to load chat connectors")
+			return
+		}
+		for _, c := range connectors {
+			if err := s.chatPoster.Post(ctx, c, chatMessage(c, n)); err != nil {
+				log.Warn().Err(err).
+					Str("connector", c.ID.String()).
+					Str("tenant", c.TenantKey).
+					Str("provider", string(c.Provider)).
+					Str("source_event_id", n.SourceEventID).
+					Msg("chat connector post failed")
+			}
+		}
+	}()
+}
+
+// chatMessage formats n with the connector's template.
+func chatMessage(c domain.ChatConnector, n *domain.Notification) domain.ChatMessage {
+	tmpl := c.Template
+	if tmpl == "" {
+		tmpl = defaultChatTemplates[c.Provider]
+	}
+	tenantKey := n.TenantKey
+	if tenantKey == "" {
+		tenantKey = c.TenantKey
+	}
+	text := strings.NewReplacer(
+		"{{title}}", n.Title,
+		"{{body}}", n.Body,
+		"{{type}}", string(n.Type),
+		"{{category}}", n.Category,
+		"{{priority}}", string(n.Priority),
+		"{{tenant}}", tenantKey,
+	).Replace(tmpl)
+	return domain.ChatMessage{Title: n.Title, Text: text, Priority: n.Priority}
+}
+
+// --- Chat connector admin ---
+
+func (s *Service) requireChatConnectors() error {
+	if s.chatConnectors == nil {
+		return fmt.Errorf("chat connectors not configured")
+	}
+	return nil
+}
+
+// validateChatConnector checks c; the URL may be empty on update to keep the stored one.
+func (s *Service) validateChatConnector(ctx context.Context, c domain.ChatConnector, requireURL bool) error {
+	if strings.TrimSpace(c.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if !c.Provider.Valid() {
+		return fmt.Errorf("unknown provider %q", c.Provider)
+	}
+	if c.URL != "" || requireURL {
+		u, err := url.Parse(c.URL)
+		if err != nil || u.Host == "" || u.Scheme != "https" {
+			return fmt.Errorf("url must be an https incoming webhook url")
+		}
+	}
+	if len(c.Types) == 0 {
+		return fmt.Errorf("at least one notification type is required")
+	}
+	for _, t := range c.Types {
+		if _, err := s.customType(ctx, c.TenantKey, t); err != nil {
+			return fmt.Errorf("invalid notification type: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListChatConnectors returns a tenant's connectors with masked URLs.
+func (s *Service) ListChatConnectors(ctx context.Context, tenantKey string) ([]domain.ChatConnector, error) {
+	if err := s.requireChatConnectors(); err != nil {
+		return nil, err
+	}
+	return s.chatConnectors.List(ctx, tenantKey)
+}
+
+// CreateChatConnector registers a Slack / Teams connector.
+func (s *Service) CreateChatConnector(ctx context.Context, c domain.ChatConnector) (*domain.ChatConnector, error) {
+	if err := s.requireChatConnectors(); err != nil {
+		return nil, err
+	}
+	if err := s.validateChatConnector(ctx, c, true); err != nil {
+		return nil, err
+	}
+	return s.chatConnectors.Create(ctx, c)
+}
+
+// UpdateChatConnector changes a connector; an empty URL keeps the stored one.
+func (s *Service) UpdateChatConnector(ctx context.Context, c domain.ChatConnector) (*domain.ChatConnector, error) {
+	if err := s.requireChatConnectors(); err != nil {
+		return nil, err
+	}
+	if err := s.validateChatConnector(ctx, c, false); err != nil {
+		return nil, err
+	}
+	saved, err := s.chatConnectors.Update(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	saved.URL = domain.MaskChatURL(saved.URL)
+	return saved, nil
+}
+
+// DeleteChatConnector removes a connector.
+func (s *Service) DeleteChatConnector(ctx context.Context, tenantKey, idStr string) error {
+	if err := s.requireChatConnectors(); err != nil {
+		return err
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return fmt.Errorf("invalid connector id: %w", err)
+	}
+	return s.chatConnectors.Delete(ctx, tenantKey, id)
+}
+
+// TestChatConnector posts a sample message through a connector and returns the
+// provider's error, if any.
+func (s *Service) TestChatConnector(ctx context.Context, tenantKey, idStr string) error {
+	if err := s.requireChatConnectors(); err != nil {
+		return err
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return fmt.Errorf("invalid connector id: %w", err)
+	}
+	c, err := s.chatConnectors.Get(ctx, tenantKey, id)
+	if err != nil {
+		return err
+	}
+	if c == nil {
+		return fmt.Errorf("chat connector not found")
+	}
+	sample := &domain.Notification{
+		TenantKey: tenantKey,
+		Type:      domain.TypeSystem,
+		Priority:  domain.PriorityNormal,
+		Title:     "Arda notification test",
+		Body:      fmt.Sprintf("Connector %q is configured correctly.", c.Name),
+	}
+	return s.chatPoster.Post(ctx, *c, chatMessage(*c, sample))
+}
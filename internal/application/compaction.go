@@ -0,0 +1,72 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/domain"
+	"vn.io.arda/notification/internal/messages"
+)
+
+// Compact collapses runs of old, read, LOW-priority notifications into one summary
+// notification per run. Runs before the TTL purge so history stays browsable.
+func (s *Service) Compact(ctx context.Context, afterDays, minRun int) {
+	cutoff := s.clock.Now().AddDate(0, 0, -afterDays)
+	runs, err := s.repo.FindCompactionRuns(ctx, cutoff, minRun)
+	if err != nil {
+		log.Error().Err(err).Msg("notification compaction failed")
+		s.jobFailed(ctx, jobCompaction, err)
+		return
+	}
+
+	// Dormant tenants are skipped: nobody reads their history.
+	active := s.activeTenants(ctx)
+
+	collapsed, skipped := 0, 0
+	for _, run := range runs {
+		if active != nil && !active[run.TenantKey] {
+			skipped++
+			continue
+		}
+		if err := s.repo.CompactRun(ctx, run, compactionSummary(run, s.clock.Now())); err != nil {
+			log.Warn().Err(err).Str("tenant", run.TenantKey).Str("user", run.UserID).Msg("failed to compact notification run")
+			continue
+		}
+		collapsed += len(run.IDs)
+	}
+
+	log.Info().
+		Int("runs", len(runs)).
+		Int("collapsed", collapsed).
+		Int("skipped_idle", skipped).
+		Int("older_than_days", afterDays).
+		Msg("notification compaction completed")
+	s.jobSucceeded(ctx, jobCompaction)
+}
+
+func compactionSummary(run domain.CompactionRun, at time.Time) domain.CreateNotificationInput {
+	const dateLayout = "02/01/2006"
+	title, body := messages.Compacted(len(run.IDs), run.From.Format(dateLayout), run.To.Format(dateLayout))
+
+	types := make(map[string]int, len(run.TypeCounts))
+	for t, n := range run.TypeCounts {
+		types[string(t)] = n
+	}
+	return domain.CreateNotificationInput{
+		TenantKey: run.TenantKey,
+		UserID:    run.UserID,
+		Type:      domain.TypeSystem,
+		Priority:  domain.PriorityLow,
+		Title:     title,
+		Body:      body,
+		Metadata: map[string]any{
+			"compacted":    true,
+			"compacted_at": at.UTC(),
+			"count":        len(run.IDs),
+			"from":         run.From,
+			"to":           run.To,
+			"types":        types,
+		},
+	}
+}
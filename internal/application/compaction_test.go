@@ -0,0 +1,57 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"vn.io.arda/notification/internal/domain"
+	"vn.io.arda/notification/internal/testsupport"
+)
+
+func TestCompact(t *testing.T) {
+	ctx := context.Background()
+	clock := domain.NewManualClock(time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC))
+	repo := testsupport.NewRepository()
+	repo.SetClock(clock)
+	s := NewService(repo, testsupport.NewHub(), testsupport.NewResolver(), WithClock(clock))
+
+	// Three read LOW notifications, a NORMAL one that breaks the run, then two more LOW.
+	for _, p := range []domain.Priority{domain.PriorityLow, domain.PriorityLow, domain.PriorityLow,
+		domain.PriorityNormal, domain.PriorityLow, domain.PriorityLow} {
+		n, err := s.Create(ctx, domain.CreateNotificationInput{TenantKey: "acme", UserID: "u1", Type: domain.TypeCRM, Priority: p, Title: "deal updated"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.MarkRead(ctx, n.ID.String(), "acme", "u1"); err != nil {
+			t.Fatal(err)
+		}
+		clock.Advance(time.Hour)
+	}
+	// An unread LOW notification is never compacted.
+	if _, err := s.Create(ctx, domain.CreateNotificationInput{TenantKey: "acme", UserID: "u1", Type: domain.TypeCRM, Priority: domain.PriorityLow, Title: "unread"}); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(40 * 24 * time.Hour)
+	s.Compact(ctx, 30, 3)
+
+	var summaries, lows int
+	for _, n := range repo.Notifications() {
+		switch {
+		case n.Metadata["compacted"] == true:
+			summaries++
+			if n.Metadata["count"] != 3 || n.Priority != domain.PriorityLow || n.Type != domain.TypeSystem {
+				t.Errorf("summary = %+v", n)
+			}
+		case n.Priority == domain.PriorityLow:
+			lows++
+		}
+	}
+	if summaries != 1 || lows != 3 {
+		t.Fatalf("%d summaries and %d LOW notifications left, want 1 and 3 (the short run and the unread one)", summaries, lows)
+	}
+	if n, _ := repo.CountUnread(ctx, "acme", "u1"); n != 1 {
+		t.Fatalf("unread = %d after compaction, want 1: the summary is already read", n)
+	}
+}
@@ -0,0 +1,65 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// SetConsumerPauses enables pausing Kafka consumption per topic. Consumers pick
+// the pauses up through ListConsumerPauses.
+func (s *Service) SetConsumerPauses(repo domain.ConsumerPauseRepository) {
+	s.consumerPauses = repo
+}
+
+func (s *Service) requireConsumerPauses() error {
+	if s.consumerPauses == nil {
+		return fmt.Errorf("consumer pauses not configured")
+	}
+	return nil
+}
+
+// ListConsumerPauses returns the paused Kafka topics.
+func (s *Service) ListConsumerPauses(ctx context.Context) ([]domain.ConsumerPause, error) {
+	if err := s.requireConsumerPauses(); err != nil {
+		return nil, err
+	}
+	return s.consumerPauses.List(ctx)
+}
+
+// PauseConsumer pauses consumption of topic on every instance. Pausing a paused
+// topic updates its reason.
+func (s *Service) PauseConsumer(ctx context.Context, topic, reason, actor string) (*domain.ConsumerPause, error) {
+	if err := s.requireConsumerPauses(); err != nil {
+		return nil, err
+	}
+	topic = strings.TrimSpace(topic)
+	if topic == "" || len(topic) > 255 || strings.ContainsAny(topic, " \t\n") {
+		return nil, fmt.Errorf("invalid topic %q", topic)
+	}
+	p, err := s.consumerPauses.Pause(ctx, domain.ConsumerPause{Topic: topic, Reason: reason, PausedBy: actor})
+	if err != nil {
+		return nil, err
+	}
+	log.Warn().Str("topic", topic).Str("reason", reason).Str("actor", actor).Msg("kafka consumption paused")
+	return p, nil
+}
+
+// ResumeConsumer resumes consumption of topic. Resuming a topic that is not
+// paused is a no-op.
+func (s *Service) ResumeConsumer(ctx context.Context, topic, actor string) error {
+	if err := s.requireConsumerPauses(); err != nil {
+		return err
+	}
+	resumed, err := s.consumerPauses.Resume(ctx, topic)
+	if err != nil {
+		return err
+	}
+	if resumed {
+		log.Info().Str("topic", topic).Str("actor", actor).Msg("kafka consumption resumed")
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package application
+
+import (
+	"context"
+	"testing"
+
+	"vn.io.arda/notification/internal/testsupport"
+)
+
+func TestConsumerPauses(t *testing.T) {
+	ctx := context.Background()
+	if _, err := NewService(testsupport.NewRepository(), nil, nil).PauseConsumer(ctx, "crm-events", "", "ops"); err == nil {
+		t.Fatal("paused a topic without a pause store")
+	}
+
+	s := NewService(testsupport.NewRepository(), nil, nil, WithConsumerPauses(testsupport.NewConsumerPauses()))
+	for _, topic := range []string{"", "  ", "crm events"} {
+		if _, err := s.PauseConsumer(ctx, topic, "", "ops"); err == nil {
+			t.Errorf("paused invalid topic %q", topic)
+		}
+	}
+	if _, err := s.PauseConsumer(ctx, " crm-events ", "bad deploy", "ops"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.PauseConsumer(ctx, "crm-events", "schema fix", "oncall"); err != nil {
+		t.Fatal(err)
+	}
+	pauses, err := s.ListConsumerPauses(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pauses) != 1 || pauses[0].Topic != "crm-events" || pauses[0].Reason != "schema fix" {
+		t.Fatalf("pauses = %+v, want crm-events with the updated reason", pauses)
+	}
+
+	if err := s.ResumeConsumer(ctx, "crm-events", "ops"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ResumeConsumer(ctx, "crm-events", "ops"); err != nil {
+		t.Fatalf("resuming a running topic: %v", err)
+	}
+	if pauses, _ := s.ListConsumerPauses(ctx); len(pauses) != 0 {
+		t.Fatalf("%d pauses left after resume", len(pauses))
+	}
+}
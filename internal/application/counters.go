@@ -0,0 +1,287 @@
+package application
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// DefaultCounterTTL is how long a cached unread count lives without being refilled.
+const DefaultCounterTTL = 5 * time.Minute
+
+// CounterReconcileConfig tunes the unread counter reconciliation job.
+type CounterReconcileConfig struct {
+	// Interval is how often cached counts are compared with Postgres.
+	Interval time.Duration
+	// BatchSize is the maximum number of cached counts checked per round.
+	BatchSize int
+}
+
+// SetCounterStore caches unread counts in c. A nil store counts every request
+// in Postgres.
+func (s *Service) SetCounterStore(c domain.CounterStore) {
+	s.counters = c
+}
+
+// unreadCount returns the user's unread count from the counter store, filling
+// it from the repository on a miss. Store errors fall back to the repository.
+func (s *Service) unreadCount(ctx context.Context, tenantKey, userID string) (int64, error) {
+	if s.counters == nil {
+		return s.repo.CountUnread(ctx, tenantKey, userID)
+	}
+	key := domain.CounterKey{TenantKey: tenantKey, UserID: userID}
+	count, ok, err := s.counters.Get(ctx, key)
+	if err != nil {
+		log.Warn().Err(err).Str("user", userID).Msg("unread counter read failed, counting in database")
+		return s.repo.CountUnread(ctx, tenantKey, userID)
+	}
+	if ok {
+		return count, nil
+	}
+	count, err = s.repo.CountUnread(ctx, tenantKey, userID)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.counters.Set(ctx, key, count); err != nil {
+		log.Warn().Err(err).Str("user", userID).Msg("failed to cache unread count")
+	}
+	return count, nil
+}
+
+// adjustUnread applies deltas to the cached counts. Entries that could not be
+// adjusted are dropped so they are recounted.
+func (s *Service) adjustUnread(ctx context.Context, deltas ...domain.CounterDelta) {
+	if s.counters == nil || len(deltas) == 0 {
+		return
+	}
+	if err := s.counters.Add(ctx, deltas); err != nil {
+		log.Warn().Err(err).Int("counters", len(deltas)).Msg("unread counter update failed")
+		keys := make([]domain.CounterKey, len(deltas))
+		for i, d := range deltas {
+			keys[i] = d.CounterKey
+		}
+		s.invalidateUnread(ctx, keys...)
+	}
+}
+
+// invalidateUnread drops the cached counts of users whose unread count changed
+// by an amount the service does not know.
+func (s *Service) invalidateUnread(ctx context.Context, keys ...domain.CounterKey) {
+	if s.counters == nil || len(keys) == 0 {
+		return
+	}
+	if err := s.counters.Invalidate(ctx, keys...); err != nil {
+		log.Error().Err(err).Int("counters", len(keys)).Msg("unread counter invalidation failed")
+	}
+}
+
+// invalidateTenantUnread drops the cached counts of a tenant, or of every tenant
+// when tenantKey is empty (platform-wide broadcasts).
+func (s *Service) invalidateTenantUnread(ctx context.Context, tenantKey string) {
+	if s.counters == nil {
+		return
+	}
+	if err := s.counters.InvalidateTenant(ctx, tenantKey); err != nil {
+		log.Error().Err(err).Str("tenant", tenantKey).Msg("unread counter invalidation failed")
+	}
+}
+
+// insertedDeltas returns one +1 delta per user for the inserted notifications.
+func insertedDeltas(ns []*domain.Notification) []domain.CounterDelta {
+	index := make(map[domain.CounterKey]int, len(ns))
+	var deltas []domain.CounterDelta
+	for _, n := range ns {
+		if n.IsRead {
+			continue
+		}
+		key := domain.CounterKey{TenantKey: n.TenantKey, UserID: n.UserID}
+		if i, ok := index[key]; ok {
+			deltas[i].Delta++
+			continue
+		}
+		index[key] = len(deltas)
+		deltas = append(deltas, domain.CounterDelta{CounterKey: key, Delta: 1})
+	}
+	return deltas
+}
+
+// readDelta is the change to a user's unread count after n notifications were read.
+func readDelta(tenantKey, userID string, n int) domain.CounterDelta {
+	return domain.CounterDelta{CounterKey: domain.CounterKey{TenantKey: tenantKey, UserID: userID}, Delta: -int64(n)}
+}
+
+// RunCounterReconciler compares cached unread counts with Postgres until ctx is
+// cancelled, correcting drift from changes the cache does not track (archiving,
+// expiry, purges, concurrent updates).
+func (s *Service) RunCounterReconciler(ctx context.Context, cfg CounterReconcileConfig) {
+	if s.counters == nil {
+		return
+	}
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.ReconcileCounters(ctx, cfg.BatchSize)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// ReconcileCounters checks up to limit cached unread counts against Postgres and
+// overwrites those that drifted. Returns the number corrected.
+func (s *Service) ReconcileCounters(ctx context.Context, limit int) int {
+	if s.counters == nil {
+		return 0
+	}
+	keys, err := s.counters.Keys(ctx, limit)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Error().Err(err).Msg("failed to list cached unread counters")
+			s.jobFailed(ctx, jobCounterReconcile, err)
+		}
+		return 0
+	}
+
+	corrected := 0
+	for _, key := range keys {
+		count, err := s.repo.CountUnread(ctx, key.TenantKey, key.UserID)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Error().Err(err).Str("user", key.UserID).Msg("unread counter reconciliation failed")
+				s.jobFailed(ctx, jobCounterReconcile, err)
+			}
+			return corrected
+		}
+		cached, ok, err := s.counters.Get(ctx, key)
+		if err != nil || !ok || cached == count {
+			continue
+		}
+		if err := s.counters.Set(ctx, key, count); err != nil {
+			log.Warn().Err(err).Str("user", key.UserID).Msg("failed to correct unread counter")
+			continue
+		}
+		corrected++
+		log.Debug().Str("tenant", key.TenantKey).Str("user", key.UserID).
+			Int64("cached", cached).Int64("actual", count).Msg("unread counter drift corrected")
+	}
+	s.jobSucceeded(ctx, jobCounterReconcile)
+	if corrected > 0 {
+		log.Info().Int("checked", len(keys)).Int("corrected", corrected).Msg("unread counters reconciled")
+	}
+	return corrected
+}
+
+// MemoryCounterStore is an in-process domain.CounterStore, used when no Redis
+// is configured. Each replica has its own cache, so counts changed through
+// another replica are only picked up when the entry expires or is reconciled.
+type MemoryCounterStore struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[domain.CounterKey]memoryCounter
+	pending []domain.CounterKey // keys not yet returned by Keys in this pass
+}
+
+type memoryCounter struct {
+	count   int64
+	expires time.Time
+}
+
+// NewMemoryCounterStore creates a MemoryCounterStore whose entries live for ttl
+// (DefaultCounterTTL when <= 0).
+func NewMemoryCounterStore(ttl time.Duration) *MemoryCounterStore {
+	if ttl <= 0 {
+		ttl = DefaultCounterTTL
+	}
+	return &MemoryCounterStore{ttl: ttl, entries: make(map[domain.CounterKey]memoryCounter)}
+}
+
+// Get implements domain.CounterStore.
+func (m *MemoryCounterStore) Get(_ context.Context, key domain.CounterKey) (int64, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[key]
+	if !ok {
+		return 0, false, nil
+	}
+	if !time.Now().Before(e.expires) {
+		delete(m.entries, key)
+		return 0, false, nil
+	}
+	return e.count, true, nil
+}
+
+// Set implements domain.CounterStore.
+func (m *MemoryCounterStore) Set(_ context.Context, key domain.CounterKey, count int64) error {
+	m.mu.Lock()
+	m.entries[key] = memoryCounter{count: max(count, 0), expires: time.Now().Add(m.ttl)}
+	m.mu.Unlock()
+	return nil
+}
+
+// Add implements domain.CounterStore.
+func (m *MemoryCounterStore) Add(_ context.Context, deltas []domain.CounterDelta) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range deltas {
+		if e, ok := m.entries[d.CounterKey]; ok {
+			e.count = max(e.count+d.Delta, 0)
+			m.entries[d.CounterKey] = e
+		}
+	}
+	return nil
+}
+
+// Invalidate implements domain.CounterStore.
+func (m *MemoryCounterStore) Invalidate(_ context.Context, keys ...domain.CounterKey) error {
+	m.mu.Lock()
+	for _, key := range keys {
+		delete(m.entries, key)
+	}
+	m.mu.Unlock()
+	return nil
+}
+
+// InvalidateTenant implements domain.CounterStore.
+func (m *MemoryCounterStore) InvalidateTenant(_ context.Context, tenantKey string) error {
+	m.mu.Lock()
+	for key := range m.entries {
+		if tenantKey == "" || key.TenantKey == tenantKey {
+			delete(m.entries, key)
+		}
+	}
+	m.mu.Unlock()
+	return nil
+}
+
+// Keys implements domain.CounterStore. Expired entries are evicted as a pass
+// starts.
+func (m *MemoryCounterStore) Keys(_ context.Context, limit int) ([]domain.CounterKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.pending) == 0 {
+		now := time.Now()
+		for key, e := range m.entries {
+			if !now.Before(e.expires) {
+				delete(m.entries, key)
+				continue
+			}
+			m.pending = append(m.pending, key)
+		}
+	}
+	var keys []domain.CounterKey
+	for len(m.pending) > 0 && len(keys) < limit {
+		key := m.pending[0]
+		m.pending = m.pending[1:]
+		if _, ok := m.entries[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,155 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"vn.io.arda/notification/internal/domain"
+	"vn.io.arda/notification/internal/testsupport"
+)
+
+type stubCountRepo struct {
+	domain.Repository
+	unread int64
+	counts int
+}
+
+func (r *stubCountRepo) CountUnread(context.Context, string, string) (int64, error) {
+	r.counts++
+	return r.unread, nil
+}
+
+func (r *stubCountRepo) Create(_ context.Context, in domain.CreateNotificationInput) (*domain.Notification, error) {
+	r.unread++
+	return &domain.Notification{ID: uuid.New(), TenantKey: in.TenantKey, UserID: in.UserID}, nil
+}
+
+func (r *stubCountRepo) MarkRead(context.Context, uuid.UUID, string, string) error {
+	r.unread--
+	return nil
+}
+
+func TestCountUnread_CachedAndAdjusted(t *testing.T) {
+	ctx := context.Background()
+	repo := &stubCountRepo{unread: 2}
+	s := NewService(repo, nil, nil, WithCounterStore(NewMemoryCounterStore(0)))
+
+	for range 2 {
+		if n, err := s.CountUnread(ctx, "t1", "u1"); err != nil || n != 2 {
+			t.Fatalf("count = %d, %v", n, err)
+		}
+	}
+	if repo.counts != 1 {
+		t.Fatalf("repository counted %d times, want 1", repo.counts)
+	}
+
+	if _, err := s.Create(ctx, domain.CreateNotificationInput{TenantKey: "t1", UserID: "u1", Type: domain.TypeSystem}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.MarkRead(ctx, uuid.NewString(), "t1", "u1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.MarkRead(ctx, uuid.NewString(), "t1", "u1"); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := s.CountUnread(ctx, "t1", "u1"); n != 1 || repo.counts != 1 {
+		t.Fatalf("count = %d after create and two reads (repository counted %d times), want 1 from cache", n, repo.counts)
+	}
+}
+
+func TestReconcileCounters_CorrectsDrift(t *testing.T) {
+	ctx := context.Background()
+	repo := &stubCountRepo{unread: 3}
+	store := NewMemoryCounterStore(0)
+	s := NewService(repo, nil, nil, WithCounterStore(store))
+
+	key := domain.CounterKey{TenantKey: "t1", UserID: "u1"}
+	store.Set(ctx, key, 7)
+	store.Set(ctx, domain.CounterKey{TenantKey: "t1", UserID: "u2"}, 3)
+
+	if corrected := s.ReconcileCounters(ctx, 10); corrected != 1 {
+		t.Fatalf("corrected = %d, want 1", corrected)
+	}
+	if n, ok, _ := store.Get(ctx, key); !ok || n != 3 {
+		t.Fatalf("cached = %d (%v), want 3", n, ok)
+	}
+}
+
+func TestMemoryCounterStore_AddSkipsMissingAndClamps(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryCounterStore(0)
+	hit := domain.CounterKey{TenantKey: "t1", UserID: "u1"}
+	miss := domain.CounterKey{TenantKey: "t1", UserID: "u2"}
+	store.Set(ctx, hit, 1)
+
+	store.Add(ctx, []domain.CounterDelta{{CounterKey: hit, Delta: -3}, {CounterKey: miss, Delta: 1}})
+	if n, ok, _ := store.Get(ctx, hit); !ok || n != 0 {
+		t.Fatalf("hit = %d (%v), want 0", n, ok)
+	}
+	if _, ok, _ := store.Get(ctx, miss); ok {
+		t.Fatal("delta created a missing entry")
+	}
+
+	store.InvalidateTenant(ctx, "t1")
+	if _, ok, _ := store.Get(ctx, hit); ok {
+		t.Fatal("entry survived tenant invalidation")
+	}
+}
+
+func TestPushUnreadCount(t *testing.T) {
+	ctx := context.Background()
+	hub := testsupport.NewHub()
+	hub.Connect("acme", "u1")
+	s := NewService(testsupport.NewRepository(), hub, testsupport.NewResolver())
+
+	// counts returns the unread counts pushed to a user so far.
+	counts := func(userID string) []int64 {
+		var out []int64
+		for _, e := range hub.Events() {
+			if e.UserID == userID && e.Name == EventUnreadCount {
+				out = append(out, e.Data.(map[string]int64)["count"])
+			}
+		}
+		return out
+	}
+
+	n, err := s.Create(ctx, domain.CreateNotificationInput{TenantKey: "acme", UserID: "u1", Type: domain.TypeSystem, Title: "t"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.dispatchOutbox(ctx, OutboxConfig{BatchSize: 10, Lease: time.Minute})
+	waitFor(t, func() bool { return len(counts("u1")) == 1 })
+	if got := counts("u1")[0]; got != 1 {
+		t.Fatalf("pushed count %d after create, want 1", got)
+	}
+	if err := s.MarkRead(ctx, n.ID.String(), "acme", "u1"); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return len(counts("u1")) == 2 })
+	if got := counts("u1")[1]; got != 0 {
+		t.Fatalf("pushed count %d after read, want 0", got)
+	}
+
+	// A user without a stream gets nothing pushed.
+	if _, err := s.Create(ctx, domain.CreateNotificationInput{TenantKey: "acme", UserID: "u2", Type: domain.TypeSystem, Title: "t"}); err != nil {
+		t.Fatal(err)
+	}
+	s.dispatchOutbox(ctx, OutboxConfig{BatchSize: 10, Lease: time.Minute})
+	s.pushUnreadCount("acme", "u2")
+	if got := counts("u2"); len(got) != 0 {
+		t.Fatalf("pushed %v to an offline user", got)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
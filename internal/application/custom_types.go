@@ -0,0 +1,93 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"vn.io.arda/notification/internal/domain"
+)
+
+// SetCustomTypes enables tenant-registered notification types. Without it only
+// the built-in types are accepted.
+func (s *Service) SetCustomTypes(repo domain.CustomTypeRepository) {
+	s.customTypes = repo
+}
+
+// customType returns the tenant's registration of t, or nil for built-in types.
+// An unregistered type yields domain.ErrUnknownType.
+func (s *Service) customType(ctx context.Context, tenantKey string, t domain.NotificationType) (*domain.CustomType, error) {
+	if t.IsBuiltin() {
+		return nil, nil
+	}
+	if !t.Valid() || s.customTypes == nil {
+		return nil, fmt.Errorf("%w: %q", domain.ErrUnknownType, t)
+	}
+	ct, err := s.customTypes.Get(ctx, tenantKey, t)
+	if err != nil {
+		return nil, fmt.Errorf("look up notification type: %w", err)
+	}
+	if ct == nil {
+		return nil, fmt.Errorf("%w: %q is not registered for tenant %q", domain.ErrUnknownType, t, tenantKey)
+	}
+	return ct, nil
+}
+
+// applyType validates input.Type against the producing tenant's registered types
+// and fills in the type's default priority when the producer did not set one.
+func (s *Service) applyType(ctx context.Context, input domain.FanoutInput) (domain.FanoutInput, error) {
+	ct, err := s.customType(ctx, input.TenantKey, input.Type)
+	if err != nil {
+		return input, err
+	}
+	if ct != nil && input.Priority == "" {
+		input.Priority = ct.DefaultPriority
+	}
+	return input, nil
+}
+
+// ListNotificationTypes returns the built-in types followed by the tenant's custom types.
+func (s *Service) ListNotificationTypes(ctx context.Context, tenantKey string) ([]domain.CustomType, error) {
+	types := make([]domain.CustomType, 0, len(domain.BuiltinTypes))
+	for _, t := range domain.BuiltinTypes {
+		types = append(types, domain.CustomType{
+			TenantKey: tenantKey, Type: t, Name: string(t),
+			DefaultPriority: domain.PriorityNormal, Builtin: true,
+		})
+	}
+	if s.customTypes == nil {
+		return types, nil
+	}
+	custom, err := s.customTypes.List(ctx, tenantKey)
+	if err != nil {
+		return nil, err
+	}
+	return append(types, custom...), nil
+}
+
+// UpsertCustomType registers or updates a tenant's custom notification type.
+func (s *Service) UpsertCustomType(ctx context.Context, ct domain.CustomType) (*domain.CustomType, error) {
+	if s.customTypes == nil {
+		return nil, fmt.Errorf("custom notification types not configured")
+	}
+	switch {
+	case !ct.Type.Valid():
+		return nil, fmt.Errorf("invalid type %q: use uppercase letters, digits and underscores", ct.Type)
+	case ct.Type.IsBuiltin():
+		return nil, fmt.Errorf("type %q is built in", ct.Type)
+	case ct.Name == "":
+		return nil, fmt.Errorf("name is required")
+	case ct.RetentionDays < 0:
+		return nil, fmt.Errorf("retention_days must not be negative")
+	}
+	ct.DefaultPriority = ct.DefaultPriority.OrDefault()
+	return s.customTypes.Upsert(ctx, ct)
+}
+
+// DeleteCustomType removes a tenant's custom type. Stored notifications keep it;
+// new notifications of the type are rejected.
+func (s *Service) DeleteCustomType(ctx context.Context, tenantKey string, t domain.NotificationType) error {
+	if s.customTypes == nil {
+		return fmt.Errorf("custom notification types not configured")
+	}
+	return s.customTypes.Delete(ctx, tenantKey, t)
+}
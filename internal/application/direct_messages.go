@@ -0,0 +1,208 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/time/rate"
+	"vn.io.arda/notification/internal/domain"
+)
+
+var (
+	// ErrDirectMessageForbidden is returned when the tenant's rule does not let
+	// the sender notify other users.
+	ErrDirectMessageForbidden = errors.New("sending notifications to other users is not allowed")
+	// ErrDirectMessageRateLimited is returned when the sender reached more
+	// recipients in the last minute than the tenant's rule allows.
+	ErrDirectMessageRateLimited = errors.New("too many notifications sent, try again later")
+)
+
+const (
+	maxDirectTitleLen = 255
+	maxDirectBodyLen  = 2000
+)
+
+// DirectMessageResult reports a sent direct notification. Recipients who muted
+// the category are counted as reached, so the sender cannot tell them apart.
+type DirectMessageResult struct {
+	SourceEventID string `json:"source_event_id"`
+	Recipients    int    `json:"recipients"`
+}
+
+// SetDirectMessages enables per-tenant rules of user-to-user notifications;
+// tenants without a rule get defaults.
+func (s *Service) SetDirectMessages(repo domain.DirectMessageRuleRepository, defaults domain.DirectMessageRule) {
+	s.directRules = repo
+	s.directDefaults = defaults
+}
+
+// directRule returns the rule of tenantKey, or the defaults.
+func (s *Service) directRule(ctx context.Context, tenantKey string) (domain.DirectMessageRule, error) {
+	rule := s.directDefaults
+	rule.TenantKey = tenantKey
+	if s.directRules == nil {
+		return rule, nil
+	}
+	stored, err := s.directRules.Get(ctx, tenantKey)
+	if err != nil {
+		return rule, err
+	}
+	if stored != nil {
+		rule = *stored
+	}
+	return rule, nil
+}
+
+// SendDirectMessage notifies colleagues of the sender in its tenant, e.g. on an
+// @mention. Each recipient gets a CUSTOM notification through Create, unless
+// muted for the category; the sender and duplicates are dropped from the list.
+func (s *Service) SendDirectMessage(ctx context.Context, tenantKey, senderID string, roles []string, in DirectMessageInput) (*DirectMessageResult, error) {
+	rule, err := s.directRule(ctx, tenantKey)
+	if err != nil {
+		return nil, fmt.Errorf("load direct message rule: %w", err)
+	}
+	if !rule.AllowsSender(roles) {
+		return nil, ErrDirectMessageForbidden
+	}
+
+	var recipients []string
+	for _, uid := range in.Recipients {
+		if uid != "" && uid != senderID && !slices.Contains(recipients, uid) {
+			recipients = append(recipients, uid)
+		}
+	}
+	switch {
+	case len(recipients) == 0:
+		return nil, fmt.Errorf("at least one recipient other than the sender is required")
+	case len(recipients) > rule.MaxRecipients:
+		return nil, fmt.Errorf("at most %d recipients are allowed", rule.MaxRecipients)
+	case in.Title == "" || utf8.RuneCountInString(in.Title) > maxDirectTitleLen:
+		return nil, fmt.Errorf("title is required and limited to %d characters", maxDirectTitleLen)
+	case utf8.RuneCountInString(in.Body) > maxDirectBodyLen:
+		return nil, fmt.Errorf("body is limited to %d characters", maxDirectBodyLen)
+	case (in.EntityType == "") != (in.EntityID == ""):
+		return nil, fmt.Errorf("entity_type and entity_id go together")
+	}
+
+	members, err := s.resolver.UsersByTenant(ctx, tenantKey)
+	if err != nil {
+		return nil, fmt.Errorf("resolve tenant users: %w", err)
+	}
+	// One generic error, without the id, so senders cannot probe who belongs to the tenant.
+	for _, uid := range recipients {
+		if !slices.Contains(members, uid) {
+			return nil, errors.New("unknown recipient")
+		}
+	}
+
+	if !s.directLimiter.allow(s.clock.Now(), tenantKey+"/"+senderID, rule.PerMinute, len(recipients)) {
+		log.Warn().Str("tenant", tenantKey).Str("sender", senderID).Int("recipients", len(recipients)).
+			Msg("direct notification rate limited")
+		return nil, ErrDirectMessageRateLimited
+	}
+
+	category := domain.CategoryUserMessage
+	if in.Mention {
+		category = domain.CategoryUserMention
+	}
+	metadata := map[string]any{"senderId": senderID}
+	if in.EntityType != "" {
+		metadata["entityType"], metadata["entityId"] = in.EntityType, in.EntityID
+	}
+	clientID := in.ClientID
+	if clientID == "" {
+		clientID = uuid.NewString()
+	}
+	sourceEventID := "direct:" + senderID + ":" + clientID
+
+	allowed := s.filterMutedUsers(ctx, map[string][]string{tenantKey: recipients}, domain.TypeCustom, category)[tenantKey]
+	for _, uid := range allowed {
+		if _, err := s.Create(ctx, domain.CreateNotificationInput{
+			TenantKey:     tenantKey,
+			UserID:        uid,
+			Type:          domain.TypeCustom,
+			Category:      category,
+			Priority:      domain.PriorityNormal,
+			Title:         in.Title,
+			Body:          in.Body,
+			Sender:        domain.UserSender(senderID),
+			Metadata:      metadata,
+			SourceEventID: sourceEventID,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return &DirectMessageResult{SourceEventID: sourceEventID, Recipients: len(recipients)}, nil
+}
+
+// senderLimiter is a token bucket per sender counting recipients.
+type senderLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*rate.Limiter
+	lastPrune time.Time
+}
+
+// allow takes n tokens from the bucket of key, refilled at perMinute tokens a
+// minute. A bucket is rebuilt when the tenant changes its rate.
+func (l *senderLimiter) allow(now time.Time, key string, perMinute, n int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.buckets == nil {
+		l.buckets = make(map[string]*rate.Limiter)
+	}
+	if now.Sub(l.lastPrune) >= rateLimitPruneEvery {
+		for k, lim := range l.buckets {
+			if lim.TokensAt(now) >= float64(lim.Burst()) {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastPrune = now
+	}
+	lim, ok := l.buckets[key]
+	if !ok || lim.Burst() != perMinute {
+		lim = rate.NewLimiter(rate.Limit(float64(perMinute)/60), perMinute)
+		l.buckets[key] = lim
+	}
+	return lim.AllowN(now, n)
+}
+
+// --- Direct message rule admin ---
+
+func validateDirectRule(r domain.DirectMessageRule) error {
+	if r.MaxRecipients <= 0 || r.PerMinute <= 0 {
+		return fmt.Errorf("max_recipients and per_minute must be positive")
+	}
+	return nil
+}
+
+// GetDirectMessageRule returns the rule in effect for a tenant.
+func (s *Service) GetDirectMessageRule(ctx context.Context, tenantKey string) (domain.DirectMessageRule, error) {
+	return s.directRule(ctx, tenantKey)
+}
+
+// UpsertDirectMessageRule stores a tenant's rule.
+func (s *Service) UpsertDirectMessageRule(ctx context.Context, r domain.DirectMessageRule) (*domain.DirectMessageRule, error) {
+	if s.directRules == nil {
+		return nil, fmt.Errorf("direct message rules not configured")
+	}
+	if err := validateDirectRule(r); err != nil {
+		return nil, err
+	}
+	return s.directRules.Upsert(ctx, r)
+}
+
+// DeleteDirectMessageRule restores the defaults for a tenant.
+func (s *Service) DeleteDirectMessageRule(ctx context.Context, tenantKey string) error {
+	if s.directRules == nil {
+		return fmt.Errorf("direct message rules not configured")
+	}
+	return s.directRules.Delete(ctx, tenantKey)
+}
@@ -0,0 +1,76 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"vn.io.arda/notification/internal/domain"
+	"vn.io.arda/notification/internal/testsupport"
+)
+
+func TestSendDirectMessage(t *testing.T) {
+	ctx := context.Background()
+	clock := domain.NewManualClock(time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC))
+	repo := testsupport.NewRepository()
+	prefs := testsupport.NewPreferences()
+	prefs.Mute("acme", "u5", domain.TypeCustom, domain.CategoryUserMention)
+	s := NewService(repo, testsupport.NewHub(), fanoutResolver(), WithPreferences(prefs), WithClock(clock),
+		WithDirectMessages(nil, domain.DirectMessageRule{Enabled: true, SenderRoles: []string{"MEMBER"}, MaxRecipients: 3, PerMinute: 4}))
+	member := []string{"MEMBER"}
+	mention := DirectMessageInput{Recipients: []string{"u2", "u3", "u2", "u1", "u5"}, Mention: true, Title: "u1 mentioned you",
+		EntityType: "deal", EntityID: "D-1", ClientID: "c-1"}
+
+	if _, err := s.SendDirectMessage(ctx, "acme", "u1", nil, mention); !errors.Is(err, ErrDirectMessageForbidden) {
+		t.Fatalf("err = %v, want ErrDirectMessageForbidden for a sender without the role", err)
+	}
+	for name, in := range map[string]DirectMessageInput{
+		"only the sender":   {Recipients: []string{"u1"}, Title: "hi"},
+		"too many":          {Recipients: []string{"u2", "u3", "u4", "u5"}, Title: "hi"},
+		"other tenant":      {Recipients: []string{"v1"}, Title: "hi"},
+		"no title":          {Recipients: []string{"u2"}},
+		"entity without id": {Recipients: []string{"u2"}, Title: "hi", EntityType: "deal"},
+	} {
+		if _, err := s.SendDirectMessage(ctx, "acme", "u1", member, in); err == nil {
+			t.Errorf("%s: expected a validation error", name)
+		}
+	}
+	if _, err := s.SendDirectMessage(ctx, "acme", "u1", member, DirectMessageInput{Recipients: []string{"v1"}, Title: "hi"}); err == nil ||
+		strings.Contains(err.Error(), "v1") {
+		t.Errorf("err = %v, want a generic error without the recipient id", err)
+	}
+
+	res, err := s.SendDirectMessage(ctx, "acme", "u1", member, mention)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Recipients != 3 {
+		t.Fatalf("recipients = %d, want 3", res.Recipients)
+	}
+	ns := repo.Notifications()
+	if got := recipients(ns)["acme"]; len(got) != 2 || got[0] != "u2" || got[1] != "u3" {
+		t.Fatalf("delivered to %v, want u2 and u3 (u5 muted mentions)", got)
+	}
+	if n := ns[0]; n.Type != domain.TypeCustom || n.Category != domain.CategoryUserMention || n.Metadata["senderId"] != "u1" ||
+		n.Metadata["entityId"] != "D-1" {
+		t.Fatalf("notification = %+v", n)
+	}
+
+	// One token is left: a retry of the same request is rejected by the limit
+	// before reaching the store, and a single recipient still fits.
+	if _, err := s.SendDirectMessage(ctx, "acme", "u1", member, mention); !errors.Is(err, ErrDirectMessageRateLimited) {
+		t.Fatalf("err = %v, want ErrDirectMessageRateLimited", err)
+	}
+	if _, err := s.SendDirectMessage(ctx, "acme", "u1", member, DirectMessageInput{Recipients: []string{"u4"}, Title: "hi"}); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Minute)
+	if _, err := s.SendDirectMessage(ctx, "acme", "u1", member, mention); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(repo.Notifications()); n != 3 {
+		t.Fatalf("stored %d notifications, want 3: a retried request is idempotent", n)
+	}
+}
@@ -9,8 +9,67 @@ type NotificationInput = domain.CreateNotificationInput
 // PreferenceUpdateInput is the DTO for batch upserting preferences.
 type PreferenceUpdateInput struct {
 	Type            string  `json:"type"`
+	Category        string  `json:"category,omitempty"` // empty = the whole type
 	ChannelInApp    *bool   `json:"channel_in_app,omitempty"`
 	ChannelEmail    *bool   `json:"channel_email,omitempty"`
 	QuietHoursStart *string `json:"quiet_hours_start,omitempty"`
 	QuietHoursEnd   *string `json:"quiet_hours_end,omitempty"`
 }
+
+// ReactionInput is the DTO for responding to a notification.
+type ReactionInput struct {
+	Response string `json:"response"` // "ACK" or "REJECT"
+	Comment  string `json:"comment,omitempty"`
+}
+
+// ActionInput is the DTO for choosing an action button on a notification.
+type ActionInput struct {
+	Comment string `json:"comment,omitempty"`
+}
+
+// ScopeResolveInput is the DTO for a dry-run scope resolution.
+type ScopeResolveInput struct {
+	TargetScope  string `json:"targetScope"`
+	TargetID     string `json:"targetId"`
+	TenantKey    string `json:"tenantKey"`
+	OriginUserID string `json:"originUserId,omitempty"`
+	// Targets and Exclude describe a composite fan-out (see domain.FanoutInput).
+	Targets []domain.FanoutTarget `json:"targets,omitempty"`
+	Exclude []domain.FanoutTarget `json:"exclude,omitempty"`
+	// Type (and optionally Category), when set together with ApplyPreferences,
+	// excludes users muted for that type or category.
+	Type             string `json:"type,omitempty"`
+	Category         string `json:"category,omitempty"`
+	ApplyPreferences bool   `json:"applyPreferences,omitempty"`
+	// SampleSize returns up to N user IDs per tenant. Zero returns counts only.
+	SampleSize int `json:"sampleSize,omitempty"`
+}
+
+// ScopeResolution reports who a fan-out would reach, without creating notifications.
+type ScopeResolution struct {
+	TotalRecipients int                    `json:"total_recipients"`
+	MutedRecipients int                    `json:"muted_recipients"`
+	Tenants         map[string]TenantReach `json:"tenants"`
+}
+
+// TenantReach is the per-tenant part of a ScopeResolution.
+type TenantReach struct {
+	Recipients int      `json:"recipients"`
+	Sample     []string `json:"sample,omitempty"`
+}
+
+// DirectMessageInput is the DTO for a user notifying colleagues in their tenant.
+type DirectMessageInput struct {
+	Recipients []string `json:"recipients"`
+	// Mention marks the notification as an @mention (category user.mention)
+	// rather than a message (user.message).
+	Mention bool   `json:"mention,omitempty"`
+	Title   string `json:"title"`
+	Body    string `json:"body,omitempty"`
+	// EntityType / EntityID link the notification to the business entity it is
+	// about, e.g. the comment thread of a deal.
+	EntityType string `json:"entity_type,omitempty"`
+	EntityID   string `json:"entity_id,omitempty"`
+	// ClientID makes a retried request idempotent.
+	ClientID string `json:"client_id,omitempty"`
+}
@@ -0,0 +1,63 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"vn.io.arda/notification/internal/domain"
+)
+
+// SetEncryptionKeys enables tenant key registration (bring-your-own-key).
+// provider is used to verify a key reference resolves before it is stored.
+func (s *Service) SetEncryptionKeys(repo domain.EncryptionKeyRepository, provider domain.KeyProvider) {
+	s.keyRepo = repo
+	s.keyProvider = provider
+}
+
+// SetKeyRotator enables RotateEncryptionKey.
+func (s *Service) SetKeyRotator(r domain.KeyRotator) {
+	s.keyRotator = r
+}
+
+// ListEncryptionKeys returns all tenant key registrations.
+func (s *Service) ListEncryptionKeys(ctx context.Context) ([]domain.TenantEncryptionKey, error) {
+	if s.keyRepo == nil {
+		return nil, fmt.Errorf("encryption not configured")
+	}
+	return s.keyRepo.List(ctx)
+}
+
+// RegisterEncryptionKey stores a tenant's KMS key reference after checking it resolves
+// to 256-bit key material. New content for the tenant is encrypted with it; existing
+// content keeps the key it was written with.
+func (s *Service) RegisterEncryptionKey(ctx context.Context, tenantKey, keyRef string) (*domain.TenantEncryptionKey, error) {
+	if s.keyRepo == nil || s.keyProvider == nil {
+		return nil, fmt.Errorf("encryption not configured")
+	}
+	key, err := s.keyProvider.DataKey(ctx, keyRef)
+	if err != nil {
+		return nil, fmt.Errorf("key reference not usable: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("key reference not usable: expected 256-bit key")
+	}
+	return s.keyRepo.Upsert(ctx, domain.TenantEncryptionKey{TenantKey: tenantKey, KeyRef: keyRef})
+}
+
+// DeleteEncryptionKey removes a tenant's key registration; new content falls back to the default key.
+func (s *Service) DeleteEncryptionKey(ctx context.Context, tenantKey string) error {
+	if s.keyRepo == nil {
+		return fmt.Errorf("encryption not configured")
+	}
+	return s.keyRepo.Delete(ctx, tenantKey)
+}
+
+// RotateEncryptionKey re-encrypts the tenant's stored content with its current
+// key, e.g. after RegisterEncryptionKey replaced it, so the old key can be
+// retired. Returns the number of notifications rewritten.
+func (s *Service) RotateEncryptionKey(ctx context.Context, tenantKey string) (int64, error) {
+	if s.keyRotator == nil {
+		return 0, fmt.Errorf("encryption not configured")
+	}
+	return s.keyRotator.Rotate(ctx, tenantKey)
+}
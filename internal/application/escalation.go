@@ -0,0 +1,243 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// EventNotificationEscalated is pushed to a user's streams when an unread
+// notification is escalated by a renotify rule; data is the notification.
+const EventNotificationEscalated = "notification_escalated"
+
+// metadataEscalationKey marks notifications sent to a fallback role, so they
+// are not escalated in turn.
+const metadataEscalationKey = "escalation"
+
+// MaxEscalationDelay bounds how long a rule waits before escalating.
+const MaxEscalationDelay = 7 * 24 * time.Hour
+
+// EscalationConfig tunes the escalation scheduler.
+type EscalationConfig struct {
+	// Interval is how often due escalations are claimed; escalations fire at most
+	// this long after their due time.
+	Interval time.Duration
+	// BatchSize is the maximum number of escalations claimed per round.
+	BatchSize int
+}
+
+// SetEscalations enables escalation of unread HIGH / URGENT notifications.
+func (s *Service) SetEscalations(repo domain.EscalationRepository) {
+	s.escalations = repo
+}
+
+// scheduleEscalations stores the pending escalations of dispatched notifications
+// that match a rule of their tenant. Rules are loaded once per tenant.
+func (s *Service) scheduleEscalations(ctx context.Context, ns []*domain.Notification) {
+	if s.escalations == nil {
+		return
+	}
+	rules := make(map[string][]domain.EscalationRule)
+	var pending []domain.Escalation
+	for _, n := range ns {
+		if (n.Priority != domain.PriorityHigh && n.Priority != domain.PriorityUrgent) || n.Metadata[metadataEscalationKey] != nil {
+			continue
+		}
+		tenantRules, ok := rules[n.TenantKey]
+		if !ok {
+			var err error
+			if tenantRules, err = s.escalations.ListRules(ctx, n.TenantKey); err != nil {
+				log.Error().Err(err).Str("tenant", n.TenantKey).Msg("failed to load escalation rules")
+			}
+			rules[n.TenantKey] = tenantRules
+		}
+		for _, r := range tenantRules {
+			if r.Matches(n) {
+				pending = append(pending, domain.Escalation{NotificationID: n.ID, TenantKey: n.TenantKey, UserID: n.UserID,
+					RuleID: r.ID, DueAt: n.CreatedAt.Add(time.Duration(r.AfterSeconds) * time.Second)})
+			}
+		}
+	}
+	if err := s.escalations.Schedule(ctx, pending); err != nil {
+		log.Error().Err(err).Int("escalations", len(pending)).Msg("failed to schedule escalations")
+	}
+}
+
+// cancelEscalations drops the pending escalations of notifications the user has
+// read or deleted; empty ids cancels all of the user's.
+func (s *Service) cancelEscalations(ctx context.Context, tenantKey, userID string, ids ...uuid.UUID) {
+	if s.escalations == nil {
+		return
+	}
+	if err := s.escalations.Cancel(ctx, tenantKey, userID, ids); err != nil {
+		// The scheduler checks the read state again before escalating.
+		log.Warn().Err(err).Str("user", userID).Msg("failed to cancel escalations")
+	}
+}
+
+// RunEscalations fires due escalations until ctx is cancelled.
+func (s *Service) RunEscalations(ctx context.Context, cfg EscalationConfig) {
+	if s.escalations == nil {
+		return
+	}
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for s.escalateDue(ctx, cfg.BatchSize) == cfg.BatchSize {
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// escalateDue fires one batch of due escalations and returns their number.
+func (s *Service) escalateDue(ctx context.Context, limit int) int {
+	due, err := s.escalations.ClaimDue(ctx, s.clock.Now(), limit)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Error().Err(err).Msg("failed to claim due escalations")
+			s.jobFailed(ctx, jobEscalation, err)
+		}
+		return 0
+	}
+	s.jobSucceeded(ctx, jobEscalation)
+	for _, e := range due {
+		if err := s.escalate(ctx, e); err != nil {
+			log.Error().Err(err).
+				Str("notification", domain.FormatID(e.NotificationID)).
+				Str("rule", e.RuleID.String()).
+				Msg("escalation failed")
+		}
+	}
+	return len(due)
+}
+
+// escalate runs the action of e's rule. Escalations are best-effort: a failed
+// one is logged and not retried.
+func (s *Service) escalate(ctx context.Context, e domain.Escalation) error {
+	rule, err := s.escalations.GetRule(ctx, e.TenantKey, e.RuleID)
+	if err != nil || rule == nil || !rule.Active {
+		return err
+	}
+	n, err := s.repo.GetByID(ctx, e.NotificationID)
+	if err != nil {
+		return fmt.Errorf("load notification: %w", err)
+	}
+	s.renderNotifications(ctx, "", []*domain.Notification{n})
+
+	switch rule.Action {
+	case domain.EscalateRenotify:
+		s.hub.BroadcastEvent(n.TenantKey, n.UserID, EventNotificationEscalated, n)
+	case domain.EscalateEmail:
+		if s.emailSender == nil || !n.AllowsChannel(domain.ChannelEmail) {
+			return nil
+		}
+		if err := s.emailSender.Send(ctx, n.UserID, n.Title, emailHTML(n)); err != nil {
+			return fmt.Errorf("send email: %w", err)
+		}
+		s.audit(ctx, deliveredEntry(n, domain.ChannelEmail))
+	case domain.EscalateRole:
+		return s.Fanout(ctx, domain.FanoutInput{
+			TargetScope: domain.ScopeRole,
+			TargetID:    rule.Role,
+			TenantKey:   n.TenantKey,
+			Type:        n.Type,
+			Category:    n.Category,
+			Priority:    n.Priority,
+			Title:       n.Title,
+			Body:        n.Body,
+			Metadata: map[string]any{metadataEscalationKey: map[string]any{
+				"notification_id": domain.FormatID(n.ID),
+				"user_id":         n.UserID,
+				"rule_id":         rule.ID.String(),
+			}},
+			SourceEventID: "escalation:" + domain.FormatID(n.ID) + ":" + rule.ID.String(),
+		})
+	}
+	log.Debug().Str("notification", domain.FormatID(n.ID)).Str("action", string(rule.Action)).Msg("notification escalated")
+	return nil
+}
+
+// --- Escalation rule admin ---
+
+func (s *Service) requireEscalations() error {
+	if s.escalations == nil {
+		return fmt.Errorf("escalations not configured")
+	}
+	return nil
+}
+
+func validateEscalationRule(r domain.EscalationRule) error {
+	switch {
+	case r.Priority != domain.PriorityHigh && r.Priority != domain.PriorityUrgent:
+		return fmt.Errorf("priority must be HIGH or URGENT")
+	case r.AfterSeconds <= 0 || time.Duration(r.AfterSeconds)*time.Second > MaxEscalationDelay:
+		return fmt.Errorf("after_seconds must be between 1 and %d", int(MaxEscalationDelay.Seconds()))
+	case !r.Action.Valid():
+		return fmt.Errorf("unknown action %q: use renotify, email or role", r.Action)
+	case r.Action == domain.EscalateRole && r.Role == "":
+		return fmt.Errorf("role is required for the role action")
+	}
+	return nil
+}
+
+// ListEscalationRules returns a tenant's escalation rules.
+func (s *Service) ListEscalationRules(ctx context.Context, tenantKey string) ([]domain.EscalationRule, error) {
+	if err := s.requireEscalations(); err != nil {
+		return nil, err
+	}
+	return s.escalations.ListRules(ctx, tenantKey)
+}
+
+// CreateEscalationRule validates and stores a rule. It applies to notifications
+// delivered from now on.
+func (s *Service) CreateEscalationRule(ctx context.Context, r domain.EscalationRule) (*domain.EscalationRule, error) {
+	if err := s.requireEscalations(); err != nil {
+		return nil, err
+	}
+	if err := validateEscalationRule(r); err != nil {
+		return nil, err
+	}
+	if r.Type != "" {
+		if _, err := s.customType(ctx, r.TenantKey, r.Type); err != nil {
+			return nil, fmt.Errorf("invalid notification type: %w", err)
+		}
+	}
+	return s.escalations.CreateRule(ctx, r)
+}
+
+// UpdateEscalationRule changes a rule; pending escalations keep their due time.
+func (s *Service) UpdateEscalationRule(ctx context.Context, r domain.EscalationRule) (*domain.EscalationRule, error) {
+	if err := s.requireEscalations(); err != nil {
+		return nil, err
+	}
+	if err := validateEscalationRule(r); err != nil {
+		return nil, err
+	}
+	if r.Type != "" {
+		if _, err := s.customType(ctx, r.TenantKey, r.Type); err != nil {
+			return nil, fmt.Errorf("invalid notification type: %w", err)
+		}
+	}
+	return s.escalations.UpdateRule(ctx, r)
+}
+
+// DeleteEscalationRule removes a rule and its pending escalations.
+func (s *Service) DeleteEscalationRule(ctx context.Context, tenantKey, idStr string) error {
+	if err := s.requireEscalations(); err != nil {
+		return err
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return fmt.Errorf("invalid rule id: %w", err)
+	}
+	return s.escalations.DeleteRule(ctx, tenantKey, id)
+}
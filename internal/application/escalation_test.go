@@ -0,0 +1,86 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"vn.io.arda/notification/internal/domain"
+	"vn.io.arda/notification/internal/testsupport"
+)
+
+func TestEscalation(t *testing.T) {
+	ctx := context.Background()
+	clock := domain.NewManualClock(time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC))
+	repo, hub := testsupport.NewRepository(), testsupport.NewHub()
+	repo.SetClock(clock)
+	escalations := testsupport.NewEscalations(repo)
+	resolver := testsupport.NewResolver().AddRole("acme", "ONCALL", "u9")
+	s := NewService(repo, hub, resolver, WithEscalations(escalations), WithClock(clock))
+	outbox := OutboxConfig{BatchSize: 10, Lease: time.Minute}
+
+	for _, r := range []domain.EscalationRule{
+		{TenantKey: "acme", Priority: domain.PriorityHigh, AfterSeconds: 600, Action: domain.EscalateRenotify, Active: true},
+		{TenantKey: "acme", Priority: domain.PriorityUrgent, AfterSeconds: 3600, Action: domain.EscalateRole, Role: "ONCALL", Active: true},
+	} {
+		if _, err := s.CreateEscalationRule(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.CreateEscalationRule(ctx, domain.EscalationRule{TenantKey: "acme", Priority: domain.PriorityNormal,
+		AfterSeconds: 60, Action: domain.EscalateEmail}); err == nil {
+		t.Fatal("accepted a rule for NORMAL notifications")
+	}
+
+	create := func(userID string, p domain.Priority) *domain.Notification {
+		t.Helper()
+		n, err := s.Create(ctx, domain.CreateNotificationInput{TenantKey: "acme", UserID: userID, Type: domain.TypeWorkflow, Priority: p, Title: "approve"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	create("u1", domain.PriorityUrgent)
+	high := create("u2", domain.PriorityHigh)
+	create("u3", domain.PriorityNormal)
+	s.dispatchOutbox(ctx, outbox)
+	if n := len(escalations.Pending()); n != 3 {
+		t.Fatalf("scheduled %d escalations, want 3", n)
+	}
+
+	if err := s.MarkRead(ctx, high.ID.String(), "acme", "u2"); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(escalations.Pending()); n != 2 {
+		t.Fatalf("%d escalations pending after a read, want 2", n)
+	}
+
+	hub.Reset()
+	clock.Advance(11 * time.Minute)
+	if fired := s.escalateDue(ctx, 10); fired != 1 {
+		t.Fatalf("fired %d escalations, want the renotify", fired)
+	}
+	if events := hub.Events(); len(events) != 1 || events[0].Name != EventNotificationEscalated || events[0].UserID != "u1" {
+		t.Fatalf("events = %+v, want one escalation for u1", events)
+	}
+
+	clock.Advance(time.Hour)
+	if fired := s.escalateDue(ctx, 10); fired != 1 {
+		t.Fatalf("fired %d escalations, want the fallback role", fired)
+	}
+	var fallback *domain.Notification
+	for _, n := range repo.Notifications() {
+		if n.UserID == "u9" {
+			fallback = n
+		}
+	}
+	if fallback == nil || fallback.Priority != domain.PriorityUrgent || fallback.Metadata[metadataEscalationKey] == nil {
+		t.Fatalf("fallback notification = %+v", fallback)
+	}
+
+	// The fallback notification is not escalated in turn.
+	s.dispatchOutbox(ctx, outbox)
+	if n := len(escalations.Pending()); n != 0 {
+		t.Fatalf("%d escalations pending, want none", n)
+	}
+}
@@ -0,0 +1,112 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// SetEventDefaults enables per-event-type defaults, consulted for every Kafka event
+// before fan-out (see ApplyEventDefaults).
+func (s *Service) SetEventDefaults(repo domain.EventDefaultsRepository) {
+	s.eventDefaults = repo
+}
+
+// ApplyEventDefaults fills the priority, category, expiry and channels that the
+// handler of eventKey ("topic:eventType") left unset. Values set by the handler
+// or the producer always win. Defaults are best-effort: when they cannot be
+// loaded the event is delivered as the handler built it.
+func (s *Service) ApplyEventDefaults(ctx context.Context, eventKey string, in *domain.FanoutInput) {
+	if s.eventDefaults == nil {
+		return
+	}
+	d, err := s.eventDefaults.Get(ctx, eventKey)
+	if err != nil {
+		log.Warn().Err(err).Str("event_key", eventKey).Str("source_event_id", in.SourceEventID).Msg("failed to load event defaults")
+		return
+	}
+	if d == nil {
+		return
+	}
+	if in.Priority == "" {
+		in.Priority = d.Priority
+	}
+	if in.Category == "" {
+		in.Category = d.Category
+	}
+	if d.TTLSeconds > 0 && !domain.HasExpiry(in.Metadata) {
+		in.Metadata = domain.WithExpiry(in.Metadata, s.clock.Now().Add(time.Duration(d.TTLSeconds)*time.Second))
+	}
+	if d.Channels != nil && !domain.HasChannels(in.Metadata) {
+		in.Metadata = domain.WithChannels(in.Metadata, d.Channels)
+	}
+}
+
+// PurgeExpired deletes notifications whose event-type TTL has elapsed. Called by
+// a background scheduler.
+func (s *Service) PurgeExpired(ctx context.Context) {
+	count, err := s.repo.PurgeExpired(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("expired notification purge failed")
+		s.jobFailed(ctx, jobPurgeExpired, err)
+		return
+	}
+	s.jobSucceeded(ctx, jobPurgeExpired)
+	if count > 0 {
+		log.Info().Int64("deleted", count).Msg("expired notification purge completed")
+	}
+}
+
+// --- Event defaults admin ---
+
+func (s *Service) requireEventDefaults() error {
+	if s.eventDefaults == nil {
+		return fmt.Errorf("event defaults not configured")
+	}
+	return nil
+}
+
+// ListEventDefaults returns every stored per-event-type default.
+func (s *Service) ListEventDefaults(ctx context.Context) ([]domain.EventDefaults, error) {
+	if err := s.requireEventDefaults(); err != nil {
+		return nil, err
+	}
+	return s.eventDefaults.List(ctx)
+}
+
+// UpsertEventDefaults validates and stores the defaults of d.EventKey.
+func (s *Service) UpsertEventDefaults(ctx context.Context, d domain.EventDefaults) (*domain.EventDefaults, error) {
+	if err := s.requireEventDefaults(); err != nil {
+		return nil, err
+	}
+	if topic, _, ok := strings.Cut(d.EventKey, ":"); !ok || topic == "" {
+		return nil, fmt.Errorf("event key must be \"topic:eventType\"")
+	}
+	if d.Priority != "" && d.Priority.OrDefault() != d.Priority {
+		return nil, fmt.Errorf("unknown priority %q", d.Priority)
+	}
+	if !domain.ValidCategory(d.Category) {
+		return nil, fmt.Errorf("invalid category %q", d.Category)
+	}
+	if d.TTLSeconds < 0 {
+		return nil, fmt.Errorf("ttl_seconds must not be negative")
+	}
+	for _, c := range d.Channels {
+		if !c.Valid() {
+			return nil, fmt.Errorf("unknown channel %q", c)
+		}
+	}
+	return s.eventDefaults.Upsert(ctx, d)
+}
+
+// DeleteEventDefaults removes the defaults of an event key.
+func (s *Service) DeleteEventDefaults(ctx context.Context, eventKey string) error {
+	if err := s.requireEventDefaults(); err != nil {
+		return err
+	}
+	return s.eventDefaults.Delete(ctx, eventKey)
+}
@@ -0,0 +1,58 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"vn.io.arda/notification/internal/domain"
+)
+
+type stubEventDefaults struct {
+	domain.EventDefaultsRepository
+	byKey map[string]domain.EventDefaults
+}
+
+func (r stubEventDefaults) Get(_ context.Context, key string) (*domain.EventDefaults, error) {
+	if d, ok := r.byKey[key]; ok {
+		return &d, nil
+	}
+	return nil, nil
+}
+
+func TestApplyEventDefaults(t *testing.T) {
+	now := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	s := NewService(nil, nil, nil,
+		WithClock(domain.NewManualClock(now)),
+		WithEventDefaults(stubEventDefaults{byKey: map[string]domain.EventDefaults{
+			"crm-events:DEAL_UPDATED": {
+				Priority:   domain.PriorityHigh,
+				Category:   "crm.deal",
+				TTLSeconds: 3600,
+				Channels:   []domain.Channel{domain.ChannelInApp},
+			},
+		}}),
+	)
+
+	in := domain.FanoutInput{Category: "crm.deal.won", Metadata: map[string]any{"entityId": "d1"}}
+	s.ApplyEventDefaults(context.Background(), "crm-events:DEAL_UPDATED", &in)
+	if in.Priority != domain.PriorityHigh {
+		t.Fatalf("priority = %q, want default HIGH", in.Priority)
+	}
+	if in.Category != "crm.deal.won" {
+		t.Fatalf("handler category was overridden: %q", in.Category)
+	}
+	if got := in.Metadata["expires_at"]; got != "2026-03-01T09:00:00Z" {
+		t.Fatalf("expires_at = %v", got)
+	}
+	n := &domain.Notification{Metadata: in.Metadata}
+	if !n.AllowsChannel(domain.ChannelInApp) || n.AllowsChannel(domain.ChannelEmail) {
+		t.Fatalf("channels not restricted: %v", in.Metadata["channels"])
+	}
+
+	other := domain.FanoutInput{Priority: domain.PriorityLow}
+	s.ApplyEventDefaults(context.Background(), "crm-events:LEAD_STATUS_CHANGED", &other)
+	if other.Priority != domain.PriorityLow || other.Metadata != nil {
+		t.Fatalf("event without defaults was changed: %+v", other)
+	}
+}
@@ -0,0 +1,51 @@
+package application
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// exportRenderBatch is how many exported notifications are rendered together,
+// so template lookups are shared without holding the whole export in memory.
+const exportRenderBatch = 200
+
+// Export streams the notification history selected by f to fn, oldest first,
+// rendering templated notifications in locale. Every export is logged with its
+// requester, as exports serve audit and compliance requests.
+func (s *Service) Export(ctx context.Context, f domain.ExportFilter, locale, requester string, fn func(*domain.Notification) error) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	log.Info().
+		Str("tenant", f.TenantKey).
+		Str("user", f.UserID).
+		Str("requester", requester).
+		Any("from", f.From).
+		Any("to", f.To).
+		Msg("notification export")
+
+	batch := make([]*domain.Notification, 0, exportRenderBatch)
+	flush := func() error {
+		s.renderNotifications(ctx, locale, batch)
+		for _, n := range batch {
+			if err := fn(n); err != nil {
+				return err
+			}
+		}
+		batch = batch[:0]
+		return nil
+	}
+	err := s.repo.Export(ctx, f, func(n *domain.Notification) error {
+		batch = append(batch, n)
+		if len(batch) < exportRenderBatch {
+			return nil
+		}
+		return flush()
+	})
+	if err != nil {
+		return err
+	}
+	return flush()
+}
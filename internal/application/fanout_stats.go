@@ -0,0 +1,82 @@
+package application
+
+import (
+	"sync/atomic"
+
+	"vn.io.arda/notification/internal/metrics"
+)
+
+// DefaultFanoutChunkSize is the number of rows per BatchCreate when no chunk size is configured.
+const DefaultFanoutChunkSize = 1000
+
+// fanoutStats aggregates chunked fan-out progress across all fan-outs since startup.
+type fanoutStats struct {
+	fanouts       atomic.Uint64
+	chunks        atomic.Uint64
+	rows          atomic.Uint64
+	inserted      atomic.Uint64
+	duplicates    atomic.Uint64
+	failed        atomic.Uint64
+	chunkLatency  *metrics.Histogram
+	fanoutLatency *metrics.Histogram
+}
+
+func newFanoutStats() *fanoutStats {
+	return &fanoutStats{
+		chunkLatency:  metrics.NewHistogram(metrics.DefaultLatencyBuckets),
+		fanoutLatency: metrics.NewHistogram(metrics.DefaultLatencyBuckets),
+	}
+}
+
+// FanoutStats is a point-in-time view of fan-out insert metrics.
+type FanoutStats struct {
+	ChunkSize     int                `json:"chunk_size"`
+	Fanouts       uint64             `json:"fanouts"`
+	FailedFanouts uint64             `json:"failed_fanouts"`
+	Chunks        uint64             `json:"chunks"`
+	Rows          uint64             `json:"rows"`
+	Inserted      uint64             `json:"inserted"`
+	Duplicates    uint64             `json:"duplicates"`
+	ChunkLatency  metrics.Snapshot   `json:"chunk_latency"`
+	FanoutLatency metrics.Snapshot   `json:"fanout_latency"`
+	RateLimit     *RateLimitStats    `json:"rate_limit,omitempty"`
+	Throttle      *ThrottleStats     `json:"throttle,omitempty"`
+	RecipientCap  *RecipientCapStats `json:"recipient_cap,omitempty"`
+}
+
+// SetFanoutChunkSize caps the number of notifications written per BatchCreate during fan-out.
+// Values <= 0 restore DefaultFanoutChunkSize.
+func (s *Service) SetFanoutChunkSize(n int) {
+	if n <= 0 {
+		n = DefaultFanoutChunkSize
+	}
+	s.chunkSize = n
+}
+
+// FanoutStats returns cumulative chunked fan-out metrics.
+func (s *Service) FanoutStats() FanoutStats {
+	stats := FanoutStats{
+		ChunkSize:     s.chunkSize,
+		Fanouts:       s.fanoutStats.fanouts.Load(),
+		FailedFanouts: s.fanoutStats.failed.Load(),
+		Chunks:        s.fanoutStats.chunks.Load(),
+		Rows:          s.fanoutStats.rows.Load(),
+		Inserted:      s.fanoutStats.inserted.Load(),
+		Duplicates:    s.fanoutStats.duplicates.Load(),
+		ChunkLatency:  s.fanoutStats.chunkLatency.Snapshot(),
+		FanoutLatency: s.fanoutStats.fanoutLatency.Snapshot(),
+	}
+	if rl := s.rateLimiter.Load(); rl != nil {
+		st := rl.stats()
+		stats.RateLimit = &st
+	}
+	if s.throttler != nil {
+		th := s.throttler.stats()
+		stats.Throttle = &th
+	}
+	if s.recipientCap != nil {
+		rc := s.recipientCap.stats()
+		stats.RecipientCap = &rc
+	}
+	return stats
+}
@@ -0,0 +1,360 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"vn.io.arda/notification/internal/domain"
+	"vn.io.arda/notification/internal/testsupport"
+)
+
+var (
+	_ SSEHub      = (*testsupport.Hub)(nil)
+	_ IAMResolver = (*testsupport.Resolver)(nil)
+)
+
+func fanoutResolver() *testsupport.Resolver {
+	return testsupport.NewResolver().
+		AddUsers("acme", "u1", "u2", "u3", "u4", "u5").
+		AddUsers("globex", "v1", "v2").
+		AddRole("acme", "MANAGER", "u2", "u4").
+		AddGroup("acme", "g-fin", "u3", "u5").
+		AddGroup("acme", "/Finance", "u3", "u5")
+}
+
+func TestFanout(t *testing.T) {
+	prefs := testsupport.NewPreferences()
+	prefs.Mute("acme", "u3", domain.TypeCRM, "")
+	prefs.Mute("acme", "u4", domain.TypeCRM, "crm.deal")
+
+	tests := []struct {
+		name    string
+		input   domain.FanoutInput
+		want    map[string][]string
+		wantErr bool
+	}{
+		{
+			name:  "user",
+			input: domain.FanoutInput{TargetScope: domain.ScopeUser, TargetID: "u1", TenantKey: "acme"},
+			want:  map[string][]string{"acme": {"u1"}},
+		},
+		{
+			name:  "tenant",
+			input: domain.FanoutInput{TargetScope: domain.ScopeTenant, TenantKey: "acme"},
+			want:  map[string][]string{"acme": {"u1", "u2", "u3", "u4", "u5"}},
+		},
+		{
+			name:  "role",
+			input: domain.FanoutInput{TargetScope: domain.ScopeRole, TargetID: "MANAGER", TenantKey: "acme"},
+			want:  map[string][]string{"acme": {"u2", "u4"}},
+		},
+		{
+			name:  "group by id",
+			input: domain.FanoutInput{TargetScope: domain.ScopeGroup, TargetID: "g-fin", TenantKey: "acme"},
+			want:  map[string][]string{"acme": {"u3", "u5"}},
+		},
+		{
+			name:  "group by path",
+			input: domain.FanoutInput{TargetScope: domain.ScopeGroup, TargetID: "/Finance", TenantKey: "acme"},
+			want:  map[string][]string{"acme": {"u3", "u5"}},
+		},
+		{
+			name:  "platform",
+			input: domain.FanoutInput{TargetScope: domain.ScopePlatform},
+			want:  map[string][]string{"acme": {"u1", "u2", "u3", "u4", "u5"}, "globex": {"v1", "v2"}},
+		},
+		{
+			name:  "originator is added",
+			input: domain.FanoutInput{TargetScope: domain.ScopeRole, TargetID: "MANAGER", TenantKey: "acme", OriginUserID: "u1"},
+			want:  map[string][]string{"acme": {"u1", "u2", "u4"}},
+		},
+		{
+			name:  "originator without tenant lands in master",
+			input: domain.FanoutInput{TargetScope: domain.ScopePlatform, OriginUserID: "admin"},
+			want:  map[string][]string{"acme": {"u1", "u2", "u3", "u4", "u5"}, "globex": {"v1", "v2"}, "master": {"admin"}},
+		},
+		{
+			name: "composite with exclusion",
+			input: domain.FanoutInput{TenantKey: "acme",
+				Targets: []domain.FanoutTarget{{Scope: domain.ScopeRole, ID: "MANAGER"}, {Scope: domain.ScopeGroup, ID: "g-fin"}},
+				Exclude: []domain.FanoutTarget{{Scope: domain.ScopeUser, ID: "u2"}}},
+			want: map[string][]string{"acme": {"u3", "u4", "u5"}},
+		},
+		{
+			name:  "type muted",
+			input: domain.FanoutInput{TargetScope: domain.ScopeTenant, TenantKey: "acme", Type: domain.TypeCRM, Category: "crm.lead"},
+			want:  map[string][]string{"acme": {"u1", "u2", "u4", "u5"}},
+		},
+		{
+			name:  "category muted",
+			input: domain.FanoutInput{TargetScope: domain.ScopeTenant, TenantKey: "acme", Type: domain.TypeCRM, Category: "crm.deal"},
+			want:  map[string][]string{"acme": {"u1", "u2", "u5"}},
+		},
+		{
+			name:  "mute of another type ignored",
+			input: domain.FanoutInput{TargetScope: domain.ScopeRole, TargetID: "MANAGER", TenantKey: "acme", Type: domain.TypeWorkflow},
+			want:  map[string][]string{"acme": {"u2", "u4"}},
+		},
+		{
+			name:  "zero recipients",
+			input: domain.FanoutInput{TargetScope: domain.ScopeRole, TargetID: "AUDITOR", TenantKey: "acme"},
+			want:  map[string][]string{},
+		},
+		{
+			name:    "user without id",
+			input:   domain.FanoutInput{TargetScope: domain.ScopeUser, TenantKey: "acme"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown scope",
+			input:   domain.FanoutInput{TargetScope: "DEPARTMENT", TargetID: "x", TenantKey: "acme"},
+			wantErr: true,
+		},
+		{
+			name:    "no target",
+			input:   domain.FanoutInput{TenantKey: "acme"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := testsupport.NewRepository()
+			s := NewService(repo, testsupport.NewHub(), fanoutResolver(), WithPreferences(prefs), WithFanout(2, nil))
+			in := tt.input
+			if in.Type == "" {
+				in.Type = domain.TypeSystem
+			}
+			in.Title, in.SourceEventID = "hello", "evt-"+tt.name
+
+			err := s.Fanout(context.Background(), in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				if n := len(repo.Notifications()); n != 0 {
+					t.Fatalf("stored %d notifications on error", n)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := recipients(repo.Notifications())
+			if !maps.EqualFunc(got, tt.want, slices.Equal) {
+				t.Fatalf("recipients = %v, want %v", got, tt.want)
+			}
+			var written int
+			for _, n := range repo.BatchSizes() {
+				if n > 2 {
+					t.Fatalf("chunk of %d rows exceeds the chunk size", n)
+				}
+				written += n
+			}
+			if total := countUsers(tt.want); written != total || repo.OutboxLen() != total {
+				t.Fatalf("wrote %d rows and %d outbox entries, want %d", written, repo.OutboxLen(), total)
+			}
+		})
+	}
+}
+
+func TestFanoutStats(t *testing.T) {
+	s := NewService(testsupport.NewRepository(), testsupport.NewHub(), fanoutResolver(), WithFanout(2, nil))
+	in := domain.FanoutInput{TargetScope: domain.ScopeTenant, TenantKey: "acme", Type: domain.TypeSystem, Title: "hello"}
+	if err := s.Fanout(context.Background(), in); err != nil {
+		t.Fatal(err)
+	}
+
+	// Five users in chunks of two.
+	got := s.FanoutStats()
+	if got.ChunkSize != 2 || got.Fanouts != 1 || got.Chunks != 3 || got.Rows != 5 || got.Inserted != 5 {
+		t.Fatalf("stats = %+v", got)
+	}
+	if got.ChunkLatency.Count != 3 || got.FanoutLatency.Count != 1 {
+		t.Fatalf("latency counts = %d chunks, %d fan-outs; want 3 and 1", got.ChunkLatency.Count, got.FanoutLatency.Count)
+	}
+
+
+	failing := NewService(failingBatchRepo{testsupport.NewRepository()}, testsupport.NewHub(), fanoutResolver(), WithFanout(2, nil))
+	if err := failing.Fanout(context.Background(), in); err == nil {
+		t.Fatal("expected write failure")
+	}
+	if got := failing.FanoutStats().FailedFanouts; got != 1 {
+		t.Fatalf("failed fan-outs = %d, want 1", got)
+	}
+
+	s.SetFanoutChunkSize(0)
+	if got := s.FanoutStats().ChunkSize; got != DefaultFanoutChunkSize {
+		t.Fatalf("chunk size after reset = %d, want %d", got, DefaultFanoutChunkSize)
+	}
+}
+
+func TestFanout_RetryIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	repo, hub := testsupport.NewRepository(), testsupport.NewHub()
+	s := NewService(repo, hub, fanoutResolver(), WithFanout(2, nil))
+	in := domain.FanoutInput{TargetScope: domain.ScopeTenant, TenantKey: "acme", Type: domain.TypeSystem,
+		Title: "maintenance", SourceEventID: "evt-1"}
+
+	for range 2 {
+		if err := s.Fanout(ctx, in); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(repo.Notifications()); n != 5 {
+		t.Fatalf("stored %d notifications after a retry, want 5", n)
+	}
+	if got := s.FanoutStats().Duplicates; got != 5 {
+		t.Fatalf("duplicates = %d, want 5", got)
+	}
+
+	if claimed := s.dispatchOutbox(ctx, OutboxConfig{BatchSize: 10, Lease: time.Minute}); claimed != 5 {
+		t.Fatalf("dispatched %d outbox entries, want 5", claimed)
+	}
+	if pushes := hub.Pushes(); len(pushes) != 5 {
+		t.Fatalf("pushed %d notifications, want 5", len(pushes))
+	}
+	if repo.OutboxLen() != 0 {
+		t.Fatalf("%d outbox entries left after dispatch", repo.OutboxLen())
+	}
+}
+
+func TestFanout_OnRead(t *testing.T) {
+	ctx := context.Background()
+	repo := testsupport.NewRepository()
+	s := NewService(repo, testsupport.NewHub(), fanoutResolver(),
+		WithFanout(0, map[domain.TargetScope]domain.FanoutStrategy{domain.ScopeTenant: domain.FanoutOnRead}))
+	in := domain.FanoutInput{TargetScope: domain.ScopeTenant, TenantKey: "acme", Type: domain.TypeSystem,
+		Title: "maintenance", SourceEventID: "evt-1"}
+
+	if err := s.Fanout(ctx, in); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.Notifications()) != 0 || len(repo.Broadcasts()) != 1 {
+		t.Fatalf("stored %d rows and %d broadcasts, want one broadcast", len(repo.Notifications()), len(repo.Broadcasts()))
+	}
+	if unread, _ := repo.CountUnread(ctx, "acme", "u1"); unread != 1 {
+		t.Fatalf("unread = %d, want 1", unread)
+	}
+
+	// A composite input needs per-user rows even when the scope fans out on read.
+	in.SourceEventID = "evt-2"
+	in.Exclude = []domain.FanoutTarget{{Scope: domain.ScopeUser, ID: "u1"}}
+	if err := s.Fanout(ctx, in); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(repo.Notifications()); n != 4 {
+		t.Fatalf("stored %d rows for a composite input, want 4", n)
+	}
+}
+
+func TestFanout_OnReadPlatform(t *testing.T) {
+	ctx := context.Background()
+	repo := testsupport.NewRepository()
+	hub := testsupport.NewHub()
+	s := NewService(repo, hub, fanoutResolver())
+	s.SetFanoutStrategy(domain.ScopePlatform, domain.FanoutOnRead)
+	s.SetFanoutStrategy(domain.ScopeRole, domain.FanoutOnRead) // ignored: only TENANT and PLATFORM
+	in := domain.FanoutInput{TargetScope: domain.ScopePlatform, Type: domain.TypeSystem, Title: "release", SourceEventID: "evt-1"}
+
+	if err := s.Fanout(ctx, in); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return len(hub.Pushes()) == 1 })
+	if p := hub.Pushes()[0]; p.TenantKey != "" || p.Notification.Title != "release" {
+		t.Fatalf("push = %+v, want one to every tenant", p)
+	}
+
+	// A redelivered event stores and pushes nothing.
+	if err := s.Fanout(ctx, in); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.Broadcasts()) != 1 || len(repo.Notifications()) != 0 {
+		t.Fatalf("stored %d broadcasts and %d rows, want one broadcast", len(repo.Broadcasts()), len(repo.Notifications()))
+	}
+
+	in = domain.FanoutInput{TargetScope: domain.ScopeRole, TargetID: "MANAGER", TenantKey: "acme", Type: domain.TypeSystem, Title: "review"}
+	if err := s.Fanout(ctx, in); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(repo.Notifications()); n != 2 {
+		t.Fatalf("stored %d rows for a ROLE fan-out, want 2", n)
+	}
+}
+
+func TestFanout_ResolverError(t *testing.T) {
+	resolver := fanoutResolver()
+	resolver.SetError(errors.New("keycloak unavailable"))
+	repo := testsupport.NewRepository()
+	s := NewService(repo, nil, resolver)
+
+	err := s.Fanout(context.Background(), domain.FanoutInput{TargetScope: domain.ScopeTenant, TenantKey: "acme", Type: domain.TypeSystem})
+	if err == nil {
+		t.Fatal("expected the resolver error")
+	}
+	if len(repo.Notifications()) != 0 {
+		t.Fatal("stored notifications despite the resolver error")
+	}
+}
+
+func TestResolveScope(t *testing.T) {
+	ctx := context.Background()
+	prefs := testsupport.NewPreferences()
+	prefs.Mute("acme", "u4", domain.TypeSystem, "")
+	repo := testsupport.NewRepository()
+	s := NewService(repo, testsupport.NewHub(), fanoutResolver(), WithPreferences(prefs))
+
+	res, err := s.ResolveScope(ctx, ScopeResolveInput{TargetScope: "PLATFORM", SampleSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.TotalRecipients != 7 || res.Tenants["acme"].Recipients != 5 || res.Tenants["globex"].Recipients != 2 ||
+		len(res.Tenants["acme"].Sample) != 1 {
+		t.Fatalf("PLATFORM = %+v", res)
+	}
+
+	res, err = s.ResolveScope(ctx, ScopeResolveInput{TargetScope: "ROLE", TargetID: "MANAGER", TenantKey: "acme",
+		Type: "SYSTEM", ApplyPreferences: true, SampleSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.TotalRecipients != 1 || res.MutedRecipients != 1 || !slices.Equal(res.Tenants["acme"].Sample, []string{"u2"}) {
+		t.Fatalf("ROLE with preferences = %+v", res)
+	}
+	if len(res.Tenants) != 1 {
+		t.Fatalf("a tenant scope reached %d tenants", len(res.Tenants))
+	}
+
+	if _, err := s.ResolveScope(ctx, ScopeResolveInput{TargetScope: "NOPE", TenantKey: "acme"}); err == nil {
+		t.Fatal("resolved an unknown scope")
+	}
+	if n := len(repo.Notifications()); n != 0 {
+		t.Fatalf("a dry run stored %d notifications", n)
+	}
+}
+
+// recipients groups the users of ns by tenant, sorted.
+func recipients(ns []*domain.Notification) map[string][]string {
+	out := make(map[string][]string)
+	for _, n := range ns {
+		out[n.TenantKey] = append(out[n.TenantKey], n.UserID)
+	}
+	for _, uids := range out {
+		slices.Sort(uids)
+	}
+	return out
+}
+
+// failingBatchRepo rejects every batch insert so fan-out write failures can be
+// exercised.
+type failingBatchRepo struct {
+	*testsupport.Repository
+}
+
+func (failingBatchRepo) BatchCreate(context.Context, []domain.CreateNotificationInput) (domain.BatchResult, error) {
+	return domain.BatchResult{}, errors.New("insert failed")
+}
@@ -1,6 +1,10 @@
 package application
 
-import "context"
+import (
+	"context"
+
+	"vn.io.arda/notification/internal/metrics"
+)
 
 // IAMResolver resolves a TargetScope to a concrete list of (tenantKey, userID) pairs.
 // The default implementation calls Keycloak Admin REST API.
@@ -12,7 +16,26 @@ type IAMResolver interface {
 	// UsersByRole returns user IDs that hold roleName within a tenant realm.
 	UsersByRole(ctx context.Context, tenantKey, roleName string) ([]string, error)
 
+	// UsersByGroup returns user IDs that are direct members of a group within a tenant realm.
+	// group is a group ID, or a group path when it starts with "/".
+	UsersByGroup(ctx context.Context, tenantKey, group string) ([]string, error)
+
 	// AllActiveUsers returns active users grouped by tenantKey across all tenants.
 	// Used for PLATFORM-scope fan-out.
 	AllActiveUsers(ctx context.Context) (map[string][]string, error)
 }
+
+// IAMCacheReporter is implemented by resolvers that cache lookups.
+type IAMCacheReporter interface {
+	CacheStats() metrics.CacheReport
+}
+
+// IAMCacheStats returns the resolver's cache metrics; ok is false when the
+// configured resolver does not cache.
+func (s *Service) IAMCacheStats() (report metrics.CacheReport, ok bool) {
+	r, ok := s.resolver.(IAMCacheReporter)
+	if !ok {
+		return metrics.CacheReport{}, false
+	}
+	return r.CacheStats(), true
+}
@@ -0,0 +1,73 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"vn.io.arda/notification/internal/domain"
+	"vn.io.arda/notification/internal/testsupport"
+)
+
+// recordingAlerter records the alerts raised.
+type recordingAlerter struct{ alerts []domain.Alert }
+
+func (a *recordingAlerter) Alert(_ context.Context, alert domain.Alert) error {
+	a.alerts = append(a.alerts, alert)
+	return nil
+}
+
+// failingEvictRepo fails every mailbox cap eviction.
+type failingEvictRepo struct {
+	*testsupport.Repository
+}
+
+func (failingEvictRepo) EvictOverCap(context.Context, int, int) (int64, error) {
+	return 0, errors.New("statement timeout")
+}
+
+func TestEvictOverCap(t *testing.T) {
+	ctx := context.Background()
+	repo := testsupport.NewRepository()
+	alerts := &recordingAlerter{}
+	s := NewService(repo, testsupport.NewHub(), testsupport.NewResolver(), WithAlerter(alerts))
+	var ns []*domain.Notification
+	for range 5 {
+		n, err := s.Create(ctx, domain.CreateNotificationInput{TenantKey: "acme", UserID: "u1", Type: domain.TypeSystem, Title: "t"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ns = append(ns, n)
+	}
+	// The three oldest are read; the oldest is pinned, so only the next two can go.
+	for _, n := range ns[:3] {
+		if err := s.MarkRead(ctx, n.ID.String(), "acme", "u1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.SetPinned(ctx, ns[0].ID.String(), "acme", "u1", true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Create(ctx, domain.CreateNotificationInput{TenantKey: "acme", UserID: "u2", Type: domain.TypeSystem, Title: "t"}); err != nil {
+		t.Fatal(err)
+	}
+
+	s.EvictOverCap(ctx, 2, 100)
+	for _, n := range repo.Notifications() {
+		if n.ID == ns[1].ID || n.ID == ns[2].ID {
+			t.Fatalf("read notification %s was not evicted", n.ID)
+		}
+	}
+	if n := len(repo.Notifications()); n != 4 {
+		t.Fatalf("%d notifications left, want u1's pinned and unread ones and u2's", n)
+	}
+	if len(alerts.alerts) != 1 || !alerts.alerts[0].Resolved {
+		t.Fatalf("alerts = %+v, want the job marked healthy", alerts.alerts)
+	}
+
+	failing := NewService(failingEvictRepo{testsupport.NewRepository()}, nil, nil, WithAlerter(alerts))
+	failing.EvictOverCap(ctx, 2, 100)
+	if a := alerts.alerts[len(alerts.alerts)-1]; a.Resolved || a.Key != "job."+jobMailboxCap || a.Severity != domain.AlertCritical {
+		t.Fatalf("alert = %+v, want a critical mailbox cap failure", a)
+	}
+}
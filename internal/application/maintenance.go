@@ -0,0 +1,237 @@
+package application
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// maxDigestLines bounds the titles listed in a maintenance digest.
+const maxDigestLines = 20
+
+// metadataMaintenanceKey carries the window ID and count of a maintenance digest.
+const metadataMaintenanceKey = "maintenance"
+
+// SetMaintenance enables maintenance windows.
+func (s *Service) SetMaintenance(repo domain.MaintenanceRepository) {
+	s.maintenance = repo
+}
+
+// holdForMaintenance suppresses or holds a SYSTEM fan-out during an active
+// maintenance window of its tenant and reports whether it did. URGENT
+// notifications always go through. A failed window lookup delivers normally.
+func (s *Service) holdForMaintenance(ctx context.Context, input domain.FanoutInput) (bool, error) {
+	if s.maintenance == nil || input.Type != domain.TypeSystem || input.Priority == domain.PriorityUrgent {
+		return false, nil
+	}
+	w, err := s.maintenance.Active(ctx, input.TenantKey, s.clock.Now())
+	if err != nil {
+		log.Warn().Err(err).Str("tenant", input.TenantKey).Msg("failed to check maintenance windows, delivering")
+		return false, nil
+	}
+	if w == nil {
+		return false, nil
+	}
+	if w.Mode == domain.MaintenanceBatch {
+		if err := s.maintenance.Hold(ctx, w.ID, input); err != nil {
+			return false, err
+		}
+	}
+	s.Trace(ctx, input.SourceEventID, domain.TraceMaintenance, map[string]any{"window_id": w.ID, "mode": w.Mode})
+	log.Debug().Str("tenant", input.TenantKey).Str("window", w.ID.String()).Str("mode", string(w.Mode)).
+		Str("source_event_id", input.SourceEventID).Msg("fan-out held by maintenance window")
+	return true, nil
+}
+
+// FlushMaintenanceWindows delivers the digests of batch windows that have ended.
+// Held notifications are grouped by audience: a single one is delivered as it
+// was sent, several become one digest listing their titles.
+func (s *Service) FlushMaintenanceWindows(ctx context.Context) {
+	if s.maintenance == nil {
+		return
+	}
+	ended, err := s.maintenance.ListEnded(ctx, s.clock.Now())
+	if err != nil {
+		log.Error().Err(err).Msg("failed to list ended maintenance windows")
+		s.jobFailed(ctx, jobMaintenanceFlush, err)
+		return
+	}
+	var failed error
+	for _, w := range ended {
+		if err := s.flushMaintenanceWindow(ctx, w); err != nil {
+			log.Error().Err(err).Str("window", w.ID.String()).Msg("failed to flush maintenance window")
+			failed = err
+		}
+	}
+	if failed != nil {
+		s.jobFailed(ctx, jobMaintenanceFlush, failed)
+		return
+	}
+	s.jobSucceeded(ctx, jobMaintenanceFlush)
+}
+
+// flushMaintenanceWindow delivers a window's digests, then marks it flushed.
+// Digest source event IDs are derived from the window and audience, so a flush
+// retried after a failure does not deliver twice.
+func (s *Service) flushMaintenanceWindow(ctx context.Context, w domain.MaintenanceWindow) error {
+	held, err := s.maintenance.Held(ctx, w.ID)
+	if err != nil {
+		return err
+	}
+	var order []string
+	groups := make(map[string][]domain.FanoutInput)
+	for _, in := range held {
+		key := audienceKey(in)
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], in)
+	}
+	for _, key := range order {
+		if err := s.Fanout(ctx, maintenanceDigest(w, key, groups[key])); err != nil {
+			return err
+		}
+	}
+	log.Info().Str("window", w.ID.String()).Int("held", len(held)).Int("digests", len(order)).Msg("maintenance window flushed")
+	return s.maintenance.MarkFlushed(ctx, w.ID)
+}
+
+// audienceKey identifies the recipients of a fan-out input.
+func audienceKey(in domain.FanoutInput) string {
+	key, _ := json.Marshal(struct {
+		TenantKey    string
+		Targets      []domain.FanoutTarget
+		Exclude      []domain.FanoutTarget
+		OriginUserID string
+	}{in.TenantKey, in.AllTargets(), in.Exclude, in.OriginUserID})
+	return string(key)
+}
+
+// maintenanceDigest returns the input delivering held to their audience.
+func maintenanceDigest(w domain.MaintenanceWindow, audience string, held []domain.FanoutInput) domain.FanoutInput {
+	if len(held) == 1 {
+		return held[0]
+	}
+	first := held[0]
+	lines := make([]string, 0, min(len(held), maxDigestLines)+1)
+	for i, in := range held {
+		if i == maxDigestLines {
+			lines = append(lines, fmt.Sprintf("…and %d more", len(held)-maxDigestLines))
+			break
+		}
+		title := in.Title
+		if title == "" && in.Template != nil {
+			title = in.Template.Key
+		}
+		lines = append(lines, "- "+title)
+	}
+	sum := sha256.Sum256([]byte(audience))
+	return domain.FanoutInput{
+		TargetScope:  first.TargetScope,
+		TargetID:     first.TargetID,
+		Targets:      first.Targets,
+		Exclude:      first.Exclude,
+		TenantKey:    first.TenantKey,
+		OriginUserID: first.OriginUserID,
+		Type:         domain.TypeSystem,
+		Priority:     domain.PriorityNormal,
+		Title:        fmt.Sprintf("%d system notifications during maintenance", len(held)),
+		Body:         strings.Join(lines, "\n"),
+		Metadata: map[string]any{metadataMaintenanceKey: map[string]any{
+			"window_id": w.ID.String(),
+			"count":     len(held),
+		}},
+		SourceEventID: "maintenance:" + w.ID.String() + ":" + hex.EncodeToString(sum[:8]),
+	}
+}
+
+// --- Maintenance window admin ---
+
+func (s *Service) requireMaintenance() error {
+	if s.maintenance == nil {
+		return fmt.Errorf("maintenance windows not configured")
+	}
+	return nil
+}
+
+func validateMaintenanceWindow(w domain.MaintenanceWindow) error {
+	switch {
+	case !w.Mode.Valid():
+		return fmt.Errorf("unknown mode %q: use suppress or batch", w.Mode)
+	case w.StartsAt.IsZero() || w.EndsAt.IsZero():
+		return fmt.Errorf("starts_at and ends_at are required")
+	case !w.EndsAt.After(w.StartsAt):
+		return fmt.Errorf("ends_at must be after starts_at")
+	}
+	return nil
+}
+
+// ListMaintenanceWindows returns the windows affecting tenantKey (its own and
+// those for every tenant), or every window when tenantKey is empty.
+func (s *Service) ListMaintenanceWindows(ctx context.Context, tenantKey string) ([]domain.MaintenanceWindow, error) {
+	if err := s.requireMaintenance(); err != nil {
+		return nil, err
+	}
+	return s.maintenance.List(ctx, tenantKey)
+}
+
+// GetMaintenanceWindow returns a window.
+func (s *Service) GetMaintenanceWindow(ctx context.Context, id uuid.UUID) (*domain.MaintenanceWindow, error) {
+	if err := s.requireMaintenance(); err != nil {
+		return nil, err
+	}
+	w, err := s.maintenance.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if w == nil {
+		return nil, fmt.Errorf("maintenance window not found")
+	}
+	return w, nil
+}
+
+// CreateMaintenanceWindow validates and stores a window starting now unless
+// StartsAt is set.
+func (s *Service) CreateMaintenanceWindow(ctx context.Context, w domain.MaintenanceWindow) (*domain.MaintenanceWindow, error) {
+	if err := s.requireMaintenance(); err != nil {
+		return nil, err
+	}
+	if w.StartsAt.IsZero() {
+		w.StartsAt = s.clock.Now()
+	}
+	if err := validateMaintenanceWindow(w); err != nil {
+		return nil, err
+	}
+	return s.maintenance.Create(ctx, w)
+}
+
+// UpdateMaintenanceWindow changes a window, e.g. ends it early by moving
+// ends_at to now; a batch window's digest follows on the next flush.
+func (s *Service) UpdateMaintenanceWindow(ctx context.Context, w domain.MaintenanceWindow) (*domain.MaintenanceWindow, error) {
+	if err := s.requireMaintenance(); err != nil {
+		return nil, err
+	}
+	if err := validateMaintenanceWindow(w); err != nil {
+		return nil, err
+	}
+	return s.maintenance.Update(ctx, w)
+}
+
+// DeleteMaintenanceWindow removes a window and discards what it holds.
+func (s *Service) DeleteMaintenanceWindow(ctx context.Context, idStr string) error {
+	if err := s.requireMaintenance(); err != nil {
+		return err
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return fmt.Errorf("invalid window id: %w", err)
+	}
+	return s.maintenance.Delete(ctx, id)
+}
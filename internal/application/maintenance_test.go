@@ -0,0 +1,85 @@
+package application
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"vn.io.arda/notification/internal/domain"
+	"vn.io.arda/notification/internal/testsupport"
+)
+
+func TestMaintenanceWindows(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 3, 1, 1, 0, 0, 0, time.UTC)
+	clock := domain.NewManualClock(start)
+	repo := testsupport.NewRepository()
+	s := NewService(repo, testsupport.NewHub(), fanoutResolver(),
+		WithMaintenance(testsupport.NewMaintenance()), WithClock(clock))
+
+	if _, err := s.CreateMaintenanceWindow(ctx, domain.MaintenanceWindow{TenantKey: "acme", Mode: "pause",
+		EndsAt: start.Add(time.Hour)}); err == nil {
+		t.Fatal("accepted an unknown mode")
+	}
+	if _, err := s.CreateMaintenanceWindow(ctx, domain.MaintenanceWindow{TenantKey: "acme", Mode: domain.MaintenanceBatch,
+		EndsAt: start.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateMaintenanceWindow(ctx, domain.MaintenanceWindow{TenantKey: "globex", Mode: domain.MaintenanceSuppress,
+		EndsAt: start.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+
+	send := func(id string, in domain.FanoutInput) {
+		t.Helper()
+		in.SourceEventID, in.Title = id, "tenant status "+id
+		if in.Type == "" {
+			in.Type = domain.TypeSystem
+		}
+		if err := s.Fanout(ctx, in); err != nil {
+			t.Fatal(err)
+		}
+	}
+	acmeAll := domain.FanoutInput{TargetScope: domain.ScopeTenant, TenantKey: "acme"}
+	send("evt-1", acmeAll)
+	send("evt-2", acmeAll)
+	send("evt-3", domain.FanoutInput{TargetScope: domain.ScopeRole, TargetID: "MANAGER", TenantKey: "acme"})
+	send("evt-4", domain.FanoutInput{TargetScope: domain.ScopeTenant, TenantKey: "globex"})
+	if n := len(repo.Notifications()); n != 0 {
+		t.Fatalf("delivered %d notifications during maintenance, want 0", n)
+	}
+
+	// URGENT and non-SYSTEM notifications are not held.
+	send("evt-5", domain.FanoutInput{TargetScope: domain.ScopeUser, TargetID: "u1", TenantKey: "acme", Priority: domain.PriorityUrgent})
+	send("evt-6", domain.FanoutInput{TargetScope: domain.ScopeUser, TargetID: "v1", TenantKey: "globex", Type: domain.TypeCRM})
+	if n := len(repo.Notifications()); n != 2 {
+		t.Fatalf("delivered %d notifications, want the URGENT and CRM ones", n)
+	}
+
+	s.FlushMaintenanceWindows(ctx)
+	if n := len(repo.Notifications()); n != 2 {
+		t.Fatal("flushed a window before it ended")
+	}
+
+	clock.Advance(time.Hour)
+	s.FlushMaintenanceWindows(ctx)
+	s.FlushMaintenanceWindows(ctx)
+	var digests, single int
+	for _, n := range repo.Notifications()[2:] {
+		switch {
+		case strings.HasPrefix(n.Title, "2 system notifications"):
+			digests++
+			if !strings.Contains(n.Body, "tenant status evt-1") || !strings.Contains(n.Body, "tenant status evt-2") {
+				t.Fatalf("digest body = %q", n.Body)
+			}
+		case n.Title == "tenant status evt-3":
+			single++
+		default:
+			t.Fatalf("unexpected notification %q for %s/%s", n.Title, n.TenantKey, n.UserID)
+		}
+	}
+	if digests != 5 || single != 2 {
+		t.Fatalf("got %d digest and %d single rows, want 5 and 2", digests, single)
+	}
+}
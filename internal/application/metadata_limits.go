@@ -0,0 +1,119 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// ErrMetadataTooLarge is returned by Create and Fanout when the metadata exceeds
+// MetadataLimits.MaxBytes and cannot be truncated to fit.
+var ErrMetadataTooLarge = errors.New("metadata too large")
+
+// MetadataLimits bounds the metadata stored with a notification and sent over SSE.
+type MetadataLimits struct {
+	// MaxBytes caps the JSON size of the metadata; 0 means no cap.
+	MaxBytes int
+	// Truncate repairs metadata instead of rejecting it: invalid well-known keys
+	// and then the largest free-form keys are dropped until it fits. The dropped
+	// keys are listed under domain.MetadataTruncated.
+	Truncate bool
+}
+
+// SetMetadataLimits enables the metadata checks of Create and Fanout.
+func (s *Service) SetMetadataLimits(l MetadataLimits) {
+	s.metadataLimits = &l
+}
+
+// checkMetadata validates meta against the well-known key schema and the size
+// cap, returning the metadata to store.
+func (s *Service) checkMetadata(ctx context.Context, sourceEventID string, meta map[string]any) (map[string]any, error) {
+	l := s.metadataLimits
+	if l == nil || len(meta) == 0 {
+		return meta, nil
+	}
+	var dropped []string
+	if errs := domain.MetadataErrors(meta); errs != nil {
+		if !l.Truncate {
+			return nil, domain.ValidateMetadata(meta)
+		}
+		meta = cloneMetadata(meta)
+		for key := range errs {
+			delete(meta, key)
+			dropped = append(dropped, key)
+		}
+	}
+
+	size, err := domain.MetadataSize(meta)
+	if err != nil {
+		return nil, err
+	}
+	if l.MaxBytes > 0 && size > l.MaxBytes {
+		if !l.Truncate {
+			return nil, fmt.Errorf("%w: %d bytes, limit %d", ErrMetadataTooLarge, size, l.MaxBytes)
+		}
+		if dropped == nil {
+			meta = cloneMetadata(meta)
+		}
+		if dropped, err = truncateMetadata(meta, dropped, l.MaxBytes); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(dropped) > 0 {
+		sort.Strings(dropped)
+		meta[domain.MetadataTruncated] = dropped
+		log.Warn().Str("source_event_id", sourceEventID).Str("topic", sourceTopic(ctx)).
+			Int("bytes", size).Strs("dropped", dropped).Msg("notification metadata truncated")
+	}
+	return meta, nil
+}
+
+// truncateMetadata drops the largest free-form keys of meta until it fits in
+// maxBytes, counting the list of dropped keys stored with it.
+func truncateMetadata(meta map[string]any, dropped []string, maxBytes int) ([]string, error) {
+	type entry struct {
+		key  string
+		size int
+	}
+	var free []entry
+	for k, v := range meta {
+		if domain.IsSystemMetadataKey(k) {
+			continue
+		}
+		size, err := domain.MetadataSize(map[string]any{k: v})
+		if err != nil {
+			return nil, err
+		}
+		free = append(free, entry{k, size})
+	}
+	sort.Slice(free, func(i, j int) bool { return free[i].size > free[j].size })
+
+	for _, e := range free {
+		delete(meta, e.key)
+		dropped = append(dropped, e.key)
+		meta[domain.MetadataTruncated] = dropped
+		size, err := domain.MetadataSize(meta)
+		delete(meta, domain.MetadataTruncated)
+		if err != nil {
+			return nil, err
+		}
+		if size <= maxBytes {
+			return dropped, nil
+		}
+	}
+	size, _ := domain.MetadataSize(meta)
+	return nil, fmt.Errorf("%w: %d bytes without free-form keys, limit %d", ErrMetadataTooLarge, size, maxBytes)
+}
+
+func cloneMetadata(meta map[string]any) map[string]any {
+	out := make(map[string]any, len(meta)+1)
+	for k, v := range meta {
+		out[k] = v
+	}
+	return out
+}
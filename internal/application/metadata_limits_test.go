@@ -0,0 +1,51 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"vn.io.arda/notification/internal/domain"
+)
+
+func TestCheckMetadata(t *testing.T) {
+	ctx := context.Background()
+	meta := map[string]any{
+		"link":     "/crm/deals/D-1",
+		"icon":     "deal",
+		"profile":  strings.Repeat("x", 300),
+		"history":  strings.Repeat("y", 200),
+		"customer": "An",
+	}
+
+	s := &Service{}
+	s.SetMetadataLimits(MetadataLimits{MaxBytes: 256, Truncate: true})
+	got, err := s.checkMetadata(ctx, "evt-1", meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dropped, _ := got[domain.MetadataTruncated].([]string); !slices.Equal(dropped, []string{"history", "profile"}) {
+		t.Fatalf("truncated = %v", got[domain.MetadataTruncated])
+	}
+	if got["link"] != "/crm/deals/D-1" || got["customer"] != "An" || len(meta) != 5 {
+		t.Fatalf("checkMetadata = %v (input %d keys)", got, len(meta))
+	}
+	if size, _ := domain.MetadataSize(got); size > 256 {
+		t.Fatalf("size = %d", size)
+	}
+
+	got, err = s.checkMetadata(ctx, "evt-2", map[string]any{"link": "ftp://files", "customer": "An"})
+	if err != nil || got["link"] != nil || !slices.Equal(got[domain.MetadataTruncated].([]string), []string{"link"}) {
+		t.Fatalf("invalid link: %v, %v", got, err)
+	}
+
+	s.SetMetadataLimits(MetadataLimits{MaxBytes: 256})
+	if _, err := s.checkMetadata(ctx, "evt-3", meta); !errors.Is(err, ErrMetadataTooLarge) {
+		t.Fatalf("reject oversized: %v", err)
+	}
+	if _, err := s.checkMetadata(ctx, "evt-4", map[string]any{"link": "ftp://files"}); !errors.Is(err, domain.ErrInvalidMetadata) {
+		t.Fatalf("reject invalid: %v", err)
+	}
+}
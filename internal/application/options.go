@@ -0,0 +1,280 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// Option configures an optional Service collaborator. Options are applied in
+// order by NewService; each has a Set method for wiring after construction.
+type Option func(*Service)
+
+// WithPreferences enables per-user channel preferences. Without it every user
+// receives in-app notifications and no email.
+func WithPreferences(repo domain.PreferenceRepository) Option {
+	return func(s *Service) { s.prefRepo = repo }
+}
+
+// WithReactions enables acknowledge/reject reactions.
+func WithReactions(repo domain.ReactionRepository) Option {
+	return func(s *Service) { s.reactionRepo = repo }
+}
+
+// WithReactionPublisher emits reactions to the originating service.
+func WithReactionPublisher(p domain.ReactionPublisher) Option {
+	return func(s *Service) { s.SetReactionPublisher(p) }
+}
+
+// WithActions enables recording the action buttons users choose.
+func WithActions(repo domain.ActionRepository) Option {
+	return func(s *Service) { s.actionRepo = repo }
+}
+
+// WithActionPublisher emits chosen command actions to the originating service.
+func WithActionPublisher(p domain.ActionPublisher) Option {
+	return func(s *Service) { s.SetActionPublisher(p) }
+}
+
+// WithEmailSender enables the email channel.
+func WithEmailSender(sender domain.EmailSender) Option {
+	return func(s *Service) { s.emailSender = sender }
+}
+
+// WithTemplateEngine enables tenant templates; mode is domain.TemplateModeWrite or TemplateModeRead.
+func WithTemplateEngine(engine *TemplateEngine, mode string) Option {
+	return func(s *Service) {
+		s.templateEngine = engine
+		s.SetTemplateMode(mode)
+	}
+}
+
+// WithRollouts enables staged PLATFORM broadcasts.
+func WithRollouts(repo domain.RolloutRepository) Option {
+	return func(s *Service) { s.SetRolloutRepo(repo) }
+}
+
+// WithFanout sets the insert chunk size and the per-scope fan-out strategies.
+func WithFanout(chunkSize int, strategies map[domain.TargetScope]domain.FanoutStrategy) Option {
+	return func(s *Service) {
+		s.SetFanoutChunkSize(chunkSize)
+		for scope, strategy := range strategies {
+			s.SetFanoutStrategy(scope, strategy)
+		}
+	}
+}
+
+// WithCustomTypes enables per-tenant custom notification types.
+func WithCustomTypes(repo domain.CustomTypeRepository) Option {
+	return func(s *Service) { s.SetCustomTypes(repo) }
+}
+
+// WithRateLimit rate limits fan-outs per tenant and producer topic.
+func WithRateLimit(cfg RateLimitConfig) Option {
+	return func(s *Service) { s.SetRateLimit(cfg) }
+}
+
+// WithThrottle enables per-user dedup and per-type throttling.
+func WithThrottle(cfg ThrottleConfig) Option {
+	return func(s *Service) { s.SetThrottle(cfg) }
+}
+
+// WithRecipientCap caps the recipients of a single fan-out.
+func WithRecipientCap(cfg RecipientCapConfig) Option {
+	return func(s *Service) { s.SetRecipientCap(cfg) }
+}
+
+// WithEncryptionKeys enables BYOK encryption key administration.
+func WithEncryptionKeys(repo domain.EncryptionKeyRepository, provider domain.KeyProvider) Option {
+	return func(s *Service) { s.SetEncryptionKeys(repo, provider) }
+}
+
+// WithRedactor redacts sensitive data from metadata before it is stored.
+func WithRedactor(r *Redactor) Option {
+	return func(s *Service) { s.SetRedactor(r) }
+}
+
+// WithMetadataLimits validates and caps the metadata of new notifications.
+func WithMetadataLimits(l MetadataLimits) Option {
+	return func(s *Service) { s.SetMetadataLimits(l) }
+}
+
+// WithKeyRotator enables re-encrypting a tenant's stored content on demand.
+func WithKeyRotator(r domain.KeyRotator) Option {
+	return func(s *Service) { s.SetKeyRotator(r) }
+}
+
+// WithTraceRepo enables per-event processing traces.
+func WithTraceRepo(repo domain.TraceRepository) Option {
+	return func(s *Service) { s.SetTraceRepo(repo) }
+}
+
+// WithStateEvents enables the per-user state stream API.
+func WithStateEvents(repo domain.StateEventRepository) Option {
+	return func(s *Service) { s.SetStateEvents(repo) }
+}
+
+// WithTenantActivity enables idle tenant tracking.
+func WithTenantActivity(repo domain.TenantActivityRepository, idleAfter time.Duration) Option {
+	return func(s *Service) { s.SetTenantActivity(repo, idleAfter) }
+}
+
+// WithPolicyEngine enables per-tenant delivery policies.
+func WithPolicyEngine(repo domain.PolicyRepository, eval domain.PolicyEvaluator, failClosed bool) Option {
+	return func(s *Service) { s.SetPolicyEngine(repo, eval, failClosed) }
+}
+
+// WithWebhooks enables tenant webhooks.
+func WithWebhooks(repo domain.WebhookRepository, sender domain.WebhookSender, cfg WebhookConfig) Option {
+	return func(s *Service) { s.SetWebhooks(repo, sender, cfg) }
+}
+
+// WithNotificationEvents streams notification created/read/deleted events to p.
+func WithNotificationEvents(p domain.NotificationEventPublisher) Option {
+	return func(s *Service) { s.SetNotificationEventPublisher(p) }
+}
+
+// WithChatConnectors enables forwarding to tenant Slack / Teams connectors.
+func WithChatConnectors(repo domain.ChatConnectorRepository, poster domain.ChatPoster) Option {
+	return func(s *Service) { s.SetChatConnectors(repo, poster) }
+}
+
+// WithClock replaces the system clock, e.g. with a domain.ManualClock in tests.
+func WithClock(c domain.Clock) Option {
+	return func(s *Service) { s.SetClock(c) }
+}
+
+// WithEventDefaults enables per-event-type defaults for Kafka events.
+func WithEventDefaults(repo domain.EventDefaultsRepository) Option {
+	return func(s *Service) { s.SetEventDefaults(repo) }
+}
+
+// WithRetentionPolicies enables the retention policy admin API.
+func WithRetentionPolicies(repo domain.RetentionPolicyRepository) Option {
+	return func(s *Service) { s.SetRetentionPolicies(repo) }
+}
+
+// WithStats enables the engagement stats rollup and its admin API.
+func WithStats(repo domain.StatsRepository) Option {
+	return func(s *Service) { s.SetStats(repo) }
+}
+
+// WithQuotas enables per-tenant monthly quotas, with defaults for tenants
+// without their own.
+func WithQuotas(repo domain.QuotaRepository, defaults QuotaConfig) Option {
+	return func(s *Service) { s.SetQuotas(repo, defaults) }
+}
+
+// WithAnnouncements enables tenant and platform banners.
+func WithAnnouncements(repo domain.AnnouncementRepository) Option {
+	return func(s *Service) { s.SetAnnouncements(repo) }
+}
+
+// WithEscalations enables escalation of unread HIGH / URGENT notifications.
+func WithEscalations(repo domain.EscalationRepository) Option {
+	return func(s *Service) { s.SetEscalations(repo) }
+}
+
+// WithMaintenance enables maintenance windows for SYSTEM notifications.
+func WithMaintenance(repo domain.MaintenanceRepository) Option {
+	return func(s *Service) { s.SetMaintenance(repo) }
+}
+
+// WithDirectMessages enables POST /notifications/send with per-tenant rules
+// stored in repo (may be nil) and defaults for tenants without one.
+func WithDirectMessages(repo domain.DirectMessageRuleRepository, defaults domain.DirectMessageRule) Option {
+	return func(s *Service) { s.SetDirectMessages(repo, defaults) }
+}
+
+// WithAudit enables the notification audit log API and delivery entries.
+func WithAudit(repo domain.AuditRepository) Option {
+	return func(s *Service) { s.SetAudit(repo) }
+}
+
+// WithConsumerPauses enables pausing Kafka consumption per topic from the admin API.
+func WithConsumerPauses(repo domain.ConsumerPauseRepository) Option {
+	return func(s *Service) { s.SetConsumerPauses(repo) }
+}
+
+// WithAlerter reports background job failures to operators.
+func WithAlerter(a domain.Alerter) Option {
+	return func(s *Service) { s.SetAlerter(a) }
+}
+
+// WithCounterStore caches unread counts in c.
+func WithCounterStore(c domain.CounterStore) Option {
+	return func(s *Service) { s.SetCounterStore(c) }
+}
+
+// --- No-op defaults ---
+
+// noopHub is the SSE hub of a Service without real-time delivery.
+type noopHub struct{}
+
+func (noopHub) Broadcast(string, string, *domain.Notification)           {}
+func (noopHub) BroadcastEvent(string, string, string, any)               {}
+func (noopHub) BroadcastEventExcept(string, string, string, string, any) {}
+func (noopHub) BroadcastScope(string, *domain.Notification)              {}
+func (noopHub) BroadcastScopeEvent(string, string, any)                  {}
+func (noopHub) IsConnected(string, string) bool                          { return false }
+
+// noopAlerter drops alerts; failures are still logged where they occur.
+type noopAlerter struct{}
+
+func (noopAlerter) Alert(context.Context, domain.Alert) error { return nil }
+
+// noopResolver resolves every scope to no users; USER targets still fan out.
+type noopResolver struct{}
+
+func (noopResolver) UsersByTenant(context.Context, string) ([]string, error)        { return nil, nil }
+func (noopResolver) UsersByRole(context.Context, string, string) ([]string, error)  { return nil, nil }
+func (noopResolver) UsersByGroup(context.Context, string, string) ([]string, error) { return nil, nil }
+func (noopResolver) AllActiveUsers(context.Context) (map[string][]string, error)    { return nil, nil }
+
+// noopPreferences stores nothing, so every user gets the default channels.
+type noopPreferences struct{}
+
+func (noopPreferences) GetByUser(context.Context, string, string) ([]domain.Preference, error) {
+	return nil, nil
+}
+
+func (noopPreferences) GetByUserAndCategory(context.Context, string, string, domain.NotificationType, string) (*domain.Preference, error) {
+	return nil, nil
+}
+
+func (noopPreferences) Upsert(context.Context, domain.Preference) (*domain.Preference, error) {
+	return nil, fmt.Errorf("preferences not configured")
+}
+
+func (noopPreferences) BatchUpsert(context.Context, []domain.Preference) ([]domain.Preference, error) {
+	return nil, fmt.Errorf("preferences not configured")
+}
+
+// noopReactions rejects reactions and lists none.
+type noopReactions struct{}
+
+func (noopReactions) Create(context.Context, domain.Reaction) (*domain.Reaction, error) {
+	return nil, fmt.Errorf("reactions not configured")
+}
+
+func (noopReactions) ListByNotification(context.Context, uuid.UUID) ([]domain.Reaction, error) {
+	return nil, nil
+}
+
+func (noopReactions) ListBySourceEvent(context.Context, string, string) ([]domain.Reaction, error) {
+	return nil, nil
+}
+
+// noopActions rejects chosen actions and lists none.
+type noopActions struct{}
+
+func (noopActions) Create(context.Context, domain.ActionRecord) (*domain.ActionRecord, error) {
+	return nil, fmt.Errorf("actions not configured")
+}
+
+func (noopActions) ListByNotification(context.Context, uuid.UUID) ([]domain.ActionRecord, error) {
+	return nil, nil
+}
@@ -0,0 +1,64 @@
+package application
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"vn.io.arda/notification/internal/domain"
+)
+
+func TestNewServiceNoopDefaults(t *testing.T) {
+	ctx := context.Background()
+	s := NewService(nil, nil, nil)
+
+	got, err := s.resolveTargets(ctx, domain.FanoutInput{
+		TenantKey: "acme",
+		Targets: []domain.FanoutTarget{
+			{Scope: domain.ScopeUser, ID: "u1"},
+			{Scope: domain.ScopeTenant},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"u1"}; !slices.Equal(got["acme"], want) {
+		t.Fatalf("resolveTargets: got %v, want %v", got["acme"], want)
+	}
+
+	kept := s.filterMutedUsers(ctx, got, domain.TypeSystem, "")
+	if !slices.Equal(kept["acme"], got["acme"]) {
+		t.Fatalf("filterMutedUsers without preferences dropped users: %v", kept)
+	}
+	if _, err := s.UpdatePreferences(ctx, "acme", "u1", []PreferenceUpdateInput{{Type: string(domain.TypeSystem)}}); err == nil {
+		t.Fatal("UpdatePreferences without preferences should fail")
+	}
+}
+
+func TestNewServiceAppliesOptions(t *testing.T) {
+	engine := NewTemplateEngine(stubTemplates{}, "vi")
+	s := NewService(nil, nil, nil, WithTemplateEngine(engine, domain.TemplateModeRead), WithFanout(10, nil))
+	if s.templateEngine != engine || s.templateMode != domain.TemplateModeRead || s.chunkSize != 10 {
+		t.Fatalf("options not applied: engine=%v mode=%q chunk=%d", s.templateEngine != nil, s.templateMode, s.chunkSize)
+	}
+}
+
+func TestWithClock(t *testing.T) {
+	clock := domain.NewManualClock(time.Date(2026, 3, 29, 1, 30, 0, 0, time.UTC))
+	s := NewService(nil, nil, nil, WithClock(clock))
+
+	future := clock.Now().Add(time.Minute)
+	if _, err := s.ListAsOf(context.Background(), domain.NotificationFilter{AsOf: &future}, "auditor"); err == nil {
+		t.Fatal("as_of after the service clock should be rejected")
+	}
+	clock.Advance(time.Hour)
+	if _, err := s.ListAsOf(context.Background(), domain.NotificationFilter{AsOf: &future}, "auditor"); err == nil || err.Error() != "user is required" {
+		t.Fatalf("as_of before the service clock: got %v, want user validation error", err)
+	}
+
+	at := clock.Now()
+	if got := compactionSummary(domain.CompactionRun{}, at).Metadata["compacted_at"]; got != at.UTC() {
+		t.Fatalf("compacted_at = %v, want %v", got, at.UTC())
+	}
+}
@@ -0,0 +1,123 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// OutboxConfig tunes the delivery outbox dispatcher.
+type OutboxConfig struct {
+	// PollInterval is the fallback polling period; inserts also wake the dispatcher directly.
+	PollInterval time.Duration
+	// BatchSize is the maximum number of entries claimed per round.
+	BatchSize int
+	// Lease is how long a claimed entry stays invisible to other dispatchers.
+	// Entries not acknowledged within the lease are delivered again.
+	Lease time.Duration
+}
+
+// dispatchTrace aggregates one dispatch round per source event for the trace ledger.
+type dispatchTrace struct {
+	notifications   int
+	sseConnected    int
+	emailCandidates int
+}
+
+// wakeOutbox signals the dispatcher that new entries are available (non-blocking).
+func (s *Service) wakeOutbox() {
+	select {
+	case s.outboxWake <- struct{}{}:
+	default:
+	}
+}
+
+// RunOutboxDispatcher drains the delivery outbox to SSE and email until ctx is cancelled.
+// Delivery is at-least-once: entries are acknowledged only after being pushed.
+func (s *Service) RunOutboxDispatcher(ctx context.Context, cfg OutboxConfig) {
+	ticker := time.NewTicker(cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain until a round comes back short, then wait for a wake-up or tick.
+		for s.dispatchOutbox(ctx, cfg) == cfg.BatchSize {
+		}
+		select {
+		case <-s.outboxWake:
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// dispatchOutbox claims and delivers one batch, returning the number of entries claimed.
+func (s *Service) dispatchOutbox(ctx context.Context, cfg OutboxConfig) int {
+	entries, err := s.repo.ClaimOutbox(ctx, cfg.BatchSize, cfg.Lease)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Error().Err(err).Msg("failed to claim delivery outbox")
+			s.jobFailed(ctx, jobOutboxDispatch, err)
+		}
+		return 0
+	}
+	s.jobSucceeded(ctx, jobOutboxDispatch)
+	if len(entries) == 0 {
+		return 0
+	}
+
+	ns := make([]*domain.Notification, len(entries))
+	for i, e := range entries {
+		ns[i] = e.Notification
+	}
+	s.renderNotifications(ctx, "", ns)
+
+	ids := make([]int64, 0, len(entries))
+	dispatched := make(map[string]*dispatchTrace)
+	var created []domain.WebhookEventInput
+	var delivered []domain.AuditEntry
+	for _, e := range entries {
+		n := e.Notification
+		dt := dispatched[n.SourceEventID]
+		if dt == nil {
+			dt = &dispatchTrace{}
+			dispatched[n.SourceEventID] = dt
+		}
+		dt.notifications++
+		if n.AllowsChannel(domain.ChannelInApp) {
+			if s.hub.IsConnected(n.TenantKey, n.UserID) {
+				dt.sseConnected++
+				delivered = append(delivered, deliveredEntry(n, domain.ChannelInApp))
+			}
+			s.hub.Broadcast(n.TenantKey, n.UserID, n)
+			go s.pushUnreadCount(n.TenantKey, n.UserID)
+		}
+		if n.AllowsChannel(domain.ChannelEmail) {
+			dt.emailCandidates++
+		}
+		go s.sendEmailIfNeeded(context.Background(), n)
+		if s.emitsEvents() {
+			created = append(created, domain.WebhookEventInput{TenantKey: n.TenantKey,
+				Event: domain.WebhookNotificationCreated, Payload: notificationWebhookPayload(n)})
+		}
+		ids = append(ids, e.ID)
+	}
+	for sourceEventID, dt := range dispatched {
+		s.Trace(ctx, sourceEventID, domain.TraceDispatched, map[string]any{
+			"notifications":    dt.notifications,
+			"sse_connected":    dt.sseConnected,
+			"email_candidates": dt.emailCandidates,
+		})
+	}
+
+	s.emitEvents(ctx, created...)
+	s.audit(ctx, delivered...)
+	s.scheduleEscalations(ctx, ns)
+
+	if err := s.repo.AckOutbox(ctx, ids); err != nil {
+		log.Error().Err(err).Int("entries", len(ids)).Msg("failed to ack delivery outbox, entries will be redelivered")
+	}
+	return len(entries)
+}
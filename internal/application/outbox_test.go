@@ -0,0 +1,70 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"vn.io.arda/notification/internal/domain"
+	"vn.io.arda/notification/internal/testsupport"
+)
+
+func TestDispatchOutbox(t *testing.T) {
+	ctx := context.Background()
+	clock := domain.NewManualClock(time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC))
+	repo, hub := testsupport.NewRepository(), testsupport.NewHub()
+	repo.SetClock(clock)
+	s := NewService(repo, hub, testsupport.NewResolver(), WithClock(clock))
+	cfg := OutboxConfig{BatchSize: 2, Lease: time.Minute}
+
+	for _, user := range []string{"u1", "u2", "u3"} {
+		if _, err := s.Create(ctx, domain.CreateNotificationInput{TenantKey: "acme", UserID: user, Type: domain.TypeSystem, Title: "t"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(hub.Pushes()); n != 0 {
+		t.Fatalf("%d pushes before dispatch, want 0: delivery goes through the outbox", n)
+	}
+
+	// A dispatcher that claims an entry and dies before acknowledging it.
+	if _, err := repo.ClaimOutbox(ctx, 1, cfg.Lease); err != nil {
+		t.Fatal(err)
+	}
+	if n := s.dispatchOutbox(ctx, cfg); n != 2 {
+		t.Fatalf("claimed %d entries, want 2 (the batch size)", n)
+	}
+	if n := s.dispatchOutbox(ctx, cfg); n != 0 {
+		t.Fatalf("claimed %d entries while the lease holds, want 0", n)
+	}
+	clock.Advance(cfg.Lease + time.Second)
+	if n := s.dispatchOutbox(ctx, cfg); n != 1 {
+		t.Fatalf("claimed %d entries after the lease expired, want 1", n)
+	}
+
+	users := make(map[string]bool)
+	for _, p := range hub.Pushes() {
+		users[p.UserID] = true
+	}
+	if len(users) != 3 || repo.OutboxLen() != 0 {
+		t.Fatalf("pushed to %v with %d entries left, want every user and an empty outbox", users, repo.OutboxLen())
+	}
+}
+
+func TestRunOutboxDispatcherWakesOnCreate(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	repo, hub := testsupport.NewRepository(), testsupport.NewHub()
+	s := NewService(repo, hub, testsupport.NewResolver())
+	done := make(chan struct{})
+	go func() {
+		s.RunOutboxDispatcher(ctx, OutboxConfig{PollInterval: time.Hour, BatchSize: 10, Lease: time.Minute})
+		close(done)
+	}()
+
+	if _, err := s.Create(ctx, domain.CreateNotificationInput{TenantKey: "acme", UserID: "u1", Type: domain.TypeSystem, Title: "t"}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return len(hub.Pushes()) == 1 && repo.OutboxLen() == 0 })
+	cancel()
+	<-done
+}
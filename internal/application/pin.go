@@ -0,0 +1,26 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"vn.io.arda/notification/internal/domain"
+)
+
+// EventNotificationPinned is pushed to the user's other streams when a notification
+// is pinned or unpinned.
+const EventNotificationPinned = "notification_pinned"
+
+// SetPinned pins or unpins a notification for the user.
+func (s *Service) SetPinned(ctx context.Context, idStr, tenantKey, userID string, pinned bool) error {
+	id, err := domain.ParseID(idStr)
+	if err != nil {
+		return fmt.Errorf("invalid notification id: %w", err)
+	}
+	if err := s.repo.SetPinned(ctx, id, tenantKey, userID, pinned); err != nil {
+		return err
+	}
+	go s.hub.BroadcastEventExcept(tenantKey, userID, originClient(ctx), EventNotificationPinned,
+		map[string]any{"ids": []string{domain.FormatID(id)}, "pinned": pinned})
+	return nil
+}
@@ -0,0 +1,62 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"vn.io.arda/notification/internal/domain"
+	"vn.io.arda/notification/internal/testsupport"
+)
+
+func TestSetPinned(t *testing.T) {
+	ctx := WithOriginClient(context.Background(), "c-1")
+	clock := domain.NewManualClock(domain.SystemClock{}.Now())
+	repo := testsupport.NewRepository()
+	repo.SetClock(clock)
+	hub := testsupport.NewHub()
+	s := NewService(repo, hub, testsupport.NewResolver(), WithClock(clock))
+	var ns []*domain.Notification
+	for _, title := range []string{"old", "new"} {
+		n, err := s.Create(ctx, domain.CreateNotificationInput{TenantKey: "acme", UserID: "u1", Type: domain.TypeSystem, Title: title})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ns = append(ns, n)
+		clock.Advance(time.Minute)
+	}
+
+	if err := s.SetPinned(ctx, ns[0].ID.String(), "acme", "u1", true); err != nil {
+		t.Fatal(err)
+	}
+	list, err := s.List(ctx, domain.NotificationFilter{TenantKey: "acme", UserID: "u1", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].ID != ns[0].ID || !list[0].Pinned {
+		t.Fatalf("list = %v, want the pinned notification first", list)
+	}
+	waitFor(t, func() bool {
+		for _, e := range hub.Events() {
+			if e.Name == EventNotificationPinned && e.ExceptClientID == "c-1" && e.Data.(map[string]any)["pinned"] == true {
+				return true
+			}
+		}
+		return false
+	})
+
+	if err := s.SetPinned(ctx, ns[0].ID.String(), "acme", "u2", true); !errors.Is(err, domain.ErrNotificationNotFound) {
+		t.Fatalf("another user pinned the notification: %v", err)
+	}
+	if err := s.SetPinned(ctx, "not-an-id", "acme", "u1", true); err == nil {
+		t.Fatal("pinned an invalid id")
+	}
+
+	if err := s.SetPinned(ctx, ns[0].ID.String(), "acme", "u1", false); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ = s.List(ctx, domain.NotificationFilter{TenantKey: "acme", UserID: "u1", Limit: 10}); list[0].ID != ns[1].ID {
+		t.Fatal("unpinned notification still listed first")
+	}
+}
@@ -0,0 +1,137 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// SetPolicyEngine enables per-tenant delivery policies evaluated at fan-out time.
+// When failClosed is set, a tenant whose policy cannot be evaluated receives nothing;
+// otherwise delivery proceeds unchanged.
+func (s *Service) SetPolicyEngine(repo domain.PolicyRepository, eval domain.PolicyEvaluator, failClosed bool) {
+	s.policyRepo = repo
+	s.policyEval = eval
+	s.policyFailClosed = failClosed
+}
+
+// applyPolicies evaluates each tenant's delivery policy once per fan-out.
+// Denied tenants are dropped; tenants whose policy narrows the channel set get
+// their own metadata carrying the restriction.
+func (s *Service) applyPolicies(ctx context.Context, input domain.FanoutInput, usersByTenant map[string][]string) (map[string][]string, map[string]map[string]any) {
+	if s.policyRepo == nil || s.policyEval == nil {
+		return usersByTenant, nil
+	}
+
+	allowed := make(map[string][]string, len(usersByTenant))
+	var metadata map[string]map[string]any
+	for tenantKey, userIDs := range usersByTenant {
+		decision, evaluated, err := s.evaluatePolicy(ctx, input, tenantKey, len(userIDs))
+		if err != nil {
+			log.Error().Err(err).
+				Str("tenant", tenantKey).
+				Str("source_event_id", input.SourceEventID).
+				Bool("fail_closed", s.policyFailClosed).
+				Msg("delivery policy evaluation failed")
+			if s.policyFailClosed {
+				continue
+			}
+			allowed[tenantKey] = userIDs
+			continue
+		}
+		if !evaluated {
+			allowed[tenantKey] = userIDs
+			continue
+		}
+
+		// Decision log.
+		log.Info().
+			Str("tenant", tenantKey).
+			Str("source_event_id", input.SourceEventID).
+			Str("type", string(input.Type)).
+			Int("recipients", len(userIDs)).
+			Bool("allow", decision.Allow).
+			Interface("channels", decision.Channels).
+			Str("reason", decision.Reason).
+			Msg("delivery policy decision")
+
+		if !decision.Allow {
+			continue
+		}
+		allowed[tenantKey] = userIDs
+		if decision.Channels != nil {
+			if metadata == nil {
+				metadata = make(map[string]map[string]any)
+			}
+			metadata[tenantKey] = domain.WithChannels(input.Metadata, decision.Channels)
+		}
+	}
+	return allowed, metadata
+}
+
+// evaluatePolicy reports evaluated=false when the tenant has no enabled policy.
+func (s *Service) evaluatePolicy(ctx context.Context, input domain.FanoutInput, tenantKey string, recipients int) (domain.PolicyDecision, bool, error) {
+	p, err := s.policyRepo.Get(ctx, tenantKey)
+	if err != nil {
+		return domain.PolicyDecision{}, false, err
+	}
+	if p == nil || !p.Enabled {
+		return domain.PolicyDecision{}, false, nil
+	}
+	decision, err := s.policyEval.Evaluate(ctx, *p, domain.PolicyInput{
+		TenantKey:     tenantKey,
+		Type:          input.Type,
+		Category:      input.Category,
+		Priority:      input.Priority.OrDefault(),
+		Title:         input.Title,
+		Body:          input.Body,
+		Metadata:      input.Metadata,
+		SourceEventID: input.SourceEventID,
+		TargetScope:   input.TargetScope,
+		TargetID:      input.TargetID,
+		Recipients:    recipients,
+	})
+	if err != nil {
+		return domain.PolicyDecision{}, false, err
+	}
+	return decision, true, nil
+}
+
+// --- Policy Management ---
+
+// ListPolicies returns all stored delivery policies.
+func (s *Service) ListPolicies(ctx context.Context) ([]domain.DeliveryPolicy, error) {
+	if s.policyRepo == nil {
+		return nil, fmt.Errorf("policy engine not configured")
+	}
+	return s.policyRepo.List(ctx)
+}
+
+// GetPolicy returns a tenant's delivery policy, or nil when none exists.
+func (s *Service) GetPolicy(ctx context.Context, tenantKey string) (*domain.DeliveryPolicy, error) {
+	if s.policyRepo == nil {
+		return nil, fmt.Errorf("policy engine not configured")
+	}
+	return s.policyRepo.Get(ctx, tenantKey)
+}
+
+// UpsertPolicy compiles and stores a tenant's delivery policy.
+func (s *Service) UpsertPolicy(ctx context.Context, p domain.DeliveryPolicy) (*domain.DeliveryPolicy, error) {
+	if s.policyRepo == nil || s.policyEval == nil {
+		return nil, fmt.Errorf("policy engine not configured")
+	}
+	if err := s.policyEval.Validate(p.Module); err != nil {
+		return nil, err
+	}
+	return s.policyRepo.Upsert(ctx, p)
+}
+
+// DeletePolicy removes a tenant's delivery policy.
+func (s *Service) DeletePolicy(ctx context.Context, tenantKey string) error {
+	if s.policyRepo == nil {
+		return fmt.Errorf("policy engine not configured")
+	}
+	return s.policyRepo.Delete(ctx, tenantKey)
+}
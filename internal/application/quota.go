@@ -0,0 +1,337 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// ErrQuotaExceeded is returned by Create and Fanout when the tenant is over its
+// monthly notification quota and the notification is not held. The consumer
+// dead-letters such records without retrying.
+var ErrQuotaExceeded = errors.New("monthly notification quota exceeded")
+
+// quotaReleaseBatch bounds the held fan-outs of a tenant released per run.
+const quotaReleaseBatch = 100
+
+// QuotaConfig is the quota of tenants without their own.
+type QuotaConfig struct {
+	MonthlyLimit int64 // 0 = unlimited
+	Overage      domain.QuotaOverage
+}
+
+// SetQuotas enables per-tenant monthly quotas and usage reporting.
+func (s *Service) SetQuotas(repo domain.QuotaRepository, defaults QuotaConfig) {
+	if !defaults.Overage.Valid() {
+		defaults.Overage = domain.QuotaReject
+	}
+	s.quotas = repo
+	s.quotaDefaults = defaults
+}
+
+type quotaReleaseKey struct{}
+
+// monthOf returns the first day of t's month in UTC, the quota period.
+func monthOf(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// effectiveQuota returns the quota of tenantKey, or the default one.
+func (s *Service) effectiveQuota(ctx context.Context, tenantKey string) (domain.TenantQuota, error) {
+	q, err := s.quotas.GetQuota(ctx, tenantKey)
+	if err != nil {
+		return domain.TenantQuota{}, err
+	}
+	if q != nil {
+		return *q, nil
+	}
+	return domain.TenantQuota{TenantKey: tenantKey, MonthlyLimit: s.quotaDefaults.MonthlyLimit, Overage: s.quotaDefaults.Overage}, nil
+}
+
+// monthUsage returns the usage of tenantKey in month.
+func (s *Service) monthUsage(ctx context.Context, tenantKey string, month time.Time) (domain.TenantUsage, error) {
+	usage, err := s.quotas.Usage(ctx, tenantKey, month, month)
+	if err != nil || len(usage) == 0 {
+		return domain.TenantUsage{TenantKey: tenantKey, Month: month}, err
+	}
+	return usage[0], nil
+}
+
+// checkQuota enforces the quota of each tenant of a fan-out to usersByTenant
+// and returns the recipients to deliver now: those of tenants over their quota
+// are held or rejected, the others are delivered. It returns the rejection
+// only when no recipient is left and none was held. PLATFORM fan-outs are sent
+// by the platform, not the tenants, and are never limited. The check is not
+// atomic: fan-outs running at once may together exceed a quota slightly.
+func (s *Service) checkQuota(ctx context.Context, input domain.FanoutInput, usersByTenant map[string][]string) (map[string][]string, error) {
+	if s.quotas == nil || input.TargetScope == domain.ScopePlatform {
+		return usersByTenant, nil
+	}
+	allowed := make(map[string][]string, len(usersByTenant))
+	var (
+		anyHeld  bool
+		rejected error
+	)
+	for tenantKey, userIDs := range usersByTenant {
+		held, err := s.enforceQuota(ctx, input.SourceEventID, tenantKey, len(userIDs), func() error {
+			return s.quotas.Hold(ctx, tenantKey, heldInput(input, tenantKey, userIDs), len(userIDs))
+		})
+		switch {
+		case errors.Is(err, ErrQuotaExceeded):
+			rejected = err
+		case err != nil:
+			return nil, err
+		case held:
+			anyHeld = true
+		default:
+			allowed[tenantKey] = userIDs
+		}
+	}
+	if len(allowed) == 0 && !anyHeld {
+		return nil, rejected
+	}
+	return allowed, nil
+}
+
+// heldInput restricts input to the users of tenantKey, so that releasing it
+// delivers to that tenant only.
+func heldInput(input domain.FanoutInput, tenantKey string, userIDs []string) domain.FanoutInput {
+	held := input
+	held.TenantKey = tenantKey
+	held.TargetScope, held.TargetID = domain.ScopeUser, userIDs[0]
+	held.Targets = make([]domain.FanoutTarget, 0, len(userIDs)-1)
+	for _, uid := range userIDs[1:] {
+		held.Targets = append(held.Targets, domain.FanoutTarget{Scope: domain.ScopeUser, ID: uid})
+	}
+	held.Exclude, held.OriginUserID = nil, ""
+	return held
+}
+
+// enforceQuota checks that tenantKey may create n more notifications this month.
+// Over the quota, the notifications are held with hold under the queue policy
+// (when hold is not nil) and rejected otherwise. A failed quota lookup lets
+// them through.
+func (s *Service) enforceQuota(ctx context.Context, sourceEventID, tenantKey string, n int, hold func() error) (bool, error) {
+	month := monthOf(s.clock.Now())
+	q, err := s.effectiveQuota(ctx, tenantKey)
+	if err != nil {
+		log.Warn().Err(err).Str("tenant", tenantKey).Msg("failed to read tenant quota, delivering")
+		return false, nil
+	}
+	if q.MonthlyLimit == 0 {
+		return false, nil
+	}
+	usage, err := s.monthUsage(ctx, tenantKey, month)
+	if err != nil {
+		log.Warn().Err(err).Str("tenant", tenantKey).Msg("failed to read tenant usage, delivering")
+		return false, nil
+	}
+	if usage.Created+int64(n) <= q.MonthlyLimit {
+		return false, nil
+	}
+
+	exceeded := fmt.Errorf("%w: tenant %s has created %d of %d notifications this month, %d more requested",
+		ErrQuotaExceeded, tenantKey, usage.Created, q.MonthlyLimit, n)
+	if releasing, _ := ctx.Value(quotaReleaseKey{}).(bool); releasing {
+		// Still over the quota: stays held.
+		return false, exceeded
+	}
+	details := map[string]any{"tenant": tenantKey, "limit": q.MonthlyLimit, "used": usage.Created, "requested": n}
+	delta := domain.TenantUsage{TenantKey: tenantKey, Month: month}
+	if q.Overage == domain.QuotaQueue && hold != nil {
+		if err := hold(); err != nil {
+			return false, err
+		}
+		delta.Queued = int64(n)
+		details["action"] = "queued"
+	} else {
+		delta.Rejected = int64(n)
+		details["action"] = "rejected"
+	}
+	if err := s.quotas.AddUsage(ctx, delta); err != nil {
+		log.Warn().Err(err).Str("tenant", tenantKey).Msg("failed to record tenant usage")
+	}
+	s.Trace(ctx, sourceEventID, domain.TraceQuotaExceeded, details)
+	log.Warn().Str("tenant", tenantKey).Int64("limit", q.MonthlyLimit).Int64("used", usage.Created).Int("requested", n).
+		Str("action", details["action"].(string)).Str("source_event_id", sourceEventID).Msg("tenant over monthly notification quota")
+	if delta.Queued > 0 {
+		return true, nil
+	}
+	return false, exceeded
+}
+
+// recordUsage adds notifications created per tenant to this month's usage.
+func (s *Service) recordUsage(ctx context.Context, created map[string]int64) {
+	if s.quotas == nil {
+		return
+	}
+	month := monthOf(s.clock.Now())
+	for tenantKey, n := range created {
+		if err := s.quotas.AddUsage(ctx, domain.TenantUsage{TenantKey: tenantKey, Month: month, Created: n}); err != nil {
+			log.Warn().Err(err).Str("tenant", tenantKey).Int64("created", n).Msg("failed to record tenant usage")
+		}
+	}
+}
+
+// createdByTenant counts notifications per tenant.
+func createdByTenant(notifications []*domain.Notification) map[string]int64 {
+	counts := make(map[string]int64)
+	for _, n := range notifications {
+		counts[n.TenantKey]++
+	}
+	return counts
+}
+
+// ReleaseQuotaHeld delivers the fan-outs held over their tenant's quota, oldest
+// first, as far as the quota now allows: after the month changed or the quota
+// was raised.
+func (s *Service) ReleaseQuotaHeld(ctx context.Context) {
+	if s.quotas == nil {
+		return
+	}
+	tenants, err := s.quotas.HeldTenants(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to list tenants with held notifications")
+		s.jobFailed(ctx, jobQuotaRelease, err)
+		return
+	}
+	releaseCtx := context.WithValue(ctx, quotaReleaseKey{}, true)
+	var failed error
+	for _, tenantKey := range tenants {
+		if err := s.releaseQuotaHeld(releaseCtx, tenantKey); err != nil {
+			log.Error().Err(err).Str("tenant", tenantKey).Msg("failed to release held notifications")
+			failed = err
+		}
+	}
+	if failed != nil {
+		s.jobFailed(ctx, jobQuotaRelease, failed)
+		return
+	}
+	s.jobSucceeded(ctx, jobQuotaRelease)
+}
+
+func (s *Service) releaseQuotaHeld(ctx context.Context, tenantKey string) error {
+	held, err := s.quotas.Held(ctx, tenantKey, quotaReleaseBatch)
+	if err != nil {
+		return err
+	}
+	released := 0
+	for _, h := range held {
+		err := s.Fanout(ctx, h.Input)
+		if errors.Is(err, ErrQuotaExceeded) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.quotas.Release(ctx, h.ID); err != nil {
+			return err
+		}
+		released++
+	}
+	if released > 0 {
+		log.Info().Str("tenant", tenantKey).Int("released", released).Msg("notifications held over quota released")
+	}
+	return nil
+}
+
+// --- Quota admin ---
+
+func (s *Service) requireQuotas() error {
+	if s.quotas == nil {
+		return fmt.Errorf("tenant quotas not configured")
+	}
+	return nil
+}
+
+// ListQuotas returns the quotas set for tenants; others use the default.
+func (s *Service) ListQuotas(ctx context.Context) ([]domain.TenantQuota, error) {
+	if err := s.requireQuotas(); err != nil {
+		return nil, err
+	}
+	return s.quotas.ListQuotas(ctx)
+}
+
+// UpsertQuota validates and stores the quota of a tenant.
+func (s *Service) UpsertQuota(ctx context.Context, q domain.TenantQuota) (*domain.TenantQuota, error) {
+	if err := s.requireQuotas(); err != nil {
+		return nil, err
+	}
+	if q.Overage == "" {
+		q.Overage = domain.QuotaReject
+	}
+	switch {
+	case q.TenantKey == "":
+		return nil, fmt.Errorf("tenant is required")
+	case q.MonthlyLimit < 0:
+		return nil, fmt.Errorf("monthly_limit must not be negative (0 = unlimited)")
+	case !q.Overage.Valid():
+		return nil, fmt.Errorf("unknown overage %q: use reject or queue", q.Overage)
+	}
+	return s.quotas.UpsertQuota(ctx, q)
+}
+
+// DeleteQuota removes the quota of a tenant, which falls back to the default.
+func (s *Service) DeleteQuota(ctx context.Context, tenantKey string) error {
+	if err := s.requireQuotas(); err != nil {
+		return err
+	}
+	return s.quotas.DeleteQuota(ctx, tenantKey)
+}
+
+// TenantUsageReport is a tenant's quota and usage.
+type TenantUsageReport struct {
+	TenantKey string             `json:"tenant_key"`
+	Quota     domain.TenantQuota `json:"quota"`
+	// QuotaSource is "tenant" for a quota of its own, "default" otherwise.
+	QuotaSource string `json:"quota_source"`
+	// Current is the usage of this month; Remaining is nil when unlimited.
+	Current   domain.TenantUsage `json:"current"`
+	Remaining *int64             `json:"remaining"`
+	// Held are the fan-outs waiting for quota.
+	Held int64 `json:"held"`
+	// History is the usage of this and the previous months, latest first.
+	History []domain.TenantUsage `json:"history"`
+}
+
+// TenantUsage reports the quota of tenantKey and its usage over the last months
+// (this one included).
+func (s *Service) TenantUsage(ctx context.Context, tenantKey string, months int) (*TenantUsageReport, error) {
+	if err := s.requireQuotas(); err != nil {
+		return nil, err
+	}
+	month := monthOf(s.clock.Now())
+	report := &TenantUsageReport{TenantKey: tenantKey, QuotaSource: "default"}
+	q, err := s.quotas.GetQuota(ctx, tenantKey)
+	if err != nil {
+		return nil, err
+	}
+	if q != nil {
+		report.Quota, report.QuotaSource = *q, "tenant"
+	} else {
+		report.Quota = domain.TenantQuota{TenantKey: tenantKey, MonthlyLimit: s.quotaDefaults.MonthlyLimit, Overage: s.quotaDefaults.Overage}
+	}
+	if report.History, err = s.quotas.Usage(ctx, tenantKey, month.AddDate(0, 1-max(months, 1), 0), month); err != nil {
+		return nil, err
+	}
+	if report.History == nil {
+		report.History = []domain.TenantUsage{}
+	}
+	report.Current = domain.TenantUsage{TenantKey: tenantKey, Month: month}
+	if len(report.History) > 0 && report.History[0].Month.Equal(month) {
+		report.Current = report.History[0]
+	}
+	if report.Quota.MonthlyLimit > 0 {
+		remaining := max(report.Quota.MonthlyLimit-report.Current.Created, 0)
+		report.Remaining = &remaining
+	}
+	if report.Held, err = s.quotas.CountHeld(ctx, tenantKey); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
@@ -0,0 +1,153 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"vn.io.arda/notification/internal/domain"
+	"vn.io.arda/notification/internal/testsupport"
+)
+
+func TestQuotaReject(t *testing.T) {
+	ctx := context.Background()
+	repo := testsupport.NewRepository()
+	quotas := testsupport.NewQuotas()
+	s := NewService(repo, testsupport.NewHub(), fanoutResolver(), WithQuotas(quotas, QuotaConfig{MonthlyLimit: 3}),
+		WithClock(domain.NewManualClock(time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC))))
+
+	fanout := func(id string, scope domain.TargetScope, target string) error {
+		return s.Fanout(ctx, domain.FanoutInput{
+			TenantKey: "acme", TargetScope: scope, TargetID: target, Type: domain.TypeCRM,
+			Title: "t", Body: "b", SourceEventID: id,
+		})
+	}
+	if err := fanout("e1", domain.ScopeRole, "MANAGER"); err != nil {
+		t.Fatal(err)
+	}
+	// 2 used, 5 more requested.
+	if err := fanout("e2", domain.ScopeTenant, "acme"); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("err = %v, want ErrQuotaExceeded", err)
+	}
+	if _, err := s.Create(ctx, domain.CreateNotificationInput{TenantKey: "acme", UserID: "u1", Type: domain.TypeCRM, Title: "t"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Create(ctx, domain.CreateNotificationInput{TenantKey: "acme", UserID: "u1", Type: domain.TypeCRM, Title: "t"}); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("err = %v, want ErrQuotaExceeded", err)
+	}
+	// Other tenants use their own quota.
+	if err := s.Fanout(ctx, domain.FanoutInput{TenantKey: "globex", TargetScope: domain.ScopeTenant, TargetID: "globex", Type: domain.TypeCRM, Title: "t"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(repo.Notifications()); n != 5 {
+		t.Fatalf("notifications = %d, want 5", n)
+	}
+	report, err := s.TenantUsage(ctx, "acme", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Current.Created != 3 || report.Current.Rejected != 6 || *report.Remaining != 0 || report.QuotaSource != "default" {
+		t.Fatalf("report = %+v", report)
+	}
+}
+
+func TestQuotaQueueRelease(t *testing.T) {
+	ctx := context.Background()
+	repo := testsupport.NewRepository()
+	quotas := testsupport.NewQuotas()
+	clock := domain.NewManualClock(time.Date(2026, 3, 31, 23, 0, 0, 0, time.UTC))
+	s := NewService(repo, testsupport.NewHub(), fanoutResolver(), WithQuotas(quotas, QuotaConfig{}), WithClock(clock))
+	if _, err := s.UpsertQuota(ctx, domain.TenantQuota{TenantKey: "acme", MonthlyLimit: 5, Overage: domain.QuotaQueue}); err != nil {
+		t.Fatal(err)
+	}
+
+	input := domain.FanoutInput{TenantKey: "acme", TargetScope: domain.ScopeTenant, TargetID: "acme", Type: domain.TypeCRM, Title: "t"}
+	for i := 0; i < 2; i++ {
+		if err := s.Fanout(ctx, input); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(repo.Notifications()); n != 5 {
+		t.Fatalf("notifications = %d, want 5", n)
+	}
+	if n, _ := quotas.CountHeld(ctx, "acme"); n != 1 {
+		t.Fatalf("held = %d, want 1", n)
+	}
+
+	// Still over the quota this month: stays held.
+	s.ReleaseQuotaHeld(ctx)
+	if n, _ := quotas.CountHeld(ctx, "acme"); n != 1 {
+		t.Fatalf("held = %d, want 1", n)
+	}
+
+	clock.Advance(2 * time.Hour)
+	s.ReleaseQuotaHeld(ctx)
+	if n, _ := quotas.CountHeld(ctx, "acme"); n != 0 {
+		t.Fatalf("held = %d after the month changed, want 0", n)
+	}
+	if n := len(repo.Notifications()); n != 10 {
+		t.Fatalf("notifications = %d, want 10", n)
+	}
+	report, err := s.TenantUsage(ctx, "acme", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.History) != 2 || report.Current.Created != 5 || report.History[1].Queued != 5 || report.QuotaSource != "tenant" {
+		t.Fatalf("report = %+v", report)
+	}
+}
+
+func TestQuotaLimitsOnlyTenantsOverQuota(t *testing.T) {
+	ctx := context.Background()
+	recipients := map[string][]string{"acme": {"u1", "u2", "u3", "u4", "u5"}, "globex": {"v1", "v2"}}
+	input := domain.FanoutInput{TenantKey: "acme", TargetScope: domain.ScopeTenant, TargetID: "acme", Type: domain.TypeCRM, Title: "t", SourceEventID: "e1"}
+
+	for _, overage := range []domain.QuotaOverage{domain.QuotaReject, domain.QuotaQueue} {
+		t.Run(string(overage), func(t *testing.T) {
+			repo := testsupport.NewRepository()
+			quotas := testsupport.NewQuotas()
+			s := NewService(repo, testsupport.NewHub(), fanoutResolver(), WithQuotas(quotas, QuotaConfig{}),
+				WithClock(domain.NewManualClock(time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC))))
+			if _, err := s.UpsertQuota(ctx, domain.TenantQuota{TenantKey: "acme", MonthlyLimit: 3, Overage: overage}); err != nil {
+				t.Fatal(err)
+			}
+
+			// globex is delivered although acme is over its quota.
+			if err := s.deliver(ctx, input, recipients); err != nil {
+				t.Fatal(err)
+			}
+			for _, n := range repo.Notifications() {
+				if n.TenantKey != "globex" {
+					t.Fatalf("delivered to %s/%s over the quota", n.TenantKey, n.UserID)
+				}
+			}
+			if n := len(repo.Notifications()); n != 2 {
+				t.Fatalf("notifications = %d, want 2", n)
+			}
+			if overage == domain.QuotaReject {
+				if n, _ := quotas.CountHeld(ctx, "acme"); n != 0 {
+					t.Fatalf("held = %d, want 0", n)
+				}
+				return
+			}
+
+			// Releasing delivers to acme only.
+			if _, err := s.UpsertQuota(ctx, domain.TenantQuota{TenantKey: "acme", MonthlyLimit: 10, Overage: overage}); err != nil {
+				t.Fatal(err)
+			}
+			s.ReleaseQuotaHeld(ctx)
+			if n, _ := quotas.CountHeld(ctx, "acme"); n != 0 {
+				t.Fatalf("held = %d after the quota was raised, want 0", n)
+			}
+			byTenant := make(map[string]int)
+			for _, n := range repo.Notifications() {
+				byTenant[n.TenantKey]++
+			}
+			if byTenant["acme"] != 5 || byTenant["globex"] != 2 {
+				t.Fatalf("notifications per tenant = %v, want acme 5, globex 2", byTenant)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// ErrRateLimited is returned by Fanout when the reject policy (or an over-long
+// queue wait) refuses a fan-out. The consumer dead-letters such records without retrying.
+var ErrRateLimited = errors.New("fan-out rate limit exceeded")
+
+// RateLimitPolicy decides what happens to a fan-out over its rate limit.
+type RateLimitPolicy string
+
+const (
+	// RateLimitQueue waits for a token, slowing the consumer down (default).
+	// Waits longer than RateLimitConfig.MaxWait are rejected.
+	RateLimitQueue RateLimitPolicy = "queue"
+	// RateLimitSample drops fan-outs beyond the rate; the record is still committed.
+	RateLimitSample RateLimitPolicy = "sample"
+	// RateLimitReject fails the fan-out with ErrRateLimited.
+	RateLimitReject RateLimitPolicy = "reject"
+)
+
+// RateLimitConfig configures the token buckets applied to every Fanout.
+// A fan-out takes one token from its tenant's bucket and one from its source
+// topic's bucket; a zero rate disables that bucket.
+type RateLimitConfig struct {
+	TenantRate  float64 // fan-outs per second per tenant
+	TenantBurst int     // 0 = max(1, TenantRate)
+	TopicRate   float64 // fan-outs per second per source topic (producer)
+	TopicBurst  int     // 0 = max(1, TopicRate)
+	Policy      RateLimitPolicy
+	MaxWait     time.Duration
+}
+
+// RateLimitStats counts fan-outs by rate limit outcome since startup.
+type RateLimitStats struct {
+	Policy   RateLimitPolicy `json:"policy"`
+	Buckets  int             `json:"buckets"`
+	Admitted uint64          `json:"admitted"`
+	Queued   uint64          `json:"queued"`
+	Dropped  uint64          `json:"dropped"`
+	Rejected uint64          `json:"rejected"`
+}
+
+// rateLimitPruneEvery bounds how often idle (full) buckets are dropped.
+const rateLimitPruneEvery = time.Minute
+
+type rateLimiter struct {
+	cfg RateLimitConfig
+
+	mu        sync.Mutex
+	buckets   map[string]*rate.Limiter
+	lastPrune time.Time
+
+	admitted atomic.Uint64
+	queued   atomic.Uint64
+	dropped  atomic.Uint64
+	rejected atomic.Uint64
+}
+
+func newRateLimiter(cfg RateLimitConfig) *rateLimiter {
+	if cfg.Policy == "" {
+		cfg.Policy = RateLimitQueue
+	}
+	return &rateLimiter{cfg: cfg, buckets: make(map[string]*rate.Limiter), lastPrune: time.Now()}
+}
+
+func burstFor(r float64, burst int) int {
+	if burst > 0 {
+		return burst
+	}
+	return max(1, int(math.Ceil(r)))
+}
+
+// reserve takes a token from the bucket of every configured key and returns the
+// reservations with the longest delay among them.
+func (l *rateLimiter) reserve(now time.Time, tenantKey, topic string) ([]*rate.Reservation, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastPrune) >= rateLimitPruneEvery {
+		for key, lim := range l.buckets {
+			if lim.TokensAt(now) >= float64(lim.Burst()) {
+				delete(l.buckets, key)
+			}
+		}
+		l.lastPrune = now
+	}
+
+	var (
+		reservations []*rate.Reservation
+		delay        time.Duration
+	)
+	take := func(key string, r float64, burst int) {
+		lim, ok := l.buckets[key]
+		if !ok {
+			lim = rate.NewLimiter(rate.Limit(r), burstFor(r, burst))
+			l.buckets[key] = lim
+		}
+		res := lim.ReserveN(now, 1)
+		reservations = append(reservations, res)
+		delay = max(delay, res.DelayFrom(now))
+	}
+	if l.cfg.TenantRate > 0 {
+		take("tenant:"+tenantKey, l.cfg.TenantRate, l.cfg.TenantBurst)
+	}
+	if l.cfg.TopicRate > 0 && topic != "" {
+		take("topic:"+topic, l.cfg.TopicRate, l.cfg.TopicBurst)
+	}
+	return reservations, delay
+}
+
+// admit applies the policy to one fan-out. It returns false (with a nil error)
+// when the fan-out is sampled out, and ErrRateLimited when it is rejected.
+func (l *rateLimiter) admit(ctx context.Context, tenantKey, topic string) (bool, error) {
+	now := time.Now()
+	reservations, delay := l.reserve(now, tenantKey, topic)
+	if delay == 0 {
+		l.admitted.Add(1)
+		return true, nil
+	}
+	cancel := func() {
+		for _, r := range reservations {
+			r.CancelAt(now)
+		}
+	}
+
+	switch {
+	case l.cfg.Policy == RateLimitSample:
+		cancel()
+		l.dropped.Add(1)
+		return false, nil
+	case l.cfg.Policy == RateLimitReject, delay > l.cfg.MaxWait:
+		cancel()
+		l.rejected.Add(1)
+		return false, ErrRateLimited
+	}
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		l.queued.Add(1)
+		l.admitted.Add(1)
+		return true, nil
+	case <-ctx.Done():
+		cancel()
+		return false, ctx.Err()
+	}
+}
+
+func (l *rateLimiter) stats() RateLimitStats {
+	l.mu.Lock()
+	buckets := len(l.buckets)
+	l.mu.Unlock()
+	return RateLimitStats{
+		Policy:   l.cfg.Policy,
+		Buckets:  buckets,
+		Admitted: l.admitted.Load(),
+		Queued:   l.queued.Load(),
+		Dropped:  l.dropped.Load(),
+		Rejected: l.rejected.Load(),
+	}
+}
+
+// SetRateLimit enables per-tenant and per-producer rate limiting of Fanout.
+// With both rates zero no limit is applied. It may be called while fan-outs run
+// (config reload): buckets restart full and the outcome counters carry over.
+func (s *Service) SetRateLimit(cfg RateLimitConfig) {
+	if cfg.TenantRate <= 0 && cfg.TopicRate <= 0 {
+		s.rateLimiter.Store(nil)
+		return
+	}
+	l := newRateLimiter(cfg)
+	if prev := s.rateLimiter.Load(); prev != nil {
+		l.admitted.Store(prev.admitted.Load())
+		l.queued.Store(prev.queued.Load())
+		l.dropped.Store(prev.dropped.Load())
+		l.rejected.Store(prev.rejected.Load())
+	}
+	s.rateLimiter.Store(l)
+}
+
+type sourceTopicKey struct{}
+
+// WithSourceTopic returns a context carrying the Kafka topic a fan-out was
+// consumed from; it selects the producer's rate limit bucket.
+func WithSourceTopic(ctx context.Context, topic string) context.Context {
+	if topic == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, sourceTopicKey{}, topic)
+}
+
+func sourceTopic(ctx context.Context) string {
+	topic, _ := ctx.Value(sourceTopicKey{}).(string)
+	return topic
+}
@@ -0,0 +1,76 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRateLimitPolicies(t *testing.T) {
+	ctx := context.Background()
+	cfg := RateLimitConfig{TenantRate: 1, TenantBurst: 1, MaxWait: 10 * time.Millisecond}
+
+	for _, tc := range []struct {
+		policy RateLimitPolicy
+		admit  bool
+		err    error
+	}{
+		{RateLimitSample, false, nil},
+		{RateLimitReject, false, ErrRateLimited},
+		{RateLimitQueue, false, ErrRateLimited}, // a ~1s wait exceeds MaxWait
+	} {
+		cfg.Policy = tc.policy
+		l := newRateLimiter(cfg)
+		if ok, err := l.admit(ctx, "acme", "crm-events"); !ok || err != nil {
+			t.Fatalf("%s: first fan-out = %v, %v; want admitted", tc.policy, ok, err)
+		}
+		if ok, err := l.admit(ctx, "other", "crm-events"); !ok || err != nil {
+			t.Fatalf("%s: other tenant = %v, %v; want its own bucket", tc.policy, ok, err)
+		}
+		ok, err := l.admit(ctx, "acme", "crm-events")
+		if ok != tc.admit || !errors.Is(err, tc.err) {
+			t.Fatalf("%s: over limit = %v, %v; want %v, %v", tc.policy, ok, err, tc.admit, tc.err)
+		}
+	}
+
+	// Queued fan-outs wait for the bucket to refill.
+	l := newRateLimiter(RateLimitConfig{TopicRate: 200, TopicBurst: 1, Policy: RateLimitQueue, MaxWait: time.Second})
+	start := time.Now()
+	for range 3 {
+		if ok, err := l.admit(ctx, "acme", "notification-commands"); !ok || err != nil {
+			t.Fatalf("queue: %v, %v", ok, err)
+		}
+	}
+	if waited := time.Since(start); waited < 5*time.Millisecond {
+		t.Fatalf("queue: 3 fan-outs at 200/s took %v, want them spaced out", waited)
+	}
+	if s := l.stats(); s.Queued != 2 || s.Admitted != 3 {
+		t.Fatalf("queue stats = %+v", s)
+	}
+}
+
+func TestSetRateLimitReconfigures(t *testing.T) {
+	ctx := context.Background()
+	s := &Service{}
+	s.SetRateLimit(RateLimitConfig{TenantRate: 1, TenantBurst: 1, Policy: RateLimitSample})
+	l := s.rateLimiter.Load()
+	l.admit(ctx, "acme", "")
+	if ok, _ := l.admit(ctx, "acme", ""); ok {
+		t.Fatal("second fan-out should be sampled out")
+	}
+
+	// A reload swaps the limits in place and keeps the counters.
+	s.SetRateLimit(RateLimitConfig{TenantRate: 100, TenantBurst: 10, Policy: RateLimitSample})
+	if ok, _ := s.rateLimiter.Load().admit(ctx, "acme", ""); !ok {
+		t.Fatal("fan-out should be admitted under the raised limit")
+	}
+	if st := s.rateLimiter.Load().stats(); st.Admitted != 2 || st.Dropped != 1 {
+		t.Fatalf("stats after reload = %+v", st)
+	}
+
+	s.SetRateLimit(RateLimitConfig{})
+	if s.rateLimiter.Load() != nil {
+		t.Fatal("zero rates should disable rate limiting")
+	}
+}
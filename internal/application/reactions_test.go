@@ -0,0 +1,78 @@
+package application
+
+import (
+	"context"
+	"testing"
+
+	"vn.io.arda/notification/internal/domain"
+	"vn.io.arda/notification/internal/testsupport"
+)
+
+type reactionSink chan domain.Reaction
+
+func (s reactionSink) PublishReaction(_ context.Context, r domain.Reaction) error {
+	s <- r
+	return nil
+}
+
+func TestReact(t *testing.T) {
+	ctx := context.Background()
+	repo := testsupport.NewRepository()
+	s := NewService(repo, testsupport.NewHub(), testsupport.NewResolver(), WithReactions(testsupport.NewReactions()))
+	published := make(reactionSink, 4)
+	s.SetReactionPublisher(published)
+
+	create := func(userID string) *domain.Notification {
+		t.Helper()
+		n, err := s.Create(ctx, domain.CreateNotificationInput{TenantKey: "acme", UserID: userID, Type: domain.TypeWorkflow,
+			Title: "approve", SourceEventID: "evt-1", Metadata: map[string]any{"reaction": map[string]any{"required": true, "require_comment_on_reject": true}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	n1, n2 := create("u1"), create("u2")
+
+	for name, c := range map[string]struct {
+		userID string
+		in     ReactionInput
+	}{
+		"unknown response":         {"u1", ReactionInput{Response: "MAYBE"}},
+		"another user's":           {"u2", ReactionInput{Response: "ACK"}},
+		"reject without a comment": {"u1", ReactionInput{Response: "REJECT"}},
+	} {
+		if _, err := s.React(ctx, n1.ID.String(), "acme", c.userID, c.in); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	if _, err := s.React(ctx, n1.ID.String(), "acme", "u1", ReactionInput{Response: "ACK"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.React(ctx, n1.ID.String(), "acme", "u1", ReactionInput{Response: "REJECT", Comment: "no"}); err == nil {
+		t.Fatal("accepted a second reaction from the same user")
+	}
+	if _, err := s.React(ctx, n2.ID.String(), "acme", "u2", ReactionInput{Response: "REJECT", Comment: "over budget"}); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := repo.CountUnread(ctx, "acme", "u1"); n != 0 {
+		t.Fatalf("unread = %d after reacting, want 0", n)
+	}
+	if r := <-published; r.SourceEventID != "evt-1" {
+		t.Fatalf("published %+v", r)
+	}
+
+	got, err := s.ListReactionsBySourceEvent(ctx, "acme", "evt-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("%d reactions for evt-1, want 2", len(got))
+	}
+	if other, _ := s.ListReactionsBySourceEvent(ctx, "globex", "evt-1"); len(other) != 0 {
+		t.Fatalf("another tenant sees %d reactions", len(other))
+	}
+	if _, err := s.ListReactionsBySourceEvent(ctx, "acme", ""); err == nil {
+		t.Fatal("listed reactions without a source event")
+	}
+}
@@ -0,0 +1,135 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// ErrRecipientCapExceeded is returned by Fanout when an event resolves to more
+// recipients than the cap and is not converted to a broadcast. The consumer
+// dead-letters such records without retrying.
+var ErrRecipientCapExceeded = errors.New("fan-out recipient cap exceeded")
+
+// RecipientCapPolicy decides what happens to a fan-out over the recipient cap.
+type RecipientCapPolicy string
+
+const (
+	// RecipientCapBroadcast stores TENANT / PLATFORM fan-outs once as a broadcast
+	// (read state tracked per user on read); other scopes are rejected (default).
+	RecipientCapBroadcast RecipientCapPolicy = "broadcast"
+	// RecipientCapReject rejects every fan-out over the cap.
+	RecipientCapReject RecipientCapPolicy = "reject"
+)
+
+// RecipientCapConfig bounds the per-user rows a single event may create.
+type RecipientCapConfig struct {
+	Max    int // recipients per event; 0 = no cap
+	Policy RecipientCapPolicy
+	// AlertRole, when set, receives a SYSTEM notification in the event's tenant
+	// whenever one of its events is rejected.
+	AlertRole string
+}
+
+// RecipientCapStats counts fan-outs over the recipient cap since startup.
+type RecipientCapStats struct {
+	Max         int                `json:"max"`
+	Policy      RecipientCapPolicy `json:"policy"`
+	Broadcasted uint64             `json:"broadcasted"`
+	Rejected    uint64             `json:"rejected"`
+}
+
+type recipientCap struct {
+	cfg         RecipientCapConfig
+	broadcasted atomic.Uint64
+	rejected    atomic.Uint64
+}
+
+// SetRecipientCap caps the recipients of a single fan-out. A zero Max disables the cap.
+func (s *Service) SetRecipientCap(cfg RecipientCapConfig) {
+	if cfg.Max <= 0 {
+		s.recipientCap = nil
+		return
+	}
+	if cfg.Policy != RecipientCapReject {
+		cfg.Policy = RecipientCapBroadcast
+	}
+	s.recipientCap = &recipientCap{cfg: cfg}
+}
+
+// overRecipientCap reports whether a fan-out to recipients users exceeds the cap.
+func (s *Service) overRecipientCap(recipients int) bool {
+	return s.recipientCap != nil && recipients > s.recipientCap.cfg.Max
+}
+
+// spillOver handles a fan-out over the recipient cap: TENANT / PLATFORM events
+// become a broadcast under RecipientCapBroadcast, anything else is rejected and
+// the tenant's admins alerted. Staged rollouts and composite targets need
+// per-user rows, so they are never converted.
+func (s *Service) spillOver(ctx context.Context, input domain.FanoutInput, recipients int) error {
+	c := s.recipientCap
+	convertible := (input.TargetScope == domain.ScopeTenant || input.TargetScope == domain.ScopePlatform) &&
+		input.Rollout == nil && !input.IsComposite()
+	if c.cfg.Policy == RecipientCapBroadcast && convertible {
+		c.broadcasted.Add(1)
+		s.Trace(ctx, input.SourceEventID, domain.TraceRecipientCapped, map[string]any{
+			"recipients": recipients, "cap": c.cfg.Max, "action": "broadcast",
+		})
+		log.Warn().Str("tenant", input.TenantKey).Str("source_event_id", input.SourceEventID).
+			Int("recipients", recipients).Int("cap", c.cfg.Max).
+			Msg("fan-out over recipient cap, storing as broadcast")
+		return s.broadcast(ctx, input)
+	}
+
+	c.rejected.Add(1)
+	s.Trace(ctx, input.SourceEventID, domain.TraceRecipientCapped, map[string]any{
+		"recipients": recipients, "cap": c.cfg.Max, "action": "reject",
+	})
+	log.Error().Str("tenant", input.TenantKey).Str("scope", string(input.TargetScope)).
+		Str("target_id", input.TargetID).Str("source_event_id", input.SourceEventID).
+		Int("recipients", recipients).Int("cap", c.cfg.Max).
+		Msg("fan-out rejected: recipient cap exceeded")
+	s.alertRecipientCap(ctx, input, recipients)
+	return fmt.Errorf("%w: %d recipients, cap %d", ErrRecipientCapExceeded, recipients, c.cfg.Max)
+}
+
+// alertRecipientCap notifies the configured admin role of the event's tenant.
+// It goes straight to deliver so the alert is never itself capped.
+func (s *Service) alertRecipientCap(ctx context.Context, input domain.FanoutInput, recipients int) {
+	role := s.recipientCap.cfg.AlertRole
+	if role == "" || input.TenantKey == "" {
+		return
+	}
+	alert := domain.FanoutInput{
+		TenantKey:   input.TenantKey,
+		TargetScope: domain.ScopeRole,
+		TargetID:    role,
+		Type:        domain.TypeSystem,
+		Priority:    domain.PriorityHigh,
+		Title:       "Notification event rejected",
+		Body: fmt.Sprintf("Event %s (%s) targeted %d recipients, over the limit of %d, and was not delivered.",
+			input.SourceEventID, input.Type, recipients, s.recipientCap.cfg.Max),
+		SourceEventID: input.SourceEventID + ":recipient-cap",
+	}
+	ctx = context.WithoutCancel(ctx)
+	admins, err := s.resolveTargets(ctx, alert)
+	if err == nil {
+		err = s.deliver(ctx, alert, admins)
+	}
+	if err != nil {
+		log.Error().Err(err).Str("tenant", input.TenantKey).Str("role", role).Msg("failed to alert recipient cap rejection")
+	}
+}
+
+func (c *recipientCap) stats() RecipientCapStats {
+	return RecipientCapStats{
+		Max:         c.cfg.Max,
+		Policy:      c.cfg.Policy,
+		Broadcasted: c.broadcasted.Load(),
+		Rejected:    c.rejected.Load(),
+	}
+}
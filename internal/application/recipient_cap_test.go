@@ -0,0 +1,36 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"vn.io.arda/notification/internal/domain"
+)
+
+func TestRecipientCap(t *testing.T) {
+	s := &Service{}
+	s.SetRecipientCap(RecipientCapConfig{Max: 0})
+	if s.overRecipientCap(1_000_000) {
+		t.Fatal("zero max should disable the cap")
+	}
+
+	s.SetRecipientCap(RecipientCapConfig{Max: 10, Policy: "bogus"})
+	if s.recipientCap.cfg.Policy != RecipientCapBroadcast {
+		t.Fatalf("unknown policy should default to broadcast, got %q", s.recipientCap.cfg.Policy)
+	}
+	if s.overRecipientCap(10) || !s.overRecipientCap(11) {
+		t.Fatal("cap should allow exactly Max recipients")
+	}
+
+	// ROLE fan-outs cannot become a broadcast, so they are rejected even under the broadcast policy.
+	err := s.spillOver(context.Background(), domain.FanoutInput{
+		TenantKey: "acme", TargetScope: domain.ScopeRole, TargetID: "everyone", SourceEventID: "evt-1",
+	}, 11)
+	if !errors.Is(err, ErrRecipientCapExceeded) {
+		t.Fatalf("expected ErrRecipientCapExceeded, got %v", err)
+	}
+	if st := s.recipientCap.stats(); st.Rejected != 1 || st.Broadcasted != 0 {
+		t.Fatalf("unexpected stats %+v", st)
+	}
+}
@@ -0,0 +1,256 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// RedactionMode selects what happens to a metadata value containing sensitive data.
+type RedactionMode string
+
+const (
+	// RedactionMask replaces the sensitive part of the value, keeping the rest.
+	RedactionMask RedactionMode = "mask"
+	// RedactionStrip removes the value from the metadata.
+	RedactionStrip RedactionMode = "strip"
+)
+
+// RedactionConfig configures the PII redaction of notification metadata.
+type RedactionConfig struct {
+	// Patterns are built-in patterns: email, national_id (12-digit CCCD),
+	// phone (Vietnamese mobile numbers) and token (JWTs, bearer tokens, API keys).
+	Patterns []string
+	// Custom adds patterns written as "name=regexp", matches of which are masked whole.
+	Custom []string
+	// Keys are metadata keys (case-insensitive, at any depth) always removed.
+	Keys []string
+	Mode RedactionMode
+}
+
+// redactionPattern finds one kind of sensitive data and masks a match.
+type redactionPattern struct {
+	name string
+	re   *regexp.Regexp
+	mask func(string) string
+}
+
+// builtinRedactionPatterns are the patterns selectable by name in RedactionConfig.Patterns.
+var builtinRedactionPatterns = map[string]redactionPattern{
+	"email": {
+		re: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+		mask: func(s string) string {
+			local, domainPart, _ := strings.Cut(s, "@")
+			return local[:1] + "***@" + domainPart
+		},
+	},
+	"national_id": {re: regexp.MustCompile(`\b\d{12}\b`), mask: keepLast(3)},
+	"phone":       {re: regexp.MustCompile(`(?:\+84|\b0)[35789]\d{8}\b`), mask: keepLast(3)},
+	"token": {
+		re:   regexp.MustCompile(`\beyJ[\w-]+\.[\w-]+\.[\w-]+|(?i:bearer\s+)[\w.~+/-]+=*|\b(?:sk|pk|rk)_(?:live|test)_\w{16,}|\bgh[pousr]_\w{36,}`),
+		mask: func(string) string { return "[REDACTED]" },
+	},
+}
+
+// redactionExempt are metadata keys written by this service itself; their
+// values are never redacted.
+var redactionExempt = map[string]bool{
+	domain.MetadataTemplate:   true,
+	domain.MetadataSender:     true,
+	domain.MetadataEntityType: true,
+	domain.MetadataEntityID:   true,
+	"channels":                true,
+	"expires_at":              true,
+	"traceparent":             true,
+}
+
+func keepLast(n int) func(string) string {
+	return func(s string) string {
+		if len(s) <= n {
+			return strings.Repeat("*", len(s))
+		}
+		return strings.Repeat("*", len(s)-n) + s[len(s)-n:]
+	}
+}
+
+// Redactor strips or masks sensitive data in notification metadata before it
+// is stored, since upstream services put raw customer data there.
+type Redactor struct {
+	patterns []redactionPattern
+	keys     map[string]bool
+	strip    bool
+}
+
+// NewRedactor checks cfg and builds a Redactor.
+func NewRedactor(cfg RedactionConfig) (*Redactor, error) {
+	r := &Redactor{keys: make(map[string]bool, len(cfg.Keys))}
+	switch cfg.Mode {
+	case "", RedactionMask:
+	case RedactionStrip:
+		r.strip = true
+	default:
+		return nil, fmt.Errorf("redaction mode %q: want mask or strip", cfg.Mode)
+	}
+	for _, name := range cfg.Patterns {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		p, ok := builtinRedactionPatterns[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown redaction pattern %q", name)
+		}
+		p.name = name
+		r.patterns = append(r.patterns, p)
+	}
+	for _, entry := range cfg.Custom {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, expr, ok := strings.Cut(entry, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("redaction pattern %q: want name=regexp", entry)
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("redaction pattern %s: %w", name, err)
+		}
+		r.patterns = append(r.patterns, redactionPattern{name: name, re: re, mask: func(string) string { return "[REDACTED]" }})
+	}
+	for _, k := range cfg.Keys {
+		if k = strings.TrimSpace(k); k != "" {
+			r.keys[strings.ToLower(k)] = true
+		}
+	}
+	return r, nil
+}
+
+// Redact returns a copy of meta without sensitive data and the paths of the
+// values redacted (e.g. "customer.email", "contacts[0]").
+func (r *Redactor) Redact(meta map[string]any) (map[string]any, []string) {
+	if len(meta) == 0 {
+		return meta, nil
+	}
+	var paths []string
+	return r.redactMap(meta, "", &paths), paths
+}
+
+// RedactParams is Redact for template params, which are stored in the metadata
+// too (see domain.WithTemplate).
+func (r *Redactor) RedactParams(params map[string]string) (map[string]string, []string) {
+	if len(params) == 0 {
+		return params, nil
+	}
+	in := make(map[string]any, len(params))
+	for k, v := range params {
+		in[k] = v
+	}
+	var paths []string
+	out := make(map[string]string, len(params))
+	for k, v := range r.redactMap(in, "template.params", &paths) {
+		out[k] = v.(string)
+	}
+	return out, paths
+}
+
+func (r *Redactor) redactMap(m map[string]any, path string, paths *[]string) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		p := k
+		if path != "" {
+			p = path + "." + k
+		}
+		switch {
+		case path == "" && redactionExempt[k]:
+			out[k] = v
+		case r.keys[strings.ToLower(k)]:
+			*paths = append(*paths, p)
+		default:
+			if v, keep := r.redactValue(v, p, paths); keep {
+				out[k] = v
+			}
+		}
+	}
+	return out
+}
+
+// redactValue returns v without sensitive data, and false when it must be removed.
+func (r *Redactor) redactValue(v any, path string, paths *[]string) (any, bool) {
+	switch v := v.(type) {
+	case map[string]any:
+		return r.redactMap(v, path, paths), true
+	case []any:
+		out := make([]any, 0, len(v))
+		for i, e := range v {
+			if e, keep := r.redactValue(e, path+"["+strconv.Itoa(i)+"]", paths); keep {
+				out = append(out, e)
+			}
+		}
+		return out, true
+	case float64:
+		// National IDs and phone numbers are sometimes sent as JSON numbers.
+		if s, matched := r.redactString(strconv.FormatFloat(v, 'f', -1, 64)); matched {
+			*paths = append(*paths, path)
+			return s, !r.strip
+		}
+	case string:
+		if s, matched := r.redactString(v); matched {
+			*paths = append(*paths, path)
+			return s, !r.strip
+		}
+	}
+	return v, true
+}
+
+func (r *Redactor) redactString(s string) (string, bool) {
+	matched := false
+	for _, p := range r.patterns {
+		if !p.re.MatchString(s) {
+			continue
+		}
+		matched = true
+		if r.strip {
+			return "", true
+		}
+		s = p.re.ReplaceAllStringFunc(s, p.mask)
+	}
+	return s, matched
+}
+
+// SetRedactor enables PII redaction of metadata in Create and Fanout.
+func (s *Service) SetRedactor(r *Redactor) {
+	s.redactor = r
+}
+
+// redactMetadata applies the redactor, if any, to meta.
+func (s *Service) redactMetadata(ctx context.Context, sourceEventID string, meta map[string]any) map[string]any {
+	if s.redactor == nil {
+		return meta
+	}
+	meta, paths := s.redactor.Redact(meta)
+	logRedacted(ctx, sourceEventID, paths)
+	return meta
+}
+
+// redactTemplate applies the redactor, if any, to the params of ref.
+func (s *Service) redactTemplate(ctx context.Context, sourceEventID string, ref *domain.TemplateRef) *domain.TemplateRef {
+	if s.redactor == nil || ref == nil {
+		return ref
+	}
+	params, paths := s.redactor.RedactParams(ref.Params)
+	logRedacted(ctx, sourceEventID, paths)
+	return &domain.TemplateRef{Key: ref.Key, Params: params}
+}
+
+func logRedacted(ctx context.Context, sourceEventID string, paths []string) {
+	if len(paths) > 0 {
+		log.Info().Str("source_event_id", sourceEventID).Str("topic", sourceTopic(ctx)).
+			Strs("fields", paths).Msg("sensitive metadata redacted")
+	}
+}
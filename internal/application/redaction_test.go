@@ -0,0 +1,98 @@
+package application
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"vn.io.arda/notification/internal/domain"
+	"vn.io.arda/notification/internal/testsupport"
+)
+
+func TestRedactorMask(t *testing.T) {
+	r, err := NewRedactor(RedactionConfig{
+		Patterns: []string{"email", "national_id", "phone", "token"},
+		Custom:   []string{`contract=HD-\d{6}`},
+		Keys:     []string{"password"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, paths := r.Redact(map[string]any{
+		"entityId": "079201001234",
+		"customer": map[string]any{"email": "nguyen.van.a@example.com", "cccd": float64(79201001234 + 1e11), "Password": "hunter2"},
+		"contacts": []any{"Gọi 0912345678", "ok"},
+		"note":     "Hợp đồng HD-123456, token eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.sig",
+		"amount":   float64(5000000),
+	})
+
+	customer := got["customer"].(map[string]any)
+	checks := map[string]any{
+		"entityId":       got["entityId"],
+		"customer.email": customer["email"],
+		"customer.cccd":  customer["cccd"],
+		"contacts[0]":    got["contacts"].([]any)[0],
+		"note":           got["note"],
+		"amount":         got["amount"],
+	}
+	want := map[string]any{
+		"entityId":       "079201001234",
+		"customer.email": "n***@example.com",
+		"customer.cccd":  "*********234",
+		"contacts[0]":    "Gọi *******678",
+		"note":           "Hợp đồng [REDACTED], token [REDACTED]",
+		"amount":         float64(5000000),
+	}
+	for k, w := range want {
+		if checks[k] != w {
+			t.Errorf("%s = %v, want %v", k, checks[k], w)
+		}
+	}
+	if _, ok := customer["Password"]; ok {
+		t.Error("password key was kept")
+	}
+	slices.Sort(paths)
+	if want := []string{"contacts[0]", "customer.Password", "customer.cccd", "customer.email", "note"}; !slices.Equal(paths, want) {
+		t.Errorf("paths = %v, want %v", paths, want)
+	}
+}
+
+func TestRedactorStrip(t *testing.T) {
+	r, err := NewRedactor(RedactionConfig{Patterns: []string{"email"}, Mode: RedactionStrip})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := r.Redact(map[string]any{"email": "a@b.vn", "cc": []any{"x@y.vn", "team"}, "name": "An"})
+	if _, ok := got["email"]; ok || !slices.Equal(got["cc"].([]any), []any{"team"}) || got["name"] != "An" {
+		t.Fatalf("Redact = %v", got)
+	}
+
+	if _, err := NewRedactor(RedactionConfig{Patterns: []string{"iban"}}); err == nil {
+		t.Error("expected an unknown pattern to be rejected")
+	}
+	if _, err := NewRedactor(RedactionConfig{Custom: []string{"bad=("}}); err == nil {
+		t.Error("expected an invalid regexp to be rejected")
+	}
+}
+
+func TestFanoutRedactsMetadata(t *testing.T) {
+	ctx := context.Background()
+	repo := testsupport.NewRepository()
+	r, err := NewRedactor(RedactionConfig{Patterns: []string{"email"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewService(repo, nil, testsupport.NewResolver(), WithRedactor(r))
+
+	err = s.Fanout(ctx, domain.FanoutInput{
+		TargetScope: domain.ScopeUser, TargetID: "u1", TenantKey: "acme", Type: domain.TypeCRM,
+		Title: "Khách hàng mới", Metadata: map[string]any{"customerEmail": "an@example.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := repo.Notifications()
+	if len(stored) != 1 || stored[0].Metadata["customerEmail"] != "a***@example.com" {
+		t.Fatalf("stored %+v", stored)
+	}
+}
@@ -0,0 +1,89 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// MaxRetentionDays bounds a retention policy; use 0 to keep forever.
+const MaxRetentionDays = 36500
+
+// SetRetentionPolicies enables the retention policy admin API. The TTL purge
+// applies stored policies either way.
+func (s *Service) SetRetentionPolicies(repo domain.RetentionPolicyRepository) {
+	s.retention = repo
+}
+
+func (s *Service) requireRetentionPolicies() error {
+	if s.retention == nil {
+		return fmt.Errorf("retention policies not configured")
+	}
+	return nil
+}
+
+// ListRetentionPolicies returns the policies affecting tenantKey (its own and
+// those for all tenants), or every policy when tenantKey is empty.
+func (s *Service) ListRetentionPolicies(ctx context.Context, tenantKey string) ([]domain.RetentionPolicy, error) {
+	if err := s.requireRetentionPolicies(); err != nil {
+		return nil, err
+	}
+	return s.retention.List(ctx, tenantKey)
+}
+
+// UpsertRetentionPolicy validates and stores a retention policy.
+func (s *Service) UpsertRetentionPolicy(ctx context.Context, p domain.RetentionPolicy) (*domain.RetentionPolicy, error) {
+	if err := s.requireRetentionPolicies(); err != nil {
+		return nil, err
+	}
+	switch {
+	case p.Type != "" && !p.Type.Valid():
+		return nil, fmt.Errorf("invalid type %q: use uppercase letters, digits and underscores", p.Type)
+	case p.RetentionDays < 0 || p.RetentionDays > MaxRetentionDays:
+		return nil, fmt.Errorf("retention_days must be between 0 (keep forever) and %d", MaxRetentionDays)
+	}
+	return s.retention.Upsert(ctx, p)
+}
+
+// DeleteRetentionPolicy removes the policy of (tenantKey, t); notifications fall
+// back to the next less specific policy.
+func (s *Service) DeleteRetentionPolicy(ctx context.Context, tenantKey string, t domain.NotificationType) error {
+	if err := s.requireRetentionPolicies(); err != nil {
+		return err
+	}
+	return s.retention.Delete(ctx, tenantKey, t)
+}
+
+// PurgeResult reports an on-demand purge of notifications.
+type PurgeResult struct {
+	DryRun        bool  `json:"dry_run"`
+	OlderThanDays int   `json:"older_than_days"`
+	Retention     int64 `json:"retention"` // past their retention policy or the TTL
+	Expired       int64 `json:"expired"`   // past their expiry
+}
+
+// Purge runs the notification part of the retention job now: notifications
+// past their retention (days when no policy applies) and those past their
+// expiry. With dryRun it only counts them; a notification both old and expired
+// is counted twice.
+func (s *Service) Purge(ctx context.Context, days int, dryRun bool) (*PurgeResult, error) {
+	result := &PurgeResult{DryRun: dryRun, OlderThanDays: days}
+	var err error
+	if dryRun {
+		if result.Retention, result.Expired, err = s.repo.CountPurgeable(ctx, days); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+	if result.Retention, err = s.repo.PurgeOlderThan(ctx, days); err != nil {
+		return nil, fmt.Errorf("purge by retention: %w", err)
+	}
+	if result.Expired, err = s.repo.PurgeExpired(ctx); err != nil {
+		return nil, fmt.Errorf("purge expired: %w", err)
+	}
+	log.Info().Int64("retention", result.Retention).Int64("expired", result.Expired).Int("older_than_days", days).
+		Msg("on-demand notification purge completed")
+	return result, nil
+}
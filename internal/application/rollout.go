@@ -0,0 +1,199 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// stageRollout splits a PLATFORM fan-out into a first wave (returned for immediate
+// delivery) and a held-back remainder persisted as a pending Rollout.
+func (s *Service) stageRollout(ctx context.Context, input domain.FanoutInput, usersByTenant map[string][]string) (map[string][]string, error) {
+	if s.rolloutRepo == nil {
+		log.Warn().Str("source_event_id", input.SourceEventID).Msg("rollout plan ignored: rollout repository not configured")
+		return usersByTenant, nil
+	}
+
+	first, rest := splitTenants(usersByTenant, input.SourceEventID, input.Rollout.InitialPercent)
+	if len(rest) == 0 {
+		return usersByTenant, nil
+	}
+
+	var releaseAt *time.Time
+	if !input.Rollout.RequireConfirmation {
+		t := s.clock.Now().Add(input.Rollout.Delay)
+		releaseAt = &t
+	}
+
+	held := input
+	held.Rollout = nil
+	ro, err := s.rolloutRepo.Create(ctx, domain.Rollout{
+		Input:          held,
+		PendingTenants: rest,
+		ReleaseAt:      releaseAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	wave := make(map[string][]string, len(first))
+	for _, tk := range first {
+		wave[tk] = usersByTenant[tk]
+	}
+
+	log.Info().
+		Str("rollout_id", ro.ID.String()).
+		Int("first_wave_tenants", len(first)).
+		Int("held_tenants", len(rest)).
+		Bool("requires_confirmation", input.Rollout.RequireConfirmation).
+		Msg("platform broadcast staged")
+	return wave, nil
+}
+
+// splitTenants orders tenants by a hash seeded with the event ID, so each broadcast
+// picks a different but reproducible first wave, and returns (first, rest).
+func splitTenants(usersByTenant map[string][]string, seed string, percent int) ([]string, []string) {
+	tenants := make([]string, 0, len(usersByTenant))
+	for tk := range usersByTenant {
+		tenants = append(tenants, tk)
+	}
+	rank := func(tk string) uint64 {
+		h := fnv.New64a()
+		h.Write([]byte(seed + ":" + tk))
+		return h.Sum64()
+	}
+	sort.Slice(tenants, func(i, j int) bool { return rank(tenants[i]) < rank(tenants[j]) })
+
+	if percent <= 0 {
+		percent = 1
+	}
+	if percent >= 100 {
+		return tenants, nil
+	}
+	n := (len(tenants)*percent + 99) / 100
+	if n < 1 && len(tenants) > 0 {
+		n = 1
+	}
+	return tenants[:n], tenants[n:]
+}
+
+// ReleaseDueRollouts delivers every pending rollout whose delay has elapsed.
+// Called periodically by the background scheduler.
+func (s *Service) ReleaseDueRollouts(ctx context.Context) {
+	if s.rolloutRepo == nil {
+		return
+	}
+	due, err := s.rolloutRepo.ListDue(ctx, s.clock.Now())
+	if err != nil {
+		log.Error().Err(err).Msg("failed to list due rollouts")
+		s.jobFailed(ctx, jobRolloutRelease, err)
+		return
+	}
+	var failed error
+	for _, ro := range due {
+		if err := s.releaseRollout(ctx, ro); err != nil {
+			log.Error().Err(err).Str("rollout_id", ro.ID.String()).Msg("failed to release rollout")
+			failed = err
+		}
+	}
+	if failed != nil {
+		s.jobFailed(ctx, jobRolloutRelease, failed)
+		return
+	}
+	s.jobSucceeded(ctx, jobRolloutRelease)
+}
+
+// ListPendingRollouts returns staged broadcasts that still hold back tenants.
+func (s *Service) ListPendingRollouts(ctx context.Context) ([]domain.Rollout, error) {
+	if s.rolloutRepo == nil {
+		return nil, fmt.Errorf("staged rollouts not configured")
+	}
+	return s.rolloutRepo.ListPending(ctx)
+}
+
+// ReleaseRollout delivers the held-back tenants of a rollout immediately (manual confirmation).
+func (s *Service) ReleaseRollout(ctx context.Context, idStr string) error {
+	ro, err := s.getRollout(ctx, idStr)
+	if err != nil {
+		return err
+	}
+	return s.releaseRollout(ctx, *ro)
+}
+
+// CancelRollout stops a rollout; held-back tenants never receive the broadcast.
+func (s *Service) CancelRollout(ctx context.Context, idStr string) error {
+	ro, err := s.getRollout(ctx, idStr)
+	if err != nil {
+		return err
+	}
+	ok, err := s.rolloutRepo.Transition(ctx, ro.ID, domain.RolloutCancelled)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("rollout is no longer pending")
+	}
+	log.Info().Str("rollout_id", ro.ID.String()).Int("held_tenants", len(ro.PendingTenants)).Msg("platform rollout cancelled")
+	return nil
+}
+
+func (s *Service) getRollout(ctx context.Context, idStr string) (*domain.Rollout, error) {
+	if s.rolloutRepo == nil {
+		return nil, fmt.Errorf("staged rollouts not configured")
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rollout id: %w", err)
+	}
+	return s.rolloutRepo.Get(ctx, id)
+}
+
+// releaseRollout re-resolves users of the held-back tenants and delivers to them.
+// The rollout is marked released only once every tenant was delivered: when
+// delivery fails it stays pending as is, and tenants that could not be resolved
+// stay pending alone. Delivering again is safe, as notifications are deduplicated
+// by source event ID.
+func (s *Service) releaseRollout(ctx context.Context, ro domain.Rollout) error {
+	if ro.Status != "" && ro.Status != domain.RolloutPending {
+		return fmt.Errorf("rollout is no longer pending")
+	}
+	usersByTenant := make(map[string][]string, len(ro.PendingTenants))
+	var unresolved []string
+	for _, tk := range ro.PendingTenants {
+		userIDs, err := s.resolver.UsersByTenant(ctx, tk)
+		if err != nil {
+			log.Warn().Err(err).Str("tenant", tk).Str("rollout_id", ro.ID.String()).Msg("failed to resolve tenant for rollout, keeping it pending")
+			unresolved = append(unresolved, tk)
+			continue
+		}
+		usersByTenant[tk] = userIDs
+	}
+
+	log.Info().Str("rollout_id", ro.ID.String()).Int("tenants", len(usersByTenant)).Msg("releasing platform rollout")
+	if len(usersByTenant) > 0 {
+		if err := s.deliver(ctx, ro.Input, usersByTenant); err != nil {
+			return err
+		}
+	}
+
+	if len(unresolved) > 0 {
+		if _, err := s.rolloutRepo.SetPending(ctx, ro.ID, unresolved); err != nil {
+			return err
+		}
+		return fmt.Errorf("rollout %s: %d tenants could not be resolved and stay pending", ro.ID, len(unresolved))
+	}
+	ok, err := s.rolloutRepo.Transition(ctx, ro.ID, domain.RolloutReleased)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		log.Warn().Str("rollout_id", ro.ID.String()).Msg("rollout left pending state during release")
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"vn.io.arda/notification/internal/domain"
+	"vn.io.arda/notification/internal/testsupport"
+)
+
+func TestSplitTenants(t *testing.T) {
+	users := map[string][]string{}
+	for _, tk := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"} {
+		users[tk] = []string{"u-" + tk}
+	}
+
+	first, rest := splitTenants(users, "evt-1", 20)
+	if len(first) != 2 || len(rest) != 8 {
+		t.Fatalf("expected 2/8 split, got %d/%d", len(first), len(rest))
+	}
+
+	again, _ := splitTenants(users, "evt-1", 20)
+	if again[0] != first[0] || again[1] != first[1] {
+		t.Fatal("split should be deterministic for the same seed")
+	}
+
+	all, none := splitTenants(users, "evt-1", 100)
+	if len(all) != 10 || len(none) != 0 {
+		t.Fatalf("expected all tenants in first wave at 100%%, got %d/%d", len(all), len(none))
+	}
+
+	one, _ := splitTenants(map[string][]string{"x": nil, "y": nil}, "evt-2", 1)
+	if len(one) != 1 {
+		t.Fatalf("expected at least one tenant in first wave, got %d", len(one))
+	}
+}
+
+// rolloutStore is a domain.RolloutRepository holding one rollout.
+type rolloutStore struct{ ro domain.Rollout }
+
+func (r *rolloutStore) Create(_ context.Context, ro domain.Rollout) (*domain.Rollout, error) {
+	ro.ID, ro.Status = uuid.New(), domain.RolloutPending
+	r.ro = ro
+	return &ro, nil
+}
+
+func (r *rolloutStore) Get(context.Context, uuid.UUID) (*domain.Rollout, error) {
+	ro := r.ro
+	return &ro, nil
+}
+
+func (r *rolloutStore) ListPending(context.Context) ([]domain.Rollout, error) {
+	return []domain.Rollout{r.ro}, nil
+}
+
+func (r *rolloutStore) ListDue(context.Context, time.Time) ([]domain.Rollout, error) {
+	return []domain.Rollout{r.ro}, nil
+}
+
+func (r *rolloutStore) Transition(_ context.Context, _ uuid.UUID, status domain.RolloutStatus) (bool, error) {
+	if r.ro.Status != domain.RolloutPending {
+		return false, nil
+	}
+	r.ro.Status = status
+	return true, nil
+}
+
+func (r *rolloutStore) SetPending(_ context.Context, _ uuid.UUID, tenants []string) (bool, error) {
+	r.ro.PendingTenants = tenants
+	return r.ro.Status == domain.RolloutPending, nil
+}
+
+// flakyResolver fails to resolve the users of the tenants in down.
+type flakyResolver struct {
+	*testsupport.Resolver
+	down map[string]bool
+}
+
+func (r flakyResolver) UsersByTenant(ctx context.Context, tenantKey string) ([]string, error) {
+	if r.down[tenantKey] {
+		return nil, errors.New("iam unavailable")
+	}
+	return r.Resolver.UsersByTenant(ctx, tenantKey)
+}
+
+func TestReleaseRolloutKeepsUnresolvedTenantsPending(t *testing.T) {
+	ctx := context.Background()
+	repo := testsupport.NewRepository()
+	resolver := flakyResolver{fanoutResolver(), map[string]bool{"globex": true}}
+	store := &rolloutStore{}
+	s := NewService(repo, testsupport.NewHub(), resolver, WithRollouts(store))
+	ro, _ := store.Create(ctx, domain.Rollout{
+		Input:          domain.FanoutInput{TargetScope: domain.ScopePlatform, Type: domain.TypeSystem, Title: "t", SourceEventID: "evt-1"},
+		PendingTenants: []string{"acme", "globex"},
+	})
+
+	if err := s.ReleaseRollout(ctx, ro.ID.String()); err == nil {
+		t.Fatal("expected an error for the unresolved tenant")
+	}
+	if store.ro.Status != domain.RolloutPending || !slices.Equal(store.ro.PendingTenants, []string{"globex"}) {
+		t.Fatalf("rollout = %s %v, want PENDING [globex]", store.ro.Status, store.ro.PendingTenants)
+	}
+	if n := len(repo.Notifications()); n != 5 {
+		t.Fatalf("notifications = %d, want 5 (acme)", n)
+	}
+
+	delete(resolver.down, "globex")
+	if err := s.ReleaseRollout(ctx, ro.ID.String()); err != nil {
+		t.Fatal(err)
+	}
+	if store.ro.Status != domain.RolloutReleased || len(repo.Notifications()) != 7 {
+		t.Fatalf("rollout = %s, notifications = %d", store.ro.Status, len(repo.Notifications()))
+	}
+}
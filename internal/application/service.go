@@ -3,6 +3,8 @@ package application
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
@@ -11,28 +13,153 @@ import (
 
 // Service holds all notification use-cases.
 type Service struct {
-	repo           domain.Repository
-	prefRepo       domain.PreferenceRepository
-	hub            SSEHub
-	resolver       IAMResolver
-	emailSender    domain.EmailSender
-	templateEngine *TemplateEngine
+	repo             domain.Repository
+	prefRepo         domain.PreferenceRepository
+	reactionRepo     domain.ReactionRepository
+	rolloutRepo      domain.RolloutRepository
+	reactionPub      domain.ReactionPublisher
+	policyRepo       domain.PolicyRepository
+	policyEval       domain.PolicyEvaluator
+	policyFailClosed bool
+	traceRepo        domain.TraceRepository
+	activity         *tenantActivity
+	keyRepo          domain.EncryptionKeyRepository
+	keyProvider      domain.KeyProvider
+	keyRotator       domain.KeyRotator
+	redactor         *Redactor
+	metadataLimits   *MetadataLimits
+	hub              SSEHub
+	outboxWake       chan struct{}
+	chunkSize        int
+	strategies       map[domain.TargetScope]domain.FanoutStrategy
+	fanoutStats      *fanoutStats
+	resolver         IAMResolver
+	emailSender      domain.EmailSender
+	templateEngine   *TemplateEngine
+	templateMode     string
+	customTypes      domain.CustomTypeRepository
+	rateLimiter      atomic.Pointer[rateLimiter]
+	throttler        *throttler
+	recipientCap     *recipientCap
+	stateEvents      domain.StateEventRepository
+	webhooks         domain.WebhookRepository
+	webhookSender    domain.WebhookSender
+	webhookCfg       WebhookConfig
+	webhookWake      chan struct{}
+	chatConnectors   domain.ChatConnectorRepository
+	chatPoster       domain.ChatPoster
+	clock            domain.Clock
+	alerter          domain.Alerter
+	eventDefaults    domain.EventDefaultsRepository
+	actionRepo       domain.ActionRepository
+	actionPub        domain.ActionPublisher
+	eventPub         domain.NotificationEventPublisher
+	auditRepo        domain.AuditRepository
+	consumerPauses   domain.ConsumerPauseRepository
+	counters         domain.CounterStore
+	retention        domain.RetentionPolicyRepository
+	stats            domain.StatsRepository
+	quotas           domain.QuotaRepository
+	quotaDefaults    QuotaConfig
+	announcements    domain.AnnouncementRepository
+	escalations      domain.EscalationRepository
+	maintenance      domain.MaintenanceRepository
+	directRules      domain.DirectMessageRuleRepository
+	directDefaults   domain.DirectMessageRule
+	directLimiter    senderLimiter
 }
 
 // SSEHub is the interface for broadcasting to connected SSE clients.
 // Implementation lives in transport/http/sse_hub.go.
 type SSEHub interface {
 	Broadcast(tenantKey, userID string, notification *domain.Notification)
+	// BroadcastEvent sends a named event (e.g. "unread_count") to a user's streams.
+	BroadcastEvent(tenantKey, userID, event string, data any)
+	// BroadcastEventExcept is BroadcastEvent, skipping the stream identified by exceptClientID.
+	BroadcastEventExcept(tenantKey, userID, exceptClientID, event string, data any)
+	// BroadcastScope sends a notification to every stream of a tenant, or of all
+	// tenants when tenantKey is empty (fan-out-on-read broadcasts).
+	BroadcastScope(tenantKey string, notification *domain.Notification)
+	// BroadcastScopeEvent sends a named event to every stream of a tenant, or of
+	// all tenants when tenantKey is empty (announcements).
+	BroadcastScopeEvent(tenantKey, event string, data any)
+	// IsConnected reports whether the user currently has an open stream.
+	IsConnected(tenantKey, userID string) bool
 }
 
-// NewService creates a new application Service.
-func NewService(repo domain.Repository, prefRepo domain.PreferenceRepository, hub SSEHub, resolver IAMResolver, emailSender domain.EmailSender, templateEngine *TemplateEngine) *Service {
-	return &Service{repo: repo, prefRepo: prefRepo, hub: hub, resolver: resolver, emailSender: emailSender, templateEngine: templateEngine}
+// SSE event names pushed alongside "notification".
+const (
+	EventUnreadCount         = "unread_count"
+	EventNotificationRead    = "notification_read"
+	EventNotificationDeleted = "notification_deleted"
+	EventAnnouncement        = "announcement"
+	EventAnnouncementRemoved = "announcement_removed"
+)
+
+type originClientKey struct{}
+
+// WithOriginClient returns a context carrying the SSE client ID of the device
+// performing a state change. Sync events skip that client.
+func WithOriginClient(ctx context.Context, clientID string) context.Context {
+	if clientID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, originClientKey{}, clientID)
+}
+
+func originClient(ctx context.Context) string {
+	id, _ := ctx.Value(originClientKey{}).(string)
+	return id
+}
+
+// NewService creates a new application Service. hub and resolver may be nil: a
+// nil hub disables real-time delivery and a nil resolver resolves only USER
+// targets. Every other collaborator is an Option and defaults to a no-op.
+func NewService(repo domain.Repository, hub SSEHub, resolver IAMResolver, opts ...Option) *Service {
+	if hub == nil {
+		hub = noopHub{}
+	}
+	if resolver == nil {
+		resolver = noopResolver{}
+	}
+	s := &Service{repo: repo, prefRepo: noopPreferences{}, reactionRepo: noopReactions{}, actionRepo: noopActions{}, hub: hub, outboxWake: make(chan struct{}, 1), chunkSize: DefaultFanoutChunkSize, fanoutStats: newFanoutStats(), resolver: resolver, clock: domain.SystemClock{}, alerter: noopAlerter{}}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// SetRolloutRepo enables staged PLATFORM broadcasts.
+// Without it, a FanoutInput.Rollout plan is ignored and all tenants receive the broadcast at once.
+func (s *Service) SetRolloutRepo(r domain.RolloutRepository) {
+	s.rolloutRepo = r
+}
+
+// SetReactionPublisher enables emitting reactions to the originating service.
+// Reactions are only stored (and queryable via API) when no publisher is set.
+func (s *Service) SetReactionPublisher(p domain.ReactionPublisher) {
+	s.reactionPub = p
+}
+
+// SetClock replaces the clock used for read/delete times, purge cutoffs and scheduling.
+func (s *Service) SetClock(c domain.Clock) {
+	s.clock = c
 }
 
 // Create processes a single notification (from direct API calls or USER-scoped Kafka events),
 // persists it, and broadcasts via SSE if the user is connected.
 func (s *Service) Create(ctx context.Context, input domain.CreateNotificationInput) (*domain.Notification, error) {
+	input.Metadata = s.redactMetadata(ctx, input.SourceEventID, withDisplay(input.Metadata, input.Link, input.Icon, input.Sender))
+	metadata, err := s.checkMetadata(ctx, input.SourceEventID, input.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	input.Metadata = metadata
+	if s.quotas != nil {
+		if _, err := s.enforceQuota(ctx, input.SourceEventID, input.TenantKey, 1, nil); err != nil {
+			return nil, err
+		}
+	}
 	n, err := s.repo.Create(ctx, input)
 	if err != nil {
 		return nil, fmt.Errorf("create notification: %w", err)
@@ -41,10 +168,12 @@ func (s *Service) Create(ctx context.Context, input domain.CreateNotificationInp
 		// Duplicate source_event_id — idempotent, not an error.
 		return nil, nil
 	}
+	s.adjustUnread(ctx, insertedDeltas([]*domain.Notification{n})...)
+	s.recordUsage(ctx, map[string]int64{n.TenantKey: 1})
 
-	// Non-blocking SSE broadcast + email delivery
-	go s.hub.Broadcast(n.TenantKey, n.UserID, n)
-	go s.sendEmailIfNeeded(context.Background(), n)
+	// Real-time delivery (SSE + email) happens via the outbox dispatcher.
+	s.wakeOutbox()
+	s.forwardToChat(ctx, n.TenantKey, n)
 
 	log.Info().
 		Str("id", n.ID.String()).
@@ -60,105 +189,277 @@ func (s *Service) Create(ctx context.Context, input domain.CreateNotificationInp
 // then batch-inserts one notification row per user (fan-out on write).
 // This is the primary entry point for Kafka-driven notifications.
 func (s *Service) Fanout(ctx context.Context, input domain.FanoutInput) error {
+	input.Metadata = s.redactMetadata(ctx, input.SourceEventID, withDisplay(input.Metadata, input.Link, input.Icon, input.Sender))
+	input.Template = s.redactTemplate(ctx, input.SourceEventID, input.Template)
+	input, err := s.applyType(ctx, input)
+	if err != nil {
+		s.Trace(ctx, input.SourceEventID, domain.TraceFailed, map[string]any{"stage": "type_validation", "error": err.Error()})
+		return err
+	}
+	if rl := s.rateLimiter.Load(); rl != nil {
+		admitted, err := rl.admit(ctx, input.TenantKey, sourceTopic(ctx))
+		if err != nil {
+			return err
+		}
+		if !admitted {
+			s.Trace(ctx, input.SourceEventID, domain.TraceRateLimited, map[string]any{
+				"tenant": input.TenantKey, "topic": sourceTopic(ctx), "policy": rl.cfg.Policy,
+			})
+			log.Warn().Str("tenant", input.TenantKey).Str("topic", sourceTopic(ctx)).
+				Str("source_event_id", input.SourceEventID).Msg("fan-out dropped by rate limit")
+			return nil
+		}
+	}
+	input = s.applyTemplate(ctx, input)
+	if input.Metadata, err = s.checkMetadata(ctx, input.SourceEventID, input.Metadata); err != nil {
+		s.Trace(ctx, input.SourceEventID, domain.TraceFailed, map[string]any{"stage": "metadata_validation", "error": err.Error()})
+		return err
+	}
+	if held, err := s.holdForMaintenance(ctx, input); held || err != nil {
+		return err
+	}
+	if s.fanoutOnRead(input) {
+		return s.broadcast(ctx, input)
+	}
+
 	// Resolve target scope to (tenantKey → []userID) map.
 	usersByTenant, err := s.resolveTargets(ctx, input)
 	if err != nil {
+		s.Trace(ctx, input.SourceEventID, domain.TraceFailed, map[string]any{"stage": "scope_resolution", "error": err.Error()})
 		return fmt.Errorf("resolve fan-out targets: %w", err)
 	}
+	s.Trace(ctx, input.SourceEventID, domain.TraceScopeResolved, map[string]any{
+		"scope":     input.TargetScope,
+		"target_id": input.TargetID,
+		"targets":   len(input.AllTargets()),
+		"excluded":  len(input.Exclude),
+		"tenants":   len(usersByTenant),
+		"users":     countUsers(usersByTenant),
+	})
+	if n := countUsers(usersByTenant); s.overRecipientCap(n) {
+		return s.spillOver(ctx, input, n)
+	}
 
-	// Filter out users who have opted out of in-app notifications for this type.
-	usersByTenant = s.filterMutedUsers(ctx, usersByTenant, input.Type)
+	// Staged PLATFORM broadcast: deliver the first wave now, hold back the rest.
+	if input.TargetScope == domain.ScopePlatform && input.Rollout != nil {
+		usersByTenant, err = s.stageRollout(ctx, input, usersByTenant)
+		if err != nil {
+			return fmt.Errorf("stage platform rollout: %w", err)
+		}
+	}
 
-	// Build one CreateNotificationInput per user.
-	var batch []domain.CreateNotificationInput
-	for tenantKey, userIDs := range usersByTenant {
-		for _, uid := range userIDs {
-			batch = append(batch, domain.CreateNotificationInput{
-				TenantKey:     tenantKey,
-				UserID:        uid,
-				Type:          input.Type,
-				Title:         input.Title,
-				Body:          input.Body,
-				Metadata:      input.Metadata,
-				SourceEventID: input.SourceEventID,
+	return s.deliver(ctx, input, usersByTenant)
+}
+
+// deliver filters muted users, batch-inserts one row per recipient and pushes
+// the inserted notifications over SSE / email.
+func (s *Service) deliver(ctx context.Context, input domain.FanoutInput, usersByTenant map[string][]string) error {
+	// Filter out users who have opted out of in-app notifications for this type/category.
+	usersByTenant = s.filterMutedUsers(ctx, usersByTenant, input.Type, input.Category)
+	usersByTenant, policyMetadata := s.applyPolicies(ctx, input, usersByTenant)
+	if s.throttler != nil {
+		var deduplicated, throttled int
+		usersByTenant, deduplicated, throttled = s.throttler.filter(s.clock.Now(), input, usersByTenant)
+		if deduplicated+throttled > 0 {
+			s.Trace(ctx, input.SourceEventID, domain.TraceThrottled, map[string]any{
+				"deduplicated": deduplicated, "throttled": throttled,
 			})
 		}
 	}
 
-	if len(batch) == 0 {
+	total := countUsers(usersByTenant)
+	s.Trace(ctx, input.SourceEventID, domain.TraceRecipientsFiltered, map[string]any{
+		"tenants":    len(usersByTenant),
+		"recipients": total,
+	})
+	if total == 0 {
 		log.Warn().
 			Str("scope", string(input.TargetScope)).
 			Str("target_id", input.TargetID).
 			Msg("fan-out resolved to zero users, skipping")
 		return nil
 	}
+	usersByTenant, err := s.checkQuota(ctx, input, usersByTenant)
+	if err != nil || len(usersByTenant) == 0 {
+		return err
+	}
+	total = countUsers(usersByTenant)
 
-	insertedResults, err := s.repo.BatchCreate(ctx, batch)
-	if err != nil {
-		return fmt.Errorf("batch create notifications: %w", err)
+	// Stream users into fixed-size chunks so a large tenant never becomes one giant INSERT.
+	// Each chunk commits (with its outbox entries) independently; a retried fan-out skips
+	// already-inserted rows via the source_event_id conflict clause.
+	started := time.Now()
+	chunkSize := s.chunkSize
+	s.fanoutStats.fanouts.Add(1)
+	title, body := s.storedText(ctx, input)
+
+	var (
+		chunk      = make([]domain.CreateNotificationInput, 0, min(chunkSize, total))
+		written    int
+		inserted   int
+		duplicates int
+		chunks     int
+		// Tenants with newly inserted rows; a retried fan-out is not forwarded again.
+		insertedTenants = make(map[string]bool)
+	)
+	flush := func() error {
+		chunkStart := time.Now()
+		result, err := s.repo.BatchCreate(ctx, chunk)
+		s.fanoutStats.chunkLatency.Observe(time.Since(chunkStart))
+		if err != nil {
+			s.Trace(ctx, input.SourceEventID, domain.TraceFailed, map[string]any{
+				"stage": "batch_insert", "error": err.Error(), "written": written, "total": total,
+			})
+			return fmt.Errorf("batch create notifications (chunk %d, %d/%d rows written): %w", chunks+1, written, total, err)
+		}
+		chunks++
+		written += len(chunk)
+		inserted += len(result.Inserted)
+		duplicates += len(result.Duplicates)
+		for _, n := range result.Inserted {
+			insertedTenants[n.TenantKey] = true
+		}
+		s.fanoutStats.chunks.Add(1)
+		s.fanoutStats.rows.Add(uint64(len(chunk)))
+		s.fanoutStats.inserted.Add(uint64(len(result.Inserted)))
+		s.fanoutStats.duplicates.Add(uint64(len(result.Duplicates)))
+		if len(result.Inserted) > 0 {
+			s.adjustUnread(ctx, insertedDeltas(result.Inserted)...)
+			s.recordUsage(ctx, createdByTenant(result.Inserted))
+			s.wakeOutbox()
+		}
+		if total > chunkSize {
+			log.Debug().
+				Str("source_event_id", input.SourceEventID).
+				Int("chunk", chunks).
+				Int("written", written).
+				Int("total", total).
+				Dur("chunk_duration", time.Since(chunkStart)).
+				Msg("fan-out chunk written")
+		}
+		chunk = chunk[:0]
+		return nil
 	}
 
-	for _, n := range insertedResults {
-		go s.hub.Broadcast(n.TenantKey, n.UserID, n)
-		go s.sendEmailIfNeeded(context.Background(), n)
+	for tenantKey, userIDs := range usersByTenant {
+		metadata := input.Metadata
+		if m, ok := policyMetadata[tenantKey]; ok {
+			metadata = m
+		}
+		for _, uid := range userIDs {
+			chunk = append(chunk, domain.CreateNotificationInput{
+				TenantKey:     tenantKey,
+				UserID:        uid,
+				Type:          input.Type,
+				Category:      input.Category,
+				Priority:      input.Priority,
+				Title:         title,
+				Body:          body,
+				Metadata:      metadata,
+				SourceEventID: input.SourceEventID,
+			})
+			if len(chunk) == chunkSize {
+				if err := flush(); err != nil {
+					s.fanoutStats.failed.Add(1)
+					return err
+				}
+			}
+		}
+	}
+	if len(chunk) > 0 {
+		if err := flush(); err != nil {
+			s.fanoutStats.failed.Add(1)
+			return err
+		}
+	}
+	s.fanoutStats.fanoutLatency.Observe(time.Since(started))
+	for tenantKey := range insertedTenants {
+		metadata := input.Metadata
+		if m, ok := policyMetadata[tenantKey]; ok {
+			metadata = m
+		}
+		s.forwardToChat(ctx, tenantKey, &domain.Notification{
+			TenantKey:     tenantKey,
+			Type:          input.Type,
+			Category:      input.Category,
+			Priority:      input.Priority,
+			Title:         input.Title,
+			Body:          input.Body,
+			Metadata:      metadata,
+			SourceEventID: input.SourceEventID,
+		})
+	}
+	s.Trace(ctx, input.SourceEventID, domain.TraceBatchInserted, map[string]any{
+		"rows":        total,
+		"chunks":      chunks,
+		"inserted":    inserted,
+		"duplicates":  duplicates,
+		"duration_ms": time.Since(started).Milliseconds(),
+	})
+	if duplicates > 0 {
+		// Idempotent re-delivery (retry, replay or redelivered event), not a failure.
+		log.Info().
+			Str("source_event_id", input.SourceEventID).
+			Int("duplicates", duplicates).
+			Int("inserted", inserted).
+			Msg("fan-out skipped recipients that already have this event")
 	}
 
 	log.Info().
 		Str("scope", string(input.TargetScope)).
 		Str("target_id", input.TargetID).
-		Int("batch_size", len(batch)).
-		Int("inserted", len(insertedResults)).
+		Int("batch_size", total).
+		Int("chunks", chunks).
+		Int("inserted", inserted).
+		Int("duplicates", duplicates).
+		Dur("duration", time.Since(started)).
 		Msg("fan-out notifications created and broadcasted")
 
 	return nil
 }
 
-// resolveTargets maps a FanoutInput to (tenantKey → []userID) using the IAMResolver.
+// resolveTargets converts the input's targets into a map of tenantKey → []userID.
+// Composite inputs are unioned and deduplicated; Exclude is applied last, after
+// the performer (OriginUserID) has been added.
 func (s *Service) resolveTargets(ctx context.Context, input domain.FanoutInput) (map[string][]string, error) {
-	result := make(map[string][]string)
-
-	switch input.TargetScope {
-	case domain.ScopeUser:
-		result[input.TenantKey] = []string{input.TargetID}
+	targets := input.AllTargets()
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no fan-out target")
+	}
 
-	case domain.ScopeTenant:
-		userIDs, err := s.resolver.UsersByTenant(ctx, input.TenantKey)
-		if err != nil {
-			return nil, fmt.Errorf("UsersByTenant(%s): %w", input.TenantKey, err)
+	result := make(map[string][]string)
+	seen := make(map[string]map[string]struct{})
+	add := func(tenantKey string, userIDs []string) {
+		set := seen[tenantKey]
+		if set == nil {
+			set = make(map[string]struct{}, len(userIDs))
+			seen[tenantKey] = set
 		}
-		result[input.TenantKey] = userIDs
-
-	case domain.ScopeRole:
-		userIDs, err := s.resolver.UsersByRole(ctx, input.TenantKey, input.TargetID)
-		if err != nil {
-			return nil, fmt.Errorf("UsersByRole(%s, %s): %w", input.TenantKey, input.TargetID, err)
+		for _, uid := range userIDs {
+			if _, dup := set[uid]; dup {
+				continue
+			}
+			set[uid] = struct{}{}
+			result[tenantKey] = append(result[tenantKey], uid)
 		}
-		result[input.TenantKey] = userIDs
+	}
 
-	case domain.ScopePlatform:
-		all, err := s.resolver.AllActiveUsers(ctx)
+	for _, t := range targets {
+		resolved, err := s.resolveTarget(ctx, input.TenantKey, t)
 		if err != nil {
-			return nil, fmt.Errorf("AllActiveUsers: %w", err)
+			return nil, err
 		}
-		for tk, uids := range all {
-			result[tk] = uids
+		for tk, uids := range resolved {
+			add(tk, uids)
 		}
-
-	default:
-		return nil, fmt.Errorf("unknown target scope: %q", input.TargetScope)
 	}
 
 	// Post-resolution: Always include the performer (OriginUserID).
 	if input.OriginUserID != "" {
 		found := false
-		for _, uids := range result {
-			for _, uid := range uids {
-				if uid == input.OriginUserID {
-					found = true
-					break
-				}
-			}
-			if found {
+		for _, set := range seen {
+			if _, ok := set[input.OriginUserID]; ok {
+				found = true
 				break
 			}
 		}
@@ -168,52 +469,312 @@ func (s *Service) resolveTargets(ctx context.Context, input domain.FanoutInput)
 			if tenant == "" {
 				tenant = "master"
 			}
-			result[tenant] = append(result[tenant], input.OriginUserID)
+			add(tenant, []string{input.OriginUserID})
+		}
+	}
+
+	if len(input.Exclude) > 0 {
+		excluded := make(map[string]map[string]struct{})
+		for _, t := range input.Exclude {
+			resolved, err := s.resolveTarget(ctx, input.TenantKey, t)
+			if err != nil {
+				return nil, fmt.Errorf("exclude: %w", err)
+			}
+			for tk, uids := range resolved {
+				if excluded[tk] == nil {
+					excluded[tk] = make(map[string]struct{}, len(uids))
+				}
+				for _, uid := range uids {
+					excluded[tk][uid] = struct{}{}
+				}
+			}
+		}
+		for tk, uids := range result {
+			drop := excluded[tk]
+			if len(drop) == 0 {
+				continue
+			}
+			kept := uids[:0]
+			for _, uid := range uids {
+				if _, ok := drop[uid]; !ok {
+					kept = append(kept, uid)
+				}
+			}
+			if len(kept) == 0 {
+				delete(result, tk)
+			} else {
+				result[tk] = kept
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// resolveTarget resolves a single scope/ID pair. tenantKey scopes USER, TENANT,
+// ROLE and GROUP targets; PLATFORM spans every tenant.
+func (s *Service) resolveTarget(ctx context.Context, tenantKey string, t domain.FanoutTarget) (map[string][]string, error) {
+	switch t.Scope {
+	case domain.ScopeUser:
+		if t.ID == "" {
+			return nil, fmt.Errorf("USER target requires an id")
+		}
+		return map[string][]string{tenantKey: {t.ID}}, nil
+
+	case domain.ScopeTenant:
+		userIDs, err := s.resolver.UsersByTenant(ctx, tenantKey)
+		if err != nil {
+			return nil, fmt.Errorf("UsersByTenant(%s): %w", tenantKey, err)
+		}
+		return map[string][]string{tenantKey: userIDs}, nil
+
+	case domain.ScopeRole:
+		userIDs, err := s.resolver.UsersByRole(ctx, tenantKey, t.ID)
+		if err != nil {
+			return nil, fmt.Errorf("UsersByRole(%s, %s): %w", tenantKey, t.ID, err)
+		}
+		return map[string][]string{tenantKey: userIDs}, nil
+
+	case domain.ScopeGroup:
+		userIDs, err := s.resolver.UsersByGroup(ctx, tenantKey, t.ID)
+		if err != nil {
+			return nil, fmt.Errorf("UsersByGroup(%s, %s): %w", tenantKey, t.ID, err)
+		}
+		return map[string][]string{tenantKey: userIDs}, nil
+
+	case domain.ScopePlatform:
+		all, err := s.resolver.AllActiveUsers(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("AllActiveUsers: %w", err)
 		}
+		return all, nil
+
+	default:
+		return nil, fmt.Errorf("unknown target scope: %q", t.Scope)
+	}
+}
+
+// maxScopeSample caps the number of user IDs returned per tenant by ResolveScope.
+const maxScopeSample = 50
+
+// ResolveScope performs a dry-run of target resolution and reports recipient counts
+// (optionally with a sample of user IDs). No notification is created.
+func (s *Service) ResolveScope(ctx context.Context, in ScopeResolveInput) (*ScopeResolution, error) {
+	input := domain.FanoutInput{
+		TargetScope:  domain.TargetScope(in.TargetScope),
+		TargetID:     in.TargetID,
+		TenantKey:    in.TenantKey,
+		OriginUserID: in.OriginUserID,
+		Type:         domain.NotificationType(in.Type),
+		Category:     in.Category,
+		Targets:      in.Targets,
+		Exclude:      in.Exclude,
+	}
+	usersByTenant, err := s.resolveTargets(ctx, input)
+	if err != nil {
+		return nil, err
 	}
 
+	result := &ScopeResolution{Tenants: make(map[string]TenantReach, len(usersByTenant))}
+	if in.ApplyPreferences && input.Type != "" {
+		before := countUsers(usersByTenant)
+		usersByTenant = s.filterMutedUsers(ctx, usersByTenant, input.Type, input.Category)
+		result.MutedRecipients = before - countUsers(usersByTenant)
+	}
+
+	sampleSize := in.SampleSize
+	if sampleSize > maxScopeSample {
+		sampleSize = maxScopeSample
+	}
+	for tk, uids := range usersByTenant {
+		reach := TenantReach{Recipients: len(uids)}
+		if sampleSize > 0 {
+			n := min(sampleSize, len(uids))
+			reach.Sample = append([]string(nil), uids[:n]...)
+		}
+		result.Tenants[tk] = reach
+		result.TotalRecipients += len(uids)
+	}
 	return result, nil
 }
 
+func countUsers(usersByTenant map[string][]string) int {
+	total := 0
+	for _, uids := range usersByTenant {
+		total += len(uids)
+	}
+	return total
+}
+
 // List returns paginated notifications for a user.
 func (s *Service) List(ctx context.Context, filter domain.NotificationFilter) ([]*domain.Notification, error) {
 	if filter.Limit <= 0 || filter.Limit > 100 {
 		filter.Limit = 20
 	}
-	return s.repo.List(ctx, filter)
+	ns, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	s.renderNotifications(ctx, filter.Locale, ns)
+	return ns, nil
+}
+
+// ListAsOf reconstructs a user's inbox as it was at filter.AsOf, for audits and
+// disputes. Only instants within the retention window can be reconstructed.
+func (s *Service) ListAsOf(ctx context.Context, filter domain.NotificationFilter, auditor string) ([]*domain.Notification, error) {
+	if filter.AsOf == nil || filter.AsOf.After(s.clock.Now()) {
+		return nil, fmt.Errorf("as_of must be a past timestamp")
+	}
+	if filter.UserID == "" {
+		return nil, fmt.Errorf("user is required")
+	}
+	log.Info().
+		Str("tenant", filter.TenantKey).
+		Str("user", filter.UserID).
+		Str("auditor", auditor).
+		Time("as_of", *filter.AsOf).
+		Msg("as-of inbox listing requested")
+	return s.List(ctx, filter)
 }
 
 // CountUnread returns the unread badge count for a user.
 func (s *Service) CountUnread(ctx context.Context, tenantKey, userID string) (int64, error) {
-	return s.repo.CountUnread(ctx, tenantKey, userID)
+	return s.unreadCount(ctx, tenantKey, userID)
+}
+
+// Counts returns the user's notification counts by type, priority and read state.
+func (s *Service) Counts(ctx context.Context, tenantKey, userID string) (domain.NotificationCounts, error) {
+	groups, err := s.repo.CountGroups(ctx, tenantKey, userID)
+	if err != nil {
+		return domain.NotificationCounts{}, err
+	}
+	return domain.SummarizeCounts(groups), nil
 }
 
 // MarkRead marks a single notification as read.
 func (s *Service) MarkRead(ctx context.Context, idStr, tenantKey, userID string) error {
-	id, err := uuid.Parse(idStr)
+	id, err := domain.ParseID(idStr)
 	if err != nil {
 		return fmt.Errorf("invalid notification id: %w", err)
 	}
-	return s.repo.MarkRead(ctx, id, tenantKey, userID)
+	if err := s.repo.MarkRead(ctx, id, tenantKey, userID); err != nil {
+		return err
+	}
+	s.adjustUnread(ctx, readDelta(tenantKey, userID, 1))
+	s.cancelEscalations(ctx, tenantKey, userID, id)
+	s.emitEvents(ctx, domain.WebhookEventInput{TenantKey: tenantKey, Event: domain.WebhookNotificationRead,
+		Payload: map[string]any{"id": domain.FormatID(id), "user_id": userID, "read_at": s.clock.Now()}})
+	go s.hub.BroadcastEventExcept(tenantKey, userID, originClient(ctx), EventNotificationRead,
+		map[string]any{"ids": []string{domain.FormatID(id)}})
+	go s.pushUnreadCount(tenantKey, userID)
+	return nil
+}
+
+// MaxReadStateBatch bounds the items accepted by one SyncReadStates call.
+const MaxReadStateBatch = 500
+
+// SyncReadStates applies reads recorded offline by a client. Applying the same batch
+// twice is a no-op; when an ID is read on several devices the earliest read_at wins.
+// Read times in the future (clock skew) or missing are clamped to now.
+// Returns the IDs that became read.
+func (s *Service) SyncReadStates(ctx context.Context, tenantKey, userID string, states []domain.ReadState) ([]uuid.UUID, error) {
+	if len(states) > MaxReadStateBatch {
+		return nil, fmt.Errorf("too many read states: %d (max %d)", len(states), MaxReadStateBatch)
+	}
+
+	now := s.clock.Now()
+	earliest := make(map[uuid.UUID]time.Time, len(states))
+	for _, st := range states {
+		readAt := st.ReadAt
+		if readAt.IsZero() || readAt.After(now) {
+			readAt = now
+		}
+		if prev, ok := earliest[st.ID]; !ok || readAt.Before(prev) {
+			earliest[st.ID] = readAt
+		}
+	}
+	deduped := make([]domain.ReadState, 0, len(earliest))
+	for id, readAt := range earliest {
+		deduped = append(deduped, domain.ReadState{ID: id, ReadAt: readAt})
+	}
+
+	newlyRead, err := s.repo.ApplyReadStates(ctx, tenantKey, userID, deduped)
+	if err != nil {
+		return nil, err
+	}
+	if len(newlyRead) > 0 {
+		s.adjustUnread(ctx, readDelta(tenantKey, userID, len(newlyRead)))
+		s.cancelEscalations(ctx, tenantKey, userID, newlyRead...)
+		go s.hub.BroadcastEventExcept(tenantKey, userID, originClient(ctx), EventNotificationRead,
+			map[string]any{"ids": domain.FormatIDs(newlyRead)})
+		go s.pushUnreadCount(tenantKey, userID)
+
+		events := make([]domain.WebhookEventInput, len(newlyRead))
+		for i, id := range newlyRead {
+			events[i] = domain.WebhookEventInput{TenantKey: tenantKey, Event: domain.WebhookNotificationRead,
+				Payload: map[string]any{"id": domain.FormatID(id), "user_id": userID, "read_at": earliest[id]}}
+		}
+		s.emitEvents(ctx, events...)
+	}
+	return newlyRead, nil
 }
 
 // MarkAllRead marks all notifications for a user as read.
 func (s *Service) MarkAllRead(ctx context.Context, tenantKey, userID string) (int64, error) {
-	return s.repo.MarkAllRead(ctx, tenantKey, userID)
+	count, err := s.repo.MarkAllRead(ctx, tenantKey, userID)
+	if err != nil {
+		return 0, err
+	}
+	if count > 0 {
+		// count includes archived rows, which the unread count leaves out.
+		s.invalidateUnread(ctx, domain.CounterKey{TenantKey: tenantKey, UserID: userID})
+		s.cancelEscalations(ctx, tenantKey, userID)
+		go s.hub.BroadcastEventExcept(tenantKey, userID, originClient(ctx), EventNotificationRead,
+			map[string]any{"all": true})
+		go s.pushUnreadCount(tenantKey, userID)
+		s.emitEvents(ctx, domain.WebhookEventInput{TenantKey: tenantKey, Event: domain.WebhookNotificationRead,
+			Payload: map[string]any{"all": true, "user_id": userID, "count": count, "read_at": s.clock.Now()}})
+	}
+	return count, nil
 }
 
 // Delete removes a notification (must belong to the requesting user).
 func (s *Service) Delete(ctx context.Context, idStr, tenantKey, userID string) error {
-	id, err := uuid.Parse(idStr)
+	id, err := domain.ParseID(idStr)
 	if err != nil {
 		return fmt.Errorf("invalid notification id: %w", err)
 	}
-	return s.repo.Delete(ctx, id, tenantKey, userID)
+	if err := s.repo.Delete(ctx, id, tenantKey, userID); err != nil {
+		return err
+	}
+	// The deleted notification may have been read already.
+	s.invalidateUnread(ctx, domain.CounterKey{TenantKey: tenantKey, UserID: userID})
+	s.cancelEscalations(ctx, tenantKey, userID, id)
+	go s.hub.BroadcastEventExcept(tenantKey, userID, originClient(ctx), EventNotificationDeleted,
+		map[string]any{"ids": []string{domain.FormatID(id)}})
+	s.emitEvents(ctx, domain.WebhookEventInput{TenantKey: tenantKey, Event: domain.WebhookNotificationDeleted,
+		Payload: map[string]any{"id": domain.FormatID(id), "user_id": userID, "deleted_at": s.clock.Now()}})
+	go s.pushUnreadCount(tenantKey, userID)
+	return nil
+}
+
+// pushUnreadCount recomputes the badge count and pushes it to the user's open streams.
+// Skipped when the user has no stream, so offline fan-out recipients cost no query.
+func (s *Service) pushUnreadCount(tenantKey, userID string) {
+	if !s.hub.IsConnected(tenantKey, userID) {
+		return
+	}
+	count, err := s.unreadCount(context.Background(), tenantKey, userID)
+	if err != nil {
+		log.Warn().Err(err).Str("user", userID).Msg("failed to recompute unread count for SSE push")
+		return
+	}
+	s.hub.BroadcastEvent(tenantKey, userID, EventUnreadCount, map[string]int64{"count": count})
 }
 
 // ExecuteAction runs an action button attached to a notification.
 func (s *Service) ExecuteAction(ctx context.Context, idStr, tenantKey, userID string, actionIndex int) (map[string]any, error) {
-	id, err := uuid.Parse(idStr)
+	id, err := domain.ParseID(idStr)
 	if err != nil {
 		return nil, fmt.Errorf("invalid notification id: %w", err)
 	}
@@ -237,7 +798,10 @@ func (s *Service) ExecuteAction(ctx context.Context, idStr, tenantKey, userID st
 		return nil, fmt.Errorf("action execution failed: %w", err)
 	}
 
-	_ = s.repo.MarkRead(ctx, id, tenantKey, userID)
+	if err := s.repo.MarkRead(ctx, id, tenantKey, userID); err == nil {
+		s.adjustUnread(ctx, readDelta(tenantKey, userID, 1))
+		go s.pushUnreadCount(tenantKey, userID)
+	}
 
 	go s.hub.Broadcast(tenantKey, userID, &domain.Notification{
 		ID: id, TenantKey: tenantKey, UserID: userID,
@@ -248,18 +812,180 @@ func (s *Service) ExecuteAction(ctx context.Context, idStr, tenantKey, userID st
 	return result, nil
 }
 
+// React records a user's structured response (acknowledge / reject) to a notification.
+// The notification is marked read, and the reaction is published when a publisher is configured.
+func (s *Service) React(ctx context.Context, idStr, tenantKey, userID string, input ReactionInput) (*domain.Reaction, error) {
+	id, err := domain.ParseID(idStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notification id: %w", err)
+	}
+
+	response := domain.ReactionResponse(input.Response)
+	switch response {
+	case domain.ReactionAcknowledge, domain.ReactionReject:
+	default:
+		return nil, fmt.Errorf("invalid reaction response: %q", input.Response)
+	}
+
+	n, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("notification not found: %w", err)
+	}
+	if n.TenantKey != tenantKey || n.UserID != userID {
+		return nil, fmt.Errorf("notification does not belong to user")
+	}
+	if policy := n.ReactionPolicy(); policy != nil && policy.RequireCommentOnReject &&
+		response == domain.ReactionReject && input.Comment == "" {
+		return nil, fmt.Errorf("a comment is required when rejecting this notification")
+	}
+
+	saved, err := s.reactionRepo.Create(ctx, domain.Reaction{
+		NotificationID: id,
+		TenantKey:      tenantKey,
+		UserID:         userID,
+		Response:       response,
+		Comment:        input.Comment,
+		SourceEventID:  n.SourceEventID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("save reaction: %w", err)
+	}
+	if saved == nil {
+		return nil, fmt.Errorf("notification already has a reaction from this user")
+	}
+
+	if err := s.repo.MarkRead(ctx, id, tenantKey, userID); err == nil {
+		s.adjustUnread(ctx, readDelta(tenantKey, userID, 1))
+		go s.pushUnreadCount(tenantKey, userID)
+	}
+
+	if s.reactionPub != nil {
+		go func(r domain.Reaction) {
+			if err := s.reactionPub.PublishReaction(context.Background(), r); err != nil {
+				log.Error().Err(err).Str("id", r.ID.String()).Msg("failed to publish reaction")
+			}
+		}(*saved)
+	}
+
+	log.Info().Str("id", id.String()).Str("response", string(response)).Msg("notification reaction recorded")
+	return saved, nil
+}
+
+// ListReactions returns reactions for a notification owned by the requesting user.
+func (s *Service) ListReactions(ctx context.Context, idStr, tenantKey, userID string) ([]domain.Reaction, error) {
+	id, err := domain.ParseID(idStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notification id: %w", err)
+	}
+	n, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("notification not found: %w", err)
+	}
+	if n.TenantKey != tenantKey || n.UserID != userID {
+		return nil, fmt.Errorf("notification does not belong to user")
+	}
+	return s.reactionRepo.ListByNotification(ctx, id)
+}
+
+// ListReactionsBySourceEvent returns all reactions to notifications created from a source event.
+// Used by originating services to collect approval-style responses.
+func (s *Service) ListReactionsBySourceEvent(ctx context.Context, tenantKey, sourceEventID string) ([]domain.Reaction, error) {
+	if sourceEventID == "" {
+		return nil, fmt.Errorf("source_event_id is required")
+	}
+	return s.reactionRepo.ListBySourceEvent(ctx, tenantKey, sourceEventID)
+}
+
 func (s *Service) callActionURL(ctx context.Context, action domain.Action) (map[string]any, error) {
 	return map[string]any{"status": "executed", "action": action.Action, "url": action.URL}, nil
 }
 
 // PurgeTTL deletes old notifications. Called by a background scheduler.
 func (s *Service) PurgeTTL(ctx context.Context, days int) {
+	cutoff := s.clock.Now().AddDate(0, 0, -days)
 	count, err := s.repo.PurgeOlderThan(ctx, days)
 	if err != nil {
 		log.Error().Err(err).Msg("notification TTL purge failed")
+		s.jobFailed(ctx, jobPurgeTTL, err)
 		return
 	}
 	log.Info().Int64("deleted", count).Int("older_than_days", days).Msg("notification TTL purge completed")
+
+	if s.traceRepo != nil {
+		traces, err := s.traceRepo.PurgeBefore(ctx, cutoff)
+		if err != nil {
+			log.Error().Err(err).Msg("event trace purge failed")
+			s.jobFailed(ctx, jobPurgeTTL, err)
+			return
+		}
+		log.Info().Int64("deleted", traces).Int("older_than_days", days).Msg("event trace purge completed")
+	}
+
+	if s.stateEvents != nil {
+		events, err := s.stateEvents.PurgeBefore(ctx, cutoff)
+		if err != nil {
+			log.Error().Err(err).Msg("state event purge failed")
+			s.jobFailed(ctx, jobPurgeTTL, err)
+			return
+		}
+		log.Info().Int64("deleted", events).Int("older_than_days", days).Msg("state event purge completed")
+	}
+
+	if s.webhooks != nil {
+		deliveries, err := s.webhooks.PurgeBefore(ctx, cutoff)
+		if err != nil {
+			log.Error().Err(err).Msg("webhook delivery purge failed")
+			s.jobFailed(ctx, jobPurgeTTL, err)
+			return
+		}
+		log.Info().Int64("deleted", deliveries).Int("older_than_days", days).Msg("webhook delivery purge completed")
+	}
+	s.jobSucceeded(ctx, jobPurgeTTL)
+}
+
+// EnsurePartitions creates the monthly notification partitions up to monthsAhead
+// months ahead. Called at startup and by the daily scheduler.
+func (s *Service) EnsurePartitions(ctx context.Context, monthsAhead int) {
+	created, err := s.repo.EnsurePartitions(ctx, monthsAhead)
+	if err != nil {
+		log.Error().Err(err).Msg("notification partition maintenance failed")
+		s.jobFailed(ctx, jobPartitions, err)
+		return
+	}
+	s.jobSucceeded(ctx, jobPartitions)
+	if created > 0 {
+		log.Info().Int("created", created).Int("months_ahead", monthsAhead).Msg("notification partitions created")
+	}
+}
+
+// ArchiveOverflow moves notifications beyond each user's newest keep into the
+// archive table. Called by a background scheduler.
+func (s *Service) ArchiveOverflow(ctx context.Context, keep, batch int) {
+	count, err := s.repo.ArchiveOverflow(ctx, keep, batch)
+	if err != nil {
+		log.Error().Err(err).Msg("notification archive failed")
+		s.jobFailed(ctx, jobArchive, err)
+		return
+	}
+	s.jobSucceeded(ctx, jobArchive)
+	if count > 0 {
+		log.Info().Int64("archived", count).Int("hot_limit", keep).Msg("notification archive completed")
+	}
+}
+
+// EvictOverCap deletes the oldest read notifications of users whose mailbox
+// holds more than capacity. Called by a background scheduler.
+func (s *Service) EvictOverCap(ctx context.Context, capacity, batch int) {
+	count, err := s.repo.EvictOverCap(ctx, capacity, batch)
+	if err != nil {
+		log.Error().Err(err).Msg("mailbox cap eviction failed")
+		s.jobFailed(ctx, jobMailboxCap, err)
+		return
+	}
+	s.jobSucceeded(ctx, jobMailboxCap)
+	if count > 0 {
+		log.Info().Int64("evicted", count).Int("mailbox_max", capacity).Msg("mailbox cap eviction completed")
+	}
 }
 
 // --- Notification Preferences ---
@@ -274,16 +1000,18 @@ func (s *Service) UpdatePreferences(ctx context.Context, tenantKey, userID strin
 	prefs := make([]domain.Preference, 0, len(inputs))
 	for _, in := range inputs {
 		notifType := domain.NotificationType(in.Type)
-		switch notifType {
-		case domain.TypeSystem, domain.TypeWorkflow, domain.TypeCRM, domain.TypeIAM, domain.TypeCustom:
-		default:
-			return nil, fmt.Errorf("invalid notification type: %q", in.Type)
+		if _, err := s.customType(ctx, tenantKey, notifType); err != nil {
+			return nil, fmt.Errorf("invalid notification type: %w", err)
+		}
+		if !domain.ValidCategory(in.Category) {
+			return nil, fmt.Errorf("invalid category: %q", in.Category)
 		}
 
 		p := domain.Preference{
 			TenantKey:       tenantKey,
 			UserID:          userID,
 			Type:            notifType,
+			Category:        in.Category,
 			QuietHoursStart: in.QuietHoursStart,
 			QuietHoursEnd:   in.QuietHoursEnd,
 		}
@@ -299,15 +1027,22 @@ func (s *Service) UpdatePreferences(ctx context.Context, tenantKey, userID strin
 		}
 		prefs = append(prefs, p)
 	}
-	return s.prefRepo.BatchUpsert(ctx, prefs)
+	saved, err := s.prefRepo.BatchUpsert(ctx, prefs)
+	if err != nil {
+		return nil, err
+	}
+	// In-app mutes hide broadcasts from the unread count.
+	s.invalidateUnread(ctx, domain.CounterKey{TenantKey: tenantKey, UserID: userID})
+	return saved, nil
 }
 
-// filterMutedUsers removes users who have opted out of in-app notifications.
-func (s *Service) filterMutedUsers(ctx context.Context, usersByTenant map[string][]string, notifType domain.NotificationType) map[string][]string {
+// filterMutedUsers removes users who have opted out of in-app notifications of
+// notifType, or of category within it.
+func (s *Service) filterMutedUsers(ctx context.Context, usersByTenant map[string][]string, notifType domain.NotificationType, category string) map[string][]string {
 	result := make(map[string][]string, len(usersByTenant))
 	for tenantKey, userIDs := range usersByTenant {
 		for _, uid := range userIDs {
-			pref, err := s.prefRepo.GetByUserAndType(ctx, tenantKey, uid, notifType)
+			pref, err := s.prefRepo.GetByUserAndCategory(ctx, tenantKey, uid, notifType, category)
 			if err != nil {
 				log.Warn().Err(err).Str("user", uid).Msg("failed to check preference, including user")
 				result[tenantKey] = append(result[tenantKey], uid)
@@ -323,10 +1058,10 @@ func (s *Service) filterMutedUsers(ctx context.Context, usersByTenant map[string
 
 // sendEmailIfNeeded checks email preference and delivers asynchronously.
 func (s *Service) sendEmailIfNeeded(ctx context.Context, n *domain.Notification) {
-	if s.emailSender == nil {
+	if s.emailSender == nil || !n.AllowsChannel(domain.ChannelEmail) {
 		return
 	}
-	pref, err := s.prefRepo.GetByUserAndType(ctx, n.TenantKey, n.UserID, n.Type)
+	pref, err := s.prefRepo.GetByUserAndCategory(ctx, n.TenantKey, n.UserID, n.Type, n.Category)
 	if err != nil {
 		log.Warn().Err(err).Str("user", n.UserID).Msg("failed to check email preference")
 		return
@@ -335,15 +1070,20 @@ func (s *Service) sendEmailIfNeeded(ctx context.Context, n *domain.Notification)
 		return
 	}
 
-	html := fmt.Sprintf(`<!DOCTYPE html><html><body style="font-family:system-ui,sans-serif;padding:20px;">
+	if err := s.emailSender.Send(ctx, n.UserID, n.Title, emailHTML(n)); err != nil {
+		log.Error().Err(err).Str("user", n.UserID).Msg("email delivery failed")
+		return
+	}
+	s.audit(ctx, deliveredEntry(n, domain.ChannelEmail))
+}
+
+// emailHTML is the email body of n.
+func emailHTML(n *domain.Notification) string {
+	return fmt.Sprintf(`<!DOCTYPE html><html><body style="font-family:system-ui,sans-serif;padding:20px;">
 <div style="max-width:560px;margin:0 auto;padding:24px;border:1px solid #e5e7eb;border-radius:8px;">
 <h2 style="margin:0 0 12px;font-size:18px;">%s</h2>
 <p style="font-size:14px;line-height:1.6;">%s</p>
 </div></body></html>`, n.Title, n.Body)
-
-	if err := s.emailSender.Send(ctx, n.UserID, n.Title, html); err != nil {
-		log.Error().Err(err).Str("user", n.UserID).Msg("email delivery failed")
-	}
 }
 
 // --- Template Management ---
@@ -380,3 +1120,49 @@ func (s *Service) DeleteTemplate(ctx context.Context, key, locale string) error
 	}
 	return s.templateEngine.DeleteTemplate(ctx, key, locale)
 }
+
+// ListTemplateOverrides returns a tenant's own template overrides for a locale.
+func (s *Service) ListTemplateOverrides(ctx context.Context, tenantKey, locale string) ([]domain.TemplateOverride, error) {
+	if s.templateEngine == nil {
+		return nil, fmt.Errorf("template engine not configured")
+	}
+	return s.templateEngine.ListOverrides(ctx, tenantKey, locale)
+}
+
+// UpsertTemplateOverride creates or updates a tenant's override of a template.
+func (s *Service) UpsertTemplateOverride(ctx context.Context, o domain.TemplateOverride) (*domain.TemplateOverride, error) {
+	if s.templateEngine == nil {
+		return nil, fmt.Errorf("template engine not configured")
+	}
+	return s.templateEngine.UpsertOverride(ctx, o)
+}
+
+// DeleteTemplateOverride removes a tenant's override of a template.
+func (s *Service) DeleteTemplateOverride(ctx context.Context, tenantKey, key, locale string) error {
+	if s.templateEngine == nil {
+		return fmt.Errorf("template engine not configured")
+	}
+	return s.templateEngine.DeleteOverride(ctx, tenantKey, key, locale)
+}
+
+// SetTemplateParent makes a tenant inherit template overrides from parent (none when empty).
+func (s *Service) SetTemplateParent(ctx context.Context, tenantKey, parent string) error {
+	if s.templateEngine == nil {
+		return fmt.Errorf("template engine not configured")
+	}
+	return s.templateEngine.SetParent(ctx, tenantKey, parent)
+}
+
+// TemplateDiff compares a tenant's effective templates with the platform ones.
+func (s *Service) TemplateDiff(ctx context.Context, tenantKey, locale string) ([]domain.TemplateDiff, error) {
+	if s.templateEngine == nil {
+		return nil, fmt.Errorf("template engine not configured")
+	}
+	return s.templateEngine.Diff(ctx, tenantKey, locale)
+}
+
+// withDisplay merges the first-class display fields of an input into its
+// metadata, where they are stored; they win over keys already there.
+func withDisplay(meta map[string]any, link, icon string, sender *domain.Sender) map[string]any {
+	return domain.WithSender(domain.WithIcon(domain.WithLink(meta, link), icon), sender)
+}
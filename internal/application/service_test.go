@@ -0,0 +1,237 @@
+package application
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"vn.io.arda/notification/internal/domain"
+	"vn.io.arda/notification/internal/testsupport"
+)
+
+func TestResolveTargetsComposite(t *testing.T) {
+	s := &Service{resolver: testsupport.NewResolver().
+		AddUsers("acme", "u1", "u2", "u3").
+		AddRole("acme", "MANAGER", "u2", "u4")}
+
+	got, err := s.resolveTargets(context.Background(), domain.FanoutInput{
+		TenantKey: "acme",
+		Targets: []domain.FanoutTarget{
+			{Scope: domain.ScopeUser, ID: "u1"},
+			{Scope: domain.ScopeUser, ID: "u5"},
+			{Scope: domain.ScopeRole, ID: "MANAGER"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"u1", "u5", "u2", "u4"}; !slices.Equal(got["acme"], want) {
+		t.Fatalf("union: got %v, want %v", got["acme"], want)
+	}
+
+	got, err = s.resolveTargets(context.Background(), domain.FanoutInput{
+		TargetScope:  domain.ScopeTenant,
+		TenantKey:    "acme",
+		OriginUserID: "u1",
+		Exclude:      []domain.FanoutTarget{{Scope: domain.ScopeUser, ID: "u1"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"u2", "u3"}; !slices.Equal(got["acme"], want) {
+		t.Fatalf("exclude originator: got %v, want %v", got["acme"], want)
+	}
+
+	got, err = s.resolveTargets(context.Background(), domain.FanoutInput{
+		TargetScope: domain.ScopeTenant,
+		TenantKey:   "acme",
+		Exclude:     []domain.FanoutTarget{{Scope: domain.ScopeRole, ID: "MANAGER"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"u1", "u3"}; !slices.Equal(got["acme"], want) {
+		t.Fatalf("exclude role: got %v, want %v", got["acme"], want)
+	}
+
+	got, err = s.resolveTargets(context.Background(), domain.FanoutInput{
+		TargetScope: domain.ScopeUser,
+		TargetID:    "u2",
+		TenantKey:   "acme",
+		Exclude:     []domain.FanoutTarget{{Scope: domain.ScopeTenant}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("expected every recipient excluded, got %v", got)
+	}
+
+	if _, err := s.resolveTargets(context.Background(), domain.FanoutInput{TenantKey: "acme"}); err == nil {
+		t.Fatal("expected error without any target")
+	}
+}
+
+type recordingEvents struct {
+	mu     sync.Mutex
+	events []domain.WebhookEventInput
+}
+
+func (r *recordingEvents) PublishNotificationEvents(_ context.Context, events []domain.WebhookEventInput) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, events...)
+	return nil
+}
+
+func TestSyncEventsSkipOriginClient(t *testing.T) {
+	ctx := WithOriginClient(context.Background(), "c-1")
+	hub := testsupport.NewHub()
+	s := NewService(testsupport.NewRepository(), hub, testsupport.NewResolver())
+	n, err := s.Create(ctx, domain.CreateNotificationInput{TenantKey: "acme", UserID: "u1", Type: domain.TypeSystem, Title: "t"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.MarkRead(ctx, n.ID.String(), "acme", "u1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(ctx, n.ID.String(), "acme", "u1"); err != nil {
+		t.Fatal(err)
+	}
+
+	syncEvents := func() map[string]testsupport.Event {
+		out := make(map[string]testsupport.Event)
+		for _, e := range hub.Events() {
+			if e.Name == EventNotificationRead || e.Name == EventNotificationDeleted {
+				out[e.Name] = e
+			}
+		}
+		return out
+	}
+	waitFor(t, func() bool { return len(syncEvents()) == 2 })
+	for name, e := range syncEvents() {
+		ids, _ := e.Data.(map[string]any)["ids"].([]string)
+		if e.UserID != "u1" || e.ExceptClientID != "c-1" || !slices.Equal(ids, []string{domain.FormatID(n.ID)}) {
+			t.Errorf("%s = %+v, want it for u1's other clients", name, e)
+		}
+	}
+}
+
+func TestSyncReadStates(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	clock := domain.NewManualClock(now)
+	repo := testsupport.NewRepository()
+	repo.SetClock(clock)
+	s := NewService(repo, testsupport.NewHub(), testsupport.NewResolver(), WithClock(clock))
+	var ids []uuid.UUID
+	for range 3 {
+		n, err := s.Create(ctx, domain.CreateNotificationInput{TenantKey: "acme", UserID: "u1", Type: domain.TypeSystem, Title: "t"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, n.ID)
+	}
+
+	// Two devices read the first notification offline; a skewed clock reports the
+	// second one read in the future.
+	states := []domain.ReadState{
+		{ID: ids[0], ReadAt: now.Add(-time.Hour)},
+		{ID: ids[0], ReadAt: now.Add(-3 * time.Hour)},
+		{ID: ids[1], ReadAt: now.Add(time.Hour)},
+	}
+	newlyRead, err := s.SyncReadStates(ctx, "acme", "u1", states)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(newlyRead) != 2 {
+		t.Fatalf("newly read %v, want the first two", newlyRead)
+	}
+	want := map[uuid.UUID]time.Time{ids[0]: now.Add(-3 * time.Hour), ids[1]: now}
+	for _, n := range repo.Notifications() {
+		if at, ok := want[n.ID]; ok && (n.ReadAt == nil || !n.ReadAt.Equal(at)) {
+			t.Errorf("%s read at %v, want %v", n.ID, n.ReadAt, at)
+		}
+	}
+	if unread, _ := s.CountUnread(ctx, "acme", "u1"); unread != 1 {
+		t.Fatalf("unread = %d, want 1", unread)
+	}
+
+	// Replaying the batch changes nothing.
+	if newlyRead, err := s.SyncReadStates(ctx, "acme", "u1", states); err != nil || len(newlyRead) != 0 {
+		t.Fatalf("replay = %v, %v; want nothing newly read", newlyRead, err)
+	}
+	if _, err := s.SyncReadStates(ctx, "acme", "u1", make([]domain.ReadState, MaxReadStateBatch+1)); err == nil {
+		t.Fatal("accepted a batch over the limit")
+	}
+}
+
+func TestNotificationEventsPublished(t *testing.T) {
+	ctx := context.Background()
+	pub := &recordingEvents{}
+	s := NewService(testsupport.NewRepository(), nil, nil, WithNotificationEvents(pub))
+
+	n, err := s.Create(ctx, domain.CreateNotificationInput{TenantKey: "acme", UserID: "u1", Type: domain.TypeWorkflow, Title: "approve"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.dispatchOutbox(ctx, OutboxConfig{BatchSize: 10, Lease: time.Minute})
+	if err := s.MarkRead(ctx, n.ID.String(), "acme", "u1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(ctx, n.ID.String(), "acme", "u1"); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []domain.WebhookEvent
+	for _, e := range pub.events {
+		if e.TenantKey != "acme" || e.Payload["id"] != domain.FormatID(n.ID) {
+			t.Fatalf("event %+v", e)
+		}
+		got = append(got, e.Event)
+	}
+	want := []domain.WebhookEvent{domain.WebhookNotificationCreated, domain.WebhookNotificationRead, domain.WebhookNotificationDeleted}
+	if !slices.Equal(got, want) {
+		t.Fatalf("published %v, want %v", got, want)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	ctx := context.Background()
+	clock := domain.NewManualClock(time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC))
+	repo := testsupport.NewRepository()
+	repo.SetClock(clock)
+	s := NewService(repo, nil, nil, WithClock(clock))
+	create := func(title string, metadata map[string]any) {
+		t.Helper()
+		if _, err := s.Create(ctx, domain.CreateNotificationInput{TenantKey: "acme", UserID: "u1", Type: domain.TypeSystem,
+			Title: title, Metadata: metadata, SourceEventID: title}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	create("old", nil)
+	clock.Advance(40 * 24 * time.Hour)
+	create("expired", domain.WithExpiry(nil, clock.Now().Add(-time.Minute)))
+	create("fresh", nil)
+
+	dry, err := s.Purge(ctx, 30, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dry.Retention != 1 || dry.Expired != 1 || len(repo.Notifications()) != 3 {
+		t.Fatalf("dry run = %+v with %d notifications left, want 1 + 1 counted and nothing deleted", dry, len(repo.Notifications()))
+	}
+	done, err := s.Purge(ctx, 30, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done.Retention != dry.Retention || done.Expired != dry.Expired {
+		t.Fatalf("purge = %+v, want the dry run's counts %+v", done, dry)
+	}
+	if ns := repo.Notifications(); len(ns) != 1 || ns[0].Title != "fresh" {
+		t.Fatalf("%d notifications left, want only the fresh one", len(ns))
+	}
+}
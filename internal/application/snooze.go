@@ -0,0 +1,107 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// MaxSnooze bounds how far ahead a notification can be snoozed.
+const MaxSnooze = 30 * 24 * time.Hour
+
+// EventNotificationSnoozed is pushed to the user's other streams when a notification is snoozed.
+const EventNotificationSnoozed = "notification_snoozed"
+
+// SnoozeConfig tunes the snooze wake-up scheduler.
+type SnoozeConfig struct {
+	// Interval is how often due snoozes are woken; snoozed notifications reappear
+	// at most this long after their wake-up time.
+	Interval time.Duration
+	// BatchSize is the maximum number of notifications woken per round.
+	BatchSize int
+}
+
+// Snooze hides a notification from the user's inbox and unread count until until.
+func (s *Service) Snooze(ctx context.Context, idStr, tenantKey, userID string, until time.Time) error {
+	id, err := domain.ParseID(idStr)
+	if err != nil {
+		return fmt.Errorf("invalid notification id: %w", err)
+	}
+	now := s.clock.Now()
+	if !until.After(now) {
+		return fmt.Errorf("snooze time must be in the future")
+	}
+	if until.Sub(now) > MaxSnooze {
+		return fmt.Errorf("cannot snooze for more than %s", MaxSnooze)
+	}
+	if err := s.repo.Snooze(ctx, id, tenantKey, userID, until); err != nil {
+		return err
+	}
+	s.invalidateUnread(ctx, domain.CounterKey{TenantKey: tenantKey, UserID: userID})
+	go s.hub.BroadcastEventExcept(tenantKey, userID, originClient(ctx), EventNotificationSnoozed,
+		map[string]any{"ids": []string{domain.FormatID(id)}, "until": until})
+	go s.pushUnreadCount(tenantKey, userID)
+	return nil
+}
+
+// ListSnoozed returns the user's snoozed notifications, soonest wake-up first.
+func (s *Service) ListSnoozed(ctx context.Context, tenantKey, userID, locale string) ([]*domain.Notification, error) {
+	ns, err := s.repo.ListSnoozed(ctx, tenantKey, userID)
+	if err != nil {
+		return nil, err
+	}
+	s.renderNotifications(ctx, locale, ns)
+	return ns, nil
+}
+
+// RunSnoozeWaker returns due snoozed notifications to their inboxes until ctx is
+// cancelled, pushing each one again over SSE.
+func (s *Service) RunSnoozeWaker(ctx context.Context, cfg SnoozeConfig) {
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for s.wakeSnoozed(ctx, cfg.BatchSize) == cfg.BatchSize {
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// wakeSnoozed wakes one batch and returns the number of notifications woken.
+func (s *Service) wakeSnoozed(ctx context.Context, limit int) int {
+	ns, err := s.repo.WakeSnoozed(ctx, limit)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Error().Err(err).Msg("failed to wake snoozed notifications")
+			s.jobFailed(ctx, jobSnoozeWake, err)
+		}
+		return 0
+	}
+	s.jobSucceeded(ctx, jobSnoozeWake)
+	if len(ns) == 0 {
+		return 0
+	}
+
+	keys := make([]domain.CounterKey, len(ns))
+	for i, n := range ns {
+		keys[i] = domain.CounterKey{TenantKey: n.TenantKey, UserID: n.UserID}
+	}
+	s.invalidateUnread(ctx, keys...)
+
+	s.renderNotifications(ctx, "", ns)
+	for _, n := range ns {
+		if n.AllowsChannel(domain.ChannelInApp) {
+			s.hub.Broadcast(n.TenantKey, n.UserID, n)
+		}
+		go s.pushUnreadCount(n.TenantKey, n.UserID)
+	}
+	log.Debug().Int("woken", len(ns)).Msg("snoozed notifications woken")
+	return len(ns)
+}
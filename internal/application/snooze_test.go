@@ -0,0 +1,46 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"vn.io.arda/notification/internal/domain"
+)
+
+type stubSnoozeRepo struct {
+	domain.Repository
+	calls int
+}
+
+func (r *stubSnoozeRepo) Snooze(context.Context, uuid.UUID, string, string, time.Time) error {
+	r.calls++
+	return domain.ErrNotificationNotFound
+}
+
+func TestSnooze_ValidatesWakeTime(t *testing.T) {
+	now := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	repo := &stubSnoozeRepo{}
+	s := NewService(repo, nil, nil, WithClock(domain.NewManualClock(now)))
+	id := uuid.NewString()
+
+	for name, until := range map[string]time.Time{
+		"past":     now.Add(-time.Minute),
+		"now":      now,
+		"too late": now.Add(MaxSnooze + time.Hour),
+	} {
+		if err := s.Snooze(context.Background(), id, "t1", "u1", until); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+	if repo.calls != 0 {
+		t.Fatalf("repository called for invalid wake times")
+	}
+
+	err := s.Snooze(context.Background(), id, "t1", "u1", now.Add(2*time.Hour))
+	if !errors.Is(err, domain.ErrNotificationNotFound) || repo.calls != 1 {
+		t.Fatalf("err = %v, calls = %d", err, repo.calls)
+	}
+}
@@ -0,0 +1,130 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// SSE events for state changes that have no dedicated event above.
+const (
+	EventNotificationSeen     = "notification_seen"
+	EventNotificationUnread   = "notification_unread"
+	EventNotificationRestored = "notification_restored"
+)
+
+// MaxStateEventPage bounds the events returned by one StateEventsSince call.
+const MaxStateEventPage = 500
+
+// NotificationHistory is a notification's state stream for one user and the
+// state folded from it.
+type NotificationHistory struct {
+	Events []domain.StateEvent      `json:"events"`
+	State  domain.NotificationState `json:"state"`
+}
+
+// SetStateEvents enables the per-user state stream API (history, sync, seen, undo).
+// Read and delete events are recorded by the Repository regardless.
+func (s *Service) SetStateEvents(repo domain.StateEventRepository) {
+	s.stateEvents = repo
+}
+
+func (s *Service) requireStateEvents() error {
+	if s.stateEvents == nil {
+		return fmt.Errorf("state events not configured")
+	}
+	return nil
+}
+
+// MarkSeen records that notifications were rendered on a device. IDs seen before
+// are ignored; other devices receive "notification_seen" with the newly seen IDs.
+func (s *Service) MarkSeen(ctx context.Context, tenantKey, userID string, idStrs []string) ([]uuid.UUID, error) {
+	if err := s.requireStateEvents(); err != nil {
+		return nil, err
+	}
+	if len(idStrs) > MaxReadStateBatch {
+		return nil, fmt.Errorf("too many ids: %d (max %d)", len(idStrs), MaxReadStateBatch)
+	}
+	ids := make([]uuid.UUID, 0, len(idStrs))
+	for _, str := range idStrs {
+		id, err := domain.ParseID(str)
+		if err != nil {
+			return nil, fmt.Errorf("invalid notification id %q: %w", str, err)
+		}
+		ids = append(ids, id)
+	}
+	seen, err := s.stateEvents.MarkSeen(ctx, tenantKey, userID, ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(seen) > 0 {
+		go s.hub.BroadcastEventExcept(tenantKey, userID, originClient(ctx), EventNotificationSeen,
+			map[string]any{"ids": domain.FormatIDs(seen)})
+	}
+	return seen, nil
+}
+
+// NotificationHistory returns the user's state events of a notification and the
+// state derived from them.
+func (s *Service) NotificationHistory(ctx context.Context, idStr, tenantKey, userID string) (*NotificationHistory, error) {
+	if err := s.requireStateEvents(); err != nil {
+		return nil, err
+	}
+	id, err := domain.ParseID(idStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notification id: %w", err)
+	}
+	events, err := s.stateEvents.ListByNotification(ctx, tenantKey, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("notification not found")
+	}
+	return &NotificationHistory{Events: events, State: domain.FoldStateEvents(events)}, nil
+}
+
+// StateEventsSince returns the user's state events after the cursor, for devices
+// catching up after being offline, and the cursor to resume from.
+func (s *Service) StateEventsSince(ctx context.Context, tenantKey, userID string, after int64, limit int) ([]domain.StateEvent, int64, error) {
+	if err := s.requireStateEvents(); err != nil {
+		return nil, after, err
+	}
+	if limit <= 0 || limit > MaxStateEventPage {
+		limit = MaxStateEventPage
+	}
+	events, err := s.stateEvents.ListSince(ctx, tenantKey, userID, after, limit)
+	if err != nil {
+		return nil, after, err
+	}
+	if len(events) > 0 {
+		after = events[len(events)-1].Seq
+	}
+	return events, after, nil
+}
+
+// Undo reverts the user's latest read or delete of a notification.
+func (s *Service) Undo(ctx context.Context, idStr, tenantKey, userID string) (domain.StateEventKind, error) {
+	if err := s.requireStateEvents(); err != nil {
+		return "", err
+	}
+	id, err := domain.ParseID(idStr)
+	if err != nil {
+		return "", fmt.Errorf("invalid notification id: %w", err)
+	}
+	kind, err := s.stateEvents.Undo(ctx, tenantKey, userID, id)
+	if err != nil {
+		return "", err
+	}
+	s.invalidateUnread(ctx, domain.CounterKey{TenantKey: tenantKey, UserID: userID})
+	event := EventNotificationUnread
+	if kind == domain.StateRestored {
+		event = EventNotificationRestored
+	}
+	go s.hub.BroadcastEventExcept(tenantKey, userID, originClient(ctx), event,
+		map[string]any{"ids": []string{domain.FormatID(id)}})
+	go s.pushUnreadCount(tenantKey, userID)
+	return kind, nil
+}
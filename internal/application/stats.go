@@ -0,0 +1,184 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// SetStats enables the engagement stats rollup and its admin API.
+func (s *Service) SetStats(repo domain.StatsRepository) {
+	s.stats = repo
+}
+
+// RollupStats recomputes the engagement rollup of the last lookbackDays days
+// before today in loc. Recent days are recomputed because notifications keep
+// being read after the day they were delivered.
+func (s *Service) RollupStats(ctx context.Context, lookbackDays int, loc *time.Location) {
+	if s.stats == nil || lookbackDays <= 0 {
+		return
+	}
+	today := s.clock.Now().In(loc)
+	from, to := today.AddDate(0, 0, -lookbackDays), today.AddDate(0, 0, -1)
+	rows, err := s.stats.Rollup(ctx, from, to, loc)
+	if err != nil {
+		log.Error().Err(err).Msg("notification stats rollup failed")
+		s.jobFailed(ctx, jobStatsRollup, err)
+		return
+	}
+	s.jobSucceeded(ctx, jobStatsRollup)
+	log.Info().Int64("rows", rows).Str("from", from.Format(time.DateOnly)).Str("to", to.Format(time.DateOnly)).
+		Msg("notification stats rollup completed")
+}
+
+// Engagement is the engagement of a set of delivered notifications.
+type Engagement struct {
+	TenantKey string                  `json:"tenant_key,omitempty"`
+	Type      domain.NotificationType `json:"type,omitempty"`
+	Delivered int64                   `json:"delivered"`
+	Read      int64                   `json:"read"`
+	ReadRate  float64                 `json:"read_rate"` // Read / Delivered, 0 when none was delivered
+	// MedianReadSeconds is the median time to read over the days, weighting the
+	// daily medians by their reads; nil when none was read.
+	MedianReadSeconds *float64 `json:"median_read_seconds"`
+}
+
+// EngagementReport summarizes the rollup over a range of days.
+type EngagementReport struct {
+	From  string     `json:"from"`
+	To    string     `json:"to"`
+	Total Engagement `json:"total"`
+	// ByType is the engagement per tenant and type.
+	ByType []Engagement `json:"by_type"`
+	// MostIgnored are the types with the lowest read rate across the selected
+	// tenants, among those delivered at least the requested number of times.
+	MostIgnored []Engagement `json:"most_ignored"`
+}
+
+// EngagementQuery selects an EngagementReport.
+type EngagementQuery struct {
+	domain.StatsFilter
+	// MinDelivered is the volume below which a type is not ranked as ignored.
+	MinDelivered int64
+	// IgnoredLimit bounds MostIgnored.
+	IgnoredLimit int
+}
+
+// EngagementStats reports the engagement rollup selected by q.
+func (s *Service) EngagementStats(ctx context.Context, q EngagementQuery) (*EngagementReport, error) {
+	if s.stats == nil {
+		return nil, fmt.Errorf("notification stats not configured")
+	}
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	rows, err := s.stats.List(ctx, q.StatsFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &EngagementReport{
+		From:        q.From.Format(time.DateOnly),
+		To:          q.To.Format(time.DateOnly),
+		ByType:      []Engagement{},
+		MostIgnored: []Engagement{},
+	}
+	total := &engagementSum{}
+	byType := make(map[[2]string]*engagementSum)
+	byTypeOnly := make(map[domain.NotificationType]*engagementSum)
+	for _, row := range rows {
+		total.add(row)
+		key := [2]string{row.TenantKey, string(row.Type)}
+		if byType[key] == nil {
+			byType[key] = &engagementSum{Engagement: Engagement{TenantKey: row.TenantKey, Type: row.Type}}
+		}
+		byType[key].add(row)
+		if byTypeOnly[row.Type] == nil {
+			byTypeOnly[row.Type] = &engagementSum{Engagement: Engagement{Type: row.Type}}
+		}
+		byTypeOnly[row.Type].add(row)
+	}
+
+	report.Total = total.result()
+	for _, sum := range byType {
+		report.ByType = append(report.ByType, sum.result())
+	}
+	sort.Slice(report.ByType, func(i, j int) bool {
+		a, b := report.ByType[i], report.ByType[j]
+		if a.TenantKey != b.TenantKey {
+			return a.TenantKey < b.TenantKey
+		}
+		return a.Type < b.Type
+	})
+	for _, sum := range byTypeOnly {
+		if sum.Delivered > 0 && sum.Delivered >= q.MinDelivered {
+			report.MostIgnored = append(report.MostIgnored, sum.result())
+		}
+	}
+	sort.Slice(report.MostIgnored, func(i, j int) bool {
+		a, b := report.MostIgnored[i], report.MostIgnored[j]
+		if a.ReadRate != b.ReadRate {
+			return a.ReadRate < b.ReadRate
+		}
+		if a.Delivered != b.Delivered {
+			return a.Delivered > b.Delivered
+		}
+		return a.Type < b.Type
+	})
+	if q.IgnoredLimit > 0 && len(report.MostIgnored) > q.IgnoredLimit {
+		report.MostIgnored = report.MostIgnored[:q.IgnoredLimit]
+	}
+	return report, nil
+}
+
+// engagementSum accumulates daily rollup rows into an Engagement.
+type engagementSum struct {
+	Engagement
+	medians []weightedValue
+}
+
+type weightedValue struct {
+	value  float64
+	weight int64
+}
+
+func (e *engagementSum) add(row domain.DailyStats) {
+	e.Delivered += row.Delivered
+	e.Read += row.Read
+	if row.MedianReadSeconds != nil && row.Read > 0 {
+		e.medians = append(e.medians, weightedValue{*row.MedianReadSeconds, row.Read})
+	}
+}
+
+func (e *engagementSum) result() Engagement {
+	out := e.Engagement
+	if out.Delivered > 0 {
+		out.ReadRate = float64(out.Read) / float64(out.Delivered)
+	}
+	out.MedianReadSeconds = weightedMedian(e.medians)
+	return out
+}
+
+// weightedMedian returns the value at which half of the total weight is
+// reached; nil when values is empty.
+func weightedMedian(values []weightedValue) *float64 {
+	if len(values) == 0 {
+		return nil
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i].value < values[j].value })
+	var total, seen int64
+	for _, v := range values {
+		total += v.weight
+	}
+	for _, v := range values {
+		seen += v.weight
+		if 2*seen >= total {
+			return &v.value
+		}
+	}
+	return &values[len(values)-1].value
+}
@@ -0,0 +1,66 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"vn.io.arda/notification/internal/domain"
+	"vn.io.arda/notification/internal/testsupport"
+)
+
+// statsRows is a domain.StatsRepository returning fixed rows.
+type statsRows []domain.DailyStats
+
+func (r statsRows) Rollup(context.Context, time.Time, time.Time, *time.Location) (int64, error) {
+	return 0, nil
+}
+
+func (r statsRows) List(context.Context, domain.StatsFilter) ([]domain.DailyStats, error) {
+	return r, nil
+}
+
+func TestEngagementStats(t *testing.T) {
+	seconds := func(v float64) *float64 { return &v }
+	day := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	rows := statsRows{
+		{Day: day, TenantKey: "acme", Type: domain.TypeCRM, Delivered: 100, Read: 80, MedianReadSeconds: seconds(60)},
+		{Day: day.AddDate(0, 0, 1), TenantKey: "acme", Type: domain.TypeCRM, Delivered: 100, Read: 20, MedianReadSeconds: seconds(600)},
+		{Day: day, TenantKey: "acme", Type: domain.TypeSystem, Delivered: 50, Read: 5, MedianReadSeconds: seconds(30)},
+		{Day: day, TenantKey: "globex", Type: domain.TypeSystem, Delivered: 50, Read: 0},
+		{Day: day, TenantKey: "globex", Type: domain.TypeIAM, Delivered: 3, Read: 0},
+	}
+	s := NewService(testsupport.NewRepository(), testsupport.NewHub(), fanoutResolver(), WithStats(rows))
+
+	report, err := s.EngagementStats(context.Background(), EngagementQuery{
+		StatsFilter:  domain.StatsFilter{From: day, To: day.AddDate(0, 0, 1)},
+		MinDelivered: 10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Total.Delivered != 303 || report.Total.Read != 105 {
+		t.Fatalf("total = %+v", report.Total)
+	}
+	if len(report.ByType) != 4 {
+		t.Fatalf("by_type = %+v", report.ByType)
+	}
+	crm := report.ByType[0]
+	// The day with 80 reads weighs more than the day with 20.
+	if crm.TenantKey != "acme" || crm.Type != domain.TypeCRM || crm.ReadRate != 0.5 || *crm.MedianReadSeconds != 60 {
+		t.Fatalf("acme CRM = %+v", crm)
+	}
+	if report.ByType[3].MedianReadSeconds != nil {
+		t.Fatalf("median of unread notifications = %v", *report.ByType[3].MedianReadSeconds)
+	}
+	// IAM is below MinDelivered; SYSTEM is read least.
+	if len(report.MostIgnored) != 2 || report.MostIgnored[0].Type != domain.TypeSystem || report.MostIgnored[0].ReadRate != 0.05 {
+		t.Fatalf("most_ignored = %+v", report.MostIgnored)
+	}
+
+	if _, err := s.EngagementStats(context.Background(), EngagementQuery{
+		StatsFilter: domain.StatsFilter{From: day, To: day.AddDate(0, 0, domain.MaxStatsRangeDays)},
+	}); err == nil {
+		t.Fatal("accepted a range over the limit")
+	}
+}
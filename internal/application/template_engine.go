@@ -2,16 +2,20 @@ package application
 
 import (
 	"context"
+	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/rs/zerolog/log"
 	"vn.io.arda/notification/internal/domain"
+	"vn.io.arda/notification/internal/messages"
 )
 
 // TemplateEngine renders notification messages from database templates.
 // Falls back to the provided defaults when no template is found.
 type TemplateEngine struct {
 	repo          domain.TemplateRepository
+	overrides     domain.TemplateOverrideRepository
 	defaultLocale string
 }
 
@@ -48,6 +52,274 @@ func (e *TemplateEngine) sub(tmpl string, vars map[string]string) string {
 	return result
 }
 
+// SetOverrides enables tenant overrides of platform templates.
+func (e *TemplateEngine) SetOverrides(repo domain.TemplateOverrideRepository) {
+	e.overrides = repo
+}
+
+// DefaultLocale returns the locale used when none is requested.
+func (e *TemplateEngine) DefaultLocale() string {
+	return e.defaultLocale
+}
+
+// refRenderer renders template references for one tenant and locale. Each key is
+// looked up once per renderer, so rendering a page of notifications costs a few
+// queries per key.
+type refRenderer func(ref *domain.TemplateRef, fallbackTitle, fallbackBody string) (title, body string, found bool)
+
+// renderer returns a refRenderer for tenantKey and locale (the default locale
+// when empty). Each field comes from the first of: the tenant's override chain
+// and the platform template for the locale, the same for the default locale,
+// then the built-in message; found is false when none exists and the fallbacks
+// were used.
+func (e *TemplateEngine) renderer(ctx context.Context, tenantKey, locale string) refRenderer {
+	if locale == "" {
+		locale = e.defaultLocale
+	}
+	var (
+		chain  []string
+		loaded bool
+		cache  = make(map[string]effectiveTemplate)
+	)
+	return func(ref *domain.TemplateRef, fallbackTitle, fallbackBody string) (string, string, bool) {
+		if !loaded {
+			chain, loaded = e.chain(ctx, tenantKey), true
+		}
+		eff, seen := cache[ref.Key]
+		if !seen {
+			eff = e.lookup(ctx, chain, ref.Key, locale)
+			cache[ref.Key] = eff
+		}
+		if eff.title == "" || eff.body == "" {
+			builtinTitle, builtinBody, ok := messages.Render(ref.Key, ref.Params)
+			if !ok && eff.title == "" && eff.body == "" {
+				return fallbackTitle, fallbackBody, false
+			}
+			if ok {
+				fallbackTitle, fallbackBody = builtinTitle, builtinBody
+			}
+		}
+		title, body := fallbackTitle, fallbackBody
+		if eff.title != "" {
+			title = e.sub(eff.title, ref.Params)
+		}
+		if eff.body != "" {
+			body = e.sub(eff.body, ref.Params)
+		}
+		return title, body, true
+	}
+}
+
+// effectiveTemplate is a template's text after applying tenant overrides. The
+// *From fields name the tenant supplying each field, or domain.TemplateSourcePlatform.
+type effectiveTemplate struct {
+	title, body         string
+	titleFrom, bodyFrom string
+}
+
+// locales returns locale followed by the default locale when they differ.
+func (e *TemplateEngine) locales(locale string) []string {
+	if locale == e.defaultLocale {
+		return []string{locale}
+	}
+	return []string{locale, e.defaultLocale}
+}
+
+// chain returns the tenants whose overrides apply to tenantKey, nearest first;
+// nil for platform-wide notifications, when overrides are disabled or the
+// lookup fails.
+func (e *TemplateEngine) chain(ctx context.Context, tenantKey string) []string {
+	if e.overrides == nil || tenantKey == "" {
+		return nil
+	}
+	chain, err := e.overrides.Chain(ctx, tenantKey)
+	if err != nil {
+		log.Warn().Err(err).Str("tenant", tenantKey).Msg("template inheritance lookup failed, using platform templates")
+		return nil
+	}
+	return chain
+}
+
+// lookup resolves key for the tenant chain in locale, falling back to the
+// default locale. Failed lookups are logged and treated as missing.
+func (e *TemplateEngine) lookup(ctx context.Context, chain []string, key, locale string) effectiveTemplate {
+	locales := e.locales(locale)
+	var overrides []domain.TemplateOverride
+	if len(chain) > 0 {
+		var err error
+		if overrides, err = e.overrides.Find(ctx, chain, key, locales); err != nil {
+			log.Warn().Err(err).Str("key", key).Msg("template override lookup failed, using platform template")
+		}
+	}
+	// A platform template has both fields, so the default locale is only
+	// consulted when the requested one has none.
+	platform := make(map[string]*domain.Template, len(locales))
+	for _, loc := range locales {
+		tmpl, err := e.repo.Get(ctx, key, loc)
+		if err != nil {
+			log.Warn().Err(err).Str("key", key).Str("locale", loc).Msg("template lookup failed, using fallback")
+			break
+		}
+		if tmpl != nil {
+			platform[loc] = tmpl
+			break
+		}
+	}
+	return mergeTemplate(key, chain, locales, overrides, platform)
+}
+
+// mergeTemplate picks each field of key from the first source, in order: for
+// each locale, the overrides of chain (nearest tenant first), then the platform
+// template of that locale.
+func mergeTemplate(key string, chain, locales []string, overrides []domain.TemplateOverride, platform map[string]*domain.Template) effectiveTemplate {
+	var eff effectiveTemplate
+	pickTitle := func(text, from string) {
+		if eff.title == "" && text != "" {
+			eff.title, eff.titleFrom = text, from
+		}
+	}
+	pickBody := func(text, from string) {
+		if eff.body == "" && text != "" {
+			eff.body, eff.bodyFrom = text, from
+		}
+	}
+	for _, loc := range locales {
+		for _, tenant := range chain {
+			for _, o := range overrides {
+				if o.TenantKey == tenant && o.TemplateKey == key && o.Locale == loc {
+					pickTitle(o.TitleTemplate, tenant)
+					pickBody(o.BodyTemplate, tenant)
+				}
+			}
+		}
+		if tmpl := platform[loc]; tmpl != nil {
+			pickTitle(tmpl.TitleTemplate, domain.TemplateSourcePlatform)
+			pickBody(tmpl.BodyTemplate, domain.TemplateSourcePlatform)
+		}
+	}
+	return eff
+}
+
+// Diff returns, for every template key known to the platform or overridden in
+// tenantKey's chain, the platform text next to the tenant's effective text.
+func (e *TemplateEngine) Diff(ctx context.Context, tenantKey, locale string) ([]domain.TemplateDiff, error) {
+	if e.overrides == nil {
+		return nil, fmt.Errorf("template overrides not configured")
+	}
+	if locale == "" {
+		locale = e.defaultLocale
+	}
+	chain, err := e.overrides.Chain(ctx, tenantKey)
+	if err != nil {
+		return nil, err
+	}
+	locales := e.locales(locale)
+	overrides, err := e.overrides.Find(ctx, chain, "", locales)
+	if err != nil {
+		return nil, err
+	}
+
+	// platform[key][locale]
+	platform := make(map[string]map[string]*domain.Template)
+	keys := make(map[string]bool)
+	for _, loc := range locales {
+		templates, err := e.repo.List(ctx, loc)
+		if err != nil {
+			return nil, err
+		}
+		for i := range templates {
+			t := &templates[i]
+			if platform[t.TemplateKey] == nil {
+				platform[t.TemplateKey] = make(map[string]*domain.Template)
+			}
+			platform[t.TemplateKey][loc] = t
+			keys[t.TemplateKey] = true
+		}
+	}
+	for _, o := range overrides {
+		keys[o.TemplateKey] = true
+	}
+
+	diffs := make([]domain.TemplateDiff, 0, len(keys))
+	for key := range keys {
+		byLocale := platform[key]
+		// Match lookup: the default locale only stands in for a missing template.
+		if byLocale[locale] != nil {
+			byLocale = map[string]*domain.Template{locale: byLocale[locale]}
+		}
+		eff := mergeTemplate(key, chain, locales, overrides, byLocale)
+		d := domain.TemplateDiff{
+			TemplateKey:   key,
+			Locale:        locale,
+			TitleTemplate: eff.title,
+			BodyTemplate:  eff.body,
+			TitleFrom:     eff.titleFrom,
+			BodyFrom:      eff.bodyFrom,
+			Overridden: (eff.titleFrom != "" && eff.titleFrom != domain.TemplateSourcePlatform) ||
+				(eff.bodyFrom != "" && eff.bodyFrom != domain.TemplateSourcePlatform),
+		}
+		for _, loc := range locales {
+			if t := byLocale[loc]; t != nil {
+				d.Platform = t
+				break
+			}
+		}
+		diffs = append(diffs, d)
+	}
+	slices.SortFunc(diffs, func(a, b domain.TemplateDiff) int { return strings.Compare(a.TemplateKey, b.TemplateKey) })
+	return diffs, nil
+}
+
+// --- Tenant overrides ---
+
+// ListOverrides returns a tenant's own overrides for a locale.
+func (e *TemplateEngine) ListOverrides(ctx context.Context, tenantKey, locale string) ([]domain.TemplateOverride, error) {
+	if e.overrides == nil {
+		return nil, fmt.Errorf("template overrides not configured")
+	}
+	return e.overrides.List(ctx, tenantKey, locale)
+}
+
+// UpsertOverride creates or updates a tenant override.
+func (e *TemplateEngine) UpsertOverride(ctx context.Context, o domain.TemplateOverride) (*domain.TemplateOverride, error) {
+	if e.overrides == nil {
+		return nil, fmt.Errorf("template overrides not configured")
+	}
+	if o.TitleTemplate == "" && o.BodyTemplate == "" {
+		return nil, fmt.Errorf("title_template or body_template is required")
+	}
+	return e.overrides.Upsert(ctx, o)
+}
+
+// DeleteOverride removes a tenant override; the inherited text applies again.
+func (e *TemplateEngine) DeleteOverride(ctx context.Context, tenantKey, key, locale string) error {
+	if e.overrides == nil {
+		return fmt.Errorf("template overrides not configured")
+	}
+	return e.overrides.Delete(ctx, tenantKey, key, locale)
+}
+
+// SetParent makes tenantKey inherit overrides from parent (none when empty),
+// rejecting cycles and chains deeper than domain.MaxTemplateInheritanceDepth.
+func (e *TemplateEngine) SetParent(ctx context.Context, tenantKey, parent string) error {
+	if e.overrides == nil {
+		return fmt.Errorf("template overrides not configured")
+	}
+	if parent != "" {
+		chain, err := e.overrides.Chain(ctx, parent)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(chain, tenantKey) {
+			return fmt.Errorf("tenant %q already inherits from %q", parent, tenantKey)
+		}
+		if len(chain) >= domain.MaxTemplateInheritanceDepth {
+			return fmt.Errorf("template inheritance deeper than %d tenants", domain.MaxTemplateInheritanceDepth)
+		}
+	}
+	return e.overrides.SetParent(ctx, tenantKey, parent)
+}
+
 // GetTemplates returns all templates for a locale.
 func (e *TemplateEngine) GetTemplates(ctx context.Context, locale string) ([]domain.Template, error) {
 	return e.repo.List(ctx, locale)
@@ -0,0 +1,69 @@
+package application
+
+import (
+	"context"
+
+	"vn.io.arda/notification/internal/domain"
+)
+
+// SetTemplateMode selects when templated notifications are rendered:
+// domain.TemplateModeWrite (default) stores the text rendered at fan-out,
+// domain.TemplateModeRead stores only the template reference and renders on
+// every read and push, so template edits and translations apply retroactively.
+func (s *Service) SetTemplateMode(mode string) {
+	s.templateMode = mode
+}
+
+// applyTemplate records input.Template in the metadata and renders Title/Body in
+// input.Locale (the default locale when empty). The rendered text is what delivery policies and traces see;
+// storedText decides whether it is also persisted.
+func (s *Service) applyTemplate(ctx context.Context, input domain.FanoutInput) domain.FanoutInput {
+	if input.Template == nil {
+		return input
+	}
+	input.Metadata = domain.WithTemplate(input.Metadata, input.Template)
+	if s.templateEngine != nil {
+		input.Title, input.Body, _ = s.templateEngine.renderer(ctx, input.TenantKey, input.Locale)(input.Template, input.Title, input.Body)
+	}
+	return input
+}
+
+// storedText returns the title and body to persist for input. In read mode the
+// text of a resolvable template is left empty and rendered when read; text of an
+// unknown template key is kept so the notification still reads as sent.
+func (s *Service) storedText(ctx context.Context, input domain.FanoutInput) (string, string) {
+	if s.templateMode != domain.TemplateModeRead || input.Template == nil || s.templateEngine == nil {
+		return input.Title, input.Body
+	}
+	if _, _, found := s.templateEngine.renderer(ctx, input.TenantKey, "")(input.Template, "", ""); found {
+		return "", ""
+	}
+	return input.Title, input.Body
+}
+
+// renderNotifications fills in the text of templated notifications for locale
+// (the default locale when empty), applying each notification's tenant
+// overrides. In read mode every templated notification is re-rendered; in
+// write mode only those stored without text.
+func (s *Service) renderNotifications(ctx context.Context, locale string, ns []*domain.Notification) {
+	if s.templateEngine == nil {
+		return
+	}
+	renderers := make(map[string]refRenderer)
+	for _, n := range ns {
+		ref := domain.TemplateRefOf(n.Metadata)
+		if ref == nil || (s.templateMode != domain.TemplateModeRead && n.Title != "") {
+			continue
+		}
+		render, ok := renderers[n.TenantKey]
+		if !ok {
+			render = s.templateEngine.renderer(ctx, n.TenantKey, locale)
+			renderers[n.TenantKey] = render
+		}
+		title, body, found := render(ref, n.Title, n.Body)
+		if !found && title == "" {
+			title = ref.Key
+		}
+		n.Title, n.Body = title, body
+	}
+}
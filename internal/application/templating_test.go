@@ -0,0 +1,161 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"vn.io.arda/notification/internal/domain"
+	"vn.io.arda/notification/internal/messages"
+)
+
+type stubTemplates map[string]*domain.Template
+
+func (r stubTemplates) Get(_ context.Context, key, locale string) (*domain.Template, error) {
+	return r[key+"/"+locale], nil
+}
+
+func (r stubTemplates) List(_ context.Context, locale string) ([]domain.Template, error) {
+	var out []domain.Template
+	for _, t := range r {
+		if t.Locale == locale {
+			out = append(out, *t)
+		}
+	}
+	return out, nil
+}
+
+func (r stubTemplates) Upsert(_ context.Context, t domain.Template) (*domain.Template, error) {
+	return &t, nil
+}
+
+func (r stubTemplates) Delete(context.Context, string, string) error { return nil }
+
+func TestTemplateReadMode(t *testing.T) {
+	ctx := context.Background()
+	repo := stubTemplates{}
+	s := &Service{templateEngine: NewTemplateEngine(repo, "vi")}
+	s.SetTemplateMode(domain.TemplateModeRead)
+
+	ref := &domain.TemplateRef{Key: messages.KeyTaskAssigned, Params: map[string]string{"taskName": "Duyệt chi", "processName": "Tạm ứng"}}
+	input := s.applyTemplate(ctx, domain.FanoutInput{Title: "x", Body: "y", Template: ref})
+	wantTitle, wantBody, _ := messages.Render(ref.Key, ref.Params)
+	if input.Title != wantTitle || input.Body != wantBody {
+		t.Fatalf("applyTemplate rendered %q / %q", input.Title, input.Body)
+	}
+	if title, body := s.storedText(ctx, input); title != "" || body != "" {
+		t.Fatalf("read mode stored %q / %q, want empty", title, body)
+	}
+	unknown := s.applyTemplate(ctx, domain.FanoutInput{Title: "x", Body: "y", Template: &domain.TemplateRef{Key: "custom.unknown"}})
+	if title, _ := s.storedText(ctx, unknown); title != "x" {
+		t.Fatalf("unknown template key stored %q, want the sent text", title)
+	}
+
+	// Metadata as read back from the database.
+	raw, _ := json.Marshal(input.Metadata)
+	var metadata map[string]any
+	if err := json.Unmarshal(raw, &metadata); err != nil {
+		t.Fatal(err)
+	}
+	n := &domain.Notification{Metadata: metadata}
+
+	s.renderNotifications(ctx, "en", []*domain.Notification{n})
+	if n.Title != wantTitle {
+		t.Fatalf("built-in fallback: got %q, want %q", n.Title, wantTitle)
+	}
+
+	// A template added later applies to the already stored notification.
+	repo[ref.Key+"/en"] = &domain.Template{TitleTemplate: "New task", BodyTemplate: "{{taskName}} in {{processName}}"}
+	n.Title, n.Body = "", ""
+	s.renderNotifications(ctx, "en", []*domain.Notification{n})
+	if n.Title != "New task" || n.Body != "Duyệt chi in Tạm ứng" {
+		t.Fatalf("en template: got %q / %q", n.Title, n.Body)
+	}
+
+	// Write mode keeps stored text as is.
+	s.SetTemplateMode(domain.TemplateModeWrite)
+	n.Title = "stored"
+	s.renderNotifications(ctx, "en", []*domain.Notification{n})
+	if n.Title != "stored" {
+		t.Fatalf("write mode re-rendered stored text: %q", n.Title)
+	}
+}
+
+type stubOverrides struct {
+	parents   map[string]string
+	overrides []domain.TemplateOverride
+}
+
+func (r *stubOverrides) Find(_ context.Context, tenants []string, key string, locales []string) ([]domain.TemplateOverride, error) {
+	var out []domain.TemplateOverride
+	for _, o := range r.overrides {
+		if slices.Contains(tenants, o.TenantKey) && (key == "" || o.TemplateKey == key) && slices.Contains(locales, o.Locale) {
+			out = append(out, o)
+		}
+	}
+	return out, nil
+}
+
+func (r *stubOverrides) List(context.Context, string, string) ([]domain.TemplateOverride, error) {
+	return nil, nil
+}
+
+func (r *stubOverrides) Upsert(_ context.Context, o domain.TemplateOverride) (*domain.TemplateOverride, error) {
+	r.overrides = append(r.overrides, o)
+	return &o, nil
+}
+
+func (r *stubOverrides) Delete(context.Context, string, string, string) error { return nil }
+
+func (r *stubOverrides) Chain(_ context.Context, tenantKey string) ([]string, error) {
+	chain := []string{tenantKey}
+	for p, ok := r.parents[tenantKey]; ok && len(chain) <= domain.MaxTemplateInheritanceDepth; p, ok = r.parents[p] {
+		chain = append(chain, p)
+	}
+	return chain, nil
+}
+
+func (r *stubOverrides) SetParent(_ context.Context, tenantKey, parent string) error {
+	r.parents[tenantKey] = parent
+	return nil
+}
+
+func TestTemplateOverrideInheritance(t *testing.T) {
+	ctx := context.Background()
+	key := "bpm.task_assigned"
+	repo := stubTemplates{key + "/vi": {TemplateKey: key, Locale: "vi", TitleTemplate: "Nhiệm vụ mới", BodyTemplate: "Bạn được giao {{taskName}}"}}
+	overrides := &stubOverrides{parents: map[string]string{}, overrides: []domain.TemplateOverride{
+		{TenantKey: "reseller", TemplateKey: key, Locale: "vi", TitleTemplate: "[Brand] Nhiệm vụ mới"},
+		{TenantKey: "acme", TemplateKey: key, Locale: "vi", BodyTemplate: "Acme giao {{taskName}}"},
+	}}
+	engine := NewTemplateEngine(repo, "vi")
+	engine.SetOverrides(overrides)
+
+	if err := engine.SetParent(ctx, "acme", "reseller"); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.SetParent(ctx, "reseller", "acme"); err == nil {
+		t.Fatal("expected inheritance cycle to be rejected")
+	}
+
+	ref := &domain.TemplateRef{Key: key, Params: map[string]string{"taskName": "Duyệt chi"}}
+	title, body, _ := engine.renderer(ctx, "acme", "en")(ref, "", "")
+	if title != "[Brand] Nhiệm vụ mới" || body != "Acme giao Duyệt chi" {
+		t.Fatalf("acme: got %q / %q", title, body)
+	}
+	if title, _, _ := engine.renderer(ctx, "other", "vi")(ref, "", ""); title != "Nhiệm vụ mới" {
+		t.Fatalf("tenant without overrides: got %q", title)
+	}
+
+	diffs, err := engine.Diff(ctx, "acme", "vi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 1 || !diffs[0].Overridden || diffs[0].TitleFrom != "reseller" || diffs[0].BodyFrom != "acme" {
+		t.Fatalf("diff: %+v", diffs)
+	}
+	if diffs[0].Platform == nil || diffs[0].Platform.TitleTemplate != "Nhiệm vụ mới" {
+		t.Fatalf("diff platform text: %+v", diffs[0].Platform)
+	}
+}
@@ -0,0 +1,99 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"vn.io.arda/notification/internal/domain"
+	"vn.io.arda/notification/internal/messages"
+)
+
+// ErrTestNotificationCooldown is returned when the user already received a test
+// notification in the current minute.
+var ErrTestNotificationCooldown = errors.New("a test notification was already sent this minute")
+
+// TestNotificationResult reports what a test notification did on each channel.
+type TestNotificationResult struct {
+	// Notification is nil when the user's preferences muted it.
+	Notification *domain.Notification `json:"notification,omitempty"`
+	Channels     []TestChannelResult  `json:"channels"`
+}
+
+// TestChannelResult is the outcome of a test notification on one channel.
+type TestChannelResult struct {
+	Channel domain.Channel `json:"channel"`
+	Enabled bool           `json:"enabled"`
+	Detail  string         `json:"detail"`
+}
+
+// SendTestNotification sends the user a SYSTEM notification the way a direct
+// notification travels: stored, then pushed over SSE and emailed by the outbox
+// dispatcher, subject to the user's SYSTEM preference. Tenant chat connectors
+// are left out. The source event ID is per user and minute, so a second test in
+// the same minute fails with ErrTestNotificationCooldown.
+func (s *Service) SendTestNotification(ctx context.Context, tenantKey, userID, locale string) (*TestNotificationResult, error) {
+	pref, err := s.prefRepo.GetByUserAndCategory(ctx, tenantKey, userID, domain.TypeSystem, "")
+	if err != nil {
+		return nil, fmt.Errorf("load preferences: %w", err)
+	}
+	result := &TestNotificationResult{}
+	if pref != nil && !pref.ChannelInApp {
+		result.Channels = []TestChannelResult{
+			{Channel: domain.ChannelInApp, Detail: "muted by your SYSTEM preference; nothing was stored or sent"},
+			{Channel: domain.ChannelEmail, Detail: "skipped: the notification was muted"},
+		}
+		return result, nil
+	}
+
+	title, body := messages.TestNotification()
+	input := s.applyTemplate(ctx, domain.FanoutInput{
+		TargetScope:   domain.ScopeUser,
+		TargetID:      userID,
+		TenantKey:     tenantKey,
+		Type:          domain.TypeSystem,
+		Priority:      domain.PriorityNormal,
+		Title:         title,
+		Body:          body,
+		Locale:        locale,
+		Metadata:      domain.WithChannels(map[string]any{"test": true}, []domain.Channel{domain.ChannelInApp, domain.ChannelEmail}),
+		Template:      &domain.TemplateRef{Key: messages.KeyTestNotification},
+		SourceEventID: fmt.Sprintf("test:%s:%d", userID, s.clock.Now().Unix()/60),
+	})
+	title, body = s.storedText(ctx, input)
+	n, err := s.Create(ctx, domain.CreateNotificationInput{
+		TenantKey:     tenantKey,
+		UserID:        userID,
+		Type:          input.Type,
+		Priority:      input.Priority,
+		Title:         title,
+		Body:          body,
+		Metadata:      input.Metadata,
+		SourceEventID: input.SourceEventID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if n == nil {
+		return nil, ErrTestNotificationCooldown
+	}
+	s.renderNotifications(ctx, locale, []*domain.Notification{n})
+	result.Notification = n
+
+	inApp := TestChannelResult{Channel: domain.ChannelInApp, Enabled: true, Detail: "stored in your inbox; no open stream on this instance to push to"}
+	if s.hub.IsConnected(tenantKey, userID) {
+		inApp.Detail = "stored in your inbox and queued for your open streams"
+	}
+	email := TestChannelResult{Channel: domain.ChannelEmail}
+	switch {
+	case s.emailSender == nil:
+		email.Detail = "email delivery is not configured"
+	case pref == nil || !pref.ChannelEmail:
+		email.Detail = "not enabled in your SYSTEM preference"
+	default:
+		email.Enabled = true
+		email.Detail = "queued for delivery"
+	}
+	result.Channels = []TestChannelResult{inApp, email}
+	return result, nil
+}
@@ -0,0 +1,56 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"vn.io.arda/notification/internal/domain"
+)
+
+type stubTestRepo struct {
+	domain.Repository
+	sources map[string]bool
+}
+
+func (r *stubTestRepo) Create(_ context.Context, in domain.CreateNotificationInput) (*domain.Notification, error) {
+	if r.sources[in.SourceEventID] {
+		return nil, nil
+	}
+	r.sources[in.SourceEventID] = true
+	return &domain.Notification{ID: uuid.New(), TenantKey: in.TenantKey, UserID: in.UserID, Type: in.Type,
+		Title: in.Title, Metadata: in.Metadata, SourceEventID: in.SourceEventID}, nil
+}
+
+type stubPrefs struct {
+	domain.PreferenceRepository
+	pref *domain.Preference
+}
+
+func (p stubPrefs) GetByUserAndCategory(context.Context, string, string, domain.NotificationType, string) (*domain.Preference, error) {
+	return p.pref, nil
+}
+
+func TestSendTestNotification(t *testing.T) {
+	ctx := context.Background()
+	repo := &stubTestRepo{sources: map[string]bool{}}
+
+	muted := NewService(repo, nil, nil, WithPreferences(stubPrefs{pref: &domain.Preference{ChannelInApp: false}}))
+	res, err := muted.SendTestNotification(ctx, "t1", "u1", "")
+	if err != nil || res.Notification != nil || len(repo.sources) != 0 {
+		t.Fatalf("muted user: result %+v, err %v, stored %d", res, err, len(repo.sources))
+	}
+
+	s := NewService(repo, nil, nil, WithPreferences(stubPrefs{}))
+	res, err = s.SendTestNotification(ctx, "t1", "u1", "")
+	if err != nil || res.Notification == nil || res.Notification.Title == "" {
+		t.Fatalf("result %+v, err %v", res, err)
+	}
+	if res.Notification.AllowsChannel(domain.ChannelChat) {
+		t.Fatal("test notification would be forwarded to tenant chat")
+	}
+	if _, err := s.SendTestNotification(ctx, "t1", "u1", ""); !errors.Is(err, ErrTestNotificationCooldown) {
+		t.Fatalf("second test in the same minute: err = %v", err)
+	}
+}
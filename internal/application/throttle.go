@@ -0,0 +1,229 @@
+package application
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// ThrottleRule limits what a single user receives of one notification type.
+type ThrottleRule struct {
+	// DedupWindow suppresses a notification whose content (type, category, title,
+	// body and template) matches one the user received within the window. 0 disables.
+	DedupWindow time.Duration
+	// PerMinute caps notifications per user per minute; 0 = unlimited.
+	// URGENT notifications are never throttled, only deduplicated.
+	PerMinute int
+}
+
+func (r ThrottleRule) enabled() bool { return r.DedupWindow > 0 || r.PerMinute > 0 }
+
+// ThrottleConfig configures per-user dedup and throttling of fan-outs.
+// Types overrides Default for the listed notification types.
+type ThrottleConfig struct {
+	Default ThrottleRule
+	Types   map[domain.NotificationType]ThrottleRule
+}
+
+func (c ThrottleConfig) rule(t domain.NotificationType) ThrottleRule {
+	if r, ok := c.Types[t]; ok {
+		return r
+	}
+	return c.Default
+}
+
+// ParseThrottleRules parses per-type overrides written as
+// "TYPE=dedup_seconds/per_minute[,...]", e.g. "WORKFLOW=300/20,CRM=0/5".
+func ParseThrottleRules(s string) (map[domain.NotificationType]ThrottleRule, error) {
+	rules := make(map[domain.NotificationType]ThrottleRule)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, spec, ok := strings.Cut(entry, "=")
+		t := domain.NotificationType(strings.TrimSpace(name))
+		if !ok || !t.Valid() {
+			return nil, fmt.Errorf("throttle rule %q: want TYPE=dedup_seconds/per_minute", entry)
+		}
+		window, perMinute, ok := strings.Cut(spec, "/")
+		if !ok {
+			return nil, fmt.Errorf("throttle rule %q: want TYPE=dedup_seconds/per_minute", entry)
+		}
+		seconds, err := strconv.Atoi(strings.TrimSpace(window))
+		if err != nil || seconds < 0 {
+			return nil, fmt.Errorf("throttle rule %q: invalid dedup seconds", entry)
+		}
+		limit, err := strconv.Atoi(strings.TrimSpace(perMinute))
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("throttle rule %q: invalid per-minute limit", entry)
+		}
+		rules[t] = ThrottleRule{DedupWindow: time.Duration(seconds) * time.Second, PerMinute: limit}
+	}
+	return rules, nil
+}
+
+// ThrottleStats counts recipients suppressed by the per-user throttle since startup.
+type ThrottleStats struct {
+	Entries      int    `json:"entries"`
+	Deduplicated uint64 `json:"deduplicated"`
+	Throttled    uint64 `json:"throttled"`
+}
+
+// throttlePruneEvery bounds how often expired dedup entries and full buckets are dropped.
+const throttlePruneEvery = time.Minute
+
+type dedupEntry struct {
+	expires       time.Time
+	sourceEventID string
+}
+
+// throttler keeps recent content fingerprints and per-user/type token buckets in
+// memory; each replica enforces its limits on the fan-outs it processes.
+type throttler struct {
+	cfg ThrottleConfig
+
+	mu        sync.Mutex
+	seen      map[string]dedupEntry
+	buckets   map[string]*rate.Limiter
+	lastPrune time.Time
+
+	deduplicated atomic.Uint64
+	throttled    atomic.Uint64
+}
+
+func newThrottler(cfg ThrottleConfig, now time.Time) *throttler {
+	return &throttler{
+		cfg:       cfg,
+		seen:      make(map[string]dedupEntry),
+		buckets:   make(map[string]*rate.Limiter),
+		lastPrune: now,
+	}
+}
+
+// fingerprint hashes the user-visible content of a fan-out.
+func fingerprint(input domain.FanoutInput) string {
+	h := sha256.New()
+	for _, part := range []string{string(input.Type), input.Category, input.Title, input.Body} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	if input.Template != nil {
+		ref, _ := json.Marshal(input.Template) // map keys are sorted
+		h.Write(ref)
+	}
+	return string(h.Sum(nil))
+}
+
+// filter drops the recipients that already received the same content within the
+// dedup window or are over their per-minute budget. A redelivered fan-out (same
+// source event) passes through so retries stay idempotent.
+func (t *throttler) filter(now time.Time, input domain.FanoutInput, usersByTenant map[string][]string) (map[string][]string, int, int) {
+	rule := t.cfg.rule(input.Type)
+	if !rule.enabled() {
+		return usersByTenant, 0, 0
+	}
+	var content string
+	if rule.DedupWindow > 0 {
+		content = fingerprint(input)
+	}
+	limitRate := rate.Limit(float64(rule.PerMinute) / 60)
+	throttle := rule.PerMinute > 0 && input.Priority != domain.PriorityUrgent
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.prune(now)
+
+	var deduplicated, throttled int
+	result := make(map[string][]string, len(usersByTenant))
+	for tenantKey, userIDs := range usersByTenant {
+		kept := make([]string, 0, len(userIDs))
+		for _, uid := range userIDs {
+			user := tenantKey + "\x00" + uid
+			var dedupKey string
+			if content != "" {
+				dedupKey = user + "\x00" + content
+				if e, ok := t.seen[dedupKey]; ok && now.Before(e.expires) {
+					if e.sourceEventID == "" || e.sourceEventID != input.SourceEventID {
+						deduplicated++
+					} else {
+						kept = append(kept, uid)
+					}
+					continue
+				}
+			}
+			if throttle {
+				key := user + "\x00" + string(input.Type)
+				lim, ok := t.buckets[key]
+				if !ok {
+					lim = rate.NewLimiter(limitRate, rule.PerMinute)
+					t.buckets[key] = lim
+				}
+				if !lim.AllowN(now, 1) {
+					throttled++
+					continue
+				}
+			}
+			if dedupKey != "" {
+				t.seen[dedupKey] = dedupEntry{expires: now.Add(rule.DedupWindow), sourceEventID: input.SourceEventID}
+			}
+			kept = append(kept, uid)
+		}
+		if len(kept) > 0 {
+			result[tenantKey] = kept
+		}
+	}
+	t.deduplicated.Add(uint64(deduplicated))
+	t.throttled.Add(uint64(throttled))
+	return result, deduplicated, throttled
+}
+
+func (t *throttler) prune(now time.Time) {
+	if now.Sub(t.lastPrune) < throttlePruneEvery {
+		return
+	}
+	for key, e := range t.seen {
+		if !now.Before(e.expires) {
+			delete(t.seen, key)
+		}
+	}
+	for key, lim := range t.buckets {
+		if lim.TokensAt(now) >= float64(lim.Burst()) {
+			delete(t.buckets, key)
+		}
+	}
+	t.lastPrune = now
+}
+
+func (t *throttler) stats() ThrottleStats {
+	t.mu.Lock()
+	entries := len(t.seen) + len(t.buckets)
+	t.mu.Unlock()
+	return ThrottleStats{
+		Entries:      entries,
+		Deduplicated: t.deduplicated.Load(),
+		Throttled:    t.throttled.Load(),
+	}
+}
+
+// SetThrottle enables per-user dedup and throttling of fan-out-on-write
+// notifications. Fan-out-on-read broadcasts are not affected.
+func (s *Service) SetThrottle(cfg ThrottleConfig) {
+	enabled := cfg.Default.enabled()
+	for _, r := range cfg.Types {
+		enabled = enabled || r.enabled()
+	}
+	if !enabled {
+		s.throttler = nil
+		return
+	}
+	s.throttler = newThrottler(cfg, s.clock.Now())
+}
@@ -0,0 +1,72 @@
+package application
+
+import (
+	"testing"
+	"time"
+
+	"vn.io.arda/notification/internal/domain"
+)
+
+func TestThrottlerDedupAndPerMinute(t *testing.T) {
+	now := time.Now()
+	th := newThrottler(ThrottleConfig{
+		Default: ThrottleRule{DedupWindow: time.Minute},
+		Types:   map[domain.NotificationType]ThrottleRule{domain.TypeCRM: {PerMinute: 2}},
+	}, now)
+	users := map[string][]string{"acme": {"u1", "u2"}}
+	alert := domain.FanoutInput{Type: domain.TypeSystem, Title: "Disk full", SourceEventID: "e1"}
+
+	got, dup, _ := th.filter(now, alert, users)
+	if len(got["acme"]) != 2 || dup != 0 {
+		t.Fatalf("first alert = %v (dup %d), want both users", got, dup)
+	}
+	// A retry of the same event is not a duplicate.
+	if got, dup, _ = th.filter(now, alert, users); len(got["acme"]) != 2 || dup != 0 {
+		t.Fatalf("redelivered alert = %v (dup %d), want both users", got, dup)
+	}
+	alert.SourceEventID = "e2"
+	if got, dup, _ = th.filter(now.Add(30*time.Second), alert, users); len(got) != 0 || dup != 2 {
+		t.Fatalf("repeated alert = %v (dup %d), want suppressed", got, dup)
+	}
+	alert.SourceEventID = "e3"
+	if got, _, _ = th.filter(now.Add(2*time.Minute), alert, users); len(got["acme"]) != 2 {
+		t.Fatalf("alert after window = %v, want delivered", got)
+	}
+
+	// CRM overrides the default: no dedup, two per user per minute.
+	deal := domain.FanoutInput{Type: domain.TypeCRM, Title: "Deal won"}
+	one := map[string][]string{"acme": {"u1"}}
+	for i := range 2 {
+		if got, _, _ := th.filter(now, deal, one); len(got["acme"]) != 1 {
+			t.Fatalf("deal %d = %v, want delivered", i, got)
+		}
+	}
+	if got, _, throttled := th.filter(now, deal, one); len(got) != 0 || throttled != 1 {
+		t.Fatalf("third deal = %v (throttled %d), want throttled", got, throttled)
+	}
+	deal.Priority = domain.PriorityUrgent
+	if got, _, _ := th.filter(now, deal, one); len(got["acme"]) != 1 {
+		t.Fatalf("urgent deal = %v, want delivered", got)
+	}
+	if got, _, _ := th.filter(now.Add(30*time.Second), domain.FanoutInput{Type: domain.TypeCRM}, one); len(got["acme"]) != 1 {
+		t.Fatalf("deal after refill = %v, want delivered", got)
+	}
+}
+
+func TestParseThrottleRules(t *testing.T) {
+	rules, err := ParseThrottleRules("WORKFLOW=300/20, CRM=0/5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := rules[domain.TypeWorkflow]; r.DedupWindow != 5*time.Minute || r.PerMinute != 20 {
+		t.Fatalf("WORKFLOW = %+v", r)
+	}
+	if r := rules[domain.TypeCRM]; r.DedupWindow != 0 || r.PerMinute != 5 {
+		t.Fatalf("CRM = %+v", r)
+	}
+	for _, bad := range []string{"WORKFLOW", "crm=1/1", "CRM=1", "CRM=-1/2", "CRM=1/x"} {
+		if _, err := ParseThrottleRules(bad); err == nil {
+			t.Errorf("ParseThrottleRules(%q) succeeded", bad)
+		}
+	}
+}
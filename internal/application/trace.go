@@ -0,0 +1,61 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// SetTraceRepo enables recording per-event processing steps for the admin trace endpoint.
+func (s *Service) SetTraceRepo(r domain.TraceRepository) {
+	s.traceRepo = r
+}
+
+// Trace records a processing step for sourceEventID. Best-effort: failures are
+// logged and never affect delivery. No-op without a trace repository or event ID.
+func (s *Service) Trace(ctx context.Context, sourceEventID string, stage domain.TraceStage, detail map[string]any) {
+	if s.traceRepo == nil || sourceEventID == "" {
+		return
+	}
+	if err := s.traceRepo.Append(ctx, domain.TraceStep{SourceEventID: sourceEventID, Stage: stage, Detail: detail}); err != nil {
+		log.Warn().Err(err).Str("source_event_id", sourceEventID).Str("stage", string(stage)).Msg("failed to record event trace")
+	}
+}
+
+// EventTrace is the assembled processing history and current delivery state of a source event.
+type EventTrace struct {
+	SourceEventID string                  `json:"source_event_id"`
+	Steps         []domain.TraceStep      `json:"steps"`
+	Delivery      *domain.DeliverySummary `json:"delivery"`
+	Reactions     int                     `json:"reactions"`
+}
+
+// GetEventTrace assembles the trace of a source event from the processing ledger,
+// the notification/outbox tables and the reactions recorded in tenantKey.
+func (s *Service) GetEventTrace(ctx context.Context, tenantKey, sourceEventID string) (*EventTrace, error) {
+	if s.traceRepo == nil {
+		return nil, fmt.Errorf("event tracing not configured")
+	}
+	steps, err := s.traceRepo.ListBySourceEvent(ctx, sourceEventID)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := s.traceRepo.DeliverySummary(ctx, sourceEventID)
+	if err != nil {
+		return nil, err
+	}
+	trace := &EventTrace{SourceEventID: sourceEventID, Steps: steps, Delivery: summary}
+	if trace.Steps == nil {
+		trace.Steps = []domain.TraceStep{}
+	}
+	if s.reactionRepo != nil {
+		reactions, err := s.reactionRepo.ListBySourceEvent(ctx, tenantKey, sourceEventID)
+		if err != nil {
+			return nil, err
+		}
+		trace.Reactions = len(reactions)
+	}
+	return trace, nil
+}
@@ -0,0 +1,310 @@
+package application
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// WebhookConfig tunes the webhook delivery dispatcher.
+type WebhookConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	// Lease is how long a claimed delivery stays invisible to other dispatchers.
+	Lease time.Duration
+	// MaxAttempts is the number of attempts before a delivery is marked failed.
+	MaxAttempts int
+	// Backoff is the delay after the first failed attempt; it doubles per attempt up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// AllowHTTP accepts plain http:// endpoints (development only).
+	AllowHTTP bool
+	// AllowPrivate accepts endpoints on loopback, private and link-local
+	// addresses (development only).
+	AllowPrivate bool
+}
+
+// SetWebhooks enables tenant webhooks on notification created/read/deleted events.
+func (s *Service) SetWebhooks(repo domain.WebhookRepository, sender domain.WebhookSender, cfg WebhookConfig) {
+	s.webhooks, s.webhookSender, s.webhookCfg = repo, sender, cfg
+	s.webhookWake = make(chan struct{}, 1)
+}
+
+// SetNotificationEventPublisher streams every notification created/read/deleted
+// event, the events tenant webhooks can subscribe to, to p.
+func (s *Service) SetNotificationEventPublisher(p domain.NotificationEventPublisher) {
+	s.eventPub = p
+}
+
+// emitsEvents reports whether notification events have any consumer, so callers
+// can skip building them.
+func (s *Service) emitsEvents() bool {
+	return s.webhooks != nil || s.eventPub != nil
+}
+
+// emitEvents hands notification events to the tenants' webhooks and to the event
+// publisher. Failures are logged; they never fail the notification operation
+// that produced the events.
+func (s *Service) emitEvents(ctx context.Context, events ...domain.WebhookEventInput) {
+	s.emitWebhooks(ctx, events...)
+	if s.eventPub == nil || len(events) == 0 {
+		return
+	}
+	if err := s.eventPub.PublishNotificationEvents(ctx, events); err != nil {
+		log.Error().Err(err).Int("events", len(events)).Msg("failed to publish notification events")
+	}
+}
+
+// emitWebhooks queues deliveries for the tenants' subscribed webhooks. Failures are
+// logged; they never fail the notification operation that produced the events.
+func (s *Service) emitWebhooks(ctx context.Context, events ...domain.WebhookEventInput) {
+	if s.webhooks == nil || len(events) == 0 {
+		return
+	}
+	queued, err := s.webhooks.Enqueue(ctx, events)
+	if err != nil {
+		log.Error().Err(err).Int("events", len(events)).Msg("failed to enqueue webhook deliveries")
+		return
+	}
+	if queued > 0 {
+		s.wakeWebhooks()
+	}
+}
+
+// wakeWebhooks signals the dispatcher that new deliveries are due (non-blocking).
+func (s *Service) wakeWebhooks() {
+	select {
+	case s.webhookWake <- struct{}{}:
+	default:
+	}
+}
+
+// notificationWebhookPayload is the data of a notification.created event.
+func notificationWebhookPayload(n *domain.Notification) map[string]any {
+	return map[string]any{
+		"id":              domain.FormatID(n.ID),
+		"user_id":         n.UserID,
+		"type":            n.Type,
+		"category":        n.Category,
+		"priority":        n.Priority,
+		"title":           n.Title,
+		"body":            n.Body,
+		"metadata":        n.Metadata,
+		"source_event_id": n.SourceEventID,
+		"created_at":      n.CreatedAt,
+	}
+}
+
+// RunWebhookDispatcher delivers queued webhook events until ctx is cancelled.
+func (s *Service) RunWebhookDispatcher(ctx context.Context) {
+	if s.webhooks == nil {
+		return
+	}
+	ticker := time.NewTicker(s.webhookCfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for s.dispatchWebhooks(ctx) == s.webhookCfg.BatchSize {
+		}
+		select {
+		case <-s.webhookWake:
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// dispatchWebhooks claims and sends one batch, returning the number of deliveries claimed.
+func (s *Service) dispatchWebhooks(ctx context.Context) int {
+	deliveries, err := s.webhooks.ClaimDeliveries(ctx, s.webhookCfg.BatchSize, s.webhookCfg.Lease)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Error().Err(err).Msg("failed to claim webhook deliveries")
+			s.jobFailed(ctx, jobWebhookDispatch, err)
+		}
+		return 0
+	}
+	s.jobSucceeded(ctx, jobWebhookDispatch)
+	for _, d := range deliveries {
+		status, err := s.webhookSender.Send(ctx, d)
+		if err == nil {
+			if err := s.webhooks.MarkDelivered(ctx, d.ID, status); err != nil {
+				log.Error().Err(err).Int64("delivery", d.ID).Msg("failed to record webhook delivery")
+			}
+			continue
+		}
+
+		var retryAt *time.Time
+		if d.Attempts < s.webhookCfg.MaxAttempts {
+			at := s.clock.Now().Add(webhookBackoff(d.Attempts, s.webhookCfg.Backoff, s.webhookCfg.MaxBackoff))
+			retryAt = &at
+		}
+		log.Warn().Err(err).
+			Int64("delivery", d.ID).
+			Str("webhook", d.WebhookID.String()).
+			Str("tenant", d.TenantKey).
+			Int("attempt", d.Attempts).
+			Bool("will_retry", retryAt != nil).
+			Msg("webhook delivery failed")
+		if err := s.webhooks.MarkAttemptFailed(ctx, d.ID, status, err.Error(), retryAt); err != nil {
+			log.Error().Err(err).Int64("delivery", d.ID).Msg("failed to record webhook attempt")
+		}
+	}
+	return len(deliveries)
+}
+
+// webhookBackoff is the delay before attempt+1: base doubled per failed attempt, capped at ceiling.
+func webhookBackoff(attempt int, base, ceiling time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay < ceiling; i++ {
+		delay *= 2
+	}
+	return min(delay, ceiling)
+}
+
+// --- Webhook admin ---
+
+func (s *Service) requireWebhooks() error {
+	if s.webhooks == nil {
+		return fmt.Errorf("webhooks not configured")
+	}
+	return nil
+}
+
+func (s *Service) validateWebhook(ctx context.Context, w domain.Webhook) error {
+	u, err := url.Parse(w.URL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid url %q", w.URL)
+	}
+	if u.Scheme != "https" && !(u.Scheme == "http" && s.webhookCfg.AllowHTTP) {
+		return fmt.Errorf("url must use https")
+	}
+	if !s.webhookCfg.AllowPrivate {
+		if err := checkPublicHost(ctx, u.Hostname()); err != nil {
+			return err
+		}
+	}
+	if len(w.Events) == 0 {
+		return fmt.Errorf("at least one event is required")
+	}
+	for _, e := range w.Events {
+		if !e.Valid() {
+			return fmt.Errorf("unknown event %q", e)
+		}
+	}
+	return nil
+}
+
+// checkPublicHost resolves host and rejects it when any of its addresses is not
+// public (domain.PublicAddr).
+func checkPublicHost(ctx context.Context, host string) error {
+	var addrs []netip.Addr
+	if a, err := netip.ParseAddr(host); err == nil {
+		addrs = []netip.Addr{a}
+	} else if addrs, err = net.DefaultResolver.LookupNetIP(ctx, "ip", host); err != nil {
+		return fmt.Errorf("resolve %s: %w", host, err)
+	}
+	for _, a := range addrs {
+		if !domain.PublicAddr(a) {
+			return fmt.Errorf("url host %s resolves to non-public address %s", host, a)
+		}
+	}
+	return nil
+}
+
+// ListWebhooks returns a tenant's webhooks without their secrets.
+func (s *Service) ListWebhooks(ctx context.Context, tenantKey string) ([]domain.Webhook, error) {
+	if err := s.requireWebhooks(); err != nil {
+		return nil, err
+	}
+	return s.webhooks.List(ctx, tenantKey)
+}
+
+// CreateWebhook registers a webhook. A signing secret is generated when none is
+// given; the response is the only place it is returned.
+func (s *Service) CreateWebhook(ctx context.Context, w domain.Webhook) (*domain.Webhook, error) {
+	if err := s.requireWebhooks(); err != nil {
+		return nil, err
+	}
+	if err := s.validateWebhook(ctx, w); err != nil {
+		return nil, err
+	}
+	if w.Secret == "" {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, fmt.Errorf("generate webhook secret: %w", err)
+		}
+		w.Secret = hex.EncodeToString(buf)
+	}
+	slices.Sort(w.Events)
+	w.Events = slices.Compact(w.Events)
+	return s.webhooks.Create(ctx, w)
+}
+
+// UpdateWebhook changes a webhook's URL, events, active flag and, when given, secret.
+func (s *Service) UpdateWebhook(ctx context.Context, w domain.Webhook) (*domain.Webhook, error) {
+	if err := s.requireWebhooks(); err != nil {
+		return nil, err
+	}
+	if err := s.validateWebhook(ctx, w); err != nil {
+		return nil, err
+	}
+	slices.Sort(w.Events)
+	w.Events = slices.Compact(w.Events)
+	return s.webhooks.Update(ctx, w)
+}
+
+// DeleteWebhook removes a webhook and its delivery history.
+func (s *Service) DeleteWebhook(ctx context.Context, tenantKey, idStr string) error {
+	if err := s.requireWebhooks(); err != nil {
+		return err
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return fmt.Errorf("invalid webhook id: %w", err)
+	}
+	return s.webhooks.Delete(ctx, tenantKey, id)
+}
+
+// ListWebhookDeliveries returns a webhook's most recent deliveries, optionally by status.
+func (s *Service) ListWebhookDeliveries(ctx context.Context, tenantKey, idStr string, status domain.WebhookDeliveryStatus, limit int) ([]domain.WebhookDelivery, error) {
+	if err := s.requireWebhooks(); err != nil {
+		return nil, err
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook id: %w", err)
+	}
+	switch status {
+	case "", domain.WebhookPending, domain.WebhookDelivered, domain.WebhookFailed:
+	default:
+		return nil, fmt.Errorf("unknown status %q", status)
+	}
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	return s.webhooks.ListDeliveries(ctx, tenantKey, id, status, limit)
+}
+
+// RetryWebhookDelivery queues a failed delivery for another attempt.
+func (s *Service) RetryWebhookDelivery(ctx context.Context, tenantKey string, deliveryID int64) error {
+	if err := s.requireWebhooks(); err != nil {
+		return err
+	}
+	if err := s.webhooks.RetryDelivery(ctx, tenantKey, deliveryID); err != nil {
+		return err
+	}
+	s.wakeWebhooks()
+	return nil
+}
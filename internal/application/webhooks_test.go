@@ -0,0 +1,39 @@
+package application
+
+import (
+	"context"
+	"testing"
+
+	"vn.io.arda/notification/internal/domain"
+)
+
+func TestValidateWebhookRejectsNonPublicHosts(t *testing.T) {
+	s := &Service{}
+	events := []domain.WebhookEvent{domain.WebhookNotificationCreated}
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{url: "https://203.0.113.10/hook"},
+		{url: "https://127.0.0.1/hook", wantErr: true},
+		{url: "https://10.1.2.3/hook", wantErr: true},
+		{url: "https://192.168.0.5:8443/hook", wantErr: true},
+		{url: "https://169.254.169.254/latest/meta-data", wantErr: true},
+		{url: "https://[::1]/hook", wantErr: true},
+		{url: "https://[fd00::1]/hook", wantErr: true},
+		{url: "https://[::ffff:127.0.0.1]/hook", wantErr: true},
+		{url: "https://0.0.0.0/hook", wantErr: true},
+		{url: "http://203.0.113.10/hook", wantErr: true},
+	}
+	for _, tt := range tests {
+		err := s.validateWebhook(context.Background(), domain.Webhook{URL: tt.url, Events: events})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, want error %v", tt.url, err, tt.wantErr)
+		}
+	}
+
+	s.webhookCfg.AllowPrivate = true
+	if err := s.validateWebhook(context.Background(), domain.Webhook{URL: "https://127.0.0.1/hook", Events: events}); err != nil {
+		t.Errorf("AllowPrivate: %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+// Package cli holds the subcommands shared by the arda-notification service
+// binary and the ardanotif admin tool.
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+
+	"vn.io.arda/notification/internal/infrastructure/postgres"
+	"vn.io.arda/notification/migrations"
+)
+
+const migrateUsage = `usage: %s migrate [command]
+
+commands:
+  up              apply all pending migrations (default)
+  up-to VERSION   apply pending migrations up to VERSION
+  status          list migrations and when they were applied
+  version         print the current schema version
+  baseline VERSION
+                  mark migrations up to VERSION as applied without running them
+                  (databases whose schema was created by hand)`
+
+// Migrate implements the "migrate" subcommand of the program prog.
+func Migrate(ctx context.Context, pool *pgxpool.Pool, prog string, args []string) error {
+	usage := fmt.Sprintf(migrateUsage, prog)
+	cmd := "up"
+	if len(args) > 0 {
+		cmd, args = args[0], args[1:]
+	}
+	versionArg := func() (int64, error) {
+		if len(args) != 1 {
+			return 0, fmt.Errorf("%s requires a VERSION\n\n%s", cmd, usage)
+		}
+		return strconv.ParseInt(args[0], 10, 64)
+	}
+
+	m, err := postgres.NewMigrator(pool, migrations.FS)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	switch cmd {
+	case "up":
+		return logApplied(m.Up(ctx))
+	case "up-to":
+		v, err := versionArg()
+		if err != nil {
+			return err
+		}
+		return logApplied(m.UpTo(ctx, v))
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tAPPLIED AT\tFILE")
+		for _, st := range statuses {
+			applied := "pending"
+			if !st.AppliedAt.IsZero() {
+				applied = st.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", st.Source.Version, applied, st.Source.Path)
+		}
+		return w.Flush()
+	case "version":
+		v, err := m.Version(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Println(v)
+		return nil
+	case "baseline":
+		v, err := versionArg()
+		if err != nil {
+			return err
+		}
+		if err := m.Baseline(ctx, v); err != nil {
+			return err
+		}
+		log.Info().Int64("version", v).Msg("migration history baselined")
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q\n\n%s", cmd, usage)
+	}
+}
+
+// MigrateUp applies pending migrations at startup (DB_AUTO_MIGRATE).
+func MigrateUp(ctx context.Context, pool *pgxpool.Pool) error {
+	m, err := postgres.NewMigrator(pool, migrations.FS)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	return logApplied(m.Up(ctx))
+}
+
+func logApplied(versions []int64, err error) error {
+	if err != nil {
+		return err
+	}
+	if len(versions) == 0 {
+		log.Info().Msg("database schema up to date")
+		return nil
+	}
+	log.Info().Ints64("versions", versions).Msg("database migrations applied")
+	return nil
+}
@@ -4,22 +4,89 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
 )
 
 // Config holds all application configuration.
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Kafka    KafkaConfig    `mapstructure:"kafka"`
-	Keycloak KeycloakConfig `mapstructure:"keycloak"`
-	Email    EmailConfig    `mapstructure:"email"`
-	TTL      TTLConfig      `mapstructure:"ttl"`
+	Server     ServerConfig     `mapstructure:"server"`
+	HTTP       HTTPConfig       `mapstructure:"http"`
+	Auth       AuthConfig       `mapstructure:"auth"`
+	Service    ServiceConfig    `mapstructure:"service_auth"`
+	Database   DatabaseConfig   `mapstructure:"database"`
+	Kafka      KafkaConfig      `mapstructure:"kafka"`
+	Keycloak   KeycloakConfig   `mapstructure:"keycloak"`
+	IAM        IAMConfig        `mapstructure:"iam"`
+	Email      EmailConfig      `mapstructure:"email"`
+	TTL        TTLConfig        `mapstructure:"ttl"`
+	SSE        SSEConfig        `mapstructure:"sse"`
+	Outbox     OutboxConfig     `mapstructure:"outbox"`
+	Fanout     FanoutConfig     `mapstructure:"fanout"`
+	Policy     PolicyConfig     `mapstructure:"policy"`
+	Encryption EncryptionConfig `mapstructure:"encryption"`
+	Widget     WidgetConfig     `mapstructure:"widget"`
+	ID         IDConfig         `mapstructure:"id"`
+	Tenant     TenantConfig     `mapstructure:"tenant"`
+	Template   TemplateConfig   `mapstructure:"template"`
+	RateLimit  RateLimitConfig  `mapstructure:"rate_limit"`
+	Throttle   ThrottleConfig   `mapstructure:"throttle"`
+	Redaction  RedactionConfig  `mapstructure:"redaction"`
+	Metadata   MetadataConfig   `mapstructure:"metadata"`
+	Stats      StatsConfig      `mapstructure:"stats"`
+	Quota      QuotaConfig      `mapstructure:"quota"`
+	Webhook    WebhookConfig    `mapstructure:"webhook"`
+	Chat       ChatConfig       `mapstructure:"chat"`
+	Alert      AlertConfig      `mapstructure:"alert"`
+	Snooze     SnoozeConfig     `mapstructure:"snooze"`
+	Escalation EscalationConfig `mapstructure:"escalation"`
+	Direct     DirectConfig     `mapstructure:"direct"`
+	Redis      RedisConfig      `mapstructure:"redis"`
+	Counters   CountersConfig   `mapstructure:"counters"`
+	Leader     LeaderConfig     `mapstructure:"leader"`
 }
 
 type ServerConfig struct {
 	Port string `mapstructure:"port"`
 	Env  string `mapstructure:"env"`
+	// DrainSeconds is how long /readyz reports not-ready before SSE streams are closed on shutdown.
+	DrainSeconds int `mapstructure:"drain_seconds"`
+	// ShutdownTimeoutSeconds bounds, after draining, stopping the Kafka consumer, closing SSE streams and the HTTP server.
+	ShutdownTimeoutSeconds int `mapstructure:"shutdown_timeout_seconds"`
+	// Region labels this deployment (e.g. "hn", "hcm"); attached to metrics, lifecycle events and SSE frames.
+	Region string `mapstructure:"region"`
+	// ProbeTimeoutMS bounds each dependency probe of /health/ready.
+	ProbeTimeoutMS int `mapstructure:"probe_timeout_ms"`
+	// ProbeCacheSeconds is how long probe results are reused across readiness checks.
+	ProbeCacheSeconds int `mapstructure:"probe_cache_seconds"`
+}
+
+type HTTPConfig struct {
+	CORSEnabled   bool     `mapstructure:"cors_enabled"`    // Default: true; disable when the API gateway answers CORS
+	CORSOrigins   []string `mapstructure:"cors_origins"`    // Default: ["*"] when server.env is development, none otherwise
+	SecureHeaders bool     `mapstructure:"secure_headers"`  // Default: true; nosniff, X-Frame-Options and HSTS
+	HSTSMaxAge    int      `mapstructure:"hsts_max_age"`    // Default: 31536000 seconds; 0 omits Strict-Transport-Security
+	RatePerSecond float64  `mapstructure:"rate_per_second"` // Default: 0 (off); requests per client IP
+	RateBurst     int      `mapstructure:"rate_burst"`      // Default: 0 (= rate_per_second rounded up)
+}
+
+type AuthConfig struct {
+	Mode           string   `mapstructure:"mode"`            // Default: "jwt" (verify X-Internal-Token); "headers" trusts X-User-ID/X-Tenant-Key/X-Roles from the gateway
+	TrustedProxies []string `mapstructure:"trusted_proxies"` // CIDRs or IPs allowed to send identity headers; empty accepts any peer
+	// Roles gating the admin routes. AdminRole and AuditorRole act within their tenant,
+	// PlatformAdminRole on any tenant (policies, encryption keys, quotas, cross-tenant scope).
+	AdminRole         string `mapstructure:"admin_role"`          // Default: "ADMIN"
+	AuditorRole       string `mapstructure:"auditor_role"`        // Default: "AUDITOR"
+	PlatformAdminRole string `mapstructure:"platform_admin_role"` // Default: "PLATFORM_ADMIN"
+}
+
+type ServiceConfig struct {
+	// Clients are the Keycloak client IDs whose service accounts may call /internal; empty disables those routes.
+	Clients  []string `mapstructure:"clients"`
+	Realm    string   `mapstructure:"realm"`    // Default: "master"; realm issuing the client-credentials tokens
+	Audience string   `mapstructure:"audience"` // Default: "arda-notification"; required in the token's aud
+	Issuer   string   `mapstructure:"issuer"`   // Default: {keycloak.base_url}/realms/{realm}; "-" skips the check
 }
 
 type DatabaseConfig struct {
@@ -28,12 +95,76 @@ type DatabaseConfig struct {
 	Name     string `mapstructure:"name"`
 	User     string `mapstructure:"user"`
 	Password string `mapstructure:"password"`
+	// AutoMigrate applies pending embedded migrations at startup.
+	AutoMigrate bool `mapstructure:"auto_migrate"`
+	// Queries taking at least SlowQueryMS are logged with their filter shape.
+	SlowQueryMS int `mapstructure:"slow_query_ms"` // Default: 500, 0 disables
+	// TLS, as in libpq: disable, allow, prefer, require, verify-ca, verify-full.
+	SSLMode     string `mapstructure:"sslmode"`     // Default: "disable"
+	SSLRootCert string `mapstructure:"sslrootcert"` // CA bundle verifying the server (verify-ca / verify-full)
+	SSLCert     string `mapstructure:"sslcert"`     // client certificate, for certificate authentication
+	SSLKey      string `mapstructure:"sslkey"`      // client certificate key
+	// Pool tuning; 0 keeps the pgxpool default.
+	MaxConns                 int32 `mapstructure:"max_conns"`                   // Default: 0 (max(4, CPUs))
+	MinConns                 int32 `mapstructure:"min_conns"`                   // Default: 0
+	MaxConnLifetimeSeconds   int   `mapstructure:"max_conn_lifetime_seconds"`   // Default: 0 (1h)
+	MaxConnIdleSeconds       int   `mapstructure:"max_conn_idle_seconds"`       // Default: 0 (30m)
+	HealthCheckPeriodSeconds int   `mapstructure:"health_check_period_seconds"` // Default: 0 (1m)
+	// ReplicaDSN is a read replica serving inbox lists and counts; empty reads from the primary.
+	ReplicaDSN          string `mapstructure:"replica_dsn"`
+	ReplicaRetrySeconds int    `mapstructure:"replica_retry_seconds"` // Default: 30; reads stay on the primary this long after the replica fails
+	// RLS sets app.tenant_key on connections used by user requests, enforcing the row-level security policies.
+	RLS bool `mapstructure:"rls"` // Default: false
+	// TenantRegistryFile routes tenants to their own database or schema (see postgres.TenantRegistry); empty keeps every tenant in DSN.
+	TenantRegistryFile string `mapstructure:"tenant_registry_file"`
 }
 
 type KafkaConfig struct {
 	Brokers         []string `mapstructure:"brokers"`
 	ConsumerGroupID string   `mapstructure:"consumer_group_id"`
-	Topics          []string `mapstructure:"topics"`
+	// Topics may include patterns: globs such as "*-events" or regexes starting with ^.
+	Topics []string `mapstructure:"topics"`
+	// ReactionTopic receives NOTIFICATION_REACTED events. Empty disables publishing.
+	ReactionTopic string `mapstructure:"reaction_topic"`
+	// ActionTopic receives chosen command actions, typed by their command. Empty disables publishing.
+	ActionTopic string `mapstructure:"action_topic"`
+	// LifecycleTopic receives service lifecycle events (service.draining). Empty disables publishing.
+	LifecycleTopic string `mapstructure:"lifecycle_topic"`
+	// NotificationEventTopic receives notification.created/read/deleted events. Empty disables publishing.
+	NotificationEventTopic string `mapstructure:"notification_event_topic"`
+	// DLQTopic receives records that still fail after MaxRetries. Empty means failing
+	// records block their partition (retried every poll) instead of being skipped.
+	DLQTopic   string `mapstructure:"dlq_topic"`
+	MaxRetries int    `mapstructure:"max_retries"`
+	// RegionPinnedGroup suffixes ConsumerGroupID with the region so each region
+	// consumes every event independently.
+	RegionPinnedGroup bool `mapstructure:"region_pinned_group"`
+	// HandlerErrorBudget is the tolerated rate of failed/malformed records per event
+	// handler over HandlerWindowMinutes before its health turns "exhausted".
+	HandlerErrorBudget   float64 `mapstructure:"handler_error_budget"`   // Default: 0.01
+	HandlerWindowMinutes int     `mapstructure:"handler_window_minutes"` // Default: 60
+	// SchemaValidation checks payloads against their handler's JSON Schema; invalid
+	// records are dead-lettered with the violations instead of being skipped.
+	SchemaValidation bool `mapstructure:"schema_validation"` // Default: true
+	// MetadataHeaders are the Kafka record headers copied into notification metadata.
+	MetadataHeaders []string `mapstructure:"metadata_headers"` // Default: ["traceparent"]
+	// PauseSyncSeconds is how often topic pauses made through the admin API are
+	// picked up by every instance.
+	PauseSyncSeconds int `mapstructure:"pause_sync_seconds"` // Default: 5
+	// TopicFormats sets the wire format of topics that are not JSON:
+	// "topic=avro,topic=protobuf:pkg.Message". Handlers receive decoded JSON either way.
+	TopicFormats string `mapstructure:"topic_formats"`
+	// SchemaRegistryURL is the Confluent Schema Registry holding Avro writer schemas.
+	SchemaRegistryURL      string `mapstructure:"schema_registry_url"`
+	SchemaRegistryUsername string `mapstructure:"schema_registry_username"`
+	SchemaRegistryPassword string `mapstructure:"schema_registry_password"`
+	// ProtoDescriptorSet is the path of a FileDescriptorSet (protoc --include_imports
+	// --descriptor_set_out) with the message types of Protobuf topics.
+	ProtoDescriptorSet string `mapstructure:"proto_descriptor_set"`
+	// Workers is the number of partitions processed concurrently (order kept per partition).
+	Workers int `mapstructure:"workers"`
+	// MaxInFlight bounds records fetched per poll across all workers.
+	MaxInFlight int `mapstructure:"max_in_flight"`
 }
 
 type KeycloakConfig struct {
@@ -47,10 +178,264 @@ type KeycloakConfig struct {
 	AdminClientSecret string `mapstructure:"admin_client_secret"`
 	AdminUser         string `mapstructure:"admin_user"`
 	AdminPassword     string `mapstructure:"admin_password"`
+	// CacheSeconds caches user lookups; NegativeCacheSeconds caches failed lookups (0 disables).
+	CacheSeconds         int `mapstructure:"cache_seconds"`          // Default: 30
+	NegativeCacheSeconds int `mapstructure:"negative_cache_seconds"` // Default: 5
+	// Per-class overrides of CacheSeconds (0 = CacheSeconds). Role also covers group lookups.
+	TenantCacheSeconds   int `mapstructure:"tenant_cache_seconds"`
+	RoleCacheSeconds     int `mapstructure:"role_cache_seconds"`
+	PlatformCacheSeconds int `mapstructure:"platform_cache_seconds"`
+	CacheMaxEntries      int `mapstructure:"cache_max_entries"` // Default: 10000, 0 = unbounded
+}
+
+type IAMConfig struct {
+	// Provider selects the user directory used for fan-out: "keycloak" (default), "ldap", "db" or "static".
+	Provider string `mapstructure:"provider"`
+	// StaticFile is the JSON user directory read by the "static" provider.
+	StaticFile string     `mapstructure:"static_file"`
+	LDAP       LDAPConfig `mapstructure:"ldap"`
+}
+
+type LDAPConfig struct {
+	URL          string `mapstructure:"url"`
+	BindDN       string `mapstructure:"bind_dn"`
+	BindPassword string `mapstructure:"bind_password"`
+	StartTLS     bool   `mapstructure:"start_tls"`
+	// UserBaseDN, GroupBaseDN and RoleBaseDN may contain "{tenant}".
+	UserBaseDN      string `mapstructure:"user_base_dn"`
+	UserFilter      string `mapstructure:"user_filter"`  // Default: "(objectClass=inetOrgPerson)"
+	IDAttribute     string `mapstructure:"id_attribute"` // Default: "uid"
+	GroupBaseDN     string `mapstructure:"group_base_dn"`
+	RoleBaseDN      string `mapstructure:"role_base_dn"`     // Default: GroupBaseDN
+	MemberAttribute string `mapstructure:"member_attribute"` // Default: "member"
+	// Tenants lists the tenant keys reached by PLATFORM scope.
+	Tenants []string `mapstructure:"tenants"`
 }
 
 type TTLConfig struct {
 	RetentionDays int `mapstructure:"retention_days"` // Default: 30
+	// Compaction collapses read LOW-priority runs before purge.
+	CompactionEnabled   bool `mapstructure:"compaction_enabled"`    // Default: true
+	CompactionAfterDays int  `mapstructure:"compaction_after_days"` // Default: 7
+	CompactionMinRun    int  `mapstructure:"compaction_min_run"`    // Default: 5
+	// Rows beyond a user's newest ArchiveHotLimit move to notifications_archive.
+	ArchiveHotLimit        int `mapstructure:"archive_hot_limit"`        // Default: 5000, 0 disables
+	ArchiveIntervalMinutes int `mapstructure:"archive_interval_minutes"` // Default: 60
+	ArchiveBatchSize       int `mapstructure:"archive_batch_size"`       // Default: 10000
+	// Users holding more than MailboxMax notifications (hot and archived) lose
+	// their oldest read ones.
+	MailboxMax             int `mapstructure:"mailbox_max"`              // Default: 0 (no cap)
+	MailboxIntervalMinutes int `mapstructure:"mailbox_interval_minutes"` // Default: 15
+	MailboxBatchSize       int `mapstructure:"mailbox_batch_size"`       // Default: 10000
+	// Notifications given a TTL by their event type's defaults are deleted by this sweep.
+	ExpirySweepMinutes int `mapstructure:"expiry_sweep_minutes"` // Default: 5
+	// The notification audit log has its own retention, usually longer than RetentionDays.
+	AuditRetentionDays int `mapstructure:"audit_retention_days"` // Default: 365, 0 keeps forever
+	// Monthly notifications partitions are created this many months ahead.
+	PartitionMonthsAhead int `mapstructure:"partition_months_ahead"` // Default: 3
+	// The retention job (compaction, partitions, TTL and audit purge) runs on a
+	// cron schedule, once across replicas, delayed by up to PurgeJitterSeconds.
+	PurgeSchedule      string `mapstructure:"purge_schedule"`       // Default: "0 3 * * *"
+	PurgeTimezone      string `mapstructure:"purge_timezone"`       // Default: "UTC"
+	PurgeJitterSeconds int    `mapstructure:"purge_jitter_seconds"` // Default: 300
+	PurgeBatchSize     int    `mapstructure:"purge_batch_size"`     // Default: 10000, 0 = one statement per table
+}
+
+type SSEConfig struct {
+	HeartbeatSeconds   int    `mapstructure:"heartbeat_seconds"`    // Default: 25, 0 disables
+	IdleTimeoutSeconds int    `mapstructure:"idle_timeout_seconds"` // Default: 90, 0 disables reaping
+	MaxConnsPerUser    int    `mapstructure:"max_conns_per_user"`   // Default: 5, 0 = unlimited
+	DropPolicy         string `mapstructure:"drop_policy"`          // "drop-oldest" (default), "drop-newest", "disconnect", "spill"
+	SpillLimit         int    `mapstructure:"spill_limit"`          // Default: 256 (spill policy only)
+	ReauthLeadSeconds  int    `mapstructure:"reauth_lead_seconds"`  // Default: 60; "event: reauth" is sent this long before token expiry
+	ReauthGraceSeconds int    `mapstructure:"reauth_grace_seconds"` // Default: 30; unrefreshed streams close this long after expiry
+}
+
+type OutboxConfig struct {
+	PollIntervalMS int `mapstructure:"poll_interval_ms"` // Default: 1000
+	BatchSize      int `mapstructure:"batch_size"`       // Default: 200
+	LeaseSeconds   int `mapstructure:"lease_seconds"`    // Default: 30
+}
+
+type FanoutConfig struct {
+	// ChunkSize caps rows per INSERT when fanning out to large tenants.
+	ChunkSize int `mapstructure:"chunk_size"` // Default: 1000
+	// CopyThreshold is the chunk size from which rows are bulk-loaded with COPY (0 disables).
+	CopyThreshold int `mapstructure:"copy_threshold"` // Default: 500
+	// TenantStrategy / PlatformStrategy: "write" (row per user) or "read" (stored once).
+	TenantStrategy   string `mapstructure:"tenant_strategy"`   // Default: "write"
+	PlatformStrategy string `mapstructure:"platform_strategy"` // Default: "write"
+	// MaxRecipients caps the recipients of one event (0 = no cap); see OverCapPolicy.
+	MaxRecipients int `mapstructure:"max_recipients"`
+	// OverCapPolicy: "broadcast" (TENANT/PLATFORM stored once, others rejected) or "reject".
+	OverCapPolicy string `mapstructure:"over_cap_policy"` // Default: "broadcast"
+	// CapAlertRole receives a SYSTEM notification in the tenant when its event is rejected.
+	CapAlertRole string `mapstructure:"cap_alert_role"`
+}
+
+type PolicyConfig struct {
+	EvalTimeoutMS int `mapstructure:"eval_timeout_ms"` // Default: 50
+	// FailClosed drops delivery for a tenant whose policy errors instead of ignoring the policy.
+	FailClosed bool `mapstructure:"fail_closed"` // Default: false
+}
+
+type EncryptionConfig struct {
+	// Keys maps key references to base64 256-bit keys: "ref=base64,ref2=base64".
+	// Empty disables content encryption.
+	Keys string `mapstructure:"keys"`
+	// DefaultKeyRef encrypts tenants without their own key; empty stores them in plaintext.
+	DefaultKeyRef string `mapstructure:"default_key_ref"`
+	CacheSeconds  int    `mapstructure:"cache_seconds"` // Default: 60
+	// SensitiveTenants limits DefaultKeyRef to these tenants; empty applies it to every tenant.
+	SensitiveTenants []string `mapstructure:"sensitive_tenants"`
+}
+
+type WidgetConfig struct {
+	// TokenSecret signs widget tokens (HS256); empty disables the widget routes.
+	TokenSecret   string `mapstructure:"token_secret"`
+	MaxTTLSeconds int    `mapstructure:"max_ttl_seconds"` // Default: 3600
+	// IssuerRole is required to call POST /widget-token; the widget is not enabled without it.
+	IssuerRole string `mapstructure:"issuer_role"`
+}
+
+type IDConfig struct {
+	// Format renders notification IDs in responses: "uuid" (default) or "ulid". Both are accepted as input.
+	Format string `mapstructure:"format"`
+	// Generator assigns new IDs: "db" (uuidv7() default), "uuidv7" or "ulid".
+	Generator string `mapstructure:"generator"`
+}
+
+type TenantConfig struct {
+	// IdleAfterHours marks tenants without REST/SSE activity for this long as idle:
+	// compaction skips them and their cached state is reclaimed. 0 disables tracking.
+	IdleAfterHours       int `mapstructure:"idle_after_hours"`       // Default: 168 (7 days)
+	ActivityFlushSeconds int `mapstructure:"activity_flush_seconds"` // Default: 60
+	ReclaimMinutes       int `mapstructure:"reclaim_minutes"`        // Default: 60
+}
+
+type RateLimitConfig struct {
+	// Fan-outs per second per tenant and per source topic (producer); 0 disables the bucket.
+	TenantPerSecond float64 `mapstructure:"tenant_per_second"` // Default: 0
+	TenantBurst     int     `mapstructure:"tenant_burst"`      // Default: 0 (= rate)
+	TopicPerSecond  float64 `mapstructure:"topic_per_second"`  // Default: 0
+	TopicBurst      int     `mapstructure:"topic_burst"`       // Default: 0 (= rate)
+	// Policy over the limit: "queue" (wait, default), "sample" (drop) or "reject" (dead-letter).
+	Policy    string `mapstructure:"policy"`
+	MaxWaitMS int    `mapstructure:"max_wait_ms"` // Default: 5000; longer queue waits are rejected
+}
+
+type ThrottleConfig struct {
+	// Per-user limits applied to every type unless overridden in Types.
+	DedupWindowSeconds int `mapstructure:"dedup_window_seconds"` // Default: 0 (disabled)
+	UserPerMinute      int `mapstructure:"user_per_minute"`      // Default: 0 (unlimited)
+	// Types overrides per type: "TYPE=dedup_seconds/per_minute,...", e.g. "WORKFLOW=300/20".
+	Types string `mapstructure:"types"`
+}
+
+type RedactionConfig struct {
+	Enabled bool `mapstructure:"enabled"` // Default: false
+	// Patterns are built-in patterns: email, national_id, phone, token.
+	Patterns []string `mapstructure:"patterns"` // Default: email,national_id,phone,token
+	// CustomPatterns adds "name=regexp" entries separated by whitespace (regexps may contain commas).
+	CustomPatterns string   `mapstructure:"custom_patterns"`
+	Keys           []string `mapstructure:"keys"` // Default: password,secret,access_token,refresh_token,api_key,authorization
+	Mode           string   `mapstructure:"mode"` // Default: mask; mask | strip
+}
+
+type MetadataConfig struct {
+	MaxBytes       int    `mapstructure:"max_bytes"`       // Default: 8192; 0 = no cap
+	OversizePolicy string `mapstructure:"oversize_policy"` // Default: truncate; truncate | reject (also rejects invalid well-known keys)
+}
+
+type StatsConfig struct {
+	// A nightly job, run once across replicas, rolls engagement up into notification_stats.
+	Enabled      bool   `mapstructure:"enabled"`       // Default: true
+	Schedule     string `mapstructure:"schedule"`      // Default: "30 1 * * *"
+	Timezone     string `mapstructure:"timezone"`      // Default: "UTC"; also the day boundaries of the rollup
+	LookbackDays int    `mapstructure:"lookback_days"` // Default: 7; days recomputed each run, as reads arrive late
+}
+
+type QuotaConfig struct {
+	// The quota of tenants without one set through the admin API; counted per calendar month (UTC).
+	MonthlyLimit           int64  `mapstructure:"monthly_limit"`            // Default: 0 (unlimited)
+	Overage                string `mapstructure:"overage"`                  // Default: "reject"; or "queue" to hold fan-outs until quota frees up
+	ReleaseIntervalSeconds int    `mapstructure:"release_interval_seconds"` // Default: 60; how often held fan-outs are retried
+}
+
+type WebhookConfig struct {
+	PollIntervalMS    int  `mapstructure:"poll_interval_ms"`    // Default: 2000
+	BatchSize         int  `mapstructure:"batch_size"`          // Default: 100
+	LeaseSeconds      int  `mapstructure:"lease_seconds"`       // Default: 60
+	TimeoutSeconds    int  `mapstructure:"timeout_seconds"`     // Default: 10
+	MaxAttempts       int  `mapstructure:"max_attempts"`        // Default: 8
+	BackoffSeconds    int  `mapstructure:"backoff_seconds"`     // Default: 30; doubles per failed attempt
+	BackoffMaxSeconds int  `mapstructure:"backoff_max_seconds"` // Default: 3600
+	AllowHTTP         bool `mapstructure:"allow_http"`          // Default: false; accept http:// endpoints (dev only)
+	AllowPrivate      bool `mapstructure:"allow_private"`       // Default: false; accept loopback/private/link-local endpoints (dev only)
+}
+
+type ChatConfig struct {
+	TimeoutSeconds int `mapstructure:"timeout_seconds"` // Default: 10; per Slack/Teams post
+}
+
+// AlertConfig routes internal critical conditions (DLQ spikes, job failures,
+// dependency outages) to operators. Environment and instance are taken from
+// server.env and the hostname.
+type AlertConfig struct {
+	Backends             []string `mapstructure:"backends"` // Default: ["log"]; any of log, webhook, pagerduty
+	WebhookURL           string   `mapstructure:"webhook_url"`
+	PagerDutyRoutingKey  string   `mapstructure:"pagerduty_routing_key"`
+	MinSeverity          string   `mapstructure:"min_severity"`           // Default: "warning"
+	CooldownSeconds      int      `mapstructure:"cooldown_seconds"`       // Default: 300; per alert key
+	TimeoutSeconds       int      `mapstructure:"timeout_seconds"`        // Default: 10; per backend call
+	DLQThreshold         int      `mapstructure:"dlq_threshold"`          // Default: 10 dead-letters per window; 0 disables
+	DLQWindowSeconds     int      `mapstructure:"dlq_window_seconds"`     // Default: 300
+	ProbeIntervalSeconds int      `mapstructure:"probe_interval_seconds"` // Default: 30; 0 disables the background probe watch
+}
+
+type SnoozeConfig struct {
+	WakeIntervalSeconds int `mapstructure:"wake_interval_seconds"` // Default: 30; max delay before a snoozed notification reappears
+	BatchSize           int `mapstructure:"batch_size"`            // Default: 500
+}
+
+type EscalationConfig struct {
+	IntervalSeconds int `mapstructure:"interval_seconds"` // Default: 30; max delay after an escalation is due
+	BatchSize       int `mapstructure:"batch_size"`       // Default: 200
+}
+
+type DirectConfig struct {
+	Enabled       bool `mapstructure:"enabled"`        // Default: true
+	MaxRecipients int  `mapstructure:"max_recipients"` // Default: 20; per request
+	PerMinute     int  `mapstructure:"per_minute"`     // Default: 30; recipients per sender per minute
+}
+
+type RedisConfig struct {
+	Addr      string `mapstructure:"addr"` // host:port; empty disables Redis
+	Password  string `mapstructure:"password"`
+	DB        int    `mapstructure:"db"`
+	TLS       bool   `mapstructure:"tls"`
+	TimeoutMS int    `mapstructure:"timeout_ms"` // Default: 200; per command round trip
+}
+
+// CountersConfig controls the unread count cache. It lives in Redis when
+// redis.addr is set, otherwise in each replica's memory.
+type CountersConfig struct {
+	Enabled                  bool `mapstructure:"enabled"`                    // Default: false
+	TTLSeconds               int  `mapstructure:"ttl_seconds"`                // Default: 300; bounds drift from untracked changes
+	ReconcileIntervalSeconds int  `mapstructure:"reconcile_interval_seconds"` // Default: 60
+	ReconcileBatchSize       int  `mapstructure:"reconcile_batch_size"`       // Default: 1000; cached counts checked per round
+}
+
+// LeaderConfig controls the election of the replica running singleton jobs.
+type LeaderConfig struct {
+	CheckSeconds int `mapstructure:"check_seconds"` // Default: 10; lock health check and takeover retry interval
+}
+
+type TemplateConfig struct {
+	// Mode is "write" (render at fan-out, default) or "read" (store the template
+	// key and parameters only, render on every read and SSE push).
+	Mode          string `mapstructure:"mode"`
+	DefaultLocale string `mapstructure:"default_locale"` // Default: "vi"
 }
 
 type EmailConfig struct {
@@ -66,28 +451,183 @@ type EmailConfig struct {
 // Load reads configuration from environment variables and config files.
 // Environment variables override file values. Prefix: ARDA_NOTIF_
 func Load() (*Config, error) {
+	return decode(newViper())
+}
+
+// Watch reloads the configuration whenever the config file changes and passes
+// the result to onChange; environment variables keep overriding file values.
+// Returns false when no config file was found, in which case nothing is watched.
+func Watch(onChange func(*Config)) bool {
+	v := newViper()
+	if v.ConfigFileUsed() == "" {
+		return false
+	}
+	v.OnConfigChange(func(e fsnotify.Event) {
+		cfg, err := decode(v)
+		if err != nil {
+			log.Error().Err(err).Str("file", e.Name).Msg("config reload failed, keeping previous configuration")
+			return
+		}
+		log.Info().Str("file", e.Name).Msg("config reloaded")
+		onChange(cfg)
+	})
+	v.WatchConfig()
+	return true
+}
+
+// newViper returns a viper instance with defaults, environment bindings and the
+// optional config file loaded.
+func newViper() *viper.Viper {
 	v := viper.New()
 
 	// Defaults
 	v.SetDefault("server.port", "8090")
 	v.SetDefault("server.env", "development")
+	v.SetDefault("server.drain_seconds", 5)
+	v.SetDefault("server.shutdown_timeout_seconds", 30)
+	v.SetDefault("server.region", "default")
+	v.SetDefault("server.probe_timeout_ms", 2000)
+	v.SetDefault("server.probe_cache_seconds", 5)
+	v.SetDefault("http.cors_enabled", true)
+	v.SetDefault("http.secure_headers", true)
+	v.SetDefault("http.hsts_max_age", 31536000)
+	v.SetDefault("http.rate_per_second", 0)
+	v.SetDefault("http.rate_burst", 0)
+	v.SetDefault("auth.mode", "jwt")
+	v.SetDefault("auth.admin_role", "ADMIN")
+	v.SetDefault("auth.auditor_role", "AUDITOR")
+	v.SetDefault("auth.platform_admin_role", "PLATFORM_ADMIN")
+	v.SetDefault("service_auth.realm", "master")
+	v.SetDefault("service_auth.audience", "arda-notification")
 	v.SetDefault("database.host", "localhost")
 	v.SetDefault("database.port", 5432)
 	v.SetDefault("database.name", "arda_notification")
 	v.SetDefault("database.user", "postgres")
 	v.SetDefault("database.password", "password")
+	v.SetDefault("database.auto_migrate", false)
+	v.SetDefault("database.rls", false)
+	v.SetDefault("database.slow_query_ms", 500)
+	v.SetDefault("database.sslmode", "disable")
+	v.SetDefault("database.replica_retry_seconds", 30)
 	v.SetDefault("kafka.brokers", []string{"localhost:9092"})
 	v.SetDefault("kafka.consumer_group_id", "arda-notification-group")
 	v.SetDefault("kafka.topics", []string{"tenant-events", "bpm-events", "crm-events", "iam-events", "notification-commands"})
+	v.SetDefault("kafka.reaction_topic", "notification-reactions")
+	v.SetDefault("kafka.action_topic", "notification-actions")
+	v.SetDefault("kafka.lifecycle_topic", "notification-lifecycle")
+	v.SetDefault("kafka.notification_event_topic", "notification-events")
+	v.SetDefault("kafka.dlq_topic", "notification-dlq")
+	v.SetDefault("kafka.max_retries", 3)
+	v.SetDefault("kafka.region_pinned_group", false)
+	v.SetDefault("kafka.workers", 4)
+	v.SetDefault("kafka.max_in_flight", 500)
+	v.SetDefault("kafka.handler_error_budget", 0.01)
+	v.SetDefault("kafka.handler_window_minutes", 60)
+	v.SetDefault("kafka.schema_validation", true)
+	v.SetDefault("kafka.metadata_headers", []string{"traceparent"})
+	v.SetDefault("kafka.pause_sync_seconds", 5)
 	v.SetDefault("keycloak.base_url", "http://localhost:8081")
 	v.SetDefault("keycloak.admin_realm", "master")
 	v.SetDefault("keycloak.admin_client_id", "admin-cli")
 	v.SetDefault("keycloak.admin_user", "admin")
 	v.SetDefault("keycloak.admin_password", "admin")
+	v.SetDefault("keycloak.cache_seconds", 30)
+	v.SetDefault("keycloak.negative_cache_seconds", 5)
+	v.SetDefault("keycloak.cache_max_entries", 10000)
+	v.SetDefault("iam.provider", "keycloak")
 	v.SetDefault("ttl.retention_days", 30)
+	v.SetDefault("ttl.compaction_enabled", true)
+	v.SetDefault("ttl.compaction_after_days", 7)
+	v.SetDefault("ttl.compaction_min_run", 5)
+	v.SetDefault("ttl.archive_hot_limit", 5000)
+	v.SetDefault("ttl.archive_interval_minutes", 60)
+	v.SetDefault("ttl.archive_batch_size", 10000)
+	v.SetDefault("ttl.mailbox_max", 0)
+	v.SetDefault("ttl.mailbox_interval_minutes", 15)
+	v.SetDefault("ttl.mailbox_batch_size", 10000)
+	v.SetDefault("ttl.expiry_sweep_minutes", 5)
+	v.SetDefault("ttl.audit_retention_days", 365)
+	v.SetDefault("ttl.partition_months_ahead", 3)
+	v.SetDefault("ttl.purge_schedule", "0 3 * * *")
+	v.SetDefault("ttl.purge_timezone", "UTC")
+	v.SetDefault("ttl.purge_jitter_seconds", 300)
+	v.SetDefault("ttl.purge_batch_size", 10000)
+	v.SetDefault("sse.heartbeat_seconds", 25)
+	v.SetDefault("sse.idle_timeout_seconds", 90)
+	v.SetDefault("sse.max_conns_per_user", 5)
+	v.SetDefault("sse.drop_policy", "drop-oldest")
+	v.SetDefault("sse.spill_limit", 256)
+	v.SetDefault("sse.reauth_lead_seconds", 60)
+	v.SetDefault("sse.reauth_grace_seconds", 30)
+	v.SetDefault("outbox.poll_interval_ms", 1000)
+	v.SetDefault("outbox.batch_size", 200)
+	v.SetDefault("outbox.lease_seconds", 30)
+	v.SetDefault("fanout.chunk_size", 1000)
+	v.SetDefault("fanout.copy_threshold", 500)
+	v.SetDefault("fanout.tenant_strategy", "write")
+	v.SetDefault("fanout.platform_strategy", "write")
+	v.SetDefault("fanout.max_recipients", 0)
+	v.SetDefault("fanout.over_cap_policy", "broadcast")
+	v.SetDefault("fanout.cap_alert_role", "")
+	v.SetDefault("policy.eval_timeout_ms", 50)
+	v.SetDefault("policy.fail_closed", false)
+	v.SetDefault("encryption.cache_seconds", 60)
+	v.SetDefault("widget.max_ttl_seconds", 3600)
+	v.SetDefault("id.format", "uuid")
+	v.SetDefault("id.generator", "db")
+	v.SetDefault("tenant.idle_after_hours", 168)
+	v.SetDefault("tenant.activity_flush_seconds", 60)
+	v.SetDefault("tenant.reclaim_minutes", 60)
+	v.SetDefault("rate_limit.policy", "queue")
+	v.SetDefault("rate_limit.max_wait_ms", 5000)
+	v.SetDefault("webhook.poll_interval_ms", 2000)
+	v.SetDefault("webhook.batch_size", 100)
+	v.SetDefault("webhook.lease_seconds", 60)
+	v.SetDefault("webhook.timeout_seconds", 10)
+	v.SetDefault("webhook.max_attempts", 8)
+	v.SetDefault("webhook.backoff_seconds", 30)
+	v.SetDefault("webhook.backoff_max_seconds", 3600)
+	v.SetDefault("webhook.allow_http", false)
+	v.SetDefault("webhook.allow_private", false)
+	v.SetDefault("chat.timeout_seconds", 10)
+	v.SetDefault("alert.backends", []string{"log"})
+	v.SetDefault("alert.min_severity", "warning")
+	v.SetDefault("alert.cooldown_seconds", 300)
+	v.SetDefault("alert.timeout_seconds", 10)
+	v.SetDefault("alert.dlq_threshold", 10)
+	v.SetDefault("alert.dlq_window_seconds", 300)
+	v.SetDefault("alert.probe_interval_seconds", 30)
+	v.SetDefault("snooze.wake_interval_seconds", 30)
+	v.SetDefault("snooze.batch_size", 500)
+	v.SetDefault("escalation.interval_seconds", 30)
+	v.SetDefault("escalation.batch_size", 200)
+	v.SetDefault("direct.enabled", true)
+	v.SetDefault("direct.max_recipients", 20)
+	v.SetDefault("direct.per_minute", 30)
+	v.SetDefault("redis.timeout_ms", 200)
+	v.SetDefault("counters.enabled", false)
+	v.SetDefault("counters.ttl_seconds", 300)
+	v.SetDefault("counters.reconcile_interval_seconds", 60)
+	v.SetDefault("counters.reconcile_batch_size", 1000)
+	v.SetDefault("leader.check_seconds", 10)
+	v.SetDefault("template.mode", "write")
+	v.SetDefault("template.default_locale", "vi")
 	v.SetDefault("email.provider", "log")
 	v.SetDefault("email.from_name", "Arda Notification")
 	v.SetDefault("email.from_address", "noreply@arda.io.vn")
+	v.SetDefault("redaction.enabled", false)
+	v.SetDefault("redaction.patterns", []string{"email", "national_id", "phone", "token"})
+	v.SetDefault("redaction.keys", []string{"password", "secret", "access_token", "refresh_token", "api_key", "authorization"})
+	v.SetDefault("redaction.mode", "mask")
+	v.SetDefault("metadata.max_bytes", 8192)
+	v.SetDefault("metadata.oversize_policy", "truncate")
+	v.SetDefault("stats.enabled", true)
+	v.SetDefault("stats.schedule", "30 1 * * *")
+	v.SetDefault("stats.timezone", "UTC")
+	v.SetDefault("stats.lookback_days", 7)
+	v.SetDefault("quota.monthly_limit", 0)
+	v.SetDefault("quota.overage", "reject")
+	v.SetDefault("quota.release_interval_seconds", 60)
 
 	// Environment variables (e.g. DB_HOST -> database.host)
 	v.SetEnvPrefix("ARDA_NOTIF")
@@ -100,14 +640,162 @@ func Load() (*Config, error) {
 	v.BindEnv("database.name", "DB_NAME")
 	v.BindEnv("database.user", "DB_USER")
 	v.BindEnv("database.password", "DB_PASSWORD")
+	v.BindEnv("database.auto_migrate", "DB_AUTO_MIGRATE")
+	v.BindEnv("database.rls", "DB_RLS")
+	v.BindEnv("database.tenant_registry_file", "DB_TENANT_REGISTRY_FILE")
+	v.BindEnv("database.slow_query_ms", "DB_SLOW_QUERY_MS")
+	v.BindEnv("database.sslmode", "DB_SSLMODE")
+	v.BindEnv("database.sslrootcert", "DB_SSLROOTCERT")
+	v.BindEnv("database.sslcert", "DB_SSLCERT")
+	v.BindEnv("database.sslkey", "DB_SSLKEY")
+	v.BindEnv("database.max_conns", "DB_MAX_CONNS")
+	v.BindEnv("database.min_conns", "DB_MIN_CONNS")
+	v.BindEnv("database.max_conn_lifetime_seconds", "DB_MAX_CONN_LIFETIME_SECONDS")
+	v.BindEnv("database.max_conn_idle_seconds", "DB_MAX_CONN_IDLE_SECONDS")
+	v.BindEnv("database.health_check_period_seconds", "DB_HEALTH_CHECK_PERIOD_SECONDS")
+	v.BindEnv("database.replica_dsn", "DB_REPLICA_DSN")
+	v.BindEnv("database.replica_retry_seconds", "DB_REPLICA_RETRY_SECONDS")
 	v.BindEnv("kafka.brokers", "KAFKA_BROKERS")
+	v.BindEnv("kafka.topics", "KAFKA_TOPICS")
+	v.BindEnv("kafka.reaction_topic", "KAFKA_REACTION_TOPIC")
+	v.BindEnv("kafka.action_topic", "KAFKA_ACTION_TOPIC")
+	v.BindEnv("kafka.lifecycle_topic", "KAFKA_LIFECYCLE_TOPIC")
+	v.BindEnv("kafka.notification_event_topic", "KAFKA_NOTIFICATION_EVENT_TOPIC")
+	v.BindEnv("kafka.dlq_topic", "KAFKA_DLQ_TOPIC")
+	v.BindEnv("kafka.max_retries", "KAFKA_MAX_RETRIES")
+	v.BindEnv("kafka.workers", "KAFKA_WORKERS")
+	v.BindEnv("kafka.max_in_flight", "KAFKA_MAX_IN_FLIGHT")
+	v.BindEnv("kafka.handler_error_budget", "KAFKA_HANDLER_ERROR_BUDGET")
+	v.BindEnv("kafka.handler_window_minutes", "KAFKA_HANDLER_WINDOW_MINUTES")
+	v.BindEnv("kafka.schema_validation", "KAFKA_SCHEMA_VALIDATION")
+	v.BindEnv("kafka.metadata_headers", "KAFKA_METADATA_HEADERS")
+	v.BindEnv("kafka.pause_sync_seconds", "KAFKA_PAUSE_SYNC_SECONDS")
+	v.BindEnv("kafka.topic_formats", "KAFKA_TOPIC_FORMATS")
+	v.BindEnv("kafka.schema_registry_url", "KAFKA_SCHEMA_REGISTRY_URL")
+	v.BindEnv("kafka.schema_registry_username", "KAFKA_SCHEMA_REGISTRY_USERNAME")
+	v.BindEnv("kafka.schema_registry_password", "KAFKA_SCHEMA_REGISTRY_PASSWORD")
+	v.BindEnv("kafka.proto_descriptor_set", "KAFKA_PROTO_DESCRIPTOR_SET")
+	v.BindEnv("fanout.chunk_size", "FANOUT_CHUNK_SIZE")
+	v.BindEnv("fanout.copy_threshold", "FANOUT_COPY_THRESHOLD")
+	v.BindEnv("fanout.tenant_strategy", "FANOUT_TENANT_STRATEGY")
+	v.BindEnv("fanout.platform_strategy", "FANOUT_PLATFORM_STRATEGY")
+	v.BindEnv("fanout.max_recipients", "FANOUT_MAX_RECIPIENTS")
+	v.BindEnv("fanout.over_cap_policy", "FANOUT_OVER_CAP_POLICY")
+	v.BindEnv("fanout.cap_alert_role", "FANOUT_CAP_ALERT_ROLE")
+	v.BindEnv("encryption.keys", "ENCRYPTION_KEYS")
+	v.BindEnv("widget.token_secret", "WIDGET_TOKEN_SECRET")
+	v.BindEnv("widget.issuer_role", "WIDGET_ISSUER_ROLE")
+	v.BindEnv("encryption.default_key_ref", "ENCRYPTION_DEFAULT_KEY_REF")
+	v.BindEnv("encryption.sensitive_tenants", "ENCRYPTION_SENSITIVE_TENANTS")
 	v.BindEnv("keycloak.base_url", "KEYCLOAK_URL")
 	v.BindEnv("keycloak.admin_realm", "KEYCLOAK_ADMIN_REALM")
 	v.BindEnv("keycloak.admin_client_id", "KEYCLOAK_ADMIN_CLIENT_ID")
 	v.BindEnv("keycloak.admin_client_secret", "KEYCLOAK_ADMIN_CLIENT_SECRET")
 	v.BindEnv("keycloak.admin_user", "KEYCLOAK_ADMIN_USER")
 	v.BindEnv("keycloak.admin_password", "KEYCLOAK_ADMIN_PASSWORD")
+	v.BindEnv("keycloak.cache_seconds", "KEYCLOAK_CACHE_SECONDS")
+	v.BindEnv("keycloak.negative_cache_seconds", "KEYCLOAK_NEGATIVE_CACHE_SECONDS")
+	v.BindEnv("keycloak.tenant_cache_seconds", "KEYCLOAK_TENANT_CACHE_SECONDS")
+	v.BindEnv("keycloak.role_cache_seconds", "KEYCLOAK_ROLE_CACHE_SECONDS")
+	v.BindEnv("keycloak.platform_cache_seconds", "KEYCLOAK_PLATFORM_CACHE_SECONDS")
+	v.BindEnv("keycloak.cache_max_entries", "KEYCLOAK_CACHE_MAX_ENTRIES")
+	v.BindEnv("iam.provider", "IAM_PROVIDER")
+	v.BindEnv("iam.static_file", "IAM_STATIC_FILE")
+	v.BindEnv("iam.ldap.url", "LDAP_URL")
+	v.BindEnv("iam.ldap.bind_dn", "LDAP_BIND_DN")
+	v.BindEnv("iam.ldap.bind_password", "LDAP_BIND_PASSWORD")
+	v.BindEnv("iam.ldap.start_tls", "LDAP_START_TLS")
+	v.BindEnv("iam.ldap.user_base_dn", "LDAP_USER_BASE_DN")
+	v.BindEnv("iam.ldap.user_filter", "LDAP_USER_FILTER")
+	v.BindEnv("iam.ldap.id_attribute", "LDAP_ID_ATTRIBUTE")
+	v.BindEnv("iam.ldap.group_base_dn", "LDAP_GROUP_BASE_DN")
+	v.BindEnv("iam.ldap.role_base_dn", "LDAP_ROLE_BASE_DN")
+	v.BindEnv("iam.ldap.member_attribute", "LDAP_MEMBER_ATTRIBUTE")
+	v.BindEnv("iam.ldap.tenants", "LDAP_TENANTS")
+	v.BindEnv("id.format", "ID_FORMAT")
+	v.BindEnv("id.generator", "ID_GENERATOR")
+	v.BindEnv("tenant.idle_after_hours", "TENANT_IDLE_AFTER_HOURS")
+	v.BindEnv("rate_limit.tenant_per_second", "RATE_LIMIT_TENANT_PER_SECOND")
+	v.BindEnv("rate_limit.tenant_burst", "RATE_LIMIT_TENANT_BURST")
+	v.BindEnv("rate_limit.topic_per_second", "RATE_LIMIT_TOPIC_PER_SECOND")
+	v.BindEnv("rate_limit.topic_burst", "RATE_LIMIT_TOPIC_BURST")
+	v.BindEnv("rate_limit.policy", "RATE_LIMIT_POLICY")
+	v.BindEnv("rate_limit.max_wait_ms", "RATE_LIMIT_MAX_WAIT_MS")
+	v.BindEnv("throttle.dedup_window_seconds", "THROTTLE_DEDUP_WINDOW_SECONDS")
+	v.BindEnv("throttle.user_per_minute", "THROTTLE_USER_PER_MINUTE")
+	v.BindEnv("throttle.types", "THROTTLE_TYPES")
+	v.BindEnv("redaction.enabled", "REDACTION_ENABLED")
+	v.BindEnv("redaction.patterns", "REDACTION_PATTERNS")
+	v.BindEnv("redaction.custom_patterns", "REDACTION_CUSTOM_PATTERNS")
+	v.BindEnv("redaction.keys", "REDACTION_KEYS")
+	v.BindEnv("redaction.mode", "REDACTION_MODE")
+	v.BindEnv("metadata.max_bytes", "METADATA_MAX_BYTES")
+	v.BindEnv("metadata.oversize_policy", "METADATA_OVERSIZE_POLICY")
+	v.BindEnv("stats.enabled", "STATS_ENABLED")
+	v.BindEnv("stats.schedule", "STATS_SCHEDULE")
+	v.BindEnv("stats.timezone", "STATS_TIMEZONE")
+	v.BindEnv("stats.lookback_days", "STATS_LOOKBACK_DAYS")
+	v.BindEnv("quota.monthly_limit", "QUOTA_MONTHLY_LIMIT")
+	v.BindEnv("quota.overage", "QUOTA_OVERAGE")
+	v.BindEnv("quota.release_interval_seconds", "QUOTA_RELEASE_INTERVAL_SECONDS")
+	v.BindEnv("webhook.poll_interval_ms", "WEBHOOK_POLL_INTERVAL_MS")
+	v.BindEnv("webhook.batch_size", "WEBHOOK_BATCH_SIZE")
+	v.BindEnv("webhook.lease_seconds", "WEBHOOK_LEASE_SECONDS")
+	v.BindEnv("webhook.timeout_seconds", "WEBHOOK_TIMEOUT_SECONDS")
+	v.BindEnv("webhook.max_attempts", "WEBHOOK_MAX_ATTEMPTS")
+	v.BindEnv("webhook.backoff_seconds", "WEBHOOK_BACKOFF_SECONDS")
+	v.BindEnv("webhook.backoff_max_seconds", "WEBHOOK_BACKOFF_MAX_SECONDS")
+	v.BindEnv("webhook.allow_http", "WEBHOOK_ALLOW_HTTP")
+	v.BindEnv("webhook.allow_private", "WEBHOOK_ALLOW_PRIVATE")
+	v.BindEnv("chat.timeout_seconds", "CHAT_TIMEOUT_SECONDS")
+	v.BindEnv("alert.backends", "ALERT_BACKENDS")
+	v.BindEnv("alert.webhook_url", "ALERT_WEBHOOK_URL")
+	v.BindEnv("alert.pagerduty_routing_key", "ALERT_PAGERDUTY_ROUTING_KEY")
+	v.BindEnv("alert.min_severity", "ALERT_MIN_SEVERITY")
+	v.BindEnv("alert.cooldown_seconds", "ALERT_COOLDOWN_SECONDS")
+	v.BindEnv("alert.timeout_seconds", "ALERT_TIMEOUT_SECONDS")
+	v.BindEnv("alert.dlq_threshold", "ALERT_DLQ_THRESHOLD")
+	v.BindEnv("alert.dlq_window_seconds", "ALERT_DLQ_WINDOW_SECONDS")
+	v.BindEnv("alert.probe_interval_seconds", "ALERT_PROBE_INTERVAL_SECONDS")
+	v.BindEnv("snooze.wake_interval_seconds", "SNOOZE_WAKE_INTERVAL_SECONDS")
+	v.BindEnv("snooze.batch_size", "SNOOZE_BATCH_SIZE")
+	v.BindEnv("escalation.interval_seconds", "ESCALATION_INTERVAL_SECONDS")
+	v.BindEnv("escalation.batch_size", "ESCALATION_BATCH_SIZE")
+	v.BindEnv("direct.enabled", "DIRECT_ENABLED")
+	v.BindEnv("direct.max_recipients", "DIRECT_MAX_RECIPIENTS")
+	v.BindEnv("direct.per_minute", "DIRECT_PER_MINUTE")
+	v.BindEnv("redis.addr", "REDIS_ADDR")
+	v.BindEnv("redis.password", "REDIS_PASSWORD")
+	v.BindEnv("redis.db", "REDIS_DB")
+	v.BindEnv("redis.tls", "REDIS_TLS")
+	v.BindEnv("redis.timeout_ms", "REDIS_TIMEOUT_MS")
+	v.BindEnv("counters.enabled", "COUNTER_CACHE_ENABLED")
+	v.BindEnv("counters.ttl_seconds", "COUNTER_CACHE_TTL_SECONDS")
+	v.BindEnv("counters.reconcile_interval_seconds", "COUNTER_RECONCILE_INTERVAL_SECONDS")
+	v.BindEnv("counters.reconcile_batch_size", "COUNTER_RECONCILE_BATCH_SIZE")
+	v.BindEnv("leader.check_seconds", "LEADER_CHECK_SECONDS")
+	v.BindEnv("template.mode", "TEMPLATE_MODE")
+	v.BindEnv("template.default_locale", "TEMPLATE_DEFAULT_LOCALE")
 	v.BindEnv("server.port", "PORT")
+	v.BindEnv("server.region", "REGION")
+	v.BindEnv("server.probe_timeout_ms", "HEALTH_PROBE_TIMEOUT_MS")
+	v.BindEnv("server.probe_cache_seconds", "HEALTH_PROBE_CACHE_SECONDS")
+	v.BindEnv("server.shutdown_timeout_seconds", "SHUTDOWN_TIMEOUT_SECONDS")
+	v.BindEnv("http.cors_enabled", "CORS_ENABLED")
+	v.BindEnv("http.cors_origins", "CORS_ALLOWED_ORIGINS")
+	v.BindEnv("http.secure_headers", "SECURE_HEADERS")
+	v.BindEnv("http.hsts_max_age", "HSTS_MAX_AGE")
+	v.BindEnv("http.rate_per_second", "HTTP_RATE_PER_SECOND")
+	v.BindEnv("http.rate_burst", "HTTP_RATE_BURST")
+	v.BindEnv("auth.mode", "AUTH_MODE")
+	v.BindEnv("auth.trusted_proxies", "AUTH_TRUSTED_PROXIES")
+	v.BindEnv("auth.admin_role", "AUTH_ADMIN_ROLE")
+	v.BindEnv("auth.auditor_role", "AUTH_AUDITOR_ROLE")
+	v.BindEnv("auth.platform_admin_role", "AUTH_PLATFORM_ADMIN_ROLE")
+	v.BindEnv("service_auth.clients", "SERVICE_AUTH_CLIENTS")
+	v.BindEnv("service_auth.realm", "SERVICE_AUTH_REALM")
+	v.BindEnv("service_auth.audience", "SERVICE_AUTH_AUDIENCE")
+	v.BindEnv("service_auth.issuer", "SERVICE_AUTH_ISSUER")
 	v.BindEnv("email.provider", "EMAIL_PROVIDER")
 	v.BindEnv("email.smtp_host", "EMAIL_SMTP_HOST")
 	v.BindEnv("email.smtp_port", "EMAIL_SMTP_PORT")
@@ -122,23 +810,48 @@ func Load() (*Config, error) {
 	v.AddConfigPath(".")
 	v.AddConfigPath("./config")
 	_ = v.ReadInConfig() // Not required
+	return v
+}
 
+func decode(v *viper.Viper) (*Config, error) {
 	var cfg Config
 	if err := v.Unmarshal(&cfg); err != nil {
 		return nil, err
 	}
 
+	if cfg.Kafka.RegionPinnedGroup && cfg.Server.Region != "" {
+		cfg.Kafka.ConsumerGroupID += "-" + cfg.Server.Region
+	}
+	if len(cfg.HTTP.CORSOrigins) == 0 && cfg.Server.Env == "development" {
+		cfg.HTTP.CORSOrigins = []string{"*"}
+	}
+
 	return &cfg, nil
 }
 
 // DSN returns the PostgreSQL connection string.
 func (d DatabaseConfig) DSN() string {
-	return "host=" + d.Host +
+	sslMode := d.SSLMode
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+	dsn := "host=" + dsnValue(d.Host) +
 		" port=" + itoa(d.Port) +
-		" dbname=" + d.Name +
-		" user=" + d.User +
-		" password=" + d.Password +
-		" sslmode=disable"
+		" dbname=" + dsnValue(d.Name) +
+		" user=" + dsnValue(d.User) +
+		" password=" + dsnValue(d.Password) +
+		" sslmode=" + dsnValue(sslMode)
+	for _, kv := range [][2]string{{"sslrootcert", d.SSLRootCert}, {"sslcert", d.SSLCert}, {"sslkey", d.SSLKey}} {
+		if kv[1] != "" {
+			dsn += " " + kv[0] + "=" + dsnValue(kv[1])
+		}
+	}
+	return dsn
+}
+
+// dsnValue quotes a keyword/value connection string value.
+func dsnValue(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
 }
 
 func itoa(i int) string {
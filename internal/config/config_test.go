@@ -0,0 +1,72 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestLoadRegionPinnedGroup(t *testing.T) {
+	t.Setenv("REGION", "hn")
+	t.Setenv("ARDA_NOTIF_KAFKA_REGION_PINNED_GROUP", "true")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.Region != "hn" || cfg.Kafka.ConsumerGroupID != "arda-notification-group-hn" {
+		t.Fatalf("region %q, consumer group %q", cfg.Server.Region, cfg.Kafka.ConsumerGroupID)
+	}
+}
+
+func TestLoadFanoutCopyThreshold(t *testing.T) {
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Fanout.CopyThreshold != 500 {
+		t.Fatalf("default copy threshold = %d, want 500", cfg.Fanout.CopyThreshold)
+	}
+
+	t.Setenv("FANOUT_COPY_THRESHOLD", "0")
+	if cfg, err = Load(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Fanout.CopyThreshold != 0 {
+		t.Fatalf("copy threshold = %d, want 0", cfg.Fanout.CopyThreshold)
+	}
+}
+
+func TestDatabaseDSN(t *testing.T) {
+	t.Setenv("DB_PASSWORD", `p@ss word'\`)
+	t.Setenv("DB_SSLMODE", "verify-full")
+	t.Setenv("DB_SSLROOTCERT", "/etc/ssl/db ca.pem")
+	t.Setenv("DB_MAX_CONNS", "20")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Database.MaxConns != 20 {
+		t.Fatalf("max conns = %d, want 20", cfg.Database.MaxConns)
+	}
+
+	// A root certificate that does not exist fails the TLS setup, so parse
+	// without it and check that it is passed through quoted.
+	dsn := cfg.Database.DSN()
+	if !strings.Contains(dsn, ` sslrootcert='/etc/ssl/db ca.pem'`) {
+		t.Fatalf("DSN %q does not carry the root certificate", dsn)
+	}
+	cfg.Database.SSLRootCert = ""
+	parsed, err := pgconn.ParseConfig(cfg.Database.DSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Password != `p@ss word'\` || parsed.TLSConfig == nil || parsed.TLSConfig.ServerName != cfg.Database.Host {
+		t.Fatalf("parsed password %q, TLS %v", parsed.Password, parsed.TLSConfig)
+	}
+
+	cfg.Database.SSLMode = ""
+	if parsed, err = pgconn.ParseConfig(cfg.Database.DSN()); err != nil || parsed.TLSConfig != nil {
+		t.Fatalf("default sslmode: TLS %v, %v; want disabled", parsed.TLSConfig, err)
+	}
+}
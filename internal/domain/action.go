@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ActionType tells the frontend how an action button is carried out.
+type ActionType string
+
+const (
+	// ActionLink navigates to the action URL.
+	ActionLink ActionType = "link"
+	// ActionHTTP calls the action URL with its method from the frontend.
+	ActionHTTP ActionType = "http"
+	// ActionCommand is chosen through the notification service, which emits the
+	// action's command back to the originating service over Kafka.
+	ActionCommand ActionType = "command"
+)
+
+// Kind returns the action's type, inferred for actions produced before types
+// existed: a command wins, then a non-GET method means an HTTP call.
+func (a Action) Kind() ActionType {
+	switch {
+	case a.Type != "":
+		return a.Type
+	case a.Command != "":
+		return ActionCommand
+	case a.Method == "" || a.Method == http.MethodGet:
+		return ActionLink
+	default:
+		return ActionHTTP
+	}
+}
+
+// FindAction returns the action button with the given identifier.
+func (n *Notification) FindAction(id string) (Action, bool) {
+	for _, a := range n.Actions() {
+		if a.Action == id {
+			return a, true
+		}
+	}
+	return Action{}, false
+}
+
+// ActionRecord is the action a user chose on a notification.
+type ActionRecord struct {
+	ID             uuid.UUID      `json:"id"`
+	NotificationID uuid.UUID      `json:"notification_id"`
+	TenantKey      string         `json:"tenant_key"`
+	UserID         string         `json:"user_id"`
+	ActionID       string         `json:"action_id"`
+	Type           ActionType     `json:"type"`
+	Command        string         `json:"command,omitempty"`
+	Payload        map[string]any `json:"payload,omitempty"`
+	Comment        string         `json:"comment,omitempty"`
+	SourceEventID  string         `json:"source_event_id,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+}
+
+// ActionRepository defines the port for chosen-action persistence.
+type ActionRepository interface {
+	// Create stores a chosen action. Returns nil (not error) when the user has already chosen one.
+	Create(ctx context.Context, r ActionRecord) (*ActionRecord, error)
+
+	// ListByNotification returns the actions chosen on a notification.
+	ListByNotification(ctx context.Context, notificationID uuid.UUID) ([]ActionRecord, error)
+}
+
+// ActionPublisher emits chosen command actions to the originating service (e.g. Kafka).
+type ActionPublisher interface {
+	PublishAction(ctx context.Context, r ActionRecord) error
+}
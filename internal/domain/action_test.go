@@ -0,0 +1,36 @@
+package domain
+
+import "testing"
+
+func TestActionKind(t *testing.T) {
+	cases := []struct {
+		action Action
+		want   ActionType
+	}{
+		{Action{URL: "/crm/deals/1", Method: "GET"}, ActionLink},
+		{Action{URL: "/crm/deals/1"}, ActionLink},
+		{Action{URL: "/bpm/tasks/1/approve", Method: "POST"}, ActionHTTP},
+		{Action{Command: "TASK_APPROVE"}, ActionCommand},
+		{Action{Type: ActionLink, Command: "TASK_APPROVE"}, ActionLink},
+	}
+	for _, c := range cases {
+		if got := c.action.Kind(); got != c.want {
+			t.Errorf("Kind(%+v) = %q, want %q", c.action, got, c.want)
+		}
+	}
+}
+
+func TestFindAction(t *testing.T) {
+	n := &Notification{Metadata: map[string]any{"actions": []any{
+		map[string]any{"label": "Phê duyệt", "action": "approve", "type": "command", "command": "TASK_APPROVE",
+			"payload": map[string]any{"taskId": "t1"}},
+		map[string]any{"label": "Xem", "action": "view", "url": "/bpm/tasks/t1"},
+	}}}
+	a, ok := n.FindAction("approve")
+	if !ok || a.Kind() != ActionCommand || a.Payload["taskId"] != "t1" {
+		t.Fatalf("approve = %+v, %v", a, ok)
+	}
+	if _, ok := n.FindAction("delete"); ok {
+		t.Fatal("found an action that does not exist")
+	}
+}
@@ -0,0 +1,15 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// TenantActivityRepository persists the last user-facing activity per tenant, shared
+// across instances, so dormant tenants can be skipped by background jobs.
+type TenantActivityRepository interface {
+	// Touch records activity times, keeping the latest value per tenant.
+	Touch(ctx context.Context, lastActive map[string]time.Time) error
+	// ActiveSince returns the tenants with activity at or after since.
+	ActiveSince(ctx context.Context, since time.Time) ([]string, error)
+}
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// AlertSeverity ranks an operational alert.
+type AlertSeverity string
+
+const (
+	AlertInfo     AlertSeverity = "info"
+	AlertWarning  AlertSeverity = "warning"
+	AlertCritical AlertSeverity = "critical"
+)
+
+// Valid reports whether s is a known severity.
+func (s AlertSeverity) Valid() bool {
+	return s == AlertInfo || s == AlertWarning || s == AlertCritical
+}
+
+// Rank orders severities for filtering; unknown severities rank as warning.
+func (s AlertSeverity) Rank() int {
+	switch s {
+	case AlertInfo:
+		return 0
+	case AlertCritical:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// Alert reports an internal condition to operators (DLQ growth, failing jobs,
+// unreachable dependencies). Alerts never reach end users.
+type Alert struct {
+	// Key identifies the condition: repeats are suppressed while it is open and
+	// a Resolved alert with the same key closes it.
+	Key      string         `json:"key"`
+	Severity AlertSeverity  `json:"severity"`
+	Source   string         `json:"source"` // component, e.g. "kafka", "job.purge_ttl", "dependency.postgres"
+	Summary  string         `json:"summary"`
+	Details  map[string]any `json:"details,omitempty"`
+	Resolved bool           `json:"resolved,omitempty"`
+
+	// Set by the dispatcher.
+	Environment string    `json:"environment,omitempty"`
+	Instance    string    `json:"instance,omitempty"`
+	At          time.Time `json:"at"`
+}
+
+// Alerter delivers alerts to an external monitoring system.
+type Alerter interface {
+	Alert(ctx context.Context, a Alert) error
+}
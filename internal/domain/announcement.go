@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AnnouncementSeverity sets how prominently a client renders a banner.
+type AnnouncementSeverity string
+
+const (
+	SeverityInfo     AnnouncementSeverity = "info"
+	SeverityWarning  AnnouncementSeverity = "warning"
+	SeverityCritical AnnouncementSeverity = "critical"
+)
+
+// Valid reports whether s is a known severity.
+func (s AnnouncementSeverity) Valid() bool {
+	return s == SeverityInfo || s == SeverityWarning || s == SeverityCritical
+}
+
+// Announcement is a tenant- or platform-wide banner (maintenance windows,
+// incidents, release notes). It is stored once and shown to every user of the
+// scope between StartsAt and EndsAt until they dismiss it, instead of being
+// fanned out as a notification per user.
+type Announcement struct {
+	ID        uuid.UUID            `json:"id"`
+	TenantKey string               `json:"tenant_key"` // empty = every tenant
+	Title     string               `json:"title"`
+	Body      string               `json:"body"`
+	Severity  AnnouncementSeverity `json:"severity"`
+	StartsAt  time.Time            `json:"starts_at"`
+	EndsAt    *time.Time           `json:"ends_at,omitempty"` // nil = until deleted
+	CreatedBy string               `json:"created_by,omitempty"`
+	CreatedAt time.Time            `json:"created_at"`
+	UpdatedAt time.Time            `json:"updated_at"`
+}
+
+// ActiveAt reports whether the banner is shown at t.
+func (a Announcement) ActiveAt(t time.Time) bool {
+	return !t.Before(a.StartsAt) && (a.EndsAt == nil || t.Before(*a.EndsAt))
+}
+
+// AnnouncementRepository defines the persistence port for announcements and
+// their per-user dismissal state.
+type AnnouncementRepository interface {
+	// ListActive returns the announcements of tenantKey and those for every tenant
+	// that are active at now and not dismissed by the user, newest first.
+	ListActive(ctx context.Context, tenantKey, userID string, now time.Time) ([]Announcement, error)
+
+	// List returns the announcements of tenantKey and those for every tenant, or
+	// all announcements when tenantKey is empty, newest first.
+	List(ctx context.Context, tenantKey string) ([]Announcement, error)
+
+	// Get returns an announcement, or nil when it does not exist.
+	Get(ctx context.Context, id uuid.UUID) (*Announcement, error)
+
+	Create(ctx context.Context, a Announcement) (*Announcement, error)
+
+	// Update replaces title, body, severity and schedule. The scope is fixed.
+	Update(ctx context.Context, a Announcement) (*Announcement, error)
+
+	// Delete removes an announcement and its dismissals.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// Dismiss hides an announcement for a user. Dismissing twice is a no-op.
+	Dismiss(ctx context.Context, id uuid.UUID, tenantKey, userID string) error
+}
@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditAction is a lifecycle step recorded in the notification audit log.
+type AuditAction string
+
+const (
+	AuditCreated   AuditAction = "created"
+	AuditDelivered AuditAction = "delivered"
+	AuditRead      AuditAction = "read"
+	AuditUnread    AuditAction = "unread"
+	AuditDeleted   AuditAction = "deleted"
+	AuditRestored  AuditAction = "restored"
+	AuditRecalled  AuditAction = "recalled"
+	AuditPurged    AuditAction = "purged"
+)
+
+// Valid reports whether a is a known audit action.
+func (a AuditAction) Valid() bool {
+	switch a {
+	case AuditCreated, AuditDelivered, AuditRead, AuditUnread, AuditDeleted, AuditRestored, AuditRecalled, AuditPurged:
+		return true
+	}
+	return false
+}
+
+// Audit actors that are not users.
+const (
+	AuditActorSystem     = "system"
+	AuditActorRetention  = "system:retention"
+	AuditActorExpiry     = "system:expiry"
+	AuditActorCompaction = "system:compaction"
+	AuditActorMailboxCap = "system:mailbox_cap"
+)
+
+// AuditEntry is one append-only record of a notification's lifecycle. Entries
+// outlive the notification, so they remain after it is deleted or purged.
+// UserID is empty for broadcasts.
+type AuditEntry struct {
+	ID             int64          `json:"id"`
+	NotificationID uuid.UUID      `json:"notification_id"`
+	TenantKey      string         `json:"tenant_key"`
+	UserID         string         `json:"user_id"`
+	Action         AuditAction    `json:"action"`
+	Actor          string         `json:"actor"`
+	Channel        Channel        `json:"channel,omitempty"`
+	SourceEventID  string         `json:"source_event_id,omitempty"`
+	Data           map[string]any `json:"data,omitempty"`
+	OccurredAt     time.Time      `json:"occurred_at"`
+}
+
+// AuditFilter selects audit entries of one tenant, newest first.
+type AuditFilter struct {
+	TenantKey      string
+	NotificationID *uuid.UUID
+	UserID         string
+	SourceEventID  string
+	Action         AuditAction
+	From, To       *time.Time
+	Limit          int
+	Offset         int
+}
+
+// Validate checks the action and time range.
+func (f AuditFilter) Validate() error {
+	if f.Action != "" && !f.Action.Valid() {
+		return fmt.Errorf("unknown audit action %q", f.Action)
+	}
+	if f.From != nil && f.To != nil && f.To.Before(*f.From) {
+		return fmt.Errorf("to must not be before from")
+	}
+	return nil
+}
+
+// AuditRepository defines the port for the notification audit log. Created,
+// read, deleted and purged entries are written by the notification repository
+// in the same statement as the change; Record is for steps outside the database
+// such as delivery.
+type AuditRepository interface {
+	// Record appends entries.
+	Record(ctx context.Context, entries []AuditEntry) error
+
+	// List returns the entries matching f.
+	List(ctx context.Context, f AuditFilter) ([]AuditEntry, error)
+
+	// PurgeBefore deletes entries that occurred before cutoff.
+	PurgeBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
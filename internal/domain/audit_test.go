@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAuditFilterValidate(t *testing.T) {
+	from := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	before := from.Add(-time.Hour)
+	cases := []struct {
+		name    string
+		f       AuditFilter
+		wantErr bool
+	}{
+		{"empty", AuditFilter{TenantKey: "t1"}, false},
+		{"known action", AuditFilter{Action: AuditDelivered}, false},
+		{"unknown action", AuditFilter{Action: "seen"}, true},
+		{"range", AuditFilter{From: &before, To: &from}, false},
+		{"inverted range", AuditFilter{From: &from, To: &before}, true},
+	}
+	for _, c := range cases {
+		if err := c.f.Validate(); (err != nil) != c.wantErr {
+			t.Errorf("%s: Validate() = %v, wantErr %v", c.name, err, c.wantErr)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package domain
+
+import "strings"
+
+// Well-known notification categories emitted by the built-in event handlers.
+// Categories are lowercase, dot-separated and start with the producing domain.
+const (
+	CategoryTenantLifecycle = "tenant.lifecycle"
+	CategoryBPMTask         = "bpm.task"
+	CategoryBPMApproval     = "bpm.approval"
+	CategoryCRMLead         = "crm.lead"
+	CategoryCRMDeal         = "crm.deal"
+	CategoryIAMSecurity     = "iam.security"
+)
+
+// MaxCategoryLen matches the category column width.
+const MaxCategoryLen = 100
+
+// ValidCategory reports whether c is a well-formed category: dot-separated segments
+// of [a-z0-9_-], e.g. "crm.deal" or "bpm.approval". The empty category is valid.
+func ValidCategory(c string) bool {
+	if len(c) > MaxCategoryLen {
+		return false
+	}
+	if c == "" {
+		return true
+	}
+	for _, seg := range strings.Split(c, ".") {
+		if seg == "" {
+			return false
+		}
+		for _, r := range seg {
+			if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '_' && r != '-' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// CategoryPrefix returns the prefix of a "crm.*" style filter, and whether filter is one.
+func CategoryPrefix(filter string) (string, bool) {
+	prefix, ok := strings.CutSuffix(filter, ".*")
+	return prefix, ok
+}
@@ -0,0 +1,20 @@
+package domain
+
+import "testing"
+
+func TestValidCategory(t *testing.T) {
+	for c, want := range map[string]bool{
+		"":              true,
+		"crm.deal":      true,
+		"bpm.approval":  true,
+		"crm_v2.deal-x": true,
+		"CRM.deal":      false,
+		"crm..deal":     false,
+		".crm":          false,
+		"crm.*":         false,
+	} {
+		if got := ValidCategory(c); got != want {
+			t.Errorf("ValidCategory(%q) = %v, want %v", c, got, want)
+		}
+	}
+}
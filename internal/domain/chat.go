@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ChatProvider is the chat tool a connector posts to.
+type ChatProvider string
+
+const (
+	ChatSlack ChatProvider = "slack"
+	ChatTeams ChatProvider = "teams"
+)
+
+// Valid reports whether p is a supported provider.
+func (p ChatProvider) Valid() bool {
+	return p == ChatSlack || p == ChatTeams
+}
+
+// ChatConnector forwards a tenant's notifications of the selected types to a
+// Slack or Microsoft Teams incoming webhook.
+//
+// Template formats the message text; {{title}}, {{body}}, {{type}}, {{category}},
+// {{priority}} and {{tenant}} are substituted. Empty uses the provider default.
+type ChatConnector struct {
+	ID        uuid.UUID          `json:"id"`
+	TenantKey string             `json:"tenant_key"`
+	Name      string             `json:"name"`
+	Provider  ChatProvider       `json:"provider"`
+	URL       string             `json:"url"` // masked except in the create response
+	Types     []NotificationType `json:"types"`
+	Template  string             `json:"template,omitempty"`
+	Active    bool               `json:"active"`
+	CreatedAt time.Time          `json:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at"`
+}
+
+// MaskChatURL hides the token path of an incoming webhook URL, which grants
+// posting rights to anyone holding it.
+func MaskChatURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "***"
+	}
+	return u.Scheme + "://" + u.Host + "/***"
+}
+
+// ChatMessage is a formatted notification ready to post.
+type ChatMessage struct {
+	Title    string
+	Text     string
+	Priority Priority
+}
+
+// ChatConnectorRepository defines the persistence port for chat connectors.
+type ChatConnectorRepository interface {
+	// List returns a tenant's connectors with masked URLs.
+	List(ctx context.Context, tenantKey string) ([]ChatConnector, error)
+
+	// ListForType returns the active connectors subscribed to notifType, with full
+	// URLs. An empty tenantKey matches every tenant (platform broadcasts).
+	ListForType(ctx context.Context, tenantKey string, notifType NotificationType) ([]ChatConnector, error)
+
+	// Get returns a connector with its full URL, or nil when it does not exist.
+	Get(ctx context.Context, tenantKey string, id uuid.UUID) (*ChatConnector, error)
+
+	Create(ctx context.Context, c ChatConnector) (*ChatConnector, error)
+
+	// Update replaces name, types, template and active flag, and the URL when non-empty.
+	Update(ctx context.Context, c ChatConnector) (*ChatConnector, error)
+
+	Delete(ctx context.Context, tenantKey string, id uuid.UUID) error
+}
+
+// ChatPoster posts a message to a connector's incoming webhook.
+type ChatPoster interface {
+	Post(ctx context.Context, c ChatConnector, msg ChatMessage) error
+}
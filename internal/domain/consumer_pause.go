@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// ConsumerPause stops Kafka consumption of a topic on every instance until it
+// is resumed. Records are kept in Kafka and consumed once the topic resumes.
+type ConsumerPause struct {
+	Topic    string    `json:"topic"`
+	Reason   string    `json:"reason,omitempty"`
+	PausedBy string    `json:"paused_by"`
+	PausedAt time.Time `json:"paused_at"`
+}
+
+// ConsumerPauseRepository defines the port for paused Kafka topics.
+type ConsumerPauseRepository interface {
+	// List returns all paused topics ordered by topic.
+	List(ctx context.Context) ([]ConsumerPause, error)
+
+	// Pause inserts the pause of p.Topic, replacing its reason if already paused.
+	Pause(ctx context.Context, p ConsumerPause) (*ConsumerPause, error)
+
+	// Resume removes the pause of a topic. Returns false when it was not paused.
+	Resume(ctx context.Context, topic string) (bool, error)
+}
@@ -0,0 +1,38 @@
+package domain
+
+import "context"
+
+// CounterKey identifies a user's cached unread count.
+type CounterKey struct {
+	TenantKey string
+	UserID    string
+}
+
+// CounterDelta is a change to a user's cached unread count.
+type CounterDelta struct {
+	CounterKey
+	Delta int64
+}
+
+// CounterStore caches per-user unread counts in front of
+// Repository.CountUnread. Entries are filled from Postgres on a miss, adjusted
+// as notifications are created and read, and dropped whenever a change cannot be
+// expressed as a delta. Entries expire after a store-specific TTL, which bounds
+// the drift from changes the service does not track (e.g. archiving).
+type CounterStore interface {
+	// Get returns the cached count; ok is false on a miss.
+	Get(ctx context.Context, key CounterKey) (count int64, ok bool, err error)
+	// Set caches count, resetting the entry's TTL.
+	Set(ctx context.Context, key CounterKey, count int64) error
+	// Add applies deltas to cached entries. Missing entries stay missing, and
+	// counts never go below zero.
+	Add(ctx context.Context, deltas []CounterDelta) error
+	// Invalidate drops the given entries.
+	Invalidate(ctx context.Context, keys ...CounterKey) error
+	// InvalidateTenant drops every entry of tenantKey, or all entries when
+	// tenantKey is empty.
+	InvalidateTenant(ctx context.Context, tenantKey string) error
+	// Keys returns about limit cached keys for reconciliation, resuming where
+	// the previous call stopped so successive calls cover every entry.
+	Keys(ctx context.Context, limit int) ([]CounterKey, error)
+}
@@ -0,0 +1,47 @@
+package domain
+
+// CountGroup is the number of a user's notifications of one type and priority.
+type CountGroup struct {
+	Type     NotificationType
+	Priority Priority
+	Total    int64
+	Unread   int64
+}
+
+// Count is a total split by read state.
+type Count struct {
+	Total  int64 `json:"total"`
+	Read   int64 `json:"read"`
+	Unread int64 `json:"unread"`
+}
+
+func (c *Count) add(g CountGroup) {
+	c.Total += g.Total
+	c.Unread += g.Unread
+	c.Read = c.Total - c.Unread
+}
+
+// NotificationCounts breaks a user's inbox down by type and priority, for per-tab badges.
+type NotificationCounts struct {
+	Count
+	ByType     map[NotificationType]Count `json:"by_type"`
+	ByPriority map[Priority]Count         `json:"by_priority"`
+}
+
+// SummarizeCounts folds per-type-and-priority groups into totals per type and per priority.
+func SummarizeCounts(groups []CountGroup) NotificationCounts {
+	counts := NotificationCounts{
+		ByType:     make(map[NotificationType]Count),
+		ByPriority: make(map[Priority]Count),
+	}
+	for _, g := range groups {
+		counts.add(g)
+		t := counts.ByType[g.Type]
+		t.add(g)
+		counts.ByType[g.Type] = t
+		p := counts.ByPriority[g.Priority]
+		p.add(g)
+		counts.ByPriority[g.Priority] = p
+	}
+	return counts
+}
@@ -0,0 +1,25 @@
+package domain
+
+import "testing"
+
+func TestSummarizeCounts(t *testing.T) {
+	counts := SummarizeCounts([]CountGroup{
+		{Type: TypeCRM, Priority: PriorityHigh, Total: 3, Unread: 2},
+		{Type: TypeCRM, Priority: PriorityNormal, Total: 5, Unread: 1},
+		{Type: TypeWorkflow, Priority: PriorityHigh, Total: 4, Unread: 4},
+	})
+	if counts.Count != (Count{Total: 12, Read: 5, Unread: 7}) {
+		t.Fatalf("total = %+v", counts.Count)
+	}
+	if got := counts.ByType[TypeCRM]; got != (Count{Total: 8, Read: 5, Unread: 3}) {
+		t.Fatalf("CRM = %+v", got)
+	}
+	if got := counts.ByPriority[PriorityHigh]; got != (Count{Total: 7, Read: 1, Unread: 6}) {
+		t.Fatalf("HIGH = %+v", got)
+	}
+
+	empty := SummarizeCounts(nil)
+	if empty.Total != 0 || empty.ByType == nil || empty.ByPriority == nil {
+		t.Fatalf("empty = %+v", empty)
+	}
+}
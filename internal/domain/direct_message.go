@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// Categories of user-to-user notifications; they are delivered as CUSTOM so a
+// recipient can mute them like any other category.
+const (
+	CategoryUserMention = "user.mention"
+	CategoryUserMessage = "user.message"
+)
+
+// DirectMessageRule governs user-to-user notifications within a tenant.
+type DirectMessageRule struct {
+	TenantKey string `json:"tenant_key"`
+	Enabled   bool   `json:"enabled"`
+	// SenderRoles restricts who may send; empty lets every user send.
+	SenderRoles []string `json:"sender_roles"`
+	// MaxRecipients caps the recipients of one request.
+	MaxRecipients int `json:"max_recipients"`
+	// PerMinute caps the recipients a sender reaches per minute.
+	PerMinute int       `json:"per_minute"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AllowsSender reports whether a user holding roles may send.
+func (r DirectMessageRule) AllowsSender(roles []string) bool {
+	if !r.Enabled {
+		return false
+	}
+	if len(r.SenderRoles) == 0 {
+		return true
+	}
+	for _, role := range roles {
+		if slices.Contains(r.SenderRoles, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// DirectMessageRuleRepository defines the persistence port for tenant rules of
+// user-to-user notifications.
+type DirectMessageRuleRepository interface {
+	// Get returns a tenant's rule. Returns nil (not error) when none exists.
+	Get(ctx context.Context, tenantKey string) (*DirectMessageRule, error)
+
+	// List returns all stored rules.
+	List(ctx context.Context) ([]DirectMessageRule, error)
+
+	// Upsert inserts or replaces a tenant's rule.
+	Upsert(ctx context.Context, r DirectMessageRule) (*DirectMessageRule, error)
+
+	// Delete removes a tenant's rule, restoring the defaults.
+	Delete(ctx context.Context, tenantKey string) error
+}
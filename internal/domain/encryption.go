@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TenantEncryptionKey registers a tenant's own KMS key (bring-your-own-key).
+// Notification title and body for the tenant are encrypted with this key at rest.
+type TenantEncryptionKey struct {
+	TenantKey string    `json:"tenant_key"`
+	KeyRef    string    `json:"key_ref"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// EncryptionKeyRepository defines the port for tenant key registration persistence.
+type EncryptionKeyRepository interface {
+	// Get returns the tenant's key registration. Returns nil (not error) when none exists.
+	Get(ctx context.Context, tenantKey string) (*TenantEncryptionKey, error)
+
+	// List returns all registered tenant keys.
+	List(ctx context.Context) ([]TenantEncryptionKey, error)
+
+	// Upsert registers or replaces a tenant's key reference.
+	Upsert(ctx context.Context, k TenantEncryptionKey) (*TenantEncryptionKey, error)
+
+	// Delete removes a tenant's key registration. Existing ciphertext stays readable
+	// as long as the key reference remains resolvable by the KeyProvider.
+	Delete(ctx context.Context, tenantKey string) error
+}
+
+// KeyProvider resolves a KMS key reference to 256-bit key material.
+type KeyProvider interface {
+	DataKey(ctx context.Context, keyRef string) ([]byte, error)
+}
+
+// StoredContent is the content of one stored notification, as persisted
+// (possibly encrypted).
+type StoredContent struct {
+	ID        uuid.UUID
+	TenantKey string
+	Title     string
+	Body      string
+	Metadata  map[string]any
+}
+
+// ContentRepository defines the port for rewriting stored notification content
+// in place, e.g. to re-encrypt it after a key rotation.
+type ContentRepository interface {
+	// RewriteContent passes each stored notification of tenantKey (inbox, archive
+	// and the tenant's broadcasts) to fn and saves those fn reports as changed.
+	// Returns the number of notifications saved.
+	RewriteContent(ctx context.Context, tenantKey string, fn func(*StoredContent) (bool, error)) (int64, error)
+}
+
+// KeyRotator re-encrypts a tenant's stored content with its current key.
+type KeyRotator interface {
+	Rotate(ctx context.Context, tenantKey string) (int64, error)
+}
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EscalationAction is what happens when a notification stays unread too long.
+type EscalationAction string
+
+const (
+	// EscalateRenotify pushes the notification to the user's streams again as a
+	// "notification_escalated" event.
+	EscalateRenotify EscalationAction = "renotify"
+	// EscalateEmail emails the notification to the user, regardless of the user's
+	// email preference.
+	EscalateEmail EscalationAction = "email"
+	// EscalateRole notifies the members of a fallback role of the tenant.
+	EscalateRole EscalationAction = "role"
+)
+
+// Valid reports whether a is a supported action.
+func (a EscalationAction) Valid() bool {
+	return a == EscalateRenotify || a == EscalateEmail || a == EscalateRole
+}
+
+// EscalationRule escalates a tenant's notifications of at least Priority (HIGH
+// or URGENT) that are still unread AfterSeconds after they were delivered. A notification
+// matching several rules escalates once per rule, so a tenant can chain a
+// reminder after 15 minutes and a fallback role after an hour.
+type EscalationRule struct {
+	ID           uuid.UUID        `json:"id"`
+	TenantKey    string           `json:"tenant_key"`
+	Type         NotificationType `json:"type,omitempty"` // empty = every type
+	Priority     Priority         `json:"priority"`
+	AfterSeconds int              `json:"after_seconds"`
+	Action       EscalationAction `json:"action"`
+	Role         string           `json:"role,omitempty"` // fallback role of EscalateRole
+	Active       bool             `json:"active"`
+	CreatedAt    time.Time        `json:"created_at"`
+	UpdatedAt    time.Time        `json:"updated_at"`
+}
+
+// Matches reports whether the rule applies to n.
+func (r EscalationRule) Matches(n *Notification) bool {
+	if !r.Active || (r.Type != "" && r.Type != n.Type) {
+		return false
+	}
+	return n.Priority == r.Priority || n.Priority == PriorityUrgent
+}
+
+// Escalation is a pending escalation of one notification by one rule.
+type Escalation struct {
+	NotificationID uuid.UUID
+	TenantKey      string
+	UserID         string
+	RuleID         uuid.UUID
+	DueAt          time.Time
+}
+
+// EscalationRepository defines the persistence port for escalation rules and
+// pending escalations.
+type EscalationRepository interface {
+	// ListRules returns a tenant's rules, active or not.
+	ListRules(ctx context.Context, tenantKey string) ([]EscalationRule, error)
+
+	// GetRule returns a rule, or nil when it does not exist.
+	GetRule(ctx context.Context, tenantKey string, id uuid.UUID) (*EscalationRule, error)
+
+	CreateRule(ctx context.Context, r EscalationRule) (*EscalationRule, error)
+
+	UpdateRule(ctx context.Context, r EscalationRule) (*EscalationRule, error)
+
+	// DeleteRule removes a rule and its pending escalations.
+	DeleteRule(ctx context.Context, tenantKey string, id uuid.UUID) error
+
+	// Schedule stores pending escalations. Already scheduled (notification, rule)
+	// pairs are skipped, so redelivered notifications are not scheduled twice.
+	Schedule(ctx context.Context, escalations []Escalation) error
+
+	// ClaimDue removes up to limit escalations due at now and returns those whose
+	// notification is still unread and not snoozed. SKIP LOCKED lets several
+	// instances run the scheduler.
+	ClaimDue(ctx context.Context, now time.Time, limit int) ([]Escalation, error)
+
+	// Cancel drops the pending escalations of a user's notifications ids, or of
+	// all the user's notifications when ids is empty.
+	Cancel(ctx context.Context, tenantKey, userID string, ids []uuid.UUID) error
+}
@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// metadataExpiresAtKey holds a notification's expiry (RFC 3339, UTC) in its metadata.
+const metadataExpiresAtKey = "expires_at"
+
+// EventDefaults fills in what a Kafka event handler leaves unset, so priority,
+// category, lifetime and channels can be tuned per event type without a redeploy.
+// Empty fields leave the handler's value (or the service default) in place.
+type EventDefaults struct {
+	// EventKey is the handler key "topic:eventType" ("topic:" for direct topics).
+	EventKey   string    `json:"event_key"`
+	Priority   Priority  `json:"priority,omitempty"`
+	Category   string    `json:"category,omitempty"`
+	TTLSeconds int       `json:"ttl_seconds,omitempty"` // 0 = kept until the retention purge
+	Channels   []Channel `json:"channels,omitempty"`    // nil = all channels
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// EventDefaultsRepository defines the port for per-event-type defaults.
+type EventDefaultsRepository interface {
+	// Get returns the defaults of an event key. Returns nil (not error) when none exist.
+	Get(ctx context.Context, eventKey string) (*EventDefaults, error)
+
+	// List returns all stored defaults ordered by event key.
+	List(ctx context.Context) ([]EventDefaults, error)
+
+	// Upsert inserts or replaces the defaults of d.EventKey.
+	Upsert(ctx context.Context, d EventDefaults) (*EventDefaults, error)
+
+	// Delete removes the defaults of an event key.
+	Delete(ctx context.Context, eventKey string) error
+}
+
+// WithExpiry returns a copy of metadata carrying an expiry; expired notifications
+// are deleted by the expiry sweep.
+func WithExpiry(metadata map[string]any, at time.Time) map[string]any {
+	out := make(map[string]any, len(metadata)+1)
+	for k, v := range metadata {
+		out[k] = v
+	}
+	out[metadataExpiresAtKey] = at.UTC().Format(time.RFC3339)
+	return out
+}
+
+// HasExpiry reports whether metadata already carries an expiry.
+func HasExpiry(metadata map[string]any) bool {
+	_, ok := metadata[metadataExpiresAtKey]
+	return ok
+}
+
+// ExpiresAt returns the expiry carried by metadata, if any.
+func ExpiresAt(metadata map[string]any) (time.Time, bool) {
+	s, ok := metadata[metadataExpiresAtKey].(string)
+	if !ok {
+		return time.Time{}, false
+	}
+	at, err := time.Parse(time.RFC3339, s)
+	return at, err == nil
+}
+
+// HasChannels reports whether metadata already carries a channel restriction.
+func HasChannels(metadata map[string]any) bool {
+	_, ok := metadata[metadataChannelsKey]
+	return ok
+}
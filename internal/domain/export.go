@@ -0,0 +1,22 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// ExportFilter selects the notifications streamed by Repository.Export.
+type ExportFilter struct {
+	TenantKey string
+	UserID    string     // empty = every user of the tenant (admin export)
+	From      *time.Time // created at or after; nil = unbounded
+	To        *time.Time // created before; nil = unbounded
+}
+
+// Validate checks that the time range is not empty.
+func (f ExportFilter) Validate() error {
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return fmt.Errorf("from must be before to")
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package domain
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IDFormat selects how notification IDs are rendered in API responses and SSE frames.
+// Both formats encode the same 128 bits, so a UUIDv7 rendered as a ULID keeps its
+// millisecond timestamp and sort order. Parsing always accepts either format.
+type IDFormat string
+
+const (
+	IDFormatUUID IDFormat = "uuid" // 8-4-4-4-12 hex (default)
+	IDFormatULID IDFormat = "ulid" // 26-char Crockford base32, lexicographically time-sortable
+)
+
+var idFormat = IDFormatUUID
+
+// SetIDFormat sets the process-wide rendering of notification IDs. Call once at startup.
+func SetIDFormat(f IDFormat) error {
+	switch f {
+	case IDFormatUUID, IDFormatULID:
+		idFormat = f
+		return nil
+	default:
+		return fmt.Errorf("unknown id format: %q", f)
+	}
+}
+
+// FormatID renders id in the configured IDFormat.
+func FormatID(id uuid.UUID) string {
+	if idFormat == IDFormatULID {
+		return encodeULID(id)
+	}
+	return id.String()
+}
+
+// FormatIDs renders ids in the configured IDFormat.
+func FormatIDs(ids []uuid.UUID) []string {
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		out[i] = FormatID(id)
+	}
+	return out
+}
+
+// ParseID accepts a UUID (any form uuid.Parse accepts) or a 26-char ULID.
+func ParseID(s string) (uuid.UUID, error) {
+	if len(s) == ulidLen {
+		return decodeULID(s)
+	}
+	return uuid.Parse(s)
+}
+
+// IDGenerator produces time-sortable notification IDs in the application instead of
+// the database default (uuidv7()).
+type IDGenerator interface {
+	NewID() uuid.UUID
+}
+
+// UUIDv7Generator generates RFC 9562 version 7 UUIDs.
+type UUIDv7Generator struct{}
+
+func (UUIDv7Generator) NewID() uuid.UUID {
+	return uuid.Must(uuid.NewV7())
+}
+
+// ULIDGenerator generates ULIDs: 48-bit millisecond timestamp + 80 random bits,
+// monotonic within the same millisecond.
+type ULIDGenerator struct {
+	mu     sync.Mutex
+	lastMS uint64
+	last   uuid.UUID
+}
+
+func (g *ULIDGenerator) NewID() uuid.UUID {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := uint64(time.Now().UnixMilli())
+	if ms <= g.lastMS {
+		// Same (or earlier, clock skew) millisecond: increment the random part.
+		next := g.last
+		for i := 15; i >= 6; i-- {
+			next[i]++
+			if next[i] != 0 {
+				break
+			}
+		}
+		g.last = next
+		return next
+	}
+
+	var id uuid.UUID
+	binary.BigEndian.PutUint16(id[0:2], uint16(ms>>32))
+	binary.BigEndian.PutUint32(id[2:6], uint32(ms))
+	_, _ = rand.Read(id[6:])
+	g.lastMS, g.last = ms, id
+	return id
+}
+
+// NewIDGenerator returns the generator for name ("uuidv7" or "ulid"), or nil for
+// "db"/empty to keep the database default.
+func NewIDGenerator(name string) (IDGenerator, error) {
+	switch name {
+	case "", "db":
+		return nil, nil
+	case "uuidv7":
+		return UUIDv7Generator{}, nil
+	case "ulid":
+		return &ULIDGenerator{}, nil
+	default:
+		return nil, fmt.Errorf("unknown id generator: %q", name)
+	}
+}
+
+// MarshalJSON renders ID in the configured IDFormat, and the deep link, icon
+// and sender as top-level fields.
+func (n Notification) MarshalJSON() ([]byte, error) {
+	type plain Notification
+	return json.Marshal(struct {
+		plain
+		ID     string  `json:"id"`
+		Link   string  `json:"link,omitempty"`
+		Icon   string  `json:"icon,omitempty"`
+		Sender *Sender `json:"sender,omitempty"`
+	}{plain(n), FormatID(n.ID), n.Link(), n.Icon(), n.Sender()})
+}
+
+// MarshalJSON renders NotificationID in the configured IDFormat.
+func (r Reaction) MarshalJSON() ([]byte, error) {
+	type plain Reaction
+	return json.Marshal(struct {
+		plain
+		NotificationID string `json:"notification_id"`
+	}{plain(r), FormatID(r.NotificationID)})
+}
+
+// UnmarshalJSON accepts the notification ID as a UUID or a ULID.
+func (rs *ReadState) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		ID     string    `json:"id"`
+		ReadAt time.Time `json:"read_at"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	id, err := ParseID(raw.ID)
+	if err != nil {
+		return fmt.Errorf("invalid notification id %q: %w", raw.ID, err)
+	}
+	rs.ID, rs.ReadAt = id, raw.ReadAt
+	return nil
+}
+
+const (
+	ulidLen      = 26
+	crockford    = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	invalidDigit = 0xFF
+)
+
+var crockfordDecode = func() [256]byte {
+	var t [256]byte
+	for i := range t {
+		t[i] = invalidDigit
+	}
+	for i := 0; i < len(crockford); i++ {
+		t[crockford[i]] = byte(i)
+		t[strings.ToLower(crockford[i : i+1])[0]] = byte(i)
+	}
+	return t
+}()
+
+// encodeULID writes the 128 bits of id as 26 base32 digits (2 leading zero bits).
+func encodeULID(id uuid.UUID) string {
+	hi := binary.BigEndian.Uint64(id[0:8])
+	lo := binary.BigEndian.Uint64(id[8:16])
+	var out [ulidLen]byte
+	for i := ulidLen - 1; i >= 0; i-- {
+		out[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+var errULIDOverflow = errors.New("ulid overflows 128 bits")
+
+func decodeULID(s string) (uuid.UUID, error) {
+	var hi, lo uint64
+	for i := 0; i < ulidLen; i++ {
+		v := crockfordDecode[s[i]]
+		if v == invalidDigit {
+			return uuid.Nil, fmt.Errorf("invalid ulid character %q", s[i])
+		}
+		if i == 0 && v > 7 {
+			return uuid.Nil, errULIDOverflow
+		}
+		hi = hi<<5 | lo>>59
+		lo = lo<<5 | uint64(v)
+	}
+	var id uuid.UUID
+	binary.BigEndian.PutUint64(id[0:8], hi)
+	binary.BigEndian.PutUint64(id[8:16], lo)
+	return id, nil
+}
@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestULIDRoundTrip(t *testing.T) {
+	// Reference vector: the maximum ULID is the all-ones 128-bit value.
+	maxID := uuid.UUID{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	if got := encodeULID(maxID); got != "7ZZZZZZZZZZZZZZZZZZZZZZZZZ" {
+		t.Fatalf("encode max: got %s", got)
+	}
+
+	for range 100 {
+		id := uuid.Must(uuid.NewV7())
+		s := encodeULID(id)
+		back, err := ParseID(strings.ToLower(s))
+		if err != nil || back != id {
+			t.Fatalf("round trip %s -> %s -> %s (%v)", id, s, back, err)
+		}
+		if back, err := ParseID(id.String()); err != nil || back != id {
+			t.Fatalf("uuid parse %s: %v", id, err)
+		}
+	}
+
+	if _, err := ParseID("8ZZZZZZZZZZZZZZZZZZZZZZZZZ"); err == nil {
+		t.Fatal("expected overflow error")
+	}
+	if _, err := ParseID("01ARZ3NDEKTSV4RRFFQ69G5FAU"); err == nil {
+		t.Fatal("expected invalid character error")
+	}
+}
+
+func TestULIDGeneratorSortable(t *testing.T) {
+	g := &ULIDGenerator{}
+	prev := encodeULID(g.NewID())
+	for range 1000 {
+		next := encodeULID(g.NewID())
+		if next <= prev {
+			t.Fatalf("not monotonic: %s after %s", next, prev)
+		}
+		prev = next
+	}
+}
+
+func TestNotificationJSONIDFormat(t *testing.T) {
+	defer SetIDFormat(IDFormatUUID)
+	n := Notification{ID: uuid.Must(uuid.NewV7()), Title: "hi"}
+
+	if err := SetIDFormat(IDFormatULID); err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]any
+	_ = json.Unmarshal(b, &out)
+	if out["id"] != encodeULID(n.ID) || out["title"] != "hi" {
+		t.Fatalf("unexpected JSON: %s", b)
+	}
+}
+
+func TestNotificationJSONDisplayFields(t *testing.T) {
+	meta := WithSender(WithIcon(WithLink(nil, "/crm/deals/D-1"), "deal"), ServiceSender("crm"))
+	n := Notification{ID: uuid.Must(uuid.NewV7()), Metadata: meta}
+	b, err := json.Marshal(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]any
+	_ = json.Unmarshal(b, &out)
+	sender, _ := out["sender"].(map[string]any)
+	if out["link"] != "/crm/deals/D-1" || out["icon"] != "deal" || sender["type"] != "service" || sender["id"] != "crm" {
+		t.Fatalf("unexpected JSON: %s", b)
+	}
+
+	// Metadata read back from the database holds decoded JSON.
+	var stored Notification
+	if err := json.Unmarshal(b, &stored); err != nil {
+		t.Fatal(err)
+	}
+	if s := stored.Sender(); s == nil || *s != *ServiceSender("crm") {
+		t.Fatalf("Sender() = %v", s)
+	}
+
+	b, _ = json.Marshal(Notification{ID: n.ID})
+	if strings.Contains(string(b), `"link"`) {
+		t.Fatalf("display fields without metadata: %s", b)
+	}
+}
@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// JobLock is a held cluster-wide lock.
+type JobLock interface {
+	// Check returns an error once the lock may have been lost, e.g. because the
+	// database session holding it ended.
+	Check(ctx context.Context) error
+	// Release gives the lock up.
+	Release()
+}
+
+// JobRunRepository records the runs of scheduled maintenance jobs and keeps
+// replicas from running the same job, or leading, at once.
+type JobRunRepository interface {
+	// TryLock takes the cluster-wide lock called name without waiting. ok is
+	// false when another instance holds it; otherwise the lock must be released.
+	TryLock(ctx context.Context, name string) (lock JobLock, ok bool, err error)
+
+	// LastRun returns when the last completed run of job started, or the zero
+	// time when it never ran.
+	LastRun(ctx context.Context, job string) (time.Time, error)
+
+	// RecordRun records a completed run of job that started at startedAt.
+	RecordRun(ctx context.Context, job string, startedAt time.Time, took time.Duration) error
+}
@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaintenanceMode is what happens to SYSTEM notifications during a window.
+type MaintenanceMode string
+
+const (
+	// MaintenanceSuppress drops them.
+	MaintenanceSuppress MaintenanceMode = "suppress"
+	// MaintenanceBatch holds them and delivers one digest per audience when the
+	// window ends.
+	MaintenanceBatch MaintenanceMode = "batch"
+)
+
+// Valid reports whether m is a known mode.
+func (m MaintenanceMode) Valid() bool {
+	return m == MaintenanceSuppress || m == MaintenanceBatch
+}
+
+// MaintenanceWindow is a planned maintenance period of a tenant, or of every
+// tenant when TenantKey is empty, during which the tenant's SYSTEM
+// notifications are suppressed or batched.
+type MaintenanceWindow struct {
+	ID        uuid.UUID       `json:"id"`
+	TenantKey string          `json:"tenant_key"` // empty = every tenant
+	Reason    string          `json:"reason,omitempty"`
+	Mode      MaintenanceMode `json:"mode"`
+	StartsAt  time.Time       `json:"starts_at"`
+	EndsAt    time.Time       `json:"ends_at"`
+	Held      int             `json:"held"`                 // notifications waiting for the digest
+	FlushedAt *time.Time      `json:"flushed_at,omitempty"` // when the digest was delivered
+	CreatedBy string          `json:"created_by,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// MaintenanceRepository defines the persistence port for maintenance windows
+// and the notifications they hold.
+type MaintenanceRepository interface {
+	// List returns the windows of tenantKey and those for every tenant, or all
+	// windows when tenantKey is empty, latest start first.
+	List(ctx context.Context, tenantKey string) ([]MaintenanceWindow, error)
+
+	// Active returns the window covering tenantKey at now, its own or one for
+	// every tenant, preferring suppress over batch; nil when there is none.
+	Active(ctx context.Context, tenantKey string, now time.Time) (*MaintenanceWindow, error)
+
+	// Get returns a window; nil when there is none.
+	Get(ctx context.Context, id uuid.UUID) (*MaintenanceWindow, error)
+
+	Create(ctx context.Context, w MaintenanceWindow) (*MaintenanceWindow, error)
+
+	// Update replaces reason, mode and schedule of a window not yet flushed.
+	Update(ctx context.Context, w MaintenanceWindow) (*MaintenanceWindow, error)
+
+	// Delete removes a window and discards the notifications it holds.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// Hold stores a fan-out input for the digest of a batch window.
+	Hold(ctx context.Context, windowID uuid.UUID, input FanoutInput) error
+
+	// ListEnded returns the batch windows ended at now whose digest has not been
+	// delivered.
+	ListEnded(ctx context.Context, now time.Time) ([]MaintenanceWindow, error)
+
+	// Held returns the inputs held by a window, oldest first.
+	Held(ctx context.Context, windowID uuid.UUID) ([]FanoutInput, error)
+
+	// MarkFlushed records the digest of a window as delivered and drops its
+	// held inputs.
+	MarkFlushed(ctx context.Context, windowID uuid.UUID) error
+}
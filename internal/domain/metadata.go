@@ -0,0 +1,326 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Well-known metadata keys, with a fixed type read by the frontend and this
+// service. Other keys are free-form.
+const (
+	// MetadataLink is the deep link opened when the notification is clicked: an
+	// app path ("/crm/deals/D-1") or an http(s) URL.
+	MetadataLink = "link"
+	// MetadataIcon is the icon name or URL shown next to the notification.
+	MetadataIcon = "icon"
+	// MetadataSender is who or what triggered the notification (see Sender).
+	MetadataSender = "sender"
+	// MetadataEntityType and MetadataEntityID reference the business entity the
+	// notification is about (see EntityRef); both or neither are set.
+	MetadataEntityType = "entityType"
+	MetadataEntityID   = "entityId"
+	// MetadataActions holds the action buttons (see Action).
+	MetadataActions = "actions"
+	// MetadataTruncated lists the keys dropped because the metadata was too large.
+	MetadataTruncated = "truncated"
+)
+
+const (
+	maxLinkLength      = 2048
+	maxIconLength      = 512
+	maxEntityRefLength = 255
+)
+
+// ErrInvalidMetadata is returned when a well-known metadata key has the wrong
+// type or value.
+var ErrInvalidMetadata = errors.New("invalid metadata")
+
+// systemMetadataKeys are written or read by this service itself; they are
+// never dropped to make metadata fit its size limit.
+var systemMetadataKeys = map[string]bool{
+	MetadataLink:         true,
+	MetadataIcon:         true,
+	MetadataSender:       true,
+	MetadataEntityType:   true,
+	MetadataEntityID:     true,
+	MetadataActions:      true,
+	MetadataTruncated:    true,
+	MetadataTemplate:     true,
+	metadataChannelsKey:  true,
+	metadataExpiresAtKey: true,
+	"reaction":           true,
+	"senderId":           true,
+	"escalation":         true,
+	"maintenance":        true,
+	"compacted":          true,
+	"compacted_at":       true,
+	"traceparent":        true,
+}
+
+// IsSystemMetadataKey reports whether key is well-known or written by this
+// service, as opposed to a free-form key of the producer.
+func IsSystemMetadataKey(key string) bool {
+	return systemMetadataKeys[key]
+}
+
+// EntityRef references the business entity a notification is about.
+type EntityRef struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// EntityOf returns the entity referenced by metadata, if any.
+func EntityOf(metadata map[string]any) (EntityRef, bool) {
+	t, _ := metadata[MetadataEntityType].(string)
+	id, _ := metadata[MetadataEntityID].(string)
+	return EntityRef{Type: t, ID: id}, t != "" && id != ""
+}
+
+// WithEntity returns a copy of metadata referencing ref.
+func WithEntity(metadata map[string]any, ref EntityRef) map[string]any {
+	out := make(map[string]any, len(metadata)+2)
+	for k, v := range metadata {
+		out[k] = v
+	}
+	out[MetadataEntityType], out[MetadataEntityID] = ref.Type, ref.ID
+	return out
+}
+
+// LinkOf returns the deep link of metadata; empty when there is none.
+func LinkOf(metadata map[string]any) string {
+	link, _ := metadata[MetadataLink].(string)
+	return link
+}
+
+// WithLink returns a copy of metadata with the deep link set, or metadata
+// itself when link is empty.
+func WithLink(metadata map[string]any, link string) map[string]any {
+	if link == "" {
+		return metadata
+	}
+	out := make(map[string]any, len(metadata)+1)
+	for k, v := range metadata {
+		out[k] = v
+	}
+	out[MetadataLink] = link
+	return out
+}
+
+// IconOf returns the icon of metadata; empty when there is none.
+func IconOf(metadata map[string]any) string {
+	icon, _ := metadata[MetadataIcon].(string)
+	return icon
+}
+
+// WithIcon returns a copy of metadata with the icon set, or metadata itself
+// when icon is empty.
+func WithIcon(metadata map[string]any, icon string) map[string]any {
+	if icon == "" {
+		return metadata
+	}
+	out := make(map[string]any, len(metadata)+1)
+	for k, v := range metadata {
+		out[k] = v
+	}
+	out[MetadataIcon] = icon
+	return out
+}
+
+// SenderType tells whether a notification was triggered by a service or a user.
+type SenderType string
+
+const (
+	SenderService SenderType = "service"
+	SenderUser    SenderType = "user"
+)
+
+// Sender identifies who or what triggered a notification: a service ("bpm",
+// "crm", ...) or a user (Keycloak user ID).
+type Sender struct {
+	Type SenderType `json:"type"`
+	ID   string     `json:"id"`
+	Name string     `json:"name,omitempty"`
+}
+
+// ServiceSender returns the Sender for the service named id.
+func ServiceSender(id string) *Sender {
+	return &Sender{Type: SenderService, ID: id}
+}
+
+// UserSender returns the Sender for the user id, or nil when id is empty.
+func UserSender(id string) *Sender {
+	if id == "" {
+		return nil
+	}
+	return &Sender{Type: SenderUser, ID: id}
+}
+
+// SenderOf returns the sender stored in metadata; nil when there is none.
+func SenderOf(metadata map[string]any) *Sender {
+	raw, ok := metadata[MetadataSender]
+	if !ok {
+		return nil
+	}
+	if sender, ok := raw.(*Sender); ok {
+		return sender
+	}
+	// Metadata read back from the database holds the decoded JSON object.
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var sender Sender
+	if err := json.Unmarshal(b, &sender); err != nil || sender.ID == "" {
+		return nil
+	}
+	return &sender
+}
+
+// WithSender returns a copy of metadata carrying sender, or metadata itself when
+// sender is nil.
+func WithSender(metadata map[string]any, sender *Sender) map[string]any {
+	if sender == nil {
+		return metadata
+	}
+	out := make(map[string]any, len(metadata)+1)
+	for k, v := range metadata {
+		out[k] = v
+	}
+	out[MetadataSender] = sender
+	return out
+}
+
+// MetadataErrors checks the well-known keys of metadata and returns the
+// problems by key; nil when there are none.
+func MetadataErrors(metadata map[string]any) map[string]error {
+	var errs map[string]error
+	fail := func(key, format string, args ...any) {
+		if errs == nil {
+			errs = make(map[string]error)
+		}
+		errs[key] = fmt.Errorf("%w: %s: "+format, append([]any{ErrInvalidMetadata, key}, args...)...)
+	}
+	for key, v := range metadata {
+		switch key {
+		case MetadataLink:
+			if s, ok := v.(string); !ok || !validLink(s) {
+				fail(key, "want an app path or an http(s) URL of at most %d characters", maxLinkLength)
+			}
+		case MetadataIcon:
+			if s, ok := v.(string); !ok || s == "" || len(s) > maxIconLength {
+				fail(key, "want a string of 1 to %d characters", maxIconLength)
+			}
+		case MetadataSender:
+			if err := checkSender(v); err != nil {
+				fail(key, "%v", err)
+			}
+		case MetadataEntityType, MetadataEntityID:
+			if s, ok := v.(string); !ok || s == "" || len(s) > maxEntityRefLength {
+				fail(key, "want a string of 1 to %d characters", maxEntityRefLength)
+			}
+		case MetadataActions:
+			if err := checkActions(v); err != nil {
+				fail(key, "%v", err)
+			}
+		case metadataExpiresAtKey:
+			if s, ok := v.(string); !ok {
+				fail(key, "want an RFC 3339 time")
+			} else if _, err := time.Parse(time.RFC3339, s); err != nil {
+				fail(key, "want an RFC 3339 time")
+			}
+		}
+	}
+	_, hasType := metadata[MetadataEntityType]
+	_, hasID := metadata[MetadataEntityID]
+	if hasType != hasID {
+		if hasType {
+			fail(MetadataEntityType, "%s is required too", MetadataEntityID)
+		} else {
+			fail(MetadataEntityID, "%s is required too", MetadataEntityType)
+		}
+	}
+	return errs
+}
+
+// ValidateMetadata checks the well-known keys of metadata.
+func ValidateMetadata(metadata map[string]any) error {
+	errs := MetadataErrors(metadata)
+	keys := make([]string, 0, len(errs))
+	for k := range errs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	joined := make([]error, len(keys))
+	for i, k := range keys {
+		joined[i] = errs[k]
+	}
+	return errors.Join(joined...)
+}
+
+// MetadataSize returns the size of metadata encoded as JSON, as stored and sent.
+func MetadataSize(metadata map[string]any) (int, error) {
+	if len(metadata) == 0 {
+		return 0, nil
+	}
+	b, err := json.Marshal(metadata)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidMetadata, err)
+	}
+	return len(b), nil
+}
+
+func validLink(s string) bool {
+	if s == "" || len(s) > maxLinkLength {
+		return false
+	}
+	if strings.HasPrefix(s, "/") {
+		return !strings.HasPrefix(s, "//")
+	}
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "https" || u.Scheme == "http") && u.Host != ""
+}
+
+func checkSender(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var sender Sender
+	if err := json.Unmarshal(b, &sender); err != nil {
+		return errors.New("want an object with a type and an id")
+	}
+	if sender.Type != SenderService && sender.Type != SenderUser {
+		return fmt.Errorf("type %q: want %s or %s", sender.Type, SenderService, SenderUser)
+	}
+	if sender.ID == "" || len(sender.ID) > maxEntityRefLength {
+		return fmt.Errorf("want an id of 1 to %d characters", maxEntityRefLength)
+	}
+	return nil
+}
+
+func checkActions(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var actions []Action
+	if err := json.Unmarshal(b, &actions); err != nil {
+		return errors.New("want a list of actions")
+	}
+	seen := make(map[string]bool, len(actions))
+	for _, a := range actions {
+		if a.Action == "" || a.Label == "" {
+			return errors.New("every action needs an action and a label")
+		}
+		if seen[a.Action] {
+			return fmt.Errorf("duplicate action %q", a.Action)
+		}
+		seen[a.Action] = true
+	}
+	return nil
+}
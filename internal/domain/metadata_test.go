@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMetadataErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		metadata map[string]any
+		invalid  []string
+	}{
+		{name: "valid", metadata: map[string]any{
+			"link": "/crm/deals/D-1", "icon": "deal", "entityType": "deal", "entityId": "D-1",
+			"actions":    []Action{{Label: "Mở", Action: "open", URL: "/crm/deals/D-1"}},
+			"expires_at": "2026-10-15T00:00:00Z", "anything": map[string]any{"nested": 1},
+		}},
+		{name: "absolute link", metadata: map[string]any{"link": "https://app.arda.vn/tasks/1"}},
+		{name: "script link", metadata: map[string]any{"link": "javascript:alert(1)"}, invalid: []string{"link"}},
+		{name: "protocol-relative link", metadata: map[string]any{"link": "//evil.example"}, invalid: []string{"link"}},
+		{name: "icon type", metadata: map[string]any{"icon": 3.0}, invalid: []string{"icon"}},
+		{name: "sender", metadata: map[string]any{"sender": UserSender("u1")}},
+		{name: "stored sender", metadata: map[string]any{"sender": map[string]any{"type": "service", "id": "bpm"}}},
+		{name: "sender type", metadata: map[string]any{"sender": map[string]any{"type": "bot", "id": "x"}}, invalid: []string{"sender"}},
+		{name: "entity half", metadata: map[string]any{"entityType": "deal"}, invalid: []string{"entityType"}},
+		{name: "actions", metadata: map[string]any{"actions": []any{map[string]any{"label": "OK"}}}, invalid: []string{"actions"}},
+		{name: "expiry", metadata: map[string]any{"expires_at": "tomorrow"}, invalid: []string{"expires_at"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			errs := MetadataErrors(c.metadata)
+			if len(errs) != len(c.invalid) {
+				t.Fatalf("MetadataErrors = %v, want problems with %v", errs, c.invalid)
+			}
+			for _, key := range c.invalid {
+				if !errors.Is(errs[key], ErrInvalidMetadata) {
+					t.Errorf("%s: %v", key, errs[key])
+				}
+			}
+		})
+	}
+}
@@ -18,6 +18,26 @@ const (
 	TypeCustom   NotificationType = "CUSTOM"
 )
 
+// Priority expresses how important a notification is to its recipient.
+type Priority string
+
+const (
+	PriorityLow    Priority = "LOW"
+	PriorityNormal Priority = "NORMAL"
+	PriorityHigh   Priority = "HIGH"
+	PriorityUrgent Priority = "URGENT"
+)
+
+// OrDefault returns p, or PriorityNormal when p is empty or unknown.
+func (p Priority) OrDefault() Priority {
+	switch p {
+	case PriorityLow, PriorityNormal, PriorityHigh, PriorityUrgent:
+		return p
+	default:
+		return PriorityNormal
+	}
+}
+
 // TargetScope defines who should receive the notification (before fan-out).
 type TargetScope string
 
@@ -30,6 +50,9 @@ const (
 	ScopePlatform TargetScope = "PLATFORM"
 	// ScopeRole fans-out to all users holding a given role within a tenant.
 	ScopeRole TargetScope = "ROLE"
+	// ScopeGroup fans-out to all members of a Keycloak group within a tenant.
+	// TargetID is the group ID, or its path when it starts with "/" (e.g. "/Finance").
+	ScopeGroup TargetScope = "GROUP"
 )
 
 // Notification is the core domain entity.
@@ -38,6 +61,8 @@ type Notification struct {
 	TenantKey     string           `json:"tenant_key"`
 	UserID        string           `json:"user_id"`
 	Type          NotificationType `json:"type"`
+	Category      string           `json:"category,omitempty"`
+	Priority      Priority         `json:"priority"`
 	Title         string           `json:"title"`
 	Body          string           `json:"body"`
 	Metadata      map[string]any   `json:"metadata,omitempty"`
@@ -45,6 +70,10 @@ type Notification struct {
 	ReadAt        *time.Time       `json:"read_at,omitempty"`
 	CreatedAt     time.Time        `json:"created_at"`
 	SourceEventID string           `json:"source_event_id,omitempty"`
+	// SnoozedUntil is only set by the snoozed list.
+	SnoozedUntil *time.Time `json:"snoozed_until,omitempty"`
+	// Pinned is only reported by List.
+	Pinned bool `json:"pinned,omitempty"`
 }
 
 // NotificationFilter holds query parameters for listing notifications.
@@ -52,7 +81,15 @@ type NotificationFilter struct {
 	TenantKey string
 	UserID    string
 	IsRead    *bool
+	Pinned    *bool
 	Type      NotificationType
+	Category  string     // exact, or a prefix when it ends in ".*" ("crm.*")
+	// EntityType / EntityID select the notifications about one business entity
+	// (metadata "entityType" / "entityId"); both must be set.
+	EntityType string
+	EntityID   string
+	AsOf      *time.Time // reconstruct the inbox as it was at this instant (audit)
+	Locale    string     // renders templated notifications; empty = default locale
 	Limit     int
 	Offset    int
 }
@@ -63,40 +100,137 @@ type CreateNotificationInput struct {
 	TenantKey     string
 	UserID        string
 	Type          NotificationType
+	Category      string
+	Priority      Priority
+	Title         string
+	Body          string
+	Link          string  // deep link, stored as metadata "link"; see Notification.Link
+	Icon          string  // stored as metadata "icon"; see Notification.Icon
+	Sender        *Sender // who or what triggered it, stored as metadata "sender"
+	Metadata      map[string]any
+	SourceEventID string
+}
+
+// BatchResult is the outcome of Repository.BatchCreate.
+type BatchResult struct {
+	// Inserted are the stored notifications.
+	Inserted []*Notification
+	// Duplicates are the indexes of the inputs skipped because their SourceEventID
+	// was already stored for the same tenant and user (idempotent re-delivery).
+	Duplicates []int
+}
+
+// FanoutStrategy selects how a scope's notifications are materialised.
+type FanoutStrategy string
+
+const (
+	// FanoutOnWrite inserts one notification row per recipient (default).
+	FanoutOnWrite FanoutStrategy = "write"
+	// FanoutOnRead stores one broadcast row, joined with per-user read state at query time.
+	FanoutOnRead FanoutStrategy = "read"
+)
+
+// BroadcastInput is a fan-out-on-read notification stored once for a whole scope.
+// Used by Repository.CreateBroadcast.
+type BroadcastInput struct {
+	// TenantKey limits the broadcast to one tenant; empty reaches every tenant (PLATFORM).
+	TenantKey     string
+	Type          NotificationType
+	Category      string
+	Priority      Priority
 	Title         string
 	Body          string
 	Metadata      map[string]any
 	SourceEventID string
 }
 
+// FanoutTarget is one scope/ID pair of a composite fan-out.
+type FanoutTarget struct {
+	Scope TargetScope `json:"scope"`
+	// ID has the same meaning as FanoutInput.TargetID for Scope.
+	ID string `json:"id,omitempty"`
+}
+
 // FanoutInput is the pre-fan-out DTO produced by Kafka handlers.
 // The application Service resolves TargetScope → concrete user IDs,
 // then batch-inserts CreateNotificationInput rows.
 type FanoutInput struct {
 	// TargetScope determines the resolution strategy.
 	TargetScope TargetScope
-	// TargetID is the userID (USER), tenantKey (TENANT), or roleName (ROLE).
+	// TargetID is the userID (USER), tenantKey (TENANT), roleName (ROLE), or group ID/path (GROUP).
 	// Empty for PLATFORM scope.
 	TargetID      string
 	TenantKey     string
 	Type          NotificationType
+	Category      string // refines Type, e.g. "crm.deal"; see ValidCategory
+	Priority      Priority
 	Title         string
 	Body          string
+	Link          string  // deep link, stored as metadata "link"; see Notification.Link
+	Icon          string  // stored as metadata "icon"; see Notification.Icon
+	Sender        *Sender // who or what triggered it, stored as metadata "sender"
 	Metadata      map[string]any
+	Template      *TemplateRef // template Title/Body were built from; stored in Metadata
+	// Locale is the locale Template is rendered in at fan-out; empty uses the
+	// template's default locale.
+	Locale string
 	SourceEventID string
 	// OriginUserID is the ID of the user who performed the action.
 	// We use this to ensure the performer also receives the notification.
 	OriginUserID string
+	// Rollout optionally stages a PLATFORM broadcast across tenants.
+	// Nil delivers to all tenants at once.
+	Rollout *RolloutPlan
+	// Targets are additional targets unioned with TargetScope/TargetID.
+	// TargetScope may be empty when Targets is set.
+	Targets []FanoutTarget
+	// Exclude lists targets whose users are removed after resolution,
+	// e.g. {USER, originator} to reach everyone in a tenant but the performer.
+	Exclude []FanoutTarget
+}
+
+// AllTargets returns the primary target followed by the additional Targets.
+func (in FanoutInput) AllTargets() []FanoutTarget {
+	targets := make([]FanoutTarget, 0, len(in.Targets)+1)
+	if in.TargetScope != "" {
+		targets = append(targets, FanoutTarget{Scope: in.TargetScope, ID: in.TargetID})
+	}
+	return append(targets, in.Targets...)
+}
+
+// IsComposite reports whether the input has more than one target or any exclusion.
+func (in FanoutInput) IsComposite() bool {
+	return len(in.Targets) > 0 || len(in.Exclude) > 0
 }
 
 // Action represents an actionable button attached to a notification.
 // Actions are stored in the notification's metadata under the "actions" key.
 type Action struct {
-	Label   string `json:"label"`            // Display text, e.g. "Approve"
-	Action  string `json:"action"`           // Action identifier, e.g. "approve"
-	URL     string `json:"url"`              // Target URL, e.g. "/api/bpm/v1/tasks/123/approve"
-	Method  string `json:"method"`           // HTTP method: GET, POST, PATCH, DELETE
-	Variant string `json:"variant,omitempty"` // UI style: "primary", "destructive", "outline"
+	Label   string         `json:"label"`             // Display text, e.g. "Approve"
+	Action  string         `json:"action"`            // Action identifier, e.g. "approve"
+	Type    ActionType     `json:"type,omitempty"`    // How the action is carried out; see Kind
+	URL     string         `json:"url,omitempty"`     // Target URL, e.g. "/api/bpm/v1/tasks/123/approve"
+	Method  string         `json:"method,omitempty"`  // HTTP method: GET, POST, PATCH, DELETE
+	Command string         `json:"command,omitempty"` // Kafka event type emitted for ActionCommand, e.g. "TASK_APPROVE"
+	Payload map[string]any `json:"payload,omitempty"` // Sent along with Command
+	Variant string         `json:"variant,omitempty"` // UI style: "primary", "destructive", "outline"
+}
+
+// Link returns where the UI navigates when the notification is clicked (metadata
+// "link"); empty when it has no deep link.
+func (n *Notification) Link() string {
+	return LinkOf(n.Metadata)
+}
+
+// Icon returns the icon shown next to the notification (metadata "icon").
+func (n *Notification) Icon() string {
+	return IconOf(n.Metadata)
+}
+
+// Sender returns who or what triggered the notification (metadata "sender");
+// nil when unknown.
+func (n *Notification) Sender() *Sender {
+	return SenderOf(n.Metadata)
 }
 
 // Actions extracts action buttons from the notification's metadata.
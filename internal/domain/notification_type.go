@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrUnknownType is returned when a notification uses a type that is neither
+// built in nor registered by its tenant.
+var ErrUnknownType = errors.New("unknown notification type")
+
+// MaxTypeLen matches the type column width.
+const MaxTypeLen = 50
+
+// BuiltinTypes are the notification types available to every tenant.
+var BuiltinTypes = []NotificationType{TypeSystem, TypeWorkflow, TypeCRM, TypeIAM, TypeCustom}
+
+// IsBuiltin reports whether t is one of BuiltinTypes.
+func (t NotificationType) IsBuiltin() bool {
+	switch t {
+	case TypeSystem, TypeWorkflow, TypeCRM, TypeIAM, TypeCustom:
+		return true
+	}
+	return false
+}
+
+// Valid reports whether t is well-formed: an uppercase letter followed by
+// [A-Z0-9_], e.g. "INVOICE_DUE".
+func (t NotificationType) Valid() bool {
+	if t == "" || len(t) > MaxTypeLen || t[0] < 'A' || t[0] > 'Z' {
+		return false
+	}
+	for _, r := range t {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// CustomType is a tenant-registered NotificationType with display metadata.
+type CustomType struct {
+	TenantKey       string           `json:"tenant_key"`
+	Type            NotificationType `json:"type"`
+	Name            string           `json:"name"`
+	Icon            string           `json:"icon,omitempty"`
+	DefaultPriority Priority         `json:"default_priority"`
+	// RetentionDays overrides the global TTL for notifications of this type; 0 keeps it.
+	RetentionDays int       `json:"retention_days"`
+	Builtin       bool      `json:"builtin,omitempty"`
+	CreatedAt     time.Time `json:"created_at,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at,omitempty"`
+}
+
+// CustomTypeRepository defines the port for tenant-registered notification types.
+type CustomTypeRepository interface {
+	// Get returns a tenant's type, or nil when it is not registered.
+	Get(ctx context.Context, tenantKey string, t NotificationType) (*CustomType, error)
+	// List returns a tenant's types ordered by type.
+	List(ctx context.Context, tenantKey string) ([]CustomType, error)
+	Upsert(ctx context.Context, ct CustomType) (*CustomType, error)
+	Delete(ctx context.Context, tenantKey string, t NotificationType) error
+}
@@ -0,0 +1,20 @@
+package domain
+
+import "testing"
+
+func TestNotificationTypeValid(t *testing.T) {
+	for typ, want := range map[NotificationType]bool{
+		"CRM":         true,
+		"INVOICE_DUE": true,
+		"V2_ALERT":    true,
+		"":            false,
+		"invoice":     false,
+		"2FA":         false,
+		"_INTERNAL":   false,
+		"INVOICE-DUE": false,
+	} {
+		if got := typ.Valid(); got != want {
+			t.Errorf("NotificationType(%q).Valid() = %v, want %v", typ, got, want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Channel is a delivery channel a notification can be pushed through.
+type Channel string
+
+const (
+	// ChannelInApp is the real-time SSE push. The notification row is always stored.
+	ChannelInApp Channel = "in_app"
+	// ChannelEmail is email delivery (still subject to the user's email preference).
+	ChannelEmail Channel = "email"
+	// ChannelChat is forwarding to the tenant's Slack / Teams connectors.
+	ChannelChat Channel = "chat"
+)
+
+// Valid reports whether c is a known channel.
+func (c Channel) Valid() bool {
+	return c == ChannelInApp || c == ChannelEmail || c == ChannelChat
+}
+
+// metadataChannelsKey holds a policy-restricted channel set in notification metadata.
+const metadataChannelsKey = "channels"
+
+// DeliveryPolicy is a tenant-supplied Rego module evaluated at fan-out time.
+// The module must declare `package arda.delivery` and may define:
+//
+//	allow    boolean (default true)  — false drops the notification for the tenant
+//	channels set/array of strings    — restricts delivery to these channels
+//	reason   string                  — recorded in the decision log
+type DeliveryPolicy struct {
+	TenantKey string    `json:"tenant_key"`
+	Module    string    `json:"module"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PolicyInput is the document exposed to a policy as `input`.
+type PolicyInput struct {
+	TenantKey     string           `json:"tenant_key"`
+	Type          NotificationType `json:"type"`
+	Category      string           `json:"category"`
+	Priority      Priority         `json:"priority"`
+	Title         string           `json:"title"`
+	Body          string           `json:"body"`
+	Metadata      map[string]any   `json:"metadata"`
+	SourceEventID string           `json:"source_event_id"`
+	TargetScope   TargetScope      `json:"target_scope"`
+	TargetID      string           `json:"target_id"`
+	Recipients    int              `json:"recipients"`
+}
+
+// PolicyDecision is the outcome of evaluating a DeliveryPolicy.
+type PolicyDecision struct {
+	Allow bool `json:"allow"`
+	// Channels restricts delivery; nil means all channels.
+	Channels []Channel `json:"channels,omitempty"`
+	Reason   string    `json:"reason,omitempty"`
+}
+
+// PolicyEvaluator compiles and evaluates delivery policies.
+type PolicyEvaluator interface {
+	// Validate compiles a module without evaluating it.
+	Validate(module string) error
+
+	// Evaluate runs the tenant's policy against input.
+	Evaluate(ctx context.Context, p DeliveryPolicy, input PolicyInput) (PolicyDecision, error)
+}
+
+// PolicyRepository defines the port for delivery policy persistence.
+type PolicyRepository interface {
+	// Get returns the policy for a tenant. Returns nil (not error) when none exists.
+	Get(ctx context.Context, tenantKey string) (*DeliveryPolicy, error)
+
+	// List returns all stored policies.
+	List(ctx context.Context) ([]DeliveryPolicy, error)
+
+	// Upsert inserts or replaces a tenant's policy.
+	Upsert(ctx context.Context, p DeliveryPolicy) (*DeliveryPolicy, error)
+
+	// Delete removes a tenant's policy.
+	Delete(ctx context.Context, tenantKey string) error
+}
+
+// AllowsChannel reports whether a policy decision recorded in metadata permits channel c.
+// Notifications without a recorded channel set allow every channel.
+func (n *Notification) AllowsChannel(c Channel) bool {
+	if n.Metadata == nil {
+		return true
+	}
+	raw, ok := n.Metadata[metadataChannelsKey].([]any)
+	if !ok {
+		return true
+	}
+	for _, v := range raw {
+		if s, _ := v.(string); Channel(s) == c {
+			return true
+		}
+	}
+	return false
+}
+
+// WithChannels returns a copy of metadata carrying the restricted channel set.
+func WithChannels(metadata map[string]any, channels []Channel) map[string]any {
+	out := make(map[string]any, len(metadata)+1)
+	for k, v := range metadata {
+		out[k] = v
+	}
+	list := make([]any, len(channels))
+	for i, c := range channels {
+		list[i] = string(c)
+	}
+	out[metadataChannelsKey] = list
+	return out
+}
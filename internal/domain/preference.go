@@ -6,12 +6,14 @@ import (
 	"time"
 )
 
-// Preference stores a user's channel settings for one notification type.
+// Preference stores a user's channel settings for one notification type, or for
+// one category within it. A category preference overrides the type-level one.
 type Preference struct {
 	ID              string    `json:"id"`
 	TenantKey       string    `json:"tenant_key"`
 	UserID          string    `json:"user_id"`
 	Type            NotificationType `json:"type"`
+	Category        string    `json:"category,omitempty"` // "" = the whole type
 	ChannelInApp    bool      `json:"channel_in_app"`
 	ChannelEmail    bool      `json:"channel_email"`
 	QuietHoursStart *string   `json:"quiet_hours_start,omitempty"` // "22:00"
@@ -23,6 +25,7 @@ type Preference struct {
 // PreferenceInput is the DTO for upsert operations.
 type PreferenceInput struct {
 	Type            NotificationType `json:"type"`
+	Category        string           `json:"category,omitempty"`
 	ChannelInApp    *bool            `json:"channel_in_app,omitempty"`
 	ChannelEmail    *bool            `json:"channel_email,omitempty"`
 	QuietHoursStart *string          `json:"quiet_hours_start,omitempty"`
@@ -65,9 +68,10 @@ type PreferenceRepository interface {
 	// GetByUser returns all preferences for a user within a tenant.
 	GetByUser(ctx context.Context, tenantKey, userID string) ([]Preference, error)
 
-	// GetByUserAndType returns the preference for a specific notification type.
-	// Returns nil (not error) when no row exists.
-	GetByUserAndType(ctx context.Context, tenantKey, userID string, notifType NotificationType) (*Preference, error)
+	// GetByUserAndCategory returns the preference that applies to a notification of
+	// notifType/category: the category preference if one exists, else the type-level one.
+	// Returns nil (not error) when neither exists.
+	GetByUserAndCategory(ctx context.Context, tenantKey, userID string, notifType NotificationType, category string) (*Preference, error)
 
 	// Upsert inserts or updates a preference. Returns the saved entity.
 	Upsert(ctx context.Context, p Preference) (*Preference, error)
@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// QuotaOverage is what happens to notifications over a tenant's monthly quota.
+type QuotaOverage string
+
+const (
+	// QuotaReject rejects them with an error to the producer.
+	QuotaReject QuotaOverage = "reject"
+	// QuotaQueue holds fan-outs until the quota allows them: next month or once
+	// the quota is raised. Single notifications are rejected.
+	QuotaQueue QuotaOverage = "queue"
+)
+
+// Valid reports whether o is a known overage policy.
+func (o QuotaOverage) Valid() bool {
+	return o == QuotaReject || o == QuotaQueue
+}
+
+// TenantQuota caps the notifications created for a tenant's users per calendar
+// month (UTC).
+type TenantQuota struct {
+	TenantKey    string       `json:"tenant_key"`
+	MonthlyLimit int64        `json:"monthly_limit"` // 0 = unlimited
+	Overage      QuotaOverage `json:"overage"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+}
+
+// TenantUsage counts a tenant's notifications in one month.
+type TenantUsage struct {
+	TenantKey string    `json:"tenant_key"`
+	Month     time.Time `json:"month"`    // first day of the month, UTC
+	Created   int64     `json:"created"`  // stored for a recipient
+	Rejected  int64     `json:"rejected"` // refused over the quota
+	Queued    int64     `json:"queued"`   // held over the quota
+}
+
+// HeldFanout is a fan-out held while its tenant is over its quota.
+type HeldFanout struct {
+	ID         int64
+	Input      FanoutInput
+	Recipients int // at the time it was held
+}
+
+// QuotaRepository defines the persistence port for tenant quotas, usage and
+// held fan-outs.
+type QuotaRepository interface {
+	// ListQuotas returns the quota of every tenant that has one.
+	ListQuotas(ctx context.Context) ([]TenantQuota, error)
+
+	// GetQuota returns the quota of tenantKey; nil when it has none.
+	GetQuota(ctx context.Context, tenantKey string) (*TenantQuota, error)
+
+	// UpsertQuota inserts or replaces the quota of q.TenantKey.
+	UpsertQuota(ctx context.Context, q TenantQuota) (*TenantQuota, error)
+
+	// DeleteQuota removes the quota of tenantKey.
+	DeleteQuota(ctx context.Context, tenantKey string) error
+
+	// AddUsage adds the counts of u to the usage of u.TenantKey in u.Month.
+	AddUsage(ctx context.Context, u TenantUsage) error
+
+	// Usage returns the usage of tenantKey in the months from from to to (both
+	// included), latest first; months without notifications are omitted.
+	Usage(ctx context.Context, tenantKey string, from, to time.Time) ([]TenantUsage, error)
+
+	// Hold stores a fan-out of tenantKey to recipients users.
+	Hold(ctx context.Context, tenantKey string, input FanoutInput, recipients int) error
+
+	// HeldTenants returns the tenants with held fan-outs.
+	HeldTenants(ctx context.Context) ([]string, error)
+
+	// Held returns up to limit held fan-outs of tenantKey, oldest first.
+	Held(ctx context.Context, tenantKey string, limit int) ([]HeldFanout, error)
+
+	// CountHeld returns the number of held fan-outs of tenantKey.
+	CountHeld(ctx context.Context, tenantKey string) (int64, error)
+
+	// Release drops a held fan-out once delivered.
+	Release(ctx context.Context, id int64) error
+}
@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReactionResponse is the structured answer a user gives to a notification.
+type ReactionResponse string
+
+const (
+	// ReactionAcknowledge confirms the user has seen and accepted the notification.
+	ReactionAcknowledge ReactionResponse = "ACK"
+	// ReactionReject dismisses the notification, optionally with a reason.
+	ReactionReject ReactionResponse = "REJECT"
+)
+
+// Reaction is a user's recorded response to a notification.
+type Reaction struct {
+	ID             uuid.UUID        `json:"id"`
+	NotificationID uuid.UUID        `json:"notification_id"`
+	TenantKey      string           `json:"tenant_key"`
+	UserID         string           `json:"user_id"`
+	Response       ReactionResponse `json:"response"`
+	Comment        string           `json:"comment,omitempty"`
+	SourceEventID  string           `json:"source_event_id,omitempty"`
+	CreatedAt      time.Time        `json:"created_at"`
+}
+
+// ReactionPolicy describes the response a producer expects for a notification.
+// Stored in the notification's metadata under the "reaction" key.
+type ReactionPolicy struct {
+	Required               bool `json:"required"`
+	RequireCommentOnReject bool `json:"require_comment_on_reject,omitempty"`
+}
+
+// ReactionPolicy extracts the reaction policy from the notification's metadata.
+// Returns nil if the notification does not request a reaction.
+func (n *Notification) ReactionPolicy() *ReactionPolicy {
+	if n.Metadata == nil {
+		return nil
+	}
+	raw, ok := n.Metadata["reaction"]
+	if !ok {
+		return nil
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var p ReactionPolicy
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil
+	}
+	return &p
+}
+
+// ReactionRepository defines the port for reaction persistence.
+type ReactionRepository interface {
+	// Create stores a reaction. Returns nil (not error) when the user has already reacted.
+	Create(ctx context.Context, r Reaction) (*Reaction, error)
+
+	// ListByNotification returns all reactions recorded for a notification.
+	ListByNotification(ctx context.Context, notificationID uuid.UUID) ([]Reaction, error)
+
+	// ListBySourceEvent returns all reactions to notifications created from the given source event.
+	ListBySourceEvent(ctx context.Context, tenantKey, sourceEventID string) ([]Reaction, error)
+}
+
+// ReactionPublisher emits reactions to external systems (e.g. Kafka)
+// so the originating service can act on them.
+type ReactionPublisher interface {
+	PublishReaction(ctx context.Context, r Reaction) error
+}
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -10,11 +11,31 @@ import (
 // Implementations live in infrastructure/postgres.
 type Repository interface {
 	// Create stores a new notification and returns the saved entity.
+	// A delivery outbox entry is written atomically with the notification.
+	// Returns nil (not error) when SourceEventID was already stored for the same
+	// tenant and user.
 	Create(ctx context.Context, input CreateNotificationInput) (*Notification, error)
 
 	// BatchCreate inserts multiple notifications in a single operation (used by fan-out).
-	// Returns the successfully inserted notifications.
-	BatchCreate(ctx context.Context, inputs []CreateNotificationInput) ([]*Notification, error)
+	// Returns the successfully inserted notifications and the indexes of the inputs
+	// skipped because their SourceEventID was already stored for their tenant and
+	// user. Like Create, it writes one delivery outbox entry per inserted
+	// notification in the same statement.
+	BatchCreate(ctx context.Context, inputs []CreateNotificationInput) (BatchResult, error)
+
+	// CreateBroadcast stores a fan-out-on-read notification once for its scope.
+	// Returns nil (not error) when SourceEventID was already stored for the tenant
+	// (or, with an empty TenantKey, for the platform).
+	// Broadcasts appear in List/CountUnread and accept MarkRead/MarkAllRead/Delete
+	// per user; GetByID only returns per-user notifications.
+	CreateBroadcast(ctx context.Context, input BroadcastInput) (*Notification, error)
+
+	// ClaimOutbox leases up to limit due outbox entries for lease, so a crashed
+	// dispatcher's entries become due again once the lease expires.
+	ClaimOutbox(ctx context.Context, limit int, lease time.Duration) ([]OutboxEntry, error)
+
+	// AckOutbox removes dispatched outbox entries.
+	AckOutbox(ctx context.Context, ids []int64) error
 
 	// List fetches notifications matching the given filter.
 	List(ctx context.Context, filter NotificationFilter) ([]*Notification, error)
@@ -25,6 +46,11 @@ type Repository interface {
 	// MarkRead marks a single notification as read.
 	MarkRead(ctx context.Context, id uuid.UUID, tenantKey, userID string) error
 
+	// ApplyReadStates records reads captured offline. For every ID owned by (or, for
+	// broadcasts, visible to) the user, the earliest read_at wins. Returns the IDs that
+	// were unread before the call. IDs must be unique.
+	ApplyReadStates(ctx context.Context, tenantKey, userID string, states []ReadState) ([]uuid.UUID, error)
+
 	// MarkAllRead marks all unread notifications for a user as read.
 	MarkAllRead(ctx context.Context, tenantKey, userID string) (int64, error)
 
@@ -34,6 +60,85 @@ type Repository interface {
 	// CountUnread returns the number of unread notifications for a user.
 	CountUnread(ctx context.Context, tenantKey, userID string) (int64, error)
 
-	// PurgeOlderThan deletes notifications older than the specified duration (TTL cleanup).
+	// Export streams the notification history selected by f, hot and archived
+	// rows and broadcasts, oldest first, calling fn for each. Stops at fn's first error.
+	Export(ctx context.Context, f ExportFilter, fn func(*Notification) error) error
+
+	// CountGroups counts a user's hot inbox (as CountUnread does) per type and priority.
+	CountGroups(ctx context.Context, tenantKey, userID string) ([]CountGroup, error)
+
+	// Snooze hides a hot or broadcast notification from List and CountUnread until
+	// until and records a snoozed state event. Snoozing again moves the wake-up time.
+	// Returns ErrNotificationNotFound when the user has no such notification.
+	Snooze(ctx context.Context, id uuid.UUID, tenantKey, userID string, until time.Time) error
+
+	// ListSnoozed returns the user's snoozed notifications with SnoozedUntil set,
+	// soonest wake-up first.
+	ListSnoozed(ctx context.Context, tenantKey, userID string) ([]*Notification, error)
+
+	// WakeSnoozed clears the snooze of up to limit notifications whose wake-up time
+	// has passed and returns them, one per recipient.
+	WakeSnoozed(ctx context.Context, limit int) ([]*Notification, error)
+
+	// SetPinned pins or unpins a hot or broadcast notification. Pinned notifications
+	// are listed first and skipped by retention, expiry, archive and compaction.
+	// Returns ErrNotificationNotFound when the user has no such notification.
+	SetPinned(ctx context.Context, id uuid.UUID, tenantKey, userID string, pinned bool) error
+
+	// PurgeOlderThan deletes notifications older than their retention policy, or
+	// than days when no policy applies (TTL cleanup).
 	PurgeOlderThan(ctx context.Context, days int) (int64, error)
+
+	// EnsurePartitions creates the monthly notification partitions through
+	// monthsAhead months from now and returns how many were created.
+	EnsurePartitions(ctx context.Context, monthsAhead int) (int, error)
+
+	// PurgeExpired deletes notifications whose expiry (see WithExpiry) has passed.
+	PurgeExpired(ctx context.Context) (int64, error)
+
+	// CountPurgeable counts what PurgeOlderThan(days) and PurgeExpired would
+	// delete, without deleting (dry run).
+	CountPurgeable(ctx context.Context, days int) (retention, expired int64, err error)
+
+	// ArchiveOverflow moves each user's notifications beyond their newest keep into
+	// the archive, at most limit rows per call, and returns how many were moved.
+	// Archived rows stay readable through List and accept MarkRead/Delete.
+	ArchiveOverflow(ctx context.Context, keep, limit int) (int64, error)
+
+	// EvictOverCap deletes the oldest read notifications of users holding more
+	// than capacity (hot and archived), at most limit rows per call, and returns how
+	// many were deleted.
+	EvictOverCap(ctx context.Context, capacity, limit int) (int64, error)
+
+	// FindCompactionRuns returns, per user, runs of consecutive read LOW-priority
+	// notifications created before cutoff that hold at least minRun items.
+	FindCompactionRuns(ctx context.Context, cutoff time.Time, minRun int) ([]CompactionRun, error)
+
+	// CompactRun atomically replaces the notifications of a run with a single,
+	// already-read summary notification.
+	CompactRun(ctx context.Context, run CompactionRun, summary CreateNotificationInput) error
+}
+
+// ReadState is a read recorded by a client, possibly while offline.
+type ReadState struct {
+	ID     uuid.UUID `json:"id"`
+	ReadAt time.Time `json:"read_at"`
+}
+
+// OutboxEntry is a pending real-time delivery of a stored notification.
+type OutboxEntry struct {
+	ID           int64
+	Attempts     int
+	Notification *Notification
+}
+
+// CompactionRun is a contiguous block of compactable notifications for one user.
+type CompactionRun struct {
+	TenantKey string
+	UserID    string
+	IDs       []uuid.UUID
+	From      time.Time
+	To        time.Time
+	// TypeCounts counts collapsed notifications per type, for the summary body.
+	TypeCounts map[NotificationType]int
 }
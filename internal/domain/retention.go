@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// RetentionPolicy sets how long notifications are kept before the TTL purge
+// deletes them. An empty TenantKey applies to all tenants and an empty Type to
+// all types. The most specific policy wins: tenant and type, then the type for
+// all tenants, then the tenant for all types, then the policy for everything.
+// With no policy the tenant custom type's retention_days, then the global
+// ttl.retention_days, apply.
+type RetentionPolicy struct {
+	TenantKey     string           `json:"tenant_key"`
+	Type          NotificationType `json:"type"`
+	RetentionDays int              `json:"retention_days"` // 0 = keep forever
+	UpdatedAt     time.Time        `json:"updated_at"`
+}
+
+// RetentionPolicyRepository defines the port for retention policies.
+type RetentionPolicyRepository interface {
+	// List returns the policies of tenantKey and those for all tenants, or every
+	// policy when tenantKey is empty, ordered by tenant and type.
+	List(ctx context.Context, tenantKey string) ([]RetentionPolicy, error)
+
+	// Upsert inserts or replaces the policy of (p.TenantKey, p.Type).
+	Upsert(ctx context.Context, p RetentionPolicy) (*RetentionPolicy, error)
+
+	// Delete removes the policy of (tenantKey, t).
+	Delete(ctx context.Context, tenantKey string, t NotificationType) error
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RolloutPlan stages a PLATFORM broadcast: a first wave reaches InitialPercent of
+// tenants immediately, the rest is held back until Delay elapses or an operator
+// confirms the release.
+type RolloutPlan struct {
+	// InitialPercent is the share of tenants (1-100) that receive the first wave.
+	InitialPercent int `json:"initialPercent"`
+	// Delay before the remaining tenants are released automatically.
+	// Ignored when RequireConfirmation is set.
+	Delay time.Duration `json:"delay"`
+	// RequireConfirmation holds the remaining tenants until released via the admin API.
+	RequireConfirmation bool `json:"requireConfirmation"`
+}
+
+// RolloutStatus is the lifecycle state of a staged rollout.
+type RolloutStatus string
+
+const (
+	RolloutPending   RolloutStatus = "PENDING"
+	RolloutReleased  RolloutStatus = "RELEASED"
+	RolloutCancelled RolloutStatus = "CANCELLED"
+)
+
+// Rollout is a PLATFORM broadcast whose remaining tenants are still held back.
+type Rollout struct {
+	ID             uuid.UUID     `json:"id"`
+	Input          FanoutInput   `json:"input"`
+	PendingTenants []string      `json:"pending_tenants"`
+	ReleaseAt      *time.Time    `json:"release_at,omitempty"` // nil = waits for confirmation
+	Status         RolloutStatus `json:"status"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+}
+
+// RolloutRepository defines the port for staged rollout persistence.
+type RolloutRepository interface {
+	// Create stores a new pending rollout.
+	Create(ctx context.Context, r Rollout) (*Rollout, error)
+
+	// Get returns a rollout by ID.
+	Get(ctx context.Context, id uuid.UUID) (*Rollout, error)
+
+	// ListPending returns all rollouts still in PENDING status.
+	ListPending(ctx context.Context) ([]Rollout, error)
+
+	// ListDue returns pending rollouts whose ReleaseAt is at or before now.
+	ListDue(ctx context.Context, now time.Time) ([]Rollout, error)
+
+	// Transition moves a rollout from PENDING to the given status.
+	// Returns false when the rollout was no longer pending (already handled).
+	Transition(ctx context.Context, id uuid.UUID, status RolloutStatus) (bool, error)
+
+	// SetPending replaces the held-back tenants of a pending rollout.
+	// Returns false when the rollout was no longer pending.
+	SetPending(ctx context.Context, id uuid.UUID, tenants []string) (bool, error)
+}
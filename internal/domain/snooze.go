@@ -0,0 +1,7 @@
+package domain
+
+import "errors"
+
+// ErrNotificationNotFound is returned when a notification does not exist or is
+// not visible to the user.
+var ErrNotificationNotFound = errors.New("notification not found")
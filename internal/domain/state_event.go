@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrNothingToUndo is returned when a notification has no undoable state change.
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+// StateEventKind names a per-user state transition of a notification.
+type StateEventKind string
+
+const (
+	StateCreated  StateEventKind = "created" // derived from the notification row, never stored
+	StateSeen     StateEventKind = "seen"    // rendered on a device
+	StateRead     StateEventKind = "read"
+	StateUnread   StateEventKind = "unread"  // a read was undone
+	StateSnoozed  StateEventKind = "snoozed" // Data["until"] holds the wake-up time
+	StateDeleted  StateEventKind = "deleted"
+	StateRestored StateEventKind = "restored" // a delete was undone
+	StateRecalled StateEventKind = "recalled" // withdrawn by its producer
+)
+
+// StateEvent is one entry of a user's append-only state stream. Seq orders events
+// across all of a user's notifications and serves as the sync cursor.
+type StateEvent struct {
+	Seq            int64          `json:"seq"`
+	NotificationID uuid.UUID      `json:"notification_id"`
+	TenantKey      string         `json:"tenant_key"`
+	UserID         string         `json:"user_id"`
+	Kind           StateEventKind `json:"kind"`
+	OccurredAt     time.Time      `json:"occurred_at"`
+	Data           map[string]any `json:"data,omitempty"`
+}
+
+// NotificationState is the state of a notification for one user, folded from its events.
+type NotificationState struct {
+	CreatedAt    *time.Time `json:"created_at,omitempty"`
+	SeenAt       *time.Time `json:"seen_at,omitempty"`
+	ReadAt       *time.Time `json:"read_at,omitempty"`
+	SnoozedUntil *time.Time `json:"snoozed_until,omitempty"`
+	DeletedAt    *time.Time `json:"deleted_at,omitempty"`
+	RecalledAt   *time.Time `json:"recalled_at,omitempty"`
+}
+
+// FoldStateEvents derives the current state from events in Seq order.
+func FoldStateEvents(events []StateEvent) NotificationState {
+	var st NotificationState
+	for _, e := range events {
+		at := e.OccurredAt
+		switch e.Kind {
+		case StateCreated:
+			st.CreatedAt = &at
+		case StateSeen:
+			if st.SeenAt == nil {
+				st.SeenAt = &at
+			}
+		case StateRead:
+			if st.ReadAt == nil || at.Before(*st.ReadAt) {
+				st.ReadAt = &at
+			}
+		case StateUnread:
+			st.ReadAt = nil
+		case StateSnoozed:
+			st.SnoozedUntil = nil
+			if s, ok := e.Data["until"].(string); ok {
+				if until, err := time.Parse(time.RFC3339Nano, s); err == nil {
+					st.SnoozedUntil = &until
+				}
+			}
+		case StateDeleted:
+			st.DeletedAt = &at
+		case StateRestored:
+			st.DeletedAt = nil
+		case StateRecalled:
+			st.RecalledAt = &at
+		}
+	}
+	return st
+}
+
+// StateEventRepository defines the port for the per-user notification state stream.
+// Read and delete events are appended by Repository in the same statement as the
+// state change they record.
+type StateEventRepository interface {
+	// ListByNotification returns a user's events for one notification, oldest first,
+	// preceded by a derived created event when the notification is still stored.
+	ListByNotification(ctx context.Context, tenantKey, userID string, id uuid.UUID) ([]StateEvent, error)
+
+	// ListSince returns up to limit of a user's events with Seq > after, oldest first.
+	ListSince(ctx context.Context, tenantKey, userID string, after int64, limit int) ([]StateEvent, error)
+
+	// MarkSeen records a seen event for each ID visible to the user that was not seen
+	// before and returns those IDs.
+	MarkSeen(ctx context.Context, tenantKey, userID string, ids []uuid.UUID) ([]uuid.UUID, error)
+
+	// Undo reverts the user's latest read or delete of a notification and returns
+	// the kind of the appended event (unread or restored), or ErrNothingToUndo.
+	Undo(ctx context.Context, tenantKey, userID string, id uuid.UUID) (StateEventKind, error)
+
+	// PurgeBefore deletes events that occurred before cutoff.
+	PurgeBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFoldStateEvents(t *testing.T) {
+	t0 := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	at := func(min int) time.Time { return t0.Add(time.Duration(min) * time.Minute) }
+	until := at(120)
+
+	events := []StateEvent{
+		{Kind: StateCreated, OccurredAt: at(0)},
+		{Kind: StateSeen, OccurredAt: at(1)},
+		{Kind: StateSeen, OccurredAt: at(2)},
+		{Kind: StateRead, OccurredAt: at(5)},
+		{Kind: StateRead, OccurredAt: at(3)}, // offline read synced later: earliest wins
+		{Kind: StateSnoozed, OccurredAt: at(6), Data: map[string]any{"until": until.Format(time.RFC3339Nano)}},
+		{Kind: StateDeleted, OccurredAt: at(7)},
+	}
+	st := FoldStateEvents(events)
+	if !st.CreatedAt.Equal(at(0)) || !st.SeenAt.Equal(at(1)) || !st.ReadAt.Equal(at(3)) {
+		t.Fatalf("state = %+v", st)
+	}
+	if st.SnoozedUntil == nil || !st.SnoozedUntil.Equal(until) || st.DeletedAt == nil {
+		t.Fatalf("state = %+v", st)
+	}
+
+	events = append(events,
+		StateEvent{Kind: StateRestored, OccurredAt: at(8)},
+		StateEvent{Kind: StateUnread, OccurredAt: at(9)},
+	)
+	st = FoldStateEvents(events)
+	if st.DeletedAt != nil || st.ReadAt != nil || st.SeenAt == nil {
+		t.Fatalf("after undo: state = %+v", st)
+	}
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// MaxStatsRangeDays bounds the range of days of a StatsFilter.
+const MaxStatsRangeDays = 366
+
+// DailyStats is the engagement of the notifications of one type delivered to a
+// tenant's users on one day.
+type DailyStats struct {
+	Day       time.Time        `json:"day"`
+	TenantKey string           `json:"tenant_key"`
+	Type      NotificationType `json:"type"`
+	Delivered int64            `json:"delivered"`
+	Read      int64            `json:"read"`
+	// MedianReadSeconds is the median time from delivery to read of those read;
+	// nil when none was.
+	MedianReadSeconds *float64 `json:"median_read_seconds"`
+}
+
+// StatsFilter selects rollup rows. From and To are days, both included; an
+// empty TenantKey or Type selects all.
+type StatsFilter struct {
+	TenantKey string
+	Type      NotificationType
+	From      time.Time
+	To        time.Time
+}
+
+// Validate checks the type and the range of days.
+func (f StatsFilter) Validate() error {
+	switch {
+	case f.Type != "" && !f.Type.Valid():
+		return fmt.Errorf("invalid type %q", f.Type)
+	case f.To.Before(f.From):
+		return fmt.Errorf("to must not be before from")
+	case f.To.Sub(f.From) >= MaxStatsRangeDays*24*time.Hour:
+		return fmt.Errorf("range must not exceed %d days", MaxStatsRangeDays)
+	}
+	return nil
+}
+
+// StatsRepository stores the daily engagement rollup (notification_stats).
+// Broadcasts are not counted: their reads are not tracked per delivery.
+type StatsRepository interface {
+	// Rollup recomputes the rows of the days from from to to (both included),
+	// taken in loc, from the notifications delivered on them, and returns the
+	// number of rows written.
+	Rollup(ctx context.Context, from, to time.Time, loc *time.Location) (int64, error)
+
+	// List returns the rows selected by f, ordered by day, tenant and type.
+	List(ctx context.Context, f StatsFilter) ([]DailyStats, error)
+}
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 )
 
@@ -30,3 +31,109 @@ type TemplateRepository interface {
 	// Delete removes a template.
 	Delete(ctx context.Context, key, locale string) error
 }
+
+// MaxTemplateInheritanceDepth bounds a tenant's chain of template parents.
+const MaxTemplateInheritanceDepth = 8
+
+// TemplateOverride replaces a platform template's title and/or body for one
+// tenant. An empty field is inherited from the tenant's parent, then from the
+// platform template.
+type TemplateOverride struct {
+	TenantKey     string    `json:"tenant_key"`
+	TemplateKey   string    `json:"template_key"`
+	Locale        string    `json:"locale"`
+	TitleTemplate string    `json:"title_template,omitempty"`
+	BodyTemplate  string    `json:"body_template,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// TemplateOverrideRepository defines the port for tenant template overrides.
+type TemplateOverrideRepository interface {
+	// Find returns the overrides of key (every key when empty) held by any of
+	// tenantKeys in any of locales.
+	Find(ctx context.Context, tenantKeys []string, key string, locales []string) ([]TemplateOverride, error)
+
+	// List returns a tenant's own overrides for a locale.
+	List(ctx context.Context, tenantKey, locale string) ([]TemplateOverride, error)
+
+	// Upsert inserts or updates an override.
+	Upsert(ctx context.Context, o TemplateOverride) (*TemplateOverride, error)
+
+	// Delete removes an override.
+	Delete(ctx context.Context, tenantKey, key, locale string) error
+
+	// Chain returns tenantKey followed by its ancestors, nearest first, at most
+	// MaxTemplateInheritanceDepth+1 entries.
+	Chain(ctx context.Context, tenantKey string) ([]string, error)
+
+	// SetParent makes tenantKey inherit from parent; an empty parent removes it.
+	SetParent(ctx context.Context, tenantKey, parent string) error
+}
+
+// TemplateSourcePlatform marks text that comes from the platform template.
+const TemplateSourcePlatform = "platform"
+
+// TemplateDiff compares a tenant's effective copy of a template with the
+// platform one. TitleFrom / BodyFrom name the tenant supplying each field, or
+// TemplateSourcePlatform.
+type TemplateDiff struct {
+	TemplateKey   string    `json:"template_key"`
+	Locale        string    `json:"locale"`
+	Platform      *Template `json:"platform,omitempty"`
+	TitleTemplate string    `json:"title_template"`
+	BodyTemplate  string    `json:"body_template"`
+	TitleFrom     string    `json:"title_from,omitempty"`
+	BodyFrom      string    `json:"body_from,omitempty"`
+	Overridden    bool      `json:"overridden"`
+}
+
+// Template storage modes.
+const (
+	// TemplateModeWrite renders templated notifications once, at fan-out (default).
+	TemplateModeWrite = "write"
+	// TemplateModeRead persists only the template reference and renders the text
+	// when the notification is listed or pushed, so template edits apply retroactively.
+	TemplateModeRead = "read"
+)
+
+// MetadataTemplate is the metadata key holding a notification's TemplateRef.
+const MetadataTemplate = "template"
+
+// TemplateRef identifies the template a notification was produced from and the
+// parameters substituted into it.
+type TemplateRef struct {
+	Key    string            `json:"key"`
+	Params map[string]string `json:"params,omitempty"`
+}
+
+// TemplateRefOf extracts the TemplateRef stored in a notification's metadata.
+// Returns nil when there is none.
+func TemplateRefOf(metadata map[string]any) *TemplateRef {
+	raw, ok := metadata[MetadataTemplate]
+	if !ok {
+		return nil
+	}
+	if ref, ok := raw.(*TemplateRef); ok {
+		return ref
+	}
+	// Metadata read back from the database holds the decoded JSON object.
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var ref TemplateRef
+	if err := json.Unmarshal(b, &ref); err != nil || ref.Key == "" {
+		return nil
+	}
+	return &ref
+}
+
+// WithTemplate returns a copy of metadata carrying ref under MetadataTemplate.
+func WithTemplate(metadata map[string]any, ref *TemplateRef) map[string]any {
+	out := make(map[string]any, len(metadata)+1)
+	for k, v := range metadata {
+		out[k] = v
+	}
+	out[MetadataTemplate] = ref
+	return out
+}
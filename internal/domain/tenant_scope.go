@@ -0,0 +1,22 @@
+package domain
+
+import "context"
+
+type tenantScopeKey struct{}
+
+// WithTenantScope marks ctx as acting for a single tenant. Repositories that
+// enforce tenant isolation (Postgres row-level security) restrict every
+// statement run with ctx to that tenant's rows, whatever the query filters on.
+func WithTenantScope(ctx context.Context, tenantKey string) context.Context {
+	if tenantKey == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, tenantScopeKey{}, tenantKey)
+}
+
+// TenantScope returns the tenant ctx is restricted to, or "" when it may
+// touch every tenant (background jobs, Kafka consumers, platform admin calls).
+func TenantScope(ctx context.Context) string {
+	tenantKey, _ := ctx.Value(tenantScopeKey{}).(string)
+	return tenantKey
+}
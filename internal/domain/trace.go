@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// TraceStage names a step of the processing pipeline recorded for a source event.
+type TraceStage string
+
+const (
+	TraceHandlerMatched     TraceStage = "HANDLER_MATCHED"
+	TraceScopeResolved      TraceStage = "SCOPE_RESOLVED"
+	TraceRecipientsFiltered TraceStage = "RECIPIENTS_FILTERED"
+	TraceBatchInserted      TraceStage = "BATCH_INSERTED"
+	TraceBroadcastStored    TraceStage = "BROADCAST_STORED"
+	TraceDispatched         TraceStage = "DISPATCHED"
+	TraceFailed             TraceStage = "FAILED"
+	TraceRateLimited        TraceStage = "RATE_LIMITED"
+	TraceThrottled          TraceStage = "THROTTLED"
+	TraceRecipientCapped    TraceStage = "RECIPIENT_CAPPED"
+	TraceMaintenance        TraceStage = "MAINTENANCE_WINDOW"
+	TraceQuotaExceeded      TraceStage = "QUOTA_EXCEEDED"
+)
+
+// TraceStep is one recorded pipeline step of a source event.
+type TraceStep struct {
+	ID            int64          `json:"id"`
+	SourceEventID string         `json:"source_event_id"`
+	Stage         TraceStage     `json:"stage"`
+	Detail        map[string]any `json:"detail,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+}
+
+// DeliverySummary is the current stored state of a source event's notifications.
+type DeliverySummary struct {
+	Notifications int64 `json:"notifications"`
+	Read          int64 `json:"read"`
+	PendingOutbox int64 `json:"pending_outbox"`
+	Broadcast     bool  `json:"broadcast"`
+}
+
+// TraceRepository defines the port for the processing ledger.
+type TraceRepository interface {
+	// Append records a step.
+	Append(ctx context.Context, step TraceStep) error
+
+	// ListBySourceEvent returns a source event's steps in recording order.
+	ListBySourceEvent(ctx context.Context, sourceEventID string) ([]TraceStep, error)
+
+	// DeliverySummary counts the stored notifications, read state and pending
+	// outbox entries of a source event.
+	DeliverySummary(ctx context.Context, sourceEventID string) (*DeliverySummary, error)
+
+	// PurgeBefore deletes steps recorded before cutoff.
+	PurgeBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
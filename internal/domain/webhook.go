@@ -0,0 +1,136 @@
+package domain
+
+import (
+	"context"
+	"net/netip"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookEvent names a notification event delivered to tenant webhooks.
+type WebhookEvent string
+
+const (
+	WebhookNotificationCreated WebhookEvent = "notification.created"
+	WebhookNotificationRead    WebhookEvent = "notification.read"
+	WebhookNotificationDeleted WebhookEvent = "notification.deleted"
+)
+
+// WebhookEvents lists every event a webhook can subscribe to.
+var WebhookEvents = []WebhookEvent{WebhookNotificationCreated, WebhookNotificationRead, WebhookNotificationDeleted}
+
+// Valid reports whether e is one of WebhookEvents.
+func (e WebhookEvent) Valid() bool {
+	switch e {
+	case WebhookNotificationCreated, WebhookNotificationRead, WebhookNotificationDeleted:
+		return true
+	}
+	return false
+}
+
+// PublicAddr reports whether a webhook may be delivered to a: not a loopback,
+// private, link-local, multicast or unspecified address, which would let a
+// tenant reach the internal network (SSRF).
+func PublicAddr(a netip.Addr) bool {
+	a = a.Unmap()
+	return a.IsValid() && !a.IsLoopback() && !a.IsPrivate() && !a.IsLinkLocalUnicast() &&
+		!a.IsLinkLocalMulticast() && !a.IsInterfaceLocalMulticast() && !a.IsMulticast() && !a.IsUnspecified()
+}
+
+// Webhook is a tenant-registered HTTPS endpoint receiving notification events.
+// Secret signs each request body (HMAC-SHA256); it is only returned on creation.
+type Webhook struct {
+	ID        uuid.UUID      `json:"id"`
+	TenantKey string         `json:"tenant_key"`
+	URL       string         `json:"url"`
+	Secret    string         `json:"secret,omitempty"`
+	Events    []WebhookEvent `json:"events"`
+	Active    bool           `json:"active"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// WebhookDeliveryStatus is the state of one webhook delivery.
+type WebhookDeliveryStatus string
+
+const (
+	WebhookPending   WebhookDeliveryStatus = "pending"   // waiting for its first or next attempt
+	WebhookDelivered WebhookDeliveryStatus = "delivered" // endpoint answered 2xx
+	WebhookFailed    WebhookDeliveryStatus = "failed"    // attempts exhausted
+)
+
+// WebhookDelivery is one event queued for one webhook.
+type WebhookDelivery struct {
+	ID             int64                 `json:"id"`
+	WebhookID      uuid.UUID             `json:"webhook_id"`
+	TenantKey      string                `json:"tenant_key"`
+	Event          WebhookEvent          `json:"event"`
+	Payload        map[string]any        `json:"payload"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	NextAttemptAt  time.Time             `json:"next_attempt_at"`
+	LastStatusCode int                   `json:"last_status_code,omitempty"`
+	LastError      string                `json:"last_error,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+
+	// Endpoint of the webhook, filled in by ClaimDeliveries.
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+// WebhookEventInput is an event to fan out to a tenant's subscribed webhooks.
+type WebhookEventInput struct {
+	TenantKey string
+	Event     WebhookEvent
+	Payload   map[string]any
+}
+
+// NotificationEventPublisher streams the events of WebhookEvents to downstream
+// consumers (e.g. a Kafka topic), for every tenant and whatever the tenants'
+// webhook subscriptions.
+type NotificationEventPublisher interface {
+	PublishNotificationEvents(ctx context.Context, events []WebhookEventInput) error
+}
+
+// WebhookRepository defines the port for webhook registrations and their delivery queue.
+type WebhookRepository interface {
+	List(ctx context.Context, tenantKey string) ([]Webhook, error)
+	// Get returns a tenant's webhook, or nil when it does not exist.
+	Get(ctx context.Context, tenantKey string, id uuid.UUID) (*Webhook, error)
+	Create(ctx context.Context, w Webhook) (*Webhook, error)
+	// Update changes URL, events and active flag; an empty Secret keeps the current one.
+	Update(ctx context.Context, w Webhook) (*Webhook, error)
+	Delete(ctx context.Context, tenantKey string, id uuid.UUID) error
+
+	// Enqueue stores one pending delivery per active webhook subscribed to each
+	// event in the event's tenant and returns the number of deliveries queued.
+	Enqueue(ctx context.Context, events []WebhookEventInput) (int64, error)
+
+	// ClaimDeliveries leases up to limit due pending deliveries for lease and
+	// increments their attempt count.
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]WebhookDelivery, error)
+
+	// MarkDelivered records a successful attempt.
+	MarkDelivered(ctx context.Context, id int64, statusCode int) error
+
+	// MarkAttemptFailed records a failed attempt; a nil retryAt gives up and marks
+	// the delivery failed.
+	MarkAttemptFailed(ctx context.Context, id int64, statusCode int, errMsg string, retryAt *time.Time) error
+
+	// ListDeliveries returns a webhook's deliveries, newest first, optionally by status.
+	ListDeliveries(ctx context.Context, tenantKey string, webhookID uuid.UUID, status WebhookDeliveryStatus, limit int) ([]WebhookDelivery, error)
+
+	// RetryDelivery makes a failed delivery pending again, due now.
+	RetryDelivery(ctx context.Context, tenantKey string, id int64) error
+
+	// PurgeBefore deletes delivered and failed deliveries created before cutoff.
+	PurgeBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// WebhookSender is the port for posting a delivery to its endpoint. It returns the
+// HTTP status code (0 when no response was received) and an error unless 2xx.
+type WebhookSender interface {
+	Send(ctx context.Context, d WebhookDelivery) (int, error)
+}
@@ -0,0 +1,122 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// maxErrorBody bounds the response body kept in the returned error.
+const maxErrorBody = 512
+
+// LogAlerter writes alerts to the service log, for log-based monitoring.
+type LogAlerter struct{}
+
+func (LogAlerter) Alert(_ context.Context, a domain.Alert) error {
+	var ev *zerolog.Event
+	switch {
+	case a.Resolved:
+		ev = log.Info()
+	case a.Severity == domain.AlertCritical:
+		ev = log.Error()
+	default:
+		ev = log.Warn()
+	}
+	ev.Bool("alert", true).
+		Str("alert_key", a.Key).
+		Str("severity", string(a.Severity)).
+		Str("source", a.Source).
+		Bool("resolved", a.Resolved).
+		Fields(a.Details).
+		Msg(a.Summary)
+	return nil
+}
+
+// WebhookAlerter POSTs each alert as JSON (domain.Alert) to a URL.
+type WebhookAlerter struct {
+	client *http.Client
+	url    string
+}
+
+// NewWebhookAlerter creates a WebhookAlerter using client.
+func NewWebhookAlerter(client *http.Client, url string) *WebhookAlerter {
+	return &WebhookAlerter{client: client, url: url}
+}
+
+func (w *WebhookAlerter) Alert(ctx context.Context, a domain.Alert) error {
+	return postJSON(ctx, w.client, w.url, a, "alert webhook")
+}
+
+// DefaultPagerDutyURL is the PagerDuty Events API v2 endpoint.
+const DefaultPagerDutyURL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDutyAlerter triggers and resolves PagerDuty incidents through the Events
+// API v2; the alert key is the dedup key.
+type PagerDutyAlerter struct {
+	client     *http.Client
+	url        string
+	routingKey string
+}
+
+// NewPagerDutyAlerter creates a PagerDutyAlerter for an integration routing key.
+func NewPagerDutyAlerter(client *http.Client, routingKey string) *PagerDutyAlerter {
+	return &PagerDutyAlerter{client: client, url: DefaultPagerDutyURL, routingKey: routingKey}
+}
+
+func (p *PagerDutyAlerter) Alert(ctx context.Context, a domain.Alert) error {
+	return postJSON(ctx, p.client, p.url, pagerDutyEvent(p.routingKey, a), "pagerduty")
+}
+
+// pagerDutyEvent builds an Events API v2 trigger or resolve event.
+func pagerDutyEvent(routingKey string, a domain.Alert) map[string]any {
+	event := map[string]any{
+		"routing_key":  routingKey,
+		"event_action": "trigger",
+		"dedup_key":    a.Key,
+	}
+	if a.Resolved {
+		event["event_action"] = "resolve"
+		return event
+	}
+	event["payload"] = map[string]any{
+		"summary":        a.Summary,
+		"source":         a.Instance,
+		"severity":       string(a.Severity),
+		"timestamp":      a.At,
+		"component":      a.Source,
+		"group":          a.Environment,
+		"class":          a.Key,
+		"custom_details": a.Details,
+	}
+	return event
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, payload any, target string) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode %s payload: %w", target, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build %s request: %w", target, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post %s: %w", target, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return fmt.Errorf("%s responded %d: %s", target, resp.StatusCode, bytes.TrimSpace(snippet))
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+// Package alerting delivers operational alerts (domain.Alert) to log, webhook
+// and PagerDuty backends, independently of the user-facing notification path.
+package alerting
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// DefaultTimeout bounds a backend call when Config.Timeout is unset.
+const DefaultTimeout = 10 * time.Second
+
+// Config tunes a Dispatcher.
+type Config struct {
+	Environment string // attached to every alert, e.g. "production"
+	Instance    string // attached to every alert, usually the hostname
+	// MinSeverity drops alerts below it (resolutions of sent alerts always pass).
+	MinSeverity domain.AlertSeverity
+	// Cooldown suppresses repeats of an open alert key; after it the alert is re-sent.
+	Cooldown time.Duration
+	// Timeout bounds each backend call.
+	Timeout time.Duration
+}
+
+// Dispatcher implements domain.Alerter: it filters and de-duplicates alerts and
+// sends them to every backend asynchronously, so callers never block on or fail
+// because of the monitoring system.
+type Dispatcher struct {
+	cfg      Config
+	backends []domain.Alerter
+	now      func() time.Time
+
+	mu   sync.Mutex
+	open map[string]time.Time // key -> last sent
+}
+
+// NewDispatcher creates a Dispatcher sending to backends.
+func NewDispatcher(cfg Config, backends ...domain.Alerter) *Dispatcher {
+	if cfg.MinSeverity == "" {
+		cfg.MinSeverity = domain.AlertWarning
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	return &Dispatcher{cfg: cfg, backends: backends, now: time.Now, open: make(map[string]time.Time)}
+}
+
+// Alert queues a for delivery. It always returns nil; backend errors are logged.
+func (d *Dispatcher) Alert(ctx context.Context, a domain.Alert) error {
+	if !d.admit(a) {
+		return nil
+	}
+	a.Environment, a.Instance = d.cfg.Environment, d.cfg.Instance
+	if a.At.IsZero() {
+		a.At = d.now()
+	}
+	ctx = context.WithoutCancel(ctx)
+	for _, b := range d.backends {
+		go func() {
+			ctx, cancel := context.WithTimeout(ctx, d.cfg.Timeout)
+			defer cancel()
+			if err := b.Alert(ctx, a); err != nil {
+				log.Warn().Err(err).Str("alert_key", a.Key).Msg("alert delivery failed")
+			}
+		}()
+	}
+	return nil
+}
+
+// admit applies the severity filter and cooldown, tracking which keys are open.
+func (d *Dispatcher) admit(a domain.Alert) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	last, open := d.open[a.Key]
+	if a.Resolved {
+		delete(d.open, a.Key)
+		return open
+	}
+	if a.Severity.Rank() < d.cfg.MinSeverity.Rank() {
+		return false
+	}
+	now := d.now()
+	if open && now.Sub(last) < d.cfg.Cooldown {
+		return false
+	}
+	d.open[a.Key] = now
+	return true
+}
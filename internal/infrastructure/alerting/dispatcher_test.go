@@ -0,0 +1,66 @@
+package alerting
+
+import (
+	"testing"
+	"time"
+
+	"vn.io.arda/notification/internal/domain"
+)
+
+func TestAdmit(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	d := NewDispatcher(Config{Cooldown: 5 * time.Minute})
+	d.now = func() time.Time { return now }
+
+	critical := domain.Alert{Key: "job.archive", Severity: domain.AlertCritical}
+	resolved := domain.Alert{Key: "job.archive", Resolved: true}
+
+	if d.admit(resolved) {
+		t.Fatal("resolution of an alert never sent was admitted")
+	}
+	if d.admit(domain.Alert{Key: "noise", Severity: domain.AlertInfo}) {
+		t.Fatal("info alert admitted with warning minimum")
+	}
+	if !d.admit(critical) {
+		t.Fatal("first critical alert was not admitted")
+	}
+	now = now.Add(time.Minute)
+	if d.admit(critical) {
+		t.Fatal("repeat within cooldown was admitted")
+	}
+	now = now.Add(5 * time.Minute)
+	if !d.admit(critical) {
+		t.Fatal("repeat after cooldown was not admitted")
+	}
+	if !d.admit(resolved) {
+		t.Fatal("resolution of an open alert was not admitted")
+	}
+	if d.admit(resolved) {
+		t.Fatal("second resolution was admitted")
+	}
+}
+
+func TestPagerDutyEvent(t *testing.T) {
+	a := domain.Alert{
+		Key:         "kafka.dlq",
+		Severity:    domain.AlertCritical,
+		Source:      "kafka.consumer",
+		Summary:     "dead-letter threshold reached",
+		Environment: "production",
+		Instance:    "notif-1",
+	}
+	ev := pagerDutyEvent("rk", a)
+	if ev["event_action"] != "trigger" || ev["dedup_key"] != "kafka.dlq" || ev["routing_key"] != "rk" {
+		t.Fatalf("trigger event = %v", ev)
+	}
+	payload := ev["payload"].(map[string]any)
+	if payload["severity"] != "critical" || payload["source"] != "notif-1" || payload["group"] != "production" {
+		t.Fatalf("payload = %v", payload)
+	}
+
+	a.Resolved = true
+	ev = pagerDutyEvent("rk", a)
+	if ev["event_action"] != "resolve" || ev["payload"] != nil {
+		t.Fatalf("resolve event = %v", ev)
+	}
+}
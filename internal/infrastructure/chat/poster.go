@@ -0,0 +1,88 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"vn.io.arda/notification/internal/domain"
+)
+
+// maxErrorBody bounds the response body kept in the returned error.
+const maxErrorBody = 512
+
+// Poster implements domain.ChatPoster for Slack and Microsoft Teams incoming webhooks.
+type Poster struct {
+	client *http.Client
+}
+
+// NewPoster creates a poster whose requests time out after timeout.
+func NewPoster(timeout time.Duration) *Poster {
+	return &Poster{client: &http.Client{Timeout: timeout}}
+}
+
+// Post sends msg in the connector provider's payload format.
+func (p *Poster) Post(ctx context.Context, c domain.ChatConnector, msg domain.ChatMessage) error {
+	var payload any
+	switch c.Provider {
+	case domain.ChatSlack:
+		payload = slackPayload(msg)
+	case domain.ChatTeams:
+		payload = teamsPayload(msg)
+	default:
+		return fmt.Errorf("unsupported chat provider %q", c.Provider)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode chat message: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build chat request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post chat message: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return fmt.Errorf("%s responded %d: %s", c.Provider, resp.StatusCode, bytes.TrimSpace(snippet))
+	}
+	return nil
+}
+
+// slackPayload is a Slack incoming webhook message; text uses Slack mrkdwn.
+func slackPayload(msg domain.ChatMessage) map[string]any {
+	return map[string]any{"text": msg.Text}
+}
+
+// teamsPayload is a legacy connector MessageCard, accepted by Teams incoming webhooks.
+func teamsPayload(msg domain.ChatMessage) map[string]any {
+	return map[string]any{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"summary":    msg.Title,
+		"themeColor": priorityColor(msg.Priority),
+		"text":       msg.Text,
+	}
+}
+
+func priorityColor(p domain.Priority) string {
+	switch p {
+	case domain.PriorityUrgent:
+		return "D70000"
+	case domain.PriorityHigh:
+		return "F2A100"
+	case domain.PriorityLow:
+		return "8A8886"
+	default:
+		return "0078D7"
+	}
+}
@@ -0,0 +1,50 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"vn.io.arda/notification/internal/domain"
+)
+
+func TestPostProviderPayloads(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = nil
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	p := NewPoster(time.Second)
+	msg := domain.ChatMessage{Title: "Disk full", Text: "*Disk full*\nnode-3", Priority: domain.PriorityUrgent}
+
+	if err := p.Post(context.Background(), domain.ChatConnector{Provider: domain.ChatSlack, URL: srv.URL}, msg); err != nil {
+		t.Fatalf("slack: %v", err)
+	}
+	if got["text"] != msg.Text || len(got) != 1 {
+		t.Errorf("slack payload = %v", got)
+	}
+
+	if err := p.Post(context.Background(), domain.ChatConnector{Provider: domain.ChatTeams, URL: srv.URL}, msg); err != nil {
+		t.Fatalf("teams: %v", err)
+	}
+	if got["@type"] != "MessageCard" || got["summary"] != "Disk full" || got["text"] != msg.Text || got["themeColor"] != "D70000" {
+		t.Errorf("teams payload = %v", got)
+	}
+}
+
+func TestPostFailsOnNon2xx(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_token", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	err := NewPoster(time.Second).Post(context.Background(), domain.ChatConnector{Provider: domain.ChatSlack, URL: srv.URL}, domain.ChatMessage{})
+	if err == nil {
+		t.Fatal("expected error on 403")
+	}
+}
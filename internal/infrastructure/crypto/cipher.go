@@ -0,0 +1,207 @@
+// Package crypto provides application-level encryption of notification content
+// with per-tenant keys (bring-your-own-key).
+package crypto
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"vn.io.arda/notification/internal/domain"
+)
+
+// prefix marks encrypted values. Format: enc:v1:<base64url(keyRef)>:<base64(nonce|ciphertext)>.
+// Embedding the key reference keeps old rows readable after a tenant rotates keys.
+const prefix = "enc:v1:"
+
+// Cipher encrypts content with the recipient tenant's registered key, falling back
+// to a default key. Values without the prefix are treated as plaintext on read.
+type Cipher struct {
+	keys       domain.EncryptionKeyRepository
+	provider   domain.KeyProvider
+	defaultRef string
+	ttl        time.Duration
+	sensitive  map[string]bool // tenants using defaultRef; nil means every tenant
+
+	mu    sync.Mutex
+	refs  map[string]cachedRef // tenantKey → key reference
+	aeads map[string]cipher.AEAD
+}
+
+type cachedRef struct {
+	ref     string
+	expires time.Time
+}
+
+// NewCipher creates a Cipher. defaultRef (may be empty) is used for tenants without
+// their own key; when empty, those tenants' content is stored in plaintext.
+// Tenant key registrations are cached for ttl.
+func NewCipher(keys domain.EncryptionKeyRepository, provider domain.KeyProvider, defaultRef string, ttl time.Duration) *Cipher {
+	return &Cipher{
+		keys:       keys,
+		provider:   provider,
+		defaultRef: defaultRef,
+		ttl:        ttl,
+		refs:       make(map[string]cachedRef),
+		aeads:      make(map[string]cipher.AEAD),
+	}
+}
+
+// SetSensitiveTenants limits the default key to tenants; other tenants are only
+// encrypted once they register their own key. Empty applies it to every tenant.
+func (c *Cipher) SetSensitiveTenants(tenants []string) {
+	if len(tenants) == 0 {
+		c.sensitive = nil
+		return
+	}
+	c.sensitive = make(map[string]bool, len(tenants))
+	for _, t := range tenants {
+		c.sensitive[t] = true
+	}
+}
+
+// Encrypt encrypts plaintext for tenantKey. The tenant key is bound as additional
+// data, so ciphertext cannot be replayed into another tenant's rows.
+func (c *Cipher) Encrypt(ctx context.Context, tenantKey, plaintext string) (string, error) {
+	ref, err := c.keyRef(ctx, tenantKey)
+	if err != nil {
+		return "", err
+	}
+	return c.seal(ctx, ref, tenantKey, plaintext)
+}
+
+// seal encrypts plaintext with ref, or returns it unchanged when ref is empty.
+func (c *Cipher) seal(ctx context.Context, ref, tenantKey, plaintext string) (string, error) {
+	if ref == "" {
+		return plaintext, nil
+	}
+	aead, err := c.aead(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("generate nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(tenantKey))
+	return prefix + base64.RawURLEncoding.EncodeToString([]byte(ref)) + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt reverses Encrypt. Plaintext values are returned unchanged.
+func (c *Cipher) Decrypt(ctx context.Context, tenantKey, value string) (string, error) {
+	rest, ok := strings.CutPrefix(value, prefix)
+	if !ok {
+		return value, nil
+	}
+	encRef, payload, ok := strings.Cut(rest, ":")
+	if !ok {
+		return "", errors.New("malformed ciphertext")
+	}
+	ref, err := base64.RawURLEncoding.DecodeString(encRef)
+	if err != nil {
+		return "", fmt.Errorf("decode key reference: %w", err)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", fmt.Errorf("decode ciphertext: %w", err)
+	}
+	aead, err := c.aead(ctx, string(ref))
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("ciphertext too short")
+	}
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(tenantKey))
+	if err != nil {
+		return "", fmt.Errorf("decrypt content for %s: %w", tenantKey, err)
+	}
+	return string(plain), nil
+}
+
+// keyRefOf returns the key reference value was encrypted with; empty for plaintext.
+func keyRefOf(value string) string {
+	rest, ok := strings.CutPrefix(value, prefix)
+	if !ok {
+		return ""
+	}
+	encRef, _, _ := strings.Cut(rest, ":")
+	ref, err := base64.RawURLEncoding.DecodeString(encRef)
+	if err != nil {
+		return ""
+	}
+	return string(ref)
+}
+
+// CheckKey verifies that keyRef resolves to usable key material.
+func (c *Cipher) CheckKey(ctx context.Context, keyRef string) error {
+	_, err := c.aead(ctx, keyRef)
+	return err
+}
+
+// keyRef returns the key reference used to encrypt new content for tenantKey.
+func (c *Cipher) keyRef(ctx context.Context, tenantKey string) (string, error) {
+	c.mu.Lock()
+	cached, ok := c.refs[tenantKey]
+	c.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.ref, nil
+	}
+
+	ref := c.defaultRef
+	if c.sensitive != nil && !c.sensitive[tenantKey] {
+		ref = ""
+	}
+	k, err := c.keys.Get(ctx, tenantKey)
+	if err != nil {
+		return "", fmt.Errorf("lookup encryption key for %s: %w", tenantKey, err)
+	}
+	if k != nil {
+		ref = k.KeyRef
+	}
+	c.mu.Lock()
+	c.refs[tenantKey] = cachedRef{ref: ref, expires: time.Now().Add(c.ttl)}
+	c.mu.Unlock()
+	return ref, nil
+}
+
+// forget drops the cached key reference of tenantKey, so a key registered since
+// is used right away.
+func (c *Cipher) forget(tenantKey string) {
+	c.mu.Lock()
+	delete(c.refs, tenantKey)
+	c.mu.Unlock()
+}
+
+func (c *Cipher) aead(ctx context.Context, ref string) (cipher.AEAD, error) {
+	c.mu.Lock()
+	aead, ok := c.aeads[ref]
+	c.mu.Unlock()
+	if ok {
+		return aead, nil
+	}
+
+	key, err := c.provider.DataKey(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("resolve key %s: %w", ref, err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("init cipher for key %s: %w", ref, err)
+	}
+	aead, err = cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("init gcm for key %s: %w", ref, err)
+	}
+	c.mu.Lock()
+	c.aeads[ref] = aead
+	c.mu.Unlock()
+	return aead, nil
+}
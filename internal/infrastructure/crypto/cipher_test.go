@@ -0,0 +1,120 @@
+package crypto
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"vn.io.arda/notification/internal/domain"
+)
+
+type stubKeys map[string]string
+
+func (s stubKeys) Get(_ context.Context, tenantKey string) (*domain.TenantEncryptionKey, error) {
+	ref, ok := s[tenantKey]
+	if !ok {
+		return nil, nil
+	}
+	return &domain.TenantEncryptionKey{TenantKey: tenantKey, KeyRef: ref}, nil
+}
+func (s stubKeys) List(context.Context) ([]domain.TenantEncryptionKey, error) { return nil, nil }
+func (s stubKeys) Upsert(_ context.Context, k domain.TenantEncryptionKey) (*domain.TenantEncryptionKey, error) {
+	return &k, nil
+}
+func (s stubKeys) Delete(context.Context, string) error { return nil }
+
+func testProvider(t *testing.T) *StaticKeyProvider {
+	t.Helper()
+	k1 := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("a", 32)))
+	k2 := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("b", 32)))
+	p, err := ParseStaticKeys("arn:aws:kms:acme=" + k1 + ",default=" + k2)
+	if err != nil {
+		t.Fatalf("ParseStaticKeys: %v", err)
+	}
+	return p
+}
+
+func TestCipherRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	c := NewCipher(stubKeys{"acme": "arn:aws:kms:acme"}, testProvider(t), "default", time.Minute)
+
+	for _, tenant := range []string{"acme", "other"} {
+		enc, err := c.Encrypt(ctx, tenant, "Xin chào")
+		if err != nil {
+			t.Fatalf("Encrypt(%s): %v", tenant, err)
+		}
+		if !strings.HasPrefix(enc, prefix) {
+			t.Fatalf("Encrypt(%s) = %q, want ciphertext", tenant, enc)
+		}
+		dec, err := c.Decrypt(ctx, tenant, enc)
+		if err != nil || dec != "Xin chào" {
+			t.Fatalf("Decrypt(%s) = %q, %v", tenant, dec, err)
+		}
+	}
+}
+
+func TestCipherBindsTenant(t *testing.T) {
+	ctx := context.Background()
+	c := NewCipher(stubKeys{}, testProvider(t), "default", time.Minute)
+
+	enc, err := c.Encrypt(ctx, "acme", "secret")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if _, err := c.Decrypt(ctx, "other", enc); err == nil {
+		t.Fatal("expected decrypt under another tenant to fail")
+	}
+}
+
+func TestCipherPlaintextPassthrough(t *testing.T) {
+	ctx := context.Background()
+	c := NewCipher(stubKeys{}, testProvider(t), "", time.Minute)
+
+	enc, err := c.Encrypt(ctx, "acme", "hello")
+	if err != nil || enc != "hello" {
+		t.Fatalf("Encrypt without key = %q, %v; want plaintext", enc, err)
+	}
+	dec, err := c.Decrypt(ctx, "acme", "legacy row")
+	if err != nil || dec != "legacy row" {
+		t.Fatalf("Decrypt plaintext = %q, %v", dec, err)
+	}
+}
+
+func TestCipherMetadata(t *testing.T) {
+	ctx := context.Background()
+	c := NewCipher(stubKeys{}, testProvider(t), "default", time.Minute)
+
+	meta := map[string]any{"entityType": "deal", "entityId": "D-1", "amount": "5.000.000 ₫"}
+	enc, err := c.EncryptMetadata(ctx, "acme", meta)
+	if err != nil {
+		t.Fatalf("EncryptMetadata: %v", err)
+	}
+	if enc["entityType"] != "deal" || enc["amount"] != nil || enc[metadataCipherKey] == nil {
+		t.Fatalf("EncryptMetadata = %v; want entityType plain and amount encrypted", enc)
+	}
+	if len(meta) != 3 {
+		t.Fatalf("EncryptMetadata modified its input: %v", meta)
+	}
+	dec, err := c.DecryptMetadata(ctx, "acme", enc)
+	if err != nil || dec["amount"] != "5.000.000 ₫" || dec["entityId"] != "D-1" || dec[metadataCipherKey] != nil {
+		t.Fatalf("DecryptMetadata = %v, %v", dec, err)
+	}
+}
+
+func TestCipherSensitiveTenants(t *testing.T) {
+	ctx := context.Background()
+	c := NewCipher(stubKeys{"acme": "arn:aws:kms:acme"}, testProvider(t), "default", time.Minute)
+	c.SetSensitiveTenants([]string{"bank"})
+
+	for tenant, encrypted := range map[string]bool{"bank": true, "acme": true, "shop": false} {
+		enc, err := c.Encrypt(ctx, tenant, "hello")
+		if err != nil {
+			t.Fatalf("Encrypt(%s): %v", tenant, err)
+		}
+		if got := strings.HasPrefix(enc, prefix); got != encrypted {
+			t.Errorf("Encrypt(%s) = %q; encrypted = %v, want %v", tenant, enc, got, encrypted)
+		}
+	}
+}
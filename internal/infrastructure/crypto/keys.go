@@ -0,0 +1,49 @@
+package crypto
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// StaticKeyProvider implements domain.KeyProvider from key material supplied in
+// configuration. It stands in for a KMS client: each entry maps a key reference
+// (as a tenant would register it) to a 256-bit key.
+type StaticKeyProvider struct {
+	keys map[string][]byte
+}
+
+// ParseStaticKeys parses "ref=base64key,ref2=base64key" into a StaticKeyProvider.
+// Keys must decode to exactly 32 bytes.
+func ParseStaticKeys(spec string) (*StaticKeyProvider, error) {
+	p := &StaticKeyProvider{keys: make(map[string][]byte)}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		ref, encoded, ok := strings.Cut(entry, "=")
+		if !ok || ref == "" {
+			return nil, fmt.Errorf("invalid key entry %q: expected ref=base64key", entry)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("decode key %q: %w", ref, err)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("key %q must be 32 bytes, got %d", ref, len(key))
+		}
+		p.keys[ref] = key
+	}
+	return p, nil
+}
+
+// DataKey returns the key material for keyRef.
+func (p *StaticKeyProvider) DataKey(_ context.Context, keyRef string) ([]byte, error) {
+	key, ok := p.keys[keyRef]
+	if !ok {
+		return nil, fmt.Errorf("unknown key reference %q", keyRef)
+	}
+	return key, nil
+}
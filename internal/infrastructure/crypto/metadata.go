@@ -0,0 +1,89 @@
+package crypto
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// metadataCipherKey holds the encrypted metadata entries of a notification.
+const metadataCipherKey = "_enc"
+
+// plainMetadataKeys stay readable in encrypted metadata because the storage
+// layer filters, purges and compacts on them in SQL.
+var plainMetadataKeys = map[string]bool{
+	"entityType":   true,
+	"entityId":     true,
+	"expires_at":   true,
+	"compacted":    true,
+	"compacted_at": true,
+}
+
+// EncryptMetadata returns a copy of meta whose entries, except plainMetadataKeys,
+// are encrypted together under metadataCipherKey. meta is returned unchanged
+// when the tenant has no key.
+func (c *Cipher) EncryptMetadata(ctx context.Context, tenantKey string, meta map[string]any) (map[string]any, error) {
+	ref, err := c.keyRef(ctx, tenantKey)
+	if err != nil {
+		return nil, err
+	}
+	return c.sealMetadata(ctx, ref, tenantKey, meta)
+}
+
+func (c *Cipher) sealMetadata(ctx context.Context, ref, tenantKey string, meta map[string]any) (map[string]any, error) {
+	if ref == "" || len(meta) == 0 {
+		return meta, nil
+	}
+	out := make(map[string]any, len(plainMetadataKeys)+1)
+	secret := make(map[string]any, len(meta))
+	for k, v := range meta {
+		if plainMetadataKeys[k] {
+			out[k] = v
+		} else {
+			secret[k] = v
+		}
+	}
+	if len(secret) == 0 {
+		return meta, nil
+	}
+	data, err := json.Marshal(secret)
+	if err != nil {
+		return nil, fmt.Errorf("encode metadata: %w", err)
+	}
+	sealed, err := c.seal(ctx, ref, tenantKey, string(data))
+	if err != nil {
+		return nil, err
+	}
+	out[metadataCipherKey] = sealed
+	return out, nil
+}
+
+// DecryptMetadata reverses EncryptMetadata. Metadata without encrypted entries
+// is returned unchanged.
+func (c *Cipher) DecryptMetadata(ctx context.Context, tenantKey string, meta map[string]any) (map[string]any, error) {
+	sealed, ok := meta[metadataCipherKey].(string)
+	if !ok {
+		return meta, nil
+	}
+	data, err := c.Decrypt(ctx, tenantKey, sealed)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]any, len(meta))
+	if err := json.Unmarshal([]byte(data), &out); err != nil {
+		return nil, fmt.Errorf("decode metadata: %w", err)
+	}
+	for k, v := range meta {
+		if k != metadataCipherKey {
+			out[k] = v
+		}
+	}
+	return out, nil
+}
+
+// metadataKeyRef returns the key reference meta was encrypted with; empty when
+// it has no encrypted entries.
+func metadataKeyRef(meta map[string]any) string {
+	sealed, _ := meta[metadataCipherKey].(string)
+	return keyRefOf(sealed)
+}
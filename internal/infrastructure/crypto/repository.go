@@ -0,0 +1,180 @@
+package crypto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// Repository decorates a domain.Repository, encrypting notification title, body
+// and metadata (see EncryptMetadata) on write and decrypting them on read.
+type Repository struct {
+	domain.Repository
+	cipher *Cipher
+}
+
+// NewRepository wraps repo with content encryption.
+func NewRepository(repo domain.Repository, c *Cipher) *Repository {
+	return &Repository{Repository: repo, cipher: c}
+}
+
+func (r *Repository) Create(ctx context.Context, input domain.CreateNotificationInput) (*domain.Notification, error) {
+	if err := r.encryptInput(ctx, &input); err != nil {
+		return nil, err
+	}
+	n, err := r.Repository.Create(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	r.decrypt(ctx, n)
+	return n, nil
+}
+
+func (r *Repository) BatchCreate(ctx context.Context, inputs []domain.CreateNotificationInput) (domain.BatchResult, error) {
+	encrypted := make([]domain.CreateNotificationInput, len(inputs))
+	copy(encrypted, inputs)
+	for i := range encrypted {
+		if err := r.encryptInput(ctx, &encrypted[i]); err != nil {
+			return domain.BatchResult{}, err
+		}
+	}
+	result, err := r.Repository.BatchCreate(ctx, encrypted)
+	if err != nil {
+		return domain.BatchResult{}, err
+	}
+	for _, n := range result.Inserted {
+		r.decrypt(ctx, n)
+	}
+	return result, nil
+}
+
+// CreateBroadcast encrypts tenant broadcasts with the tenant's key. Platform-wide
+// broadcasts have no owning tenant and are stored in plaintext.
+func (r *Repository) CreateBroadcast(ctx context.Context, input domain.BroadcastInput) (*domain.Notification, error) {
+	if input.TenantKey != "" {
+		title, err := r.cipher.Encrypt(ctx, input.TenantKey, input.Title)
+		if err != nil {
+			return nil, fmt.Errorf("encrypt title: %w", err)
+		}
+		body, err := r.cipher.Encrypt(ctx, input.TenantKey, input.Body)
+		if err != nil {
+			return nil, fmt.Errorf("encrypt body: %w", err)
+		}
+		meta, err := r.cipher.EncryptMetadata(ctx, input.TenantKey, input.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("encrypt metadata: %w", err)
+		}
+		input.Title, input.Body, input.Metadata = title, body, meta
+	}
+	n, err := r.Repository.CreateBroadcast(ctx, input)
+	if err != nil || n == nil {
+		return n, err
+	}
+	r.decrypt(ctx, n)
+	return n, nil
+}
+
+func (r *Repository) ClaimOutbox(ctx context.Context, limit int, lease time.Duration) ([]domain.OutboxEntry, error) {
+	entries, err := r.Repository.ClaimOutbox(ctx, limit, lease)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		r.decrypt(ctx, e.Notification)
+	}
+	return entries, nil
+}
+
+func (r *Repository) List(ctx context.Context, filter domain.NotificationFilter) ([]*domain.Notification, error) {
+	results, err := r.Repository.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range results {
+		r.decrypt(ctx, n)
+	}
+	return results, nil
+}
+
+func (r *Repository) ListSnoozed(ctx context.Context, tenantKey, userID string) ([]*domain.Notification, error) {
+	results, err := r.Repository.ListSnoozed(ctx, tenantKey, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range results {
+		r.decrypt(ctx, n)
+	}
+	return results, nil
+}
+
+func (r *Repository) Export(ctx context.Context, f domain.ExportFilter, fn func(*domain.Notification) error) error {
+	return r.Repository.Export(ctx, f, func(n *domain.Notification) error {
+		r.decrypt(ctx, n)
+		return fn(n)
+	})
+}
+
+func (r *Repository) WakeSnoozed(ctx context.Context, limit int) ([]*domain.Notification, error) {
+	results, err := r.Repository.WakeSnoozed(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range results {
+		r.decrypt(ctx, n)
+	}
+	return results, nil
+}
+
+func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Notification, error) {
+	n, err := r.Repository.GetByID(ctx, id)
+	if err != nil || n == nil {
+		return n, err
+	}
+	r.decrypt(ctx, n)
+	return n, nil
+}
+
+func (r *Repository) CompactRun(ctx context.Context, run domain.CompactionRun, summary domain.CreateNotificationInput) error {
+	if err := r.encryptInput(ctx, &summary); err != nil {
+		return err
+	}
+	return r.Repository.CompactRun(ctx, run, summary)
+}
+
+func (r *Repository) encryptInput(ctx context.Context, input *domain.CreateNotificationInput) error {
+	title, err := r.cipher.Encrypt(ctx, input.TenantKey, input.Title)
+	if err != nil {
+		return fmt.Errorf("encrypt title: %w", err)
+	}
+	body, err := r.cipher.Encrypt(ctx, input.TenantKey, input.Body)
+	if err != nil {
+		return fmt.Errorf("encrypt body: %w", err)
+	}
+	meta, err := r.cipher.EncryptMetadata(ctx, input.TenantKey, input.Metadata)
+	if err != nil {
+		return fmt.Errorf("encrypt metadata: %w", err)
+	}
+	input.Title, input.Body, input.Metadata = title, body, meta
+	return nil
+}
+
+// decrypt replaces title, body and metadata with plaintext. Content whose key can
+// no longer be resolved (e.g. a revoked tenant key) is blanked rather than failing
+// the read.
+func (r *Repository) decrypt(ctx context.Context, n *domain.Notification) {
+	title, errT := r.cipher.Decrypt(ctx, n.TenantKey, n.Title)
+	body, errB := r.cipher.Decrypt(ctx, n.TenantKey, n.Body)
+	meta, errM := r.cipher.DecryptMetadata(ctx, n.TenantKey, n.Metadata)
+	if err := errors.Join(errT, errB, errM); err != nil {
+		log.Warn().Err(err).Str("id", n.ID.String()).Str("tenant", n.TenantKey).Msg("failed to decrypt notification content")
+		n.Title, n.Body = "", ""
+		delete(n.Metadata, metadataCipherKey)
+		return
+	}
+	n.Title, n.Body, n.Metadata = title, body, meta
+}
@@ -0,0 +1,42 @@
+package crypto
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"vn.io.arda/notification/internal/domain"
+	"vn.io.arda/notification/internal/testsupport"
+)
+
+func TestRepositoryBatchCreate(t *testing.T) {
+	ctx := context.Background()
+	store := testsupport.NewRepository()
+	repo := NewRepository(store, NewCipher(stubKeys{}, testProvider(t), "default", time.Hour))
+	input := func(user string) domain.CreateNotificationInput {
+		return domain.CreateNotificationInput{TenantKey: "acme", UserID: user, Type: domain.TypeSystem,
+			Title: "Hợp đồng mới", SourceEventID: "evt-1"}
+	}
+	if _, err := repo.Create(ctx, input("u2")); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := repo.BatchCreate(ctx, []domain.CreateNotificationInput{input("u1"), input("u2"), input("u3")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Inserted) != 2 || !slices.Equal(result.Duplicates, []int{1}) {
+		t.Fatalf("inserted %d, duplicates %v; want 2 and [1]", len(result.Inserted), result.Duplicates)
+	}
+	for _, n := range result.Inserted {
+		if n.Title != "Hợp đồng mới" {
+			t.Fatalf("returned title %q, want it decrypted", n.Title)
+		}
+	}
+	for _, n := range store.Notifications() {
+		if keyRefOf(n.Title) != "default" {
+			t.Fatalf("stored title %q, want it encrypted under the default key", n.Title)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package crypto
+
+import (
+	"context"
+	"fmt"
+
+	"vn.io.arda/notification/internal/domain"
+)
+
+// Rotator implements domain.KeyRotator: it re-encrypts a tenant's stored content
+// whose key reference differs from the tenant's current one, including
+// plaintext written before the tenant had a key.
+type Rotator struct {
+	content domain.ContentRepository
+	cipher  *Cipher
+}
+
+// NewRotator creates a Rotator rewriting content through content.
+func NewRotator(content domain.ContentRepository, c *Cipher) *Rotator {
+	return &Rotator{content: content, cipher: c}
+}
+
+// Rotate re-encrypts tenantKey's content with its current key and returns the
+// number of notifications rewritten. Content is decrypted to plaintext when the
+// tenant no longer has a key.
+func (r *Rotator) Rotate(ctx context.Context, tenantKey string) (int64, error) {
+	r.cipher.forget(tenantKey)
+	ref, err := r.cipher.keyRef(ctx, tenantKey)
+	if err != nil {
+		return 0, err
+	}
+	if ref != "" {
+		if err := r.cipher.CheckKey(ctx, ref); err != nil {
+			return 0, err
+		}
+	}
+	return r.content.RewriteContent(ctx, tenantKey, func(c *domain.StoredContent) (bool, error) {
+		return r.reencrypt(ctx, ref, c)
+	})
+}
+
+// reencrypt rewrites the fields of c not encrypted with ref and reports whether
+// any changed.
+func (r *Rotator) reencrypt(ctx context.Context, ref string, c *domain.StoredContent) (bool, error) {
+	changed := false
+	for _, field := range []*string{&c.Title, &c.Body} {
+		if keyRefOf(*field) == ref {
+			continue
+		}
+		plain, err := r.cipher.Decrypt(ctx, c.TenantKey, *field)
+		if err != nil {
+			return false, fmt.Errorf("notification %s: %w", c.ID, err)
+		}
+		if *field, err = r.cipher.seal(ctx, ref, c.TenantKey, plain); err != nil {
+			return false, err
+		}
+		changed = true
+	}
+
+	if metadataKeyRef(c.Metadata) != ref {
+		meta, err := r.cipher.DecryptMetadata(ctx, c.TenantKey, c.Metadata)
+		if err != nil {
+			return false, fmt.Errorf("notification %s: %w", c.ID, err)
+		}
+		if meta, err = r.cipher.sealMetadata(ctx, ref, c.TenantKey, meta); err != nil {
+			return false, err
+		}
+		// Metadata with only plain entries is left as is.
+		if metadataKeyRef(meta) != metadataKeyRef(c.Metadata) {
+			c.Metadata = meta
+			changed = true
+		}
+	}
+	return changed, nil
+}
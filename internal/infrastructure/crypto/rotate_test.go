@@ -0,0 +1,67 @@
+package crypto
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"vn.io.arda/notification/internal/domain"
+)
+
+type stubContent []*domain.StoredContent
+
+func (s stubContent) RewriteContent(_ context.Context, tenantKey string, fn func(*domain.StoredContent) (bool, error)) (int64, error) {
+	var saved int64
+	for _, c := range s {
+		if c.TenantKey != tenantKey {
+			continue
+		}
+		changed, err := fn(c)
+		if err != nil {
+			return saved, err
+		}
+		if changed {
+			saved++
+		}
+	}
+	return saved, nil
+}
+
+func TestRotatorReencrypts(t *testing.T) {
+	ctx := context.Background()
+	keys := stubKeys{}
+	c := NewCipher(keys, testProvider(t), "default", time.Hour)
+
+	title, _ := c.Encrypt(ctx, "acme", "Hợp đồng mới")
+	meta, _ := c.EncryptMetadata(ctx, "acme", map[string]any{"entityType": "contract", "customer": "ACME"})
+	content := stubContent{
+		{ID: uuid.New(), TenantKey: "acme", Title: title, Body: "legacy plaintext", Metadata: meta},
+		{ID: uuid.New(), TenantKey: "acme", Title: title, Body: "", Metadata: map[string]any{"entityType": "contract"}},
+	}
+
+	// The tenant registers its own key; the cached default reference must not be used.
+	keys["acme"] = "arn:aws:kms:acme"
+	n, err := NewRotator(content, c).Rotate(ctx, "acme")
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("Rotate rewrote %d notifications, want 2", n)
+	}
+	for _, s := range content {
+		if keyRefOf(s.Title) != "arn:aws:kms:acme" || keyRefOf(s.Body) != "arn:aws:kms:acme" {
+			t.Fatalf("title/body not under the tenant key: %q, %q", s.Title, s.Body)
+		}
+	}
+	if metadataKeyRef(content[0].Metadata) != "arn:aws:kms:acme" || content[1].Metadata[metadataCipherKey] != nil {
+		t.Fatalf("metadata = %v, %v", content[0].Metadata, content[1].Metadata)
+	}
+	if body, _ := c.Decrypt(ctx, "acme", content[0].Body); body != "legacy plaintext" {
+		t.Fatalf("body = %q", body)
+	}
+
+	if n, err := NewRotator(content, c).Rotate(ctx, "acme"); err != nil || n != 0 {
+		t.Fatalf("second Rotate = %d, %v; want nothing to rewrite", n, err)
+	}
+}
@@ -1,6 +1,7 @@
 package keycloak
 
 import (
+	"container/list"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -8,6 +9,9 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"golang.org/x/sync/singleflight"
+	"vn.io.arda/notification/internal/metrics"
 )
 
 // Resolver implements application.IAMResolver by calling Keycloak Admin REST API.
@@ -22,17 +26,51 @@ type Resolver struct {
 
 	httpClient *http.Client
 
-	// Simple in-memory cache to avoid hammering Keycloak on every fan-out.
-	mu        sync.RWMutex
-	cacheTTL  time.Duration
-	cacheData map[string]cacheEntry // key: "tenant:<tenantKey>" | "role:<tenantKey>:<role>" | "platform"
+	// In-memory LRU cache to avoid hammering Keycloak on every fan-out.
+	mu        sync.Mutex
+	cache     CacheConfig
+	cacheData map[string]*list.Element // key: "tenant:<tenantKey>" | "role:<tenantKey>:<role>" | "group:<tenantKey>:<group>" | "platform"
+	lru       *list.List               // of *cacheItem, most recently used first
+	stats     map[string]*metrics.CacheCounters
+	// flights coalesces concurrent misses for the same key into one request.
+	flights singleflight.Group
 }
 
 type cacheEntry struct {
 	data      any
+	err       error // non-nil for a negative entry
 	expiresAt time.Time
 }
 
+type cacheItem struct {
+	key string
+	cacheEntry
+}
+
+// Cache key classes; group lookups share the role TTL.
+var cacheClasses = []string{"tenant", "role", "group", "platform"}
+
+// CacheConfig tunes the lookup cache. Zero per-class TTLs fall back to TTL.
+type CacheConfig struct {
+	TTL         time.Duration // Default: 30s
+	TenantTTL   time.Duration // all users of a realm
+	RoleTTL     time.Duration // role and group members
+	PlatformTTL time.Duration // users of all realms
+	// NegativeTTL caches failed lookups so a burst of fan-outs against a broken
+	// realm fails fast instead of retrying Keycloak. Zero disables negative caching.
+	NegativeTTL time.Duration
+	// MaxEntries bounds the cache; the least recently used entries are evicted first.
+	// Zero means unbounded.
+	MaxEntries int
+}
+
+const (
+	// DefaultCacheTTL is how long a successful lookup is remembered.
+	DefaultCacheTTL = 30 * time.Second
+	// DefaultNegativeCacheTTL is how long a failed lookup is remembered.
+	DefaultNegativeCacheTTL = 5 * time.Second
+)
+
 // New creates a Keycloak Resolver with a 30-second cache TTL.
 // If clientSecret is empty, it falls back to Resource Owner Password grant
 // using adminUser/adminPassword (useful for local development).
@@ -43,8 +81,10 @@ func New(adminURL, adminRealm, clientID, clientSecret string) *Resolver {
 		clientID:     clientID,
 		clientSecret: clientSecret,
 		httpClient:   &http.Client{Timeout: 10 * time.Second},
-		cacheTTL:     30 * time.Second,
-		cacheData:    make(map[string]cacheEntry),
+		cache:        CacheConfig{TTL: DefaultCacheTTL, NegativeTTL: DefaultNegativeCacheTTL},
+		cacheData:    make(map[string]*list.Element),
+		lru:          list.New(),
+		stats:        newCacheStats(),
 	}
 }
 
@@ -58,9 +98,19 @@ func NewWithPassword(adminURL, adminRealm, clientID, adminUser, adminPassword st
 		adminUser:     adminUser,
 		adminPassword: adminPassword,
 		httpClient:    &http.Client{Timeout: 10 * time.Second},
-		cacheTTL:      30 * time.Second,
-		cacheData:     make(map[string]cacheEntry),
+		cache:         CacheConfig{TTL: DefaultCacheTTL, NegativeTTL: DefaultNegativeCacheTTL},
+		cacheData:     make(map[string]*list.Element),
+		lru:           list.New(),
+		stats:         newCacheStats(),
+	}
+}
+
+func newCacheStats() map[string]*metrics.CacheCounters {
+	stats := make(map[string]*metrics.CacheCounters, len(cacheClasses))
+	for _, class := range cacheClasses {
+		stats[class] = &metrics.CacheCounters{}
 	}
+	return stats
 }
 
 // SetPasswordFallback sets the admin username/password used when clientSecret is empty.
@@ -70,6 +120,31 @@ func (r *Resolver) SetPasswordFallback(user, password string) {
 	r.adminPassword = password
 }
 
+// SetCache replaces the cache configuration. Call before the resolver is used.
+func (r *Resolver) SetCache(cfg CacheConfig) {
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultCacheTTL
+	}
+	r.cache = cfg
+}
+
+// ttl returns the lifetime of a successful lookup of the given key class.
+func (r *Resolver) ttl(class string) time.Duration {
+	var ttl time.Duration
+	switch class {
+	case "tenant":
+		ttl = r.cache.TenantTTL
+	case "role", "group":
+		ttl = r.cache.RoleTTL
+	case "platform":
+		ttl = r.cache.PlatformTTL
+	}
+	if ttl <= 0 {
+		return r.cache.TTL
+	}
+	return ttl
+}
+
 // keycloakUser is a minimal representation of a Keycloak user.
 type keycloakUser struct {
 	ID      string `json:"id"`
@@ -78,113 +153,192 @@ type keycloakUser struct {
 
 // UsersByTenant returns all enabled user IDs in the given realm.
 func (r *Resolver) UsersByTenant(ctx context.Context, tenantKey string) ([]string, error) {
-	cacheKey := "tenant:" + tenantKey
-	if cached, ok := r.fromCache(cacheKey); ok {
-		return cached.([]string), nil
-	}
-
-	users, err := r.listUsers(ctx, tenantKey)
+	v, err := r.load(ctx, "tenant:"+tenantKey, func(ctx context.Context) (any, error) {
+		users, err := r.listUsers(ctx, tenantKey)
+		if err != nil {
+			return nil, err
+		}
+		return enabledIDs(users), nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	ids := enabledIDs(users)
-	r.toCache(cacheKey, ids)
-	return ids, nil
+	return v.([]string), nil
 }
 
 // UsersByRole returns user IDs that hold roleName within the given realm.
 func (r *Resolver) UsersByRole(ctx context.Context, tenantKey, roleName string) ([]string, error) {
-	cacheKey := fmt.Sprintf("role:%s:%s", tenantKey, roleName)
-	if cached, ok := r.fromCache(cacheKey); ok {
-		return cached.([]string), nil
-	}
+	v, err := r.load(ctx, fmt.Sprintf("role:%s:%s", tenantKey, roleName), func(ctx context.Context) (any, error) {
+		token, err := r.adminToken(ctx)
+		if err != nil {
+			return nil, err
+		}
 
-	token, err := r.adminToken(ctx)
-	if err != nil {
-		return nil, err
-	}
+		url := fmt.Sprintf("%s/admin/realms/%s/roles/%s/users", r.adminURL, tenantKey, roleName)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
 
-	url := fmt.Sprintf("%s/admin/realms/%s/roles/%s/users", r.adminURL, tenantKey, roleName)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := r.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("keycloak roles/%s/users: %w", roleName, err)
+		}
+		defer resp.Body.Close()
 
-	resp, err := r.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("keycloak roles/%s/users: %w", roleName, err)
-	}
-	defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("keycloak roles/%s/users: status %d", roleName, resp.StatusCode)
+		}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("keycloak roles/%s/users: status %d", roleName, resp.StatusCode)
-	}
+		var users []keycloakUser
+		if err := json.NewDecoder(resp.Body).Decode(&users); err != nil {
+			return nil, err
+		}
 
-	var users []keycloakUser
-	if err := json.NewDecoder(resp.Body).Decode(&users); err != nil {
+		return enabledIDs(users), nil
+	})
+	if err != nil {
 		return nil, err
 	}
-
-	ids := enabledIDs(users)
-	r.toCache(cacheKey, ids)
-	return ids, nil
+	return v.([]string), nil
 }
 
-// AllActiveUsers returns enabled users grouped by realm across all Keycloak realms.
-// Each Keycloak realm is treated as a tenant.
-func (r *Resolver) AllActiveUsers(ctx context.Context) (map[string][]string, error) {
-	cacheKey := "platform"
-	if cached, ok := r.fromCache(cacheKey); ok {
-		return cached.(map[string][]string), nil
-	}
+// groupPageSize is the page size used when listing group members.
+const groupPageSize = 500
 
-	token, err := r.adminToken(ctx)
+// UsersByGroup returns enabled direct members of a group within the given realm.
+// group is a group ID, or a group path (e.g. "/Finance/Payroll") when it starts with "/".
+// Members of subgroups are not included.
+func (r *Resolver) UsersByGroup(ctx context.Context, tenantKey, group string) ([]string, error) {
+	v, err := r.load(ctx, fmt.Sprintf("group:%s:%s", tenantKey, group), func(ctx context.Context) (any, error) {
+		token, err := r.adminToken(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		groupID := group
+		if strings.HasPrefix(group, "/") {
+			groupID, err = r.groupIDByPath(ctx, token, tenantKey, group)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		var ids []string
+		for first := 0; ; first += groupPageSize {
+			url := fmt.Sprintf("%s/admin/realms/%s/groups/%s/members?briefRepresentation=true&first=%d&max=%d",
+				r.adminURL, tenantKey, groupID, first, groupPageSize)
+			var page []keycloakUser
+			if err := r.getJSON(ctx, token, url, &page); err != nil {
+				return nil, fmt.Errorf("keycloak groups/%s/members: %w", groupID, err)
+			}
+			ids = append(ids, enabledIDs(page)...)
+			if len(page) < groupPageSize {
+				break
+			}
+		}
+
+		return ids, nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	return v.([]string), nil
+}
 
-	// 1. List all realms (excluding master)
-	type realmRep struct {
-		Realm   string `json:"realm"`
-		Enabled bool   `json:"enabled"`
+// groupIDByPath resolves a group path to its ID.
+func (r *Resolver) groupIDByPath(ctx context.Context, token, tenantKey, path string) (string, error) {
+	url := fmt.Sprintf("%s/admin/realms/%s/group-by-path/%s", r.adminURL, tenantKey, strings.TrimPrefix(path, "/"))
+	var g struct {
+		ID string `json:"id"`
+	}
+	if err := r.getJSON(ctx, token, url, &g); err != nil {
+		return "", fmt.Errorf("keycloak group-by-path %s: %w", path, err)
 	}
+	return g.ID, nil
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.adminURL+"/admin/realms", nil)
+// getJSON performs an authenticated admin GET and decodes the JSON response into out.
+func (r *Resolver) getJSON(ctx context.Context, token, url string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	req.Header.Set("Authorization", "Bearer "+token)
 
 	resp, err := r.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("keycloak list realms: %w", err)
+		return err
 	}
 	defer resp.Body.Close()
 
-	var realms []realmRep
-	if err := json.NewDecoder(resp.Body).Decode(&realms); err != nil {
-		return nil, err
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", resp.StatusCode)
 	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
 
-	// 2. For each realm, list enabled users.
-	result := make(map[string][]string)
-	for _, realm := range realms {
-		if !realm.Enabled || realm.Realm == r.adminRealm {
-			continue
+// AllActiveUsers returns enabled users grouped by realm across all Keycloak realms.
+// Each Keycloak realm is treated as a tenant.
+func (r *Resolver) AllActiveUsers(ctx context.Context) (map[string][]string, error) {
+	v, err := r.load(ctx, "platform", func(ctx context.Context) (any, error) {
+		token, err := r.adminToken(ctx)
+		if err != nil {
+			return nil, err
 		}
-		users, err := r.listUsers(ctx, realm.Realm)
+
+		// 1. List all realms (excluding master)
+		type realmRep struct {
+			Realm   string `json:"realm"`
+			Enabled bool   `json:"enabled"`
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.adminURL+"/admin/realms", nil)
 		if err != nil {
-			// Log and continue rather than aborting the entire fan-out.
-			continue
+			return nil, err
 		}
-		if ids := enabledIDs(users); len(ids) > 0 {
-			result[realm.Realm] = ids
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := r.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("keycloak list realms: %w", err)
+		}
+		defer resp.Body.Close()
+
+		var realms []realmRep
+		if err := json.NewDecoder(resp.Body).Decode(&realms); err != nil {
+			return nil, err
 		}
+
+		// 2. For each realm, list enabled users.
+		result := make(map[string][]string)
+		for _, realm := range realms {
+			if !realm.Enabled || realm.Realm == r.adminRealm {
+				continue
+			}
+			users, err := r.listUsers(ctx, realm.Realm)
+			if err != nil {
+				// Log and continue rather than aborting the entire fan-out.
+				continue
+			}
+			if ids := enabledIDs(users); len(ids) > 0 {
+				result[realm.Realm] = ids
+			}
+		}
+
+		return result, nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return v.(map[string][]string), nil
+}
 
-	r.toCache(cacheKey, result)
-	return result, nil
+// Ping checks that the admin token endpoint issues a token with the configured credentials.
+func (r *Resolver) Ping(ctx context.Context) error {
+	_, err := r.adminToken(ctx)
+	return err
 }
 
 // --- internal helpers ---
@@ -312,20 +466,144 @@ func enabledIDs(users []keycloakUser) []string {
 	return ids
 }
 
-// fromCache retrieves a cached value if not expired.
-func (r *Resolver) fromCache(key string) (any, bool) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	entry, ok := r.cacheData[key]
-	if !ok || time.Now().After(entry.expiresAt) {
-		return nil, false
+// load returns the cached value for key, or calls fetch once for all concurrent
+// callers missing the same key. Successes are cached for cacheTTL, failures for
+// negativeTTL. fetch runs detached from the caller's cancellation so one caller
+// giving up does not fail the others waiting on the same flight.
+func (r *Resolver) load(ctx context.Context, key string, fetch func(ctx context.Context) (any, error)) (any, error) {
+	stats := r.stats[cacheClass(key)]
+	if entry, ok := r.fromCache(key); ok {
+		stats.Hit(entry.err != nil)
+		return entry.data, entry.err
+	}
+	stats.Miss()
+	v, err, _ := r.flights.Do(key, func() (any, error) {
+		// A flight that finished just before this one started may have filled the cache.
+		if entry, ok := r.fromCache(key); ok {
+			return entry.data, entry.err
+		}
+		data, err := fetch(context.WithoutCancel(ctx))
+		if err != nil {
+			if r.cache.NegativeTTL > 0 {
+				r.storeCache(key, cacheEntry{err: err, expiresAt: time.Now().Add(r.cache.NegativeTTL)})
+			}
+			return nil, err
+		}
+		r.toCache(key, data)
+		return data, nil
+	})
+	return v, err
+}
+
+// fromCache retrieves a cached entry (positive or negative) if not expired,
+// marking it most recently used. Expired entries are dropped.
+func (r *Resolver) fromCache(key string) (cacheEntry, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	el, ok := r.cacheData[key]
+	if !ok {
+		return cacheEntry{}, false
+	}
+	item := el.Value.(*cacheItem)
+	if time.Now().After(item.expiresAt) {
+		r.removeLocked(el)
+		r.stats[cacheClass(key)].Expire()
+		return cacheEntry{}, false
+	}
+	r.lru.MoveToFront(el)
+	return item.cacheEntry, true
+}
+
+// CacheStats reports cache occupancy and per-class hit/miss counters.
+func (r *Resolver) CacheStats() metrics.CacheReport {
+	r.mu.Lock()
+	entries := make(map[string]int, len(cacheClasses))
+	for key := range r.cacheData {
+		entries[cacheClass(key)]++
+	}
+	total := len(r.cacheData)
+	r.mu.Unlock()
+
+	report := metrics.CacheReport{
+		Entries:    total,
+		MaxEntries: r.cache.MaxEntries,
+		Classes:    make(map[string]metrics.CacheSnapshot, len(cacheClasses)),
+	}
+	for class, stats := range r.stats {
+		snap := stats.Snapshot()
+		snap.Entries = entries[class]
+		report.Classes[class] = snap
+	}
+	return report
+}
+
+// ReclaimTenants drops cached lookups of idle tenants, and expired entries of any
+// tenant. This satisfies the application.TenantReclaimer interface.
+func (r *Resolver) ReclaimTenants(isIdle func(tenantKey string) bool) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	reclaimed := make(map[string]struct{})
+	for key, el := range r.cacheData {
+		tenantKey, scoped := cacheTenant(key)
+		switch {
+		case scoped && isIdle(tenantKey):
+			reclaimed[tenantKey] = struct{}{}
+		case now.After(el.Value.(*cacheItem).expiresAt):
+			r.stats[cacheClass(key)].Expire()
+		default:
+			continue
+		}
+		r.removeLocked(el)
+	}
+	return len(reclaimed)
+}
+
+// cacheClass returns the class ("tenant", "role", "group" or "platform") of a cache key.
+func cacheClass(key string) string {
+	class, _, _ := strings.Cut(key, ":")
+	return class
+}
+
+// cacheTenant extracts the tenant from a "tenant:", "role:" or "group:" cache key.
+func cacheTenant(key string) (string, bool) {
+	kind, rest, ok := strings.Cut(key, ":")
+	if !ok || kind == "platform" {
+		return "", false
 	}
-	return entry.data, true
+	if kind != "tenant" {
+		rest, _, _ = strings.Cut(rest, ":")
+	}
+	return rest, true
 }
 
-// toCache stores a value with the configured TTL.
+// toCache stores a value with the TTL of its key class.
 func (r *Resolver) toCache(key string, data any) {
+	r.storeCache(key, cacheEntry{data: data, expiresAt: time.Now().Add(r.ttl(cacheClass(key)))})
+}
+
+// storeCache inserts or replaces an entry as most recently used, evicting the
+// least recently used entries beyond MaxEntries.
+func (r *Resolver) storeCache(key string, entry cacheEntry) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.cacheData[key] = cacheEntry{data: data, expiresAt: time.Now().Add(r.cacheTTL)}
+
+	if el, ok := r.cacheData[key]; ok {
+		el.Value.(*cacheItem).cacheEntry = entry
+		r.lru.MoveToFront(el)
+		return
+	}
+	r.cacheData[key] = r.lru.PushFront(&cacheItem{key: key, cacheEntry: entry})
+
+	for r.cache.MaxEntries > 0 && r.lru.Len() > r.cache.MaxEntries {
+		oldest := r.lru.Back()
+		r.removeLocked(oldest)
+		r.stats[cacheClass(oldest.Value.(*cacheItem).key)].Evict()
+	}
+}
+
+func (r *Resolver) removeLocked(el *list.Element) {
+	r.lru.Remove(el)
+	delete(r.cacheData, el.Value.(*cacheItem).key)
 }
@@ -0,0 +1,152 @@
+package keycloak
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReclaimTenants(t *testing.T) {
+	r := New("http://keycloak", "master", "admin-cli", "")
+	r.toCache("tenant:acme", []string{"u1"})
+	r.toCache("role:acme:ADMIN", []string{"u1"})
+	r.toCache("group:globex:/Finance", []string{"u2"})
+	r.toCache("platform", map[string][]string{})
+	r.storeCache("tenant:initech", cacheEntry{data: []string{"u3"}, expiresAt: time.Now().Add(-time.Second)})
+
+	n := r.ReclaimTenants(func(tk string) bool { return tk == "acme" })
+	if n != 1 {
+		t.Fatalf("expected 1 reclaimed tenant, got %d", n)
+	}
+	for _, key := range []string{"tenant:acme", "role:acme:ADMIN", "tenant:initech"} {
+		if _, ok := r.cacheData[key]; ok {
+			t.Fatalf("%s should have been dropped", key)
+		}
+	}
+	for _, key := range []string{"group:globex:/Finance", "platform"} {
+		if _, ok := r.cacheData[key]; !ok {
+			t.Fatalf("%s should have been kept", key)
+		}
+	}
+}
+
+func TestLoadCoalescesAndCachesFailures(t *testing.T) {
+	var users, broken atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case strings.HasSuffix(req.URL.Path, "/token"):
+			_, _ = w.Write([]byte(`{"access_token":"t"}`))
+		case strings.Contains(req.URL.Path, "/realms/acme/users"):
+			users.Add(1)
+			<-release
+			_, _ = w.Write([]byte(`[{"id":"u1","enabled":true}]`))
+		default:
+			broken.Add(1)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	r := New(srv.URL, "master", "svc", "secret")
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ids, err := r.UsersByTenant(context.Background(), "acme"); err != nil || len(ids) != 1 {
+				t.Errorf("UsersByTenant: %v %v", ids, err)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if n := users.Load(); n != 1 {
+		t.Fatalf("expected 1 coalesced user-list request, got %d", n)
+	}
+
+	for range 3 {
+		if _, err := r.UsersByTenant(context.Background(), "missing"); err == nil {
+			t.Fatal("expected error for missing realm")
+		}
+	}
+	if n := broken.Load(); n != 1 {
+		t.Fatalf("expected failure to be negatively cached, got %d requests", n)
+	}
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	r := New("http://keycloak", "master", "admin-cli", "")
+	r.SetCache(CacheConfig{TTL: time.Minute, PlatformTTL: time.Hour, MaxEntries: 2})
+	r.toCache("tenant:acme", []string{"u1"})
+	r.toCache("tenant:globex", []string{"u2"})
+	if _, ok := r.fromCache("tenant:acme"); !ok {
+		t.Fatal("expected tenant:acme to be cached")
+	}
+	r.toCache("platform", map[string][]string{})
+
+	if _, ok := r.cacheData["tenant:globex"]; ok {
+		t.Fatal("least recently used entry should have been evicted")
+	}
+	if ttl := time.Until(r.cacheData["platform"].Value.(*cacheItem).expiresAt); ttl < 59*time.Minute {
+		t.Fatalf("expected platform TTL override, got %v", ttl)
+	}
+
+	report := r.CacheStats()
+	if report.Entries != 2 || report.Classes["tenant"].Evictions != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+}
+
+func TestUsersByGroup(t *testing.T) {
+	var memberPages atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case strings.HasSuffix(req.URL.Path, "/token"):
+			_, _ = w.Write([]byte(`{"access_token":"t"}`))
+		case req.URL.Path == "/admin/realms/acme/group-by-path/Finance/Payroll":
+			_, _ = w.Write([]byte(`{"id":"g1"}`))
+		case req.URL.Path == "/admin/realms/acme/groups/g1/members":
+			memberPages.Add(1)
+			if req.URL.Query().Get("first") != "0" {
+				_, _ = w.Write([]byte(`[{"id":"u-last","enabled":true},{"id":"u-off","enabled":false}]`))
+				return
+			}
+			page := make([]string, groupPageSize)
+			for i := range page {
+				page[i] = fmt.Sprintf(`{"id":"u%d","enabled":true}`, i)
+			}
+			_, _ = w.Write([]byte("[" + strings.Join(page, ",") + "]"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	r := New(srv.URL, "master", "svc", "secret")
+	ids, err := r.UsersByGroup(context.Background(), "acme", "/Finance/Payroll")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A full first page fetches the next one; disabled members are skipped.
+	if len(ids) != groupPageSize+1 || ids[len(ids)-1] != "u-last" || memberPages.Load() != 2 {
+		t.Fatalf("got %d members from %d pages", len(ids), memberPages.Load())
+	}
+	if _, err := r.UsersByGroup(context.Background(), "acme", "/Finance/Payroll"); err != nil || memberPages.Load() != 2 {
+		t.Fatalf("cached lookup: %v, %d pages fetched", err, memberPages.Load())
+	}
+
+	if ids, err := r.UsersByGroup(context.Background(), "acme", "g1"); err != nil || len(ids) != groupPageSize+1 {
+		t.Fatalf("by ID: %d members, %v", len(ids), err)
+	}
+	if _, err := r.UsersByGroup(context.Background(), "acme", "/Missing"); err == nil {
+		t.Fatal("expected an error for an unknown group path")
+	}
+}
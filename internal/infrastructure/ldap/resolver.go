@@ -0,0 +1,217 @@
+// Package ldap implements application.IAMResolver against an LDAP directory
+// (OpenLDAP, Active Directory) for on-prem deployments that do not run Keycloak.
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+// tenantPlaceholder is replaced by the tenant key in base DNs.
+const tenantPlaceholder = "{tenant}"
+
+// pageSize is the simple-paged-results size used for user searches.
+const pageSize = 500
+
+// Config describes how tenants, users, roles and groups map onto the directory.
+type Config struct {
+	URL          string // e.g. "ldaps://ldap.corp.local:636"
+	BindDN       string
+	BindPassword string
+	StartTLS     bool
+	// UserBaseDN, GroupBaseDN and RoleBaseDN may contain "{tenant}".
+	UserBaseDN  string
+	UserFilter  string // Default: "(objectClass=inetOrgPerson)"; add a clause to skip disabled accounts
+	IDAttribute string // Default: "uid"; must match the user ID carried in access tokens
+	GroupBaseDN string
+	// RoleBaseDN holds the groups that represent roles (matched by cn). Default: GroupBaseDN.
+	RoleBaseDN      string
+	MemberAttribute string // Default: "member"
+	// Tenants lists the tenant keys reached by PLATFORM scope.
+	Tenants []string
+	Timeout time.Duration // Default: 10s
+}
+
+// Resolver implements application.IAMResolver with one LDAP connection per call.
+type Resolver struct {
+	cfg Config
+}
+
+// New creates an LDAP Resolver, filling in defaults.
+func New(cfg Config) *Resolver {
+	if cfg.UserFilter == "" {
+		cfg.UserFilter = "(objectClass=inetOrgPerson)"
+	}
+	if cfg.IDAttribute == "" {
+		cfg.IDAttribute = "uid"
+	}
+	if cfg.RoleBaseDN == "" {
+		cfg.RoleBaseDN = cfg.GroupBaseDN
+	}
+	if cfg.MemberAttribute == "" {
+		cfg.MemberAttribute = "member"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	return &Resolver{cfg: cfg}
+}
+
+// UsersByTenant returns the IDs of all users matching UserFilter under the tenant's UserBaseDN.
+func (r *Resolver) UsersByTenant(ctx context.Context, tenantKey string) ([]string, error) {
+	conn, err := r.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	users, err := r.users(conn, tenantKey)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(users))
+	for _, id := range users {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+// UsersByRole returns the members of the group named roleName (cn) under RoleBaseDN.
+func (r *Resolver) UsersByRole(ctx context.Context, tenantKey, roleName string) ([]string, error) {
+	return r.members(ctx, tenantKey, r.cfg.RoleBaseDN, roleName)
+}
+
+// UsersByGroup returns the direct members of group. group is a full DN, or a cn
+// under GroupBaseDN; for a path ("/Finance/Payroll") the last segment is used.
+func (r *Resolver) UsersByGroup(ctx context.Context, tenantKey, group string) ([]string, error) {
+	if strings.HasPrefix(group, "/") {
+		group = group[strings.LastIndex(group, "/")+1:]
+	}
+	return r.members(ctx, tenantKey, r.cfg.GroupBaseDN, group)
+}
+
+// AllActiveUsers returns users grouped by the configured Tenants.
+func (r *Resolver) AllActiveUsers(ctx context.Context) (map[string][]string, error) {
+	result := make(map[string][]string, len(r.cfg.Tenants))
+	for _, tk := range r.cfg.Tenants {
+		ids, err := r.UsersByTenant(ctx, tk)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) > 0 {
+			result[tk] = ids
+		}
+	}
+	return result, nil
+}
+
+// members resolves a group's member DNs and keeps those that are users of the tenant.
+func (r *Resolver) members(ctx context.Context, tenantKey, baseDN, group string) ([]string, error) {
+	conn, err := r.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var req *goldap.SearchRequest
+	if strings.Contains(group, "=") {
+		req = goldap.NewSearchRequest(group, goldap.ScopeBaseObject, goldap.NeverDerefAliases, 0, 0, false,
+			"(objectClass=*)", []string{r.cfg.MemberAttribute}, nil)
+	} else {
+		req = goldap.NewSearchRequest(forTenant(baseDN, tenantKey), goldap.ScopeWholeSubtree, goldap.NeverDerefAliases, 2, 0, false,
+			"(cn="+goldap.EscapeFilter(group)+")", []string{r.cfg.MemberAttribute}, nil)
+	}
+	res, err := conn.Search(req)
+	if err != nil {
+		return nil, fmt.Errorf("ldap search group %s: %w", group, err)
+	}
+	if len(res.Entries) == 0 {
+		return nil, fmt.Errorf("ldap group %s not found", group)
+	}
+	if len(res.Entries) > 1 {
+		return nil, fmt.Errorf("ldap group %s is ambiguous", group)
+	}
+
+	users, err := r.users(conn, tenantKey)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, dn := range res.Entries[0].GetEqualFoldAttributeValues(r.cfg.MemberAttribute) {
+		if id, ok := users[normalizeDN(dn)]; ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// users returns the tenant's users keyed by normalized DN.
+func (r *Resolver) users(conn *goldap.Conn, tenantKey string) (map[string]string, error) {
+	req := goldap.NewSearchRequest(forTenant(r.cfg.UserBaseDN, tenantKey), goldap.ScopeWholeSubtree, goldap.NeverDerefAliases, 0, 0, false,
+		r.cfg.UserFilter, []string{r.cfg.IDAttribute}, nil)
+	res, err := conn.SearchWithPaging(req, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("ldap search users(%s): %w", tenantKey, err)
+	}
+	users := make(map[string]string, len(res.Entries))
+	for _, e := range res.Entries {
+		if id := e.GetEqualFoldAttributeValue(r.cfg.IDAttribute); id != "" {
+			users[normalizeDN(e.DN)] = id
+		}
+	}
+	return users, nil
+}
+
+// dial connects and binds with the service account.
+func (r *Resolver) dial(ctx context.Context) (*goldap.Conn, error) {
+	conn, err := goldap.DialURL(r.cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("ldap dial: %w", err)
+	}
+	timeout := r.cfg.Timeout
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < timeout {
+		timeout = time.Until(deadline)
+	}
+	conn.SetTimeout(timeout)
+
+	if r.cfg.StartTLS {
+		u, err := url.Parse(r.cfg.URL)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("ldap url: %w", err)
+		}
+		if err := conn.StartTLS(&tls.Config{ServerName: u.Hostname()}); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("ldap starttls: %w", err)
+		}
+	}
+	if r.cfg.BindDN != "" {
+		if err := conn.Bind(r.cfg.BindDN, r.cfg.BindPassword); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("ldap bind: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+func forTenant(baseDN, tenantKey string) string {
+	return strings.ReplaceAll(baseDN, tenantPlaceholder, goldap.EscapeDN(tenantKey))
+}
+
+// normalizeDN makes member values comparable with entry DNs regardless of
+// attribute case and spacing.
+func normalizeDN(dn string) string {
+	parsed, err := goldap.ParseDN(dn)
+	if err != nil {
+		return strings.ToLower(dn)
+	}
+	return strings.ToLower(parsed.String())
+}
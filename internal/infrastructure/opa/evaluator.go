@@ -0,0 +1,130 @@
+package opa
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// query is the document every delivery policy is evaluated for.
+const query = "data.arda.delivery"
+
+// Evaluator implements domain.PolicyEvaluator with the embedded OPA library.
+// Compiled policies are cached per tenant and recompiled when the policy changes.
+type Evaluator struct {
+	timeout time.Duration
+
+	mu    sync.Mutex
+	cache map[string]prepared
+}
+
+type prepared struct {
+	updatedAt time.Time
+	query     rego.PreparedEvalQuery
+}
+
+// NewEvaluator creates an Evaluator. timeout bounds a single evaluation (0 = no bound).
+func NewEvaluator(timeout time.Duration) *Evaluator {
+	return &Evaluator{timeout: timeout, cache: make(map[string]prepared)}
+}
+
+// Validate compiles module and checks it declares the expected package.
+func (e *Evaluator) Validate(module string) error {
+	mod, err := ast.ParseModule("policy.rego", module)
+	if err != nil {
+		return fmt.Errorf("parse policy: %w", err)
+	}
+	if mod == nil || mod.Package.Path.String() != query {
+		return fmt.Errorf("policy must declare package arda.delivery")
+	}
+	_, err = e.prepare(context.Background(), module)
+	return err
+}
+
+// Evaluate runs p against input. Undefined rules keep their defaults:
+// allow = true and all channels.
+func (e *Evaluator) Evaluate(ctx context.Context, p domain.DeliveryPolicy, input domain.PolicyInput) (domain.PolicyDecision, error) {
+	pq, err := e.preparedFor(ctx, p)
+	if err != nil {
+		return domain.PolicyDecision{}, err
+	}
+	if e.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.timeout)
+		defer cancel()
+	}
+
+	rs, err := pq.Eval(ctx, rego.EvalInput(input))
+	if err != nil {
+		return domain.PolicyDecision{}, fmt.Errorf("evaluate policy for %s: %w", p.TenantKey, err)
+	}
+	decision := domain.PolicyDecision{Allow: true}
+	if len(rs) == 0 || len(rs[0].Expressions) == 0 {
+		return decision, nil
+	}
+	doc, ok := rs[0].Expressions[0].Value.(map[string]any)
+	if !ok {
+		return decision, nil
+	}
+	if allow, ok := doc["allow"].(bool); ok {
+		decision.Allow = allow
+	}
+	if reason, ok := doc["reason"].(string); ok {
+		decision.Reason = reason
+	}
+	// Sets and arrays both decode to []any.
+	if chans, ok := doc["channels"].([]any); ok {
+		decision.Channels = make([]domain.Channel, 0, len(chans))
+		for _, c := range chans {
+			if s, ok := c.(string); ok {
+				decision.Channels = append(decision.Channels, domain.Channel(s))
+			}
+		}
+	}
+	return decision, nil
+}
+
+func (e *Evaluator) preparedFor(ctx context.Context, p domain.DeliveryPolicy) (rego.PreparedEvalQuery, error) {
+	e.mu.Lock()
+	cached, ok := e.cache[p.TenantKey]
+	e.mu.Unlock()
+	if ok && cached.updatedAt.Equal(p.UpdatedAt) {
+		return cached.query, nil
+	}
+
+	pq, err := e.prepare(ctx, p.Module)
+	if err != nil {
+		return rego.PreparedEvalQuery{}, fmt.Errorf("compile policy for %s: %w", p.TenantKey, err)
+	}
+	e.mu.Lock()
+	e.cache[p.TenantKey] = prepared{updatedAt: p.UpdatedAt, query: pq}
+	e.mu.Unlock()
+	return pq, nil
+}
+
+// ReclaimTenants drops compiled policies of idle tenants; they are recompiled on next use.
+// This satisfies the application.TenantReclaimer interface.
+func (e *Evaluator) ReclaimTenants(isIdle func(tenantKey string) bool) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	n := 0
+	for tk := range e.cache {
+		if isIdle(tk) {
+			delete(e.cache, tk)
+			n++
+		}
+	}
+	return n
+}
+
+func (e *Evaluator) prepare(ctx context.Context, module string) (rego.PreparedEvalQuery, error) {
+	return rego.New(
+		rego.Query(query),
+		rego.Module("policy.rego", module),
+	).PrepareForEval(ctx)
+}
@@ -0,0 +1,61 @@
+package opa
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"vn.io.arda/notification/internal/domain"
+)
+
+const testModule = `package arda.delivery
+
+import rego.v1
+
+default allow := true
+
+allow := false if input.type == "MARKETING"
+
+channels := {"in_app"} if input.priority == "LOW"
+
+reason := "low priority" if input.priority == "LOW"
+`
+
+func TestEvaluate(t *testing.T) {
+	e := NewEvaluator(time.Second)
+	if err := e.Validate(testModule); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	p := domain.DeliveryPolicy{TenantKey: "acme", Module: testModule, Enabled: true}
+
+	cases := []struct {
+		name     string
+		input    domain.PolicyInput
+		allow    bool
+		channels []domain.Channel
+	}{
+		{"default", domain.PolicyInput{Type: "SYSTEM", Priority: domain.PriorityNormal}, true, nil},
+		{"denied", domain.PolicyInput{Type: "MARKETING", Priority: domain.PriorityNormal}, false, nil},
+		{"narrowed", domain.PolicyInput{Type: "SYSTEM", Priority: domain.PriorityLow}, true, []domain.Channel{domain.ChannelInApp}},
+	}
+	for _, tc := range cases {
+		d, err := e.Evaluate(context.Background(), p, tc.input)
+		if err != nil {
+			t.Fatalf("%s: Evaluate: %v", tc.name, err)
+		}
+		if d.Allow != tc.allow || len(d.Channels) != len(tc.channels) {
+			t.Fatalf("%s: got %+v", tc.name, d)
+		}
+		for i := range tc.channels {
+			if d.Channels[i] != tc.channels[i] {
+				t.Fatalf("%s: got channels %v, want %v", tc.name, d.Channels, tc.channels)
+			}
+		}
+	}
+}
+
+func TestValidateRejectsWrongPackage(t *testing.T) {
+	if err := NewEvaluator(0).Validate("package other\n\nallow := true\n"); err == nil {
+		t.Fatal("expected error for wrong package")
+	}
+}
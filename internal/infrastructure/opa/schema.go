@@ -0,0 +1,76 @@
+package opa
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/storage/inmem"
+	"github.com/open-policy-agent/opa/topdown/cache"
+)
+
+// CompileSchema compiles a JSON Schema document (drafts 4, 6 and 7) into a
+// validator backed by OPA's json.match_schema builtin. The validator returns the
+// violations of a payload, or none when it matches; the error is reserved for
+// evaluation failures. It is safe for concurrent use.
+func CompileSchema(schema []byte) (func(data []byte) ([]string, error), error) {
+	var doc map[string]any
+	if err := json.Unmarshal(schema, &doc); err != nil {
+		return nil, fmt.Errorf("parse schema: %w", err)
+	}
+	ctx := context.Background()
+	store := inmem.NewFromObject(map[string]any{"schema": doc})
+
+	verify, err := rego.New(rego.Query("x := json.verify_schema(data.schema)"), rego.Store(store)).Eval(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("verify schema: %w", err)
+	}
+	if res, ok := result(verify); ok && res[0] != true {
+		return nil, fmt.Errorf("invalid schema: %v", res[1])
+	}
+
+	pq, err := rego.New(rego.Query("x := json.match_schema(input, data.schema)"), rego.Store(store)).PrepareForEval(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("compile schema: %w", err)
+	}
+	// The compiled schema is cached across evaluations instead of being rebuilt per payload.
+	compiled := cache.NewInterQueryValueCache(ctx, nil)
+
+	return func(data []byte) ([]string, error) {
+		var input any
+		if err := json.Unmarshal(data, &input); err != nil {
+			return []string{"payload is not valid JSON: " + err.Error()}, nil
+		}
+		rs, err := pq.Eval(ctx, rego.EvalInput(input), rego.EvalInterQueryBuiltinValueCache(compiled))
+		if err != nil {
+			return nil, fmt.Errorf("match schema: %w", err)
+		}
+		res, ok := result(rs)
+		if !ok {
+			return nil, fmt.Errorf("match schema: no result")
+		}
+		if res[0] == true {
+			return nil, nil
+		}
+		errs, _ := res[1].([]any)
+		violations := make([]string, 0, len(errs))
+		for _, e := range errs {
+			if m, ok := e.(map[string]any); ok {
+				violations = append(violations, fmt.Sprint(m["error"]))
+			}
+		}
+		sort.Strings(violations)
+		return violations, nil
+	}, nil
+}
+
+// result returns the [ok, detail] pair bound to x by a schema query.
+func result(rs rego.ResultSet) ([]any, bool) {
+	if len(rs) == 0 {
+		return nil, false
+	}
+	pair, ok := rs[0].Bindings["x"].([]any)
+	return pair, ok && len(pair) == 2
+}
@@ -0,0 +1,52 @@
+package opa
+
+import (
+	"strings"
+	"testing"
+)
+
+const testSchema = `{
+	"type": "object",
+	"required": ["tenantKey", "payload"],
+	"properties": {
+		"tenantKey": {"type": "string", "minLength": 1},
+		"payload": {"type": "object", "required": ["userId"], "properties": {"userId": {"type": "string"}}}
+	}
+}`
+
+func TestCompileSchema(t *testing.T) {
+	validate, err := CompileSchema([]byte(testSchema))
+	if err != nil {
+		t.Fatalf("CompileSchema: %v", err)
+	}
+
+	cases := []struct {
+		name    string
+		payload string
+		want    string // substring of the violations, "" for a match
+	}{
+		{"valid", `{"tenantKey":"acme","payload":{"userId":"u1"}}`, ""},
+		{"missing field", `{"tenantKey":"acme","payload":{}}`, "userId"},
+		{"wrong type", `{"tenantKey":1,"payload":{"userId":"u1"}}`, "tenantKey"},
+		{"not json", `{"tenantKey":`, "not valid JSON"},
+	}
+	for _, tc := range cases {
+		violations, err := validate([]byte(tc.payload))
+		if err != nil {
+			t.Fatalf("%s: validate: %v", tc.name, err)
+		}
+		got := strings.Join(violations, "; ")
+		if tc.want == "" && got != "" || tc.want != "" && !strings.Contains(got, tc.want) {
+			t.Errorf("%s: violations %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestCompileSchemaRejectsInvalidSchema(t *testing.T) {
+	if _, err := CompileSchema([]byte(`{"type": 5}`)); err == nil {
+		t.Fatal("expected error for invalid schema")
+	}
+	if _, err := CompileSchema([]byte(`not json`)); err == nil {
+		t.Fatal("expected error for non-JSON schema")
+	}
+}
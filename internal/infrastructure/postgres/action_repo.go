@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// ActionRepo implements domain.ActionRepository.
+type ActionRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewActionRepo creates a new ActionRepo.
+func NewActionRepo(pool *pgxpool.Pool) *ActionRepo {
+	return &ActionRepo{pool: pool}
+}
+
+const actionColumns = `id, notification_id, tenant_key, user_id, action_id, type, command, payload, comment, source_event_id, created_at`
+
+// Create inserts a chosen action. A second choice by the same user is ignored.
+func (r *ActionRepo) Create(ctx context.Context, in domain.ActionRecord) (*domain.ActionRecord, error) {
+	var sourceEventID *string
+	if in.SourceEventID != "" {
+		sourceEventID = &in.SourceEventID
+	}
+	var payload []byte
+	if in.Payload != nil {
+		payload, _ = json.Marshal(in.Payload)
+	}
+
+	row := r.pool.QueryRow(ctx, `
+		INSERT INTO notification_actions (notification_id, tenant_key, user_id, action_id, type, command, payload, comment, source_event_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (notification_id, user_id) DO NOTHING
+		RETURNING `+actionColumns,
+		in.NotificationID, in.TenantKey, in.UserID, in.ActionID, string(in.Type), in.Command, payload, in.Comment, sourceEventID)
+
+	saved, err := scanActionRecord(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("insert notification action: %w", err)
+	}
+	return saved, nil
+}
+
+// ListByNotification returns the actions chosen on a notification, oldest first.
+func (r *ActionRepo) ListByNotification(ctx context.Context, notificationID uuid.UUID) ([]domain.ActionRecord, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+actionColumns+`
+		FROM notification_actions
+		WHERE notification_id = $1
+		ORDER BY created_at
+	`, notificationID)
+	if err != nil {
+		return nil, fmt.Errorf("list notification actions: %w", err)
+	}
+	defer rows.Close()
+
+	var results []domain.ActionRecord
+	for rows.Next() {
+		a, err := scanActionRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *a)
+	}
+	return results, rows.Err()
+}
+
+func scanActionRecord(row scannable) (*domain.ActionRecord, error) {
+	var (
+		a             domain.ActionRecord
+		payload       []byte
+		sourceEventID *string
+	)
+	err := row.Scan(&a.ID, &a.NotificationID, &a.TenantKey, &a.UserID, &a.ActionID, &a.Type,
+		&a.Command, &payload, &a.Comment, &sourceEventID, &a.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) > 0 {
+		_ = json.Unmarshal(payload, &a.Payload)
+	}
+	if sourceEventID != nil {
+		a.SourceEventID = *sourceEventID
+	}
+	return &a, nil
+}
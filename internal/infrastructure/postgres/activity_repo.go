@@ -0,0 +1,53 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TenantActivityRepo implements domain.TenantActivityRepository.
+type TenantActivityRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewTenantActivityRepo creates a new TenantActivityRepo.
+func NewTenantActivityRepo(pool *pgxpool.Pool) *TenantActivityRepo {
+	return &TenantActivityRepo{pool: pool}
+}
+
+func (r *TenantActivityRepo) Touch(ctx context.Context, lastActive map[string]time.Time) error {
+	if len(lastActive) == 0 {
+		return nil
+	}
+	tenants := make([]string, 0, len(lastActive))
+	times := make([]time.Time, 0, len(lastActive))
+	for tk, at := range lastActive {
+		tenants = append(tenants, tk)
+		times = append(times, at)
+	}
+	if _, err := r.pool.Exec(ctx, `
+		INSERT INTO tenant_activity (tenant_key, last_active_at)
+		SELECT * FROM unnest($1::text[], $2::timestamptz[])
+		ON CONFLICT (tenant_key) DO UPDATE
+		SET last_active_at = GREATEST(tenant_activity.last_active_at, EXCLUDED.last_active_at)
+	`, tenants, times); err != nil {
+		return fmt.Errorf("touch tenant activity: %w", err)
+	}
+	return nil
+}
+
+func (r *TenantActivityRepo) ActiveSince(ctx context.Context, since time.Time) ([]string, error) {
+	rows, err := r.pool.Query(ctx, `SELECT tenant_key FROM tenant_activity WHERE last_active_at >= $1`, since)
+	if err != nil {
+		return nil, fmt.Errorf("list active tenants: %w", err)
+	}
+	tenants, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("list active tenants: %w", err)
+	}
+	return tenants, nil
+}
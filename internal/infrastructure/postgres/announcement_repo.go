@@ -0,0 +1,144 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// AnnouncementRepo implements domain.AnnouncementRepository.
+type AnnouncementRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewAnnouncementRepo creates a new AnnouncementRepo.
+func NewAnnouncementRepo(pool *pgxpool.Pool) *AnnouncementRepo {
+	return &AnnouncementRepo{pool: pool}
+}
+
+const announcementColumns = `id, tenant_key, title, body, severity, starts_at, ends_at, created_by, created_at, updated_at`
+
+func (r *AnnouncementRepo) ListActive(ctx context.Context, tenantKey, userID string, now time.Time) ([]domain.Announcement, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+announcementColumns+` FROM announcements a
+		WHERE a.tenant_key IN ('', $1)
+		  AND a.starts_at <= $3 AND (a.ends_at IS NULL OR a.ends_at > $3)
+		  AND NOT EXISTS (
+			SELECT 1 FROM announcement_dismissals d
+			WHERE d.announcement_id = a.id AND d.tenant_key = $1 AND d.user_id = $2)
+		ORDER BY a.starts_at DESC, a.id DESC
+	`, tenantKey, userID, now)
+	if err != nil {
+		return nil, fmt.Errorf("list active announcements: %w", err)
+	}
+	return scanAnnouncements(rows)
+}
+
+func (r *AnnouncementRepo) List(ctx context.Context, tenantKey string) ([]domain.Announcement, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+announcementColumns+` FROM announcements
+		WHERE $1 = '' OR tenant_key IN ('', $1)
+		ORDER BY starts_at DESC, id DESC
+	`, tenantKey)
+	if err != nil {
+		return nil, fmt.Errorf("list announcements: %w", err)
+	}
+	return scanAnnouncements(rows)
+}
+
+func (r *AnnouncementRepo) Get(ctx context.Context, id uuid.UUID) (*domain.Announcement, error) {
+	a, err := scanAnnouncement(r.pool.QueryRow(ctx, `SELECT `+announcementColumns+` FROM announcements WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get announcement: %w", err)
+	}
+	return a, nil
+}
+
+func (r *AnnouncementRepo) Create(ctx context.Context, a domain.Announcement) (*domain.Announcement, error) {
+	row := r.pool.QueryRow(ctx, `
+		INSERT INTO announcements (tenant_key, title, body, severity, starts_at, ends_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+announcementColumns,
+		a.TenantKey, a.Title, a.Body, string(a.Severity), a.StartsAt, a.EndsAt, a.CreatedBy)
+	saved, err := scanAnnouncement(row)
+	if err != nil {
+		return nil, fmt.Errorf("create announcement: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *AnnouncementRepo) Update(ctx context.Context, a domain.Announcement) (*domain.Announcement, error) {
+	row := r.pool.QueryRow(ctx, `
+		UPDATE announcements SET
+			title      = $2,
+			body       = $3,
+			severity   = $4,
+			starts_at  = $5,
+			ends_at    = $6,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+announcementColumns,
+		a.ID, a.Title, a.Body, string(a.Severity), a.StartsAt, a.EndsAt)
+	saved, err := scanAnnouncement(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("announcement not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("update announcement: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *AnnouncementRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM announcements WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete announcement: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("announcement not found")
+	}
+	return nil
+}
+
+func (r *AnnouncementRepo) Dismiss(ctx context.Context, id uuid.UUID, tenantKey, userID string) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO announcement_dismissals (announcement_id, tenant_key, user_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING
+	`, id, tenantKey, userID)
+	if err != nil {
+		return fmt.Errorf("dismiss announcement: %w", err)
+	}
+	return nil
+}
+
+func scanAnnouncements(rows pgx.Rows) ([]domain.Announcement, error) {
+	defer rows.Close()
+	var results []domain.Announcement
+	for rows.Next() {
+		a, err := scanAnnouncement(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *a)
+	}
+	return results, rows.Err()
+}
+
+func scanAnnouncement(row scannable) (*domain.Announcement, error) {
+	var a domain.Announcement
+	if err := row.Scan(&a.ID, &a.TenantKey, &a.Title, &a.Body, &a.Severity, &a.StartsAt, &a.EndsAt,
+		&a.CreatedBy, &a.CreatedAt, &a.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
@@ -0,0 +1,116 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// archiveUsersPerRun bounds how many over-cap users one ArchiveOverflow call visits.
+const archiveUsersPerRun = 100
+
+// ArchiveOverflow moves each user's notifications beyond their newest keep rows
+// into notifications_archive, at most limit rows per call. Rows with a pending
+// outbox entry, a reaction or a chosen action, and snoozed or pinned rows, stay hot
+// until those are gone.
+func (r *Repository) ArchiveOverflow(ctx context.Context, keep, limit int) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `
+		WITH over AS (
+			SELECT tenant_key, user_id FROM notifications
+			GROUP BY tenant_key, user_id
+			HAVING COUNT(*) > $1
+			LIMIT $3
+		), victims AS (
+			SELECT v.id FROM over o
+			CROSS JOIN LATERAL (
+				SELECT id, snoozed_until, pinned FROM notifications
+				WHERE tenant_key = o.tenant_key AND user_id = o.user_id
+				ORDER BY created_at DESC, id DESC
+				OFFSET $1
+			) v
+			WHERE v.snoozed_until IS NULL AND NOT v.pinned
+				AND NOT EXISTS (SELECT 1 FROM delivery_outbox d WHERE d.notification_id = v.id)
+				AND NOT EXISTS (SELECT 1 FROM notification_reactions x WHERE x.notification_id = v.id)
+				AND NOT EXISTS (SELECT 1 FROM notification_actions a WHERE a.notification_id = v.id)
+			LIMIT $2
+		), moved AS (
+			DELETE FROM notifications WHERE id IN (SELECT id FROM victims)
+			RETURNING `+notificationColumns+`
+		)
+		INSERT INTO notifications_archive (`+notificationColumns+`)
+		SELECT `+notificationColumns+` FROM moved
+		ON CONFLICT (id) DO NOTHING
+	`, keep, limit, archiveUsersPerRun)
+	if err != nil {
+		return 0, fmt.Errorf("archive overflow notifications: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// listArchived pages a user's archived notifications matching f, newest first.
+// Archived rows are never pinned.
+func (r *Repository) listArchived(ctx context.Context, db querier, f domain.NotificationFilter, offset, limit int) ([]*domain.Notification, error) {
+	if f.Pinned != nil {
+		if *f.Pinned {
+			return nil, nil
+		}
+		f.Pinned = nil
+	}
+	conditions, args := inboxConditions(f, []any{f.TenantKey, f.UserID})
+	query := `
+		SELECT ` + notificationColumns + `
+		FROM notifications_archive
+		WHERE tenant_key = $1 AND user_id = $2` + conditions +
+		fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+	args = append(args, limit, offset)
+
+	results, err := r.queryNotifications(ctx, db, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list archived notifications: %w", err)
+	}
+	return results, nil
+}
+
+// countInbox counts the hot inbox rows matching f.
+func (r *Repository) countInbox(ctx context.Context, db querier, f domain.NotificationFilter) (int, error) {
+	conditions, args := inboxConditions(f, []any{f.TenantKey, f.UserID})
+	var count int
+	if err := db.QueryRow(ctx, `SELECT COUNT(*) FROM `+inboxSource+` WHERE TRUE`+conditions, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count inbox: %w", err)
+	}
+	return count, nil
+}
+
+// markArchivedRead marks an archived notification as read.
+func (r *Repository) markArchivedRead(ctx context.Context, id uuid.UUID, tenantKey, userID string, at time.Time) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		WITH up AS (
+			UPDATE notifications_archive SET is_read = TRUE, read_at = $1
+			WHERE id = $2 AND tenant_key = $3 AND user_id = $4 AND is_read = FALSE
+			RETURNING id, tenant_key, user_id, read_at
+		)
+		`+stateEventInsert(domain.StateRead, "up", "read_at"), at, id, tenantKey, userID)
+	if err != nil {
+		return false, fmt.Errorf("mark archived read: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// deleteArchived removes an archived notification, keeping a tombstone for as-of listing.
+func (r *Repository) deleteArchived(ctx context.Context, id uuid.UUID, tenantKey, userID string, at time.Time) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		WITH del AS (
+			DELETE FROM notifications_archive WHERE id = $1 AND tenant_key = $2 AND user_id = $3
+			RETURNING *
+		), ev AS (
+			`+stateEventInsert(domain.StateDeleted, "del", "$4::timestamptz")+`
+		)
+		`+tombstoneInsert("deleted", "$4::timestamptz"), id, tenantKey, userID, at)
+	if err != nil {
+		return false, fmt.Errorf("delete archived notification: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
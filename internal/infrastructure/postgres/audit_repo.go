@@ -0,0 +1,158 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// auditInsert appends an action entry by actor for each row of from, which must
+// expose id, tenant_key, user_id and source_event_id; at is the SQL expression
+// for occurred_at.
+func auditInsert(action domain.AuditAction, actor, from, at string) string {
+	return `INSERT INTO notification_audit (notification_id, tenant_key, user_id, action, actor, source_event_id, occurred_at)
+		SELECT id, tenant_key, user_id, '` + string(action) + `', '` + actor + `', source_event_id, ` + at + `
+		FROM ` + from
+}
+
+// auditedPurge turns del, a "DELETE FROM table n ..." statement, into one that
+// also leaves a purged entry by actor for every deleted row. RowsAffected still
+// counts the deleted rows.
+func auditedPurge(table, del, actor string) string {
+	user := "n.user_id"
+	if table == "broadcast_notifications" {
+		user = "''"
+	}
+	return `WITH del AS (` + del + `
+			RETURNING n.id, COALESCE(n.tenant_key, '') AS tenant_key, ` + user + ` AS user_id, n.source_event_id
+		)
+		` + auditInsert(domain.AuditPurged, actor, "del", "NOW()")
+}
+
+// AuditRepo implements domain.AuditRepository.
+type AuditRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewAuditRepo creates a new AuditRepo.
+func NewAuditRepo(pool *pgxpool.Pool) *AuditRepo {
+	return &AuditRepo{pool: pool}
+}
+
+const auditColumns = `id, notification_id, tenant_key, user_id, action, actor, channel, source_event_id, data, occurred_at`
+
+func (r *AuditRepo) Record(ctx context.Context, entries []domain.AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	var (
+		ids      = make([]string, len(entries))
+		tenants  = make([]string, len(entries))
+		users    = make([]string, len(entries))
+		actions  = make([]string, len(entries))
+		actors   = make([]string, len(entries))
+		channels = make([]string, len(entries))
+		sources  = make([]*string, len(entries))
+		data     = make([]*string, len(entries))
+		occurred = make([]time.Time, len(entries))
+		now      = time.Now()
+	)
+	for i, e := range entries {
+		ids[i], tenants[i], users[i] = e.NotificationID.String(), e.TenantKey, e.UserID
+		actions[i], actors[i], channels[i] = string(e.Action), e.Actor, string(e.Channel)
+		if e.SourceEventID != "" {
+			sources[i] = &entries[i].SourceEventID
+		}
+		if e.Data != nil {
+			b, _ := json.Marshal(e.Data)
+			s := string(b)
+			data[i] = &s
+		}
+		occurred[i] = e.OccurredAt
+		if occurred[i].IsZero() {
+			occurred[i] = now
+		}
+	}
+	if _, err := r.pool.Exec(ctx, `
+		INSERT INTO notification_audit (notification_id, tenant_key, user_id, action, actor, channel, source_event_id, data, occurred_at)
+		SELECT id::uuid, tenant_key, user_id, action, actor, channel, source_event_id, data::jsonb, occurred_at
+		FROM unnest($1::text[], $2::text[], $3::text[], $4::text[], $5::text[], $6::text[], $7::text[], $8::text[], $9::timestamptz[])
+			AS t(id, tenant_key, user_id, action, actor, channel, source_event_id, data, occurred_at)
+	`, ids, tenants, users, actions, actors, channels, sources, data, occurred); err != nil {
+		return fmt.Errorf("record notification audit: %w", err)
+	}
+	return nil
+}
+
+func (r *AuditRepo) List(ctx context.Context, f domain.AuditFilter) ([]domain.AuditEntry, error) {
+	conds := []string{"tenant_key = $1"}
+	args := []any{f.TenantKey}
+	add := func(cond string, v any) {
+		args = append(args, v)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if f.NotificationID != nil {
+		add("notification_id = $%d", *f.NotificationID)
+	}
+	if f.UserID != "" {
+		add("user_id = $%d", f.UserID)
+	}
+	if f.SourceEventID != "" {
+		add("source_event_id = $%d", f.SourceEventID)
+	}
+	if f.Action != "" {
+		add("action = $%d", string(f.Action))
+	}
+	if f.From != nil {
+		add("occurred_at >= $%d", *f.From)
+	}
+	if f.To != nil {
+		add("occurred_at < $%d", *f.To)
+	}
+	args = append(args, f.Limit, f.Offset)
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+auditColumns+`
+		FROM notification_audit
+		WHERE `+strings.Join(conds, " AND ")+fmt.Sprintf(`
+		ORDER BY occurred_at DESC, id DESC
+		LIMIT $%d OFFSET $%d`, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("list notification audit: %w", err)
+	}
+	defer rows.Close()
+
+	var results []domain.AuditEntry
+	for rows.Next() {
+		var (
+			e             domain.AuditEntry
+			sourceEventID *string
+			dataJSON      []byte
+		)
+		if err := rows.Scan(&e.ID, &e.NotificationID, &e.TenantKey, &e.UserID, &e.Action, &e.Actor,
+			&e.Channel, &sourceEventID, &dataJSON, &e.OccurredAt); err != nil {
+			return nil, err
+		}
+		if sourceEventID != nil {
+			e.SourceEventID = *sourceEventID
+		}
+		if len(dataJSON) > 0 {
+			_ = json.Unmarshal(dataJSON, &e.Data)
+		}
+		results = append(results, e)
+	}
+	return results, rows.Err()
+}
+
+func (r *AuditRepo) PurgeBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM notification_audit WHERE occurred_at < $1`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("purge notification audit: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,143 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// ChatConnectorRepo implements domain.ChatConnectorRepository.
+type ChatConnectorRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewChatConnectorRepo creates a new ChatConnectorRepo.
+func NewChatConnectorRepo(pool *pgxpool.Pool) *ChatConnectorRepo {
+	return &ChatConnectorRepo{pool: pool}
+}
+
+const chatConnectorColumns = `id, tenant_key, name, provider, url, types, template, active, created_at, updated_at`
+
+func (r *ChatConnectorRepo) List(ctx context.Context, tenantKey string) ([]domain.ChatConnector, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+chatConnectorColumns+` FROM chat_connectors WHERE tenant_key = $1 ORDER BY created_at`, tenantKey)
+	if err != nil {
+		return nil, fmt.Errorf("list chat connectors: %w", err)
+	}
+	results, err := scanChatConnectors(rows)
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].URL = domain.MaskChatURL(results[i].URL)
+	}
+	return results, nil
+}
+
+func (r *ChatConnectorRepo) ListForType(ctx context.Context, tenantKey string, notifType domain.NotificationType) ([]domain.ChatConnector, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+chatConnectorColumns+` FROM chat_connectors
+		WHERE active AND ($1 = '' OR tenant_key = $1) AND $2 = ANY(types)
+	`, tenantKey, string(notifType))
+	if err != nil {
+		return nil, fmt.Errorf("list chat connectors for type: %w", err)
+	}
+	return scanChatConnectors(rows)
+}
+
+func (r *ChatConnectorRepo) Get(ctx context.Context, tenantKey string, id uuid.UUID) (*domain.ChatConnector, error) {
+	row := r.pool.QueryRow(ctx, `SELECT `+chatConnectorColumns+` FROM chat_connectors WHERE id = $1 AND tenant_key = $2`, id, tenantKey)
+	c, err := scanChatConnector(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get chat connector: %w", err)
+	}
+	return c, nil
+}
+
+func (r *ChatConnectorRepo) Create(ctx context.Context, c domain.ChatConnector) (*domain.ChatConnector, error) {
+	row := r.pool.QueryRow(ctx, `
+		INSERT INTO chat_connectors (tenant_key, name, provider, url, types, template, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+chatConnectorColumns,
+		c.TenantKey, c.Name, string(c.Provider), c.URL, notificationTypeStrings(c.Types), c.Template, c.Active)
+	saved, err := scanChatConnector(row)
+	if err != nil {
+		return nil, fmt.Errorf("create chat connector: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *ChatConnectorRepo) Update(ctx context.Context, c domain.ChatConnector) (*domain.ChatConnector, error) {
+	row := r.pool.QueryRow(ctx, `
+		UPDATE chat_connectors SET
+			name       = $3,
+			url        = COALESCE(NULLIF($4, ''), url),
+			types      = $5,
+			template   = $6,
+			active     = $7,
+			updated_at = NOW()
+		WHERE id = $1 AND tenant_key = $2
+		RETURNING `+chatConnectorColumns,
+		c.ID, c.TenantKey, c.Name, c.URL, notificationTypeStrings(c.Types), c.Template, c.Active)
+	saved, err := scanChatConnector(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("chat connector not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("update chat connector: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *ChatConnectorRepo) Delete(ctx context.Context, tenantKey string, id uuid.UUID) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM chat_connectors WHERE id = $1 AND tenant_key = $2`, id, tenantKey)
+	if err != nil {
+		return fmt.Errorf("delete chat connector: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("chat connector not found")
+	}
+	return nil
+}
+
+func scanChatConnectors(rows pgx.Rows) ([]domain.ChatConnector, error) {
+	defer rows.Close()
+	var results []domain.ChatConnector
+	for rows.Next() {
+		c, err := scanChatConnector(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *c)
+	}
+	return results, rows.Err()
+}
+
+func scanChatConnector(row scannable) (*domain.ChatConnector, error) {
+	var (
+		c     domain.ChatConnector
+		types []string
+	)
+	if err := row.Scan(&c.ID, &c.TenantKey, &c.Name, &c.Provider, &c.URL, &types, &c.Template, &c.Active, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		return nil, err
+	}
+	for _, t := range types {
+		c.Types = append(c.Types, domain.NotificationType(t))
+	}
+	return &c, nil
+}
+
+func notificationTypeStrings(types []domain.NotificationType) []string {
+	out := make([]string, len(types))
+	for i, t := range types {
+		out[i] = string(t)
+	}
+	return out
+}
@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// ConsumerPauseRepo implements domain.ConsumerPauseRepository.
+type ConsumerPauseRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewConsumerPauseRepo creates a new ConsumerPauseRepo.
+func NewConsumerPauseRepo(pool *pgxpool.Pool) *ConsumerPauseRepo {
+	return &ConsumerPauseRepo{pool: pool}
+}
+
+const consumerPauseColumns = `topic, reason, paused_by, paused_at`
+
+func (r *ConsumerPauseRepo) List(ctx context.Context) ([]domain.ConsumerPause, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+consumerPauseColumns+` FROM consumer_pauses ORDER BY topic`)
+	if err != nil {
+		return nil, fmt.Errorf("list consumer pauses: %w", err)
+	}
+	defer rows.Close()
+
+	var results []domain.ConsumerPause
+	for rows.Next() {
+		p, err := scanConsumerPause(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *p)
+	}
+	return results, rows.Err()
+}
+
+func (r *ConsumerPauseRepo) Pause(ctx context.Context, p domain.ConsumerPause) (*domain.ConsumerPause, error) {
+	row := r.pool.QueryRow(ctx, `
+		INSERT INTO consumer_pauses (topic, reason, paused_by)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (topic) DO UPDATE SET reason = EXCLUDED.reason
+		RETURNING `+consumerPauseColumns, p.Topic, p.Reason, p.PausedBy)
+	saved, err := scanConsumerPause(row)
+	if err != nil {
+		return nil, fmt.Errorf("pause consumer topic: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *ConsumerPauseRepo) Resume(ctx context.Context, topic string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM consumer_pauses WHERE topic = $1`, topic)
+	if err != nil {
+		return false, fmt.Errorf("resume consumer topic: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func scanConsumerPause(row scannable) (*domain.ConsumerPause, error) {
+	var p domain.ConsumerPause
+	if err := row.Scan(&p.Topic, &p.Reason, &p.PausedBy, &p.PausedAt); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// contentBatchSize bounds the rows read and rewritten per round trip by
+// RewriteContent.
+const contentBatchSize = 500
+
+// contentTables hold notification content owned by a tenant.
+var contentTables = []string{"notifications", "notifications_archive", "broadcast_notifications"}
+
+// ContentRepo implements domain.ContentRepository.
+type ContentRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewContentRepo creates a new ContentRepo.
+func NewContentRepo(pool *pgxpool.Pool) *ContentRepo {
+	return &ContentRepo{pool: pool}
+}
+
+// RewriteContent walks each content table of the tenant by ID in batches, so
+// rows written meanwhile are either visited or already use the current content
+// format. Platform broadcasts are not visited: they are never encrypted.
+func (r *ContentRepo) RewriteContent(ctx context.Context, tenantKey string, fn func(*domain.StoredContent) (bool, error)) (int64, error) {
+	var saved int64
+	for _, table := range contentTables {
+		after := uuid.Nil
+		for {
+			rows, err := r.pool.Query(ctx, `
+				SELECT id, title, body, metadata FROM `+table+`
+				WHERE tenant_key = $1 AND id > $2
+				ORDER BY id LIMIT $3`, tenantKey, after, contentBatchSize)
+			if err != nil {
+				return saved, fmt.Errorf("read %s content: %w", table, err)
+			}
+			var changed []*domain.StoredContent
+			n := 0
+			for rows.Next() {
+				c := &domain.StoredContent{TenantKey: tenantKey}
+				if err := rows.Scan(&c.ID, &c.Title, &c.Body, &c.Metadata); err != nil {
+					rows.Close()
+					return saved, fmt.Errorf("scan %s content: %w", table, err)
+				}
+				n++
+				after = c.ID
+				ok, err := fn(c)
+				if err != nil {
+					rows.Close()
+					return saved, err
+				}
+				if ok {
+					changed = append(changed, c)
+				}
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return saved, fmt.Errorf("read %s content: %w", table, err)
+			}
+
+			if len(changed) > 0 {
+				batch := &pgx.Batch{}
+				for _, c := range changed {
+					batch.Queue(`UPDATE `+table+` SET title = $3, body = $4, metadata = $5
+						WHERE id = $1 AND tenant_key = $2`, c.ID, tenantKey, c.Title, c.Body, c.Metadata)
+				}
+				if err := r.pool.SendBatch(ctx, batch).Close(); err != nil {
+					return saved, fmt.Errorf("rewrite %s content: %w", table, err)
+				}
+				saved += int64(len(changed))
+			}
+			if n < contentBatchSize {
+				break
+			}
+		}
+	}
+	return saved, nil
+}
@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// CustomTypeRepo implements domain.CustomTypeRepository.
+type CustomTypeRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewCustomTypeRepo creates a new CustomTypeRepo.
+func NewCustomTypeRepo(pool *pgxpool.Pool) *CustomTypeRepo {
+	return &CustomTypeRepo{pool: pool}
+}
+
+const customTypeColumns = `tenant_key, type, name, icon, default_priority, retention_days, created_at, updated_at`
+
+func (r *CustomTypeRepo) Get(ctx context.Context, tenantKey string, t domain.NotificationType) (*domain.CustomType, error) {
+	row := r.pool.QueryRow(ctx, `SELECT `+customTypeColumns+` FROM notification_types WHERE tenant_key = $1 AND type = $2`,
+		tenantKey, string(t))
+	ct, err := scanCustomType(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get notification type: %w", err)
+	}
+	return ct, nil
+}
+
+func (r *CustomTypeRepo) List(ctx context.Context, tenantKey string) ([]domain.CustomType, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+customTypeColumns+` FROM notification_types WHERE tenant_key = $1 ORDER BY type`, tenantKey)
+	if err != nil {
+		return nil, fmt.Errorf("list notification types: %w", err)
+	}
+	defer rows.Close()
+
+	var results []domain.CustomType
+	for rows.Next() {
+		ct, err := scanCustomType(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *ct)
+	}
+	return results, rows.Err()
+}
+
+func (r *CustomTypeRepo) Upsert(ctx context.Context, ct domain.CustomType) (*domain.CustomType, error) {
+	row := r.pool.QueryRow(ctx, `
+		INSERT INTO notification_types (tenant_key, type, name, icon, default_priority, retention_days)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (tenant_key, type) DO UPDATE SET
+			name             = EXCLUDED.name,
+			icon             = EXCLUDED.icon,
+			default_priority = EXCLUDED.default_priority,
+			retention_days   = EXCLUDED.retention_days,
+			updated_at       = NOW()
+		RETURNING `+customTypeColumns,
+		ct.TenantKey, string(ct.Type), ct.Name, ct.Icon, string(ct.DefaultPriority), ct.RetentionDays)
+	saved, err := scanCustomType(row)
+	if err != nil {
+		return nil, fmt.Errorf("upsert notification type: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *CustomTypeRepo) Delete(ctx context.Context, tenantKey string, t domain.NotificationType) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM notification_types WHERE tenant_key = $1 AND type = $2`, tenantKey, string(t))
+	return err
+}
+
+func scanCustomType(row scannable) (*domain.CustomType, error) {
+	var ct domain.CustomType
+	if err := row.Scan(&ct.TenantKey, &ct.Type, &ct.Name, &ct.Icon, &ct.DefaultPriority, &ct.RetentionDays,
+		&ct.CreatedAt, &ct.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &ct, nil
+}
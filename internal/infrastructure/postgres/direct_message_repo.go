@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// DirectMessageRuleRepo implements domain.DirectMessageRuleRepository.
+type DirectMessageRuleRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewDirectMessageRuleRepo creates a new DirectMessageRuleRepo.
+func NewDirectMessageRuleRepo(pool *pgxpool.Pool) *DirectMessageRuleRepo {
+	return &DirectMessageRuleRepo{pool: pool}
+}
+
+const directRuleColumns = `tenant_key, enabled, sender_roles, max_recipients, per_minute, updated_at`
+
+func (r *DirectMessageRuleRepo) Get(ctx context.Context, tenantKey string) (*domain.DirectMessageRule, error) {
+	row := r.pool.QueryRow(ctx, `SELECT `+directRuleColumns+` FROM direct_message_rules WHERE tenant_key = $1`, tenantKey)
+	rule, err := scanDirectRule(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get direct message rule: %w", err)
+	}
+	return rule, nil
+}
+
+func (r *DirectMessageRuleRepo) List(ctx context.Context) ([]domain.DirectMessageRule, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+directRuleColumns+` FROM direct_message_rules ORDER BY tenant_key`)
+	if err != nil {
+		return nil, fmt.Errorf("list direct message rules: %w", err)
+	}
+	defer rows.Close()
+
+	var results []domain.DirectMessageRule
+	for rows.Next() {
+		rule, err := scanDirectRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *rule)
+	}
+	return results, rows.Err()
+}
+
+func (r *DirectMessageRuleRepo) Upsert(ctx context.Context, rule domain.DirectMessageRule) (*domain.DirectMessageRule, error) {
+	roles := rule.SenderRoles
+	if roles == nil {
+		roles = []string{}
+	}
+	row := r.pool.QueryRow(ctx, `
+		INSERT INTO direct_message_rules (tenant_key, enabled, sender_roles, max_recipients, per_minute)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (tenant_key) DO UPDATE SET
+			enabled        = EXCLUDED.enabled,
+			sender_roles   = EXCLUDED.sender_roles,
+			max_recipients = EXCLUDED.max_recipients,
+			per_minute     = EXCLUDED.per_minute,
+			updated_at     = NOW()
+		RETURNING `+directRuleColumns, rule.TenantKey, rule.Enabled, roles, rule.MaxRecipients, rule.PerMinute)
+	saved, err := scanDirectRule(row)
+	if err != nil {
+		return nil, fmt.Errorf("upsert direct message rule: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *DirectMessageRuleRepo) Delete(ctx context.Context, tenantKey string) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM direct_message_rules WHERE tenant_key = $1`, tenantKey)
+	return err
+}
+
+func scanDirectRule(row scannable) (*domain.DirectMessageRule, error) {
+	var rule domain.DirectMessageRule
+	if err := row.Scan(&rule.TenantKey, &rule.Enabled, &rule.SenderRoles, &rule.MaxRecipients, &rule.PerMinute, &rule.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// EncryptionKeyRepo implements domain.EncryptionKeyRepository.
+type EncryptionKeyRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewEncryptionKeyRepo creates a new EncryptionKeyRepo.
+func NewEncryptionKeyRepo(pool *pgxpool.Pool) *EncryptionKeyRepo {
+	return &EncryptionKeyRepo{pool: pool}
+}
+
+const encryptionKeyColumns = `tenant_key, key_ref, created_at, updated_at`
+
+func (r *EncryptionKeyRepo) Get(ctx context.Context, tenantKey string) (*domain.TenantEncryptionKey, error) {
+	row := r.pool.QueryRow(ctx, `SELECT `+encryptionKeyColumns+` FROM tenant_encryption_keys WHERE tenant_key = $1`, tenantKey)
+	k, err := scanEncryptionKey(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get tenant encryption key: %w", err)
+	}
+	return k, nil
+}
+
+func (r *EncryptionKeyRepo) List(ctx context.Context) ([]domain.TenantEncryptionKey, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+encryptionKeyColumns+` FROM tenant_encryption_keys ORDER BY tenant_key`)
+	if err != nil {
+		return nil, fmt.Errorf("list tenant encryption keys: %w", err)
+	}
+	defer rows.Close()
+
+	var results []domain.TenantEncryptionKey
+	for rows.Next() {
+		k, err := scanEncryptionKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *k)
+	}
+	return results, rows.Err()
+}
+
+func (r *EncryptionKeyRepo) Upsert(ctx context.Context, k domain.TenantEncryptionKey) (*domain.TenantEncryptionKey, error) {
+	row := r.pool.QueryRow(ctx, `
+		INSERT INTO tenant_encryption_keys (tenant_key, key_ref)
+		VALUES ($1, $2)
+		ON CONFLICT (tenant_key) DO UPDATE SET
+			key_ref    = EXCLUDED.key_ref,
+			updated_at = NOW()
+		RETURNING `+encryptionKeyColumns, k.TenantKey, k.KeyRef)
+	saved, err := scanEncryptionKey(row)
+	if err != nil {
+		return nil, fmt.Errorf("upsert tenant encryption key: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *EncryptionKeyRepo) Delete(ctx context.Context, tenantKey string) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM tenant_encryption_keys WHERE tenant_key = $1`, tenantKey)
+	return err
+}
+
+func scanEncryptionKey(row scannable) (*domain.TenantEncryptionKey, error) {
+	var k domain.TenantEncryptionKey
+	if err := row.Scan(&k.TenantKey, &k.KeyRef, &k.CreatedAt, &k.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
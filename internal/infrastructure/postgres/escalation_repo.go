@@ -0,0 +1,179 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// EscalationRepo implements domain.EscalationRepository.
+type EscalationRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewEscalationRepo creates a new EscalationRepo.
+func NewEscalationRepo(pool *pgxpool.Pool) *EscalationRepo {
+	return &EscalationRepo{pool: pool}
+}
+
+const escalationRuleColumns = `id, tenant_key, type, priority, after_seconds, action, role, active, created_at, updated_at`
+
+func (r *EscalationRepo) ListRules(ctx context.Context, tenantKey string) ([]domain.EscalationRule, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+escalationRuleColumns+` FROM escalation_rules WHERE tenant_key = $1 ORDER BY after_seconds, created_at`, tenantKey)
+	if err != nil {
+		return nil, fmt.Errorf("list escalation rules: %w", err)
+	}
+	defer rows.Close()
+	var results []domain.EscalationRule
+	for rows.Next() {
+		rule, err := scanEscalationRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *rule)
+	}
+	return results, rows.Err()
+}
+
+func (r *EscalationRepo) GetRule(ctx context.Context, tenantKey string, id uuid.UUID) (*domain.EscalationRule, error) {
+	row := r.pool.QueryRow(ctx, `SELECT `+escalationRuleColumns+` FROM escalation_rules WHERE id = $1 AND tenant_key = $2`, id, tenantKey)
+	rule, err := scanEscalationRule(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get escalation rule: %w", err)
+	}
+	return rule, nil
+}
+
+func (r *EscalationRepo) CreateRule(ctx context.Context, rule domain.EscalationRule) (*domain.EscalationRule, error) {
+	row := r.pool.QueryRow(ctx, `
+		INSERT INTO escalation_rules (tenant_key, type, priority, after_seconds, action, role, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+escalationRuleColumns,
+		rule.TenantKey, string(rule.Type), string(rule.Priority), rule.AfterSeconds, string(rule.Action), rule.Role, rule.Active)
+	saved, err := scanEscalationRule(row)
+	if err != nil {
+		return nil, fmt.Errorf("create escalation rule: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *EscalationRepo) UpdateRule(ctx context.Context, rule domain.EscalationRule) (*domain.EscalationRule, error) {
+	row := r.pool.QueryRow(ctx, `
+		UPDATE escalation_rules SET
+			type          = $3,
+			priority      = $4,
+			after_seconds = $5,
+			action        = $6,
+			role          = $7,
+			active        = $8,
+			updated_at    = NOW()
+		WHERE id = $1 AND tenant_key = $2
+		RETURNING `+escalationRuleColumns,
+		rule.ID, rule.TenantKey, string(rule.Type), string(rule.Priority), rule.AfterSeconds, string(rule.Action), rule.Role, rule.Active)
+	saved, err := scanEscalationRule(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("escalation rule not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("update escalation rule: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *EscalationRepo) DeleteRule(ctx context.Context, tenantKey string, id uuid.UUID) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM escalation_rules WHERE id = $1 AND tenant_key = $2`, id, tenantKey)
+	if err != nil {
+		return fmt.Errorf("delete escalation rule: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("escalation rule not found")
+	}
+	return nil
+}
+
+func (r *EscalationRepo) Schedule(ctx context.Context, escalations []domain.Escalation) error {
+	if len(escalations) == 0 {
+		return nil
+	}
+	var (
+		ids     = make([]uuid.UUID, len(escalations))
+		rules   = make([]uuid.UUID, len(escalations))
+		tenants = make([]string, len(escalations))
+		users   = make([]string, len(escalations))
+		due     = make([]time.Time, len(escalations))
+	)
+	for i, e := range escalations {
+		ids[i], rules[i], tenants[i], users[i], due[i] = e.NotificationID, e.RuleID, e.TenantKey, e.UserID, e.DueAt
+	}
+	if _, err := r.pool.Exec(ctx, `
+		INSERT INTO notification_escalations (notification_id, rule_id, tenant_key, user_id, due_at)
+		SELECT * FROM unnest($1::uuid[], $2::uuid[], $3::text[], $4::text[], $5::timestamptz[])
+		ON CONFLICT DO NOTHING
+	`, ids, rules, tenants, users, due); err != nil {
+		return fmt.Errorf("schedule escalations: %w", err)
+	}
+	return nil
+}
+
+func (r *EscalationRepo) ClaimDue(ctx context.Context, now time.Time, limit int) ([]domain.Escalation, error) {
+	rows, err := r.pool.Query(ctx, `
+		WITH due AS (
+			SELECT notification_id, rule_id FROM notification_escalations
+			WHERE due_at <= $1
+			ORDER BY due_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		), claimed AS (
+			DELETE FROM notification_escalations e
+			USING due
+			WHERE e.notification_id = due.notification_id AND e.rule_id = due.rule_id
+			RETURNING e.notification_id, e.tenant_key, e.user_id, e.rule_id, e.due_at
+		)
+		SELECT c.notification_id, c.tenant_key, c.user_id, c.rule_id, c.due_at
+		FROM claimed c
+		JOIN notifications n ON n.id = c.notification_id
+		WHERE NOT n.is_read AND (n.snoozed_until IS NULL OR n.snoozed_until <= $1)
+		ORDER BY c.due_at
+	`, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("claim due escalations: %w", err)
+	}
+	defer rows.Close()
+	var results []domain.Escalation
+	for rows.Next() {
+		var e domain.Escalation
+		if err := rows.Scan(&e.NotificationID, &e.TenantKey, &e.UserID, &e.RuleID, &e.DueAt); err != nil {
+			return nil, fmt.Errorf("claim due escalations: %w", err)
+		}
+		results = append(results, e)
+	}
+	return results, rows.Err()
+}
+
+func (r *EscalationRepo) Cancel(ctx context.Context, tenantKey, userID string, ids []uuid.UUID) error {
+	if _, err := r.pool.Exec(ctx, `
+		DELETE FROM notification_escalations
+		WHERE tenant_key = $1 AND user_id = $2 AND (cardinality(COALESCE($3::uuid[], '{}')) = 0 OR notification_id = ANY($3))
+	`, tenantKey, userID, ids); err != nil {
+		return fmt.Errorf("cancel escalations: %w", err)
+	}
+	return nil
+}
+
+func scanEscalationRule(row scannable) (*domain.EscalationRule, error) {
+	var rule domain.EscalationRule
+	if err := row.Scan(&rule.ID, &rule.TenantKey, &rule.Type, &rule.Priority, &rule.AfterSeconds, &rule.Action,
+		&rule.Role, &rule.Active, &rule.CreatedAt, &rule.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// EventDefaultsRepo implements domain.EventDefaultsRepository.
+type EventDefaultsRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewEventDefaultsRepo creates a new EventDefaultsRepo.
+func NewEventDefaultsRepo(pool *pgxpool.Pool) *EventDefaultsRepo {
+	return &EventDefaultsRepo{pool: pool}
+}
+
+const eventDefaultsColumns = `event_key, priority, category, ttl_seconds, channels, updated_at`
+
+func (r *EventDefaultsRepo) Get(ctx context.Context, eventKey string) (*domain.EventDefaults, error) {
+	row := r.pool.QueryRow(ctx, `SELECT `+eventDefaultsColumns+` FROM event_defaults WHERE event_key = $1`, eventKey)
+	d, err := scanEventDefaults(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get event defaults: %w", err)
+	}
+	return d, nil
+}
+
+func (r *EventDefaultsRepo) List(ctx context.Context) ([]domain.EventDefaults, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+eventDefaultsColumns+` FROM event_defaults ORDER BY event_key`)
+	if err != nil {
+		return nil, fmt.Errorf("list event defaults: %w", err)
+	}
+	defer rows.Close()
+
+	var results []domain.EventDefaults
+	for rows.Next() {
+		d, err := scanEventDefaults(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *d)
+	}
+	return results, rows.Err()
+}
+
+func (r *EventDefaultsRepo) Upsert(ctx context.Context, d domain.EventDefaults) (*domain.EventDefaults, error) {
+	var channels []string
+	if d.Channels != nil {
+		channels = make([]string, len(d.Channels))
+		for i, c := range d.Channels {
+			channels[i] = string(c)
+		}
+	}
+	row := r.pool.QueryRow(ctx, `
+		INSERT INTO event_defaults (event_key, priority, category, ttl_seconds, channels)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (event_key) DO UPDATE SET
+			priority    = EXCLUDED.priority,
+			category    = EXCLUDED.category,
+			ttl_seconds = EXCLUDED.ttl_seconds,
+			channels    = EXCLUDED.channels,
+			updated_at  = NOW()
+		RETURNING `+eventDefaultsColumns, d.EventKey, string(d.Priority), d.Category, d.TTLSeconds, channels)
+	saved, err := scanEventDefaults(row)
+	if err != nil {
+		return nil, fmt.Errorf("upsert event defaults: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *EventDefaultsRepo) Delete(ctx context.Context, eventKey string) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM event_defaults WHERE event_key = $1`, eventKey)
+	return err
+}
+
+func scanEventDefaults(row scannable) (*domain.EventDefaults, error) {
+	var (
+		d        domain.EventDefaults
+		channels []string
+	)
+	if err := row.Scan(&d.EventKey, &d.Priority, &d.Category, &d.TTLSeconds, &channels, &d.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if channels != nil {
+		d.Channels = make([]domain.Channel, len(channels))
+		for i, c := range channels {
+			d.Channels[i] = domain.Channel(c)
+		}
+	}
+	return &d, nil
+}
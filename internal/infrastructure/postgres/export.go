@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"vn.io.arda/notification/internal/domain"
+)
+
+// Export streams a user's history (their rows, archived rows and the broadcasts
+// they have not deleted) or, without f.UserID, the whole tenant's, where each
+// tenant broadcast appears once with an empty user. Rows are read as they are
+// sent, so exports of any size use constant memory.
+func (r *Repository) Export(ctx context.Context, f domain.ExportFilter, fn func(*domain.Notification) error) error {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+notificationColumns+` FROM (
+			SELECT `+notificationColumns+`
+			FROM notifications
+			WHERE tenant_key = $1 AND ($2 = '' OR user_id = $2)
+			UNION ALL
+			SELECT `+notificationColumns+`
+			FROM notifications_archive
+			WHERE tenant_key = $1 AND ($2 = '' OR user_id = $2)
+			UNION ALL
+			SELECT b.id, $1::varchar, $2::varchar, b.type, b.title, b.body, b.metadata,
+				s.read_at IS NOT NULL, s.read_at, b.created_at, b.source_event_id, b.priority, b.category
+			FROM broadcast_notifications b
+			LEFT JOIN broadcast_read_state s
+				ON s.broadcast_id = b.id AND s.tenant_key = $1 AND s.user_id = $2
+			WHERE (b.tenant_key = $1 OR ($2 <> '' AND b.tenant_key IS NULL))
+				AND s.deleted_at IS NULL
+		) history
+		WHERE ($3::timestamptz IS NULL OR created_at >= $3)
+			AND ($4::timestamptz IS NULL OR created_at < $4)
+		ORDER BY created_at, id`, f.TenantKey, f.UserID, f.From, f.To)
+	if err != nil {
+		return fmt.Errorf("export notifications: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(n); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,677 @@
+//go:build integration
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"vn.io.arda/notification/internal/domain"
+	"vn.io.arda/notification/migrations"
+)
+
+// The integration suite runs against ARDA_NOTIF_TEST_DATABASE_URL, or against a
+// throwaway container of ARDA_NOTIF_TEST_POSTGRES_IMAGE (default postgres:18)
+// started with the docker CLI and removed afterwards:
+//
+//	go test -tags integration ./internal/infrastructure/postgres/
+//
+// Every test writes under its own tenant key, so the suite can share a
+// database with other data.
+
+var testPool *pgxpool.Pool
+
+func TestMain(m *testing.M) {
+	os.Exit(runIntegration(m))
+}
+
+func runIntegration(m *testing.M) int {
+	ctx := context.Background()
+	dsn := os.Getenv("ARDA_NOTIF_TEST_DATABASE_URL")
+	if dsn == "" {
+		var stop func()
+		var err error
+		dsn, stop, err = startPostgres(ctx)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "start postgres container:", err)
+			return 1
+		}
+		defer stop()
+	}
+
+	pool, err := connect(ctx, dsn, time.Minute)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "connect:", err)
+		return 1
+	}
+	defer pool.Close()
+	migrator, err := NewMigrator(pool, migrations.FS)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "migrations:", err)
+		return 1
+	}
+	_, err = migrator.Up(ctx)
+	migrator.Close()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "migrate:", err)
+		return 1
+	}
+	if _, err := New(pool).EnsurePartitions(ctx, 2); err != nil {
+		fmt.Fprintln(os.Stderr, "partitions:", err)
+		return 1
+	}
+	testPool = pool
+	return m.Run()
+}
+
+// startPostgres runs a Postgres container on a random local port and returns
+// its DSN and a function removing it.
+func startPostgres(ctx context.Context) (string, func(), error) {
+	image := os.Getenv("ARDA_NOTIF_TEST_POSTGRES_IMAGE")
+	if image == "" {
+		image = "postgres:18"
+	}
+	out, err := exec.CommandContext(ctx, "docker", "run", "-d", "--rm",
+		"-e", "POSTGRES_USER=arda", "-e", "POSTGRES_PASSWORD=arda", "-e", "POSTGRES_DB=notification",
+		"-p", "127.0.0.1::5432", image).Output()
+	if err != nil {
+		return "", nil, fmt.Errorf("docker run %s: %w", image, err)
+	}
+	id := strings.TrimSpace(string(out))
+	stop := func() { _ = exec.Command("docker", "rm", "-f", id).Run() }
+
+	out, err = exec.CommandContext(ctx, "docker", "port", id, "5432/tcp").Output()
+	if err != nil {
+		stop()
+		return "", nil, fmt.Errorf("docker port: %w", err)
+	}
+	addr, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	return "postgres://arda:arda@" + addr + "/notification?sslmode=disable", stop, nil
+}
+
+// connect opens a pool once the server accepts connections, waiting up to timeout.
+func connect(ctx context.Context, dsn string, timeout time.Duration) (*pgxpool.Pool, error) {
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		err := pool.Ping(ctx)
+		if err == nil {
+			return pool, nil
+		}
+		if time.Now().After(deadline) {
+			pool.Close()
+			return nil, err
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+}
+
+// newTestRepo returns a Repository and a tenant key unique to this run.
+func newTestRepo(t *testing.T) (*Repository, string) {
+	t.Helper()
+	return New(testPool), "it-" + uuid.NewString()[:8]
+}
+
+func TestIntegration_CreateIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	repo, tenant := newTestRepo(t)
+	in := domain.CreateNotificationInput{TenantKey: tenant, UserID: "u1", Type: domain.TypeSystem,
+		Title: "hello", SourceEventID: "evt-1"}
+
+	n, err := repo.Create(ctx, in)
+	if err != nil || n == nil {
+		t.Fatalf("Create = %v, %v", n, err)
+	}
+	if n.Priority != domain.PriorityNormal || n.IsRead {
+		t.Fatalf("stored %+v", n)
+	}
+	dup, err := repo.Create(ctx, in)
+	if err != nil || dup != nil {
+		t.Fatalf("repeated Create = %v, %v; want nil, nil", dup, err)
+	}
+
+	// The same event for another user is not a duplicate.
+	in.UserID = "u2"
+	if n2, err := repo.Create(ctx, in); err != nil || n2 == nil {
+		t.Fatalf("Create for another user = %v, %v", n2, err)
+	}
+	if unread, err := repo.CountUnread(ctx, tenant, "u1"); err != nil || unread != 1 {
+		t.Fatalf("CountUnread = %d, %v; want 1", unread, err)
+	}
+
+	entries, err := repo.ClaimOutbox(ctx, 1000, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mine []int64
+	for _, e := range entries {
+		if e.Notification.TenantKey == tenant {
+			mine = append(mine, e.ID)
+		}
+	}
+	if len(mine) != 2 {
+		t.Fatalf("claimed %d outbox entries for the tenant, want 2", len(mine))
+	}
+	if err := repo.AckOutbox(ctx, mine); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIntegration_SourceEventScopedToTenant(t *testing.T) {
+	ctx := context.Background()
+	repo, tenant := newTestRepo(t)
+	other := "other-" + tenant
+	event := "evt-" + tenant // unique per run, as PLATFORM broadcasts are shared
+
+	for _, tk := range []string{tenant, other} {
+		n, err := repo.Create(ctx, domain.CreateNotificationInput{TenantKey: tk, UserID: "u1", Type: domain.TypeSystem,
+			Title: "hello", SourceEventID: event})
+		if err != nil || n == nil {
+			t.Fatalf("Create in %s = %v, %v; an event ID reused by another tenant is not a duplicate", tk, n, err)
+		}
+	}
+
+	broadcast := func(tk string) *domain.Notification {
+		t.Helper()
+		b, err := repo.CreateBroadcast(ctx, domain.BroadcastInput{TenantKey: tk, Type: domain.TypeSystem, Title: "b", SourceEventID: event})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	if broadcast(tenant) == nil || broadcast(other) == nil {
+		t.Fatal("tenant broadcasts reusing an event ID were skipped")
+	}
+	if broadcast(tenant) != nil {
+		t.Fatal("a repeated tenant broadcast was stored")
+	}
+	if broadcast("") == nil || broadcast("") != nil {
+		t.Fatal("want one PLATFORM broadcast per event ID")
+	}
+}
+
+func TestIntegration_BatchCreateDuplicates(t *testing.T) {
+	ctx := context.Background()
+	for _, threshold := range []int{0, 2} { // VALUES insert, then COPY
+		t.Run(fmt.Sprintf("copy_threshold=%d", threshold), func(t *testing.T) {
+			repo, tenant := newTestRepo(t)
+			repo.SetCopyThreshold(threshold)
+			input := func(user string) domain.CreateNotificationInput {
+				return domain.CreateNotificationInput{TenantKey: tenant, UserID: user, Type: domain.TypeCRM,
+					Category: "crm.deal", Title: "deal won", SourceEventID: "evt-1"}
+			}
+			if _, err := repo.Create(ctx, input("u2")); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := repo.BatchCreate(ctx, []domain.CreateNotificationInput{input("u1"), input("u2"), input("u3")})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Inserted) != 2 || !slices.Equal(result.Duplicates, []int{1}) {
+				t.Fatalf("inserted %d, duplicates %v; want 2 and [1]", len(result.Inserted), result.Duplicates)
+			}
+			for _, n := range result.Inserted {
+				if n.TenantKey != tenant || n.Category != "crm.deal" || n.ID == uuid.Nil {
+					t.Fatalf("inserted %+v", n)
+				}
+			}
+
+			// A retried batch inserts nothing.
+			result, err = repo.BatchCreate(ctx, []domain.CreateNotificationInput{input("u1"), input("u3")})
+			if err != nil || len(result.Inserted) != 0 || len(result.Duplicates) != 2 {
+				t.Fatalf("retry: %+v, %v", result, err)
+			}
+		})
+	}
+}
+
+func TestIntegration_ListAndMarkRead(t *testing.T) {
+	ctx := context.Background()
+	repo, tenant := newTestRepo(t)
+	var ids []uuid.UUID
+	for i, category := range []string{"crm.deal", "crm.lead", "workflow.task"} {
+		n, err := repo.Create(ctx, domain.CreateNotificationInput{TenantKey: tenant, UserID: "u1",
+			Type: domain.TypeCRM, Category: category, Title: fmt.Sprintf("n%d", i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, n.ID)
+	}
+
+	crm, err := repo.List(ctx, domain.NotificationFilter{TenantKey: tenant, UserID: "u1", Category: "crm.*", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(crm) != 2 || crm[0].ID != ids[1] {
+		t.Fatalf("crm.* listed %d, want 2 newest first", len(crm))
+	}
+	if other, _ := repo.List(ctx, domain.NotificationFilter{TenantKey: tenant, UserID: "u2", Limit: 10}); len(other) != 0 {
+		t.Fatalf("another user sees %d notifications", len(other))
+	}
+
+	if err := repo.MarkRead(ctx, ids[0], tenant, "u1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.MarkRead(ctx, ids[0], tenant, "u1"); err == nil {
+		t.Fatal("marking a read notification read again should fail")
+	}
+	if err := repo.MarkRead(ctx, ids[1], tenant, "u2"); err == nil {
+		t.Fatal("another user marked the notification read")
+	}
+	read := true
+	got, err := repo.List(ctx, domain.NotificationFilter{TenantKey: tenant, UserID: "u1", IsRead: &read, Limit: 10})
+	if err != nil || len(got) != 1 || got[0].ID != ids[0] || got[0].ReadAt == nil {
+		t.Fatalf("read filter = %v, %v", got, err)
+	}
+
+	if marked, err := repo.MarkAllRead(ctx, tenant, "u1"); err != nil || marked != 2 {
+		t.Fatalf("MarkAllRead = %d, %v; want 2", marked, err)
+	}
+	if unread, _ := repo.CountUnread(ctx, tenant, "u1"); unread != 0 {
+		t.Fatalf("CountUnread = %d after MarkAllRead", unread)
+	}
+}
+
+func TestIntegration_ApplyReadStates(t *testing.T) {
+	ctx := context.Background()
+	repo, tenant := newTestRepo(t)
+	n, err := repo.Create(ctx, domain.CreateNotificationInput{TenantKey: tenant, UserID: "u1", Type: domain.TypeSystem, Title: "t"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := repo.CreateBroadcast(ctx, domain.BroadcastInput{TenantKey: tenant, Type: domain.TypeSystem, Title: "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	earlier := time.Now().Add(-2 * time.Hour).Truncate(time.Microsecond)
+	later := earlier.Add(time.Hour)
+
+	newlyRead, err := repo.ApplyReadStates(ctx, tenant, "u1", []domain.ReadState{{ID: n.ID, ReadAt: later}, {ID: b.ID, ReadAt: later}})
+	if err != nil || len(newlyRead) != 2 {
+		t.Fatalf("ApplyReadStates = %v, %v; want both newly read", newlyRead, err)
+	}
+	// An earlier read from another device moves read_at back without counting as new.
+	newlyRead, err = repo.ApplyReadStates(ctx, tenant, "u1", []domain.ReadState{{ID: n.ID, ReadAt: earlier}, {ID: b.ID, ReadAt: earlier}})
+	if err != nil || len(newlyRead) != 0 {
+		t.Fatalf("earlier reads = %v, %v; want nothing newly read", newlyRead, err)
+	}
+	if newlyRead, _ = repo.ApplyReadStates(ctx, tenant, "u2", []domain.ReadState{{ID: n.ID, ReadAt: later}}); len(newlyRead) != 0 {
+		t.Fatal("another user marked the notification read")
+	}
+
+	got, err := repo.List(ctx, domain.NotificationFilter{TenantKey: tenant, UserID: "u1", Limit: 10})
+	if err != nil || len(got) != 2 {
+		t.Fatalf("List = %v, %v", got, err)
+	}
+	for _, g := range got {
+		if g.ReadAt == nil || !g.ReadAt.Equal(earlier) {
+			t.Errorf("%s read at %v, want %v", g.ID, g.ReadAt, earlier)
+		}
+	}
+}
+
+func TestIntegration_ListAsOf(t *testing.T) {
+	ctx := context.Background()
+	repo, tenant := newTestRepo(t)
+	var ids []uuid.UUID
+	for _, title := range []string{"kept", "deleted"} {
+		n, err := repo.Create(ctx, domain.CreateNotificationInput{TenantKey: tenant, UserID: "u1", Type: domain.TypeSystem, Title: title})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, n.ID)
+	}
+	// Read and delete an hour from now so both instants are distinguishable from creation.
+	created := time.Now()
+	repo.SetClock(domain.NewManualClock(created.Add(time.Hour)))
+	if err := repo.MarkRead(ctx, ids[0], tenant, "u1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Delete(ctx, ids[1], tenant, "u1"); err != nil {
+		t.Fatal(err)
+	}
+
+	list := func(asOf time.Time) []*domain.Notification {
+		t.Helper()
+		got, err := repo.List(ctx, domain.NotificationFilter{TenantKey: tenant, UserID: "u1", AsOf: &asOf, Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+	before := list(created.Add(30 * time.Minute))
+	if len(before) != 2 || before[0].IsRead || before[1].IsRead {
+		t.Fatalf("before the read and delete: %d notifications, want both unread", len(before))
+	}
+	after := list(created.Add(2 * time.Hour))
+	if len(after) != 1 || after[0].ID != ids[0] || !after[0].IsRead {
+		t.Fatalf("after the read and delete: %+v, want only the read one", after)
+	}
+	if got := list(created.Add(-time.Hour)); len(got) != 0 {
+		t.Fatalf("before creation: %d notifications", len(got))
+	}
+}
+
+func TestIntegration_ArchiveOverflow(t *testing.T) {
+	ctx := context.Background()
+	repo, tenant := newTestRepo(t)
+	var ids []uuid.UUID // oldest first
+	for i := range 4 {
+		n, err := repo.Create(ctx, domain.CreateNotificationInput{TenantKey: tenant, UserID: "u1", Type: domain.TypeSystem,
+			Title: fmt.Sprintf("n%d", i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, n.ID)
+	}
+	if _, err := repo.ArchiveOverflow(ctx, 2, 100); err != nil {
+		t.Fatal(err)
+	}
+	var hot int
+	if err := testPool.QueryRow(ctx, `SELECT COUNT(*) FROM notifications WHERE tenant_key = $1`, tenant).Scan(&hot); err != nil {
+		t.Fatal(err)
+	}
+	if hot != 4 {
+		t.Fatalf("%d hot rows, want all 4 kept while their outbox entries are pending", hot)
+	}
+
+	if _, err := testPool.Exec(ctx, `DELETE FROM delivery_outbox WHERE notification_id = ANY($1)`, ids); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.ArchiveOverflow(ctx, 2, 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := testPool.QueryRow(ctx, `SELECT COUNT(*) FROM notifications WHERE tenant_key = $1`, tenant).Scan(&hot); err != nil {
+		t.Fatal(err)
+	}
+	if hot != 2 {
+		t.Fatalf("%d hot rows, want 2", hot)
+	}
+
+	// Listing pages from the hot rows into the archive, newest first.
+	got, err := repo.List(ctx, domain.NotificationFilter{TenantKey: tenant, UserID: "u1", Limit: 10})
+	if err != nil || len(got) != 4 || got[3].ID != ids[0] {
+		t.Fatalf("List = %d notifications, %v; want 4 ending with the oldest", len(got), err)
+	}
+	page, err := repo.List(ctx, domain.NotificationFilter{TenantKey: tenant, UserID: "u1", Limit: 1, Offset: 3})
+	if err != nil || len(page) != 1 || page[0].ID != ids[0] {
+		t.Fatalf("archive page = %v, %v", page, err)
+	}
+
+	if err := repo.MarkRead(ctx, ids[0], tenant, "u1"); err != nil {
+		t.Fatalf("MarkRead on an archived notification: %v", err)
+	}
+	if err := repo.Delete(ctx, ids[1], tenant, "u1"); err != nil {
+		t.Fatalf("Delete on an archived notification: %v", err)
+	}
+	if got, _ := repo.List(ctx, domain.NotificationFilter{TenantKey: tenant, UserID: "u1", Limit: 10}); len(got) != 3 || !got[2].IsRead {
+		t.Fatalf("after read and delete: %d notifications", len(got))
+	}
+}
+
+func TestIntegration_EvictOverCap(t *testing.T) {
+	ctx := context.Background()
+	repo, tenant := newTestRepo(t)
+	var ids []uuid.UUID // oldest first
+	for i := range 5 {
+		n, err := repo.Create(ctx, domain.CreateNotificationInput{TenantKey: tenant, UserID: "u1", Type: domain.TypeSystem,
+			Title: fmt.Sprintf("n%d", i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, n.ID)
+	}
+	if _, err := testPool.Exec(ctx, `DELETE FROM delivery_outbox WHERE notification_id = ANY($1)`, ids); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range ids[:3] {
+		if err := repo.MarkRead(ctx, id, tenant, "u1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repo.SetPinned(ctx, ids[0], tenant, "u1", true); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := repo.EvictOverCap(ctx, 2, 100); err != nil {
+		t.Fatal(err)
+	}
+	left, err := repo.List(ctx, domain.NotificationFilter{TenantKey: tenant, UserID: "u1", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []uuid.UUID
+	for _, n := range left {
+		got = append(got, n.ID)
+	}
+	// Pinned first, then newest first; the two unpinned read rows are gone.
+	if want := []uuid.UUID{ids[0], ids[4], ids[3]}; !slices.Equal(got, want) {
+		t.Fatalf("left %v, want %v", got, want)
+	}
+	var purged int
+	if err := testPool.QueryRow(ctx, `SELECT COUNT(*) FROM notification_audit WHERE tenant_key = $1 AND action = $2 AND actor = $3`,
+		tenant, domain.AuditPurged, domain.AuditActorMailboxCap).Scan(&purged); err != nil {
+		t.Fatal(err)
+	}
+	if purged != 2 {
+		t.Fatalf("%d purge audit entries, want 2", purged)
+	}
+}
+
+func TestIntegration_Broadcast(t *testing.T) {
+	ctx := context.Background()
+	repo, tenant := newTestRepo(t)
+	in := domain.BroadcastInput{TenantKey: tenant, Type: domain.TypeSystem, Title: "maintenance", SourceEventID: "evt-1"}
+
+	b, err := repo.CreateBroadcast(ctx, in)
+	if err != nil || b == nil {
+		t.Fatalf("CreateBroadcast = %v, %v", b, err)
+	}
+	if dup, err := repo.CreateBroadcast(ctx, in); err != nil || dup != nil {
+		t.Fatalf("repeated CreateBroadcast = %v, %v; want nil, nil", dup, err)
+	}
+
+	for _, user := range []string{"u1", "u2"} {
+		if unread, _ := repo.CountUnread(ctx, tenant, user); unread != 1 {
+			t.Fatalf("%s: CountUnread = %d, want 1", user, unread)
+		}
+	}
+	if err := repo.MarkRead(ctx, b.ID, tenant, "u1"); err != nil {
+		t.Fatal(err)
+	}
+	if unread, _ := repo.CountUnread(ctx, tenant, "u1"); unread != 0 {
+		t.Fatalf("u1: CountUnread = %d after reading the broadcast", unread)
+	}
+	if unread, _ := repo.CountUnread(ctx, tenant, "u2"); unread != 1 {
+		t.Fatalf("u2: CountUnread = %d; a read by u1 leaked", unread)
+	}
+	if err := repo.MarkRead(ctx, b.ID, "other-"+tenant, "u1"); err == nil {
+		t.Fatal("a user of another tenant marked the broadcast read")
+	}
+}
+
+func TestIntegration_Pin(t *testing.T) {
+	ctx := context.Background()
+	repo, tenant := newTestRepo(t)
+	b, err := repo.CreateBroadcast(ctx, domain.BroadcastInput{TenantKey: tenant, Type: domain.TypeSystem, Title: "broadcast"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := repo.Create(ctx, domain.CreateNotificationInput{TenantKey: tenant, UserID: "u1", Type: domain.TypeSystem, Title: "own"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.SetPinned(ctx, b.ID, tenant, "u1", true); err != nil {
+		t.Fatal(err)
+	}
+	got, err := repo.List(ctx, domain.NotificationFilter{TenantKey: tenant, UserID: "u1", Limit: 10})
+	if err != nil || len(got) != 2 || got[0].ID != b.ID || !got[0].Pinned || got[1].Pinned {
+		t.Fatalf("List = %v, %v; want the pinned broadcast first", got, err)
+	}
+	if other, _ := repo.List(ctx, domain.NotificationFilter{TenantKey: tenant, UserID: "u2", Limit: 10}); len(other) != 1 || other[0].Pinned {
+		t.Fatal("the pin leaked to another user")
+	}
+
+	pinned := true
+	if err := repo.SetPinned(ctx, n.ID, tenant, "u1", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SetPinned(ctx, b.ID, tenant, "u1", false); err != nil {
+		t.Fatal(err)
+	}
+	got, err = repo.List(ctx, domain.NotificationFilter{TenantKey: tenant, UserID: "u1", Pinned: &pinned, Limit: 10})
+	if err != nil || len(got) != 1 || got[0].ID != n.ID {
+		t.Fatalf("pinned filter = %v, %v", got, err)
+	}
+	if err := repo.SetPinned(ctx, n.ID, tenant, "u2", true); !errors.Is(err, domain.ErrNotificationNotFound) {
+		t.Fatalf("pinning another user's notification: %v", err)
+	}
+}
+
+func TestIntegration_ListByEntity(t *testing.T) {
+	ctx := context.Background()
+	repo, tenant := newTestRepo(t)
+	entity := func(typ, id string) map[string]any { return map[string]any{"entityType": typ, "entityId": id} }
+	for _, m := range []map[string]any{entity("deal", "1"), entity("deal", "1"), entity("deal", "2"), entity("lead", "1"), nil} {
+		if _, err := repo.Create(ctx, domain.CreateNotificationInput{TenantKey: tenant, UserID: "u1", Type: domain.TypeCRM,
+			Title: "crm", Metadata: m}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := repo.CreateBroadcast(ctx, domain.BroadcastInput{TenantKey: tenant, Type: domain.TypeCRM, Title: "crm",
+		Metadata: entity("deal", "1")}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := repo.List(ctx, domain.NotificationFilter{TenantKey: tenant, UserID: "u1", EntityType: "deal", EntityID: "1", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("deal/1 listed %d notifications, want 2 own and the broadcast", len(got))
+	}
+	for _, n := range got {
+		if n.Metadata["entityType"] != "deal" || n.Metadata["entityId"] != "1" {
+			t.Fatalf("listed %v for deal/1", n.Metadata)
+		}
+	}
+	if other, _ := repo.List(ctx, domain.NotificationFilter{TenantKey: tenant, UserID: "u2", EntityType: "deal", EntityID: "1", Limit: 10}); len(other) != 1 {
+		t.Fatalf("another user listed %d notifications for deal/1, want only the broadcast", len(other))
+	}
+}
+
+func TestIntegration_Purge(t *testing.T) {
+	ctx := context.Background()
+	repo, tenant := newTestRepo(t)
+	repo.SetPurgeBatchSize(1)
+	create := func(title string, metadata map[string]any) *domain.Notification {
+		n, err := repo.Create(ctx, domain.CreateNotificationInput{TenantKey: tenant, UserID: "u1",
+			Type: domain.TypeSystem, Title: title, Metadata: metadata})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	expired := create("expired", domain.WithExpiry(nil, time.Now().Add(-time.Minute)))
+	create("old", nil)
+	pinned := create("pinned", nil)
+	if err := repo.SetPinned(ctx, pinned.ID, tenant, "u1", true); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := repo.PurgeExpired(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.GetByID(ctx, expired.ID); err == nil {
+		t.Fatal("expired notification survived PurgeExpired")
+	}
+
+	// 40 days on, every unpinned row is past a 30-day retention.
+	repo.SetClock(domain.NewManualClock(time.Now().AddDate(0, 0, 40)))
+	if _, err := repo.PurgeOlderThan(ctx, 30); err != nil {
+		t.Fatal(err)
+	}
+	left, err := repo.List(ctx, domain.NotificationFilter{TenantKey: tenant, UserID: "u1", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(left) != 1 || left[0].ID != pinned.ID {
+		t.Fatalf("%d notifications left after purge, want only the pinned one", len(left))
+	}
+}
+
+func TestIntegration_RowLevelSecurity(t *testing.T) {
+	ctx := context.Background()
+	repo, tenant := newTestRepo(t)
+	other := "other-" + tenant
+	for _, tk := range []string{tenant, other} {
+		if _, err := repo.Create(ctx, domain.CreateNotificationInput{TenantKey: tk, UserID: "u1",
+			Type: domain.TypeSystem, Title: "hello"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Superusers bypass row-level security, so connect through a plain role.
+	if _, err := testPool.Exec(ctx, `
+		DO $$ BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'arda_rls_test') THEN
+				CREATE ROLE arda_rls_test NOLOGIN;
+			END IF;
+		END $$;
+		GRANT USAGE ON SCHEMA public TO arda_rls_test;
+		GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO arda_rls_test;
+		GRANT USAGE ON ALL SEQUENCES IN SCHEMA public TO arda_rls_test`); err != nil {
+		t.Fatal(err)
+	}
+	cfg := testPool.Config()
+	cfg.MaxConns = 1 // every call reuses the connection, switching its scope
+	cfg.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+		_, err := conn.Exec(ctx, "SET ROLE arda_rls_test")
+		return err
+	}
+	EnableTenantScope(cfg)
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	scoped := New(pool)
+	tenantCtx := domain.WithTenantScope(ctx, tenant)
+
+	list := func(ctx context.Context, tenantKey string) int {
+		t.Helper()
+		got, err := scoped.List(ctx, domain.NotificationFilter{TenantKey: tenantKey, UserID: "u1", Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(got)
+	}
+	if n := list(tenantCtx, tenant); n != 1 {
+		t.Fatalf("own tenant: listed %d, want 1", n)
+	}
+	if n := list(tenantCtx, other); n != 0 {
+		t.Fatalf("a query for another tenant returned %d notifications", n)
+	}
+	if n := list(ctx, other); n != 1 {
+		t.Fatalf("unscoped: listed %d, want 1", n)
+	}
+	if _, err := scoped.Create(tenantCtx, domain.CreateNotificationInput{TenantKey: other, UserID: "u1",
+		Type: domain.TypeSystem, Title: "leak"}); err == nil {
+		t.Fatal("wrote a notification of another tenant")
+	}
+}
@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// JobRunRepo implements domain.JobRunRepository. Locks are session-level
+// advisory locks held on a dedicated pooled connection, so a crashed instance
+// releases them when its connection drops.
+type JobRunRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewJobRunRepo creates a new JobRunRepo.
+func NewJobRunRepo(pool *pgxpool.Pool) *JobRunRepo {
+	return &JobRunRepo{pool: pool}
+}
+
+// jobLockKey derives the advisory lock key of a lock name, namespaced so it
+// cannot collide with the migration lock.
+const jobLockKey = `hashtextextended('arda-notification:job:' || $1, 0)`
+
+func (r *JobRunRepo) TryLock(ctx context.Context, name string) (domain.JobLock, bool, error) {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("acquire job lock connection: %w", err)
+	}
+	var ok bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock(`+jobLockKey+`)`, name).Scan(&ok); err != nil {
+		conn.Release()
+		return nil, false, fmt.Errorf("lock %s: %w", name, err)
+	}
+	if !ok {
+		conn.Release()
+		return nil, false, nil
+	}
+	return &advisoryLock{conn: conn, name: name}, true, nil
+}
+
+// advisoryLock is a session advisory lock held on conn. It is not safe for
+// concurrent use.
+type advisoryLock struct {
+	conn *pgxpool.Conn
+	name string
+}
+
+func (l *advisoryLock) Check(ctx context.Context) error {
+	if err := l.conn.Ping(ctx); err != nil {
+		return fmt.Errorf("lock %s lost: %w", l.name, err)
+	}
+	return nil
+}
+
+func (l *advisoryLock) Release() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := l.conn.Exec(ctx, `SELECT pg_advisory_unlock(`+jobLockKey+`)`, l.name); err != nil {
+		// Closing the session drops the lock with it.
+		log.Warn().Err(err).Str("lock", l.name).Msg("failed to unlock; closing its connection")
+		l.conn.Conn().Close(ctx)
+	}
+	l.conn.Release()
+}
+
+func (r *JobRunRepo) LastRun(ctx context.Context, job string) (time.Time, error) {
+	var at time.Time
+	err := r.pool.QueryRow(ctx, `SELECT last_run_at FROM job_runs WHERE name = $1`, job).Scan(&at)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("get last run of job %s: %w", job, err)
+	}
+	return at, nil
+}
+
+func (r *JobRunRepo) RecordRun(ctx context.Context, job string, startedAt time.Time, took time.Duration) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO job_runs (name, last_run_at, duration_ms) VALUES ($1, $2, $3)
+		ON CONFLICT (name) DO UPDATE
+		SET last_run_at = EXCLUDED.last_run_at, duration_ms = EXCLUDED.duration_ms, updated_at = NOW()`,
+		job, startedAt, took.Milliseconds())
+	if err != nil {
+		return fmt.Errorf("record run of job %s: %w", job, err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// EvictOverCap deletes the oldest read notifications of users whose mailbox (hot
+// and archived rows) holds more than capacity, at most limit rows per call, leaving a
+// purged audit entry per row. Unread, pinned and snoozed rows, and rows with a
+// pending outbox entry, a reaction or a chosen action, are never evicted, so a
+// mailbox can stay above capacity. Returns the number of notifications evicted.
+func (r *Repository) EvictOverCap(ctx context.Context, capacity, limit int) (int64, error) {
+	rows, err := r.pool.Query(ctx, `
+		WITH over AS (
+			SELECT tenant_key, user_id, SUM(n) - $1 AS excess
+			FROM (
+				SELECT tenant_key, user_id, COUNT(*) AS n FROM notifications GROUP BY tenant_key, user_id
+				UNION ALL
+				SELECT tenant_key, user_id, COUNT(*) FROM notifications_archive GROUP BY tenant_key, user_id
+			) c
+			GROUP BY tenant_key, user_id
+			HAVING SUM(n) > $1
+			LIMIT $3
+		)
+		SELECT v.archived, v.id FROM over o
+		CROSS JOIN LATERAL (
+			SELECT archived, id FROM (
+				SELECT FALSE AS archived, n.id, n.created_at FROM notifications n
+				WHERE n.tenant_key = o.tenant_key AND n.user_id = o.user_id
+					AND n.is_read AND NOT n.pinned AND n.snoozed_until IS NULL
+					AND NOT EXISTS (SELECT 1 FROM delivery_outbox d WHERE d.notification_id = n.id)
+				UNION ALL
+				SELECT TRUE, a.id, a.created_at FROM notifications_archive a
+				WHERE a.tenant_key = o.tenant_key AND a.user_id = o.user_id AND a.is_read
+			) m
+			WHERE NOT EXISTS (SELECT 1 FROM notification_reactions x WHERE x.notification_id = m.id)
+				AND NOT EXISTS (SELECT 1 FROM notification_actions x WHERE x.notification_id = m.id)
+			ORDER BY m.created_at, m.id
+			LIMIT o.excess
+		) v
+		LIMIT $2`, capacity, limit, archiveUsersPerRun)
+	if err != nil {
+		return 0, fmt.Errorf("find mailbox overflow: %w", err)
+	}
+	var hot, archived []uuid.UUID
+	for rows.Next() {
+		var (
+			inArchive bool
+			id        uuid.UUID
+		)
+		if err := rows.Scan(&inArchive, &id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scan mailbox overflow: %w", err)
+		}
+		if inArchive {
+			archived = append(archived, id)
+		} else {
+			hot = append(hot, id)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("find mailbox overflow: %w", err)
+	}
+
+	var evicted int64
+	for table, ids := range map[string][]uuid.UUID{"notifications": hot, "notifications_archive": archived} {
+		if len(ids) == 0 {
+			continue
+		}
+		// Re-checked at delete time: the row may have been marked unread or pinned meanwhile.
+		cond := ` AND n.is_read`
+		if table == "notifications" {
+			cond += ` AND NOT n.pinned AND n.snoozed_until IS NULL`
+		}
+		tag, err := r.pool.Exec(ctx, auditedPurge(table,
+			`DELETE FROM `+table+` n WHERE n.id = ANY($1)`+cond, domain.AuditActorMailboxCap), ids)
+		if err != nil {
+			return evicted, fmt.Errorf("evict from %s: %w", table, err)
+		}
+		evicted += tag.RowsAffected()
+	}
+	return evicted, nil
+}
@@ -0,0 +1,198 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// MaintenanceRepo implements domain.MaintenanceRepository.
+type MaintenanceRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewMaintenanceRepo creates a new MaintenanceRepo.
+func NewMaintenanceRepo(pool *pgxpool.Pool) *MaintenanceRepo {
+	return &MaintenanceRepo{pool: pool}
+}
+
+const maintenanceColumns = `w.id, w.tenant_key, w.reason, w.mode, w.starts_at, w.ends_at,
+	(SELECT COUNT(*) FROM maintenance_held_notifications h WHERE h.window_id = w.id),
+	w.flushed_at, w.created_by, w.created_at`
+
+func (r *MaintenanceRepo) List(ctx context.Context, tenantKey string) ([]domain.MaintenanceWindow, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+maintenanceColumns+` FROM maintenance_windows w
+		WHERE $1 = '' OR w.tenant_key IN ('', $1)
+		ORDER BY w.starts_at DESC
+	`, tenantKey)
+	if err != nil {
+		return nil, fmt.Errorf("list maintenance windows: %w", err)
+	}
+	return scanMaintenanceWindows(rows)
+}
+
+func (r *MaintenanceRepo) Active(ctx context.Context, tenantKey string, now time.Time) (*domain.MaintenanceWindow, error) {
+	row := r.pool.QueryRow(ctx, `
+		SELECT `+maintenanceColumns+` FROM maintenance_windows w
+		WHERE w.tenant_key IN ('', $1) AND w.starts_at <= $2 AND w.ends_at > $2
+		ORDER BY w.mode = 'suppress' DESC, w.starts_at
+		LIMIT 1
+	`, tenantKey, now)
+	w, err := scanMaintenanceWindow(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get active maintenance window: %w", err)
+	}
+	return w, nil
+}
+
+func (r *MaintenanceRepo) Get(ctx context.Context, id uuid.UUID) (*domain.MaintenanceWindow, error) {
+	row := r.pool.QueryRow(ctx, `SELECT `+maintenanceColumns+` FROM maintenance_windows w WHERE w.id = $1`, id)
+	w, err := scanMaintenanceWindow(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get maintenance window: %w", err)
+	}
+	return w, nil
+}
+
+func (r *MaintenanceRepo) Create(ctx context.Context, w domain.MaintenanceWindow) (*domain.MaintenanceWindow, error) {
+	row := r.pool.QueryRow(ctx, `
+		WITH w AS (
+			INSERT INTO maintenance_windows (tenant_key, reason, mode, starts_at, ends_at, created_by)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING *
+		)
+		SELECT w.id, w.tenant_key, w.reason, w.mode, w.starts_at, w.ends_at, 0, w.flushed_at, w.created_by, w.created_at
+		FROM w`,
+		w.TenantKey, w.Reason, string(w.Mode), w.StartsAt, w.EndsAt, w.CreatedBy)
+	saved, err := scanMaintenanceWindow(row)
+	if err != nil {
+		return nil, fmt.Errorf("create maintenance window: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *MaintenanceRepo) Update(ctx context.Context, w domain.MaintenanceWindow) (*domain.MaintenanceWindow, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE maintenance_windows SET reason = $2, mode = $3, starts_at = $4, ends_at = $5
+		WHERE id = $1 AND flushed_at IS NULL
+	`, w.ID, w.Reason, string(w.Mode), w.StartsAt, w.EndsAt)
+	if err != nil {
+		return nil, fmt.Errorf("update maintenance window: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, fmt.Errorf("maintenance window not found or already flushed")
+	}
+	saved, err := scanMaintenanceWindow(r.pool.QueryRow(ctx, `SELECT `+maintenanceColumns+` FROM maintenance_windows w WHERE w.id = $1`, w.ID))
+	if err != nil {
+		return nil, fmt.Errorf("update maintenance window: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *MaintenanceRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM maintenance_windows WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete maintenance window: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("maintenance window not found")
+	}
+	return nil
+}
+
+func (r *MaintenanceRepo) Hold(ctx context.Context, windowID uuid.UUID, input domain.FanoutInput) error {
+	inputJSON, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("marshal held input: %w", err)
+	}
+	if _, err := r.pool.Exec(ctx, `INSERT INTO maintenance_held_notifications (window_id, input) VALUES ($1, $2)`, windowID, inputJSON); err != nil {
+		return fmt.Errorf("hold notification: %w", err)
+	}
+	return nil
+}
+
+func (r *MaintenanceRepo) ListEnded(ctx context.Context, now time.Time) ([]domain.MaintenanceWindow, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+maintenanceColumns+` FROM maintenance_windows w
+		WHERE w.mode = 'batch' AND w.ends_at <= $1 AND w.flushed_at IS NULL
+		ORDER BY w.ends_at
+	`, now)
+	if err != nil {
+		return nil, fmt.Errorf("list ended maintenance windows: %w", err)
+	}
+	return scanMaintenanceWindows(rows)
+}
+
+func (r *MaintenanceRepo) Held(ctx context.Context, windowID uuid.UUID) ([]domain.FanoutInput, error) {
+	rows, err := r.pool.Query(ctx, `SELECT input FROM maintenance_held_notifications WHERE window_id = $1 ORDER BY id`, windowID)
+	if err != nil {
+		return nil, fmt.Errorf("list held notifications: %w", err)
+	}
+	defer rows.Close()
+	var inputs []domain.FanoutInput
+	for rows.Next() {
+		var (
+			raw   []byte
+			input domain.FanoutInput
+		)
+		if err := rows.Scan(&raw); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(raw, &input); err != nil {
+			return nil, fmt.Errorf("unmarshal held input: %w", err)
+		}
+		inputs = append(inputs, input)
+	}
+	return inputs, rows.Err()
+}
+
+func (r *MaintenanceRepo) MarkFlushed(ctx context.Context, windowID uuid.UUID) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin flush maintenance window: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	if _, err := tx.Exec(ctx, `UPDATE maintenance_windows SET flushed_at = NOW() WHERE id = $1`, windowID); err != nil {
+		return fmt.Errorf("flush maintenance window: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM maintenance_held_notifications WHERE window_id = $1`, windowID); err != nil {
+		return fmt.Errorf("drop held notifications: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
+func scanMaintenanceWindows(rows pgx.Rows) ([]domain.MaintenanceWindow, error) {
+	defer rows.Close()
+	var results []domain.MaintenanceWindow
+	for rows.Next() {
+		w, err := scanMaintenanceWindow(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *w)
+	}
+	return results, rows.Err()
+}
+
+func scanMaintenanceWindow(row scannable) (*domain.MaintenanceWindow, error) {
+	var w domain.MaintenanceWindow
+	if err := row.Scan(&w.ID, &w.TenantKey, &w.Reason, &w.Mode, &w.StartsAt, &w.EndsAt, &w.Held,
+		&w.FlushedAt, &w.CreatedBy, &w.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
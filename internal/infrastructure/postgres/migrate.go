@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/database"
+	"github.com/pressly/goose/v3/lock"
+)
+
+// Migrator applies the versioned SQL migrations in fsys (see package migrations).
+// Applied versions are recorded in goose_db_version; a Postgres advisory lock
+// keeps replicas starting together from migrating concurrently.
+type Migrator struct {
+	pool     *pgxpool.Pool
+	db       *sql.DB
+	store    database.Store
+	provider *goose.Provider
+}
+
+// NewMigrator creates a Migrator over pool. Close releases the database/sql handle.
+func NewMigrator(pool *pgxpool.Pool, fsys fs.FS) (*Migrator, error) {
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return nil, fmt.Errorf("migration lock: %w", err)
+	}
+	store, err := database.NewStore(database.DialectPostgres, goose.DefaultTablename)
+	if err != nil {
+		return nil, fmt.Errorf("migration store: %w", err)
+	}
+	db := stdlib.OpenDBFromPool(pool)
+	provider, err := goose.NewProvider("", db, fsys, goose.WithStore(store), goose.WithSessionLocker(locker))
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("load migrations: %w", err)
+	}
+	return &Migrator{pool: pool, db: db, store: store, provider: provider}, nil
+}
+
+// Up applies every pending migration and returns the versions applied.
+func (m *Migrator) Up(ctx context.Context) ([]int64, error) {
+	return applied(m.provider.Up(ctx))
+}
+
+// UpTo applies pending migrations up to and including version.
+func (m *Migrator) UpTo(ctx context.Context, version int64) ([]int64, error) {
+	return applied(m.provider.UpTo(ctx, version))
+}
+
+// Status reports every known migration and whether it has been applied.
+func (m *Migrator) Status(ctx context.Context) ([]*goose.MigrationStatus, error) {
+	return m.provider.Status(ctx)
+}
+
+// Version returns the latest applied migration version (0 when none).
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	return m.provider.GetDBVersion(ctx)
+}
+
+// Baseline records migrations up to version as applied without running them,
+// for databases whose schema was created out-of-band. It refuses to touch an
+// existing migration history.
+func (m *Migrator) Baseline(ctx context.Context, version int64) error {
+	var exists bool
+	if err := m.pool.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, goose.DefaultTablename).Scan(&exists); err != nil {
+		return fmt.Errorf("check migration history: %w", err)
+	}
+	if exists {
+		return fmt.Errorf("migration history %s already exists", goose.DefaultTablename)
+	}
+	var versions []int64
+	for _, src := range m.provider.ListSources() {
+		if src.Version <= version {
+			versions = append(versions, src.Version)
+		}
+	}
+	if len(versions) == 0 || versions[len(versions)-1] != version {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := m.store.CreateVersionTable(ctx, tx); err != nil {
+		return fmt.Errorf("create migration history: %w", err)
+	}
+	// goose seeds a new history with version 0.
+	for _, v := range append([]int64{0}, versions...) {
+		if err := m.store.Insert(ctx, tx, database.InsertRequest{Version: v}); err != nil {
+			return fmt.Errorf("record migration %d: %w", v, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Close releases the database/sql handle; the pool stays open.
+func (m *Migrator) Close() error {
+	return m.provider.Close()
+}
+
+func applied(results []*goose.MigrationResult, err error) ([]int64, error) {
+	versions := make([]int64, 0, len(results))
+	for _, r := range results {
+		versions = append(versions, r.Source.Version)
+	}
+	return versions, err
+}
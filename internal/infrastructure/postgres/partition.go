@@ -0,0 +1,185 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// notificationPartition is a partition of the notifications table. To is the
+// exclusive upper bound; nil for the default partition.
+type notificationPartition struct {
+	Name string
+	To   *time.Time
+}
+
+// notificationPartitions lists the partitions of notifications (see migration 031).
+func (r *Repository) notificationPartitions(ctx context.Context) ([]notificationPartition, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT c.relname,
+		       substring(pg_get_expr(c.relpartbound, c.oid) FROM 'TO \(''([^'']+)''\)')::timestamptz
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = 'notifications'::regclass
+		ORDER BY 2 NULLS LAST`)
+	if err != nil {
+		return nil, fmt.Errorf("list notification partitions: %w", err)
+	}
+	defer rows.Close()
+
+	var parts []notificationPartition
+	for rows.Next() {
+		var p notificationPartition
+		if err := rows.Scan(&p.Name, &p.To); err != nil {
+			return nil, fmt.Errorf("scan notification partition: %w", err)
+		}
+		parts = append(parts, p)
+	}
+	return parts, rows.Err()
+}
+
+// EnsurePartitions creates the monthly (UTC) notifications partitions from the
+// current month through monthsAhead months ahead, each with its source_event_id
+// idempotency index. Months already covered are skipped. Returns the number of
+// partitions created.
+func (r *Repository) EnsurePartitions(ctx context.Context, monthsAhead int) (int, error) {
+	parts, err := r.notificationPartitions(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var covered time.Time
+	for _, p := range parts {
+		if p.To != nil && p.To.After(covered) {
+			covered = *p.To
+		}
+	}
+
+	now := r.clock.Now().UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	created := 0
+	for i := 0; i <= monthsAhead; i++ {
+		from, to := month.AddDate(0, i, 0), month.AddDate(0, i+1, 0)
+		if !to.After(covered) {
+			continue
+		}
+		// Ranges stay contiguous when the last partition ends mid-month.
+		from = maxTime(from, covered)
+		if err := r.createPartition(ctx, partitionName(from), from, to); err != nil {
+			return created, err
+		}
+		covered = to
+		created++
+	}
+	return created, nil
+}
+
+func (r *Repository) createPartition(ctx context.Context, name string, from, to time.Time) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	table := pgx.Identifier{name}.Sanitize()
+	if _, err := tx.Exec(ctx, fmt.Sprintf(
+		`CREATE TABLE %s PARTITION OF notifications FOR VALUES FROM ('%s') TO ('%s')`,
+		table, from.Format(time.RFC3339), to.Format(time.RFC3339))); err != nil {
+		// Fails when notifications_default already holds rows of the range.
+		return fmt.Errorf("create partition %s: %w", name, err)
+	}
+	if _, err := tx.Exec(ctx, fmt.Sprintf(`
+		CREATE UNIQUE INDEX %s ON %s (source_event_id, tenant_key, user_id)
+		WHERE source_event_id IS NOT NULL`,
+		pgx.Identifier{name + "_source_event_recipient"}.Sanitize(), table)); err != nil {
+		return fmt.Errorf("create partition %s source event index: %w", name, err)
+	}
+	return tx.Commit(ctx)
+}
+
+// dropExpiredPartitions drops the notifications partitions that end before
+// cutoff, leaving a purged audit entry per row. A partition still holding a
+// pinned notification, or one whose retention (fallbackDays when no policy
+// applies) outlives now, is kept for the row-level purge. Returns the number of
+// notifications dropped.
+func (r *Repository) dropExpiredPartitions(ctx context.Context, cutoff, now time.Time, fallbackDays int) (int64, error) {
+	parts, err := r.notificationPartitions(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var dropped int64
+	for _, p := range parts {
+		if p.To == nil || p.To.After(cutoff) {
+			continue
+		}
+		n, err := r.dropPartition(ctx, p.Name, now, fallbackDays)
+		if err != nil {
+			return dropped, err
+		}
+		dropped += n
+	}
+	return dropped, nil
+}
+
+func (r *Repository) dropPartition(ctx context.Context, name string, now time.Time, fallbackDays int) (int64, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+	return dropPartitionTx(ctx, tx, name, now, fallbackDays)
+}
+
+// dropPartitionTx drops partition name in tx and commits, unless a row must be kept.
+func dropPartitionTx(ctx context.Context, tx pgx.Tx, name string, now time.Time, fallbackDays int) (int64, error) {
+	table := pgx.Identifier{name}.Sanitize()
+	// Blocks pin and retention changes on the partition until it is dropped.
+	if _, err := tx.Exec(ctx, `LOCK TABLE `+table+` IN EXCLUSIVE MODE`); err != nil {
+		return 0, fmt.Errorf("lock partition %s: %w", name, err)
+	}
+	var keep bool
+	if err := tx.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM `+table+` n
+			WHERE n.pinned
+			   OR COALESCE(n.created_at >= $1::timestamptz - make_interval(days => `+retentionDaysExpr+`), TRUE)
+		)`, now, fallbackDays).Scan(&keep); err != nil {
+		return 0, fmt.Errorf("check partition %s: %w", name, err)
+	}
+	if keep {
+		return 0, nil
+	}
+
+	tag, err := tx.Exec(ctx, auditInsert(domain.AuditPurged, domain.AuditActorRetention, table, "NOW()"))
+	if err != nil {
+		return 0, fmt.Errorf("audit partition %s: %w", name, err)
+	}
+	// DROP skips the delete trigger that cleans up dependents of deleted rows.
+	for _, dep := range []string{"delivery_outbox", "notification_reactions", "notification_actions"} {
+		if _, err := tx.Exec(ctx,
+			`DELETE FROM `+dep+` WHERE notification_id IN (SELECT id FROM `+table+`)`); err != nil {
+			return 0, fmt.Errorf("purge %s of partition %s: %w", dep, name, err)
+		}
+	}
+	if _, err := tx.Exec(ctx, `DROP TABLE `+table); err != nil {
+		return 0, fmt.Errorf("drop partition %s: %w", name, err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit drop partition %s: %w", name, err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// partitionName names the notifications partition starting at from, e.g. notifications_p2026_11.
+func partitionName(from time.Time) string {
+	return fmt.Sprintf("notifications_p%04d_%02d", from.Year(), from.Month())
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
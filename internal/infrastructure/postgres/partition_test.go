@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// partitionTx records the statements of a partition drop; the keep check
+// returns keep.
+type partitionTx struct {
+	pgx.Tx
+	keep      bool
+	keepQuery string
+	keepArgs  []any
+	execs     []string
+	committed bool
+}
+
+func (tx *partitionTx) Exec(_ context.Context, sql string, _ ...any) (pgconn.CommandTag, error) {
+	tx.execs = append(tx.execs, strings.Join(strings.Fields(sql), " "))
+	if strings.HasPrefix(strings.TrimSpace(sql), "INSERT") {
+		return pgconn.NewCommandTag("INSERT 0 3"), nil
+	}
+	return pgconn.CommandTag{}, nil
+}
+
+func (tx *partitionTx) QueryRow(_ context.Context, sql string, args ...any) pgx.Row {
+	tx.keepQuery, tx.keepArgs = sql, args
+	return keepRow{tx.keep}
+}
+
+func (tx *partitionTx) Commit(context.Context) error {
+	tx.committed = true
+	return nil
+}
+
+type keepRow struct{ keep bool }
+
+func (r keepRow) Scan(dest ...any) error {
+	*dest[0].(*bool) = r.keep
+	return nil
+}
+
+func TestDropPartitionKeepsPinnedRows(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tx := &partitionTx{keep: true}
+	n, err := dropPartitionTx(ctx, tx, "notifications_p2026_01", now, 90)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 || tx.committed {
+		t.Fatalf("dropped %d rows, committed %v: a partition with a row to keep must stay", n, tx.committed)
+	}
+	if len(tx.execs) != 1 || !strings.HasPrefix(tx.execs[0], "LOCK TABLE") {
+		t.Fatalf("statements = %q, want only the lock", tx.execs)
+	}
+	if !strings.Contains(tx.keepQuery, "n.pinned") {
+		t.Errorf("keep check does not look at pinned rows:\n%s", tx.keepQuery)
+	}
+	if len(tx.keepArgs) != 2 || tx.keepArgs[0] != now || tx.keepArgs[1] != 90 {
+		t.Errorf("keep check args = %v, want [%v 90]", tx.keepArgs, now)
+	}
+}
+
+func TestDropPartitionDropsExpiredPartition(t *testing.T) {
+	tx := &partitionTx{}
+	n, err := dropPartitionTx(context.Background(), tx, "notifications_p2026_01", time.Now(), 90)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 || !tx.committed {
+		t.Fatalf("dropped %d rows, committed %v; want 3 audited rows and a commit", n, tx.committed)
+	}
+	// The audit and the dependents are written before the partition goes away.
+	want := []string{"LOCK TABLE", "INSERT INTO notification_audit", "DELETE FROM delivery_outbox",
+		"DELETE FROM notification_reactions", "DELETE FROM notification_actions", `DROP TABLE "notifications_p2026_01"`}
+	if len(tx.execs) != len(want) {
+		t.Fatalf("statements = %q", tx.execs)
+	}
+	for i, prefix := range want {
+		if !strings.HasPrefix(tx.execs[i], prefix) {
+			t.Errorf("statement %d = %q, want prefix %q", i, tx.execs[i], prefix)
+		}
+	}
+}
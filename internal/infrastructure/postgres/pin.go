@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// SetPinned pins or unpins a notification. Per-user rows carry the flag
+// themselves; broadcasts are pinned in the user's broadcast_read_state.
+func (r *Repository) SetPinned(ctx context.Context, id uuid.UUID, tenantKey, userID string, pinned bool) error {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE notifications SET pinned = $4
+		WHERE id = $1 AND tenant_key = $2 AND user_id = $3`, id, tenantKey, userID, pinned)
+	if err != nil {
+		return fmt.Errorf("pin notification: %w", err)
+	}
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+
+	tag, err = r.pool.Exec(ctx, `
+		INSERT INTO broadcast_read_state (broadcast_id, tenant_key, user_id, pinned)
+		SELECT b.id, $2, $3, $4 FROM broadcast_notifications b
+		WHERE b.id = $1 AND (b.tenant_key = $2 OR b.tenant_key IS NULL)
+		ON CONFLICT (broadcast_id, tenant_key, user_id) DO UPDATE SET pinned = EXCLUDED.pinned
+		WHERE broadcast_read_state.deleted_at IS NULL`, id, tenantKey, userID, pinned)
+	if err != nil {
+		return fmt.Errorf("pin broadcast: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrNotificationNotFound
+	}
+	return nil
+}
+
+// notPinned returns the condition keeping pinned rows of table (aliased n) out of
+// a purge: a broadcast is kept while any user has it pinned. Archived rows are
+// never pinned.
+func notPinned(table string) string {
+	switch table {
+	case "notifications":
+		return ` AND NOT n.pinned`
+	case "broadcast_notifications":
+		return ` AND NOT EXISTS (SELECT 1 FROM broadcast_read_state s WHERE s.broadcast_id = n.id AND s.pinned)`
+	}
+	return ""
+}
@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// PolicyRepo implements domain.PolicyRepository.
+type PolicyRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewPolicyRepo creates a new PolicyRepo.
+func NewPolicyRepo(pool *pgxpool.Pool) *PolicyRepo {
+	return &PolicyRepo{pool: pool}
+}
+
+const policyColumns = `tenant_key, module, enabled, created_at, updated_at`
+
+func (r *PolicyRepo) Get(ctx context.Context, tenantKey string) (*domain.DeliveryPolicy, error) {
+	row := r.pool.QueryRow(ctx, `SELECT `+policyColumns+` FROM delivery_policies WHERE tenant_key = $1`, tenantKey)
+	p, err := scanPolicy(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get delivery policy: %w", err)
+	}
+	return p, nil
+}
+
+func (r *PolicyRepo) List(ctx context.Context) ([]domain.DeliveryPolicy, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+policyColumns+` FROM delivery_policies ORDER BY tenant_key`)
+	if err != nil {
+		return nil, fmt.Errorf("list delivery policies: %w", err)
+	}
+	defer rows.Close()
+
+	var results []domain.DeliveryPolicy
+	for rows.Next() {
+		p, err := scanPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *p)
+	}
+	return results, rows.Err()
+}
+
+func (r *PolicyRepo) Upsert(ctx context.Context, p domain.DeliveryPolicy) (*domain.DeliveryPolicy, error) {
+	row := r.pool.QueryRow(ctx, `
+		INSERT INTO delivery_policies (tenant_key, module, enabled)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (tenant_key) DO UPDATE SET
+			module     = EXCLUDED.module,
+			enabled    = EXCLUDED.enabled,
+			updated_at = NOW()
+		RETURNING `+policyColumns, p.TenantKey, p.Module, p.Enabled)
+	saved, err := scanPolicy(row)
+	if err != nil {
+		return nil, fmt.Errorf("upsert delivery policy: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *PolicyRepo) Delete(ctx context.Context, tenantKey string) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM delivery_policies WHERE tenant_key = $1`, tenantKey)
+	return err
+}
+
+func scanPolicy(row scannable) (*domain.DeliveryPolicy, error) {
+	var p domain.DeliveryPolicy
+	if err := row.Scan(&p.TenantKey, &p.Module, &p.Enabled, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
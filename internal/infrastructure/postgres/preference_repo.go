@@ -23,11 +23,11 @@ func NewPreferenceRepo(pool *pgxpool.Pool) *PreferenceRepo {
 
 func (r *PreferenceRepo) GetByUser(ctx context.Context, tenantKey, userID string) ([]domain.Preference, error) {
 	rows, err := r.pool.Query(ctx, `
-		SELECT id, tenant_key, user_id, type, channel_in_app, channel_email,
+		SELECT id, tenant_key, user_id, type, category, channel_in_app, channel_email,
 		       quiet_hours_start, quiet_hours_end, created_at, updated_at
 		FROM notification_preferences
 		WHERE tenant_key = $1 AND user_id = $2
-		ORDER BY type
+		ORDER BY type, category
 	`, tenantKey, userID)
 	if err != nil {
 		return nil, err
@@ -45,13 +45,16 @@ func (r *PreferenceRepo) GetByUser(ctx context.Context, tenantKey, userID string
 	return results, nil
 }
 
-func (r *PreferenceRepo) GetByUserAndType(ctx context.Context, tenantKey, userID string, notifType domain.NotificationType) (*domain.Preference, error) {
+func (r *PreferenceRepo) GetByUserAndCategory(ctx context.Context, tenantKey, userID string, notifType domain.NotificationType, category string) (*domain.Preference, error) {
+	// The category row sorts before the type-level ('') row.
 	row := r.pool.QueryRow(ctx, `
-		SELECT id, tenant_key, user_id, type, channel_in_app, channel_email,
+		SELECT id, tenant_key, user_id, type, category, channel_in_app, channel_email,
 		       quiet_hours_start, quiet_hours_end, created_at, updated_at
 		FROM notification_preferences
-		WHERE tenant_key = $1 AND user_id = $2 AND type = $3
-	`, tenantKey, userID, string(notifType))
+		WHERE tenant_key = $1 AND user_id = $2 AND type = $3 AND category IN ('', $4)
+		ORDER BY category DESC
+		LIMIT 1
+	`, tenantKey, userID, string(notifType), category)
 
 	p, err := scanPreference(row)
 	if err != nil {
@@ -73,18 +76,18 @@ func (r *PreferenceRepo) Upsert(ctx context.Context, p domain.Preference) (*doma
 	}
 
 	row := r.pool.QueryRow(ctx, `
-		INSERT INTO notification_preferences (id, tenant_key, user_id, type, channel_in_app, channel_email,
+		INSERT INTO notification_preferences (id, tenant_key, user_id, type, category, channel_in_app, channel_email,
 		                                      quiet_hours_start, quiet_hours_end, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-		ON CONFLICT (tenant_key, user_id, type) DO UPDATE SET
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (tenant_key, user_id, type, category) DO UPDATE SET
 			channel_in_app = EXCLUDED.channel_in_app,
 			channel_email  = EXCLUDED.channel_email,
 			quiet_hours_start = EXCLUDED.quiet_hours_start,
 			quiet_hours_end   = EXCLUDED.quiet_hours_end,
 			updated_at = EXCLUDED.updated_at
-		RETURNING id, tenant_key, user_id, type, channel_in_app, channel_email,
+		RETURNING id, tenant_key, user_id, type, category, channel_in_app, channel_email,
 		          quiet_hours_start, quiet_hours_end, created_at, updated_at
-	`, idStr, p.TenantKey, p.UserID, string(p.Type), p.ChannelInApp, p.ChannelEmail,
+	`, idStr, p.TenantKey, p.UserID, string(p.Type), p.Category, p.ChannelInApp, p.ChannelEmail,
 		p.QuietHoursStart, p.QuietHoursEnd, now, now)
 
 	return scanPreference(row)
@@ -111,18 +114,18 @@ func (r *PreferenceRepo) BatchUpsert(ctx context.Context, prefs []domain.Prefere
 		}
 
 		row := tx.QueryRow(ctx, `
-			INSERT INTO notification_preferences (id, tenant_key, user_id, type, channel_in_app, channel_email,
+			INSERT INTO notification_preferences (id, tenant_key, user_id, type, category, channel_in_app, channel_email,
 			                                      quiet_hours_start, quiet_hours_end, created_at, updated_at)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-			ON CONFLICT (tenant_key, user_id, type) DO UPDATE SET
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			ON CONFLICT (tenant_key, user_id, type, category) DO UPDATE SET
 				channel_in_app = EXCLUDED.channel_in_app,
 				channel_email  = EXCLUDED.channel_email,
 				quiet_hours_start = EXCLUDED.quiet_hours_start,
 				quiet_hours_end   = EXCLUDED.quiet_hours_end,
 				updated_at = EXCLUDED.updated_at
-			RETURNING id, tenant_key, user_id, type, channel_in_app, channel_email,
+			RETURNING id, tenant_key, user_id, type, category, channel_in_app, channel_email,
 			          quiet_hours_start, quiet_hours_end, created_at, updated_at
-		`, idStr, p.TenantKey, p.UserID, string(p.Type), p.ChannelInApp, p.ChannelEmail,
+		`, idStr, p.TenantKey, p.UserID, string(p.Type), p.Category, p.ChannelInApp, p.ChannelEmail,
 			p.QuietHoursStart, p.QuietHoursEnd, now, now)
 
 		saved, err := scanPreference(row)
@@ -142,7 +145,7 @@ func (r *PreferenceRepo) BatchUpsert(ctx context.Context, prefs []domain.Prefere
 func scanPreference(row scannable) (*domain.Preference, error) {
 	var p domain.Preference
 	err := row.Scan(
-		&p.ID, &p.TenantKey, &p.UserID, &p.Type,
+		&p.ID, &p.TenantKey, &p.UserID, &p.Type, &p.Category,
 		&p.ChannelInApp, &p.ChannelEmail,
 		&p.QuietHoursStart, &p.QuietHoursEnd,
 		&p.CreatedAt, &p.UpdatedAt,
@@ -0,0 +1,178 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// QuotaRepo implements domain.QuotaRepository.
+type QuotaRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewQuotaRepo creates a new QuotaRepo.
+func NewQuotaRepo(pool *pgxpool.Pool) *QuotaRepo {
+	return &QuotaRepo{pool: pool}
+}
+
+const quotaColumns = `tenant_key, monthly_limit, overage, updated_at`
+
+func (r *QuotaRepo) ListQuotas(ctx context.Context) ([]domain.TenantQuota, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+quotaColumns+` FROM tenant_quotas ORDER BY tenant_key`)
+	if err != nil {
+		return nil, fmt.Errorf("list tenant quotas: %w", err)
+	}
+	defer rows.Close()
+
+	var results []domain.TenantQuota
+	for rows.Next() {
+		q, err := scanQuota(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *q)
+	}
+	return results, rows.Err()
+}
+
+func (r *QuotaRepo) GetQuota(ctx context.Context, tenantKey string) (*domain.TenantQuota, error) {
+	q, err := scanQuota(r.pool.QueryRow(ctx, `SELECT `+quotaColumns+` FROM tenant_quotas WHERE tenant_key = $1`, tenantKey))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get tenant quota: %w", err)
+	}
+	return q, nil
+}
+
+func (r *QuotaRepo) UpsertQuota(ctx context.Context, q domain.TenantQuota) (*domain.TenantQuota, error) {
+	row := r.pool.QueryRow(ctx, `
+		INSERT INTO tenant_quotas (tenant_key, monthly_limit, overage)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (tenant_key) DO UPDATE SET
+			monthly_limit = EXCLUDED.monthly_limit,
+			overage       = EXCLUDED.overage,
+			updated_at    = NOW()
+		RETURNING `+quotaColumns, q.TenantKey, q.MonthlyLimit, string(q.Overage))
+	saved, err := scanQuota(row)
+	if err != nil {
+		return nil, fmt.Errorf("upsert tenant quota: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *QuotaRepo) DeleteQuota(ctx context.Context, tenantKey string) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM tenant_quotas WHERE tenant_key = $1`, tenantKey)
+	return err
+}
+
+func (r *QuotaRepo) AddUsage(ctx context.Context, u domain.TenantUsage) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO tenant_usage (tenant_key, month, created, rejected, queued)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (tenant_key, month) DO UPDATE SET
+			created    = tenant_usage.created + EXCLUDED.created,
+			rejected   = tenant_usage.rejected + EXCLUDED.rejected,
+			queued     = tenant_usage.queued + EXCLUDED.queued,
+			updated_at = NOW()`, u.TenantKey, u.Month, u.Created, u.Rejected, u.Queued)
+	if err != nil {
+		return fmt.Errorf("add tenant usage: %w", err)
+	}
+	return nil
+}
+
+func (r *QuotaRepo) Usage(ctx context.Context, tenantKey string, from, to time.Time) ([]domain.TenantUsage, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT tenant_key, month, created, rejected, queued FROM tenant_usage
+		WHERE tenant_key = $1 AND month BETWEEN $2 AND $3
+		ORDER BY month DESC`, tenantKey, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("list tenant usage: %w", err)
+	}
+	defer rows.Close()
+
+	var results []domain.TenantUsage
+	for rows.Next() {
+		var u domain.TenantUsage
+		if err := rows.Scan(&u.TenantKey, &u.Month, &u.Created, &u.Rejected, &u.Queued); err != nil {
+			return nil, fmt.Errorf("scan tenant usage: %w", err)
+		}
+		results = append(results, u)
+	}
+	return results, rows.Err()
+}
+
+func (r *QuotaRepo) Hold(ctx context.Context, tenantKey string, input domain.FanoutInput, recipients int) error {
+	inputJSON, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("marshal held input: %w", err)
+	}
+	if _, err := r.pool.Exec(ctx, `
+		INSERT INTO quota_held_notifications (tenant_key, input, recipients) VALUES ($1, $2, $3)`,
+		tenantKey, inputJSON, recipients); err != nil {
+		return fmt.Errorf("hold notification: %w", err)
+	}
+	return nil
+}
+
+func (r *QuotaRepo) HeldTenants(ctx context.Context) ([]string, error) {
+	rows, err := r.pool.Query(ctx, `SELECT DISTINCT tenant_key FROM quota_held_notifications ORDER BY tenant_key`)
+	if err != nil {
+		return nil, fmt.Errorf("list tenants with held notifications: %w", err)
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+func (r *QuotaRepo) Held(ctx context.Context, tenantKey string, limit int) ([]domain.HeldFanout, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, input, recipients FROM quota_held_notifications
+		WHERE tenant_key = $1 ORDER BY id LIMIT $2`, tenantKey, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list held notifications: %w", err)
+	}
+	defer rows.Close()
+	var results []domain.HeldFanout
+	for rows.Next() {
+		var (
+			h   domain.HeldFanout
+			raw []byte
+		)
+		if err := rows.Scan(&h.ID, &raw, &h.Recipients); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(raw, &h.Input); err != nil {
+			return nil, fmt.Errorf("unmarshal held input: %w", err)
+		}
+		results = append(results, h)
+	}
+	return results, rows.Err()
+}
+
+func (r *QuotaRepo) CountHeld(ctx context.Context, tenantKey string) (int64, error) {
+	var n int64
+	if err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM quota_held_notifications WHERE tenant_key = $1`, tenantKey).Scan(&n); err != nil {
+		return 0, fmt.Errorf("count held notifications: %w", err)
+	}
+	return n, nil
+}
+
+func (r *QuotaRepo) Release(ctx context.Context, id int64) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM quota_held_notifications WHERE id = $1`, id)
+	return err
+}
+
+func scanQuota(row scannable) (*domain.TenantQuota, error) {
+	var q domain.TenantQuota
+	if err := row.Scan(&q.TenantKey, &q.MonthlyLimit, &q.Overage, &q.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// ReactionRepo implements domain.ReactionRepository.
+type ReactionRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewReactionRepo creates a new ReactionRepo.
+func NewReactionRepo(pool *pgxpool.Pool) *ReactionRepo {
+	return &ReactionRepo{pool: pool}
+}
+
+// Create inserts a reaction. A second reaction by the same user is ignored.
+func (r *ReactionRepo) Create(ctx context.Context, in domain.Reaction) (*domain.Reaction, error) {
+	var sourceEventID *string
+	if in.SourceEventID != "" {
+		sourceEventID = &in.SourceEventID
+	}
+
+	row := r.pool.QueryRow(ctx, `
+		INSERT INTO notification_reactions (notification_id, tenant_key, user_id, response, comment, source_event_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (notification_id, user_id) DO NOTHING
+		RETURNING id, notification_id, tenant_key, user_id, response, comment, source_event_id, created_at
+	`, in.NotificationID, in.TenantKey, in.UserID, string(in.Response), in.Comment, sourceEventID)
+
+	saved, err := scanReaction(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("insert reaction: %w", err)
+	}
+	return saved, nil
+}
+
+// ListByNotification returns all reactions for a notification, oldest first.
+func (r *ReactionRepo) ListByNotification(ctx context.Context, notificationID uuid.UUID) ([]domain.Reaction, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, notification_id, tenant_key, user_id, response, comment, source_event_id, created_at
+		FROM notification_reactions
+		WHERE notification_id = $1
+		ORDER BY created_at
+	`, notificationID)
+	if err != nil {
+		return nil, fmt.Errorf("list reactions: %w", err)
+	}
+	defer rows.Close()
+	return collectReactions(rows)
+}
+
+// ListBySourceEvent returns all reactions linked to a source event within a tenant.
+func (r *ReactionRepo) ListBySourceEvent(ctx context.Context, tenantKey, sourceEventID string) ([]domain.Reaction, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, notification_id, tenant_key, user_id, response, comment, source_event_id, created_at
+		FROM notification_reactions
+		WHERE tenant_key = $1 AND source_event_id = $2
+		ORDER BY created_at
+	`, tenantKey, sourceEventID)
+	if err != nil {
+		return nil, fmt.Errorf("list reactions by source event: %w", err)
+	}
+	defer rows.Close()
+	return collectReactions(rows)
+}
+
+func collectReactions(rows pgx.Rows) ([]domain.Reaction, error) {
+	var results []domain.Reaction
+	for rows.Next() {
+		re, err := scanReaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *re)
+	}
+	return results, rows.Err()
+}
+
+func scanReaction(row scannable) (*domain.Reaction, error) {
+	var re domain.Reaction
+	var sourceEventID *string
+	err := row.Scan(&re.ID, &re.NotificationID, &re.TenantKey, &re.UserID,
+		&re.Response, &re.Comment, &sourceEventID, &re.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if sourceEventID != nil {
+		re.SourceEventID = *sourceEventID
+	}
+	return &re, nil
+}
@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultReplicaRetry is how long reads stay on the primary after the read
+// replica failed.
+const DefaultReplicaRetry = 30 * time.Second
+
+// querier runs read queries; implemented by *pgxpool.Pool and pgx.Tx.
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// readReplica is an optional read-only pool with its failure backoff.
+type readReplica struct {
+	pool      *pgxpool.Pool
+	retry     time.Duration
+	downUntil atomic.Int64 // unix nanoseconds
+}
+
+// SetReadReplica routes the inbox reads (List, CountUnread, CountGroups, GetByID)
+// to pool, a streaming replica of the primary. When the replica cannot be
+// reached, reads fall back to the primary for retry (DefaultReplicaRetry when
+// <= 0). A nil pool reads from the primary.
+func (r *Repository) SetReadReplica(pool *pgxpool.Pool, retry time.Duration) {
+	if pool == nil {
+		r.replica = nil
+		return
+	}
+	if retry <= 0 {
+		retry = DefaultReplicaRetry
+	}
+	r.replica = &readReplica{pool: pool, retry: retry}
+}
+
+// read runs fn against the read replica, or the primary when there is none, it
+// is backing off, or it fails with a connection-level error.
+func (r *Repository) read(ctx context.Context, fn func(db querier) error) error {
+	if rep := r.replica; rep != nil && time.Now().UnixNano() >= rep.downUntil.Load() {
+		err := fn(rep.pool)
+		if err == nil || ctx.Err() != nil || !replicaUnavailable(err) {
+			return err
+		}
+		// Later reads skip the replica until retry has passed, so this logs once per outage window.
+		rep.downUntil.Store(time.Now().Add(rep.retry).UnixNano())
+		log.Warn().Err(err).Dur("retry_in", rep.retry).Msg("read replica unavailable, reading from primary")
+	}
+	return fn(r.pool)
+}
+
+// replicaUnavailable reports whether err means the replica could not serve the
+// query at all, as opposed to the query failing.
+func replicaUnavailable(err error) bool {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// 08: connection exception; 57: shutdown, cannot connect now, recovery conflicts.
+		return strings.HasPrefix(pgErr.Code, "08") || strings.HasPrefix(pgErr.Code, "57")
+	}
+	return true
+}
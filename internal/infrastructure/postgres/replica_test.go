@@ -0,0 +1,28 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestReplicaUnavailable(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{pgx.ErrNoRows, false},
+		{fmt.Errorf("count notifications: %w", &pgconn.PgError{Code: "42P01"}), false},
+		{&pgconn.PgError{Code: "57P03"}, true}, // cannot_connect_now
+		{&pgconn.PgError{Code: "08006"}, true}, // connection_failure
+		{errors.New("dial tcp: connection refused"), true},
+	}
+	for _, c := range cases {
+		if got := replicaUnavailable(c.err); got != c.want {
+			t.Errorf("replicaUnavailable(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+}
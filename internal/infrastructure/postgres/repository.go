@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,14 +17,82 @@ import (
 
 // Repository is the PostgreSQL implementation of domain.Repository.
 type Repository struct {
-	pool *pgxpool.Pool
+	pool          *pgxpool.Pool
+	replica       *readReplica
+	copyThreshold int
+	purgeBatch    int
+	idGen         domain.IDGenerator
+	clock         domain.Clock
 }
 
+// DefaultCopyThreshold is the batch size from which BatchCreate switches to COPY.
+const DefaultCopyThreshold = 500
+
 // New creates a new postgres Repository.
 func New(pool *pgxpool.Pool) *Repository {
-	return &Repository{pool: pool}
+	return &Repository{pool: pool, copyThreshold: DefaultCopyThreshold, clock: domain.SystemClock{}}
+}
+
+// SetClock sets the clock for read/delete times and purge cutoffs.
+// Outbox leases stay on database time, which every instance shares.
+func (r *Repository) SetClock(c domain.Clock) {
+	r.clock = c
+}
+
+// SetCopyThreshold sets the batch size from which BatchCreate uses BatchCreateCopy.
+// Values <= 0 disable the COPY path.
+func (r *Repository) SetCopyThreshold(n int) {
+	r.copyThreshold = n
 }
 
+// SetPurgeBatchSize caps the rows each purge DELETE statement removes; purges
+// repeat the statement until a batch comes back short. Values <= 0 (default)
+// delete everything in one statement.
+func (r *Repository) SetPurgeBatchSize(n int) {
+	r.purgeBatch = n
+}
+
+// purge deletes the rows of table (aliased n) matching cond through
+// auditedPurge, purgeBatch rows per statement so no single statement holds its
+// locks and WAL for the whole backlog.
+func (r *Repository) purge(ctx context.Context, table, cond, actor string, args ...any) (int64, error) {
+	del := `DELETE FROM ` + table + ` n WHERE ` + cond
+	if r.purgeBatch > 0 {
+		del = `DELETE FROM ` + table + ` n WHERE n.id = ANY(ARRAY(
+			SELECT n.id FROM ` + table + ` n WHERE ` + cond + ` LIMIT ` + strconv.Itoa(r.purgeBatch) + `))`
+	}
+	query := auditedPurge(table, del, actor)
+	var purged int64
+	for {
+		tag, err := r.pool.Exec(ctx, query, args...)
+		if err != nil {
+			return purged, err
+		}
+		purged += tag.RowsAffected()
+		if r.purgeBatch <= 0 || tag.RowsAffected() < int64(r.purgeBatch) {
+			return purged, nil
+		}
+	}
+}
+
+// SetIDGenerator generates notification IDs in the application. Nil (default) keeps
+// the database default, uuidv7().
+func (r *Repository) SetIDGenerator(gen domain.IDGenerator) {
+	r.idGen = gen
+}
+
+// newID returns a generated ID, or nil to let the database assign one.
+func (r *Repository) newID() *uuid.UUID {
+	if r.idGen == nil {
+		return nil
+	}
+	id := r.idGen.NewID()
+	return &id
+}
+
+// notificationColumns is the column list matching scanNotification.
+const notificationColumns = "id, tenant_key, user_id, type, title, body, metadata, is_read, read_at, created_at, source_event_id, priority, category"
+
 // Create inserts a new notification record.
 func (r *Repository) Create(ctx context.Context, input domain.CreateNotificationInput) (*domain.Notification, error) {
 	metaJSON, _ := json.Marshal(input.Metadata)
@@ -32,42 +102,45 @@ func (r *Repository) Create(ctx context.Context, input domain.CreateNotification
 		sourceEventID = &input.SourceEventID
 	}
 
-	var n domain.Notification
-	err := r.pool.QueryRow(ctx, `
-		INSERT INTO notifications (tenant_key, user_id, type, title, body, metadata, source_event_id)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		ON CONFLICT (source_event_id) WHERE source_event_id IS NOT NULL DO NOTHING
-		RETURNING id, tenant_key, user_id, type, title, body, metadata, is_read, read_at, created_at, source_event_id
-	`, input.TenantKey, input.UserID, string(input.Type), input.Title, input.Body, metaJSON, sourceEventID).
-		Scan(&n.ID, &n.TenantKey, &n.UserID, &n.Type, &n.Title, &n.Body,
-			&metaJSON, &n.IsRead, &n.ReadAt, &n.CreatedAt, &sourceEventID)
+	// The source_event_id unique index exists per partition only (a partitioned
+	// table cannot have one without created_at), hence the targetless ON CONFLICT.
+	row := r.pool.QueryRow(ctx, `
+		WITH ins AS (
+			INSERT INTO notifications (id, tenant_key, user_id, type, title, body, metadata, source_event_id, priority, category)
+			VALUES (COALESCE($9::uuid, uuidv7()), $1, $2, $3, $4, $5, $6, $7, $8, $10)
+			ON CONFLICT DO NOTHING
+			RETURNING `+notificationColumns+`
+		), outbox AS (
+			INSERT INTO delivery_outbox (notification_id) SELECT id FROM ins
+		), audit AS (
+			`+auditInsert(domain.AuditCreated, domain.AuditActorSystem, "ins", "created_at")+`
+		)
+		SELECT `+notificationColumns+` FROM ins`,
+		input.TenantKey, input.UserID, string(input.Type), input.Title, input.Body, metaJSON, sourceEventID,
+		string(input.Priority.OrDefault()), r.newID(), input.Category)
 
+	n, err := scanNotification(row)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			// Duplicate source_event_id, idempotent — not an error
+			// Already delivered to this recipient (source_event_id), idempotent — not an error
 			return nil, nil
 		}
 		return nil, fmt.Errorf("insert notification: %w", err)
 	}
-
-	if sourceEventID != nil {
-		n.SourceEventID = *sourceEventID
-	}
-	if len(metaJSON) > 0 {
-		_ = json.Unmarshal(metaJSON, &n.Metadata)
-	}
-
-	return &n, nil
+	return n, nil
 }
 
-func (r *Repository) BatchCreate(ctx context.Context, inputs []domain.CreateNotificationInput) ([]*domain.Notification, error) {
+func (r *Repository) BatchCreate(ctx context.Context, inputs []domain.CreateNotificationInput) (domain.BatchResult, error) {
 	if len(inputs) == 0 {
-		return nil, nil
+		return domain.BatchResult{}, nil
+	}
+	if r.copyThreshold > 0 && len(inputs) >= r.copyThreshold {
+		return r.BatchCreateCopy(ctx, inputs)
 	}
 
-	// Build VALUES list: ($1,$2,...), ($9,$10,...) etc.
-	// Each row has 7 params: tenant_key, user_id, type, title, body, metadata, source_event_id
-	const paramsPerRow = 7
+	// Build VALUES list: ($1,$2,...), ($10,$11,...) etc.
+	// Each row has 10 params: id, tenant_key, user_id, type, title, body, metadata, source_event_id, priority, category
+	const paramsPerRow = 10
 	args := make([]any, 0, len(inputs)*paramsPerRow)
 	valuesClauses := make([]string, 0, len(inputs))
 
@@ -80,24 +153,31 @@ func (r *Repository) BatchCreate(ctx context.Context, inputs []domain.CreateNoti
 		}
 
 		valuesClauses = append(valuesClauses, fmt.Sprintf(
-			"($%d,$%d,$%d,$%d,$%d,$%d,$%d)",
-			base+1, base+2, base+3, base+4, base+5, base+6, base+7,
+			"(COALESCE($%d::uuid, uuidv7()),$%d,$%d,$%d,$%d,$%d,$%d,$%d,$%d,$%d)",
+			base+1, base+2, base+3, base+4, base+5, base+6, base+7, base+8, base+9, base+10,
 		))
-		args = append(args,
+		args = append(args, r.newID(),
 			input.TenantKey, input.UserID, string(input.Type),
 			input.Title, input.Body, metaJSON, sourceEventID,
+			string(input.Priority.OrDefault()), input.Category,
 		)
 	}
 
 	// Join all value tuples into a single INSERT statement.
-	query := "INSERT INTO notifications (tenant_key, user_id, type, title, body, metadata, source_event_id) VALUES " +
+	// The outbox and audit CTEs run in the same statement, so notifications, their
+	// delivery entries and created audit entries are committed atomically.
+	query := "WITH ins AS (" +
+		"INSERT INTO notifications (id, tenant_key, user_id, type, title, body, metadata, source_event_id, priority, category) VALUES " +
 		joinStrings(valuesClauses, ",") +
-		" ON CONFLICT (source_event_id) WHERE source_event_id IS NOT NULL DO NOTHING " +
-		"RETURNING id, tenant_key, user_id, type, title, body, metadata, is_read, read_at, created_at, source_event_id"
+		" ON CONFLICT DO NOTHING " +
+		"RETURNING " + notificationColumns +
+		"), outbox AS (INSERT INTO delivery_outbox (notification_id) SELECT id FROM ins), " +
+		"audit AS (" + auditInsert(domain.AuditCreated, domain.AuditActorSystem, "ins", "created_at") + ") " +
+		"SELECT " + notificationColumns + " FROM ins"
 
 	rows, err := r.pool.Query(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("batch insert notifications query failed: %w", err)
+		return domain.BatchResult{}, fmt.Errorf("batch insert notifications query failed: %w", err)
 	}
 	defer rows.Close()
 
@@ -105,12 +185,123 @@ func (r *Repository) BatchCreate(ctx context.Context, inputs []domain.CreateNoti
 	for rows.Next() {
 		n, err := scanNotification(rows)
 		if err != nil {
-			return nil, err
+			return domain.BatchResult{}, err
+		}
+		insertedResults = append(insertedResults, n)
+	}
+
+	if err := rows.Err(); err != nil {
+		return domain.BatchResult{}, fmt.Errorf("batch insert notifications: %w", err)
+	}
+	return batchResult(inputs, insertedResults), nil
+}
+
+// BatchCreateCopy is BatchCreate for large batches: rows are streamed with COPY into a
+// transaction-scoped staging table, then moved into notifications in one statement so
+// the source_event_id conflict handling and outbox entries match BatchCreate.
+func (r *Repository) BatchCreateCopy(ctx context.Context, inputs []domain.CreateNotificationInput) (domain.BatchResult, error) {
+	if len(inputs) == 0 {
+		return domain.BatchResult{}, nil
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return domain.BatchResult{}, fmt.Errorf("begin copy batch: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `
+		CREATE TEMP TABLE notifications_staging (
+			id              UUID,
+			tenant_key      VARCHAR(100),
+			user_id         VARCHAR(255),
+			type            VARCHAR(50),
+			title           TEXT,
+			body            TEXT,
+			metadata        JSONB,
+			source_event_id VARCHAR(255),
+			priority        VARCHAR(10),
+			category        VARCHAR(100)
+		) ON COMMIT DROP
+	`); err != nil {
+		return domain.BatchResult{}, fmt.Errorf("create staging table: %w", err)
+	}
+
+	copyColumns := []string{"id", "tenant_key", "user_id", "type", "title", "body", "metadata", "source_event_id", "priority", "category"}
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"notifications_staging"}, copyColumns,
+		pgx.CopyFromSlice(len(inputs), func(i int) ([]any, error) {
+			input := inputs[i]
+			metaJSON, _ := json.Marshal(input.Metadata)
+			var sourceEventID *string
+			if input.SourceEventID != "" {
+				sourceEventID = &input.SourceEventID
+			}
+			return []any{
+				r.newID(), input.TenantKey, input.UserID, string(input.Type),
+				input.Title, input.Body, metaJSON, sourceEventID,
+				string(input.Priority.OrDefault()), input.Category,
+			}, nil
+		})); err != nil {
+		return domain.BatchResult{}, fmt.Errorf("copy notifications: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, `
+		WITH ins AS (
+			INSERT INTO notifications (id, tenant_key, user_id, type, title, body, metadata, source_event_id, priority, category)
+			SELECT COALESCE(id, uuidv7()), tenant_key, user_id, type, title, body, metadata, source_event_id, priority, category
+			FROM notifications_staging
+			ON CONFLICT DO NOTHING
+			RETURNING `+notificationColumns+`
+		), outbox AS (
+			INSERT INTO delivery_outbox (notification_id) SELECT id FROM ins
+		), audit AS (
+			`+auditInsert(domain.AuditCreated, domain.AuditActorSystem, "ins", "created_at")+`
+		)
+		SELECT `+notificationColumns+` FROM ins
+	`)
+	if err != nil {
+		return domain.BatchResult{}, fmt.Errorf("move staged notifications: %w", err)
+	}
+
+	var insertedResults []*domain.Notification
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			rows.Close()
+			return domain.BatchResult{}, err
 		}
 		insertedResults = append(insertedResults, n)
 	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return domain.BatchResult{}, fmt.Errorf("move staged notifications: %w", err)
+	}
 
-	return insertedResults, nil
+	if err := tx.Commit(ctx); err != nil {
+		return domain.BatchResult{}, fmt.Errorf("commit copy batch: %w", err)
+	}
+	return batchResult(inputs, insertedResults), nil
+}
+
+// batchResult pairs the rows a batch insert returned with its inputs: an input
+// without a returned row of the same tenant, user and source_event_id hit the
+// source_event_id conflict (or repeated an earlier input of the batch).
+func batchResult(inputs []domain.CreateNotificationInput, inserted []*domain.Notification) domain.BatchResult {
+	type key struct{ tenant, user, source string }
+	returned := make(map[key]int, len(inserted))
+	for _, n := range inserted {
+		returned[key{n.TenantKey, n.UserID, n.SourceEventID}]++
+	}
+	result := domain.BatchResult{Inserted: inserted}
+	for i, in := range inputs {
+		k := key{in.TenantKey, in.UserID, in.SourceEventID}
+		if returned[k] > 0 {
+			returned[k]--
+			continue
+		}
+		result.Duplicates = append(result.Duplicates, i)
+	}
+	return result
 }
 
 // joinStrings joins a slice of strings with a separator (avoids importing strings package).
@@ -127,33 +318,114 @@ func joinStrings(parts []string, sep string) string {
 
 
 
-// List fetches paginated notifications for a user.
+// List fetches paginated notifications for a user, pinned ones first. With f.AsOf
+// set, the inbox is reconstructed as it was at that instant.
 func (r *Repository) List(ctx context.Context, f domain.NotificationFilter) ([]*domain.Notification, error) {
-	query := `
-		SELECT id, tenant_key, user_id, type, title, body, metadata, is_read, read_at, created_at, source_event_id
-		FROM notifications
-		WHERE tenant_key = $1 AND user_id = $2
-	`
+	var results []*domain.Notification
+	err := r.read(ctx, func(db querier) error {
+		var err error
+		results, err = r.list(ctx, db, f)
+		return err
+	})
+	return results, err
+}
+
+func (r *Repository) list(ctx context.Context, db querier, f domain.NotificationFilter) ([]*domain.Notification, error) {
+	source := inboxSource
 	args := []any{f.TenantKey, f.UserID}
-	paramIdx := 3
+	if f.AsOf != nil {
+		source = inboxAsOfSource
+		args = append(args, *f.AsOf)
+	}
+	conditions, args := inboxConditions(f, args)
+
+	query := `
+		SELECT pinned, ` + notificationColumns + `
+		FROM ` + source + `
+		WHERE TRUE` + conditions +
+		fmt.Sprintf(" ORDER BY pinned DESC, created_at DESC LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+	args = append(args, f.Limit, f.Offset)
+
+	results, err := r.queryInbox(ctx, db, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list notifications: %w", err)
+	}
+	if f.AsOf != nil || len(results) >= f.Limit {
+		return results, nil
+	}
 
+	// The hot page ran short: continue into the archive, which only holds rows
+	// older than the user's hot rows.
+	archiveOffset := 0
+	if len(results) == 0 && f.Offset > 0 {
+		hot, err := r.countInbox(ctx, db, f)
+		if err != nil {
+			return nil, err
+		}
+		archiveOffset = max(0, f.Offset-hot)
+	}
+	archived, err := r.listArchived(ctx, db, f, archiveOffset, f.Limit-len(results))
+	if err != nil {
+		return nil, err
+	}
+	return append(results, archived...), nil
+}
+
+// inboxConditions appends the optional filters of f as AND clauses, numbering
+// parameters after the ones already in args.
+func inboxConditions(f domain.NotificationFilter, args []any) (string, []any) {
+	var conditions string
 	if f.IsRead != nil {
-		query += fmt.Sprintf(" AND is_read = $%d", paramIdx)
 		args = append(args, *f.IsRead)
-		paramIdx++
+		conditions += fmt.Sprintf(" AND is_read = $%d", len(args))
+	}
+	if f.Pinned != nil {
+		args = append(args, *f.Pinned)
+		conditions += fmt.Sprintf(" AND pinned = $%d", len(args))
 	}
 	if f.Type != "" {
-		query += fmt.Sprintf(" AND type = $%d", paramIdx)
 		args = append(args, string(f.Type))
-		paramIdx++
+		conditions += fmt.Sprintf(" AND type = $%d", len(args))
+	}
+	if prefix, ok := domain.CategoryPrefix(f.Category); ok {
+		args = append(args, prefix)
+		conditions += fmt.Sprintf(" AND (category = $%d OR category LIKE $%d || '.%%')", len(args), len(args))
+	} else if f.Category != "" {
+		args = append(args, f.Category)
+		conditions += fmt.Sprintf(" AND category = $%d", len(args))
 	}
+	if f.EntityType != "" && f.EntityID != "" {
+		args = append(args, f.EntityType, f.EntityID)
+		conditions += fmt.Sprintf(" AND entity_type = $%d AND entity_id = $%d", len(args)-1, len(args))
+	}
+	return conditions, args
+}
 
-	query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d OFFSET $%d", paramIdx, paramIdx+1)
-	args = append(args, f.Limit, f.Offset)
+// queryInbox runs a query selecting pinned before the notification columns.
+func (r *Repository) queryInbox(ctx context.Context, db querier, query string, args ...any) ([]*domain.Notification, error) {
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	rows, err := r.pool.Query(ctx, query, args...)
+	var results []*domain.Notification
+	for rows.Next() {
+		var pinned bool
+		n, err := scanNotification(leadingRow{rows: rows, lead: []any{&pinned}})
+		if err != nil {
+			return nil, err
+		}
+		n.Pinned = pinned
+		results = append(results, n)
+	}
+	return results, rows.Err()
+}
+
+func (r *Repository) queryNotifications(ctx context.Context, db querier, query string, args ...any) ([]*domain.Notification, error) {
+	rows, err := db.Query(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("list notifications: %w", err)
+		return nil, err
 	}
 	defer rows.Close()
 
@@ -165,13 +437,145 @@ func (r *Repository) List(ctx context.Context, f domain.NotificationFilter) ([]*
 		}
 		results = append(results, n)
 	}
-	return results, nil
+	return results, rows.Err()
+}
+
+// inboxSource is a user's inbox: their own rows plus visible broadcasts with the
+// user's read state applied. Broadcasts whose type or category the user muted in-app
+// are hidden; a category preference overrides the type-level one. Snoozed
+// notifications are hidden until the wake-up scheduler clears snoozed_until.
+// Expects the tenant key as $1 and the user ID as $2.
+const inboxSource = `(
+		SELECT ` + notificationColumns + `, pinned, entity_type, entity_id
+		FROM notifications
+		WHERE tenant_key = $1 AND user_id = $2 AND snoozed_until IS NULL
+		UNION ALL
+		SELECT b.id, $1::varchar, $2::varchar, b.type, b.title, b.body, b.metadata,
+			s.read_at IS NOT NULL, s.read_at, b.created_at, b.source_event_id, b.priority, b.category,
+			COALESCE(s.pinned, FALSE), b.entity_type, b.entity_id
+		FROM broadcast_notifications b
+		LEFT JOIN broadcast_read_state s
+			ON s.broadcast_id = b.id AND s.tenant_key = $1 AND s.user_id = $2
+		WHERE (b.tenant_key = $1 OR b.tenant_key IS NULL)
+			AND s.deleted_at IS NULL
+			AND s.snoozed_until IS NULL
+			AND COALESCE((
+				SELECT p.channel_in_app FROM notification_preferences p
+				WHERE p.tenant_key = $1 AND p.user_id = $2 AND p.type = b.type AND p.category IN ('', b.category)
+				ORDER BY p.category DESC
+				LIMIT 1
+			), TRUE)
+	) inbox`
+
+// inboxAsOfSource is a user's inbox as of $3: rows created by then that were not yet
+// deleted or compacted (live and archived rows plus tombstones), with reads after $3 undone.
+// Compaction summaries only appear once their compaction ran. Broadcasts are listed
+// regardless of in-app mutes and nothing is pinned, since neither is versioned.
+// Expects the tenant key as $1, the user ID as $2 and the timestamp as $3.
+const inboxAsOfSource = `(
+		SELECT id, tenant_key, user_id, type, title, body, metadata,
+			read_at IS NOT NULL AND read_at <= $3, CASE WHEN read_at <= $3 THEN read_at END,
+			created_at, source_event_id, priority, category, FALSE AS pinned, entity_type, entity_id
+		FROM notifications
+		WHERE tenant_key = $1 AND user_id = $2 AND created_at <= $3
+			AND COALESCE((metadata->>'compacted_at')::timestamptz <= $3, TRUE)
+		UNION ALL
+		SELECT id, tenant_key, user_id, type, title, body, metadata,
+			read_at IS NOT NULL AND read_at <= $3, CASE WHEN read_at <= $3 THEN read_at END,
+			created_at, source_event_id, priority, category, FALSE, entity_type, entity_id
+		FROM notifications_archive
+		WHERE tenant_key = $1 AND user_id = $2 AND created_at <= $3
+		UNION ALL
+		SELECT id, tenant_key, user_id, type, title, body, metadata,
+			read_at IS NOT NULL AND read_at <= $3, CASE WHEN read_at <= $3 THEN read_at END,
+			created_at, source_event_id, priority, category, FALSE, metadata->>'entityType', metadata->>'entityId'
+		FROM notification_tombstones
+		WHERE tenant_key = $1 AND user_id = $2 AND created_at <= $3 AND deleted_at > $3
+		UNION ALL
+		SELECT b.id, $1::varchar, $2::varchar, b.type, b.title, b.body, b.metadata,
+			s.read_at IS NOT NULL AND s.read_at <= $3, CASE WHEN s.read_at <= $3 THEN s.read_at END,
+			b.created_at, b.source_event_id, b.priority, b.category, FALSE, b.entity_type, b.entity_id
+		FROM broadcast_notifications b
+		LEFT JOIN broadcast_read_state s
+			ON s.broadcast_id = b.id AND s.tenant_key = $1 AND s.user_id = $2
+		WHERE (b.tenant_key = $1 OR b.tenant_key IS NULL)
+			AND b.created_at <= $3
+			AND (s.deleted_at IS NULL OR s.deleted_at > $3)
+	) inbox`
+
+// tombstoneInsert copies the rows returned by a "del" CTE into notification_tombstones
+// with the given reason; at is the SQL expression for deleted_at.
+func tombstoneInsert(reason, at string) string {
+	return `INSERT INTO notification_tombstones (id, tenant_key, user_id, type, category, priority, title, body,
+			metadata, read_at, created_at, source_event_id, reason, deleted_at)
+		SELECT id, tenant_key, user_id, type, category, priority, title, body,
+			metadata, read_at, created_at, source_event_id, '` + reason + `', ` + at + `
+		FROM del`
+}
+
+// stateEventInsert appends a kind event for each row of from, which must expose
+// id, tenant_key and user_id; at is the SQL expression for occurred_at.
+func stateEventInsert(kind domain.StateEventKind, from, at string) string {
+	return `INSERT INTO notification_events (notification_id, tenant_key, user_id, kind, occurred_at)
+		SELECT id, tenant_key, user_id, '` + string(kind) + `', ` + at + `
+		FROM ` + from
+}
+
+// CreateBroadcast stores a fan-out-on-read notification.
+func (r *Repository) CreateBroadcast(ctx context.Context, input domain.BroadcastInput) (*domain.Notification, error) {
+	metaJSON, _ := json.Marshal(input.Metadata)
+
+	var tenantKey, sourceEventID *string
+	if input.TenantKey != "" {
+		tenantKey = &input.TenantKey
+	}
+	if input.SourceEventID != "" {
+		sourceEventID = &input.SourceEventID
+	}
+
+	row := r.pool.QueryRow(ctx, `
+		WITH ins AS (
+			INSERT INTO broadcast_notifications (id, tenant_key, type, title, body, metadata, source_event_id, priority, category)
+			VALUES (COALESCE($8::uuid, uuidv7()), $1, $2, $3, $4, $5, $6, $7, $9)
+			ON CONFLICT (source_event_id, tenant_key) WHERE source_event_id IS NOT NULL DO NOTHING
+			RETURNING id, COALESCE(tenant_key, '') AS tenant_key, '' AS user_id, type, title, body, metadata,
+				created_at, source_event_id, priority, category
+		), audit AS (
+			`+auditInsert(domain.AuditCreated, domain.AuditActorSystem, "ins", "created_at")+`
+		)
+		SELECT id, tenant_key, user_id, type, title, body, metadata, FALSE, NULL::timestamptz,
+			created_at, source_event_id, priority, category
+		FROM ins
+	`, tenantKey, string(input.Type), input.Title, input.Body, metaJSON, sourceEventID,
+		string(input.Priority.OrDefault()), r.newID(), input.Category)
+	n, err := scanNotification(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("insert broadcast notification: %w", err)
+	}
+	return n, nil
 }
 
 // GetByID fetches a single notification.
 func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Notification, error) {
-	row := r.pool.QueryRow(ctx, `
-		SELECT id, tenant_key, user_id, type, title, body, metadata, is_read, read_at, created_at, source_event_id
+	var n *domain.Notification
+	err := r.read(ctx, func(db querier) error {
+		var err error
+		n, err = getByID(ctx, db, id)
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) && r.replica != nil {
+		// The replica may lag behind a notification created moments ago.
+		return getByID(ctx, r.pool, id)
+	}
+	return n, err
+}
+
+func getByID(ctx context.Context, db querier, id uuid.UUID) (*domain.Notification, error) {
+	row := db.QueryRow(ctx, `
+		SELECT `+notificationColumns+`
 		FROM notifications WHERE id = $1
 	`, id)
 	return scanNotification(row)
@@ -179,41 +583,205 @@ func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Notific
 
 // MarkRead marks a single notification as read.
 func (r *Repository) MarkRead(ctx context.Context, id uuid.UUID, tenantKey, userID string) error {
-	now := time.Now()
+	now := r.clock.Now()
 	tag, err := r.pool.Exec(ctx, `
-		UPDATE notifications SET is_read = TRUE, read_at = $1
-		WHERE id = $2 AND tenant_key = $3 AND user_id = $4 AND is_read = FALSE
-	`, now, id, tenantKey, userID)
+		WITH up AS (
+			UPDATE notifications SET is_read = TRUE, read_at = $1
+			WHERE id = $2 AND tenant_key = $3 AND user_id = $4 AND is_read = FALSE
+			RETURNING id, tenant_key, user_id, read_at
+		)
+		`+stateEventInsert(domain.StateRead, "up", "read_at"), now, id, tenantKey, userID)
 	if err != nil {
 		return fmt.Errorf("mark read: %w", err)
 	}
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+	if ok, err := r.markArchivedRead(ctx, id, tenantKey, userID, now); err != nil || ok {
+		return err
+	}
+
+	// Not a per-user row: record read state if it is a broadcast visible to the user.
+	tag, err = r.pool.Exec(ctx, `
+		WITH up AS (
+			INSERT INTO broadcast_read_state (broadcast_id, tenant_key, user_id, read_at)
+			SELECT b.id, $2, $3, $4 FROM broadcast_notifications b
+			WHERE b.id = $1 AND (b.tenant_key = $2 OR b.tenant_key IS NULL)
+			ON CONFLICT (broadcast_id, tenant_key, user_id) DO UPDATE SET read_at = EXCLUDED.read_at
+			WHERE broadcast_read_state.read_at IS NULL AND broadcast_read_state.deleted_at IS NULL
+			RETURNING broadcast_id AS id, tenant_key, user_id, read_at
+		)
+		`+stateEventInsert(domain.StateRead, "up", "read_at"), id, tenantKey, userID, now)
+	if err != nil {
+		return fmt.Errorf("mark broadcast read: %w", err)
+	}
 	if tag.RowsAffected() == 0 {
 		return fmt.Errorf("notification not found or already read")
 	}
 	return nil
 }
 
+// ApplyReadStates applies offline reads in bulk; the earliest read_at wins.
+func (r *Repository) ApplyReadStates(ctx context.Context, tenantKey, userID string, states []domain.ReadState) ([]uuid.UUID, error) {
+	if len(states) == 0 {
+		return nil, nil
+	}
+	ids := make([]uuid.UUID, len(states))
+	readAts := make([]time.Time, len(states))
+	for i, st := range states {
+		ids[i], readAts[i] = st.ID, st.ReadAt
+	}
+
+	// The self-join exposes pre-update values, so RETURNING can tell newly read rows apart.
+	rows, err := r.pool.Query(ctx, `
+		WITH input AS (
+			SELECT * FROM unnest($3::uuid[], $4::timestamptz[]) AS t(id, read_at)
+		), up AS (
+			UPDATE notifications n
+			SET is_read = TRUE, read_at = i.read_at
+			FROM input i, notifications old
+			WHERE n.id = i.id AND old.id = n.id
+				AND n.tenant_key = $1 AND n.user_id = $2
+				AND (n.read_at IS NULL OR i.read_at < n.read_at)
+			RETURNING n.id, n.tenant_key, n.user_id, n.read_at, old.is_read
+		), ev AS (
+			`+stateEventInsert(domain.StateRead, "up WHERE NOT is_read", "read_at")+`
+		)
+		SELECT id, is_read FROM up
+	`, tenantKey, userID, ids, readAts)
+	if err != nil {
+		return nil, fmt.Errorf("apply read states: %w", err)
+	}
+	var newlyRead []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		var wasRead bool
+		if err := rows.Scan(&id, &wasRead); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if !wasRead {
+			newlyRead = append(newlyRead, id)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("apply read states: %w", err)
+	}
+
+	// Broadcasts: CTEs see the table as of statement start, so prev holds prior reads.
+	rows, err = r.pool.Query(ctx, `
+		WITH input AS (
+			SELECT * FROM unnest($3::uuid[], $4::timestamptz[]) AS t(id, read_at)
+		), prev AS (
+			SELECT broadcast_id FROM broadcast_read_state
+			WHERE tenant_key = $1 AND user_id = $2 AND read_at IS NOT NULL AND broadcast_id = ANY($3)
+		), up AS (
+			INSERT INTO broadcast_read_state (broadcast_id, tenant_key, user_id, read_at)
+			SELECT b.id, $1, $2, i.read_at
+			FROM input i JOIN broadcast_notifications b ON b.id = i.id
+			WHERE b.tenant_key = $1 OR b.tenant_key IS NULL
+			ON CONFLICT (broadcast_id, tenant_key, user_id) DO UPDATE SET read_at = EXCLUDED.read_at
+			WHERE broadcast_read_state.read_at IS NULL OR EXCLUDED.read_at < broadcast_read_state.read_at
+			RETURNING broadcast_id, broadcast_id AS id, tenant_key, user_id, read_at
+		), ev AS (
+			`+stateEventInsert(domain.StateRead, "up WHERE broadcast_id NOT IN (SELECT broadcast_id FROM prev)", "read_at")+`
+		)
+		SELECT broadcast_id FROM up WHERE broadcast_id NOT IN (SELECT broadcast_id FROM prev)
+	`, tenantKey, userID, ids, readAts)
+	if err != nil {
+		return nil, fmt.Errorf("apply broadcast read states: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		newlyRead = append(newlyRead, id)
+	}
+	return newlyRead, rows.Err()
+}
+
 // MarkAllRead marks all unread notifications for a user as read.
 func (r *Repository) MarkAllRead(ctx context.Context, tenantKey, userID string) (int64, error) {
-	now := time.Now()
+	now := r.clock.Now()
 	tag, err := r.pool.Exec(ctx, `
-		UPDATE notifications SET is_read = TRUE, read_at = $1
-		WHERE tenant_key = $2 AND user_id = $3 AND is_read = FALSE
-	`, now, tenantKey, userID)
+		WITH up AS (
+			UPDATE notifications SET is_read = TRUE, read_at = $1
+			WHERE tenant_key = $2 AND user_id = $3 AND is_read = FALSE
+			RETURNING id, tenant_key, user_id, read_at
+		)
+		`+stateEventInsert(domain.StateRead, "up", "read_at"), now, tenantKey, userID)
 	if err != nil {
 		return 0, fmt.Errorf("mark all read: %w", err)
 	}
-	return tag.RowsAffected(), nil
+	archivedTag, err := r.pool.Exec(ctx, `
+		WITH up AS (
+			UPDATE notifications_archive SET is_read = TRUE, read_at = $1
+			WHERE tenant_key = $2 AND user_id = $3 AND is_read = FALSE
+			RETURNING id, tenant_key, user_id, read_at
+		)
+		`+stateEventInsert(domain.StateRead, "up", "read_at"), now, tenantKey, userID)
+	if err != nil {
+		return 0, fmt.Errorf("mark all archived read: %w", err)
+	}
+
+	broadcastTag, err := r.pool.Exec(ctx, `
+		WITH up AS (
+			INSERT INTO broadcast_read_state (broadcast_id, tenant_key, user_id, read_at)
+			SELECT b.id, $2, $3, $1 FROM broadcast_notifications b
+			LEFT JOIN broadcast_read_state s
+				ON s.broadcast_id = b.id AND s.tenant_key = $2 AND s.user_id = $3
+			WHERE (b.tenant_key = $2 OR b.tenant_key IS NULL)
+				AND s.read_at IS NULL AND s.deleted_at IS NULL
+			ON CONFLICT (broadcast_id, tenant_key, user_id) DO UPDATE SET read_at = EXCLUDED.read_at
+			RETURNING broadcast_id AS id, tenant_key, user_id, read_at
+		)
+		`+stateEventInsert(domain.StateRead, "up", "read_at"), now, tenantKey, userID)
+	if err != nil {
+		return 0, fmt.Errorf("mark all broadcasts read: %w", err)
+	}
+	return tag.RowsAffected() + archivedTag.RowsAffected() + broadcastTag.RowsAffected(), nil
 }
 
-// Delete removes a notification belonging to the user.
+// Delete removes a notification belonging to the user, keeping a tombstone for
+// as-of listing.
 func (r *Repository) Delete(ctx context.Context, id uuid.UUID, tenantKey, userID string) error {
+	now := r.clock.Now()
 	tag, err := r.pool.Exec(ctx, `
-		DELETE FROM notifications WHERE id = $1 AND tenant_key = $2 AND user_id = $3
-	`, id, tenantKey, userID)
+		WITH del AS (
+			DELETE FROM notifications WHERE id = $1 AND tenant_key = $2 AND user_id = $3
+			RETURNING *
+		), ev AS (
+			`+stateEventInsert(domain.StateDeleted, "del", "$4::timestamptz")+`
+		)
+		`+tombstoneInsert("deleted", "$4::timestamptz"), id, tenantKey, userID, now)
 	if err != nil {
 		return fmt.Errorf("delete notification: %w", err)
 	}
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+	if ok, err := r.deleteArchived(ctx, id, tenantKey, userID, now); err != nil || ok {
+		return err
+	}
+
+	// Broadcasts are shared; deleting hides it for this user only.
+	tag, err = r.pool.Exec(ctx, `
+		WITH up AS (
+			INSERT INTO broadcast_read_state (broadcast_id, tenant_key, user_id, read_at, deleted_at)
+			SELECT b.id, $2, $3, $4, $4 FROM broadcast_notifications b
+			WHERE b.id = $1 AND (b.tenant_key = $2 OR b.tenant_key IS NULL)
+			ON CONFLICT (broadcast_id, tenant_key, user_id) DO UPDATE
+				SET deleted_at = $4, read_at = COALESCE(broadcast_read_state.read_at, $4)
+			WHERE broadcast_read_state.deleted_at IS NULL
+			RETURNING broadcast_id AS id, tenant_key, user_id, deleted_at
+		)
+		`+stateEventInsert(domain.StateDeleted, "up", "deleted_at"), id, tenantKey, userID, now)
+	if err != nil {
+		return fmt.Errorf("delete broadcast notification: %w", err)
+	}
 	if tag.RowsAffected() == 0 {
 		return fmt.Errorf("notification not found")
 	}
@@ -223,22 +791,278 @@ func (r *Repository) Delete(ctx context.Context, id uuid.UUID, tenantKey, userID
 // CountUnread returns the count of unread notifications for a user.
 func (r *Repository) CountUnread(ctx context.Context, tenantKey, userID string) (int64, error) {
 	var count int64
-	err := r.pool.QueryRow(ctx,
-		`SELECT COUNT(*) FROM notifications WHERE tenant_key = $1 AND user_id = $2 AND is_read = FALSE`,
-		tenantKey, userID,
-	).Scan(&count)
+	err := r.read(ctx, func(db querier) error {
+		return db.QueryRow(ctx,
+			`SELECT COUNT(*) FROM `+inboxSource+` WHERE is_read = FALSE`,
+			tenantKey, userID,
+		).Scan(&count)
+	})
 	return count, err
 }
 
-// PurgeOlderThan deletes notifications older than the given number of days.
+// CountGroups counts the user's inbox per type and priority in one grouped query.
+func (r *Repository) CountGroups(ctx context.Context, tenantKey, userID string) ([]domain.CountGroup, error) {
+	var groups []domain.CountGroup
+	err := r.read(ctx, func(db querier) error {
+		var err error
+		groups, err = countGroups(ctx, db, tenantKey, userID)
+		return err
+	})
+	return groups, err
+}
+
+func countGroups(ctx context.Context, db querier, tenantKey, userID string) ([]domain.CountGroup, error) {
+	rows, err := db.Query(ctx, `
+		SELECT type, priority, COUNT(*), COUNT(*) FILTER (WHERE is_read = FALSE)
+		FROM `+inboxSource+`
+		GROUP BY type, priority`, tenantKey, userID)
+	if err != nil {
+		return nil, fmt.Errorf("count notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []domain.CountGroup
+	for rows.Next() {
+		var g domain.CountGroup
+		if err := rows.Scan(&g.Type, &g.Priority, &g.Total, &g.Unread); err != nil {
+			return nil, fmt.Errorf("scan notification counts: %w", err)
+		}
+		groups = append(groups, g)
+	}
+	return groups, rows.Err()
+}
+
+// retentionDaysExpr is the retention of notification n, NULL when kept forever
+// (see migration 032). Expects the fallback retention as $2.
+const retentionDaysExpr = `NULLIF(notification_retention_days(COALESCE(n.tenant_key, ''), n.type, $2), 0)`
+
+// PurgeOlderThan deletes notifications older than their retention policy
+// (retention_policies, then the tenant custom type's retention_days), or than
+// days when no policy applies. Pinned notifications and those whose retention is
+// 0 are kept. Monthly notifications partitions that ended before the shortest
+// retention are dropped whole when nothing in them is still retained; the rest
+// are purged row by row.
 func (r *Repository) PurgeOlderThan(ctx context.Context, days int) (int64, error) {
-	cutoff := time.Now().AddDate(0, 0, -days)
-	tag, err := r.pool.Exec(ctx,
-		`DELETE FROM notifications WHERE created_at < $1`, cutoff)
+	now := r.clock.Now()
+	var shortest *int
+	if err := r.pool.QueryRow(ctx, `
+		SELECT MIN(d) FROM (
+			SELECT retention_days FROM retention_policies WHERE retention_days > 0
+			UNION ALL
+			SELECT retention_days FROM notification_types WHERE retention_days > 0
+			UNION ALL
+			SELECT $1::int WHERE $1 > 0
+		) x(d)`, days).Scan(&shortest); err != nil {
+		return 0, fmt.Errorf("find shortest retention: %w", err)
+	}
+	if shortest == nil {
+		// Every retention is "keep forever".
+		return 0, nil
+	}
+	cutoff := now.AddDate(0, 0, -*shortest)
+	purged, err := r.dropExpiredPartitions(ctx, cutoff, now, days)
+	if err != nil {
+		return purged, err
+	}
+	for _, table := range []string{"notifications", "notifications_archive", "broadcast_notifications"} {
+		n, err := r.purge(ctx, table, `n.created_at < $3
+			  AND n.created_at < $1::timestamptz - make_interval(days => `+retentionDaysExpr+`)`+notPinned(table),
+			domain.AuditActorRetention, now, days, cutoff)
+		purged += n
+		if err != nil {
+			return purged, fmt.Errorf("purge %s: %w", table, err)
+		}
+	}
+	if days > 0 {
+		if _, err := r.pool.Exec(ctx,
+			`DELETE FROM notification_tombstones WHERE created_at < $1`, now.AddDate(0, 0, -days)); err != nil {
+			return 0, fmt.Errorf("purge notification tombstones: %w", err)
+		}
+	}
+	return purged, nil
+}
+
+// PurgeExpired deletes notifications whose metadata expires_at (see
+// domain.WithExpiry) has passed. Malformed expiries and pinned notifications are kept.
+func (r *Repository) PurgeExpired(ctx context.Context) (int64, error) {
+	now := r.clock.Now()
+	var purged int64
+	for _, table := range []string{"notifications", "notifications_archive", "broadcast_notifications"} {
+		n, err := r.purge(ctx, table, `n.metadata ? 'expires_at'
+			  AND CASE WHEN n.metadata->>'expires_at' ~ '^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}'
+			      THEN (n.metadata->>'expires_at')::timestamptz < $1 END`+notPinned(table), domain.AuditActorExpiry, now)
+		purged += n
+		if err != nil {
+			return purged, fmt.Errorf("purge expired %s: %w", table, err)
+		}
+	}
+	return purged, nil
+}
+
+// CountPurgeable counts the rows PurgeOlderThan(days) and PurgeExpired would
+// delete. Partition drops are counted row by row.
+func (r *Repository) CountPurgeable(ctx context.Context, days int) (retention, expired int64, err error) {
+	now := r.clock.Now()
+	for _, table := range []string{"notifications", "notifications_archive", "broadcast_notifications"} {
+		var old, exp int64
+		if err := r.pool.QueryRow(ctx, `
+			SELECT
+				COUNT(*) FILTER (WHERE n.created_at < $1::timestamptz - make_interval(days => `+retentionDaysExpr+`)),
+				COUNT(*) FILTER (WHERE n.metadata ? 'expires_at'
+					AND CASE WHEN n.metadata->>'expires_at' ~ '^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}'
+					    THEN (n.metadata->>'expires_at')::timestamptz < $1 END)
+			FROM `+table+` n WHERE TRUE`+notPinned(table), now, days).Scan(&old, &exp); err != nil {
+			return 0, 0, fmt.Errorf("count purgeable %s: %w", table, err)
+		}
+		retention += old
+		expired += exp
+	}
+	return retention, expired, nil
+}
+
+// ClaimOutbox leases due outbox entries (SKIP LOCKED lets several dispatchers run)
+// and loads their notifications.
+func (r *Repository) ClaimOutbox(ctx context.Context, limit int, lease time.Duration) ([]domain.OutboxEntry, error) {
+	rows, err := r.pool.Query(ctx, `
+		WITH claimed AS (
+			UPDATE delivery_outbox SET attempts = attempts + 1, next_attempt_at = NOW() + $2::interval
+			WHERE id IN (
+				SELECT id FROM delivery_outbox
+				WHERE next_attempt_at <= NOW()
+				ORDER BY id
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, notification_id, attempts
+		)
+		SELECT c.id, c.attempts, `+prefixColumns("n.", notificationColumns)+`
+		FROM claimed c JOIN notifications n ON n.id = c.notification_id
+		ORDER BY c.id
+	`, limit, lease.String())
+	if err != nil {
+		return nil, fmt.Errorf("claim outbox: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []domain.OutboxEntry
+	for rows.Next() {
+		var e domain.OutboxEntry
+		n, err := scanNotification(outboxRow{rows: rows, id: &e.ID, attempts: &e.Attempts})
+		if err != nil {
+			return nil, err
+		}
+		e.Notification = n
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// AckOutbox deletes dispatched outbox entries.
+func (r *Repository) AckOutbox(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if _, err := r.pool.Exec(ctx, `DELETE FROM delivery_outbox WHERE id = ANY($1)`, ids); err != nil {
+		return fmt.Errorf("ack outbox: %w", err)
+	}
+	return nil
+}
+
+// outboxRow prepends the outbox columns to a notification scan.
+type outboxRow struct {
+	rows     pgx.Rows
+	id       *int64
+	attempts *int
+}
+
+func (o outboxRow) Scan(dest ...any) error {
+	return o.rows.Scan(append([]any{o.id, o.attempts}, dest...)...)
+}
+
+// prefixColumns qualifies a comma-separated column list with a table alias.
+func prefixColumns(prefix, columns string) string {
+	parts := strings.Split(columns, ", ")
+	for i, p := range parts {
+		parts[i] = prefix + p
+	}
+	return strings.Join(parts, ", ")
+}
+
+// FindCompactionRuns detects runs of read LOW-priority notifications per user using
+// a gaps-and-islands query: rows before cutoff are ordered per user, and each break in
+// eligibility starts a new island. Pinned rows and already compacted summaries are never eligible.
+func (r *Repository) FindCompactionRuns(ctx context.Context, cutoff time.Time, minRun int) ([]domain.CompactionRun, error) {
+	rows, err := r.pool.Query(ctx, `
+		WITH ordered AS (
+			SELECT id, tenant_key, user_id, type, created_at,
+			       (is_read AND priority = 'LOW' AND NOT pinned
+			        AND NOT COALESCE((metadata->>'compacted')::boolean, FALSE)) AS eligible
+			FROM notifications
+			WHERE created_at < $1
+		), islands AS (
+			SELECT *,
+			       ROW_NUMBER() OVER (PARTITION BY tenant_key, user_id ORDER BY created_at, id)
+			     - ROW_NUMBER() OVER (PARTITION BY tenant_key, user_id, eligible ORDER BY created_at, id) AS grp
+			FROM ordered
+		)
+		SELECT tenant_key, user_id, array_agg(id ORDER BY created_at), array_agg(type ORDER BY created_at),
+		       MIN(created_at), MAX(created_at)
+		FROM islands
+		WHERE eligible
+		GROUP BY tenant_key, user_id, grp
+		HAVING COUNT(*) >= $2
+	`, cutoff, minRun)
+	if err != nil {
+		return nil, fmt.Errorf("find compaction runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []domain.CompactionRun
+	for rows.Next() {
+		var run domain.CompactionRun
+		var types []string
+		if err := rows.Scan(&run.TenantKey, &run.UserID, &run.IDs, &types, &run.From, &run.To); err != nil {
+			return nil, fmt.Errorf("scan compaction run: %w", err)
+		}
+		run.TypeCounts = make(map[domain.NotificationType]int)
+		for _, t := range types {
+			run.TypeCounts[domain.NotificationType(t)]++
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// CompactRun inserts the summary (read, dated at the end of the run) and moves the run's
+// rows to tombstones in one transaction.
+func (r *Repository) CompactRun(ctx context.Context, run domain.CompactionRun, summary domain.CreateNotificationInput) error {
+	tx, err := r.pool.Begin(ctx)
 	if err != nil {
-		return 0, fmt.Errorf("purge notifications: %w", err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	metaJSON, _ := json.Marshal(summary.Metadata)
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO notifications (id, tenant_key, user_id, type, title, body, metadata, priority, is_read, read_at, created_at)
+		VALUES (COALESCE($9::uuid, uuidv7()), $1, $2, $3, $4, $5, $6, $7, TRUE, NOW(), $8)
+	`, summary.TenantKey, summary.UserID, string(summary.Type), summary.Title, summary.Body, metaJSON,
+		string(summary.Priority.OrDefault()), run.To, r.newID()); err != nil {
+		return fmt.Errorf("insert compaction summary: %w", err)
 	}
-	return tag.RowsAffected(), nil
+
+	if _, err := tx.Exec(ctx, `
+		WITH del AS (
+			DELETE FROM notifications WHERE id = ANY($1) AND tenant_key = $2 AND user_id = $3
+			RETURNING *
+		), tomb AS (
+			`+tombstoneInsert("compacted", "NOW()")+`
+		)
+		`+auditInsert(domain.AuditPurged, domain.AuditActorCompaction, "del", "NOW()"), run.IDs, run.TenantKey, run.UserID); err != nil {
+		return fmt.Errorf("delete compacted notifications: %w", err)
+	}
+
+	return tx.Commit(ctx)
 }
 
 // scanNotification is a helper to scan a row into a Notification struct.
@@ -246,6 +1070,16 @@ type scannable interface {
 	Scan(dest ...any) error
 }
 
+// leadingRow scans extra columns selected before the notification columns.
+type leadingRow struct {
+	rows pgx.Rows
+	lead []any
+}
+
+func (l leadingRow) Scan(dest ...any) error {
+	return l.rows.Scan(append(append([]any{}, l.lead...), dest...)...)
+}
+
 func scanNotification(row scannable) (*domain.Notification, error) {
 	var n domain.Notification
 	var metaJSON []byte
@@ -253,7 +1087,7 @@ func scanNotification(row scannable) (*domain.Notification, error) {
 
 	err := row.Scan(
 		&n.ID, &n.TenantKey, &n.UserID, &n.Type, &n.Title, &n.Body,
-		&metaJSON, &n.IsRead, &n.ReadAt, &n.CreatedAt, &sourceEventID,
+		&metaJSON, &n.IsRead, &n.ReadAt, &n.CreatedAt, &sourceEventID, &n.Priority, &n.Category,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("scan notification: %w", err)
@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// RetentionPolicyRepo implements domain.RetentionPolicyRepository. The purge
+// applies the policies in SQL (notification_retention_days, migration 032).
+type RetentionPolicyRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewRetentionPolicyRepo creates a new RetentionPolicyRepo.
+func NewRetentionPolicyRepo(pool *pgxpool.Pool) *RetentionPolicyRepo {
+	return &RetentionPolicyRepo{pool: pool}
+}
+
+const retentionPolicyColumns = `tenant_key, type, retention_days, updated_at`
+
+func (r *RetentionPolicyRepo) List(ctx context.Context, tenantKey string) ([]domain.RetentionPolicy, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+retentionPolicyColumns+` FROM retention_policies
+		WHERE $1 = '' OR tenant_key IN ($1, '')
+		ORDER BY tenant_key, type`, tenantKey)
+	if err != nil {
+		return nil, fmt.Errorf("list retention policies: %w", err)
+	}
+	defer rows.Close()
+
+	var results []domain.RetentionPolicy
+	for rows.Next() {
+		p, err := scanRetentionPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *p)
+	}
+	return results, rows.Err()
+}
+
+func (r *RetentionPolicyRepo) Upsert(ctx context.Context, p domain.RetentionPolicy) (*domain.RetentionPolicy, error) {
+	row := r.pool.QueryRow(ctx, `
+		INSERT INTO retention_policies (tenant_key, type, retention_days)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (tenant_key, type) DO UPDATE SET
+			retention_days = EXCLUDED.retention_days,
+			updated_at     = NOW()
+		RETURNING `+retentionPolicyColumns, p.TenantKey, string(p.Type), p.RetentionDays)
+	saved, err := scanRetentionPolicy(row)
+	if err != nil {
+		return nil, fmt.Errorf("upsert retention policy: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *RetentionPolicyRepo) Delete(ctx context.Context, tenantKey string, t domain.NotificationType) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM retention_policies WHERE tenant_key = $1 AND type = $2`, tenantKey, string(t))
+	return err
+}
+
+func scanRetentionPolicy(row scannable) (*domain.RetentionPolicy, error) {
+	var p domain.RetentionPolicy
+	if err := row.Scan(&p.TenantKey, &p.Type, &p.RetentionDays, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
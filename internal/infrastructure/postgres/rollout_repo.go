@@ -0,0 +1,120 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// RolloutRepo implements domain.RolloutRepository.
+type RolloutRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewRolloutRepo creates a new RolloutRepo.
+func NewRolloutRepo(pool *pgxpool.Pool) *RolloutRepo {
+	return &RolloutRepo{pool: pool}
+}
+
+const rolloutColumns = `id, input, pending_tenants, release_at, status, created_at, updated_at`
+
+func (r *RolloutRepo) Create(ctx context.Context, ro domain.Rollout) (*domain.Rollout, error) {
+	inputJSON, err := json.Marshal(ro.Input)
+	if err != nil {
+		return nil, fmt.Errorf("marshal rollout input: %w", err)
+	}
+	row := r.pool.QueryRow(ctx, `
+		INSERT INTO platform_rollouts (input, pending_tenants, release_at)
+		VALUES ($1, $2, $3)
+		RETURNING `+rolloutColumns, inputJSON, ro.PendingTenants, ro.ReleaseAt)
+	return scanRollout(row)
+}
+
+func (r *RolloutRepo) Get(ctx context.Context, id uuid.UUID) (*domain.Rollout, error) {
+	row := r.pool.QueryRow(ctx, `SELECT `+rolloutColumns+` FROM platform_rollouts WHERE id = $1`, id)
+	ro, err := scanRollout(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("rollout not found")
+	}
+	return ro, err
+}
+
+func (r *RolloutRepo) ListPending(ctx context.Context) ([]domain.Rollout, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+rolloutColumns+` FROM platform_rollouts
+		WHERE status = 'PENDING'
+		ORDER BY created_at
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("list pending rollouts: %w", err)
+	}
+	defer rows.Close()
+	return collectRollouts(rows)
+}
+
+func (r *RolloutRepo) ListDue(ctx context.Context, now time.Time) ([]domain.Rollout, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+rolloutColumns+` FROM platform_rollouts
+		WHERE status = 'PENDING' AND release_at IS NOT NULL AND release_at <= $1
+		ORDER BY release_at
+	`, now)
+	if err != nil {
+		return nil, fmt.Errorf("list due rollouts: %w", err)
+	}
+	defer rows.Close()
+	return collectRollouts(rows)
+}
+
+func (r *RolloutRepo) Transition(ctx context.Context, id uuid.UUID, status domain.RolloutStatus) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE platform_rollouts SET status = $1, updated_at = NOW()
+		WHERE id = $2 AND status = 'PENDING'
+	`, string(status), id)
+	if err != nil {
+		return false, fmt.Errorf("transition rollout: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *RolloutRepo) SetPending(ctx context.Context, id uuid.UUID, tenants []string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE platform_rollouts SET pending_tenants = $1, updated_at = NOW()
+		WHERE id = $2 AND status = 'PENDING'
+	`, tenants, id)
+	if err != nil {
+		return false, fmt.Errorf("update rollout tenants: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func collectRollouts(rows pgx.Rows) ([]domain.Rollout, error) {
+	var results []domain.Rollout
+	for rows.Next() {
+		ro, err := scanRollout(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *ro)
+	}
+	return results, rows.Err()
+}
+
+func scanRollout(row scannable) (*domain.Rollout, error) {
+	var ro domain.Rollout
+	var inputJSON []byte
+	err := row.Scan(&ro.ID, &inputJSON, &ro.PendingTenants, &ro.ReleaseAt, &ro.Status, &ro.CreatedAt, &ro.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(inputJSON, &ro.Input); err != nil {
+		return nil, fmt.Errorf("unmarshal rollout input: %w", err)
+	}
+	return &ro, nil
+}
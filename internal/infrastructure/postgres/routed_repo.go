@@ -0,0 +1,490 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// outboxTargetShift places the target index in the high bits of the outbox
+// IDs returned by RoutedRepository.ClaimOutbox, since every target numbers
+// its entries independently.
+const outboxTargetShift = 56
+
+// RoutedRepository is a domain.Repository over a TenantRouter: calls for a
+// tenant go to the Repository of its target, and background jobs (outbox,
+// snooze, retention, archive, compaction) run on every target in turn.
+type RoutedRepository struct {
+	routes tenantRoutes[*Repository]
+}
+
+// NewRoutedRepository builds a Repository per target of router with build and
+// routes between them.
+func NewRoutedRepository(router *TenantRouter, build func(*pgxpool.Pool) *Repository) *RoutedRepository {
+	return &RoutedRepository{routes: newTenantRoutes(router, build)}
+}
+
+// Create stores the notification in its tenant's target.
+func (r *RoutedRepository) Create(ctx context.Context, input domain.CreateNotificationInput) (*domain.Notification, error) {
+	return r.routes.of(input.TenantKey).Create(ctx, input)
+}
+
+// BatchCreate splits inputs by target, keeping the indexes of Duplicates
+// relative to inputs.
+func (r *RoutedRepository) BatchCreate(ctx context.Context, inputs []domain.CreateNotificationInput) (domain.BatchResult, error) {
+	byTarget := make(map[int][]int)
+	for i, in := range inputs {
+		t := r.routes.router.index(in.TenantKey)
+		byTarget[t] = append(byTarget[t], i)
+	}
+	if len(byTarget) == 1 {
+		for t := range byTarget {
+			return r.routes.byPool[t].BatchCreate(ctx, inputs)
+		}
+	}
+
+	var out domain.BatchResult
+	for t, idx := range byTarget {
+		part := make([]domain.CreateNotificationInput, len(idx))
+		for i, j := range idx {
+			part[i] = inputs[j]
+		}
+		res, err := r.routes.byPool[t].BatchCreate(ctx, part)
+		out.Inserted = append(out.Inserted, res.Inserted...)
+		for _, d := range res.Duplicates {
+			out.Duplicates = append(out.Duplicates, idx[d])
+		}
+		if err != nil {
+			return out, err
+		}
+	}
+	return out, nil
+}
+
+// CreateBroadcast stores a tenant broadcast in the tenant's target and a
+// PLATFORM broadcast in every target, returning the default target's result.
+func (r *RoutedRepository) CreateBroadcast(ctx context.Context, input domain.BroadcastInput) (*domain.Notification, error) {
+	if input.TenantKey != "" {
+		return r.routes.of(input.TenantKey).CreateBroadcast(ctx, input)
+	}
+	var first *domain.Notification
+	for i, repo := range r.routes.byPool {
+		n, err := repo.CreateBroadcast(ctx, input)
+		if err != nil {
+			return first, err
+		}
+		if i == 0 {
+			first = n
+		}
+	}
+	return first, nil
+}
+
+// ClaimOutbox claims due entries from each target until limit is reached.
+// The returned IDs encode their target for AckOutbox.
+func (r *RoutedRepository) ClaimOutbox(ctx context.Context, limit int, lease time.Duration) ([]domain.OutboxEntry, error) {
+	var out []domain.OutboxEntry
+	for t, repo := range r.routes.byPool {
+		if len(out) >= limit {
+			break
+		}
+		entries, err := repo.ClaimOutbox(ctx, limit-len(out), lease)
+		for _, e := range entries {
+			e.ID |= int64(t) << outboxTargetShift
+			out = append(out, e)
+		}
+		if err != nil {
+			return out, err
+		}
+	}
+	return out, nil
+}
+
+// AckOutbox acknowledges entries claimed by ClaimOutbox on their targets.
+func (r *RoutedRepository) AckOutbox(ctx context.Context, ids []int64) error {
+	byTarget := make(map[int][]int64)
+	for _, id := range ids {
+		t := int(id >> outboxTargetShift)
+		byTarget[t] = append(byTarget[t], id&(1<<outboxTargetShift-1))
+	}
+	for t, ids := range byTarget {
+		if err := r.routes.byPool[t].AckOutbox(ctx, ids); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List lists the notifications of filter.TenantKey from its target.
+func (r *RoutedRepository) List(ctx context.Context, filter domain.NotificationFilter) ([]*domain.Notification, error) {
+	return r.routes.of(filter.TenantKey).List(ctx, filter)
+}
+
+// GetByID looks the notification up in the target of the context's tenant
+// (domain.TenantScope), or else in every target.
+func (r *RoutedRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Notification, error) {
+	if tenantKey := domain.TenantScope(ctx); tenantKey != "" {
+		return r.routes.of(tenantKey).GetByID(ctx, id)
+	}
+	var err error
+	for _, repo := range r.routes.byPool {
+		var n *domain.Notification
+		if n, err = repo.GetByID(ctx, id); !errors.Is(err, pgx.ErrNoRows) {
+			return n, err
+		}
+	}
+	return nil, err
+}
+
+// MarkRead marks the notification read in the tenant's target.
+func (r *RoutedRepository) MarkRead(ctx context.Context, id uuid.UUID, tenantKey, userID string) error {
+	return r.routes.of(tenantKey).MarkRead(ctx, id, tenantKey, userID)
+}
+
+// ApplyReadStates applies offline reads in the tenant's target.
+func (r *RoutedRepository) ApplyReadStates(ctx context.Context, tenantKey, userID string, states []domain.ReadState) ([]uuid.UUID, error) {
+	return r.routes.of(tenantKey).ApplyReadStates(ctx, tenantKey, userID, states)
+}
+
+// MarkAllRead marks the user's notifications read in the tenant's target.
+func (r *RoutedRepository) MarkAllRead(ctx context.Context, tenantKey, userID string) (int64, error) {
+	return r.routes.of(tenantKey).MarkAllRead(ctx, tenantKey, userID)
+}
+
+// Delete deletes the notification in the tenant's target.
+func (r *RoutedRepository) Delete(ctx context.Context, id uuid.UUID, tenantKey, userID string) error {
+	return r.routes.of(tenantKey).Delete(ctx, id, tenantKey, userID)
+}
+
+// CountUnread counts the user's unread notifications in the tenant's target.
+func (r *RoutedRepository) CountUnread(ctx context.Context, tenantKey, userID string) (int64, error) {
+	return r.routes.of(tenantKey).CountUnread(ctx, tenantKey, userID)
+}
+
+// Export streams the history of f.TenantKey from its target.
+func (r *RoutedRepository) Export(ctx context.Context, f domain.ExportFilter, fn func(*domain.Notification) error) error {
+	return r.routes.of(f.TenantKey).Export(ctx, f, fn)
+}
+
+// CountGroups counts the user's inbox groups in the tenant's target.
+func (r *RoutedRepository) CountGroups(ctx context.Context, tenantKey, userID string) ([]domain.CountGroup, error) {
+	return r.routes.of(tenantKey).CountGroups(ctx, tenantKey, userID)
+}
+
+// Snooze snoozes the notification in the tenant's target.
+func (r *RoutedRepository) Snooze(ctx context.Context, id uuid.UUID, tenantKey, userID string, until time.Time) error {
+	return r.routes.of(tenantKey).Snooze(ctx, id, tenantKey, userID, until)
+}
+
+// ListSnoozed lists the user's snoozed notifications in the tenant's target.
+func (r *RoutedRepository) ListSnoozed(ctx context.Context, tenantKey, userID string) ([]*domain.Notification, error) {
+	return r.routes.of(tenantKey).ListSnoozed(ctx, tenantKey, userID)
+}
+
+// WakeSnoozed wakes due notifications in each target until limit is reached.
+func (r *RoutedRepository) WakeSnoozed(ctx context.Context, limit int) ([]*domain.Notification, error) {
+	var out []*domain.Notification
+	for _, repo := range r.routes.byPool {
+		if len(out) >= limit {
+			break
+		}
+		woken, err := repo.WakeSnoozed(ctx, limit-len(out))
+		out = append(out, woken...)
+		if err != nil {
+			return out, err
+		}
+	}
+	return out, nil
+}
+
+// SetPinned pins or unpins the notification in the tenant's target.
+func (r *RoutedRepository) SetPinned(ctx context.Context, id uuid.UUID, tenantKey, userID string, pinned bool) error {
+	return r.routes.of(tenantKey).SetPinned(ctx, id, tenantKey, userID, pinned)
+}
+
+// PurgeOlderThan purges every target.
+func (r *RoutedRepository) PurgeOlderThan(ctx context.Context, days int) (int64, error) {
+	return r.routes.sum(func(repo *Repository) (int64, error) { return repo.PurgeOlderThan(ctx, days) })
+}
+
+// EnsurePartitions creates the partitions of every target.
+func (r *RoutedRepository) EnsurePartitions(ctx context.Context, monthsAhead int) (int, error) {
+	n, err := r.routes.sum(func(repo *Repository) (int64, error) {
+		created, err := repo.EnsurePartitions(ctx, monthsAhead)
+		return int64(created), err
+	})
+	return int(n), err
+}
+
+// PurgeExpired purges expired notifications of every target.
+func (r *RoutedRepository) PurgeExpired(ctx context.Context) (int64, error) {
+	return r.routes.sum(func(repo *Repository) (int64, error) { return repo.PurgeExpired(ctx) })
+}
+
+// CountPurgeable adds up the purgeable notifications of every target.
+func (r *RoutedRepository) CountPurgeable(ctx context.Context, days int) (retention, expired int64, err error) {
+	for _, repo := range r.routes.byPool {
+		rt, ex, err := repo.CountPurgeable(ctx, days)
+		if err != nil {
+			return retention, expired, err
+		}
+		retention, expired = retention+rt, expired+ex
+	}
+	return retention, expired, nil
+}
+
+// ArchiveOverflow archives in each target until limit rows were moved.
+func (r *RoutedRepository) ArchiveOverflow(ctx context.Context, keep, limit int) (int64, error) {
+	var moved int64
+	for _, repo := range r.routes.byPool {
+		if moved >= int64(limit) {
+			break
+		}
+		n, err := repo.ArchiveOverflow(ctx, keep, limit-int(moved))
+		moved += n
+		if err != nil {
+			return moved, err
+		}
+	}
+	return moved, nil
+}
+
+// EvictOverCap evicts in each target until limit rows were deleted.
+func (r *RoutedRepository) EvictOverCap(ctx context.Context, capacity, limit int) (int64, error) {
+	var deleted int64
+	for _, repo := range r.routes.byPool {
+		if deleted >= int64(limit) {
+			break
+		}
+		n, err := repo.EvictOverCap(ctx, capacity, limit-int(deleted))
+		deleted += n
+		if err != nil {
+			return deleted, err
+		}
+	}
+	return deleted, nil
+}
+
+// FindCompactionRuns collects the compaction runs of every target.
+func (r *RoutedRepository) FindCompactionRuns(ctx context.Context, cutoff time.Time, minRun int) ([]domain.CompactionRun, error) {
+	var out []domain.CompactionRun
+	for _, repo := range r.routes.byPool {
+		runs, err := repo.FindCompactionRuns(ctx, cutoff, minRun)
+		if err != nil {
+			return out, err
+		}
+		out = append(out, runs...)
+	}
+	return out, nil
+}
+
+// CompactRun compacts the run in its tenant's target.
+func (r *RoutedRepository) CompactRun(ctx context.Context, run domain.CompactionRun, summary domain.CreateNotificationInput) error {
+	return r.routes.of(run.TenantKey).CompactRun(ctx, run, summary)
+}
+
+// RoutedStateEventRepo routes domain.StateEventRepository calls like
+// RoutedRepository, since state events are written with the notifications.
+type RoutedStateEventRepo struct {
+	routes tenantRoutes[*StateEventRepo]
+}
+
+// NewRoutedStateEventRepo creates a StateEventRepo per target of router.
+func NewRoutedStateEventRepo(router *TenantRouter) *RoutedStateEventRepo {
+	return &RoutedStateEventRepo{routes: newTenantRoutes(router, NewStateEventRepo)}
+}
+
+// ListByNotification lists the events in the tenant's target.
+func (r *RoutedStateEventRepo) ListByNotification(ctx context.Context, tenantKey, userID string, id uuid.UUID) ([]domain.StateEvent, error) {
+	return r.routes.of(tenantKey).ListByNotification(ctx, tenantKey, userID, id)
+}
+
+// ListSince lists the events in the tenant's target.
+func (r *RoutedStateEventRepo) ListSince(ctx context.Context, tenantKey, userID string, after int64, limit int) ([]domain.StateEvent, error) {
+	return r.routes.of(tenantKey).ListSince(ctx, tenantKey, userID, after, limit)
+}
+
+// MarkSeen records seen events in the tenant's target.
+func (r *RoutedStateEventRepo) MarkSeen(ctx context.Context, tenantKey, userID string, ids []uuid.UUID) ([]uuid.UUID, error) {
+	return r.routes.of(tenantKey).MarkSeen(ctx, tenantKey, userID, ids)
+}
+
+// Undo reverts the user's latest change in the tenant's target.
+func (r *RoutedStateEventRepo) Undo(ctx context.Context, tenantKey, userID string, id uuid.UUID) (domain.StateEventKind, error) {
+	return r.routes.of(tenantKey).Undo(ctx, tenantKey, userID, id)
+}
+
+// PurgeBefore purges the events of every target.
+func (r *RoutedStateEventRepo) PurgeBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	return r.routes.sum(func(repo *StateEventRepo) (int64, error) { return repo.PurgeBefore(ctx, cutoff) })
+}
+
+// RoutedAuditRepo routes domain.AuditRepository calls like RoutedRepository,
+// since state changes are audited by a trigger in the notification's database.
+type RoutedAuditRepo struct {
+	routes tenantRoutes[*AuditRepo]
+}
+
+// NewRoutedAuditRepo creates an AuditRepo per target of router.
+func NewRoutedAuditRepo(router *TenantRouter) *RoutedAuditRepo {
+	return &RoutedAuditRepo{routes: newTenantRoutes(router, NewAuditRepo)}
+}
+
+// Record appends each entry in its tenant's target.
+func (r *RoutedAuditRepo) Record(ctx context.Context, entries []domain.AuditEntry) error {
+	byTarget := make(map[int][]domain.AuditEntry)
+	for _, e := range entries {
+		t := r.routes.router.index(e.TenantKey)
+		byTarget[t] = append(byTarget[t], e)
+	}
+	for t, part := range byTarget {
+		if err := r.routes.byPool[t].Record(ctx, part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List lists the entries of f.TenantKey from its target.
+func (r *RoutedAuditRepo) List(ctx context.Context, f domain.AuditFilter) ([]domain.AuditEntry, error) {
+	return r.routes.of(f.TenantKey).List(ctx, f)
+}
+
+// PurgeBefore purges the entries of every target.
+func (r *RoutedAuditRepo) PurgeBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	return r.routes.sum(func(repo *AuditRepo) (int64, error) { return repo.PurgeBefore(ctx, cutoff) })
+}
+
+// RoutedEscalationRepo routes domain.EscalationRepository calls like
+// RoutedRepository, since due escalations are joined with their notification.
+type RoutedEscalationRepo struct {
+	routes tenantRoutes[*EscalationRepo]
+}
+
+// NewRoutedEscalationRepo creates an EscalationRepo per target of router.
+func NewRoutedEscalationRepo(router *TenantRouter) *RoutedEscalationRepo {
+	return &RoutedEscalationRepo{routes: newTenantRoutes(router, NewEscalationRepo)}
+}
+
+// ListRules lists the tenant's rules from its target.
+func (r *RoutedEscalationRepo) ListRules(ctx context.Context, tenantKey string) ([]domain.EscalationRule, error) {
+	return r.routes.of(tenantKey).ListRules(ctx, tenantKey)
+}
+
+// GetRule returns the rule from the tenant's target.
+func (r *RoutedEscalationRepo) GetRule(ctx context.Context, tenantKey string, id uuid.UUID) (*domain.EscalationRule, error) {
+	return r.routes.of(tenantKey).GetRule(ctx, tenantKey, id)
+}
+
+// CreateRule creates the rule in its tenant's target.
+func (r *RoutedEscalationRepo) CreateRule(ctx context.Context, rule domain.EscalationRule) (*domain.EscalationRule, error) {
+	return r.routes.of(rule.TenantKey).CreateRule(ctx, rule)
+}
+
+// UpdateRule updates the rule in its tenant's target.
+func (r *RoutedEscalationRepo) UpdateRule(ctx context.Context, rule domain.EscalationRule) (*domain.EscalationRule, error) {
+	return r.routes.of(rule.TenantKey).UpdateRule(ctx, rule)
+}
+
+// DeleteRule deletes the rule in the tenant's target.
+func (r *RoutedEscalationRepo) DeleteRule(ctx context.Context, tenantKey string, id uuid.UUID) error {
+	return r.routes.of(tenantKey).DeleteRule(ctx, tenantKey, id)
+}
+
+// Schedule stores each escalation in its tenant's target.
+func (r *RoutedEscalationRepo) Schedule(ctx context.Context, escalations []domain.Escalation) error {
+	byTarget := make(map[int][]domain.Escalation)
+	for _, e := range escalations {
+		t := r.routes.router.index(e.TenantKey)
+		byTarget[t] = append(byTarget[t], e)
+	}
+	for t, part := range byTarget {
+		if err := r.routes.byPool[t].Schedule(ctx, part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ClaimDue claims due escalations from each target until limit is reached.
+func (r *RoutedEscalationRepo) ClaimDue(ctx context.Context, now time.Time, limit int) ([]domain.Escalation, error) {
+	var out []domain.Escalation
+	for _, repo := range r.routes.byPool {
+		if len(out) >= limit {
+			break
+		}
+		due, err := repo.ClaimDue(ctx, now, limit-len(out))
+		out = append(out, due...)
+		if err != nil {
+			return out, err
+		}
+	}
+	return out, nil
+}
+
+// Cancel drops the user's pending escalations in the tenant's target.
+func (r *RoutedEscalationRepo) Cancel(ctx context.Context, tenantKey, userID string, ids []uuid.UUID) error {
+	return r.routes.of(tenantKey).Cancel(ctx, tenantKey, userID, ids)
+}
+
+// RoutedContentRepo routes domain.ContentRepository calls to the tenant's target.
+type RoutedContentRepo struct {
+	routes tenantRoutes[*ContentRepo]
+}
+
+// NewRoutedContentRepo creates a ContentRepo per target of router.
+func NewRoutedContentRepo(router *TenantRouter) *RoutedContentRepo {
+	return &RoutedContentRepo{routes: newTenantRoutes(router, NewContentRepo)}
+}
+
+// RewriteContent rewrites the tenant's content in its target.
+func (r *RoutedContentRepo) RewriteContent(ctx context.Context, tenantKey string, fn func(*domain.StoredContent) (bool, error)) (int64, error) {
+	return r.routes.of(tenantKey).RewriteContent(ctx, tenantKey, fn)
+}
+
+// RoutedStatsRepo routes domain.StatsRepository calls like RoutedRepository,
+// since each target rolls up its own notifications.
+type RoutedStatsRepo struct {
+	routes tenantRoutes[*StatsRepo]
+}
+
+// NewRoutedStatsRepo creates a StatsRepo per target of router.
+func NewRoutedStatsRepo(router *TenantRouter) *RoutedStatsRepo {
+	return &RoutedStatsRepo{routes: newTenantRoutes(router, NewStatsRepo)}
+}
+
+// Rollup rolls up the stats of every target.
+func (r *RoutedStatsRepo) Rollup(ctx context.Context, from, to time.Time, loc *time.Location) (int64, error) {
+	return r.routes.sum(func(repo *StatsRepo) (int64, error) { return repo.Rollup(ctx, from, to, loc) })
+}
+
+// List lists the stats of f.TenantKey from its target, or of every target when
+// it is empty.
+func (r *RoutedStatsRepo) List(ctx context.Context, f domain.StatsFilter) ([]domain.DailyStats, error) {
+	if f.TenantKey != "" {
+		return r.routes.of(f.TenantKey).List(ctx, f)
+	}
+	var all []domain.DailyStats
+	for _, repo := range r.routes.byPool {
+		stats, err := repo.List(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, stats...)
+	}
+	return all, nil
+}
+
+var (
+	_ domain.Repository           = (*RoutedRepository)(nil)
+	_ domain.StateEventRepository = (*RoutedStateEventRepo)(nil)
+	_ domain.AuditRepository      = (*RoutedAuditRepo)(nil)
+	_ domain.EscalationRepository = (*RoutedEscalationRepo)(nil)
+	_ domain.ContentRepository    = (*RoutedContentRepo)(nil)
+	_ domain.StatsRepository      = (*RoutedStatsRepo)(nil)
+)
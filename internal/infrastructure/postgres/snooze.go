@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// snoozedEventInsert appends a snoozed state event, with the wake-up time in its
+// data, for every row of the "up" CTE. Expects now as $4 and the data as $6.
+const snoozedEventInsert = `INSERT INTO notification_events (notification_id, tenant_key, user_id, kind, occurred_at, data)
+		SELECT id, tenant_key, user_id, '` + string(domain.StateSnoozed) + `', $4, $6::jsonb
+		FROM up`
+
+// Snooze hides a notification until until. Per-user rows carry snoozed_until
+// themselves; broadcasts are snoozed in the user's broadcast_read_state.
+func (r *Repository) Snooze(ctx context.Context, id uuid.UUID, tenantKey, userID string, until time.Time) error {
+	now := r.clock.Now()
+	data, _ := json.Marshal(map[string]any{"until": until.UTC().Format(time.RFC3339Nano)})
+
+	tag, err := r.pool.Exec(ctx, `
+		WITH up AS (
+			UPDATE notifications SET snoozed_until = $5
+			WHERE id = $1 AND tenant_key = $2 AND user_id = $3
+			RETURNING id, tenant_key, user_id
+		)
+		`+snoozedEventInsert, id, tenantKey, userID, now, until, data)
+	if err != nil {
+		return fmt.Errorf("snooze notification: %w", err)
+	}
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+
+	tag, err = r.pool.Exec(ctx, `
+		WITH up AS (
+			INSERT INTO broadcast_read_state (broadcast_id, tenant_key, user_id, snoozed_until)
+			SELECT b.id, $2, $3, $5 FROM broadcast_notifications b
+			WHERE b.id = $1 AND (b.tenant_key = $2 OR b.tenant_key IS NULL)
+			ON CONFLICT (broadcast_id, tenant_key, user_id) DO UPDATE SET snoozed_until = EXCLUDED.snoozed_until
+			WHERE broadcast_read_state.deleted_at IS NULL
+			RETURNING broadcast_id AS id, tenant_key, user_id
+		)
+		`+snoozedEventInsert, id, tenantKey, userID, now, until, data)
+	if err != nil {
+		return fmt.Errorf("snooze broadcast: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrNotificationNotFound
+	}
+	return nil
+}
+
+// ListSnoozed returns the user's snoozed per-user and broadcast notifications.
+func (r *Repository) ListSnoozed(ctx context.Context, tenantKey, userID string) ([]*domain.Notification, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT snoozed_until, `+notificationColumns+`
+		FROM notifications
+		WHERE tenant_key = $1 AND user_id = $2 AND snoozed_until IS NOT NULL
+		UNION ALL
+		SELECT s.snoozed_until, b.id, $1::varchar, $2::varchar, b.type, b.title, b.body, b.metadata,
+			s.read_at IS NOT NULL, s.read_at, b.created_at, b.source_event_id, b.priority, b.category
+		FROM broadcast_read_state s
+		JOIN broadcast_notifications b ON b.id = s.broadcast_id
+		WHERE s.tenant_key = $1 AND s.user_id = $2 AND s.snoozed_until IS NOT NULL AND s.deleted_at IS NULL
+		ORDER BY 1
+	`, tenantKey, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list snoozed notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*domain.Notification
+	for rows.Next() {
+		var until time.Time
+		n, err := scanNotification(leadingRow{rows: rows, lead: []any{&until}})
+		if err != nil {
+			return nil, err
+		}
+		n.SnoozedUntil = &until
+		results = append(results, n)
+	}
+	return results, rows.Err()
+}
+
+// WakeSnoozed clears due snoozes, per-user rows first, then broadcasts up to the
+// remaining limit. SKIP LOCKED lets several instances run the scheduler.
+func (r *Repository) WakeSnoozed(ctx context.Context, limit int) ([]*domain.Notification, error) {
+	now := r.clock.Now()
+	woken, err := r.queryNotifications(ctx, r.pool, `
+		WITH due AS (
+			SELECT id FROM notifications
+			WHERE snoozed_until <= $1
+			ORDER BY snoozed_until
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE notifications n SET snoozed_until = NULL
+		FROM due WHERE n.id = due.id
+		RETURNING `+prefixColumns("n.", notificationColumns), now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("wake snoozed notifications: %w", err)
+	}
+	if len(woken) >= limit {
+		return woken, nil
+	}
+
+	broadcasts, err := r.queryNotifications(ctx, r.pool, `
+		WITH due AS (
+			SELECT broadcast_id, tenant_key, user_id FROM broadcast_read_state
+			WHERE snoozed_until <= $1
+			ORDER BY snoozed_until
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		), woken AS (
+			UPDATE broadcast_read_state s SET snoozed_until = NULL
+			FROM due
+			WHERE s.broadcast_id = due.broadcast_id AND s.tenant_key = due.tenant_key AND s.user_id = due.user_id
+			RETURNING s.broadcast_id, s.tenant_key, s.user_id, s.read_at
+		)
+		SELECT b.id, w.tenant_key, w.user_id, b.type, b.title, b.body, b.metadata,
+			w.read_at IS NOT NULL, w.read_at, b.created_at, b.source_event_id, b.priority, b.category
+		FROM woken w
+		JOIN broadcast_notifications b ON b.id = w.broadcast_id`, now, limit-len(woken))
+	if err != nil {
+		return nil, fmt.Errorf("wake snoozed broadcasts: %w", err)
+	}
+	return append(woken, broadcasts...), nil
+}
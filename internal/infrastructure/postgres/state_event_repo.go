@@ -0,0 +1,230 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// StateEventRepo implements domain.StateEventRepository.
+type StateEventRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewStateEventRepo creates a new StateEventRepo.
+func NewStateEventRepo(pool *pgxpool.Pool) *StateEventRepo {
+	return &StateEventRepo{pool: pool}
+}
+
+const stateEventColumns = `seq, notification_id, tenant_key, user_id, kind, occurred_at, data`
+
+func (r *StateEventRepo) ListByNotification(ctx context.Context, tenantKey, userID string, id uuid.UUID) ([]domain.StateEvent, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT 0::bigint, id, $1::varchar, $2::varchar, 'created', created_at, NULL::jsonb
+		FROM (
+			SELECT id, created_at FROM notifications WHERE id = $3 AND tenant_key = $1 AND user_id = $2
+			UNION ALL
+			SELECT id, created_at FROM notifications_archive WHERE id = $3 AND tenant_key = $1 AND user_id = $2
+			UNION ALL
+			SELECT id, created_at FROM notification_tombstones WHERE id = $3 AND tenant_key = $1 AND user_id = $2
+			UNION ALL
+			SELECT id, created_at FROM broadcast_notifications
+			WHERE id = $3 AND (tenant_key = $1 OR tenant_key IS NULL)
+			LIMIT 1
+		) created
+		UNION ALL
+		(SELECT `+stateEventColumns+` FROM notification_events
+		WHERE notification_id = $3 AND tenant_key = $1 AND user_id = $2
+		ORDER BY seq)
+	`, tenantKey, userID, id)
+	if err != nil {
+		return nil, fmt.Errorf("list notification state events: %w", err)
+	}
+	return scanStateEvents(rows)
+}
+
+func (r *StateEventRepo) ListSince(ctx context.Context, tenantKey, userID string, after int64, limit int) ([]domain.StateEvent, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+stateEventColumns+` FROM notification_events
+		WHERE tenant_key = $1 AND user_id = $2 AND seq > $3
+		ORDER BY seq
+		LIMIT $4
+	`, tenantKey, userID, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list state events: %w", err)
+	}
+	return scanStateEvents(rows)
+}
+
+func (r *StateEventRepo) MarkSeen(ctx context.Context, tenantKey, userID string, ids []uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := r.pool.Query(ctx, `
+		INSERT INTO notification_events (notification_id, tenant_key, user_id, kind)
+		SELECT i.id, $1, $2, 'seen' FROM unnest($3::uuid[]) AS i(id)
+		WHERE NOT EXISTS (
+				SELECT 1 FROM notification_events e
+				WHERE e.notification_id = i.id AND e.tenant_key = $1 AND e.user_id = $2 AND e.kind = 'seen'
+			)
+			AND (
+				EXISTS (SELECT 1 FROM notifications n WHERE n.id = i.id AND n.tenant_key = $1 AND n.user_id = $2)
+				OR EXISTS (SELECT 1 FROM notifications_archive a WHERE a.id = i.id AND a.tenant_key = $1 AND a.user_id = $2)
+				OR EXISTS (SELECT 1 FROM broadcast_notifications b WHERE b.id = i.id AND (b.tenant_key = $1 OR b.tenant_key IS NULL))
+			)
+		RETURNING notification_id
+	`, tenantKey, userID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("mark seen: %w", err)
+	}
+	defer rows.Close()
+
+	var seen []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		seen = append(seen, id)
+	}
+	return seen, rows.Err()
+}
+
+// Undo reverts the latest read or delete in one transaction. A deleted per-user
+// notification is restored from its tombstone into the hot table.
+func (r *StateEventRepo) Undo(ctx context.Context, tenantKey, userID string, id uuid.UUID) (domain.StateEventKind, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return "", fmt.Errorf("begin undo: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var last domain.StateEventKind
+	err = tx.QueryRow(ctx, `
+		SELECT kind FROM notification_events
+		WHERE notification_id = $1 AND tenant_key = $2 AND user_id = $3
+			AND kind IN ('read', 'unread', 'deleted', 'restored')
+		ORDER BY seq DESC
+		LIMIT 1
+		FOR UPDATE
+	`, id, tenantKey, userID).Scan(&last)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", domain.ErrNothingToUndo
+	}
+	if err != nil {
+		return "", fmt.Errorf("find last state event: %w", err)
+	}
+
+	var undone domain.StateEventKind
+	switch last {
+	case domain.StateRead:
+		undone, err = domain.StateUnread, undoRead(ctx, tx, tenantKey, userID, id)
+	case domain.StateDeleted:
+		undone, err = domain.StateRestored, undoDelete(ctx, tx, tenantKey, userID, id)
+	default:
+		return "", domain.ErrNothingToUndo
+	}
+	if err != nil {
+		return "", err
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO notification_events (notification_id, tenant_key, user_id, kind)
+		VALUES ($1, $2, $3, $4)
+	`, id, tenantKey, userID, string(undone)); err != nil {
+		return "", fmt.Errorf("append %s event: %w", undone, err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return "", fmt.Errorf("commit undo: %w", err)
+	}
+	return undone, nil
+}
+
+func undoRead(ctx context.Context, tx pgx.Tx, tenantKey, userID string, id uuid.UUID) error {
+	var affected int64
+	for _, table := range []string{"notifications", "notifications_archive"} {
+		tag, err := tx.Exec(ctx, `
+			UPDATE `+table+` SET is_read = FALSE, read_at = NULL
+			WHERE id = $1 AND tenant_key = $2 AND user_id = $3 AND is_read
+		`, id, tenantKey, userID)
+		if err != nil {
+			return fmt.Errorf("undo read: %w", err)
+		}
+		affected += tag.RowsAffected()
+	}
+	tag, err := tx.Exec(ctx, `
+		UPDATE broadcast_read_state SET read_at = NULL
+		WHERE broadcast_id = $1 AND tenant_key = $2 AND user_id = $3
+			AND read_at IS NOT NULL AND deleted_at IS NULL
+	`, id, tenantKey, userID)
+	if err != nil {
+		return fmt.Errorf("undo broadcast read: %w", err)
+	}
+	if affected+tag.RowsAffected() == 0 {
+		return domain.ErrNothingToUndo
+	}
+	return nil
+}
+
+func undoDelete(ctx context.Context, tx pgx.Tx, tenantKey, userID string, id uuid.UUID) error {
+	tag, err := tx.Exec(ctx, `
+		WITH restored AS (
+			DELETE FROM notification_tombstones
+			WHERE id = $1 AND tenant_key = $2 AND user_id = $3 AND reason = 'deleted'
+			RETURNING *
+		)
+		INSERT INTO notifications (id, tenant_key, user_id, type, category, priority, title, body,
+			metadata, is_read, read_at, created_at, source_event_id)
+		SELECT id, tenant_key, user_id, type, category, priority, title, body,
+			metadata, read_at IS NOT NULL, read_at, created_at, source_event_id
+		FROM restored
+		ON CONFLICT DO NOTHING
+	`, id, tenantKey, userID)
+	if err != nil {
+		return fmt.Errorf("restore notification: %w", err)
+	}
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+	tag, err = tx.Exec(ctx, `
+		UPDATE broadcast_read_state SET deleted_at = NULL
+		WHERE broadcast_id = $1 AND tenant_key = $2 AND user_id = $3 AND deleted_at IS NOT NULL
+	`, id, tenantKey, userID)
+	if err != nil {
+		return fmt.Errorf("restore broadcast notification: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrNothingToUndo
+	}
+	return nil
+}
+
+func (r *StateEventRepo) PurgeBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM notification_events WHERE occurred_at < $1`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("purge state events: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+func scanStateEvents(rows pgx.Rows) ([]domain.StateEvent, error) {
+	defer rows.Close()
+	var results []domain.StateEvent
+	for rows.Next() {
+		var (
+			e        domain.StateEvent
+			dataJSON []byte
+		)
+		if err := rows.Scan(&e.Seq, &e.NotificationID, &e.TenantKey, &e.UserID, &e.Kind, &e.OccurredAt, &dataJSON); err != nil {
+			return nil, err
+		}
+		if len(dataJSON) > 0 {
+			_ = json.Unmarshal(dataJSON, &e.Data)
+		}
+		results = append(results, e)
+	}
+	return results, rows.Err()
+}
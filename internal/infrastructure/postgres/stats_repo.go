@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// StatsRepo implements domain.StatsRepository.
+type StatsRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewStatsRepo creates a new StatsRepo.
+func NewStatsRepo(pool *pgxpool.Pool) *StatsRepo {
+	return &StatsRepo{pool: pool}
+}
+
+// Rollup aggregates hot and archived notifications in one statement, so a
+// notification moved to the archive meanwhile is counted once.
+func (r *StatsRepo) Rollup(ctx context.Context, from, to time.Time, loc *time.Location) (int64, error) {
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+	end := time.Date(to.Year(), to.Month(), to.Day()+1, 0, 0, 0, 0, loc)
+	tag, err := r.pool.Exec(ctx, `
+		WITH delivered AS (
+			SELECT tenant_key, type, created_at, read_at FROM notifications
+			WHERE created_at >= $1 AND created_at < $2
+			UNION ALL
+			SELECT tenant_key, type, created_at, read_at FROM notifications_archive
+			WHERE created_at >= $1 AND created_at < $2
+		)
+		INSERT INTO notification_stats (day, tenant_key, type, delivered, read, median_read_seconds)
+		SELECT (created_at AT TIME ZONE $3)::date, tenant_key, type, COUNT(*), COUNT(read_at),
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM read_at - created_at))
+				FILTER (WHERE read_at IS NOT NULL)
+		FROM delivered
+		GROUP BY 1, 2, 3
+		ON CONFLICT (day, tenant_key, type) DO UPDATE SET
+			delivered           = EXCLUDED.delivered,
+			read                = EXCLUDED.read,
+			median_read_seconds = EXCLUDED.median_read_seconds,
+			computed_at         = NOW()`, start, end, loc.String())
+	if err != nil {
+		return 0, fmt.Errorf("roll up notification stats: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r *StatsRepo) List(ctx context.Context, f domain.StatsFilter) ([]domain.DailyStats, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT day, tenant_key, type, delivered, read, median_read_seconds FROM notification_stats
+		WHERE day BETWEEN $1 AND $2
+		  AND ($3 = '' OR tenant_key = $3)
+		  AND ($4 = '' OR type = $4)
+		ORDER BY day, tenant_key, type`, f.From, f.To, f.TenantKey, string(f.Type))
+	if err != nil {
+		return nil, fmt.Errorf("list notification stats: %w", err)
+	}
+	defer rows.Close()
+
+	var results []domain.DailyStats
+	for rows.Next() {
+		var s domain.DailyStats
+		var t string
+		if err := rows.Scan(&s.Day, &s.TenantKey, &t, &s.Delivered, &s.Read, &s.MedianReadSeconds); err != nil {
+			return nil, fmt.Errorf("scan notification stats: %w", err)
+		}
+		s.Type = domain.NotificationType(t)
+		results = append(results, s)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// TemplateOverrideRepo implements domain.TemplateOverrideRepository.
+type TemplateOverrideRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewTemplateOverrideRepo creates a new TemplateOverrideRepo.
+func NewTemplateOverrideRepo(pool *pgxpool.Pool) *TemplateOverrideRepo {
+	return &TemplateOverrideRepo{pool: pool}
+}
+
+const templateOverrideColumns = `tenant_key, template_key, locale, title_template, body_template, updated_at`
+
+func (r *TemplateOverrideRepo) Find(ctx context.Context, tenantKeys []string, key string, locales []string) ([]domain.TemplateOverride, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+templateOverrideColumns+` FROM template_overrides
+		WHERE tenant_key = ANY($1) AND ($2 = '' OR template_key = $2) AND locale = ANY($3)
+	`, tenantKeys, key, locales)
+	if err != nil {
+		return nil, fmt.Errorf("find template overrides: %w", err)
+	}
+	return scanTemplateOverrides(rows)
+}
+
+func (r *TemplateOverrideRepo) List(ctx context.Context, tenantKey, locale string) ([]domain.TemplateOverride, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+templateOverrideColumns+` FROM template_overrides
+		WHERE tenant_key = $1 AND locale = $2
+		ORDER BY template_key
+	`, tenantKey, locale)
+	if err != nil {
+		return nil, fmt.Errorf("list template overrides: %w", err)
+	}
+	return scanTemplateOverrides(rows)
+}
+
+func (r *TemplateOverrideRepo) Upsert(ctx context.Context, o domain.TemplateOverride) (*domain.TemplateOverride, error) {
+	row := r.pool.QueryRow(ctx, `
+		INSERT INTO template_overrides (tenant_key, template_key, locale, title_template, body_template)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (tenant_key, template_key, locale) DO UPDATE SET
+			title_template = EXCLUDED.title_template,
+			body_template  = EXCLUDED.body_template,
+			updated_at     = NOW()
+		RETURNING `+templateOverrideColumns,
+		o.TenantKey, o.TemplateKey, o.Locale, o.TitleTemplate, o.BodyTemplate)
+	saved, err := scanTemplateOverride(row)
+	if err != nil {
+		return nil, fmt.Errorf("upsert template override: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *TemplateOverrideRepo) Delete(ctx context.Context, tenantKey, key, locale string) error {
+	_, err := r.pool.Exec(ctx, `
+		DELETE FROM template_overrides WHERE tenant_key = $1 AND template_key = $2 AND locale = $3
+	`, tenantKey, key, locale)
+	if err != nil {
+		return fmt.Errorf("delete template override: %w", err)
+	}
+	return nil
+}
+
+// Chain follows template_inheritance from tenantKey. The depth bound also stops
+// a cycle introduced behind the service's back.
+func (r *TemplateOverrideRepo) Chain(ctx context.Context, tenantKey string) ([]string, error) {
+	rows, err := r.pool.Query(ctx, `
+		WITH RECURSIVE chain (tenant_key, depth) AS (
+			SELECT $1::varchar, 0
+			UNION ALL
+			SELECT i.inherits_from, c.depth + 1
+			FROM template_inheritance i
+			JOIN chain c ON c.tenant_key = i.tenant_key
+			WHERE c.depth < $2
+		)
+		SELECT tenant_key FROM chain ORDER BY depth
+	`, tenantKey, domain.MaxTemplateInheritanceDepth)
+	if err != nil {
+		return nil, fmt.Errorf("template inheritance chain: %w", err)
+	}
+	chain, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("template inheritance chain: %w", err)
+	}
+	return chain, nil
+}
+
+func (r *TemplateOverrideRepo) SetParent(ctx context.Context, tenantKey, parent string) error {
+	var err error
+	if parent == "" {
+		_, err = r.pool.Exec(ctx, `DELETE FROM template_inheritance WHERE tenant_key = $1`, tenantKey)
+	} else {
+		_, err = r.pool.Exec(ctx, `
+			INSERT INTO template_inheritance (tenant_key, inherits_from) VALUES ($1, $2)
+			ON CONFLICT (tenant_key) DO UPDATE SET inherits_from = EXCLUDED.inherits_from, updated_at = NOW()
+		`, tenantKey, parent)
+	}
+	if err != nil {
+		return fmt.Errorf("set template parent: %w", err)
+	}
+	return nil
+}
+
+func scanTemplateOverride(row scannable) (*domain.TemplateOverride, error) {
+	var o domain.TemplateOverride
+	if err := row.Scan(&o.TenantKey, &o.TemplateKey, &o.Locale, &o.TitleTemplate, &o.BodyTemplate, &o.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+func scanTemplateOverrides(rows pgx.Rows) ([]domain.TemplateOverride, error) {
+	defer rows.Close()
+	var results []domain.TemplateOverride
+	for rows.Next() {
+		o, err := scanTemplateOverride(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan template override: %w", err)
+		}
+		results = append(results, *o)
+	}
+	return results, rows.Err()
+}
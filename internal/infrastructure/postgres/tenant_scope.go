@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// tenantSetting is the session variable read by the row-level security
+// policies of migration 038.
+const tenantSetting = "app.tenant_key"
+
+// EnableTenantScope makes every connection acquired from a pool built from cfg
+// carry the tenant of the acquiring context (domain.TenantScope) in
+// app.tenant_key, so the row-level security policies restrict its statements
+// to that tenant. Unscoped contexts clear the setting and see every tenant.
+// The setting is only sent when it changes, costing a round trip on the
+// acquires that switch tenant.
+func EnableTenantScope(cfg *pgxpool.Config) {
+	before := cfg.BeforeAcquire
+	cfg.BeforeAcquire = func(ctx context.Context, conn *pgx.Conn) bool {
+		if before != nil && !before(ctx, conn) {
+			return false
+		}
+		return setTenantScope(ctx, conn, domain.TenantScope(ctx))
+	}
+}
+
+// setTenantScope sets app.tenant_key on conn unless it already holds
+// tenantKey. A connection that cannot be scoped is dropped from the pool.
+func setTenantScope(ctx context.Context, conn *pgx.Conn, tenantKey string) bool {
+	data := conn.PgConn().CustomData()
+	if current, _ := data[tenantSetting].(string); current == tenantKey {
+		return true // new connections start unscoped
+	}
+	if _, err := conn.Exec(ctx, "SELECT set_config('"+tenantSetting+"', $1, false)", tenantKey); err != nil {
+		log.Warn().Err(err).Str("tenant", tenantKey).Msg("failed to set tenant scope, dropping connection")
+		return false
+	}
+	data[tenantSetting] = tenantKey
+	return true
+}
@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// TraceRepo implements domain.TraceRepository.
+type TraceRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewTraceRepo creates a new TraceRepo.
+func NewTraceRepo(pool *pgxpool.Pool) *TraceRepo {
+	return &TraceRepo{pool: pool}
+}
+
+func (r *TraceRepo) Append(ctx context.Context, step domain.TraceStep) error {
+	detailJSON, _ := json.Marshal(step.Detail)
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO event_traces (source_event_id, stage, detail) VALUES ($1, $2, $3)
+	`, step.SourceEventID, string(step.Stage), detailJSON)
+	if err != nil {
+		return fmt.Errorf("append event trace: %w", err)
+	}
+	return nil
+}
+
+func (r *TraceRepo) ListBySourceEvent(ctx context.Context, sourceEventID string) ([]domain.TraceStep, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, source_event_id, stage, detail, created_at
+		FROM event_traces WHERE source_event_id = $1
+		ORDER BY id
+	`, sourceEventID)
+	if err != nil {
+		return nil, fmt.Errorf("list event traces: %w", err)
+	}
+	defer rows.Close()
+
+	var results []domain.TraceStep
+	for rows.Next() {
+		var (
+			st         domain.TraceStep
+			detailJSON []byte
+		)
+		if err := rows.Scan(&st.ID, &st.SourceEventID, &st.Stage, &detailJSON, &st.CreatedAt); err != nil {
+			return nil, err
+		}
+		if len(detailJSON) > 0 {
+			_ = json.Unmarshal(detailJSON, &st.Detail)
+		}
+		results = append(results, st)
+	}
+	return results, rows.Err()
+}
+
+func (r *TraceRepo) DeliverySummary(ctx context.Context, sourceEventID string) (*domain.DeliverySummary, error) {
+	var s domain.DeliverySummary
+	err := r.pool.QueryRow(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM notifications WHERE source_event_id = $1),
+			(SELECT COUNT(*) FROM notifications WHERE source_event_id = $1 AND is_read),
+			(SELECT COUNT(*) FROM delivery_outbox o JOIN notifications n ON n.id = o.notification_id
+				WHERE n.source_event_id = $1),
+			EXISTS (SELECT 1 FROM broadcast_notifications WHERE source_event_id = $1)
+	`, sourceEventID).Scan(&s.Notifications, &s.Read, &s.PendingOutbox, &s.Broadcast)
+	if err != nil {
+		return nil, fmt.Errorf("event delivery summary: %w", err)
+	}
+	return &s, nil
+}
+
+func (r *TraceRepo) PurgeBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM event_traces WHERE created_at < $1`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("purge event traces: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
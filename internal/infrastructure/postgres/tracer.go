@@ -0,0 +1,262 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/metrics"
+)
+
+const (
+	// maxQueryShapes bounds the per-shape stats; further shapes share otherQueries.
+	maxQueryShapes = 500
+	otherQueries   = "(other)"
+	// slowQueryLogLimit truncates queries in the slow query log.
+	slowQueryLogLimit = 2000
+)
+
+var (
+	sqlLiteral     = regexp.MustCompile(`'(?:[^']|'')*'`)
+	sqlPlaceholder = regexp.MustCompile(`\$\d+`)
+	sqlWhere       = regexp.MustCompile(`(?i)\bWHERE\s+(.+?)(?:\s+(?:GROUP\s+BY|ORDER\s+BY|LIMIT|OFFSET|RETURNING|FOR\s+UPDATE|ON\s+CONFLICT)\b|\)\s*(?:,|SELECT|INSERT|UPDATE|DELETE)\b|$)`)
+)
+
+// QueryTracer is a pgx tracer that records duration, rows and errors per query
+// shape and logs queries slower than a threshold with their filter shape.
+// Install it on the pool's ConnConfig.Tracer.
+type QueryTracer struct {
+	slow atomic.Int64 // nanoseconds, 0 disables the slow query log
+
+	mu     sync.RWMutex
+	shapes map[string]*queryShape
+}
+
+type queryShape struct {
+	calls, errors, rows, slow atomic.Uint64
+	total                     atomic.Int64 // nanoseconds
+	latency                   *metrics.Histogram
+}
+
+type queryTrace struct {
+	start time.Time
+	sql   string
+	args  []any
+}
+
+type queryTraceKey struct{}
+
+// NewQueryTracer creates a QueryTracer logging queries that take at least slow
+// (0 disables the log).
+func NewQueryTracer(slow time.Duration) *QueryTracer {
+	t := &QueryTracer{shapes: make(map[string]*queryShape)}
+	t.SetSlowThreshold(slow)
+	return t
+}
+
+// SetSlowThreshold changes the slow query log threshold; 0 disables the log.
+func (t *QueryTracer) SetSlowThreshold(d time.Duration) {
+	t.slow.Store(int64(max(d, 0)))
+}
+
+// TraceQueryStart implements pgx.QueryTracer.
+func (t *QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryTraceKey{}, queryTrace{start: time.Now(), sql: data.SQL, args: data.Args})
+}
+
+// TraceQueryEnd implements pgx.QueryTracer.
+func (t *QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	if qt, ok := ctx.Value(queryTraceKey{}).(queryTrace); ok {
+		t.record(qt, data.CommandTag.RowsAffected(), data.Err)
+	}
+}
+
+// TraceCopyFromStart implements pgx.CopyFromTracer.
+func (t *QueryTracer) TraceCopyFromStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	sql := "COPY " + data.TableName.Sanitize() + " (" + strings.Join(data.ColumnNames, ", ") + ") FROM STDIN"
+	return context.WithValue(ctx, queryTraceKey{}, queryTrace{start: time.Now(), sql: sql})
+}
+
+// TraceCopyFromEnd implements pgx.CopyFromTracer.
+func (t *QueryTracer) TraceCopyFromEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	if qt, ok := ctx.Value(queryTraceKey{}).(queryTrace); ok {
+		t.record(qt, data.CommandTag.RowsAffected(), data.Err)
+	}
+}
+
+func (t *QueryTracer) record(qt queryTrace, rows int64, err error) {
+	elapsed := time.Since(qt.start)
+	query := normalizeQuery(qt.sql)
+	s := t.shape(query)
+	s.calls.Add(1)
+	s.total.Add(int64(elapsed))
+	s.latency.Observe(elapsed)
+	if rows > 0 {
+		s.rows.Add(uint64(rows))
+	}
+	if err != nil {
+		s.errors.Add(1)
+	}
+
+	slow := time.Duration(t.slow.Load())
+	if slow <= 0 || elapsed < slow {
+		return
+	}
+	s.slow.Add(1)
+	event := log.Warn().
+		Dur("duration", elapsed).
+		Int64("rows", rows).
+		Strs("filter", filterShape(query)).
+		Strs("args", argShape(qt.args)).
+		Str("query", truncate(query, slowQueryLogLimit))
+	if err != nil {
+		event = event.Err(err)
+	}
+	event.Msg("slow query")
+}
+
+// shape returns the stats of query, creating them while under maxQueryShapes.
+func (t *QueryTracer) shape(query string) *queryShape {
+	t.mu.RLock()
+	s, ok := t.shapes[query]
+	t.mu.RUnlock()
+	if ok {
+		return s
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if s, ok := t.shapes[query]; ok {
+		return s
+	}
+	if len(t.shapes) >= maxQueryShapes {
+		query = otherQueries
+		if s, ok := t.shapes[query]; ok {
+			return s
+		}
+	}
+	s = &queryShape{latency: metrics.NewHistogram(metrics.DefaultLatencyBuckets)}
+	t.shapes[query] = s
+	return s
+}
+
+// QueryStats returns the per-shape stats, by total time spent, highest first.
+func (t *QueryTracer) QueryStats() []metrics.QueryStat {
+	t.mu.RLock()
+	stats := make([]metrics.QueryStat, 0, len(t.shapes))
+	for query, s := range t.shapes {
+		stats = append(stats, metrics.QueryStat{
+			Query:   query,
+			Calls:   s.calls.Load(),
+			Errors:  s.errors.Load(),
+			Rows:    s.rows.Load(),
+			Slow:    s.slow.Load(),
+			TotalMS: float64(s.total.Load()) / float64(time.Millisecond),
+			Latency: s.latency.Snapshot(),
+		})
+	}
+	t.mu.RUnlock()
+	slices.SortFunc(stats, func(a, b metrics.QueryStat) int {
+		switch {
+		case a.TotalMS > b.TotalMS:
+			return -1
+		case a.TotalMS < b.TotalMS:
+			return 1
+		}
+		return strings.Compare(a.Query, b.Query)
+	})
+	return stats
+}
+
+// normalizeQuery reduces sql to its shape, so that calls differing only in
+// arguments, literals or batch size share stats.
+func normalizeQuery(sql string) string {
+	q := strings.Join(strings.Fields(sql), " ")
+	q = sqlLiteral.ReplaceAllString(q, "?")
+	q = sqlPlaceholder.ReplaceAllString(q, "?")
+	return foldValues(q)
+}
+
+// foldValues keeps the first tuple of a multi-row VALUES list.
+func foldValues(q string) string {
+	const kw = "VALUES "
+	i := strings.Index(strings.ToUpper(q), kw)
+	if i < 0 {
+		return q
+	}
+	start := i + len(kw)
+	end := closingParen(q, start)
+	if end < 0 {
+		return q
+	}
+	tuple := q[start : end+1]
+	rest := q[end+1:]
+	for {
+		next := strings.TrimPrefix(strings.TrimPrefix(rest, ","), " ")
+		if len(next) == len(rest) || !strings.HasPrefix(next, tuple) {
+			break
+		}
+		rest = next[len(tuple):]
+	}
+	return q[:end+1] + foldValues(rest)
+}
+
+// closingParen returns the index of the parenthesis closing the one at q[start],
+// or -1.
+func closingParen(q string, start int) int {
+	if start >= len(q) || q[start] != '(' {
+		return -1
+	}
+	depth := 0
+	for i := start; i < len(q); i++ {
+		switch q[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// filterShape returns the WHERE clauses of a normalized query.
+func filterShape(query string) []string {
+	var filters []string
+	for _, m := range sqlWhere.FindAllStringSubmatch(query, -1) {
+		filters = append(filters, m[1])
+	}
+	return filters
+}
+
+// argShape describes the query arguments by type only; NULLs (unset optional
+// filters) show as "null".
+func argShape(args []any) []string {
+	shape := make([]string, len(args))
+	for i, a := range args {
+		v := reflect.ValueOf(a)
+		if a == nil || (v.Kind() == reflect.Pointer && v.IsNil()) {
+			shape[i] = "null"
+			continue
+		}
+		shape[i] = fmt.Sprintf("%T", a)
+	}
+	return shape
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "…"
+}
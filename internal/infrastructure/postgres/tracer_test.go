@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"vn.io.arda/notification/internal/metrics"
+)
+
+func TestNormalizeQuery(t *testing.T) {
+	got := normalizeQuery(`INSERT INTO notifications (id, title) VALUES ($1,$2),($3,$4),
+		($5,$6) ON CONFLICT DO NOTHING RETURNING id`)
+	want := "INSERT INTO notifications (id, title) VALUES (?,?) ON CONFLICT DO NOTHING RETURNING id"
+	if got != want {
+		t.Fatalf("normalizeQuery = %q, want %q", got, want)
+	}
+
+	got = normalizeQuery("SELECT id FROM notification_audit WHERE action = 'created' AND tenant_key = $1\n\t\tORDER BY id LIMIT $2")
+	want = "SELECT id FROM notification_audit WHERE action = ? AND tenant_key = ? ORDER BY id LIMIT ?"
+	if got != want {
+		t.Fatalf("normalizeQuery = %q, want %q", got, want)
+	}
+	if f := filterShape(got); !slices.Equal(f, []string{"action = ? AND tenant_key = ?"}) {
+		t.Fatalf("filterShape = %q", f)
+	}
+}
+
+func TestQueryTracerCapsShapes(t *testing.T) {
+	tr := NewQueryTracer(0)
+	for i := 0; i < maxQueryShapes+10; i++ {
+		tr.shape(normalizeQuery("SELECT " + string(rune('a'+i%26)) + string(rune('a'+i/26%26))))
+	}
+	if len(tr.shapes) > maxQueryShapes+1 {
+		t.Fatalf("expected at most %d shapes, got %d", maxQueryShapes+1, len(tr.shapes))
+	}
+	if _, ok := tr.shapes[otherQueries]; !ok {
+		t.Fatal("expected overflow shape")
+	}
+}
+
+func TestQueryTracerRecordsStats(t *testing.T) {
+	tr := NewQueryTracer(time.Nanosecond) // every query is slow
+	run := func(sql string, args []any, tag string, err error) {
+		ctx := tr.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: sql, Args: args})
+		tr.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag(tag), Err: err})
+	}
+	run("UPDATE notifications SET is_read = TRUE WHERE id = $1", []any{"a"}, "UPDATE 1", nil)
+	run("UPDATE notifications SET is_read = TRUE WHERE id = $1", []any{"b"}, "UPDATE 0", errors.New("deadlock"))
+	ctx := tr.TraceCopyFromStart(context.Background(), nil, pgx.TraceCopyFromStartData{
+		TableName: pgx.Identifier{"notifications_staging"}, ColumnNames: []string{"tenant_key", "user_id"}})
+	tr.TraceCopyFromEnd(ctx, nil, pgx.TraceCopyFromEndData{CommandTag: pgconn.NewCommandTag("COPY 3")})
+
+	stats := make(map[string]metrics.QueryStat)
+	for _, s := range tr.QueryStats() {
+		stats[s.Query] = s
+	}
+	update := stats["UPDATE notifications SET is_read = TRUE WHERE id = ?"]
+	if update.Calls != 2 || update.Errors != 1 || update.Rows != 1 || update.Slow != 2 || update.Latency.Count != 2 {
+		t.Fatalf("update stats = %+v", update)
+	}
+	if copied := stats[`COPY "notifications_staging" (tenant_key, user_id) FROM STDIN`]; copied.Calls != 1 || copied.Rows != 3 {
+		t.Fatalf("copy stats = %+v, all shapes %v", copied, stats)
+	}
+
+	tr.SetSlowThreshold(0)
+	run("SELECT 1", nil, "SELECT 1", nil)
+	if s := tr.QueryStats(); len(s) != 3 {
+		t.Fatalf("%d shapes, want 3", len(s))
+	}
+	for _, s := range tr.QueryStats() {
+		if s.Query == "SELECT ?" && s.Slow != 0 {
+			t.Fatal("counted a slow query with the slow log disabled")
+		}
+	}
+}
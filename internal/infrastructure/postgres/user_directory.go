@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// UserDirectory implements application.IAMResolver over the user_directory table.
+type UserDirectory struct {
+	pool *pgxpool.Pool
+}
+
+// NewUserDirectory creates a new UserDirectory.
+func NewUserDirectory(pool *pgxpool.Pool) *UserDirectory {
+	return &UserDirectory{pool: pool}
+}
+
+func (d *UserDirectory) UsersByTenant(ctx context.Context, tenantKey string) ([]string, error) {
+	ids, err := d.userIDs(ctx, `SELECT user_id FROM user_directory
+		WHERE tenant_key = $1 AND active ORDER BY user_id`, tenantKey)
+	if err != nil {
+		return nil, fmt.Errorf("directory users by tenant: %w", err)
+	}
+	return ids, nil
+}
+
+func (d *UserDirectory) UsersByRole(ctx context.Context, tenantKey, roleName string) ([]string, error) {
+	ids, err := d.userIDs(ctx, `SELECT user_id FROM user_directory
+		WHERE tenant_key = $1 AND active AND roles @> ARRAY[$2::text] ORDER BY user_id`, tenantKey, roleName)
+	if err != nil {
+		return nil, fmt.Errorf("directory users by role: %w", err)
+	}
+	return ids, nil
+}
+
+func (d *UserDirectory) UsersByGroup(ctx context.Context, tenantKey, group string) ([]string, error) {
+	ids, err := d.userIDs(ctx, `SELECT user_id FROM user_directory
+		WHERE tenant_key = $1 AND active AND groups @> ARRAY[$2::text] ORDER BY user_id`, tenantKey, group)
+	if err != nil {
+		return nil, fmt.Errorf("directory users by group: %w", err)
+	}
+	return ids, nil
+}
+
+func (d *UserDirectory) AllActiveUsers(ctx context.Context) (map[string][]string, error) {
+	rows, err := d.pool.Query(ctx, `SELECT tenant_key, user_id FROM user_directory
+		WHERE active ORDER BY tenant_key, user_id`)
+	if err != nil {
+		return nil, fmt.Errorf("directory all active users: %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[string][]string)
+	for rows.Next() {
+		var tenantKey, userID string
+		if err := rows.Scan(&tenantKey, &userID); err != nil {
+			return nil, fmt.Errorf("directory all active users: %w", err)
+		}
+		result[tenantKey] = append(result[tenantKey], userID)
+	}
+	return result, rows.Err()
+}
+
+func (d *UserDirectory) userIDs(ctx context.Context, query string, args ...any) ([]string, error) {
+	rows, err := d.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
@@ -0,0 +1,287 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// WebhookRepo implements domain.WebhookRepository.
+type WebhookRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewWebhookRepo creates a new WebhookRepo.
+func NewWebhookRepo(pool *pgxpool.Pool) *WebhookRepo {
+	return &WebhookRepo{pool: pool}
+}
+
+const webhookColumns = `id, tenant_key, url, events, active, created_at, updated_at`
+
+func (r *WebhookRepo) List(ctx context.Context, tenantKey string) ([]domain.Webhook, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE tenant_key = $1 ORDER BY created_at`, tenantKey)
+	if err != nil {
+		return nil, fmt.Errorf("list webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var results []domain.Webhook
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *w)
+	}
+	return results, rows.Err()
+}
+
+func (r *WebhookRepo) Get(ctx context.Context, tenantKey string, id uuid.UUID) (*domain.Webhook, error) {
+	row := r.pool.QueryRow(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE id = $1 AND tenant_key = $2`, id, tenantKey)
+	w, err := scanWebhook(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get webhook: %w", err)
+	}
+	return w, nil
+}
+
+func (r *WebhookRepo) Create(ctx context.Context, w domain.Webhook) (*domain.Webhook, error) {
+	row := r.pool.QueryRow(ctx, `
+		INSERT INTO webhooks (tenant_key, url, secret, events, active)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+webhookColumns,
+		w.TenantKey, w.URL, w.Secret, webhookEventStrings(w.Events), w.Active)
+	saved, err := scanWebhook(row)
+	if err != nil {
+		return nil, fmt.Errorf("create webhook: %w", err)
+	}
+	saved.Secret = w.Secret
+	return saved, nil
+}
+
+func (r *WebhookRepo) Update(ctx context.Context, w domain.Webhook) (*domain.Webhook, error) {
+	row := r.pool.QueryRow(ctx, `
+		UPDATE webhooks SET
+			url        = $3,
+			secret     = COALESCE(NULLIF($4, ''), secret),
+			events     = $5,
+			active     = $6,
+			updated_at = NOW()
+		WHERE id = $1 AND tenant_key = $2
+		RETURNING `+webhookColumns,
+		w.ID, w.TenantKey, w.URL, w.Secret, webhookEventStrings(w.Events), w.Active)
+	saved, err := scanWebhook(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("webhook not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("update webhook: %w", err)
+	}
+	saved.Secret = w.Secret
+	return saved, nil
+}
+
+func (r *WebhookRepo) Delete(ctx context.Context, tenantKey string, id uuid.UUID) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM webhooks WHERE id = $1 AND tenant_key = $2`, id, tenantKey)
+	if err != nil {
+		return fmt.Errorf("delete webhook: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("webhook not found")
+	}
+	return nil
+}
+
+func (r *WebhookRepo) Enqueue(ctx context.Context, events []domain.WebhookEventInput) (int64, error) {
+	if len(events) == 0 {
+		return 0, nil
+	}
+	tenants := make([]string, len(events))
+	names := make([]string, len(events))
+	payloads := make([]string, len(events))
+	for i, e := range events {
+		payloadJSON, _ := json.Marshal(e.Payload)
+		tenants[i], names[i], payloads[i] = e.TenantKey, string(e.Event), string(payloadJSON)
+	}
+	tag, err := r.pool.Exec(ctx, `
+		INSERT INTO webhook_deliveries (webhook_id, tenant_key, event, payload)
+		SELECT w.id, e.tenant_key, e.event, e.payload::jsonb
+		FROM unnest($1::varchar[], $2::varchar[], $3::text[]) WITH ORDINALITY AS e(tenant_key, event, payload, ord)
+		JOIN webhooks w ON w.tenant_key = e.tenant_key AND w.active AND e.event = ANY(w.events)
+		ORDER BY e.ord, w.id
+	`, tenants, names, payloads)
+	if err != nil {
+		return 0, fmt.Errorf("enqueue webhook deliveries: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r *WebhookRepo) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookDelivery, error) {
+	rows, err := r.pool.Query(ctx, `
+		WITH claimed AS (
+			UPDATE webhook_deliveries SET attempts = attempts + 1, next_attempt_at = NOW() + $2::interval
+			WHERE id IN (
+				SELECT id FROM webhook_deliveries
+				WHERE status = 'pending' AND next_attempt_at <= NOW()
+				ORDER BY id
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING *
+		)
+		SELECT c.id, c.webhook_id, c.tenant_key, c.event, c.payload, c.status, c.attempts, c.next_attempt_at,
+			COALESCE(c.last_status_code, 0), COALESCE(c.last_error, ''), c.created_at, c.delivered_at,
+			w.url, w.secret
+		FROM claimed c JOIN webhooks w ON w.id = c.webhook_id
+		ORDER BY c.id
+	`, limit, lease.String())
+	if err != nil {
+		return nil, fmt.Errorf("claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var results []domain.WebhookDelivery
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows, &deliveryEndpoint{})
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *d)
+	}
+	return results, rows.Err()
+}
+
+func (r *WebhookRepo) MarkDelivered(ctx context.Context, id int64, statusCode int) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE webhook_deliveries
+		SET status = 'delivered', delivered_at = NOW(), last_status_code = $2, last_error = NULL
+		WHERE id = $1
+	`, id, statusCode)
+	if err != nil {
+		return fmt.Errorf("mark webhook delivered: %w", err)
+	}
+	return nil
+}
+
+func (r *WebhookRepo) MarkAttemptFailed(ctx context.Context, id int64, statusCode int, errMsg string, retryAt *time.Time) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE webhook_deliveries
+		SET status = CASE WHEN $4::timestamptz IS NULL THEN 'failed' ELSE 'pending' END,
+			next_attempt_at = COALESCE($4, next_attempt_at),
+			last_status_code = NULLIF($2, 0), last_error = $3
+		WHERE id = $1
+	`, id, statusCode, errMsg, retryAt)
+	if err != nil {
+		return fmt.Errorf("record webhook attempt: %w", err)
+	}
+	return nil
+}
+
+func (r *WebhookRepo) ListDeliveries(ctx context.Context, tenantKey string, webhookID uuid.UUID, status domain.WebhookDeliveryStatus, limit int) ([]domain.WebhookDelivery, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries
+		WHERE webhook_id = $1 AND tenant_key = $2 AND ($3 = '' OR status = $3)
+		ORDER BY id DESC
+		LIMIT $4
+	`, webhookID, tenantKey, string(status), limit)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var results []domain.WebhookDelivery
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows, nil)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *d)
+	}
+	return results, rows.Err()
+}
+
+func (r *WebhookRepo) RetryDelivery(ctx context.Context, tenantKey string, id int64) error {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE webhook_deliveries SET status = 'pending', next_attempt_at = NOW()
+		WHERE id = $1 AND tenant_key = $2 AND status = 'failed'
+	`, id, tenantKey)
+	if err != nil {
+		return fmt.Errorf("retry webhook delivery: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("delivery not found or not failed")
+	}
+	return nil
+}
+
+func (r *WebhookRepo) PurgeBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `
+		DELETE FROM webhook_deliveries WHERE created_at < $1 AND status <> 'pending'
+	`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("purge webhook deliveries: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+const webhookDeliveryColumns = `id, webhook_id, tenant_key, event, payload, status, attempts, next_attempt_at,
+	COALESCE(last_status_code, 0), COALESCE(last_error, ''), created_at, delivered_at`
+
+// deliveryEndpoint receives the webhook columns appended by ClaimDeliveries.
+type deliveryEndpoint struct {
+	url, secret string
+}
+
+func scanWebhookDelivery(row scannable, endpoint *deliveryEndpoint) (*domain.WebhookDelivery, error) {
+	var (
+		d           domain.WebhookDelivery
+		payloadJSON []byte
+	)
+	dest := []any{&d.ID, &d.WebhookID, &d.TenantKey, &d.Event, &payloadJSON, &d.Status, &d.Attempts, &d.NextAttemptAt,
+		&d.LastStatusCode, &d.LastError, &d.CreatedAt, &d.DeliveredAt}
+	if endpoint != nil {
+		dest = append(dest, &endpoint.url, &endpoint.secret)
+	}
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	if len(payloadJSON) > 0 {
+		_ = json.Unmarshal(payloadJSON, &d.Payload)
+	}
+	if endpoint != nil {
+		d.URL, d.Secret = endpoint.url, endpoint.secret
+	}
+	return &d, nil
+}
+
+func scanWebhook(row scannable) (*domain.Webhook, error) {
+	var (
+		w      domain.Webhook
+		events []string
+	)
+	if err := row.Scan(&w.ID, &w.TenantKey, &w.URL, &events, &w.Active, &w.CreatedAt, &w.UpdatedAt); err != nil {
+		return nil, err
+	}
+	for _, e := range events {
+		w.Events = append(w.Events, domain.WebhookEvent(e))
+	}
+	return &w, nil
+}
+
+func webhookEventStrings(events []domain.WebhookEvent) []string {
+	out := make([]string, len(events))
+	for i, e := range events {
+		out[i] = string(e)
+	}
+	return out
+}
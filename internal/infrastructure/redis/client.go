@@ -0,0 +1,272 @@
+// Package redis is a minimal Redis client (RESP2 over TCP) covering the
+// commands used by the unread counter cache.
+package redis
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// DefaultTimeout bounds a command round trip when the context has no deadline.
+const DefaultTimeout = 2 * time.Second
+
+// Error is an error reply from the server.
+type Error string
+
+func (e Error) Error() string { return "redis: " + string(e) }
+
+// Options configures a Client.
+type Options struct {
+	Addr     string // host:port
+	Password string
+	DB       int
+	TLS      bool
+	PoolSize int           // idle connections kept; default 10
+	Timeout  time.Duration // per round trip; default DefaultTimeout
+}
+
+// Client sends commands over a pool of connections. Safe for concurrent use.
+type Client struct {
+	opts  Options
+	idle  chan *conn
+	close chan struct{}
+}
+
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+// NewClient creates a Client for opts. Connections are dialled on first use.
+func NewClient(opts Options) *Client {
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = 10
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	return &Client{opts: opts, idle: make(chan *conn, opts.PoolSize), close: make(chan struct{})}
+}
+
+// Do sends one command and returns its reply: a string, int64, []any, or nil
+// for a null reply. A server error reply is returned as an Error.
+func (c *Client) Do(ctx context.Context, args ...any) (any, error) {
+	replies, err := c.Pipeline(ctx, [][]any{args})
+	if err != nil {
+		return nil, err
+	}
+	if e, ok := replies[0].(Error); ok {
+		return nil, e
+	}
+	return replies[0], nil
+}
+
+// Pipeline sends cmds in one round trip and returns their replies in order.
+// Server error replies are returned in place as Error values.
+func (c *Client) Pipeline(ctx context.Context, cmds [][]any) ([]any, error) {
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	replies, err := cn.roundTrip(ctx, c.opts.Timeout, cmds)
+	if err != nil {
+		cn.Close()
+		return nil, err
+	}
+	c.put(cn)
+	return replies, nil
+}
+
+// Ping checks that the server is reachable.
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.Do(ctx, "PING")
+	return err
+}
+
+// Close closes the idle connections. Connections in use are closed when returned.
+func (c *Client) Close() error {
+	select {
+	case <-c.close:
+		return nil
+	default:
+		close(c.close)
+	}
+	for {
+		select {
+		case cn := <-c.idle:
+			cn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+func (c *Client) get(ctx context.Context) (*conn, error) {
+	select {
+	case cn := <-c.idle:
+		return cn, nil
+	default:
+	}
+	return c.dial(ctx)
+}
+
+func (c *Client) put(cn *conn) {
+	select {
+	case <-c.close:
+		cn.Close()
+		return
+	default:
+	}
+	select {
+	case c.idle <- cn:
+	default:
+		cn.Close()
+	}
+}
+
+func (c *Client) dial(ctx context.Context) (*conn, error) {
+	dialCtx, cancel := context.WithTimeout(ctx, c.opts.Timeout)
+	defer cancel()
+	var (
+		nc  net.Conn
+		err error
+	)
+	if c.opts.TLS {
+		d := &tls.Dialer{Config: &tls.Config{MinVersion: tls.VersionTLS12}}
+		nc, err = d.DialContext(dialCtx, "tcp", c.opts.Addr)
+	} else {
+		var d net.Dialer
+		nc, err = d.DialContext(dialCtx, "tcp", c.opts.Addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redis dial %s: %w", c.opts.Addr, err)
+	}
+	cn := &conn{Conn: nc, r: bufio.NewReader(nc), w: bufio.NewWriter(nc)}
+
+	var setup [][]any
+	if c.opts.Password != "" {
+		setup = append(setup, []any{"AUTH", c.opts.Password})
+	}
+	if c.opts.DB != 0 {
+		setup = append(setup, []any{"SELECT", c.opts.DB})
+	}
+	if len(setup) > 0 {
+		replies, err := cn.roundTrip(ctx, c.opts.Timeout, setup)
+		if err == nil {
+			err = firstError(replies)
+		}
+		if err != nil {
+			nc.Close()
+			return nil, fmt.Errorf("redis connection setup: %w", err)
+		}
+	}
+	return cn, nil
+}
+
+func (cn *conn) roundTrip(ctx context.Context, timeout time.Duration, cmds [][]any) ([]any, error) {
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := cn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	for _, cmd := range cmds {
+		if err := writeCommand(cn.w, cmd); err != nil {
+			return nil, err
+		}
+	}
+	if err := cn.w.Flush(); err != nil {
+		return nil, fmt.Errorf("redis write: %w", err)
+	}
+	replies := make([]any, len(cmds))
+	for i := range cmds {
+		reply, err := readReply(cn.r)
+		if err != nil {
+			return nil, fmt.Errorf("redis read: %w", err)
+		}
+		replies[i] = reply
+	}
+	return replies, nil
+}
+
+// writeCommand encodes cmd as a RESP array of bulk strings.
+func writeCommand(w *bufio.Writer, cmd []any) error {
+	fmt.Fprintf(w, "*%d\r\n", len(cmd))
+	for _, arg := range cmd {
+		var s string
+		switch v := arg.(type) {
+		case string:
+			s = v
+		case []byte:
+			s = string(v)
+		case int:
+			s = strconv.Itoa(v)
+		case int64:
+			s = strconv.FormatInt(v, 10)
+		default:
+			return fmt.Errorf("redis: unsupported argument type %T", arg)
+		}
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(s), s)
+	}
+	return nil
+}
+
+// readReply decodes one RESP2 reply.
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return Error(body), nil
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]any, n)
+		for i := range items {
+			if items[i], err = readReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("unknown reply type %q", kind)
+}
+
+func firstError(replies []any) error {
+	for _, reply := range replies {
+		if e, ok := reply.(Error); ok {
+			return e
+		}
+	}
+	return nil
+}
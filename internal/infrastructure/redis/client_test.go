@@ -0,0 +1,55 @@
+package redis
+
+import (
+	"bufio"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"vn.io.arda/notification/internal/domain"
+)
+
+func TestWriteCommand(t *testing.T) {
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	if err := writeCommand(w, []any{"SET", "k", int64(-2), "PX", 500}); err != nil {
+		t.Fatal(err)
+	}
+	w.Flush()
+	want := "*5\r\n$3\r\nSET\r\n$1\r\nk\r\n$2\r\n-2\r\n$2\r\nPX\r\n$3\r\n500\r\n"
+	if buf.String() != want {
+		t.Fatalf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestReadReply(t *testing.T) {
+	for in, want := range map[string]any{
+		"+OK\r\n":                              "OK",
+		"-ERR wrong\r\n":                       Error("ERR wrong"),
+		":42\r\n":                              int64(42),
+		"$5\r\nhe\r\nl\r\n":                    "he\r\nl",
+		"$-1\r\n":                              nil,
+		"*2\r\n$1\r\n0\r\n*1\r\n$3\r\nabc\r\n": []any{"0", []any{"abc"}},
+	} {
+		got, err := readReply(bufio.NewReader(strings.NewReader(in)))
+		if err != nil {
+			t.Fatalf("%q: %v", in, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%q: got %#v, want %#v", in, got, want)
+		}
+	}
+}
+
+func TestCounterStoreKey_RoundTrip(t *testing.T) {
+	s := NewCounterStore(nil, "", 0)
+	k := domain.CounterKey{TenantKey: "acme:vn*", UserID: "u:1"}
+	key := s.key(k)
+	if strings.ContainsAny(strings.TrimPrefix(key, DefaultKeyPrefix), "*?[") {
+		t.Fatalf("key %q has glob characters", key)
+	}
+	if got, ok := s.parseKey(key); !ok || got != k {
+		t.Fatalf("parseKey(%q) = %+v, %v", key, got, ok)
+	}
+}
@@ -0,0 +1,188 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"vn.io.arda/notification/internal/domain"
+)
+
+// DefaultKeyPrefix namespaces the counter keys.
+const DefaultKeyPrefix = "arda:notif:unread:"
+
+// addScript increments an existing counter, clamping at zero, and leaves a
+// missing one missing so a delta never stands in for a count.
+const addScript = `
+if redis.call('EXISTS', KEYS[1]) == 0 then return 0 end
+if redis.call('INCRBY', KEYS[1], ARGV[1]) < 0 then redis.call('SET', KEYS[1], 0, 'KEEPTTL') end
+return 1`
+
+// scanCount is the SCAN COUNT hint used when walking the counter keys.
+const scanCount = 1000
+
+// CounterStore implements domain.CounterStore with one string key per user,
+// "<prefix><tenant>:<user>", expiring ttl after it was last filled. Requires
+// Redis 6 or later (SET KEEPTTL).
+type CounterStore struct {
+	client *Client
+	prefix string
+	ttl    time.Duration
+
+	mu     sync.Mutex
+	cursor string // SCAN cursor of the reconciliation pass
+}
+
+// NewCounterStore creates a CounterStore; prefix defaults to DefaultKeyPrefix.
+func NewCounterStore(client *Client, prefix string, ttl time.Duration) *CounterStore {
+	if prefix == "" {
+		prefix = DefaultKeyPrefix
+	}
+	return &CounterStore{client: client, prefix: prefix, ttl: ttl, cursor: "0"}
+}
+
+// key escapes the tenant key so neither ':' nor glob characters leak into the
+// key layout or SCAN patterns.
+func (s *CounterStore) key(k domain.CounterKey) string {
+	return s.prefix + url.QueryEscape(k.TenantKey) + ":" + k.UserID
+}
+
+func (s *CounterStore) parseKey(key string) (domain.CounterKey, bool) {
+	tenant, user, ok := strings.Cut(strings.TrimPrefix(key, s.prefix), ":")
+	if !ok {
+		return domain.CounterKey{}, false
+	}
+	tenant, err := url.QueryUnescape(tenant)
+	if err != nil {
+		return domain.CounterKey{}, false
+	}
+	return domain.CounterKey{TenantKey: tenant, UserID: user}, true
+}
+
+// Get implements domain.CounterStore.
+func (s *CounterStore) Get(ctx context.Context, key domain.CounterKey) (int64, bool, error) {
+	reply, err := s.client.Do(ctx, "GET", s.key(key))
+	if err != nil || reply == nil {
+		return 0, false, err
+	}
+	str, _ := reply.(string)
+	count, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("parse unread counter %q: %w", str, err)
+	}
+	return count, true, nil
+}
+
+// Set implements domain.CounterStore.
+func (s *CounterStore) Set(ctx context.Context, key domain.CounterKey, count int64) error {
+	_, err := s.client.Do(ctx, "SET", s.key(key), max(count, 0), "PX", s.ttl.Milliseconds())
+	return err
+}
+
+// Add implements domain.CounterStore. The deltas are sent in one pipeline.
+func (s *CounterStore) Add(ctx context.Context, deltas []domain.CounterDelta) error {
+	if len(deltas) == 0 {
+		return nil
+	}
+	cmds := make([][]any, len(deltas))
+	for i, d := range deltas {
+		cmds[i] = []any{"EVAL", addScript, 1, s.key(d.CounterKey), d.Delta}
+	}
+	replies, err := s.client.Pipeline(ctx, cmds)
+	if err != nil {
+		return err
+	}
+	return firstError(replies)
+}
+
+// Invalidate implements domain.CounterStore.
+func (s *CounterStore) Invalidate(ctx context.Context, keys ...domain.CounterKey) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	args := make([]any, 0, len(keys)+1)
+	args = append(args, "DEL")
+	for _, k := range keys {
+		args = append(args, s.key(k))
+	}
+	_, err := s.client.Do(ctx, args...)
+	return err
+}
+
+// InvalidateTenant implements domain.CounterStore by scanning for the tenant's
+// keys, so it costs a pass over the keyspace.
+func (s *CounterStore) InvalidateTenant(ctx context.Context, tenantKey string) error {
+	pattern := s.prefix + "*"
+	if tenantKey != "" {
+		pattern = s.prefix + url.QueryEscape(tenantKey) + ":*"
+	}
+	cursor := "0"
+	for {
+		next, keys, err := s.scan(ctx, cursor, pattern)
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			args := make([]any, 0, len(keys)+1)
+			args = append(args, "DEL")
+			for _, k := range keys {
+				args = append(args, k)
+			}
+			if _, err := s.client.Do(ctx, args...); err != nil {
+				return err
+			}
+		}
+		if next == "0" {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// Keys implements domain.CounterStore, continuing the SCAN of the previous call.
+func (s *CounterStore) Keys(ctx context.Context, limit int) ([]domain.CounterKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var keys []domain.CounterKey
+	for len(keys) < limit {
+		next, found, err := s.scan(ctx, s.cursor, s.prefix+"*")
+		if err != nil {
+			return keys, err
+		}
+		for _, k := range found {
+			if key, ok := s.parseKey(k); ok {
+				keys = append(keys, key)
+			}
+		}
+		s.cursor = next
+		if next == "0" {
+			break
+		}
+	}
+	return keys, nil
+}
+
+func (s *CounterStore) scan(ctx context.Context, cursor, pattern string) (string, []string, error) {
+	reply, err := s.client.Do(ctx, "SCAN", cursor, "MATCH", pattern, "COUNT", scanCount)
+	if err != nil {
+		return "", nil, err
+	}
+	parts, ok := reply.([]any)
+	if !ok || len(parts) != 2 {
+		return "", nil, fmt.Errorf("unexpected SCAN reply %v", reply)
+	}
+	next, _ := parts[0].(string)
+	items, _ := parts[1].([]any)
+	keys := make([]string, 0, len(items))
+	for _, item := range items {
+		if k, ok := item.(string); ok {
+			keys = append(keys, k)
+		}
+	}
+	return next, keys, nil
+}
@@ -0,0 +1,106 @@
+// Package static implements application.IAMResolver from a JSON user directory file,
+// for on-prem deployments that do not run Keycloak.
+package static
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Directory is the file format:
+//
+//	{"tenants": {"acme": {"users": [{"id": "u1", "roles": ["ADMIN"], "groups": ["/Finance"]}]}}}
+type Directory struct {
+	Tenants map[string]Tenant `json:"tenants"`
+}
+
+// Tenant lists the users of one tenant.
+type Tenant struct {
+	Users []User `json:"users"`
+}
+
+// User is a directory entry. Disabled users are never resolved.
+type User struct {
+	ID       string   `json:"id"`
+	Roles    []string `json:"roles,omitempty"`
+	Groups   []string `json:"groups,omitempty"`
+	Disabled bool     `json:"disabled,omitempty"`
+}
+
+// Resolver implements application.IAMResolver over an in-memory Directory.
+type Resolver struct {
+	dir Directory
+}
+
+// New creates a Resolver over dir.
+func New(dir Directory) *Resolver {
+	return &Resolver{dir: dir}
+}
+
+// Load reads a Directory from a JSON file.
+func Load(path string) (*Resolver, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read user directory: %w", err)
+	}
+	var dir Directory
+	if err := json.Unmarshal(data, &dir); err != nil {
+		return nil, fmt.Errorf("parse user directory %s: %w", path, err)
+	}
+	for tk, t := range dir.Tenants {
+		for i, u := range t.Users {
+			if u.ID == "" {
+				return nil, fmt.Errorf("user directory: tenant %q user #%d has no id", tk, i)
+			}
+		}
+	}
+	return New(dir), nil
+}
+
+// UsersByTenant returns the enabled users of tenantKey.
+func (r *Resolver) UsersByTenant(_ context.Context, tenantKey string) ([]string, error) {
+	return r.filter(tenantKey, func(User) bool { return true }), nil
+}
+
+// UsersByRole returns the enabled users of tenantKey holding roleName.
+func (r *Resolver) UsersByRole(_ context.Context, tenantKey, roleName string) ([]string, error) {
+	return r.filter(tenantKey, func(u User) bool { return slices.Contains(u.Roles, roleName) }), nil
+}
+
+// UsersByGroup returns the enabled members of group. A leading "/" is optional.
+func (r *Resolver) UsersByGroup(_ context.Context, tenantKey, group string) ([]string, error) {
+	group = strings.TrimPrefix(group, "/")
+	return r.filter(tenantKey, func(u User) bool {
+		for _, g := range u.Groups {
+			if strings.TrimPrefix(g, "/") == group {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+// AllActiveUsers returns the enabled users of every tenant.
+func (r *Resolver) AllActiveUsers(_ context.Context) (map[string][]string, error) {
+	result := make(map[string][]string, len(r.dir.Tenants))
+	for tk := range r.dir.Tenants {
+		if ids := r.filter(tk, func(User) bool { return true }); len(ids) > 0 {
+			result[tk] = ids
+		}
+	}
+	return result, nil
+}
+
+func (r *Resolver) filter(tenantKey string, match func(User) bool) []string {
+	var ids []string
+	for _, u := range r.dir.Tenants[tenantKey].Users {
+		if !u.Disabled && match(u) {
+			ids = append(ids, u.ID)
+		}
+	}
+	return ids
+}
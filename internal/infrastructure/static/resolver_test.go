@@ -0,0 +1,50 @@
+package static
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLoadAndResolve(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "directory.json")
+	data := `{"tenants": {
+		"acme": {"users": [
+			{"id": "u1", "roles": ["ADMIN"], "groups": ["/Finance"]},
+			{"id": "u2", "groups": ["Finance"]},
+			{"id": "u3", "roles": ["ADMIN"], "disabled": true}
+		]},
+		"globex": {"users": [{"id": "u9"}]}
+	}}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	r, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if ids, _ := r.UsersByTenant(ctx, "acme"); !slices.Equal(ids, []string{"u1", "u2"}) {
+		t.Fatalf("tenant: got %v", ids)
+	}
+	if ids, _ := r.UsersByRole(ctx, "acme", "ADMIN"); !slices.Equal(ids, []string{"u1"}) {
+		t.Fatalf("role: got %v", ids)
+	}
+	if ids, _ := r.UsersByGroup(ctx, "acme", "/Finance"); !slices.Equal(ids, []string{"u1", "u2"}) {
+		t.Fatalf("group: got %v", ids)
+	}
+	all, _ := r.AllActiveUsers(ctx)
+	if len(all) != 2 || len(all["globex"]) != 1 {
+		t.Fatalf("platform: got %v", all)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"tenants": {"acme": {"users": [{"roles": ["ADMIN"]}]}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Fatal("expected error for user without id")
+	}
+}
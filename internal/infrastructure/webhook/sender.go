@@ -0,0 +1,110 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+
+	"vn.io.arda/notification/internal/domain"
+)
+
+// Request headers sent with every delivery.
+const (
+	HeaderEvent     = "X-Arda-Event"
+	HeaderDelivery  = "X-Arda-Delivery"
+	HeaderTimestamp = "X-Arda-Timestamp"
+	// HeaderSignature is "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body)).
+	HeaderSignature = "X-Arda-Signature"
+)
+
+// maxErrorBody bounds the response body kept as the delivery error.
+const maxErrorBody = 512
+
+// Sender implements domain.WebhookSender.
+type Sender struct {
+	client *http.Client
+}
+
+// NewSender creates a webhook sender whose requests time out after timeout.
+// Unless allowPrivate, it refuses to connect to non-public addresses
+// (domain.PublicAddr), checked at dial time so that a host re-resolving to an
+// internal address after registration is still refused.
+func NewSender(timeout time.Duration, allowPrivate bool) *Sender {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			ap, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !domain.PublicAddr(ap.Addr()) {
+				return fmt.Errorf("webhook address %s is not public", ap.Addr())
+			}
+			return nil
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.Proxy = nil
+	return &Sender{client: &http.Client{Timeout: timeout, Transport: transport}}
+}
+
+// envelope is the JSON body of a delivery.
+type envelope struct {
+	ID        int64               `json:"id"`
+	Event     domain.WebhookEvent `json:"event"`
+	TenantKey string              `json:"tenant_key"`
+	CreatedAt time.Time           `json:"created_at"`
+	Data      map[string]any      `json:"data"`
+}
+
+// Send posts the signed delivery and returns the response status code.
+func (s *Sender) Send(ctx context.Context, d domain.WebhookDelivery) (int, error) {
+	body, err := json.Marshal(envelope{ID: d.ID, Event: d.Event, TenantKey: d.TenantKey, CreatedAt: d.CreatedAt, Data: d.Payload})
+	if err != nil {
+		return 0, fmt.Errorf("encode webhook body: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("build webhook request: %w", err)
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "arda-notification-webhook/1")
+	req.Header.Set(HeaderEvent, string(d.Event))
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(d.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(d.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook responded %d: %s", resp.StatusCode, bytes.TrimSpace(snippet))
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the X-Arda-Signature value for body sent at timestamp (Unix seconds).
+// Receivers recompute it with their secret and compare in constant time.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,81 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"vn.io.arda/notification/internal/domain"
+)
+
+func TestSendSignsBody(t *testing.T) {
+	var got struct {
+		header http.Header
+		body   []byte
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.header = r.Header.Clone()
+		got.body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	d := domain.WebhookDelivery{
+		ID:        7,
+		TenantKey: "acme",
+		Event:     domain.WebhookNotificationRead,
+		Payload:   map[string]any{"id": "n-1"},
+		CreatedAt: time.Now(),
+		URL:       srv.URL,
+		Secret:    "s3cret",
+	}
+	status, err := NewSender(time.Second, true).Send(context.Background(), d)
+	if err != nil || status != http.StatusNoContent {
+		t.Fatalf("Send = %d, %v", status, err)
+	}
+
+	ts, err := strconv.ParseInt(got.header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		t.Fatalf("timestamp header: %v", err)
+	}
+	if sig := got.header.Get(HeaderSignature); sig != Sign("s3cret", ts, got.body) {
+		t.Errorf("signature %q does not match body", sig)
+	}
+	if got.header.Get(HeaderDelivery) != "7" || got.header.Get(HeaderEvent) != "notification.read" {
+		t.Errorf("unexpected headers %v", got.header)
+	}
+	var env envelope
+	if err := json.Unmarshal(got.body, &env); err != nil || env.TenantKey != "acme" || env.Data["id"] != "n-1" {
+		t.Errorf("unexpected body %s (%v)", got.body, err)
+	}
+}
+
+func TestSendFailsOnNon2xx(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	status, err := NewSender(time.Second, true).Send(context.Background(), domain.WebhookDelivery{URL: srv.URL})
+	if err == nil || status != http.StatusBadGateway {
+		t.Fatalf("Send = %d, %v; want 502 error", status, err)
+	}
+}
+
+func TestSendRefusesPrivateAddresses(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	status, err := NewSender(time.Second, false).Send(context.Background(), domain.WebhookDelivery{URL: srv.URL})
+	if err == nil || status != 0 || called {
+		t.Fatalf("Send to %s = %d, %v; want refused", srv.URL, status, err)
+	}
+}
@@ -3,43 +3,308 @@ package kafka
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	"github.com/twmb/franz-go/pkg/kgo"
 	"vn.io.arda/notification/internal/application"
+	"vn.io.arda/notification/internal/domain"
 	"vn.io.arda/notification/internal/kafka/registry"
+	"vn.io.arda/notification/internal/kafka/serde"
 
 	// Blank imports trigger init() in each handler file,
 	// registering all event handlers into the registry.
 	_ "vn.io.arda/notification/internal/kafka/handlers"
 )
 
+// Config holds consumer connection and concurrency settings.
+type Config struct {
+	Brokers []string
+	GroupID string
+	// Topics to consume. Entries may be patterns (see registry.IsTopicPattern),
+	// e.g. "*-events"; topics created later that match are picked up on the next
+	// metadata refresh without a restart.
+	Topics []string
+	// ExcludeTopics are never fetched even when a pattern matches them, e.g. the
+	// dead-letter and other topics this service produces to. Empty entries are ignored.
+	ExcludeTopics []string
+	// Workers is the number of partitions processed concurrently. Records within
+	// a partition are always processed in order by a single worker. Default: 1.
+	Workers int
+	// MaxInFlight bounds the records fetched per poll (and thus in flight across workers).
+	// Zero means no bound.
+	MaxInFlight int
+	// MaxRetries is how many times a failed Fanout is retried before the record
+	// is routed to the dead-letter sink.
+	MaxRetries int
+}
+
+// DeadLetterSink receives records that could not be processed after all retries.
+type DeadLetterSink interface {
+	PublishDeadLetter(ctx context.Context, r *kgo.Record, cause error) error
+}
+
+// RecordDecoder converts record values from their topic's wire format to the
+// JSON handlers parse. Implemented by serde.Decoder.
+type RecordDecoder interface {
+	Decode(ctx context.Context, topic string, value []byte) ([]byte, error)
+}
+
 // Consumer wraps the franz-go Kafka client.
 type Consumer struct {
 	client  *kgo.Client
 	service *application.Service
+	cfg     Config
+	dlq     DeadLetterSink
+	dlqRate *dlqRate
+	decoder RecordDecoder
+
+	topicsMu sync.Mutex // guards cfg.Topics against concurrent SetTopics
+
+	pauseMu  sync.Mutex
+	excluded []string // cfg.ExcludeTopics, paused for good
+	paused   []string // topics paused by admins, see SyncPauses
+
+	// work carries in-flight processing and commits. It is independent of the
+	// ctx given to Start so a shutdown signal stops polling without cutting a
+	// Fanout short; abort cancels it when the shutdown timeout expires.
+	work    context.Context
+	abort   context.CancelFunc
+	stopped chan struct{} // closed when Start returns
+}
+
+// SetDeadLetterSink routes records that keep failing to sink instead of blocking the partition.
+// Without a sink, a failing record is retried on the next poll and nothing after it is committed.
+func (c *Consumer) SetDeadLetterSink(sink DeadLetterSink) {
+	c.dlq = sink
+}
+
+// SetDecoder decodes Avro / Protobuf topics before routing. Without it every
+// record value is handed to the handlers as is (JSON).
+func (c *Consumer) SetDecoder(d RecordDecoder) {
+	c.decoder = d
+}
+
+// dlqRate raises an alert when too many records are dead-lettered within a window.
+type dlqRate struct {
+	alerter   domain.Alerter
+	threshold int
+	window    time.Duration
+
+	mu      sync.Mutex
+	started time.Time
+	count   int
+}
+
+// SetDeadLetterAlert alerts when threshold or more records are dead-lettered
+// within window. A zero threshold disables the alert.
+func (c *Consumer) SetDeadLetterAlert(alerter domain.Alerter, threshold int, window time.Duration) {
+	if threshold <= 0 || alerter == nil {
+		c.dlqRate = nil
+		return
+	}
+	c.dlqRate = &dlqRate{alerter: alerter, threshold: threshold, window: window}
 }
 
-// New creates a Consumer with the given brokers, group ID, and topics.
-func New(brokers []string, groupID string, topics []string, svc *application.Service) (*Consumer, error) {
-	client, err := kgo.NewClient(
-		kgo.SeedBrokers(brokers...),
-		kgo.ConsumerGroup(groupID),
-		kgo.ConsumeTopics(topics...),
+// record counts one dead-lettered record from topic.
+func (d *dlqRate) record(ctx context.Context, topic string) {
+	d.mu.Lock()
+	now := time.Now()
+	if now.Sub(d.started) >= d.window {
+		d.started, d.count = now, 0
+	}
+	d.count++
+	count := d.count
+	d.mu.Unlock()
+
+	if count < d.threshold {
+		return
+	}
+	d.alerter.Alert(ctx, domain.Alert{
+		Key:      "kafka.dlq",
+		Severity: domain.AlertCritical,
+		Source:   "kafka",
+		Summary:  fmt.Sprintf("%d kafka records dead-lettered within %s", count, d.window),
+		Details:  map[string]any{"count": count, "window": d.window.String(), "threshold": d.threshold, "last_topic": topic},
+	})
+}
+
+// New creates a Consumer for the given configuration.
+func New(cfg Config, svc *application.Service) (*Consumer, error) {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(cfg.Brokers...),
+		kgo.ConsumerGroup(cfg.GroupID),
 		kgo.DisableAutoCommit(),
-	)
+	}
+	if topics, ok := topicRegexps(cfg.Topics); ok {
+		opts = append(opts, kgo.ConsumeTopics(topics...), kgo.ConsumeRegex())
+	} else {
+		opts = append(opts, kgo.ConsumeTopics(cfg.Topics...))
+	}
+	client, err := kgo.NewClient(opts...)
 	if err != nil {
 		return nil, err
 	}
-	return &Consumer{client: client, service: svc}, nil
+	exclude := slices.DeleteFunc(slices.Clone(cfg.ExcludeTopics), func(t string) bool { return t == "" })
+	if len(exclude) > 0 {
+		client.PauseFetchTopics(exclude...)
+	}
+	work, abort := context.WithCancel(context.Background())
+	return &Consumer{client: client, service: svc, cfg: cfg, excluded: exclude, work: work, abort: abort, stopped: make(chan struct{})}, nil
+}
+
+// topicRegexps translates topics to regular expressions when any of them is a
+// pattern; franz-go then treats every entry as one, so plain names are anchored too.
+func topicRegexps(topics []string) ([]string, bool) {
+	if !slices.ContainsFunc(topics, registry.IsTopicPattern) {
+		return nil, false
+	}
+	out := make([]string, len(topics))
+	for i, topic := range topics {
+		out[i] = registry.TopicRegexp(topic)
+	}
+	return out, true
 }
 
-// Start begins polling Kafka and processing records. Blocks until ctx is cancelled.
+// SetTopics changes the consumed topics at runtime (config reload): topics not
+// consumed yet are added and dropped ones purged, which rebalances the group.
+// Pattern subscriptions are fixed when the consumer is created; changing them
+// returns an error and leaves the subscription as is.
+func (c *Consumer) SetTopics(topics []string) error {
+	c.topicsMu.Lock()
+	defer c.topicsMu.Unlock()
+
+	current := c.cfg.Topics
+	_, wasRegex := topicRegexps(current)
+	_, isRegex := topicRegexps(topics)
+	if wasRegex || isRegex {
+		if slices.Equal(current, topics) {
+			return nil
+		}
+		return fmt.Errorf("kafka topic patterns cannot change at runtime (have %v, want %v): restart to apply", current, topics)
+	}
+
+	var added, removed []string
+	for _, t := range topics {
+		if !slices.Contains(current, t) {
+			added = append(added, t)
+		}
+	}
+	for _, t := range current {
+		if !slices.Contains(topics, t) {
+			removed = append(removed, t)
+		}
+	}
+	if len(added) > 0 {
+		c.client.AddConsumeTopics(added...)
+	}
+	if len(removed) > 0 {
+		c.client.PurgeTopicsFromConsuming(removed...)
+	}
+	c.cfg.Topics = slices.Clone(topics)
+	if len(added) > 0 || len(removed) > 0 {
+		log.Info().Strs("added", added).Strs("removed", removed).Msg("kafka topics updated")
+	}
+	return nil
+}
+
+// Topics returns the topics (or patterns) this consumer subscribes to.
+func (c *Consumer) Topics() []string {
+	c.topicsMu.Lock()
+	defer c.topicsMu.Unlock()
+	return slices.Clone(c.cfg.Topics)
+}
+
+// PausedTopics returns the topics this instance currently holds paused by admins.
+func (c *Consumer) PausedTopics() []string {
+	c.pauseMu.Lock()
+	defer c.pauseMu.Unlock()
+	return slices.Clone(c.paused)
+}
+
+// SyncPauses pauses fetching of the topics paused through the admin API and
+// resumes the ones no longer paused. Records already fetched are still processed.
+func (c *Consumer) SyncPauses(ctx context.Context) error {
+	pauses, err := c.service.ListConsumerPauses(ctx)
+	if err != nil {
+		return fmt.Errorf("load consumer pauses: %w", err)
+	}
+	want := make([]string, len(pauses))
+	for i, p := range pauses {
+		want[i] = p.Topic
+	}
+
+	c.pauseMu.Lock()
+	defer c.pauseMu.Unlock()
+	var pause, resume []string
+	for _, t := range want {
+		if !slices.Contains(c.paused, t) {
+			pause = append(pause, t)
+		}
+	}
+	for _, t := range c.paused {
+		if !slices.Contains(want, t) && !slices.Contains(c.excluded, t) {
+			resume = append(resume, t)
+		}
+	}
+	if len(pause) > 0 {
+		c.client.PauseFetchTopics(pause...)
+		log.Warn().Strs("topics", pause).Msg("kafka topics paused")
+	}
+	if len(resume) > 0 {
+		c.client.ResumeFetchTopics(resume...)
+		log.Info().Strs("topics", resume).Msg("kafka topics resumed")
+	}
+	c.paused = want
+	return nil
+}
+
+// WatchPauses calls SyncPauses every interval until ctx is cancelled, so pauses
+// made on any instance reach this one.
+func (c *Consumer) WatchPauses(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.SyncPauses(ctx); err != nil {
+				log.Error().Err(err).Msg("kafka pause sync failed")
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Ping checks that a broker answers and that this instance is a member of the
+// consumer group. Membership is briefly lost while the group rebalances.
+func (c *Consumer) Ping(ctx context.Context) error {
+	if err := c.client.Ping(ctx); err != nil {
+		return fmt.Errorf("kafka brokers unreachable: %w", err)
+	}
+	if member, _ := c.client.GroupMetadata(); member == "" {
+		return fmt.Errorf("not a member of consumer group %q", c.cfg.GroupID)
+	}
+	return nil
+}
+
+// Start begins polling Kafka and processing records. Blocks until ctx is cancelled;
+// records already fetched are then processed and committed before the client is
+// closed (see Shutdown).
 func (c *Consumer) Start(ctx context.Context) {
+	defer close(c.stopped)
 	log.Info().Msg("kafka consumer started")
 
 	for {
-		fetches := c.client.PollFetches(ctx)
+		fetches := c.client.PollRecords(ctx, c.cfg.MaxInFlight)
 		if fetches.IsClientClosed() || ctx.Err() != nil {
 			break
 		}
@@ -48,11 +313,11 @@ func (c *Consumer) Start(ctx context.Context) {
 			log.Error().Err(err).Str("topic", topic).Int32("partition", partition).Msg("kafka fetch error")
 		})
 
-		fetches.EachRecord(func(r *kgo.Record) {
-			c.process(ctx, r)
-		})
-
-		if err := c.client.CommitUncommittedOffsets(ctx); err != nil {
+		done := c.processPartitions(c.work, fetches)
+		if len(done) == 0 {
+			continue
+		}
+		if err := c.client.CommitRecords(c.work, done...); err != nil {
 			log.Error().Err(err).Msg("kafka commit error")
 		}
 	}
@@ -61,33 +326,194 @@ func (c *Consumer) Start(ctx context.Context) {
 	log.Info().Msg("kafka consumer stopped")
 }
 
+// Shutdown waits for Start to finish the in-flight batch, commit its offsets and
+// leave the group. Call it after cancelling the ctx passed to Start. If ctx
+// expires first, in-flight processing is aborted; uncommitted records are
+// redelivered to another group member.
+func (c *Consumer) Shutdown(ctx context.Context) error {
+	select {
+	case <-c.stopped:
+		return nil
+	case <-ctx.Done():
+		c.abort()
+		return fmt.Errorf("kafka consumer shutdown: %w", ctx.Err())
+	}
+}
+
+// processPartitions fans partitions out to at most cfg.Workers goroutines.
+// Each partition's records are processed sequentially, preserving per-partition order.
+// Returns, per partition, the last record that was handled (processed or dead-lettered);
+// only these offsets may be committed.
+func (c *Consumer) processPartitions(ctx context.Context, fetches kgo.Fetches) []*kgo.Record {
+	sem := make(chan struct{}, c.cfg.Workers)
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		done []*kgo.Record
+	)
+
+	fetches.EachPartition(func(p kgo.FetchTopicPartition) {
+		if len(p.Records) == 0 {
+			return
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(records []*kgo.Record) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if last := c.processPartition(ctx, records); last != nil {
+				mu.Lock()
+				done = append(done, last)
+				mu.Unlock()
+			}
+		}(p.Records)
+	})
+
+	wg.Wait()
+	return done
+}
+
+// processPartition handles records in order and stops at the first record that could
+// neither be processed nor dead-lettered, rewinding the partition so it is re-fetched.
+// Returns the last handled record, or nil if none was.
+func (c *Consumer) processPartition(ctx context.Context, records []*kgo.Record) *kgo.Record {
+	var last *kgo.Record
+	for _, r := range records {
+		if err := c.handle(ctx, r); err != nil {
+			log.Error().Err(err).
+				Str("topic", r.Topic).
+				Int32("partition", r.Partition).
+				Int64("offset", r.Offset).
+				Msg("kafka record not handled, rewinding partition")
+			c.client.SetOffsets(map[string]map[int32]kgo.EpochOffset{
+				r.Topic: {r.Partition: {Epoch: r.LeaderEpoch, Offset: r.Offset}},
+			})
+			return last
+		}
+		last = r
+	}
+	return last
+}
+
+// handle processes a record with retries, falling back to the dead-letter sink.
+// A nil error means the record's offset may be committed.
+func (c *Consumer) handle(ctx context.Context, r *kgo.Record) error {
+	var err error
+	backoff := 200 * time.Millisecond
+	for attempt := 0; attempt <= c.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			backoff *= 2
+		}
+		if err = c.process(ctx, r); err == nil {
+			return nil
+		}
+		if errors.Is(err, application.ErrRateLimited) || errors.Is(err, application.ErrRecipientCapExceeded) ||
+			errors.Is(err, application.ErrQuotaExceeded) {
+			break // retrying would only add load; dead-letter right away
+		}
+		if errors.Is(err, registry.ErrInvalidPayload) || errors.Is(err, serde.ErrMalformed) ||
+			errors.Is(err, domain.ErrInvalidMetadata) || errors.Is(err, application.ErrMetadataTooLarge) {
+			break // the same payload fails its schema, decoding or metadata checks again
+		}
+	}
+
+	if c.dlq == nil {
+		return err
+	}
+	if dlqErr := c.dlq.PublishDeadLetter(ctx, r, err); dlqErr != nil {
+		return fmt.Errorf("dead-letter publish failed: %w (cause: %v)", dlqErr, err)
+	}
+	log.Warn().Err(err).
+		Str("topic", r.Topic).
+		Int32("partition", r.Partition).
+		Int64("offset", r.Offset).
+		Msg("kafka record routed to dead-letter topic")
+	if c.dlqRate != nil {
+		c.dlqRate.record(ctx, r.Topic)
+	}
+	return nil
+}
+
+// recordHeaders returns the headers of r by lower-cased name.
+func recordHeaders(r *kgo.Record) registry.Headers {
+	if len(r.Headers) == 0 {
+		return nil
+	}
+	h := make(registry.Headers, len(r.Headers))
+	for _, hdr := range r.Headers {
+		h[strings.ToLower(hdr.Key)] = string(hdr.Value)
+	}
+	return h
+}
+
+// route decodes r (when decoder is set) and dispatches it to its handler.
+// fanout is nil when no handler matched or the handler skipped the event.
+func route(ctx context.Context, decoder RecordDecoder, r *kgo.Record) (key string, fanout *domain.FanoutInput, headers registry.Headers, err error) {
+	value := r.Value
+	if decoder != nil {
+		decoded, err := decoder.Decode(ctx, r.Topic, r.Value)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("decode %s record: %w", r.Topic, err)
+		}
+		value = decoded
+	}
+
+	// notification-commands doesn't use eventType routing
+	headers = recordHeaders(r)
+	key, fanout, err = registry.Route(r.Topic, headers, value)
+	return key, fanout, headers, err
+}
+
 // process dispatches a Kafka record to the registered handler via the registry,
-// then calls Fanout on the result.
-func (c *Consumer) process(ctx context.Context, r *kgo.Record) {
+// then calls Fanout on the result. Records without a matching handler are skipped
+// (nil error); schema violations and Fanout failures are returned.
+func (c *Consumer) process(ctx context.Context, r *kgo.Record) error {
 	log.Debug().
 		Str("topic", r.Topic).
 		Str("key", string(r.Key)).
 		Msg("processing kafka record")
 
-	// notification-commands doesn't use eventType routing
-	var fanout = registry.DispatchDirect(r.Topic, r.Value)
-	if fanout == nil {
-		fanout = registry.Dispatch(r.Topic, r.Value)
+	key, fanout, headers, err := route(ctx, c.decoder, r)
+	if err != nil {
+		return err
 	}
 
 	if fanout == nil {
 		log.Debug().Str("topic", r.Topic).Msg("no handler matched, skipping")
-		return
+		return nil
 	}
+	c.service.ApplyEventDefaults(ctx, key, fanout)
+
+	c.service.Trace(ctx, fanout.SourceEventID, domain.TraceHandlerMatched, map[string]any{
+		"topic":       r.Topic,
+		"partition":   r.Partition,
+		"offset":      r.Offset,
+		"scope":       fanout.TargetScope,
+		"target_id":   fanout.TargetID,
+		"type":        fanout.Type,
+		"traceparent": headers.Get(registry.HeaderTraceparent),
+	})
 
-	if err := c.service.Fanout(ctx, *fanout); err != nil {
+	err = c.service.Fanout(application.WithSourceTopic(ctx, r.Topic), *fanout)
+	registry.RecordFanout(key, err)
+	if err != nil {
+		c.service.Trace(ctx, fanout.SourceEventID, domain.TraceFailed, map[string]any{"stage": "fanout", "error": err.Error()})
 		log.Error().Err(err).
 			Str("topic", r.Topic).
 			Str("scope", string(fanout.TargetScope)).
 			Str("target_id", fanout.TargetID).
 			Str("source_event_id", fanout.SourceEventID).
 			Msg("failed to fan-out notification from kafka event")
+		return err
 	}
+	return nil
 }
 
 // --- Shared event envelope ---
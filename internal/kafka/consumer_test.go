@@ -0,0 +1,229 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+	"vn.io.arda/notification/internal/application"
+	"vn.io.arda/notification/internal/testsupport"
+)
+
+// downResolver fails to resolve the users of tenant "down".
+type downResolver struct{ *testsupport.Resolver }
+
+func (r downResolver) UsersByTenant(ctx context.Context, tenantKey string) ([]string, error) {
+	if tenantKey == "down" {
+		return nil, errors.New("iam unavailable")
+	}
+	return r.Resolver.UsersByTenant(ctx, tenantKey)
+}
+
+// busyResolver tracks how many tenant resolutions run at once.
+type busyResolver struct {
+	*testsupport.Resolver
+
+	mu           sync.Mutex
+	active, peak int
+}
+
+func (r *busyResolver) UsersByTenant(ctx context.Context, tenantKey string) ([]string, error) {
+	r.mu.Lock()
+	r.active++
+	r.peak = max(r.peak, r.active)
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		r.active--
+		r.mu.Unlock()
+	}()
+	time.Sleep(5 * time.Millisecond)
+	return r.Resolver.UsersByTenant(ctx, tenantKey)
+}
+
+// deadLetters records the records dead-lettered.
+type deadLetters struct{ records []*kgo.Record }
+
+func (d *deadLetters) PublishDeadLetter(_ context.Context, r *kgo.Record, _ error) error {
+	d.records = append(d.records, r)
+	return nil
+}
+
+func newTestConsumer(t *testing.T, workers int) (*Consumer, *testsupport.Repository) {
+	t.Helper()
+	client, err := kgo.NewClient(kgo.SeedBrokers("127.0.0.1:1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	repo := testsupport.NewRepository()
+	svc := application.NewService(repo, testsupport.NewHub(), downResolver{testsupport.NewResolver()})
+	return &Consumer{client: client, service: svc, cfg: Config{Workers: workers}}, repo
+}
+
+// command returns a notification-commands record at offset for user, or for
+// every user of tenant "down" (which fails) when user is empty.
+func command(partition int32, offset int64, user string) *kgo.Record {
+	value := fmt.Sprintf(`{"commandId":"cmd-%d-%d","tenantKey":"acme","targetScope":"USER","targetId":%q,"title":"t"}`, partition, offset, user)
+	if user == "" {
+		value = fmt.Sprintf(`{"commandId":"cmd-%d-%d","tenantKey":"down","targetScope":"TENANT","targetId":"down","title":"t"}`, partition, offset)
+	}
+	return &kgo.Record{Topic: "notification-commands", Partition: partition, Offset: offset, Value: []byte(value)}
+}
+
+func fetches(partitions map[int32][]*kgo.Record) kgo.Fetches {
+	topic := kgo.FetchTopic{Topic: "notification-commands"}
+	for p, records := range partitions {
+		topic.Partitions = append(topic.Partitions, kgo.FetchPartition{Partition: p, Records: records})
+	}
+	return kgo.Fetches{{Topics: []kgo.FetchTopic{topic}}}
+}
+
+func committed(done []*kgo.Record) map[int32]int64 {
+	out := make(map[int32]int64, len(done))
+	for _, r := range done {
+		out[r.Partition] = r.Offset
+	}
+	return out
+}
+
+func TestProcessPartitionsCommitsOnlyHandledRecords(t *testing.T) {
+	c, repo := newTestConsumer(t, 2)
+	done := c.processPartitions(context.Background(), fetches(map[int32][]*kgo.Record{
+		0: {command(0, 10, "u1"), command(0, 11, ""), command(0, 12, "u2")},
+		1: {command(1, 20, "")},
+	}))
+
+	// Partition 0 stops at the failed record; partition 1 has nothing to commit.
+	if got := committed(done); len(got) != 1 || got[0] != 10 {
+		t.Fatalf("committed = %v, want {0: 10}", got)
+	}
+	if n := len(repo.Notifications()); n != 1 {
+		t.Fatalf("notifications = %d, want 1 (records after the failure are not processed)", n)
+	}
+}
+
+func TestProcessPartitionsCommitsDeadLetteredRecords(t *testing.T) {
+	c, repo := newTestConsumer(t, 2)
+	dlq := &deadLetters{}
+	c.SetDeadLetterSink(dlq)
+	done := c.processPartitions(context.Background(), fetches(map[int32][]*kgo.Record{
+		0: {command(0, 10, "u1"), command(0, 11, ""), command(0, 12, "u2")},
+		1: {command(1, 20, "")},
+	}))
+
+	if got := committed(done); len(got) != 2 || got[0] != 12 || got[1] != 20 {
+		t.Fatalf("committed = %v, want {0: 12, 1: 20}", got)
+	}
+	if len(dlq.records) != 2 {
+		t.Fatalf("dead-lettered = %d, want 2", len(dlq.records))
+	}
+	if n := len(repo.Notifications()); n != 2 {
+		t.Fatalf("notifications = %d, want 2", n)
+	}
+}
+
+func TestProcessPartitionsKeepsPartitionOrder(t *testing.T) {
+	c, repo := newTestConsumer(t, 4)
+	partitions := make(map[int32][]*kgo.Record)
+	for p := int32(0); p < 4; p++ {
+		for o := int64(0); o < 25; o++ {
+			partitions[p] = append(partitions[p], command(p, o, fmt.Sprintf("u%d", p)))
+		}
+	}
+	done := c.processPartitions(context.Background(), fetches(partitions))
+	if got := committed(done); len(got) != 4 || got[0] != 24 || got[3] != 24 {
+		t.Fatalf("committed = %v", got)
+	}
+
+	next := make(map[string]int64)
+	for _, n := range repo.Notifications() {
+		var p int32
+		var o int64
+		if _, err := fmt.Sscanf(n.SourceEventID, "cmd-%d-%d", &p, &o); err != nil {
+			t.Fatal(err)
+		}
+		if user := fmt.Sprintf("u%d", p); n.UserID != user || o != next[user] {
+			t.Fatalf("partition %d: offset %d processed, want %d", p, o, next[user])
+		}
+		next[n.UserID]++
+	}
+	if len(next) != 4 {
+		t.Fatalf("partitions processed = %d, want 4", len(next))
+	}
+}
+
+func TestProcessPartitionsBoundsWorkers(t *testing.T) {
+	client, err := kgo.NewClient(kgo.SeedBrokers("127.0.0.1:1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	resolver := &busyResolver{Resolver: testsupport.NewResolver().AddUsers("acme", "u1")}
+	svc := application.NewService(testsupport.NewRepository(), testsupport.NewHub(), resolver)
+	c := &Consumer{client: client, service: svc, cfg: Config{Workers: 2}}
+
+	partitions := make(map[int32][]*kgo.Record)
+	for p := int32(0); p < 6; p++ {
+		value := fmt.Sprintf(`{"commandId":"cmd-%d","tenantKey":"acme","targetScope":"TENANT","targetId":"acme","title":"t"}`, p)
+		partitions[p] = []*kgo.Record{{Topic: "notification-commands", Partition: p, Value: []byte(value)}}
+	}
+	if done := c.processPartitions(context.Background(), fetches(partitions)); len(done) != 6 {
+		t.Fatalf("%d partitions done, want 6", len(done))
+	}
+	if peak := resolver.peak; peak != 2 {
+		t.Fatalf("%d partitions processed at once, want 2 workers", peak)
+	}
+}
+
+func TestSyncPauses(t *testing.T) {
+	ctx := context.Background()
+	client, err := kgo.NewClient(kgo.SeedBrokers("127.0.0.1:1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	client.PauseFetchTopics("audit-events")
+	svc := application.NewService(testsupport.NewRepository(), testsupport.NewHub(), testsupport.NewResolver(),
+		application.WithConsumerPauses(testsupport.NewConsumerPauses()))
+	c := &Consumer{client: client, service: svc, excluded: []string{"audit-events"}}
+	syncPauses := func() []string {
+		t.Helper()
+		if err := c.SyncPauses(ctx); err != nil {
+			t.Fatal(err)
+		}
+		paused := client.PauseFetchTopics()
+		slices.Sort(paused)
+		return paused
+	}
+
+	for _, topic := range []string{"crm-events", "audit-events"} {
+		if _, err := svc.PauseConsumer(ctx, topic, "", "ops"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := syncPauses(); !slices.Equal(got, []string{"audit-events", "crm-events"}) {
+		t.Fatalf("paused %v", got)
+	}
+	if got := c.PausedTopics(); !slices.Equal(got, []string{"audit-events", "crm-events"}) {
+		t.Fatalf("PausedTopics = %v", got)
+	}
+
+	// Resuming an excluded topic keeps it paused.
+	for _, topic := range []string{"crm-events", "audit-events"} {
+		if err := svc.ResumeConsumer(ctx, topic, "ops"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := syncPauses(); !slices.Equal(got, []string{"audit-events"}) {
+		t.Fatalf("paused %v after resume, want only the excluded topic", got)
+	}
+	if got := c.PausedTopics(); len(got) != 0 {
+		t.Fatalf("PausedTopics = %v after resume", got)
+	}
+}
@@ -11,8 +11,32 @@ func init() {
 	Register("bpm-events", "TASK_ASSIGNED", handleTaskAssigned)
 	Register("bpm-events", "TASK_COMPLETED", handleTaskCompleted)
 	Register("bpm-events", "APPROVAL_REQUIRED", handleApprovalRequired)
+	for _, eventType := range []string{"TASK_ASSIGNED", "TASK_COMPLETED", "APPROVAL_REQUIRED"} {
+		RegisterSchema("bpm-events", eventType, bpmSchema)
+	}
 }
 
+const bpmSchema = `{
+	"$schema": "http://json-schema.org/draft-07/schema#",
+	"type": "object",
+	"required": ["eventType", "tenantKey", "payload"],
+	"properties": {
+		"eventType": {"type": ["string", "null"]},
+		"eventId": {"type": ["string", "null"]},
+		"tenantKey": {"type": "string", "minLength": 1},
+		"payload": {
+			"type": "object",
+			"required": ["taskId", "assigneeId"],
+			"properties": {
+				"taskId": {"type": "string", "minLength": 1},
+				"taskName": {"type": ["string", "null"]},
+				"assigneeId": {"type": "string", "minLength": 1},
+				"processName": {"type": ["string", "null"]}
+			}
+		}
+	}
+}`
+
 type bpmEnv struct {
 	EventType string `json:"eventType"`
 	EventID   string `json:"eventId"`
@@ -47,15 +71,21 @@ func handleTaskAssigned(data []byte) *domain.FanoutInput {
 		TargetID:      env.Payload.AssigneeID,
 		TenantKey:     env.TenantKey,
 		Type:          domain.TypeWorkflow,
+		Category:      domain.CategoryBPMTask,
 		Title:         title,
 		Body:          body,
+		Icon:          "task",
+		Sender:        domain.ServiceSender("bpm"),
 		Metadata: map[string]any{
 			"taskId":      env.Payload.TaskID,
 			"processName": env.Payload.ProcessName,
+			"entityType":  "task",
+			"entityId":    env.Payload.TaskID,
 			"actions": []map[string]string{
-				{"label": "Xem nhiệm vụ", "action": "view", "url": "/bpm/tasks/" + env.Payload.TaskID, "method": "GET", "variant": "primary"},
+				{"label": "Xem nhiệm vụ", "action": "view", "type": "link", "url": "/bpm/tasks/" + env.Payload.TaskID, "method": "GET", "variant": "primary"},
 			},
 		},
+		Template:      &domain.TemplateRef{Key: messages.KeyTaskAssigned, Params: map[string]string{"taskName": env.Payload.TaskName, "processName": env.Payload.ProcessName}},
 		SourceEventID: env.EventID,
 	}
 }
@@ -71,9 +101,18 @@ func handleTaskCompleted(data []byte) *domain.FanoutInput {
 		TargetID:      env.Payload.AssigneeID,
 		TenantKey:     env.TenantKey,
 		Type:          domain.TypeWorkflow,
+		Category:      domain.CategoryBPMTask,
 		Title:         title,
 		Body:          body,
-		Metadata:      map[string]any{"taskId": env.Payload.TaskID, "processName": env.Payload.ProcessName},
+		Icon:          "task-done",
+		Sender:        domain.ServiceSender("bpm"),
+		Metadata: map[string]any{
+			"taskId":      env.Payload.TaskID,
+			"processName": env.Payload.ProcessName,
+			"entityType":  "task",
+			"entityId":    env.Payload.TaskID,
+		},
+		Template:      &domain.TemplateRef{Key: messages.KeyTaskCompleted, Params: map[string]string{"taskName": env.Payload.TaskName}},
 		SourceEventID: env.EventID,
 	}
 }
@@ -89,16 +128,22 @@ func handleApprovalRequired(data []byte) *domain.FanoutInput {
 		TargetID:      env.Payload.AssigneeID,
 		TenantKey:     env.TenantKey,
 		Type:          domain.TypeWorkflow,
+		Category:      domain.CategoryBPMApproval,
 		Title:         title,
 		Body:          body,
+		Icon:          "approval",
+		Sender:        domain.ServiceSender("bpm"),
 		Metadata: map[string]any{
 			"taskId":      env.Payload.TaskID,
 			"processName": env.Payload.ProcessName,
+			"entityType":  "task",
+			"entityId":    env.Payload.TaskID,
 			"actions": []map[string]string{
-				{"label": "Phê duyệt", "action": "approve", "url": "/bpm/tasks/" + env.Payload.TaskID + "/approve", "method": "POST", "variant": "primary"},
-				{"label": "Từ chối", "action": "reject", "url": "/bpm/tasks/" + env.Payload.TaskID + "/reject", "method": "POST", "variant": "destructive"},
+				{"label": "Phê duyệt", "action": "approve", "type": "http", "url": "/bpm/tasks/" + env.Payload.TaskID + "/approve", "method": "POST", "variant": "primary"},
+				{"label": "Từ chối", "action": "reject", "type": "http", "url": "/bpm/tasks/" + env.Payload.TaskID + "/reject", "method": "POST", "variant": "destructive"},
 			},
 		},
+		Template:      &domain.TemplateRef{Key: messages.KeyApprovalRequired, Params: map[string]string{"taskName": env.Payload.TaskName, "processName": env.Payload.ProcessName}},
 		SourceEventID: env.EventID,
 	}
 }
@@ -0,0 +1,108 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"vn.io.arda/notification/internal/domain"
+	"vn.io.arda/notification/internal/infrastructure/opa"
+	_ "vn.io.arda/notification/internal/kafka/handlers"
+	"vn.io.arda/notification/internal/kafka/registry"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of the contract fixtures")
+
+// contractDir holds recorded upstream events as <topic>/<name>.json, each with
+// the expected routing result in <name>.golden.json and optional Kafka headers
+// in <name>.headers.json.
+const contractDir = "testdata/contracts"
+
+// contractResult is what a fixture is expected to produce.
+type contractResult struct {
+	Key    string              `json:"key"`
+	Error  string              `json:"error,omitempty"`
+	Fanout *domain.FanoutInput `json:"fanout"`
+}
+
+// TestContracts routes every fixture through the registry, with schema
+// validation on, and compares the result to its golden file. Run with -update
+// after an intended change and review the golden diff.
+func TestContracts(t *testing.T) {
+	if err := registry.SetSchemaCompiler(func(schema []byte) (registry.Validator, error) {
+		return opa.CompileSchema(schema)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	defer registry.SetSchemaCompiler(nil)
+
+	fixtures, err := filepath.Glob(filepath.Join(contractDir, "*", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	covered := make(map[string]bool)
+	for _, path := range fixtures {
+		if strings.HasSuffix(path, ".golden.json") || strings.HasSuffix(path, ".headers.json") {
+			continue
+		}
+		topic := filepath.Base(filepath.Dir(path))
+		name := strings.TrimSuffix(path, ".json")
+		t.Run(topic+"/"+filepath.Base(name), func(t *testing.T) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var headers registry.Headers
+			if raw, err := os.ReadFile(name + ".headers.json"); err == nil {
+				if err := json.Unmarshal(raw, &headers); err != nil {
+					t.Fatalf("headers: %v", err)
+				}
+			}
+
+			key, fanout, err := registry.Route(topic, headers, data)
+			got := contractResult{Key: key, Fanout: fanout}
+			if err != nil {
+				var verr *registry.ValidationError
+				if !errors.As(err, &verr) {
+					t.Fatal(err)
+				}
+				got.Error = err.Error()
+			}
+			covered[key] = true
+			compareGolden(t, name+".golden.json", got)
+		})
+	}
+
+	for _, key := range registry.Keys() {
+		if !covered[key] {
+			t.Errorf("handler %s has no contract fixture", key)
+		}
+	}
+}
+
+func compareGolden(t *testing.T, path string, got contractResult) {
+	t.Helper()
+	actual, err := json.MarshalIndent(got, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual = append(actual, '\n')
+	if *update {
+		if err := os.WriteFile(path, actual, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+	if !bytes.Equal(want, actual) {
+		t.Errorf("routing result differs from %s (run with -update if intended)\ngot:\n%s", path, actual)
+	}
+}
@@ -10,8 +10,31 @@ import (
 func init() {
 	Register("crm-events", "LEAD_STATUS_CHANGED", handleLeadStatusChanged)
 	Register("crm-events", "DEAL_UPDATED", handleDealUpdated)
+	for _, eventType := range []string{"LEAD_STATUS_CHANGED", "DEAL_UPDATED"} {
+		RegisterSchema("crm-events", eventType, crmSchema)
+	}
 }
 
+const crmSchema = `{
+	"$schema": "http://json-schema.org/draft-07/schema#",
+	"type": "object",
+	"required": ["eventType", "tenantKey", "payload"],
+	"properties": {
+		"eventType": {"type": ["string", "null"]},
+		"eventId": {"type": ["string", "null"]},
+		"tenantKey": {"type": "string", "minLength": 1},
+		"payload": {
+			"type": "object",
+			"required": ["entityId", "ownerId"],
+			"properties": {
+				"entityId": {"type": "string", "minLength": 1},
+				"entityName": {"type": ["string", "null"]},
+				"ownerId": {"type": "string", "minLength": 1}
+			}
+		}
+	}
+}`
+
 type crmEnv struct {
 	EventType string `json:"eventType"`
 	EventID   string `json:"eventId"`
@@ -45,9 +68,13 @@ func handleLeadStatusChanged(data []byte) *domain.FanoutInput {
 		TargetID:      env.Payload.OwnerID,
 		TenantKey:     env.TenantKey,
 		Type:          domain.TypeCRM,
+		Category:      domain.CategoryCRMLead,
 		Title:         title,
 		Body:          body,
-		Metadata:      map[string]any{"entityId": env.Payload.EntityID},
+		Icon:          "lead",
+		Sender:        domain.ServiceSender("crm"),
+		Metadata:      map[string]any{"entityType": "lead", "entityId": env.Payload.EntityID},
+		Template:      &domain.TemplateRef{Key: messages.KeyLeadStatusChanged, Params: map[string]string{"entityName": env.Payload.EntityName}},
 		SourceEventID: env.EventID,
 	}
 }
@@ -63,14 +90,19 @@ func handleDealUpdated(data []byte) *domain.FanoutInput {
 		TargetID:      env.Payload.OwnerID,
 		TenantKey:     env.TenantKey,
 		Type:          domain.TypeCRM,
+		Category:      domain.CategoryCRMDeal,
 		Title:         title,
 		Body:          body,
+		Icon:          "deal",
+		Sender:        domain.ServiceSender("crm"),
 		Metadata: map[string]any{
-			"entityId": env.Payload.EntityID,
+			"entityType": "deal",
+			"entityId":   env.Payload.EntityID,
 			"actions": []map[string]string{
-				{"label": "Xem deal", "action": "view", "url": "/crm/deals/" + env.Payload.EntityID, "method": "GET", "variant": "primary"},
+				{"label": "Xem deal", "action": "view", "type": "link", "url": "/crm/deals/" + env.Payload.EntityID, "method": "GET", "variant": "primary"},
 			},
 		},
+		Template:      &domain.TemplateRef{Key: messages.KeyDealUpdated, Params: map[string]string{"entityName": env.Payload.EntityName}},
 		SourceEventID: env.EventID,
 	}
 }
@@ -2,56 +2,148 @@ package handlers
 
 import (
 	"encoding/json"
+	"time"
 
 	"vn.io.arda/notification/internal/domain"
 )
 
 func init() {
 	RegisterDirect("notification-commands", handleDirectCommand)
+	RegisterSchema("notification-commands", "", directCommandSchema)
 }
 
+// directCommandSchema requires a target: a known scope, a target ID or an explicit target list.
+const directCommandSchema = `{
+	"$schema": "http://json-schema.org/draft-07/schema#",
+	"type": "object",
+	"properties": {
+		"commandId": {"type": ["string", "null"]},
+		"tenantKey": {"type": ["string", "null"]},
+		"targetScope": {"type": ["string", "null"]},
+		"targetId": {"type": ["string", "null"]},
+		"targets": {"type": ["array", "null"], "items": {"type": "object"}},
+		"exclude": {"type": ["array", "null"], "items": {"type": "object"}},
+		"type": {"type": ["string", "null"]},
+		"category": {"type": ["string", "null"]},
+		"priority": {"type": ["string", "null"]},
+		"title": {"type": ["string", "null"]},
+		"body": {"type": ["string", "null"]},
+		"link": {"type": ["string", "null"]},
+		"icon": {"type": ["string", "null"]},
+		"sender": {
+			"type": ["object", "null"],
+			"required": ["type", "id"],
+			"properties": {
+				"type": {"enum": ["service", "user"]},
+				"id": {"type": "string", "minLength": 1},
+				"name": {"type": ["string", "null"]}
+			}
+		},
+		"metadata": {"type": ["object", "null"]},
+		"template": {
+			"type": ["object", "null"],
+			"properties": {"key": {"type": ["string", "null"]}, "params": {"type": ["object", "null"]}}
+		},
+		"rollout": {
+			"type": ["object", "null"],
+			"properties": {
+				"initialPercent": {"type": "integer", "minimum": 0, "maximum": 100},
+				"delaySeconds": {"type": "integer", "minimum": 0},
+				"requireConfirmation": {"type": ["boolean", "null"]}
+			}
+		}
+	},
+	"anyOf": [
+		{"required": ["targetScope"], "properties": {"targetScope": {"enum": ["USER", "TENANT", "PLATFORM", "ROLE", "GROUP"]}}},
+		{"required": ["targetId"], "properties": {"targetId": {"type": "string", "minLength": 1}}},
+		{"required": ["targets"], "properties": {"targets": {"type": "array", "minItems": 1}}}
+	]
+}`
+
 func handleDirectCommand(data []byte) *domain.FanoutInput {
 	var cmd struct {
-		CommandID   string         `json:"commandId"`
-		TenantKey   string         `json:"tenantKey"`
-		TargetScope string         `json:"targetScope"`
-		TargetID    string         `json:"targetId"`
-		Type        string         `json:"type"`
-		Title       string         `json:"title"`
-		Body        string         `json:"body"`
-		Metadata    map[string]any `json:"metadata"`
+		CommandID   string                `json:"commandId"`
+		TenantKey   string                `json:"tenantKey"`
+		TargetScope string                `json:"targetScope"`
+		TargetID    string                `json:"targetId"`
+		Targets     []domain.FanoutTarget `json:"targets"`
+		Exclude     []domain.FanoutTarget `json:"exclude"`
+		Type        string                `json:"type"`
+		Category    string                `json:"category"`
+		Priority    string                `json:"priority"`
+		Title       string                `json:"title"`
+		Body        string                `json:"body"`
+		Link        string                `json:"link"`
+		Icon        string                `json:"icon"`
+		Sender      *domain.Sender        `json:"sender"`
+		Metadata    map[string]any        `json:"metadata"`
+		Template    *domain.TemplateRef   `json:"template"`
+		Rollout     *struct {
+			InitialPercent      int  `json:"initialPercent"`
+			DelaySeconds        int  `json:"delaySeconds"`
+			RequireConfirmation bool `json:"requireConfirmation"`
+		} `json:"rollout"`
 	}
 
 	if err := json.Unmarshal(data, &cmd); err != nil {
 		return nil
 	}
 
+	// Types other than the built-in ones must be registered by the tenant;
+	// the service rejects unknown types instead of coercing them.
 	notifType := domain.NotificationType(cmd.Type)
-	switch notifType {
-	case domain.TypeSystem, domain.TypeWorkflow, domain.TypeCRM, domain.TypeIAM, domain.TypeCustom:
-	default:
+	if notifType == "" {
 		notifType = domain.TypeCustom
 	}
+	// An empty priority lets the service apply the type's default priority.
+	priority := domain.Priority(cmd.Priority)
+	if priority != "" {
+		priority = priority.OrDefault()
+	}
+	category := cmd.Category
+	if !domain.ValidCategory(category) {
+		category = ""
+	}
 
 	scope := domain.TargetScope(cmd.TargetScope)
 	switch scope {
-	case domain.ScopeUser, domain.ScopeTenant, domain.ScopePlatform, domain.ScopeRole:
+	case domain.ScopeUser, domain.ScopeTenant, domain.ScopePlatform, domain.ScopeRole, domain.ScopeGroup:
 	default:
 		if cmd.TargetID != "" {
 			scope = domain.ScopeUser
+		} else if len(cmd.Targets) > 0 {
+			scope = ""
 		} else {
 			return nil
 		}
 	}
 
-	return &domain.FanoutInput{
+	input := &domain.FanoutInput{
 		TargetScope:   scope,
 		TargetID:      cmd.TargetID,
 		TenantKey:     cmd.TenantKey,
 		Type:          notifType,
+		Category:      category,
+		Priority:      priority,
 		Title:         cmd.Title,
 		Body:          cmd.Body,
+		Link:          cmd.Link,
+		Icon:          cmd.Icon,
+		Sender:        cmd.Sender,
 		Metadata:      cmd.Metadata,
 		SourceEventID: cmd.CommandID,
+		Targets:       cmd.Targets,
+		Exclude:       cmd.Exclude,
+	}
+	if cmd.Template != nil && cmd.Template.Key != "" {
+		input.Template = cmd.Template
+	}
+	if cmd.Rollout != nil && scope == domain.ScopePlatform {
+		input.Rollout = &domain.RolloutPlan{
+			InitialPercent:      cmd.Rollout.InitialPercent,
+			Delay:               time.Duration(cmd.Rollout.DelaySeconds) * time.Second,
+			RequireConfirmation: cmd.Rollout.RequireConfirmation,
+		}
 	}
+	return input
 }
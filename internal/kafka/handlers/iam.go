@@ -10,8 +10,31 @@ import (
 func init() {
 	Register("iam-events", "LOGIN_NEW_DEVICE", handleLoginNewDevice)
 	Register("iam-events", "PASSWORD_CHANGED", handlePasswordChanged)
+	for _, eventType := range []string{"LOGIN_NEW_DEVICE", "PASSWORD_CHANGED"} {
+		RegisterSchema("iam-events", eventType, iamSchema)
+	}
 }
 
+const iamSchema = `{
+	"$schema": "http://json-schema.org/draft-07/schema#",
+	"type": "object",
+	"required": ["eventType", "tenantKey", "payload"],
+	"properties": {
+		"eventType": {"type": ["string", "null"]},
+		"eventId": {"type": ["string", "null"]},
+		"tenantKey": {"type": "string", "minLength": 1},
+		"payload": {
+			"type": "object",
+			"required": ["userId"],
+			"properties": {
+				"userId": {"type": "string", "minLength": 1},
+				"ip": {"type": ["string", "null"]},
+				"detail": {"type": ["string", "null"]}
+			}
+		}
+	}
+}`
+
 type iamEnv struct {
 	EventType string `json:"eventType"`
 	EventID   string `json:"eventId"`
@@ -42,9 +65,13 @@ func handleLoginNewDevice(data []byte) *domain.FanoutInput {
 		TargetID:      env.Payload.UserID,
 		TenantKey:     env.TenantKey,
 		Type:          domain.TypeIAM,
+		Category:      domain.CategoryIAMSecurity,
 		Title:         title,
 		Body:          body,
+		Icon:          "security",
+		Sender:        domain.ServiceSender("iam"),
 		Metadata:      map[string]any{"ip": env.Payload.IP, "detail": env.Payload.Detail},
+		Template:      &domain.TemplateRef{Key: messages.KeyLoginNewDevice, Params: map[string]string{"ip": env.Payload.IP}},
 		SourceEventID: env.EventID,
 	}
 }
@@ -60,9 +87,13 @@ func handlePasswordChanged(data []byte) *domain.FanoutInput {
 		TargetID:      env.Payload.UserID,
 		TenantKey:     env.TenantKey,
 		Type:          domain.TypeIAM,
+		Category:      domain.CategoryIAMSecurity,
 		Title:         title,
 		Body:          body,
+		Icon:          "security",
+		Sender:        domain.ServiceSender("iam"),
 		Metadata:      map[string]any{"ip": env.Payload.IP, "detail": env.Payload.Detail},
+		Template:      &domain.TemplateRef{Key: messages.KeyPasswordChanged},
 		SourceEventID: env.EventID,
 	}
 }
@@ -16,5 +16,11 @@ func RegisterDirect(topic string, h registry.EventHandler) {
 	registry.Register(topic, "", h)
 }
 
+// RegisterSchema declares the JSON Schema of a handler's events; payloads that do
+// not match are dead-lettered with the violations instead of reaching the handler.
+func RegisterSchema(topic, eventType, schema string) {
+	registry.RegisterSchema(topic, eventType, schema)
+}
+
 // Ensure domain is imported (used by all handler files).
 var _ = domain.TypeSystem
@@ -12,8 +12,25 @@ func init() {
 	Register("tenant-events", "TENANT_UPDATED", handleTenantUpdated)
 	Register("tenant-events", "TENANT_STATUS_UPDATED", handleTenantStatusUpdated)
 	Register("tenant-events", "TENANT_DELETED", handleTenantDeleted)
+	for _, eventType := range []string{"TENANT_CREATED", "TENANT_UPDATED", "TENANT_STATUS_UPDATED", "TENANT_DELETED"} {
+		RegisterSchema("tenant-events", eventType, tenantSchema)
+	}
 }
 
+const tenantSchema = `{
+	"$schema": "http://json-schema.org/draft-07/schema#",
+	"type": "object",
+	"required": ["eventType", "tenantKey"],
+	"properties": {
+		"eventType": {"type": ["string", "null"]},
+		"eventId": {"type": ["string", "null"]},
+		"tenantKey": {"type": "string", "minLength": 1},
+		"displayName": {"type": ["string", "null"]},
+		"status": {"type": ["string", "null"]},
+		"createdBy": {"type": ["string", "null"]}
+	}
+}`
+
 type tenantEnv struct {
 	EventType   string `json:"eventType"`
 	EventID     string `json:"eventId"`
@@ -31,15 +48,23 @@ func parseTenantEnv(data []byte) (*tenantEnv, bool) {
 	return &env, true
 }
 
-func tenantFanout(env *tenantEnv, title, body string) *domain.FanoutInput {
+func tenantFanout(env *tenantEnv, title, body string, ref *domain.TemplateRef) *domain.FanoutInput {
+	sender := domain.UserSender(env.CreatedBy)
+	if sender == nil {
+		sender = domain.ServiceSender("tenant")
+	}
 	return &domain.FanoutInput{
 		TargetScope:   domain.ScopeRole,
 		TargetID:      "PLATFORM_ADMIN",
 		TenantKey:     "master",
 		Type:          domain.TypeSystem,
+		Category:      domain.CategoryTenantLifecycle,
 		Title:         title,
 		Body:          body,
-		Metadata:      map[string]any{"eventType": env.EventType, "tenantKey": env.TenantKey},
+		Icon:          "tenant",
+		Sender:        sender,
+		Metadata:      map[string]any{"eventType": env.EventType, "tenantKey": env.TenantKey, "entityType": "tenant", "entityId": env.TenantKey},
+		Template:      ref,
 		SourceEventID: env.EventID,
 		OriginUserID:  env.CreatedBy,
 	}
@@ -55,7 +80,9 @@ func handleTenantCreated(data []byte) *domain.FanoutInput {
 		displayName = env.TenantKey
 	}
 	title, body := messages.TenantCreated(displayName)
-	return tenantFanout(env, title, body)
+	return tenantFanout(env, title, body, &domain.TemplateRef{
+		Key: messages.KeyTenantCreated, Params: map[string]string{"displayName": displayName},
+	})
 }
 
 func handleTenantUpdated(data []byte) *domain.FanoutInput {
@@ -68,7 +95,9 @@ func handleTenantUpdated(data []byte) *domain.FanoutInput {
 		displayName = env.TenantKey
 	}
 	title, body := messages.TenantUpdated(displayName)
-	return tenantFanout(env, title, body)
+	return tenantFanout(env, title, body, &domain.TemplateRef{
+		Key: messages.KeyTenantUpdated, Params: map[string]string{"displayName": displayName},
+	})
 }
 
 func handleTenantStatusUpdated(data []byte) *domain.FanoutInput {
@@ -77,7 +106,9 @@ func handleTenantStatusUpdated(data []byte) *domain.FanoutInput {
 		return nil
 	}
 	title, body := messages.TenantStatusUpdated(env.TenantKey, env.Status)
-	return tenantFanout(env, title, body)
+	return tenantFanout(env, title, body, &domain.TemplateRef{
+		Key: messages.KeyTenantStatusUpdated, Params: map[string]string{"tenantKey": env.TenantKey, "status": env.Status},
+	})
 }
 
 func handleTenantDeleted(data []byte) *domain.FanoutInput {
@@ -86,5 +117,7 @@ func handleTenantDeleted(data []byte) *domain.FanoutInput {
 		return nil
 	}
 	title, body := messages.TenantDeleted(env.TenantKey)
-	return tenantFanout(env, title, body)
+	return tenantFanout(env, title, body, &domain.TemplateRef{
+		Key: messages.KeyTenantDeleted, Params: map[string]string{"tenantKey": env.TenantKey},
+	})
 }
@@ -0,0 +1,204 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"vn.io.arda/notification/internal/domain"
+	"vn.io.arda/notification/internal/kafka/registry"
+)
+
+// ProducerTopics names the topics the Producer writes to. An empty topic disables that stream.
+type ProducerTopics struct {
+	Reactions     string
+	Actions       string
+	Lifecycle     string
+	DeadLetter    string
+	Notifications string
+}
+
+// Producer publishes notification-side events back to Kafka.
+type Producer struct {
+	client *kgo.Client
+	topics ProducerTopics
+}
+
+// NewProducer creates a Producer connected to the given brokers.
+func NewProducer(brokers []string, topics ProducerTopics) (*Producer, error) {
+	client, err := kgo.NewClient(kgo.SeedBrokers(brokers...))
+	if err != nil {
+		return nil, err
+	}
+	return &Producer{client: client, topics: topics}, nil
+}
+
+// PublishReaction emits a reaction event keyed by source event ID so the
+// originating service receives all responses for an event on one partition.
+// This satisfies the domain.ReactionPublisher interface.
+func (p *Producer) PublishReaction(ctx context.Context, r domain.Reaction) error {
+	if p.topics.Reactions == "" {
+		return nil
+	}
+	value, err := json.Marshal(EventEnvelope{
+		EventType: "NOTIFICATION_REACTED",
+		EventID:   r.ID.String(),
+		TenantKey: r.TenantKey,
+		Payload:   mustMarshal(r),
+	})
+	if err != nil {
+		return err
+	}
+	record := &kgo.Record{Topic: p.topics.Reactions, Key: []byte(r.SourceEventID), Value: value}
+	if err := p.client.ProduceSync(ctx, record).FirstErr(); err != nil {
+		return fmt.Errorf("produce reaction: %w", err)
+	}
+	return nil
+}
+
+// PublishAction emits a chosen command action as an event of type r.Command, keyed
+// by source event ID like reactions.
+// This satisfies the domain.ActionPublisher interface.
+func (p *Producer) PublishAction(ctx context.Context, r domain.ActionRecord) error {
+	if p.topics.Actions == "" {
+		return nil
+	}
+	value, err := json.Marshal(EventEnvelope{
+		EventType: r.Command,
+		EventID:   r.ID.String(),
+		TenantKey: r.TenantKey,
+		Payload:   mustMarshal(r),
+	})
+	if err != nil {
+		return err
+	}
+	record := &kgo.Record{Topic: p.topics.Actions, Key: []byte(r.SourceEventID), Value: value}
+	if err := p.client.ProduceSync(ctx, record).FirstErr(); err != nil {
+		return fmt.Errorf("produce action: %w", err)
+	}
+	return nil
+}
+
+// PublishDeadLetter copies a record that could not be processed to the dead-letter topic,
+// preserving key/value and recording its origin and the failure cause in headers.
+// Schema violations are also listed as a JSON array in x-validation-errors.
+// This satisfies the DeadLetterSink interface.
+func (p *Producer) PublishDeadLetter(ctx context.Context, r *kgo.Record, cause error) error {
+	if p.topics.DeadLetter == "" {
+		return fmt.Errorf("dead-letter topic not configured")
+	}
+	headers := append([]kgo.RecordHeader(nil), r.Headers...)
+	headers = append(headers,
+		kgo.RecordHeader{Key: "x-origin-topic", Value: []byte(r.Topic)},
+		kgo.RecordHeader{Key: "x-origin-partition", Value: []byte(strconv.Itoa(int(r.Partition)))},
+		kgo.RecordHeader{Key: "x-origin-offset", Value: []byte(strconv.FormatInt(r.Offset, 10))},
+		kgo.RecordHeader{Key: "x-error", Value: []byte(cause.Error())},
+	)
+	var invalid *registry.ValidationError
+	if errors.As(cause, &invalid) {
+		violations, _ := json.Marshal(invalid.Violations)
+		headers = append(headers, kgo.RecordHeader{Key: "x-validation-errors", Value: violations})
+	}
+	record := &kgo.Record{Topic: p.topics.DeadLetter, Key: r.Key, Value: r.Value, Headers: headers}
+	if err := p.client.ProduceSync(ctx, record).FirstErr(); err != nil {
+		return fmt.Errorf("produce dead letter: %w", err)
+	}
+	return nil
+}
+
+// Service lifecycle event types published to the lifecycle topic.
+const (
+	LifecycleDraining = "service.draining"
+)
+
+// LifecycleEvent describes a state change of this service instance.
+type LifecycleEvent struct {
+	Instance     string    `json:"instance"`
+	Region       string    `json:"region,omitempty"`
+	DrainSeconds int       `json:"drainSeconds,omitempty"`
+	At           time.Time `json:"at"`
+}
+
+// PublishLifecycle emits a service lifecycle event (e.g. service.draining) keyed by instance,
+// so integrators can shift traffic before connections drop.
+func (p *Producer) PublishLifecycle(ctx context.Context, eventType string, ev LifecycleEvent) error {
+	if p.topics.Lifecycle == "" {
+		return nil
+	}
+	value, err := json.Marshal(EventEnvelope{
+		EventType: eventType,
+		EventID:   uuid.NewString(),
+		Payload:   mustMarshal(ev),
+	})
+	if err != nil {
+		return err
+	}
+	record := &kgo.Record{Topic: p.topics.Lifecycle, Key: []byte(ev.Instance), Value: value}
+	if err := p.client.ProduceSync(ctx, record).FirstErr(); err != nil {
+		return fmt.Errorf("produce lifecycle event: %w", err)
+	}
+	return nil
+}
+
+// PublishNotificationEvents emits notification events (notification.created,
+// notification.read, notification.deleted) keyed by notification ID, so the
+// events of one notification stay in order on one partition; a "mark all read"
+// event is keyed by its user. Records are produced asynchronously: failures are
+// logged, and Close flushes what is still buffered.
+// This satisfies the domain.NotificationEventPublisher interface.
+func (p *Producer) PublishNotificationEvents(ctx context.Context, events []domain.WebhookEventInput) error {
+	if p.topics.Notifications == "" {
+		return nil
+	}
+	// The records outlive the request that produced the events.
+	ctx = context.WithoutCancel(ctx)
+	for _, e := range events {
+		value, err := json.Marshal(EventEnvelope{
+			EventType: string(e.Event),
+			EventID:   uuid.NewString(),
+			TenantKey: e.TenantKey,
+			Payload:   mustMarshal(e.Payload),
+		})
+		if err != nil {
+			return err
+		}
+		record := &kgo.Record{Topic: p.topics.Notifications, Key: []byte(notificationEventKey(e)), Value: value}
+		p.client.Produce(ctx, record, func(r *kgo.Record, err error) {
+			if err != nil {
+				log.Error().Err(err).Str("event", string(e.Event)).Str("key", string(r.Key)).
+					Msg("failed to produce notification event")
+			}
+		})
+	}
+	return nil
+}
+
+// notificationEventKey is the record key of e: its notification ID, else its user.
+func notificationEventKey(e domain.WebhookEventInput) string {
+	if id, ok := e.Payload["id"].(string); ok && id != "" {
+		return id
+	}
+	userID, _ := e.Payload["user_id"].(string)
+	return e.TenantKey + "/" + userID
+}
+
+// Close flushes pending records and closes the client.
+func (p *Producer) Close() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := p.client.Flush(ctx); err != nil {
+		log.Warn().Err(err).Msg("kafka producer: records still buffered at shutdown were dropped")
+	}
+	p.client.Close()
+}
+
+func mustMarshal(v any) json.RawMessage {
+	b, _ := json.Marshal(v)
+	return b
+}
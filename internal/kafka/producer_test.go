@@ -0,0 +1,76 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// bufferedRecords captures the records a client buffers, so they can be
+// checked without a broker.
+type bufferedRecords struct {
+	mu      sync.Mutex
+	records []*kgo.Record
+}
+
+func (b *bufferedRecords) OnProduceRecordBuffered(r *kgo.Record) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.records = append(b.records, r)
+}
+
+func newTestProducer(t *testing.T, topics ProducerTopics) (*Producer, *bufferedRecords) {
+	t.Helper()
+	buffered := &bufferedRecords{}
+	client, err := kgo.NewClient(kgo.SeedBrokers("127.0.0.1:1"), kgo.WithHooks(buffered))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	return &Producer{client: client, topics: topics}, buffered
+}
+
+func TestPublishLifecycle(t *testing.T) {
+	p, buffered := newTestProducer(t, ProducerTopics{Lifecycle: "notification-lifecycle"})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	// No broker answers, so only the buffered record is checked.
+	_ = p.PublishLifecycle(ctx, LifecycleDraining, LifecycleEvent{Instance: "pod-1", DrainSeconds: 30, At: at})
+
+	buffered.mu.Lock()
+	defer buffered.mu.Unlock()
+	if len(buffered.records) != 1 {
+		t.Fatalf("buffered %d records, want 1", len(buffered.records))
+	}
+	r := buffered.records[0]
+	if r.Topic != "notification-lifecycle" || string(r.Key) != "pod-1" {
+		t.Fatalf("record topic %q key %q", r.Topic, r.Key)
+	}
+	env, err := ParseEnvelope(r.Value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ev LifecycleEvent
+	if err := json.Unmarshal(env.Payload, &ev); err != nil {
+		t.Fatal(err)
+	}
+	if env.EventType != LifecycleDraining || env.EventID == "" || ev.Instance != "pod-1" || ev.DrainSeconds != 30 || !ev.At.Equal(at) {
+		t.Fatalf("envelope %+v, event %+v", env, ev)
+	}
+}
+
+func TestPublishLifecycleDisabled(t *testing.T) {
+	p, buffered := newTestProducer(t, ProducerTopics{})
+	if err := p.PublishLifecycle(context.Background(), LifecycleDraining, LifecycleEvent{Instance: "pod-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(buffered.records) != 0 {
+		t.Fatal("produced a lifecycle event without a lifecycle topic")
+	}
+}
@@ -0,0 +1,70 @@
+package registry
+
+import (
+	"strings"
+
+	"vn.io.arda/notification/internal/domain"
+)
+
+// Kafka record headers understood by Route. Producers that set them spare the
+// service a JSON probe of every message and pass context the payload lacks.
+const (
+	// HeaderEventType routes the record like the payload's eventType field, which
+	// it takes precedence over.
+	HeaderEventType = "x-event-type"
+	// HeaderTenantKey fills the tenant of events whose payload has none.
+	HeaderTenantKey = "x-tenant-key"
+	// HeaderLocale is the locale templated notifications are rendered in at fan-out.
+	HeaderLocale = "x-locale"
+	// HeaderTraceparent is the W3C trace context of the producing request.
+	HeaderTraceparent = "traceparent"
+)
+
+// Headers are a record's Kafka headers keyed by lower-cased name; of repeated
+// headers the last one wins.
+type Headers map[string]string
+
+// Get returns the value of the header name (case-insensitive), or "".
+func (h Headers) Get(name string) string {
+	return h[strings.ToLower(name)]
+}
+
+var metadataHeaders = []string{HeaderTraceparent}
+
+// SetMetadataHeaders sets the headers copied into the metadata of routed
+// notifications, under their lower-cased name. Metadata set by the handler is
+// never overwritten. Must be called before events are routed.
+func SetMetadataHeaders(names []string) {
+	metadataHeaders = metadataHeaders[:0:0]
+	for _, name := range names {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			metadataHeaders = append(metadataHeaders, name)
+		}
+	}
+}
+
+// apply fills fanout from the headers: an empty tenant or locale, and the
+// configured metadata headers.
+func (h Headers) apply(fanout *domain.FanoutInput) {
+	if len(h) == 0 {
+		return
+	}
+	if fanout.TenantKey == "" {
+		fanout.TenantKey = h.Get(HeaderTenantKey)
+	}
+	if fanout.Locale == "" {
+		fanout.Locale = h.Get(HeaderLocale)
+	}
+	for _, name := range metadataHeaders {
+		v := h.Get(name)
+		if v == "" {
+			continue
+		}
+		if fanout.Metadata == nil {
+			fanout.Metadata = make(map[string]any)
+		}
+		if _, exists := fanout.Metadata[name]; !exists {
+			fanout.Metadata[name] = v
+		}
+	}
+}
@@ -5,6 +5,8 @@ package registry
 
 import (
 	"encoding/json"
+	"maps"
+	"slices"
 
 	"github.com/rs/zerolog/log"
 	"vn.io.arda/notification/internal/domain"
@@ -17,6 +19,8 @@ type EventHandler func(data []byte) *domain.FanoutInput
 var mu_handlers = map[string]EventHandler{}
 
 // Register binds a handler to a {topic}:{eventType} key.
+// topic may be a pattern (see IsTopicPattern), e.g. "*-events"; a handler
+// registered for the exact topic takes precedence over patterns.
 // Should be called from each domain handler's init() function.
 // Panics on duplicate registration to catch config mistakes early.
 func Register(topic, eventType string, h EventHandler) {
@@ -24,38 +28,97 @@ func Register(topic, eventType string, h EventHandler) {
 	if _, exists := mu_handlers[key]; exists {
 		panic("registry: duplicate handler registered for key: " + key)
 	}
+	if IsTopicPattern(topic) {
+		registerPattern(topic, eventType, key)
+	}
 	mu_handlers[key] = h
 }
 
+// Keys returns the {topic}:{eventType} keys of every registered handler, sorted.
+func Keys() []string {
+	return slices.Sorted(maps.Keys(mu_handlers))
+}
+
+// Route dispatches data to the direct handler of topic if one is registered, otherwise
+// to the handler of its eventType. key identifies the handler for RecordFanout.
+// The produced fanout is completed from headers (see Headers.apply).
+// A *ValidationError is returned when data does not match the handler's schema.
+func Route(topic string, headers Headers, data []byte) (key string, fanout *domain.FanoutInput, err error) {
+	key, fanout, ok, err := dispatchDirect(topic, data)
+	if !ok {
+		key, fanout, err = dispatch(topic, headers, data)
+	}
+	if fanout != nil {
+		headers.apply(fanout)
+	}
+	return key, fanout, err
+}
+
 // Dispatch looks up and calls the handler for the given topic + eventType.
-// The eventType is extracted from the "eventType" JSON field in data.
-// Returns nil if no handler found or data cannot be parsed.
-func Dispatch(topic string, data []byte) *domain.FanoutInput {
-	// Extract eventType without full parse
-	var probe struct {
-		EventType string `json:"eventType"`
+// The eventType is taken from the x-event-type header, or else extracted from
+// the "eventType" JSON field in data.
+// Returns nil if no handler found or data cannot be parsed or validated.
+func Dispatch(topic string, headers Headers, data []byte) *domain.FanoutInput {
+	_, fanout, _ := dispatch(topic, headers, data)
+	if fanout != nil {
+		headers.apply(fanout)
 	}
-	if err := json.Unmarshal(data, &probe); err != nil {
-		log.Warn().Str("topic", topic).Err(err).Msg("registry: failed to probe eventType")
-		return nil
+	return fanout
+}
+
+func dispatch(topic string, headers Headers, data []byte) (string, *domain.FanoutInput, error) {
+	eventType := headers.Get(HeaderEventType)
+	if eventType == "" {
+		// Extract eventType without full parse
+		var probe struct {
+			EventType string `json:"eventType"`
+		}
+		if err := json.Unmarshal(data, &probe); err != nil {
+			log.Warn().Str("topic", topic).Err(err).Msg("registry: failed to probe eventType")
+			record(topic+":", outcomeMalformed, err)
+			return topic + ":", nil, nil
+		}
+		eventType = probe.EventType
 	}
 
-	key := topic + ":" + probe.EventType
-	h, ok := mu_handlers[key]
+	key, h, ok := lookup(topic, eventType)
 	if !ok {
 		log.Debug().Str("key", key).Msg("registry: no handler registered")
-		return nil
+		record(key, outcomeUnmatched, nil)
+		return key, nil, nil
 	}
-	return h(data)
+	fanout, err := invoke(key, h, data)
+	return key, fanout, err
 }
 
 // DispatchDirect calls the handler registered for a topic without eventType routing.
 // Used for topics like notification-commands where the entire message is the command.
 func DispatchDirect(topic string, data []byte) *domain.FanoutInput {
-	key := topic + ":"
-	h, ok := mu_handlers[key]
+	_, fanout, _, _ := dispatchDirect(topic, data)
+	return fanout
+}
+
+func dispatchDirect(topic string, data []byte) (string, *domain.FanoutInput, bool, error) {
+	key, h, ok := lookup(topic, "")
 	if !ok {
-		return nil
+		return key, nil, false, nil
+	}
+	fanout, err := invoke(key, h, data)
+	return key, fanout, true, err
+}
+
+// invoke validates data against the schema of key, then calls h and records
+// whether it produced a notification.
+func invoke(key string, h EventHandler, data []byte) (*domain.FanoutInput, error) {
+	if err := validate(key, data); err != nil {
+		record(key, outcomeInvalid, err)
+		return nil, err
+	}
+	fanout := h(data)
+	if fanout == nil {
+		record(key, outcomeSkipped, nil)
+	} else {
+		record(key, outcomeParsed, nil)
 	}
-	return h(data)
+	return fanout, nil
 }
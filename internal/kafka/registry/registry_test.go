@@ -2,6 +2,7 @@ package registry_test
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
 
 	"vn.io.arda/notification/internal/domain"
@@ -20,7 +21,7 @@ func TestRegisterAndDispatch(t *testing.T) {
 		return &domain.FanoutInput{Title: "test"}
 	})
 
-	result := registry.Dispatch("test-topic", makeJSON(map[string]string{
+	result := registry.Dispatch("test-topic", nil, makeJSON(map[string]string{
 		"eventType": "TEST_EVENT",
 	}))
 
@@ -33,7 +34,7 @@ func TestRegisterAndDispatch(t *testing.T) {
 }
 
 func TestDispatch_UnknownEvent_ReturnsNil(t *testing.T) {
-	result := registry.Dispatch("test-topic", makeJSON(map[string]string{
+	result := registry.Dispatch("test-topic", nil, makeJSON(map[string]string{
 		"eventType": "UNKNOWN_EVENT_XYZ",
 	}))
 	if result != nil {
@@ -42,7 +43,7 @@ func TestDispatch_UnknownEvent_ReturnsNil(t *testing.T) {
 }
 
 func TestDispatch_InvalidJSON_ReturnsNil(t *testing.T) {
-	result := registry.Dispatch("test-topic", []byte("not json"))
+	result := registry.Dispatch("test-topic", nil, []byte("not json"))
 	if result != nil {
 		t.Fatal("expected nil for invalid JSON")
 	}
@@ -68,3 +69,136 @@ func TestRegister_DuplicatePanics(t *testing.T) {
 	registry.Register("dupe-topic", "DUPE_EVENT", func(_ []byte) *domain.FanoutInput { return nil })
 	registry.Register("dupe-topic", "DUPE_EVENT", func(_ []byte) *domain.FanoutInput { return nil })
 }
+
+func TestHealth_TracksOutcomesAndBudget(t *testing.T) {
+	registry.Register("health-topic", "OK_EVENT", func(data []byte) *domain.FanoutInput {
+		return &domain.FanoutInput{Title: "ok"}
+	})
+	registry.Register("health-topic", "FILTERED_EVENT", func(data []byte) *domain.FanoutInput { return nil })
+
+	key, fanout, _ := registry.Route("health-topic", nil, makeJSON(map[string]string{"eventType": "OK_EVENT"}))
+	if fanout == nil {
+		t.Fatal("expected fan-out input")
+	}
+	registry.RecordFanout(key, errors.New("db down"))
+	registry.Route("health-topic", nil, makeJSON(map[string]string{"eventType": "FILTERED_EVENT"}))
+
+	health := map[string]registry.HandlerHealth{}
+	for _, h := range registry.Health() {
+		health[h.Key] = h
+	}
+	ok := health["health-topic:OK_EVENT"]
+	if ok.Total.Parsed != 1 || ok.Total.Failed != 1 || ok.Status != registry.StatusExhausted || ok.LastError != "db down" {
+		t.Fatalf("unexpected health for OK_EVENT: %+v", ok)
+	}
+	if filtered := health["health-topic:FILTERED_EVENT"]; filtered.Total.Skipped != 1 || filtered.Status != registry.StatusOK {
+		t.Fatalf("unexpected health for FILTERED_EVENT: %+v", filtered)
+	}
+	if idle := health["dupe-topic:DUPE_EVENT"]; !idle.Registered || idle.Status != registry.StatusIdle {
+		t.Fatalf("expected registered idle handler, got %+v", idle)
+	}
+}
+
+func TestRoute_SchemaViolationIsReturned(t *testing.T) {
+	called := false
+	registry.Register("schema-topic", "CHECKED_EVENT", func(data []byte) *domain.FanoutInput {
+		called = true
+		return &domain.FanoutInput{Title: "checked"}
+	})
+	registry.RegisterSchema("schema-topic", "CHECKED_EVENT", `required: userId`)
+	// A stand-in compiler: the payload must contain a userId.
+	if err := registry.SetSchemaCompiler(func(schema []byte) (registry.Validator, error) {
+		return func(data []byte) ([]string, error) {
+			var v struct{ UserID string }
+			_ = json.Unmarshal(data, &v)
+			if v.UserID == "" {
+				return []string{string(schema)}, nil
+			}
+			return nil, nil
+		}, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	defer registry.SetSchemaCompiler(nil)
+
+	_, fanout, err := registry.Route("schema-topic", nil, makeJSON(map[string]string{"eventType": "CHECKED_EVENT"}))
+	var invalid *registry.ValidationError
+	if fanout != nil || called || !errors.Is(err, registry.ErrInvalidPayload) || !errors.As(err, &invalid) {
+		t.Fatalf("expected schema violation, got fanout=%v called=%v err=%v", fanout, called, err)
+	}
+	if len(invalid.Violations) != 1 || invalid.Violations[0] != "required: userId" {
+		t.Fatalf("unexpected violations: %v", invalid.Violations)
+	}
+
+	_, fanout, err = registry.Route("schema-topic", nil, makeJSON(map[string]string{"eventType": "CHECKED_EVENT", "userId": "u1"}))
+	if err != nil || fanout == nil || !called {
+		t.Fatalf("expected valid payload to reach the handler, got fanout=%v err=%v", fanout, err)
+	}
+
+	for _, h := range registry.Health() {
+		if h.Key == "schema-topic:CHECKED_EVENT" && (h.Total.Invalid != 1 || h.Total.Parsed != 1) {
+			t.Fatalf("unexpected health: %+v", h)
+		}
+	}
+}
+
+func TestRoute_HeadersRouteAndFillFanout(t *testing.T) {
+	registry.Register("header-topic", "HEADER_EVENT", func(data []byte) *domain.FanoutInput {
+		return &domain.FanoutInput{Title: "header", Metadata: map[string]any{"traceparent": "from-payload"}}
+	})
+	registry.SetMetadataHeaders([]string{"traceparent", "X-Request-Id"})
+	defer registry.SetMetadataHeaders([]string{registry.HeaderTraceparent})
+
+	headers := registry.Headers{
+		registry.HeaderEventType:   "HEADER_EVENT",
+		registry.HeaderTenantKey:   "acme",
+		registry.HeaderLocale:      "vi",
+		registry.HeaderTraceparent: "00-abc-def-01",
+		"x-request-id":             "req-1",
+	}
+	// The payload is not JSON: routing must not depend on probing it.
+	key, fanout, err := registry.Route("header-topic", headers, []byte("binary"))
+	if err != nil || fanout == nil || key != "header-topic:HEADER_EVENT" {
+		t.Fatalf("expected header routing, got key=%q fanout=%v err=%v", key, fanout, err)
+	}
+	if fanout.TenantKey != "acme" || fanout.Locale != "vi" {
+		t.Fatalf("expected tenant and locale from headers, got %q / %q", fanout.TenantKey, fanout.Locale)
+	}
+	if fanout.Metadata["traceparent"] != "from-payload" || fanout.Metadata["x-request-id"] != "req-1" {
+		t.Fatalf("unexpected metadata: %v", fanout.Metadata)
+	}
+}
+
+func TestRoute_TopicPattern(t *testing.T) {
+	registry.Register("*-pattern-events", "PATTERN_EVENT", func(data []byte) *domain.FanoutInput {
+		return &domain.FanoutInput{Title: "pattern"}
+	})
+	registry.Register("billing-pattern-events", "PATTERN_EVENT", func(data []byte) *domain.FanoutInput {
+		return &domain.FanoutInput{Title: "exact"}
+	})
+	event := makeJSON(map[string]string{"eventType": "PATTERN_EVENT"})
+
+	key, fanout, _ := registry.Route("hr-pattern-events", nil, event)
+	if fanout == nil || fanout.Title != "pattern" || key != "*-pattern-events:PATTERN_EVENT" {
+		t.Fatalf("expected pattern handler, got key=%q fanout=%v", key, fanout)
+	}
+	if _, fanout, _ = registry.Route("billing-pattern-events", nil, event); fanout == nil || fanout.Title != "exact" {
+		t.Fatalf("expected exact topic to take precedence, got %v", fanout)
+	}
+	if _, fanout, _ = registry.Route("hr-pattern-events-v2", nil, event); fanout != nil {
+		t.Fatalf("pattern must be anchored, got %v", fanout)
+	}
+}
+
+func TestTopicRegexp(t *testing.T) {
+	for topic, want := range map[string]string{
+		"bpm-events":  `^bpm-events$`,
+		"*-events":    `^.*-events$`,
+		"crm.v?":      `^crm\.v.$`,
+		"^(a|b)-cmd$": `^(a|b)-cmd$`,
+	} {
+		if got := registry.TopicRegexp(topic); got != want {
+			t.Errorf("TopicRegexp(%q) = %q, want %q", topic, got, want)
+		}
+	}
+}
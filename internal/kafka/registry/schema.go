@@ -0,0 +1,80 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidPayload marks events rejected by their handler's JSON Schema.
+// Such events are dead-lettered right away instead of retried or skipped.
+var ErrInvalidPayload = errors.New("event payload does not match schema")
+
+// ValidationError lists why an event payload failed its handler's schema.
+type ValidationError struct {
+	Key        string
+	Violations []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("registry: %s: %s: %s", e.Key, ErrInvalidPayload, strings.Join(e.Violations, "; "))
+}
+
+func (e *ValidationError) Unwrap() error { return ErrInvalidPayload }
+
+// Validator checks a payload against a compiled schema and returns its violations.
+type Validator func(data []byte) ([]string, error)
+
+// SchemaCompiler compiles a JSON Schema document into a Validator.
+type SchemaCompiler func(schema []byte) (Validator, error)
+
+var (
+	schemas    = map[string]string{}
+	validators = map[string]Validator{}
+)
+
+// RegisterSchema declares the JSON Schema of the events routed to the handler of
+// {topic}:{eventType} (eventType "" for direct topics). Should be called from
+// init() next to Register. Schemas take effect once SetSchemaCompiler is called.
+// Panics on duplicate registration.
+func RegisterSchema(topic, eventType, schema string) {
+	key := topic + ":" + eventType
+	if _, exists := schemas[key]; exists {
+		panic("registry: duplicate schema registered for key: " + key)
+	}
+	schemas[key] = schema
+}
+
+// SetSchemaCompiler compiles every registered schema with compile and enables
+// validation before dispatch. A nil compile disables validation. Must be called
+// before events are routed; returns the first schema that does not compile.
+func SetSchemaCompiler(compile SchemaCompiler) error {
+	compiled := make(map[string]Validator, len(schemas))
+	if compile != nil {
+		for key, schema := range schemas {
+			v, err := compile([]byte(schema))
+			if err != nil {
+				return fmt.Errorf("registry: schema for %s: %w", key, err)
+			}
+			compiled[key] = v
+		}
+	}
+	validators = compiled
+	return nil
+}
+
+// validate checks data against the schema of key, if one is enabled.
+func validate(key string, data []byte) error {
+	v, ok := validators[key]
+	if !ok {
+		return nil
+	}
+	violations, err := v(data)
+	if err != nil {
+		return fmt.Errorf("registry: validate %s: %w", key, err)
+	}
+	if len(violations) > 0 {
+		return &ValidationError{Key: key, Violations: violations}
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package registry
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+type outcome int
+
+const (
+	outcomeParsed    outcome = iota // handler returned a notification
+	outcomeSkipped                  // handler returned nil (filtered or unparseable payload)
+	outcomeUnmatched                // no handler registered for topic:eventType
+	outcomeMalformed                // eventType could not be probed
+	outcomeInvalid                  // payload did not match the handler's schema
+	outcomeFannedOut                // Fanout succeeded
+	outcomeFailed                   // Fanout failed
+)
+
+// Counts are per-handler record outcomes.
+type Counts struct {
+	Parsed    uint64 `json:"parsed"`
+	Skipped   uint64 `json:"skipped"`
+	Unmatched uint64 `json:"unmatched"`
+	Malformed uint64 `json:"malformed"`
+	Invalid   uint64 `json:"invalid"`
+	FannedOut uint64 `json:"fanned_out"`
+	Failed    uint64 `json:"failed"`
+}
+
+func (c *Counts) add(o outcome) {
+	switch o {
+	case outcomeParsed:
+		c.Parsed++
+	case outcomeSkipped:
+		c.Skipped++
+	case outcomeUnmatched:
+		c.Unmatched++
+	case outcomeMalformed:
+		c.Malformed++
+	case outcomeInvalid:
+		c.Invalid++
+	case outcomeFannedOut:
+		c.FannedOut++
+	case outcomeFailed:
+		c.Failed++
+	}
+}
+
+func (c Counts) plus(o Counts) Counts {
+	return Counts{
+		Parsed:    c.Parsed + o.Parsed,
+		Skipped:   c.Skipped + o.Skipped,
+		Unmatched: c.Unmatched + o.Unmatched,
+		Malformed: c.Malformed + o.Malformed,
+		Invalid:   c.Invalid + o.Invalid,
+		FannedOut: c.FannedOut + o.FannedOut,
+		Failed:    c.Failed + o.Failed,
+	}
+}
+
+func (c Counts) records() uint64 {
+	return c.Parsed + c.Skipped + c.Unmatched + c.Malformed + c.Invalid
+}
+
+// errors are records that should have produced notifications but did not.
+func (c Counts) errors() uint64 {
+	return c.Malformed + c.Invalid + c.Failed
+}
+
+// handlerStats tracks one handler key. Recent counts cover the current window plus
+// the previous one, so the error rate always spans at least one full window.
+type handlerStats struct {
+	total       Counts
+	current     Counts
+	previous    Counts
+	windowStart time.Time
+
+	lastSeen      time.Time
+	lastFannedOut time.Time
+	lastFailure   time.Time
+	lastError     string
+}
+
+var (
+	statsMu     sync.Mutex
+	stats       = map[string]*handlerStats{}
+	errorBudget = 0.01
+	statsWindow = time.Hour
+)
+
+// SetErrorBudget sets the tolerated error rate of a handler (failed fan-outs,
+// malformed and schema-invalid records over records seen) and the window it is measured over.
+func SetErrorBudget(budget float64, window time.Duration) {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	if budget >= 0 {
+		errorBudget = budget
+	}
+	if window > 0 {
+		statsWindow = window
+	}
+}
+
+// RecordFanout records the fan-out result of a record routed to key.
+func RecordFanout(key string, err error) {
+	if err != nil {
+		record(key, outcomeFailed, err)
+		return
+	}
+	record(key, outcomeFannedOut, nil)
+}
+
+func record(key string, o outcome, err error) {
+	now := time.Now()
+	statsMu.Lock()
+	defer statsMu.Unlock()
+
+	s, ok := stats[key]
+	if !ok {
+		s = &handlerStats{windowStart: now}
+		stats[key] = s
+	}
+	s.rotate(now)
+	s.total.add(o)
+	s.current.add(o)
+
+	switch o {
+	case outcomeFannedOut:
+		s.lastFannedOut = now
+	case outcomeFailed:
+		s.lastFailure, s.lastError = now, err.Error()
+	case outcomeMalformed, outcomeInvalid:
+		s.lastSeen, s.lastFailure, s.lastError = now, now, err.Error()
+	default:
+		s.lastSeen = now
+	}
+}
+
+// rotate advances the window; a window with no records in between clears both buckets.
+func (s *handlerStats) rotate(now time.Time) {
+	switch elapsed := now.Sub(s.windowStart); {
+	case elapsed < statsWindow:
+	case elapsed < 2*statsWindow:
+		s.previous, s.current = s.current, Counts{}
+		s.windowStart = s.windowStart.Add(statsWindow)
+	default:
+		s.previous, s.current = Counts{}, Counts{}
+		s.windowStart = now
+	}
+}
+
+// Handler health statuses.
+const (
+	StatusOK        = "ok"        // error rate within budget
+	StatusExhausted = "exhausted" // error rate above budget
+	StatusIdle      = "idle"      // no records within the window
+)
+
+// HandlerHealth summarises one topic:eventType handler.
+type HandlerHealth struct {
+	Key        string `json:"key"`
+	Topic      string `json:"topic"`
+	EventType  string `json:"event_type,omitempty"`
+	Registered bool   `json:"registered"`
+	Status     string `json:"status"`
+	// Recent covers the last one to two windows; Total is since startup.
+	Recent        Counts     `json:"recent"`
+	Total         Counts     `json:"total"`
+	ErrorRate     float64    `json:"error_rate"`
+	ErrorBudget   float64    `json:"error_budget"`
+	LastSeen      *time.Time `json:"last_seen,omitempty"`
+	LastFannedOut *time.Time `json:"last_fanned_out,omitempty"`
+	LastFailure   *time.Time `json:"last_failure,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+}
+
+// Health returns a summary for every registered handler and every key records
+// were routed to, sorted by key.
+func Health() []HandlerHealth {
+	now := time.Now()
+	statsMu.Lock()
+	defer statsMu.Unlock()
+
+	keys := make(map[string]struct{}, len(mu_handlers)+len(stats))
+	for key := range mu_handlers {
+		keys[key] = struct{}{}
+	}
+	for key := range stats {
+		keys[key] = struct{}{}
+	}
+
+	out := make([]HandlerHealth, 0, len(keys))
+	for key := range keys {
+		topic, eventType, _ := strings.Cut(key, ":")
+		_, registered := mu_handlers[key]
+		h := HandlerHealth{
+			Key: key, Topic: topic, EventType: eventType,
+			Registered: registered, Status: StatusIdle, ErrorBudget: errorBudget,
+		}
+		if s, ok := stats[key]; ok {
+			s.rotate(now)
+			h.Recent, h.Total = s.current.plus(s.previous), s.total
+			h.LastSeen, h.LastFannedOut, h.LastFailure = timePtr(s.lastSeen), timePtr(s.lastFannedOut), timePtr(s.lastFailure)
+			h.LastError = s.lastError
+		}
+		if n := h.Recent.records(); n > 0 {
+			h.ErrorRate = float64(h.Recent.errors()) / float64(n)
+			h.Status = StatusOK
+			if h.ErrorRate > errorBudget {
+				h.Status = StatusExhausted
+			}
+		}
+		out = append(out, h)
+	}
+	slices.SortFunc(out, func(a, b HandlerHealth) int { return strings.Compare(a.Key, b.Key) })
+	return out
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
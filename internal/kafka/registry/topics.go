@@ -0,0 +1,60 @@
+package registry
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// IsTopicPattern reports whether topic is a pattern rather than a topic name:
+// a glob using * or ? (e.g. "*-events"), or a regular expression starting with ^.
+func IsTopicPattern(topic string) bool {
+	return strings.HasPrefix(topic, "^") || strings.ContainsAny(topic, "*?")
+}
+
+// TopicRegexp returns the anchored regular expression matching topic: globs are
+// translated (* matches any run of characters, ? a single one), regular expressions
+// are returned as is and plain names match only themselves.
+func TopicRegexp(topic string) string {
+	if strings.HasPrefix(topic, "^") {
+		return topic
+	}
+	expr := regexp.QuoteMeta(topic)
+	expr = strings.ReplaceAll(expr, `\*`, `.*`)
+	expr = strings.ReplaceAll(expr, `\?`, `.`)
+	return "^" + expr + "$"
+}
+
+// topicPattern is a handler registered for a topic pattern.
+type topicPattern struct {
+	key       string
+	re        *regexp.Regexp
+	eventType string
+}
+
+// patterns are tried in registration order when no handler is registered for
+// the exact topic.
+var patterns []topicPattern
+
+func registerPattern(topic, eventType, key string) {
+	re, err := regexp.Compile(TopicRegexp(topic))
+	if err != nil {
+		panic(fmt.Sprintf("registry: invalid topic pattern %q: %v", topic, err))
+	}
+	patterns = append(patterns, topicPattern{key: key, re: re, eventType: eventType})
+}
+
+// lookup returns the handler of topic and eventType and the key it was
+// registered under: the exact topic first, then the first matching pattern.
+func lookup(topic, eventType string) (string, EventHandler, bool) {
+	key := topic + ":" + eventType
+	if h, ok := mu_handlers[key]; ok {
+		return key, h, true
+	}
+	for _, p := range patterns {
+		if p.eventType == eventType && p.re.MatchString(topic) {
+			return p.key, mu_handlers[p.key], true
+		}
+	}
+	return key, nil, false
+}
@@ -0,0 +1,303 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"vn.io.arda/notification/internal/application"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// replayIdleTimeout ends a replay whose remaining partitions return no records.
+const replayIdleTimeout = 10 * time.Second
+
+// ReplayConfig selects the records Replay re-consumes. Each bound is either an
+// offset (applied to every partition) or a record timestamp; offsets win.
+type ReplayConfig struct {
+	Brokers    []string
+	Topic      string
+	Partitions []int32 // empty = all partitions
+	// From and To bound record timestamps; zero means the earliest record and the
+	// end of the partition when the replay starts. To is exclusive.
+	From, To time.Time
+	// FromOffset and ToOffset bound offsets; negative means unset. ToOffset is exclusive.
+	FromOffset, ToOffset int64
+	// DryRun routes records without fanning them out.
+	DryRun bool
+	// Force fans out events whose notifications the audit log records as created,
+	// recreating the ones users deleted since.
+	Force bool
+}
+
+// ReplayStats counts the outcome of replayed records.
+type ReplayStats struct {
+	Records   int `json:"records"`
+	Unmatched int `json:"unmatched"` // no handler, or the handler skipped the event
+	NoEventID int `json:"no_event_id"`
+	Delivered int `json:"already_delivered"`
+	FannedOut int `json:"fanned_out"`
+	Failed    int `json:"failed"`
+}
+
+// Replay re-consumes cfg.Topic over the selected range outside the consumer
+// group and runs every record through its handler and Fanout again. Events are
+// idempotent by source_event_id: events without one are skipped, and notifications
+// already stored are not duplicated. Failed records are logged and counted; the
+// replay goes on.
+func Replay(ctx context.Context, cfg ReplayConfig, svc *application.Service, decoder RecordDecoder) (ReplayStats, error) {
+	var stats ReplayStats
+	if cfg.Topic == "" {
+		return stats, errors.New("replay: topic is required")
+	}
+	client, err := kgo.NewClient(kgo.SeedBrokers(cfg.Brokers...))
+	if err != nil {
+		return stats, err
+	}
+	defer client.Close()
+
+	bounds, err := replayBounds(ctx, client, cfg)
+	if err != nil {
+		return stats, err
+	}
+	start := make(map[int32]kgo.Offset, len(bounds))
+	for p, b := range bounds {
+		log.Info().Str("topic", cfg.Topic).Int32("partition", p).Int64("from", b[0]).Int64("to", b[1]).Msg("replaying partition")
+		start[p] = kgo.NewOffset().At(b[0])
+	}
+	if len(start) == 0 {
+		return stats, nil
+	}
+	client.AddConsumePartitions(map[string]map[int32]kgo.Offset{cfg.Topic: start})
+
+	for len(bounds) > 0 {
+		pollCtx, cancel := context.WithTimeout(ctx, replayIdleTimeout)
+		fetches := client.PollFetches(pollCtx)
+		idle := pollCtx.Err() != nil
+		cancel()
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+		if idle {
+			// The rest of the range holds no deliverable records, e.g. transaction
+			// markers or offsets removed by compaction.
+			for p, b := range bounds {
+				log.Warn().Str("topic", cfg.Topic).Int32("partition", p).Int64("to", b[1]).Msg("replay: no more records in range, partition done")
+			}
+			break
+		}
+		var fetchErr error
+		fetches.EachError(func(topic string, partition int32, err error) {
+			fetchErr = fmt.Errorf("replay: fetch %s/%d: %w", topic, partition, err)
+		})
+		if fetchErr != nil {
+			return stats, fetchErr
+		}
+		fetches.EachRecord(func(r *kgo.Record) {
+			b, ok := bounds[r.Partition]
+			if !ok {
+				return
+			}
+			if r.Offset >= b[1] {
+				delete(bounds, r.Partition)
+				return
+			}
+			replayRecord(ctx, cfg, svc, decoder, r, &stats)
+			if r.Offset+1 >= b[1] {
+				delete(bounds, r.Partition)
+			}
+		})
+	}
+	return stats, nil
+}
+
+// replayRecord routes r and fans it out unless it is ineligible, updating stats.
+func replayRecord(ctx context.Context, cfg ReplayConfig, svc *application.Service, decoder RecordDecoder, r *kgo.Record, stats *ReplayStats) {
+	stats.Records++
+	logger := log.With().Str("topic", r.Topic).Int32("partition", r.Partition).Int64("offset", r.Offset).Logger()
+
+	key, fanout, _, err := route(ctx, decoder, r)
+	if err != nil {
+		stats.Failed++
+		logger.Error().Err(err).Msg("replay: record not routed")
+		return
+	}
+	if fanout == nil {
+		stats.Unmatched++
+		return
+	}
+	if fanout.SourceEventID == "" {
+		stats.NoEventID++
+		logger.Warn().Str("handler", key).Msg("replay: event has no source event ID, skipped to avoid duplicates")
+		return
+	}
+	if !cfg.Force && fanout.TenantKey != "" {
+		created, err := svc.EventCreated(ctx, fanout.TenantKey, fanout.SourceEventID)
+		if err != nil {
+			stats.Failed++
+			logger.Error().Err(err).Str("source_event_id", fanout.SourceEventID).Msg("replay: audit lookup failed")
+			return
+		}
+		if created {
+			stats.Delivered++
+			return
+		}
+	}
+	if cfg.DryRun {
+		stats.FannedOut++
+		logger.Info().Str("handler", key).Str("source_event_id", fanout.SourceEventID).Msg("replay (dry run): would fan out")
+		return
+	}
+
+	svc.ApplyEventDefaults(ctx, key, fanout)
+	svc.Trace(ctx, fanout.SourceEventID, domain.TraceHandlerMatched, map[string]any{
+		"topic":     r.Topic,
+		"partition": r.Partition,
+		"offset":    r.Offset,
+		"scope":     fanout.TargetScope,
+		"target_id": fanout.TargetID,
+		"type":      fanout.Type,
+		"replay":    true,
+	})
+	if err := svc.Fanout(application.WithSourceTopic(ctx, r.Topic), *fanout); err != nil {
+		stats.Failed++
+		svc.Trace(ctx, fanout.SourceEventID, domain.TraceFailed, map[string]any{"stage": "fanout", "error": err.Error(), "replay": true})
+		logger.Error().Err(err).Str("source_event_id", fanout.SourceEventID).Msg("replay: fan-out failed")
+		return
+	}
+	stats.FannedOut++
+}
+
+// replayBounds returns the [from, to) offsets to replay per partition, leaving
+// out partitions with nothing in range.
+func replayBounds(ctx context.Context, client *kgo.Client, cfg ReplayConfig) (map[int32][2]int64, error) {
+	partitions, err := topicPartitions(ctx, client, cfg.Topic)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.Partitions) > 0 {
+		for _, p := range cfg.Partitions {
+			if !slices.Contains(partitions, p) {
+				return nil, fmt.Errorf("replay: topic %s has no partition %d", cfg.Topic, p)
+			}
+		}
+		partitions = cfg.Partitions
+	}
+
+	// ListOffsets: -2 is the earliest offset, -1 the end of the partition.
+	millis := func(t time.Time, unset int64) int64 {
+		if t.IsZero() {
+			return unset
+		}
+		return t.UnixMilli()
+	}
+	earliest, err := listOffsets(ctx, client, cfg.Topic, partitions, -2)
+	if err != nil {
+		return nil, err
+	}
+	end, err := listOffsets(ctx, client, cfg.Topic, partitions, -1)
+	if err != nil {
+		return nil, err
+	}
+	from, err := listOffsets(ctx, client, cfg.Topic, partitions, millis(cfg.From, -2))
+	if err != nil {
+		return nil, err
+	}
+	to, err := listOffsets(ctx, client, cfg.Topic, partitions, millis(cfg.To, -1))
+	if err != nil {
+		return nil, err
+	}
+	return clampBounds(cfg, partitions, earliest, end, from, to), nil
+}
+
+// clampBounds combines the listed offsets of each partition with the offset
+// bounds of cfg into the [from, to) range to replay, clamped to the records the
+// partition still holds.
+func clampBounds(cfg ReplayConfig, partitions []int32, earliest, end, from, to map[int32]int64) map[int32][2]int64 {
+	bounds := make(map[int32][2]int64, len(partitions))
+	for _, p := range partitions {
+		lo, hi := from[p], to[p]
+		if cfg.FromOffset >= 0 {
+			lo = cfg.FromOffset
+		}
+		if cfg.ToOffset >= 0 {
+			hi = cfg.ToOffset
+		}
+		// A timestamp past the last record has no offset (-1): read to the end.
+		if lo < 0 {
+			lo = end[p]
+		}
+		if hi < 0 {
+			hi = end[p]
+		}
+		lo, hi = max(lo, earliest[p]), min(hi, end[p])
+		if lo < hi {
+			bounds[p] = [2]int64{lo, hi}
+		}
+	}
+	return bounds
+}
+
+// topicPartitions returns the partition IDs of topic.
+func topicPartitions(ctx context.Context, client *kgo.Client, topic string) ([]int32, error) {
+	req := kmsg.NewPtrMetadataRequest()
+	t := kmsg.NewMetadataRequestTopic()
+	t.Topic = kmsg.StringPtr(topic)
+	req.Topics = append(req.Topics, t)
+	resp, err := req.RequestWith(ctx, client)
+	if err != nil {
+		return nil, fmt.Errorf("replay: metadata of %s: %w", topic, err)
+	}
+	if len(resp.Topics) != 1 {
+		return nil, fmt.Errorf("replay: metadata of %s: topic missing from response", topic)
+	}
+	if err := kerr.ErrorForCode(resp.Topics[0].ErrorCode); err != nil {
+		return nil, fmt.Errorf("replay: metadata of %s: %w", topic, err)
+	}
+	partitions := make([]int32, 0, len(resp.Topics[0].Partitions))
+	for _, p := range resp.Topics[0].Partitions {
+		partitions = append(partitions, p.Partition)
+	}
+	slices.Sort(partitions)
+	return partitions, nil
+}
+
+// listOffsets returns, per partition, the first offset whose record timestamp is
+// at or after timestamp (-1 when there is none), or the earliest (-2) / end (-1)
+// offset.
+func listOffsets(ctx context.Context, client *kgo.Client, topic string, partitions []int32, timestamp int64) (map[int32]int64, error) {
+	req := kmsg.NewPtrListOffsetsRequest()
+	req.ReplicaID = -1
+	t := kmsg.NewListOffsetsRequestTopic()
+	t.Topic = topic
+	for _, p := range partitions {
+		rp := kmsg.NewListOffsetsRequestTopicPartition()
+		rp.Partition = p
+		rp.CurrentLeaderEpoch = -1
+		rp.Timestamp = timestamp
+		t.Partitions = append(t.Partitions, rp)
+	}
+	req.Topics = append(req.Topics, t)
+
+	offsets := make(map[int32]int64, len(partitions))
+	for _, shard := range client.RequestSharded(ctx, req) {
+		if shard.Err != nil {
+			return nil, fmt.Errorf("replay: list offsets of %s: %w", topic, shard.Err)
+		}
+		for _, rt := range shard.Resp.(*kmsg.ListOffsetsResponse).Topics {
+			for _, rp := range rt.Partitions {
+				if err := kerr.ErrorForCode(rp.ErrorCode); err != nil {
+					return nil, fmt.Errorf("replay: list offsets of %s/%d: %w", topic, rp.Partition, err)
+				}
+				offsets[rp.Partition] = rp.Offset
+			}
+		}
+	}
+	return offsets, nil
+}
@@ -0,0 +1,81 @@
+package kafka
+
+import (
+	"context"
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+	"vn.io.arda/notification/internal/application"
+	"vn.io.arda/notification/internal/domain"
+	"vn.io.arda/notification/internal/testsupport"
+)
+
+// createdEvents is an audit log recording which source events created notifications.
+type createdEvents map[string]bool // tenantKey/sourceEventID
+
+func (createdEvents) Record(context.Context, []domain.AuditEntry) error { return nil }
+
+func (e createdEvents) List(_ context.Context, f domain.AuditFilter) ([]domain.AuditEntry, error) {
+	if f.Action == domain.AuditCreated && e[f.TenantKey+"/"+f.SourceEventID] {
+		return []domain.AuditEntry{{TenantKey: f.TenantKey, SourceEventID: f.SourceEventID, Action: domain.AuditCreated}}, nil
+	}
+	return nil, nil
+}
+
+func (createdEvents) PurgeBefore(context.Context, time.Time) (int64, error) { return 0, nil }
+
+func TestReplayRecord(t *testing.T) {
+	ctx := context.Background()
+	repo := testsupport.NewRepository()
+	svc := application.NewService(repo, testsupport.NewHub(), testsupport.NewResolver().AddUsers("acme", "u1"),
+		application.WithAudit(createdEvents{"acme/cmd-0-1": true}))
+	replay := func(cfg ReplayConfig, records ...*kgo.Record) ReplayStats {
+		var stats ReplayStats
+		for _, r := range records {
+			replayRecord(ctx, cfg, svc, nil, r, &stats)
+		}
+		return stats
+	}
+	unmatched := &kgo.Record{Topic: "notification-commands", Value: []byte(`{`)}
+	noEventID := &kgo.Record{Topic: "notification-commands",
+		Value: []byte(`{"tenantKey":"acme","targetScope":"USER","targetId":"u1","title":"t"}`)}
+
+	stats := replay(ReplayConfig{DryRun: true}, command(0, 0, "u1"), command(0, 1, "u1"), unmatched, noEventID)
+	want := ReplayStats{Records: 4, FannedOut: 1, Delivered: 1, Unmatched: 1, NoEventID: 1}
+	if stats != want {
+		t.Fatalf("dry run = %+v, want %+v", stats, want)
+	}
+	if n := len(repo.Notifications()); n != 0 {
+		t.Fatalf("dry run stored %d notifications", n)
+	}
+
+	// Force fans out the event the audit log records as created.
+	stats = replay(ReplayConfig{Force: true}, command(0, 0, "u1"), command(0, 1, "u1"))
+	if stats.FannedOut != 2 || len(repo.Notifications()) != 2 {
+		t.Fatalf("forced replay = %+v with %d notifications, want both fanned out", stats, len(repo.Notifications()))
+	}
+}
+
+func TestClampBounds(t *testing.T) {
+	partitions := []int32{0, 1, 2}
+	earliest := map[int32]int64{0: 10, 1: 0, 2: 5}
+	end := map[int32]int64{0: 100, 1: 50, 2: 5}
+	// Timestamp lookups: partition 1 has no record at or after From.
+	from := map[int32]int64{0: 20, 1: -1, 2: 5}
+	to := map[int32]int64{0: -1, 1: 40, 2: 5}
+
+	got := clampBounds(ReplayConfig{FromOffset: -1, ToOffset: -1}, partitions, earliest, end, from, to)
+	want := map[int32][2]int64{0: {20, 100}}
+	if !maps.Equal(got, want) {
+		t.Fatalf("by timestamp = %v, want %v", got, want)
+	}
+
+	// Offsets win over timestamps and are clamped to what the partition holds.
+	got = clampBounds(ReplayConfig{FromOffset: 0, ToOffset: 60}, partitions, earliest, end, from, to)
+	want = map[int32][2]int64{0: {10, 60}, 1: {0, 50}}
+	if !maps.Equal(got, want) {
+		t.Fatalf("by offset = %v, want %v", got, want)
+	}
+}
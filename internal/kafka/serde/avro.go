@@ -0,0 +1,302 @@
+package serde
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// avroSchema is a parsed Avro schema node. Named types (record, enum, fixed) are
+// shared by pointer, so recursive records refer back to themselves.
+type avroSchema struct {
+	kind     string // primitive name, "record", "enum", "array", "map", "union" or "fixed"
+	fields   []avroField
+	symbols  []string
+	items    *avroSchema // array items and map values
+	branches []*avroSchema
+	size     int
+}
+
+type avroField struct {
+	name   string
+	schema *avroSchema
+}
+
+var avroPrimitives = map[string]bool{
+	"null": true, "boolean": true, "int": true, "long": true,
+	"float": true, "double": true, "bytes": true, "string": true,
+}
+
+// parseAvroSchema parses an Avro schema in its JSON form.
+func parseAvroSchema(text []byte) (*avroSchema, error) {
+	var doc any
+	if err := json.Unmarshal(text, &doc); err != nil {
+		return nil, err
+	}
+	p := avroParser{named: make(map[string]*avroSchema)}
+	return p.parse(doc, "")
+}
+
+type avroParser struct {
+	named map[string]*avroSchema
+}
+
+func (p avroParser) parse(doc any, namespace string) (*avroSchema, error) {
+	switch v := doc.(type) {
+	case string:
+		if avroPrimitives[v] {
+			return &avroSchema{kind: v}, nil
+		}
+		if s, ok := p.named[fullName(v, namespace)]; ok {
+			return s, nil
+		}
+		if s, ok := p.named[v]; ok {
+			return s, nil
+		}
+		return nil, fmt.Errorf("unknown avro type %q", v)
+	case []any:
+		u := &avroSchema{kind: "union"}
+		for _, b := range v {
+			s, err := p.parse(b, namespace)
+			if err != nil {
+				return nil, err
+			}
+			u.branches = append(u.branches, s)
+		}
+		return u, nil
+	case map[string]any:
+		return p.parseComplex(v, namespace)
+	}
+	return nil, fmt.Errorf("invalid avro schema node %v", doc)
+}
+
+func (p avroParser) parseComplex(v map[string]any, namespace string) (*avroSchema, error) {
+	kind, _ := v["type"].(string)
+	if ns, ok := v["namespace"].(string); ok {
+		namespace = ns
+	}
+	switch kind {
+	case "record", "error", "enum", "fixed":
+		name, _ := v["name"].(string)
+		if name == "" {
+			return nil, fmt.Errorf("avro %s without name", kind)
+		}
+		full := fullName(name, namespace)
+		if i := strings.LastIndex(full, "."); i >= 0 {
+			namespace = full[:i]
+		}
+		s := &avroSchema{kind: kind}
+		if kind == "error" {
+			s.kind = "record"
+		}
+		p.named[full] = s
+		switch s.kind {
+		case "record":
+			fields, _ := v["fields"].([]any)
+			for _, f := range fields {
+				fm, _ := f.(map[string]any)
+				fname, _ := fm["name"].(string)
+				fs, err := p.parse(fm["type"], namespace)
+				if err != nil {
+					return nil, fmt.Errorf("field %s.%s: %w", name, fname, err)
+				}
+				s.fields = append(s.fields, avroField{name: fname, schema: fs})
+			}
+		case "enum":
+			symbols, _ := v["symbols"].([]any)
+			for _, sym := range symbols {
+				str, _ := sym.(string)
+				s.symbols = append(s.symbols, str)
+			}
+		case "fixed":
+			size, _ := v["size"].(float64)
+			s.size = int(size)
+		}
+		return s, nil
+	case "array", "map":
+		key := "items"
+		if kind == "map" {
+			key = "values"
+		}
+		items, err := p.parse(v[key], namespace)
+		if err != nil {
+			return nil, err
+		}
+		return &avroSchema{kind: kind, items: items}, nil
+	default:
+		// {"type": "long", "logicalType": ...} and similar annotated primitives.
+		return p.parse(v["type"], namespace)
+	}
+}
+
+func fullName(name, namespace string) string {
+	if strings.Contains(name, ".") || namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
+
+// decodeAvro decodes Avro binary data written with schema to JSON. Unions decode
+// to the bare branch value, bytes and fixed to base64, enums to their symbol;
+// logical types keep their underlying representation.
+func decodeAvro(schema *avroSchema, data []byte) ([]byte, error) {
+	r := &avroReader{buf: data}
+	v, err := r.read(schema)
+	if err != nil {
+		return nil, fmt.Errorf("%w: avro: %v", ErrMalformed, err)
+	}
+	return json.Marshal(v)
+}
+
+type avroReader struct {
+	buf []byte
+}
+
+func (r *avroReader) long() (int64, error) {
+	v, n := binary.Varint(r.buf)
+	if n <= 0 {
+		return 0, fmt.Errorf("invalid varint")
+	}
+	r.buf = r.buf[n:]
+	return v, nil
+}
+
+func (r *avroReader) take(n int64) ([]byte, error) {
+	if n < 0 || n > int64(len(r.buf)) {
+		return nil, fmt.Errorf("unexpected end of data")
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b, nil
+}
+
+func (r *avroReader) read(s *avroSchema) (any, error) {
+	switch s.kind {
+	case "null":
+		return nil, nil
+	case "boolean":
+		b, err := r.take(1)
+		if err != nil {
+			return nil, err
+		}
+		return b[0] != 0, nil
+	case "int", "long":
+		return r.long()
+	case "float":
+		b, err := r.take(4)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float32frombits(binary.LittleEndian.Uint32(b)), nil
+	case "double":
+		b, err := r.take(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b)), nil
+	case "bytes", "string":
+		n, err := r.long()
+		if err != nil {
+			return nil, err
+		}
+		b, err := r.take(n)
+		if err != nil {
+			return nil, err
+		}
+		if s.kind == "string" {
+			return string(b), nil
+		}
+		return append([]byte(nil), b...), nil
+	case "fixed":
+		b, err := r.take(int64(s.size))
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), b...), nil
+	case "enum":
+		i, err := r.long()
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 || i >= int64(len(s.symbols)) {
+			return nil, fmt.Errorf("enum index %d out of range", i)
+		}
+		return s.symbols[i], nil
+	case "union":
+		i, err := r.long()
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 || i >= int64(len(s.branches)) {
+			return nil, fmt.Errorf("union index %d out of range", i)
+		}
+		return r.read(s.branches[i])
+	case "record":
+		obj := make(map[string]any, len(s.fields))
+		for _, f := range s.fields {
+			v, err := r.read(f.schema)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", f.name, err)
+			}
+			obj[f.name] = v
+		}
+		return obj, nil
+	case "array":
+		items := []any{}
+		err := r.blocks(s.items.kind == "null", func() error {
+			v, err := r.read(s.items)
+			items = append(items, v)
+			return err
+		})
+		return items, err
+	case "map":
+		m := map[string]any{}
+		err := r.blocks(false, func() error {
+			n, err := r.long()
+			if err != nil {
+				return err
+			}
+			k, err := r.take(n)
+			if err != nil {
+				return err
+			}
+			v, err := r.read(s.items)
+			m[string(k)] = v
+			return err
+		})
+		return m, err
+	}
+	return nil, fmt.Errorf("unsupported avro type %q", s.kind)
+}
+
+// blocks reads the blocks of an array or map, calling item for each entry.
+// Unless entries are zeroSized (arrays of null), each takes at least one byte,
+// which bounds the count of a corrupt block.
+func (r *avroReader) blocks(zeroSized bool, item func() error) error {
+	for {
+		count, err := r.long()
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return nil
+		}
+		if count < 0 {
+			// A negative count is followed by the block size in bytes.
+			count = -count
+			if _, err := r.long(); err != nil {
+				return err
+			}
+		}
+		if count > int64(len(r.buf)) && !zeroSized {
+			return fmt.Errorf("block count %d exceeds remaining data", count)
+		}
+		for ; count > 0; count-- {
+			if err := item(); err != nil {
+				return err
+			}
+		}
+	}
+}
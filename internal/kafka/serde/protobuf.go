@@ -0,0 +1,90 @@
+package serde
+
+import (
+	"fmt"
+	"os"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// protoFiles holds the message types of a descriptor set.
+type protoFiles struct {
+	files *protoregistry.Files
+}
+
+// loadDescriptorSet reads a binary FileDescriptorSet, which must include imports.
+func loadDescriptorSet(path string) (*protoFiles, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read descriptor set: %w", err)
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(b, &set); err != nil {
+		return nil, fmt.Errorf("parse descriptor set %s: %w", path, err)
+	}
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, fmt.Errorf("load descriptor set %s: %w", path, err)
+	}
+	return &protoFiles{files: files}, nil
+}
+
+func (f *protoFiles) message(name string) (*protoType, error) {
+	d, err := f.files.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil, fmt.Errorf("protobuf message %s: %w", name, err)
+	}
+	md, ok := d.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("protobuf %s is not a message", name)
+	}
+	return &protoType{typ: dynamicpb.NewMessageType(md)}, nil
+}
+
+// protoType decodes one message type to JSON with lowerCamelCase field names,
+// matching the JSON envelopes of the other producers.
+type protoType struct {
+	typ protoreflect.MessageType
+}
+
+func (t *protoType) decode(payload []byte) ([]byte, error) {
+	m := t.typ.New().Interface()
+	if err := proto.Unmarshal(payload, m); err != nil {
+		return nil, fmt.Errorf("%w: protobuf: %v", ErrMalformed, err)
+	}
+	b, err := protojson.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("%w: protobuf: %v", ErrMalformed, err)
+	}
+	return b, nil
+}
+
+// skipMessageIndexes drops the message index list the Schema Registry serializer
+// writes after the header: a zigzag varint count followed by that many indexes,
+// or a single 0 for the first message. The message type is taken from the topic
+// configuration instead.
+func skipMessageIndexes(b []byte) ([]byte, bool) {
+	count, n := protowire.ConsumeVarint(b)
+	if n < 0 {
+		return nil, false
+	}
+	b = b[n:]
+	indexes := protowire.DecodeZigZag(count)
+	if indexes < 0 {
+		return nil, false
+	}
+	for i := indexes; i > 0; i-- {
+		if _, n = protowire.ConsumeVarint(b); n < 0 {
+			return nil, false
+		}
+		b = b[n:]
+	}
+	return b, true
+}
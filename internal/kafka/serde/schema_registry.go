@@ -0,0 +1,93 @@
+package serde
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SchemaRegistry is a minimal Confluent Schema Registry client. Schemas are
+// immutable per ID, so every fetched schema is cached for the process lifetime.
+type SchemaRegistry struct {
+	url                string
+	username, password string
+	client             *http.Client
+
+	mu    sync.Mutex
+	avros map[uint32]*avroSchema
+}
+
+// NewSchemaRegistry creates a client for the registry at url. username and
+// password enable basic auth when set.
+func NewSchemaRegistry(url, username, password string, timeout time.Duration) *SchemaRegistry {
+	return &SchemaRegistry{
+		url:      strings.TrimRight(url, "/"),
+		username: username,
+		password: password,
+		client:   &http.Client{Timeout: timeout},
+		avros:    make(map[uint32]*avroSchema),
+	}
+}
+
+// avroSchema returns the parsed Avro schema registered under id.
+func (r *SchemaRegistry) avroSchema(ctx context.Context, id uint32) (*avroSchema, error) {
+	r.mu.Lock()
+	s, ok := r.avros[id]
+	r.mu.Unlock()
+	if ok {
+		return s, nil
+	}
+
+	schemaType, text, err := r.fetch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if schemaType != "" && schemaType != "AVRO" {
+		return nil, fmt.Errorf("%w: schema %d is %s, not AVRO", ErrMalformed, id, schemaType)
+	}
+	if s, err = parseAvroSchema([]byte(text)); err != nil {
+		return nil, fmt.Errorf("parse avro schema %d: %w", id, err)
+	}
+	r.mu.Lock()
+	r.avros[id] = s
+	r.mu.Unlock()
+	return s, nil
+}
+
+// fetch reads GET /schemas/ids/{id}. schemaType is empty for Avro, the registry default.
+func (r *SchemaRegistry) fetch(ctx context.Context, id uint32) (schemaType, schema string, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/schemas/ids/%d", r.url, id), nil)
+	if err != nil {
+		return "", "", fmt.Errorf("build schema registry request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json")
+	if r.username != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", "", fmt.Errorf("fetch schema %d: %w", id, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", "", fmt.Errorf("%w: schema %d not found in registry", ErrMalformed, id)
+	case resp.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", "", fmt.Errorf("fetch schema %d: status %d: %s", id, resp.StatusCode, body)
+	}
+	var out struct {
+		Schema     string `json:"schema"`
+		SchemaType string `json:"schemaType"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", "", fmt.Errorf("decode schema %d: %w", id, err)
+	}
+	return out.SchemaType, out.Schema, nil
+}
@@ -0,0 +1,162 @@
+// Package serde decodes Kafka record values from their wire format (JSON, Avro or
+// Protobuf) to the JSON documents event handlers parse, so handlers do not depend
+// on how a producer serializes its events.
+package serde
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Format is the wire format of a topic's record values.
+type Format string
+
+const (
+	// FormatJSON is plain JSON, optionally framed by the Schema Registry header.
+	FormatJSON Format = "json"
+	// FormatAvro is Avro binary framed by the Schema Registry header; the writer
+	// schema is fetched from the registry by ID.
+	FormatAvro Format = "avro"
+	// FormatProtobuf is a Protobuf message, framed by the Schema Registry header or
+	// bare. The message type comes from the configured descriptor set.
+	FormatProtobuf Format = "protobuf"
+)
+
+// ErrMalformed marks record values that cannot be decoded in their topic's format.
+// Decoding them again fails the same way, so they are dead-lettered without retry.
+var ErrMalformed = errors.New("malformed record value")
+
+// TopicFormat is the configured wire format of one topic.
+type TopicFormat struct {
+	Format Format
+	// Message is the Protobuf message full name (FormatProtobuf only).
+	Message string
+}
+
+// ParseFormats parses per-topic formats written as
+// "topic=format[,...]", e.g. "bpm-events=avro,crm-events=protobuf:arda.crm.v1.CrmEvent".
+// Topics not listed are JSON.
+func ParseFormats(s string) (map[string]TopicFormat, error) {
+	formats := make(map[string]TopicFormat)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		topic, spec, ok := strings.Cut(entry, "=")
+		topic = strings.TrimSpace(topic)
+		if !ok || topic == "" {
+			return nil, fmt.Errorf("topic format %q: want topic=json|avro|protobuf:Message", entry)
+		}
+		name, message, _ := strings.Cut(strings.TrimSpace(spec), ":")
+		tf := TopicFormat{Format: Format(name), Message: message}
+		switch tf.Format {
+		case FormatJSON, FormatAvro:
+			if message != "" {
+				return nil, fmt.Errorf("topic format %q: only protobuf takes a message type", entry)
+			}
+		case FormatProtobuf:
+			if message == "" {
+				return nil, fmt.Errorf("topic format %q: protobuf needs a message type, e.g. protobuf:pkg.Message", entry)
+			}
+		default:
+			return nil, fmt.Errorf("topic format %q: unknown format %q", entry, name)
+		}
+		formats[topic] = tf
+	}
+	return formats, nil
+}
+
+// Config configures a Decoder.
+type Config struct {
+	// Formats is the per-topic format list accepted by ParseFormats.
+	Formats string
+	// Registry resolves Avro writer schemas. Required when a topic is Avro.
+	Registry *SchemaRegistry
+	// DescriptorSet is the path of a FileDescriptorSet (protoc --include_imports
+	// --descriptor_set_out) holding the Protobuf message types. Required when a topic is Protobuf.
+	DescriptorSet string
+}
+
+// Decoder converts record values of each topic to JSON.
+type Decoder struct {
+	formats  map[string]TopicFormat
+	registry *SchemaRegistry
+	protos   map[string]*protoType
+}
+
+// New checks cfg and loads the Protobuf message types it names.
+func New(cfg Config) (*Decoder, error) {
+	formats, err := ParseFormats(cfg.Formats)
+	if err != nil {
+		return nil, err
+	}
+	d := &Decoder{formats: formats, registry: cfg.Registry, protos: make(map[string]*protoType)}
+
+	var files *protoFiles
+	for topic, tf := range formats {
+		switch tf.Format {
+		case FormatAvro:
+			if cfg.Registry == nil {
+				return nil, fmt.Errorf("topic %s is avro but no schema registry is configured", topic)
+			}
+		case FormatProtobuf:
+			if files == nil {
+				if cfg.DescriptorSet == "" {
+					return nil, fmt.Errorf("topic %s is protobuf but no descriptor set is configured", topic)
+				}
+				if files, err = loadDescriptorSet(cfg.DescriptorSet); err != nil {
+					return nil, err
+				}
+			}
+			pt, err := files.message(tf.Message)
+			if err != nil {
+				return nil, fmt.Errorf("topic %s: %w", topic, err)
+			}
+			d.protos[topic] = pt
+		}
+	}
+	return d, nil
+}
+
+// Decode returns the JSON document carried by a record value of topic.
+func (d *Decoder) Decode(ctx context.Context, topic string, value []byte) ([]byte, error) {
+	switch d.formats[topic].Format {
+	case FormatAvro:
+		id, payload, ok := splitFrame(value)
+		if !ok {
+			return nil, fmt.Errorf("%w: avro value without schema registry header", ErrMalformed)
+		}
+		schema, err := d.registry.avroSchema(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return decodeAvro(schema, payload)
+	case FormatProtobuf:
+		payload := value
+		if _, framed, ok := splitFrame(value); ok {
+			// A bare message never starts with 0: field number 0 is invalid.
+			if payload, ok = skipMessageIndexes(framed); !ok {
+				return nil, fmt.Errorf("%w: invalid protobuf message indexes", ErrMalformed)
+			}
+		}
+		return d.protos[topic].decode(payload)
+	default:
+		if _, payload, ok := splitFrame(value); ok {
+			return payload, nil
+		}
+		return value, nil
+	}
+}
+
+// splitFrame splits the Schema Registry wire header (magic byte 0, big-endian
+// schema ID) from value.
+func splitFrame(value []byte) (id uint32, payload []byte, ok bool) {
+	if len(value) < 5 || value[0] != 0 {
+		return 0, nil, false
+	}
+	return binary.BigEndian.Uint32(value[1:5]), value[5:], true
+}
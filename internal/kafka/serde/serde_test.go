@@ -0,0 +1,197 @@
+package serde
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestParseFormats(t *testing.T) {
+	got, err := ParseFormats(" bpm-events=avro, crm-events=protobuf:arda.crm.CrmEvent ,iam-events=json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]TopicFormat{
+		"bpm-events": {Format: FormatAvro},
+		"crm-events": {Format: FormatProtobuf, Message: "arda.crm.CrmEvent"},
+		"iam-events": {Format: FormatJSON},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for topic, tf := range want {
+		if got[topic] != tf {
+			t.Errorf("%s: got %+v, want %+v", topic, got[topic], tf)
+		}
+	}
+	for _, bad := range []string{"bpm-events", "bpm-events=xml", "crm-events=protobuf", "bpm-events=avro:X"} {
+		if _, err := ParseFormats(bad); err == nil {
+			t.Errorf("%q: expected error", bad)
+		}
+	}
+}
+
+func frame(id uint32, payload []byte) []byte {
+	return append(binary.BigEndian.AppendUint32([]byte{0}, id), payload...)
+}
+
+func avroString(b []byte, s string) []byte {
+	return append(binary.AppendVarint(b, int64(len(s))), s...)
+}
+
+const testAvroSchema = `{
+	"type": "record", "name": "BpmEvent", "namespace": "arda.bpm",
+	"fields": [
+		{"name": "eventType", "type": "string"},
+		{"name": "eventId", "type": ["null", "string"]},
+		{"name": "payload", "type": {"type": "record", "name": "Payload", "fields": [
+			{"name": "assigneeId", "type": "string"},
+			{"name": "priority", "type": {"type": "enum", "name": "Priority", "symbols": ["LOW", "HIGH"]}},
+			{"name": "attempt", "type": "int"}
+		]}},
+		{"name": "tags", "type": {"type": "array", "items": "string"}},
+		{"name": "previous", "type": ["null", "Payload"]}
+	]
+}`
+
+func TestDecodeAvro(t *testing.T) {
+	fetches := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/schemas/ids/7" {
+			http.NotFound(w, r)
+			return
+		}
+		fetches++
+		json.NewEncoder(w).Encode(map[string]string{"schema": testAvroSchema})
+	}))
+	defer srv.Close()
+
+	d, err := New(Config{Formats: "bpm-events=avro", Registry: NewSchemaRegistry(srv.URL, "", "", time.Second)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b []byte
+	b = avroString(b, "TASK_ASSIGNED")
+	b = binary.AppendVarint(b, 1) // eventId: string branch
+	b = avroString(b, "ev-1")
+	b = avroString(b, "u1")       // payload.assigneeId
+	b = binary.AppendVarint(b, 1) // payload.priority: HIGH
+	b = binary.AppendVarint(b, -3)
+	b = binary.AppendVarint(b, 2) // tags: one block of two
+	b = avroString(b, "a")
+	b = avroString(b, "b")
+	b = binary.AppendVarint(b, 0)
+	b = binary.AppendVarint(b, 0) // previous: null
+
+	for i := 0; i < 2; i++ {
+		got, err := d.Decode(context.Background(), "bpm-events", frame(7, b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := `{"eventId":"ev-1","eventType":"TASK_ASSIGNED","payload":{"assigneeId":"u1","attempt":-3,"priority":"HIGH"},"previous":null,"tags":["a","b"]}`
+		if string(got) != want {
+			t.Fatalf("got %s\nwant %s", got, want)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("schema fetched %d times, want 1 (cached)", fetches)
+	}
+
+	if _, err := d.Decode(context.Background(), "bpm-events", frame(7, b[:5])); !errors.Is(err, ErrMalformed) {
+		t.Errorf("truncated value: got %v, want ErrMalformed", err)
+	}
+	if _, err := d.Decode(context.Background(), "bpm-events", []byte(`{"eventType":"X"}`)); !errors.Is(err, ErrMalformed) {
+		t.Errorf("unframed value: got %v, want ErrMalformed", err)
+	}
+	if _, err := d.Decode(context.Background(), "bpm-events", frame(8, b)); !errors.Is(err, ErrMalformed) {
+		t.Errorf("unknown schema: got %v, want ErrMalformed", err)
+	}
+}
+
+func TestDecodeProtobuf(t *testing.T) {
+	str := descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()
+	opt := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+	file := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("crm.proto"),
+		Package: proto.String("arda.crm"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("CrmEvent"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{Name: proto.String("event_type"), JsonName: proto.String("eventType"), Number: proto.Int32(1), Type: str, Label: opt},
+				{Name: proto.String("tenant_key"), JsonName: proto.String("tenantKey"), Number: proto.Int32(2), Type: str, Label: opt},
+			},
+		}},
+	}
+	set, err := proto.Marshal(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{file}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "events.pb")
+	if err := os.WriteFile(path, set, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := New(Config{Formats: "crm-events=protobuf:arda.crm.CrmEvent", DescriptorSet: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fd, err := protodesc.NewFile(file, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := dynamicpb.NewMessage(fd.Messages().Get(0))
+	msg.Set(fd.Messages().Get(0).Fields().ByNumber(1), protoreflect.ValueOfString("DEAL_UPDATED"))
+	msg.Set(fd.Messages().Get(0).Fields().ByNumber(2), protoreflect.ValueOfString("acme"))
+	payload, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, value := range map[string][]byte{
+		"bare":   payload,
+		"framed": frame(3, append([]byte{0}, payload...)),
+	} {
+		got, err := d.Decode(context.Background(), "crm-events", value)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		var doc map[string]string
+		if err := json.Unmarshal(got, &doc); err != nil || doc["eventType"] != "DEAL_UPDATED" || doc["tenantKey"] != "acme" {
+			t.Errorf("%s: got %s (%v)", name, got, err)
+		}
+	}
+
+	if _, err := New(Config{Formats: "crm-events=protobuf:arda.crm.Missing", DescriptorSet: path}); err == nil {
+		t.Error("expected error for unknown message type")
+	}
+}
+
+func TestDecodeJSONPassThrough(t *testing.T) {
+	d, err := New(Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := []byte(`{"eventType":"TENANT_CREATED"}`)
+	for _, value := range [][]byte{doc, frame(1, doc)} {
+		got, err := d.Decode(context.Background(), "tenant-events", value)
+		if err != nil || string(got) != string(doc) {
+			t.Errorf("got %s, %v", got, err)
+		}
+	}
+}
@@ -53,3 +53,58 @@ func LoginNewDevice(ip string) (string, string) {
 func PasswordChanged() (string, string) {
 	return PasswordChangedTitle, PasswordChangedBody
 }
+
+// ─── System builders ─────────────────────────────────────────────────────────
+
+func Compacted(count int, from, to string) (string, string) {
+	return fmt.Sprintf(CompactedTitle, count), fmt.Sprintf(CompactedBody, count, from, to)
+}
+
+func TestNotification() (string, string) {
+	return TestNotificationTitle, TestNotificationBody
+}
+
+// ─── Template keys ───────────────────────────────────────────────────────────
+
+// Template keys of the built-in messages. A stored template with the same key
+// overrides the text; Render is the fallback when none exists.
+const (
+	KeyTenantCreated       = "tenant.created"
+	KeyTenantUpdated       = "tenant.updated"
+	KeyTenantStatusUpdated = "tenant.status_updated"
+	KeyTenantDeleted       = "tenant.deleted"
+	KeyTaskAssigned        = "bpm.task_assigned"
+	KeyTaskCompleted       = "bpm.task_completed"
+	KeyApprovalRequired    = "bpm.approval_required"
+	KeyLeadStatusChanged   = "crm.lead_status_changed"
+	KeyDealUpdated         = "crm.deal_updated"
+	KeyLoginNewDevice      = "iam.login_new_device"
+	KeyPasswordChanged     = "iam.password_changed"
+	KeyTestNotification    = "system.test"
+)
+
+var builders = map[string]func(p map[string]string) (string, string){
+	KeyTenantCreated:       func(p map[string]string) (string, string) { return TenantCreated(p["displayName"]) },
+	KeyTenantUpdated:       func(p map[string]string) (string, string) { return TenantUpdated(p["displayName"]) },
+	KeyTenantStatusUpdated: func(p map[string]string) (string, string) { return TenantStatusUpdated(p["tenantKey"], p["status"]) },
+	KeyTenantDeleted:       func(p map[string]string) (string, string) { return TenantDeleted(p["tenantKey"]) },
+	KeyTaskAssigned:        func(p map[string]string) (string, string) { return TaskAssigned(p["taskName"], p["processName"]) },
+	KeyTaskCompleted:       func(p map[string]string) (string, string) { return TaskCompleted(p["taskName"]) },
+	KeyApprovalRequired:    func(p map[string]string) (string, string) { return ApprovalRequired(p["taskName"], p["processName"]) },
+	KeyLeadStatusChanged:   func(p map[string]string) (string, string) { return LeadStatusChanged(p["entityName"]) },
+	KeyDealUpdated:         func(p map[string]string) (string, string) { return DealUpdated(p["entityName"]) },
+	KeyLoginNewDevice:      func(p map[string]string) (string, string) { return LoginNewDevice(p["ip"]) },
+	KeyPasswordChanged:     func(map[string]string) (string, string) { return PasswordChanged() },
+	KeyTestNotification:    func(map[string]string) (string, string) { return TestNotification() },
+}
+
+// Render builds the built-in message for a template key from its parameters.
+// ok is false for keys without a built-in message.
+func Render(key string, params map[string]string) (title, body string, ok bool) {
+	build, ok := builders[key]
+	if !ok {
+		return "", "", false
+	}
+	title, body = build(params)
+	return title, body, true
+}
@@ -48,3 +48,13 @@ const (
 	PasswordChangedTitle = "Mật khẩu đã thay đổi"
 	PasswordChangedBody  = "Mật khẩu tài khoản của bạn vừa được đổi. Hãy liên hệ quản trị viên nếu bạn không thực hiện thao tác này."
 )
+
+// ─── System ──────────────────────────────────────────────────────────────────
+
+const (
+	CompactedTitle = "%d thông báo cũ hơn"
+	CompactedBody  = "%d thông báo đã đọc từ %s đến %s đã được gộp lại."
+
+	TestNotificationTitle = "Thông báo thử"
+	TestNotificationBody  = "Nếu bạn thấy thông báo này, kết nối và cài đặt thông báo của bạn đang hoạt động bình thường."
+)
@@ -0,0 +1,63 @@
+package metrics
+
+import "sync/atomic"
+
+// CacheCounters counts lookups against one class of an in-process cache.
+// Safe for concurrent use.
+type CacheCounters struct {
+	hits         atomic.Uint64
+	negativeHits atomic.Uint64
+	misses       atomic.Uint64
+	evictions    atomic.Uint64
+	expirations  atomic.Uint64
+}
+
+// Hit records a lookup served from the cache; negative marks a cached failure.
+func (c *CacheCounters) Hit(negative bool) {
+	c.hits.Add(1)
+	if negative {
+		c.negativeHits.Add(1)
+	}
+}
+
+// Miss records a lookup that had to go to the backing store.
+func (c *CacheCounters) Miss() { c.misses.Add(1) }
+
+// Evict records an entry dropped to stay within the size bound.
+func (c *CacheCounters) Evict() { c.evictions.Add(1) }
+
+// Expire records an entry dropped because its TTL elapsed.
+func (c *CacheCounters) Expire() { c.expirations.Add(1) }
+
+// CacheSnapshot is a point-in-time view of CacheCounters.
+type CacheSnapshot struct {
+	Entries      int     `json:"entries"`
+	Hits         uint64  `json:"hits"`
+	NegativeHits uint64  `json:"negative_hits"`
+	Misses       uint64  `json:"misses"`
+	HitRatio     float64 `json:"hit_ratio"`
+	Evictions    uint64  `json:"evictions"`
+	Expirations  uint64  `json:"expirations"`
+}
+
+// Snapshot returns the cumulative counters. Entries is left for the cache to fill in.
+func (c *CacheCounters) Snapshot() CacheSnapshot {
+	snap := CacheSnapshot{
+		Hits:         c.hits.Load(),
+		NegativeHits: c.negativeHits.Load(),
+		Misses:       c.misses.Load(),
+		Evictions:    c.evictions.Load(),
+		Expirations:  c.expirations.Load(),
+	}
+	if total := snap.Hits + snap.Misses; total > 0 {
+		snap.HitRatio = float64(snap.Hits) / float64(total)
+	}
+	return snap
+}
+
+// CacheReport describes a size-bounded cache whose keys are split into classes.
+type CacheReport struct {
+	Entries    int                      `json:"entries"`
+	MaxEntries int                      `json:"max_entries"` // 0 = unbounded
+	Classes    map[string]CacheSnapshot `json:"classes"`
+}
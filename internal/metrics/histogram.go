@@ -0,0 +1,127 @@
+// Package metrics provides small in-process instrumentation primitives
+// exposed through the service's admin JSON endpoints.
+package metrics
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// DefaultLatencyBuckets covers sub-millisecond channel writes up to multi-second stalls.
+var DefaultLatencyBuckets = []time.Duration{
+	100 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+}
+
+// Histogram is a fixed-bucket latency histogram safe for concurrent use.
+type Histogram struct {
+	bounds []time.Duration
+	counts []atomic.Uint64 // len(bounds)+1, last bucket is +Inf
+	count  atomic.Uint64
+	sum    atomic.Int64 // nanoseconds
+	max    atomic.Int64 // nanoseconds
+}
+
+// NewHistogram creates a Histogram with the given ascending bucket upper bounds.
+func NewHistogram(bounds []time.Duration) *Histogram {
+	return &Histogram{bounds: bounds, counts: make([]atomic.Uint64, len(bounds)+1)}
+}
+
+// Observe records one duration.
+func (h *Histogram) Observe(d time.Duration) {
+	i := 0
+	for i < len(h.bounds) && d > h.bounds[i] {
+		i++
+	}
+	h.counts[i].Add(1)
+	h.count.Add(1)
+	h.sum.Add(int64(d))
+	for {
+		cur := h.max.Load()
+		if int64(d) <= cur || h.max.CompareAndSwap(cur, int64(d)) {
+			break
+		}
+	}
+}
+
+// Bucket is one cumulative histogram bucket in a snapshot.
+type Bucket struct {
+	LE    string `json:"le"` // upper bound, "+Inf" for the overflow bucket
+	Count uint64 `json:"count"`
+}
+
+// Snapshot is a point-in-time view of a Histogram.
+type Snapshot struct {
+	Count   uint64   `json:"count"`
+	AvgMS   float64  `json:"avg_ms"`
+	MaxMS   float64  `json:"max_ms"`
+	P50MS   float64  `json:"p50_ms"`
+	P95MS   float64  `json:"p95_ms"`
+	P99MS   float64  `json:"p99_ms"`
+	Buckets []Bucket `json:"buckets"`
+}
+
+// Snapshot returns cumulative bucket counts and bucket-resolution percentiles.
+func (h *Histogram) Snapshot() Snapshot {
+	raw := make([]uint64, len(h.counts))
+	var total uint64
+	for i := range h.counts {
+		raw[i] = h.counts[i].Load()
+		total += raw[i]
+	}
+
+	snap := Snapshot{Count: total, MaxMS: ms(time.Duration(h.max.Load()))}
+	if total > 0 {
+		snap.AvgMS = ms(time.Duration(h.sum.Load() / int64(total)))
+	}
+
+	var cumulative uint64
+	for i, c := range raw {
+		cumulative += c
+		le := "+Inf"
+		if i < len(h.bounds) {
+			le = h.bounds[i].String()
+		}
+		snap.Buckets = append(snap.Buckets, Bucket{LE: le, Count: cumulative})
+	}
+
+	snap.P50MS = h.quantile(raw, total, 0.50)
+	snap.P95MS = h.quantile(raw, total, 0.95)
+	snap.P99MS = h.quantile(raw, total, 0.99)
+	return snap
+}
+
+// quantile returns the upper bound of the bucket holding quantile q
+// (or the observed max for the overflow bucket).
+func (h *Histogram) quantile(raw []uint64, total uint64, q float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	rank := uint64(float64(total)*q + 0.5)
+	if rank == 0 {
+		rank = 1
+	}
+	var cumulative uint64
+	for i, c := range raw {
+		cumulative += c
+		if cumulative >= rank {
+			if i < len(h.bounds) {
+				return ms(h.bounds[i])
+			}
+			break
+		}
+	}
+	return ms(time.Duration(h.max.Load()))
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
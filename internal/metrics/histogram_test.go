@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistogram_Snapshot(t *testing.T) {
+	h := NewHistogram([]time.Duration{time.Millisecond, 10 * time.Millisecond})
+	for i := 0; i < 90; i++ {
+		h.Observe(500 * time.Microsecond)
+	}
+	for i := 0; i < 9; i++ {
+		h.Observe(5 * time.Millisecond)
+	}
+	h.Observe(2 * time.Second)
+
+	snap := h.Snapshot()
+	if snap.Count != 100 {
+		t.Fatalf("expected 100 observations, got %d", snap.Count)
+	}
+	if snap.P50MS != 1 {
+		t.Fatalf("expected p50 in 1ms bucket, got %v", snap.P50MS)
+	}
+	if snap.P95MS != 10 {
+		t.Fatalf("expected p95 in 10ms bucket, got %v", snap.P95MS)
+	}
+	if snap.MaxMS != 2000 {
+		t.Fatalf("expected max 2000ms, got %v", snap.MaxMS)
+	}
+	if last := snap.Buckets[len(snap.Buckets)-1]; last.LE != "+Inf" || last.Count != 100 {
+		t.Fatalf("unexpected overflow bucket: %+v", last)
+	}
+}
@@ -0,0 +1,14 @@
+package metrics
+
+// QueryStat is a point-in-time view of one SQL query shape.
+type QueryStat struct {
+	// Query is the normalized statement: whitespace collapsed, literals and
+	// placeholders replaced by ?, repeated VALUES tuples folded into one.
+	Query   string   `json:"query"`
+	Calls   uint64   `json:"calls"`
+	Errors  uint64   `json:"errors"`
+	Rows    uint64   `json:"rows"`
+	Slow    uint64   `json:"slow"`
+	TotalMS float64  `json:"total_ms"`
+	Latency Snapshot `json:"latency"`
+}
@@ -0,0 +1,179 @@
+// Package scheduler runs periodic maintenance jobs on cron schedules, once
+// across all replicas, catching up on runs missed while no instance was up.
+package scheduler
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed 5-field cron expression (minute hour day-of-month month
+// day-of-week). Fields accept *, lists, ranges and steps ("*/15", "1-5",
+// "0,30"); months and weekdays also accept names ("JAN", "MON"); Sunday is 0
+// or 7. As in cron, when both day fields are restricted a day matching either
+// one fires. The descriptors @yearly, @monthly, @weekly, @daily (@midnight) and
+// @hourly are accepted too.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	bothDays                      bool // both day fields are restricted
+}
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var (
+	monthNames = map[string]int{"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+		"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12}
+	dayNames = map[string]int{"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6}
+)
+
+// ParseSchedule parses a cron expression.
+func ParseSchedule(expr string) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if d, ok := descriptors[strings.ToLower(expr)]; ok {
+		expr = d
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron %q: expected 5 fields, got %d", expr, len(fields))
+	}
+	var (
+		s   Schedule
+		err error
+	)
+	if s.minute, err = parseField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("cron %q minute: %w", expr, err)
+	}
+	if s.hour, err = parseField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("cron %q hour: %w", expr, err)
+	}
+	if s.dom, err = parseField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("cron %q day of month: %w", expr, err)
+	}
+	if s.month, err = parseField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, fmt.Errorf("cron %q month: %w", expr, err)
+	}
+	if s.dow, err = parseField(fields[4], 0, 7, dayNames); err != nil {
+		return nil, fmt.Errorf("cron %q day of week: %w", expr, err)
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	domAny, dowAny := fields[2] == "*" || fields[2] == "?", fields[4] == "*" || fields[4] == "?"
+	s.bothDays = !domAny && !dowAny
+	return &s, nil
+}
+
+// parseField returns the bitset of the values a field matches.
+func parseField(field string, lo, hi int, names map[string]int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepStr)
+			}
+			step = n
+		}
+		from, to := lo, hi
+		switch {
+		case rng == "*" || rng == "?":
+		case strings.Contains(rng, "-"):
+			a, b, _ := strings.Cut(rng, "-")
+			var err error
+			if from, err = fieldValue(a, names); err != nil {
+				return 0, err
+			}
+			if to, err = fieldValue(b, names); err != nil {
+				return 0, err
+			}
+		default:
+			v, err := fieldValue(rng, names)
+			if err != nil {
+				return 0, err
+			}
+			from = v
+			if !hasStep {
+				to = v
+			}
+		}
+		if from < lo || to > hi || from > to {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, lo, hi)
+		}
+		for v := from; v <= to; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+func fieldValue(s string, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToUpper(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	return v, nil
+}
+
+// Next returns the first time strictly after t, at minute resolution and in
+// t's location, that the schedule fires. Returns the zero time when nothing
+// matches within five years (e.g. "0 0 30 2 *").
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			next := time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			if !next.After(t) { // DST repeats the hour
+				next = t.Add(time.Hour).Truncate(time.Hour)
+			}
+			t = next
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			// Jump to the next matching minute of this hour, or the next hour.
+			rest := s.minute >> uint(t.Minute())
+			if rest == 0 {
+				t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+			} else {
+				t = t.Add(time.Duration(bits.TrailingZeros64(rest)) * time.Minute)
+			}
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.bothDays {
+		return dom || dow
+	}
+	return dom && dow
+}
@@ -0,0 +1,63 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	from := time.Date(2026, 1, 30, 22, 47, 10, 0, time.UTC) // a Friday
+	for expr, want := range map[string]time.Time{
+		"0 3 * * *":        time.Date(2026, 1, 31, 3, 0, 0, 0, time.UTC),
+		"*/15 * * * *":     time.Date(2026, 1, 30, 23, 0, 0, 0, time.UTC),
+		"50 22 * * *":      time.Date(2026, 1, 30, 22, 50, 0, 0, time.UTC),
+		"0 0 1 * *":        time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+		"30 2 * * MON-WED": time.Date(2026, 2, 2, 2, 30, 0, 0, time.UTC),
+		"0 0 31 * *":       time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC),
+		"0 0 29 2 *":       time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+		"0 0 15 * 7":       time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), // Sunday before the 15th
+		"@hourly":          time.Date(2026, 1, 30, 23, 0, 0, 0, time.UTC),
+		"@yearly":          time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+		"0 0 30 2 *":       {},
+	} {
+		s, err := ParseSchedule(expr)
+		if err != nil {
+			t.Fatalf("%q: %v", expr, err)
+		}
+		if got := s.Next(from); !got.Equal(want) {
+			t.Errorf("%q: Next = %v, want %v", expr, got, want)
+		}
+	}
+}
+
+func TestScheduleNext_Location(t *testing.T) {
+	hcm := time.FixedZone("ICT", 7*3600)
+	s, _ := ParseSchedule("0 3 * * *")
+	got := s.Next(time.Date(2026, 1, 30, 22, 0, 0, 0, time.UTC).In(hcm))
+	if want := time.Date(2026, 1, 31, 20, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("Next = %v, want %v", got, want)
+	}
+}
+
+func TestParseSchedule_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "* * * FOO *"} {
+		if _, err := ParseSchedule(expr); err == nil {
+			t.Errorf("%q: expected an error", expr)
+		}
+	}
+}
+
+func TestNextRun_CatchesUp(t *testing.T) {
+	s, _ := ParseSchedule("0 3 * * *")
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	if got := nextRun(s, time.Time{}, now, time.UTC); !got.Equal(now) {
+		t.Errorf("never run: due %v, want now", got)
+	}
+	if got := nextRun(s, now.AddDate(0, 0, -2), now, time.UTC); !got.Equal(now) {
+		t.Errorf("missed run: due %v, want now", got)
+	}
+	last := time.Date(2026, 3, 10, 3, 4, 0, 0, time.UTC)
+	if got, want := nextRun(s, last, now, time.UTC), time.Date(2026, 3, 11, 3, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("ran today: due %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,122 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// DefaultLeaderCheck is how often the leader verifies it still holds the
+// leadership lock, and how often followers try to take it.
+const DefaultLeaderCheck = 10 * time.Second
+
+// Task is a long-running singleton job: Run loops until its context ends.
+type Task struct {
+	Name string
+	Run  func(ctx context.Context)
+}
+
+// Leader elects one instance of the fleet, the holder of a cluster-wide lock
+// (domain.JobRunRepository.TryLock), to run the singleton tasks. The tasks are
+// started when the instance becomes leader and cancelled when it steps down,
+// so a task may overlap with the next leader's for up to one check interval
+// after the lock is lost; tasks must still be safe to repeat.
+type Leader struct {
+	locks   domain.JobRunRepository
+	name    string
+	check   time.Duration
+	tasks   []Task
+	leading atomic.Bool
+}
+
+// NewLeader creates a Leader campaigning for the lock called name; check
+// defaults to DefaultLeaderCheck.
+func NewLeader(locks domain.JobRunRepository, name string, check time.Duration) *Leader {
+	if check <= 0 {
+		check = DefaultLeaderCheck
+	}
+	return &Leader{locks: locks, name: name, check: check}
+}
+
+// Add registers a task. Tasks must be added before Run.
+func (l *Leader) Add(task Task) {
+	l.tasks = append(l.tasks, task)
+}
+
+// IsLeader reports whether this instance currently leads.
+func (l *Leader) IsLeader() bool {
+	return l.leading.Load()
+}
+
+// Run campaigns for leadership until ctx is done, running the tasks while
+// leading.
+func (l *Leader) Run(ctx context.Context) {
+	for {
+		lock, ok, err := l.locks.TryLock(ctx, l.name)
+		switch {
+		case err != nil:
+			if ctx.Err() == nil {
+				log.Warn().Err(err).Str("lock", l.name).Msg("leader election failed")
+			}
+		case ok:
+			l.lead(ctx, lock)
+		}
+		if !sleep(ctx, l.check) {
+			return
+		}
+	}
+}
+
+// lead runs the tasks until ctx is done or the lock is lost.
+func (l *Leader) lead(ctx context.Context, lock domain.JobLock) {
+	defer lock.Release()
+	l.leading.Store(true)
+	defer l.leading.Store(false)
+	log.Info().Str("lock", l.name).Int("tasks", len(l.tasks)).Msg("became leader")
+
+	taskCtx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	for _, task := range l.tasks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			log.Debug().Str("task", task.Name).Msg("singleton task started")
+			task.Run(taskCtx)
+			log.Debug().Str("task", task.Name).Msg("singleton task stopped")
+		}()
+	}
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	for sleep(ctx, l.check) {
+		if err := lock.Check(ctx); err != nil {
+			if ctx.Err() == nil {
+				log.Error().Err(err).Str("lock", l.name).Msg("lost leadership; stopping singleton tasks")
+			}
+			return
+		}
+	}
+	log.Info().Str("lock", l.name).Msg("stepping down as leader")
+}
+
+// Every returns a task loop calling fn every interval until its context ends.
+func Every(interval time.Duration, fn func(ctx context.Context)) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				fn(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
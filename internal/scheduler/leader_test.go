@@ -0,0 +1,92 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"vn.io.arda/notification/internal/domain"
+)
+
+type memLocks struct {
+	domain.JobRunRepository
+	mu   sync.Mutex
+	held map[string]*memLock
+}
+
+type memLock struct {
+	locks *memLocks
+	name  string
+	lost  atomic.Bool
+}
+
+func (m *memLocks) TryLock(_ context.Context, name string) (domain.JobLock, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.held[name] != nil {
+		return nil, false, nil
+	}
+	l := &memLock{locks: m, name: name}
+	m.held[name] = l
+	return l, true, nil
+}
+
+func (l *memLock) Check(context.Context) error {
+	if l.lost.Load() {
+		return errors.New("session ended")
+	}
+	return nil
+}
+
+func (l *memLock) Release() {
+	l.locks.mu.Lock()
+	defer l.locks.mu.Unlock()
+	delete(l.locks.held, l.name)
+}
+
+func TestLeader_RunsTasksOnOneInstance(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	locks := &memLocks{held: map[string]*memLock{}}
+
+	var running atomic.Int32
+	task := Task{Name: "t", Run: func(ctx context.Context) {
+		running.Add(1)
+		<-ctx.Done()
+		running.Add(-1)
+	}}
+	a := NewLeader(locks, "leader", 5*time.Millisecond)
+	b := NewLeader(locks, "leader", 5*time.Millisecond)
+	a.Add(task)
+	b.Add(task)
+	go a.Run(ctx)
+	waitFor(t, func() bool { return a.IsLeader() && running.Load() == 1 })
+	go b.Run(ctx)
+	time.Sleep(20 * time.Millisecond)
+	if b.IsLeader() || running.Load() != 1 {
+		t.Fatalf("b leads = %v, %d tasks running; want only a", b.IsLeader(), running.Load())
+	}
+
+	// a loses its session: it stops its task and b takes over.
+	locks.mu.Lock()
+	locks.held["leader"].lost.Store(true)
+	locks.mu.Unlock()
+	waitFor(t, func() bool { return b.IsLeader() && !a.IsLeader() && running.Load() == 1 })
+
+	cancel()
+	waitFor(t, func() bool { return !b.IsLeader() && running.Load() == 0 })
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
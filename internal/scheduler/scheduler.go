@@ -0,0 +1,160 @@
+package scheduler
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// DefaultRetry is how long a job waits before trying again when its lock is
+// held elsewhere or the run bookkeeping fails.
+const DefaultRetry = time.Minute
+
+// Job is a task run on a cron schedule.
+type Job struct {
+	Name     string
+	Schedule *Schedule
+	// Jitter delays each run by a random duration below it, so replicas and
+	// neighbouring deployments do not all hit the database at the same instant.
+	Jitter time.Duration
+	Run    func(ctx context.Context)
+}
+
+// Scheduler runs jobs on their schedules. A run is taken by a single instance
+// (domain.JobRunRepository.TryLock), and the start of the last completed run
+// is recorded so an instance starting after a missed run catches up at once.
+type Scheduler struct {
+	runs  domain.JobRunRepository
+	loc   *time.Location
+	retry time.Duration
+	jobs  []Job
+}
+
+// New creates a Scheduler evaluating schedules in loc (UTC when nil).
+func New(runs domain.JobRunRepository, loc *time.Location) *Scheduler {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return &Scheduler{runs: runs, loc: loc, retry: DefaultRetry}
+}
+
+// Add registers a job. Jobs must be added before Run.
+func (s *Scheduler) Add(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Run runs every job until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, job := range s.jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.loop(ctx, job)
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	logger := log.With().Str("job", job.Name).Logger()
+	for {
+		last, err := s.runs.LastRun(ctx, job.Name)
+		if err != nil {
+			logger.Warn().Err(err).Msg("scheduled job: cannot read last run")
+			if !sleep(ctx, s.retry) {
+				return
+			}
+			continue
+		}
+		due := nextRun(job.Schedule, last, time.Now(), s.loc)
+		if due.IsZero() {
+			logger.Error().Msg("scheduled job: schedule never fires; job disabled")
+			return
+		}
+		wait := time.Until(due)
+		if job.Jitter > 0 {
+			wait += rand.N(job.Jitter)
+		}
+		logger.Debug().Time("due", due).Dur("wait", wait).Msg("scheduled job: waiting")
+		if !sleep(ctx, wait) {
+			return
+		}
+		if !s.runOnce(ctx, job) && !sleep(ctx, s.retry) {
+			return
+		}
+	}
+}
+
+// runOnce runs job under its lock. It returns false when the job should be
+// retried later: the lock is held elsewhere or could not be taken.
+func (s *Scheduler) runOnce(ctx context.Context, job Job) bool {
+	logger := log.With().Str("job", job.Name).Logger()
+	lock, ok, err := s.runs.TryLock(ctx, job.Name)
+	if err != nil {
+		logger.Warn().Err(err).Msg("scheduled job: cannot take lock")
+		return false
+	}
+	if !ok {
+		logger.Debug().Msg("scheduled job: running on another instance")
+		return false
+	}
+	defer lock.Release()
+
+	// Another instance may have completed this run while we waited for the lock.
+	last, err := s.runs.LastRun(ctx, job.Name)
+	if err != nil {
+		logger.Warn().Err(err).Msg("scheduled job: cannot read last run")
+		return false
+	}
+	start := time.Now()
+	if nextRun(job.Schedule, last, start, s.loc).After(start) {
+		return true
+	}
+
+	logger.Info().Time("last_run", last).Msg("scheduled job: starting")
+	job.Run(ctx)
+	took := time.Since(start)
+	if ctx.Err() != nil {
+		// Interrupted by shutdown: leave the run due so the next start redoes it.
+		return true
+	}
+	if err := s.runs.RecordRun(ctx, job.Name, start, took); err != nil {
+		logger.Warn().Err(err).Msg("scheduled job: cannot record run")
+	}
+	logger.Info().Dur("took", took).Msg("scheduled job: completed")
+	return true
+}
+
+// nextRun returns when a job that last started at last is next due: its next
+// scheduled time, or now when that has already passed (a missed run, or a job
+// that never ran). Zero when the schedule never fires.
+func nextRun(sched *Schedule, last, now time.Time, loc *time.Location) time.Time {
+	if last.IsZero() {
+		return now
+	}
+	next := sched.Next(last.In(loc))
+	if !next.IsZero() && next.Before(now) {
+		return now
+	}
+	return next
+}
+
+// sleep waits for d and reports false when ctx ended first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
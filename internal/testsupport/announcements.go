@@ -0,0 +1,126 @@
+package testsupport
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// Announcements is an in-memory domain.AnnouncementRepository. Safe for
+// concurrent use.
+type Announcements struct {
+	mu         sync.Mutex
+	items      map[uuid.UUID]domain.Announcement
+	dismissals map[uuid.UUID]map[string]bool // tenant/user
+}
+
+// NewAnnouncements creates an empty Announcements store.
+func NewAnnouncements() *Announcements {
+	return &Announcements{items: make(map[uuid.UUID]domain.Announcement), dismissals: make(map[uuid.UUID]map[string]bool)}
+}
+
+// ListActive returns the active, undismissed announcements of a user, newest first.
+func (r *Announcements) ListActive(_ context.Context, tenantKey, userID string, now time.Time) ([]domain.Announcement, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []domain.Announcement
+	for _, a := range r.items {
+		if (a.TenantKey == "" || a.TenantKey == tenantKey) && a.ActiveAt(now) && !r.dismissals[a.ID][tenantKey+"/"+userID] {
+			out = append(out, a)
+		}
+	}
+	sortAnnouncements(out)
+	return out, nil
+}
+
+// List returns the announcements of tenantKey and platform-wide ones, or all
+// when tenantKey is empty, newest first.
+func (r *Announcements) List(_ context.Context, tenantKey string) ([]domain.Announcement, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []domain.Announcement
+	for _, a := range r.items {
+		if tenantKey == "" || a.TenantKey == "" || a.TenantKey == tenantKey {
+			out = append(out, a)
+		}
+	}
+	sortAnnouncements(out)
+	return out, nil
+}
+
+// Get returns an announcement, or nil when it does not exist.
+func (r *Announcements) Get(_ context.Context, id uuid.UUID) (*domain.Announcement, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	a, ok := r.items[id]
+	if !ok {
+		return nil, nil
+	}
+	return &a, nil
+}
+
+// Create stores an announcement under a new ID.
+func (r *Announcements) Create(_ context.Context, a domain.Announcement) (*domain.Announcement, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	a.ID = uuid.Must(uuid.NewV7())
+	a.CreatedAt, a.UpdatedAt = time.Now(), time.Now()
+	r.items[a.ID] = a
+	return &a, nil
+}
+
+// Update replaces the text, severity and schedule of an announcement.
+func (r *Announcements) Update(_ context.Context, a domain.Announcement) (*domain.Announcement, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cur, ok := r.items[a.ID]
+	if !ok {
+		return nil, fmt.Errorf("announcement not found")
+	}
+	cur.Title, cur.Body, cur.Severity, cur.StartsAt, cur.EndsAt = a.Title, a.Body, a.Severity, a.StartsAt, a.EndsAt
+	cur.UpdatedAt = time.Now()
+	r.items[a.ID] = cur
+	return &cur, nil
+}
+
+// Delete removes an announcement and its dismissals.
+func (r *Announcements) Delete(_ context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.items[id]; !ok {
+		return fmt.Errorf("announcement not found")
+	}
+	delete(r.items, id)
+	delete(r.dismissals, id)
+	return nil
+}
+
+// Dismiss hides an announcement for a user.
+func (r *Announcements) Dismiss(_ context.Context, id uuid.UUID, tenantKey, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.items[id]; !ok {
+		return fmt.Errorf("announcement not found")
+	}
+	if r.dismissals[id] == nil {
+		r.dismissals[id] = make(map[string]bool)
+	}
+	r.dismissals[id][tenantKey+"/"+userID] = true
+	return nil
+}
+
+func sortAnnouncements(as []domain.Announcement) {
+	slices.SortFunc(as, func(a, b domain.Announcement) int {
+		if c := b.StartsAt.Compare(a.StartsAt); c != 0 {
+			return c
+		}
+		return slices.Compare(b.ID[:], a.ID[:])
+	})
+}
+
+var _ domain.AnnouncementRepository = (*Announcements)(nil)
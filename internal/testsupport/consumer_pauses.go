@@ -0,0 +1,57 @@
+package testsupport
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"vn.io.arda/notification/internal/domain"
+)
+
+// ConsumerPauses is an in-memory domain.ConsumerPauseRepository. Safe for
+// concurrent use.
+type ConsumerPauses struct {
+	mu     sync.Mutex
+	pauses map[string]domain.ConsumerPause
+}
+
+// NewConsumerPauses creates an empty ConsumerPauses store.
+func NewConsumerPauses() *ConsumerPauses {
+	return &ConsumerPauses{pauses: make(map[string]domain.ConsumerPause)}
+}
+
+// List returns all paused topics ordered by topic.
+func (p *ConsumerPauses) List(context.Context) ([]domain.ConsumerPause, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]domain.ConsumerPause, 0, len(p.pauses))
+	for _, pause := range p.pauses {
+		out = append(out, pause)
+	}
+	slices.SortFunc(out, func(a, b domain.ConsumerPause) int { return strings.Compare(a.Topic, b.Topic) })
+	return out, nil
+}
+
+// Pause stores the pause of pause.Topic, replacing its reason if already paused.
+func (p *ConsumerPauses) Pause(_ context.Context, pause domain.ConsumerPause) (*domain.ConsumerPause, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if prev, ok := p.pauses[pause.Topic]; ok {
+		pause.PausedBy, pause.PausedAt = prev.PausedBy, prev.PausedAt
+	} else {
+		pause.PausedAt = time.Now()
+	}
+	p.pauses[pause.Topic] = pause
+	return &pause, nil
+}
+
+// Resume removes the pause of topic. Returns false when it was not paused.
+func (p *ConsumerPauses) Resume(_ context.Context, topic string) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.pauses[topic]
+	delete(p.pauses, topic)
+	return ok, nil
+}
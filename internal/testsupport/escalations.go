@@ -0,0 +1,142 @@
+package testsupport
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// Escalations is an in-memory domain.EscalationRepository. It reads the state
+// of notifications from a Repository when claiming. Safe for concurrent use.
+type Escalations struct {
+	repo *Repository
+
+	mu      sync.Mutex
+	rules   []domain.EscalationRule
+	pending []domain.Escalation
+}
+
+// NewEscalations creates an Escalations store over the notifications of repo.
+func NewEscalations(repo *Repository) *Escalations {
+	return &Escalations{repo: repo}
+}
+
+// Pending returns the scheduled escalations, soonest first.
+func (r *Escalations) Pending() []domain.Escalation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.pending)
+}
+
+// ListRules returns a tenant's rules.
+func (r *Escalations) ListRules(_ context.Context, tenantKey string) ([]domain.EscalationRule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []domain.EscalationRule
+	for _, rule := range r.rules {
+		if rule.TenantKey == tenantKey {
+			out = append(out, rule)
+		}
+	}
+	return out, nil
+}
+
+// GetRule returns a rule, or nil when it does not exist.
+func (r *Escalations) GetRule(_ context.Context, tenantKey string, id uuid.UUID) (*domain.EscalationRule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rule := range r.rules {
+		if rule.ID == id && rule.TenantKey == tenantKey {
+			return &rule, nil
+		}
+	}
+	return nil, nil
+}
+
+// CreateRule stores a rule under a new ID.
+func (r *Escalations) CreateRule(_ context.Context, rule domain.EscalationRule) (*domain.EscalationRule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rule.ID = uuid.New()
+	r.rules = append(r.rules, rule)
+	return &rule, nil
+}
+
+// UpdateRule replaces a rule.
+func (r *Escalations) UpdateRule(_ context.Context, rule domain.EscalationRule) (*domain.EscalationRule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.rules {
+		if r.rules[i].ID == rule.ID && r.rules[i].TenantKey == rule.TenantKey {
+			r.rules[i] = rule
+			return &rule, nil
+		}
+	}
+	return nil, fmt.Errorf("escalation rule not found")
+}
+
+// DeleteRule removes a rule and its pending escalations.
+func (r *Escalations) DeleteRule(_ context.Context, tenantKey string, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := len(r.rules)
+	r.rules = slices.DeleteFunc(r.rules, func(rule domain.EscalationRule) bool { return rule.ID == id && rule.TenantKey == tenantKey })
+	if len(r.rules) == n {
+		return fmt.Errorf("escalation rule not found")
+	}
+	r.pending = slices.DeleteFunc(r.pending, func(e domain.Escalation) bool { return e.RuleID == id })
+	return nil
+}
+
+// Schedule stores escalations, skipping already scheduled (notification, rule) pairs.
+func (r *Escalations) Schedule(_ context.Context, escalations []domain.Escalation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, e := range escalations {
+		if !slices.ContainsFunc(r.pending, func(p domain.Escalation) bool {
+			return p.NotificationID == e.NotificationID && p.RuleID == e.RuleID
+		}) {
+			r.pending = append(r.pending, e)
+		}
+	}
+	slices.SortStableFunc(r.pending, func(a, b domain.Escalation) int { return a.DueAt.Compare(b.DueAt) })
+	return nil
+}
+
+// ClaimDue removes up to limit due escalations and returns those whose
+// notification is still unread.
+func (r *Escalations) ClaimDue(ctx context.Context, now time.Time, limit int) ([]domain.Escalation, error) {
+	r.mu.Lock()
+	var claimed []domain.Escalation
+	for len(r.pending) > 0 && len(claimed) < limit && !r.pending[0].DueAt.After(now) {
+		claimed = append(claimed, r.pending[0])
+		r.pending = r.pending[1:]
+	}
+	r.mu.Unlock()
+
+	var out []domain.Escalation
+	for _, e := range claimed {
+		if n, err := r.repo.GetByID(ctx, e.NotificationID); err == nil && !n.IsRead {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// Cancel drops the pending escalations of a user's notifications ids, or of
+// all of them when ids is empty.
+func (r *Escalations) Cancel(_ context.Context, tenantKey, userID string, ids []uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending = slices.DeleteFunc(r.pending, func(e domain.Escalation) bool {
+		return e.TenantKey == tenantKey && e.UserID == userID && (len(ids) == 0 || slices.Contains(ids, e.NotificationID))
+	})
+	return nil
+}
+
+var _ domain.EscalationRepository = (*Escalations)(nil)
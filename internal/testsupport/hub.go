@@ -0,0 +1,116 @@
+package testsupport
+
+import (
+	"slices"
+	"sync"
+
+	"vn.io.arda/notification/internal/domain"
+)
+
+// Push is a notification sent by a Hub. UserID is empty for scope broadcasts.
+type Push struct {
+	TenantKey    string
+	UserID       string
+	Notification *domain.Notification
+}
+
+// Event is a named event sent by a Hub. UserID is empty for scope events.
+type Event struct {
+	TenantKey      string
+	UserID         string
+	ExceptClientID string
+	Name           string
+	Data           any
+}
+
+// Hub is an SSE hub that records what it is asked to push instead of writing
+// to streams. Users count as connected once Connect is called. Safe for
+// concurrent use.
+type Hub struct {
+	mu        sync.Mutex
+	connected map[string]bool
+	pushes    []Push
+	events    []Event
+}
+
+// NewHub creates a Hub with no connected users.
+func NewHub() *Hub {
+	return &Hub{connected: make(map[string]bool)}
+}
+
+// Connect marks a user as having an open stream.
+func (h *Hub) Connect(tenantKey, userID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.connected[tenantKey+"/"+userID] = true
+}
+
+// Disconnect marks a user as having no open stream.
+func (h *Hub) Disconnect(tenantKey, userID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.connected, tenantKey+"/"+userID)
+}
+
+// Broadcast records a notification pushed to a user.
+func (h *Hub) Broadcast(tenantKey, userID string, notification *domain.Notification) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.pushes = append(h.pushes, Push{TenantKey: tenantKey, UserID: userID, Notification: notification})
+}
+
+// BroadcastEvent records a named event sent to a user.
+func (h *Hub) BroadcastEvent(tenantKey, userID, event string, data any) {
+	h.BroadcastEventExcept(tenantKey, userID, "", event, data)
+}
+
+// BroadcastEventExcept records a named event sent to a user's other streams.
+func (h *Hub) BroadcastEventExcept(tenantKey, userID, exceptClientID, event string, data any) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.events = append(h.events, Event{TenantKey: tenantKey, UserID: userID, ExceptClientID: exceptClientID, Name: event, Data: data})
+}
+
+// BroadcastScope records a notification pushed to a tenant, or to every tenant
+// when tenantKey is empty.
+func (h *Hub) BroadcastScope(tenantKey string, notification *domain.Notification) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.pushes = append(h.pushes, Push{TenantKey: tenantKey, Notification: notification})
+}
+
+// BroadcastScopeEvent records a named event sent to a tenant, or to every
+// tenant when tenantKey is empty.
+func (h *Hub) BroadcastScopeEvent(tenantKey, event string, data any) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.events = append(h.events, Event{TenantKey: tenantKey, Name: event, Data: data})
+}
+
+// IsConnected reports whether Connect was called for the user.
+func (h *Hub) IsConnected(tenantKey, userID string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.connected[tenantKey+"/"+userID]
+}
+
+// Pushes returns the notifications pushed so far, oldest first.
+func (h *Hub) Pushes() []Push {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return slices.Clone(h.pushes)
+}
+
+// Events returns the named events sent so far, oldest first.
+func (h *Hub) Events() []Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return slices.Clone(h.events)
+}
+
+// Reset forgets the recorded pushes and events.
+func (h *Hub) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.pushes, h.events = nil, nil
+}
@@ -0,0 +1,152 @@
+package testsupport
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// Maintenance is an in-memory domain.MaintenanceRepository. Safe for
+// concurrent use.
+type Maintenance struct {
+	mu      sync.Mutex
+	windows []domain.MaintenanceWindow
+	held    map[uuid.UUID][]domain.FanoutInput
+}
+
+// NewMaintenance creates an empty Maintenance store.
+func NewMaintenance() *Maintenance {
+	return &Maintenance{held: make(map[uuid.UUID][]domain.FanoutInput)}
+}
+
+func (r *Maintenance) find(id uuid.UUID) int {
+	return slices.IndexFunc(r.windows, func(w domain.MaintenanceWindow) bool { return w.ID == id })
+}
+
+// List returns the windows of tenantKey and those for every tenant, or all
+// windows when tenantKey is empty, latest start first.
+func (r *Maintenance) List(_ context.Context, tenantKey string) ([]domain.MaintenanceWindow, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []domain.MaintenanceWindow
+	for _, w := range r.windows {
+		if tenantKey == "" || w.TenantKey == "" || w.TenantKey == tenantKey {
+			w.Held = len(r.held[w.ID])
+			out = append(out, w)
+		}
+	}
+	slices.SortFunc(out, func(a, b domain.MaintenanceWindow) int { return b.StartsAt.Compare(a.StartsAt) })
+	return out, nil
+}
+
+// Active returns the window covering tenantKey at now, preferring suppress.
+func (r *Maintenance) Active(_ context.Context, tenantKey string, now time.Time) (*domain.MaintenanceWindow, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var active *domain.MaintenanceWindow
+	for _, w := range r.windows {
+		if w.TenantKey != "" && w.TenantKey != tenantKey || now.Before(w.StartsAt) || !now.Before(w.EndsAt) {
+			continue
+		}
+		if active == nil || w.Mode == domain.MaintenanceSuppress {
+			active = &w
+		}
+	}
+	return active, nil
+}
+
+// Get returns a window; nil when there is none.
+func (r *Maintenance) Get(_ context.Context, id uuid.UUID) (*domain.MaintenanceWindow, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := r.find(id)
+	if i < 0 {
+		return nil, nil
+	}
+	w := r.windows[i]
+	w.Held = len(r.held[w.ID])
+	return &w, nil
+}
+
+// Create stores a window.
+func (r *Maintenance) Create(_ context.Context, w domain.MaintenanceWindow) (*domain.MaintenanceWindow, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	w.ID, w.CreatedAt = uuid.New(), time.Now()
+	r.windows = append(r.windows, w)
+	return &w, nil
+}
+
+// Update replaces reason, mode and schedule of a window not yet flushed.
+func (r *Maintenance) Update(_ context.Context, w domain.MaintenanceWindow) (*domain.MaintenanceWindow, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := r.find(w.ID)
+	if i < 0 || r.windows[i].FlushedAt != nil {
+		return nil, fmt.Errorf("maintenance window not found or already flushed")
+	}
+	cur := &r.windows[i]
+	cur.Reason, cur.Mode, cur.StartsAt, cur.EndsAt = w.Reason, w.Mode, w.StartsAt, w.EndsAt
+	saved := *cur
+	return &saved, nil
+}
+
+// Delete removes a window and its held inputs.
+func (r *Maintenance) Delete(_ context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := r.find(id)
+	if i < 0 {
+		return fmt.Errorf("maintenance window not found")
+	}
+	r.windows = slices.Delete(r.windows, i, i+1)
+	delete(r.held, id)
+	return nil
+}
+
+// Hold stores an input for the digest of a window.
+func (r *Maintenance) Hold(_ context.Context, windowID uuid.UUID, input domain.FanoutInput) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.held[windowID] = append(r.held[windowID], input)
+	return nil
+}
+
+// ListEnded returns the batch windows ended at now and not yet flushed.
+func (r *Maintenance) ListEnded(_ context.Context, now time.Time) ([]domain.MaintenanceWindow, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []domain.MaintenanceWindow
+	for _, w := range r.windows {
+		if w.Mode == domain.MaintenanceBatch && w.FlushedAt == nil && !now.Before(w.EndsAt) {
+			out = append(out, w)
+		}
+	}
+	return out, nil
+}
+
+// Held returns the inputs held by a window, oldest first.
+func (r *Maintenance) Held(_ context.Context, windowID uuid.UUID) ([]domain.FanoutInput, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.held[windowID]), nil
+}
+
+// MarkFlushed records a window's digest as delivered and drops its inputs.
+func (r *Maintenance) MarkFlushed(_ context.Context, windowID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if i := r.find(windowID); i >= 0 {
+		now := time.Now()
+		r.windows[i].FlushedAt = &now
+	}
+	delete(r.held, windowID)
+	return nil
+}
+
+var _ domain.MaintenanceRepository = (*Maintenance)(nil)
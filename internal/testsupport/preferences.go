@@ -0,0 +1,81 @@
+package testsupport
+
+import (
+	"context"
+	"sync"
+
+	"vn.io.arda/notification/internal/domain"
+)
+
+// Preferences is an in-memory domain.PreferenceRepository. Safe for concurrent
+// use.
+type Preferences struct {
+	mu    sync.Mutex
+	prefs map[prefKey]domain.Preference
+}
+
+type prefKey struct {
+	tenantKey, userID string
+	notifType         domain.NotificationType
+	category          string
+}
+
+// NewPreferences creates an empty Preferences store.
+func NewPreferences() *Preferences {
+	return &Preferences{prefs: make(map[prefKey]domain.Preference)}
+}
+
+// Mute stores a preference turning off in-app and email delivery of
+// notifType (or of category within it) for a user.
+func (p *Preferences) Mute(tenantKey, userID string, notifType domain.NotificationType, category string) {
+	_, _ = p.Upsert(context.Background(), domain.Preference{TenantKey: tenantKey, UserID: userID, Type: notifType, Category: category})
+}
+
+// GetByUser returns all preferences of a user.
+func (p *Preferences) GetByUser(_ context.Context, tenantKey, userID string) ([]domain.Preference, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var out []domain.Preference
+	for k, pref := range p.prefs {
+		if k.tenantKey == tenantKey && k.userID == userID {
+			out = append(out, pref)
+		}
+	}
+	return out, nil
+}
+
+// GetByUserAndCategory returns the category preference if one exists, else the
+// type-level one, else nil.
+func (p *Preferences) GetByUserAndCategory(_ context.Context, tenantKey, userID string, notifType domain.NotificationType, category string) (*domain.Preference, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if category != "" {
+		if pref, ok := p.prefs[prefKey{tenantKey, userID, notifType, category}]; ok {
+			return &pref, nil
+		}
+	}
+	if pref, ok := p.prefs[prefKey{tenantKey, userID, notifType, ""}]; ok {
+		return &pref, nil
+	}
+	return nil, nil
+}
+
+// Upsert stores a preference.
+func (p *Preferences) Upsert(_ context.Context, pref domain.Preference) (*domain.Preference, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.prefs[prefKey{pref.TenantKey, pref.UserID, pref.Type, pref.Category}] = pref
+	return &pref, nil
+}
+
+// BatchUpsert stores several preferences.
+func (p *Preferences) BatchUpsert(ctx context.Context, prefs []domain.Preference) ([]domain.Preference, error) {
+	for _, pref := range prefs {
+		if _, err := p.Upsert(ctx, pref); err != nil {
+			return nil, err
+		}
+	}
+	return prefs, nil
+}
+
+var _ domain.PreferenceRepository = (*Preferences)(nil)
@@ -0,0 +1,158 @@
+package testsupport
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"vn.io.arda/notification/internal/domain"
+)
+
+// Quotas is an in-memory domain.QuotaRepository. Safe for concurrent use.
+type Quotas struct {
+	mu     sync.Mutex
+	quotas map[string]domain.TenantQuota
+	usage  map[string]map[time.Time]domain.TenantUsage
+	held   []heldFanout
+	nextID int64
+}
+
+type heldFanout struct {
+	domain.HeldFanout
+	tenantKey string
+}
+
+// NewQuotas creates an empty Quotas store.
+func NewQuotas() *Quotas {
+	return &Quotas{quotas: make(map[string]domain.TenantQuota), usage: make(map[string]map[time.Time]domain.TenantUsage)}
+}
+
+// ListQuotas returns the quotas by tenant key.
+func (r *Quotas) ListQuotas(context.Context) ([]domain.TenantQuota, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]domain.TenantQuota, 0, len(r.quotas))
+	for _, q := range r.quotas {
+		out = append(out, q)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].TenantKey < out[j].TenantKey })
+	return out, nil
+}
+
+// GetQuota returns the quota of tenantKey, or nil.
+func (r *Quotas) GetQuota(_ context.Context, tenantKey string) (*domain.TenantQuota, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	q, ok := r.quotas[tenantKey]
+	if !ok {
+		return nil, nil
+	}
+	return &q, nil
+}
+
+// UpsertQuota stores q.
+func (r *Quotas) UpsertQuota(_ context.Context, q domain.TenantQuota) (*domain.TenantQuota, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	q.UpdatedAt = time.Now()
+	r.quotas[q.TenantKey] = q
+	return &q, nil
+}
+
+// DeleteQuota removes the quota of tenantKey.
+func (r *Quotas) DeleteQuota(_ context.Context, tenantKey string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.quotas, tenantKey)
+	return nil
+}
+
+// AddUsage adds the counts of u.
+func (r *Quotas) AddUsage(_ context.Context, u domain.TenantUsage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	months := r.usage[u.TenantKey]
+	if months == nil {
+		months = make(map[time.Time]domain.TenantUsage)
+		r.usage[u.TenantKey] = months
+	}
+	cur := months[u.Month]
+	cur.TenantKey, cur.Month = u.TenantKey, u.Month
+	cur.Created += u.Created
+	cur.Rejected += u.Rejected
+	cur.Queued += u.Queued
+	months[u.Month] = cur
+	return nil
+}
+
+// Usage returns the usage of tenantKey from from to to, latest first.
+func (r *Quotas) Usage(_ context.Context, tenantKey string, from, to time.Time) ([]domain.TenantUsage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []domain.TenantUsage
+	for month, u := range r.usage[tenantKey] {
+		if !month.Before(from) && !month.After(to) {
+			out = append(out, u)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Month.After(out[j].Month) })
+	return out, nil
+}
+
+// Hold stores a held fan-out.
+func (r *Quotas) Hold(_ context.Context, tenantKey string, input domain.FanoutInput, recipients int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	r.held = append(r.held, heldFanout{domain.HeldFanout{ID: r.nextID, Input: input, Recipients: recipients}, tenantKey})
+	return nil
+}
+
+// HeldTenants returns the tenants with held fan-outs.
+func (r *Quotas) HeldTenants(context.Context) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []string
+	for _, h := range r.held {
+		if !slices.Contains(out, h.tenantKey) {
+			out = append(out, h.tenantKey)
+		}
+	}
+	return out, nil
+}
+
+// Held returns up to limit held fan-outs of tenantKey, oldest first.
+func (r *Quotas) Held(_ context.Context, tenantKey string, limit int) ([]domain.HeldFanout, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []domain.HeldFanout
+	for _, h := range r.held {
+		if h.tenantKey == tenantKey && len(out) < limit {
+			out = append(out, h.HeldFanout)
+		}
+	}
+	return out, nil
+}
+
+// CountHeld returns the number of held fan-outs of tenantKey.
+func (r *Quotas) CountHeld(_ context.Context, tenantKey string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int64
+	for _, h := range r.held {
+		if h.tenantKey == tenantKey {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Release drops a held fan-out.
+func (r *Quotas) Release(_ context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.held = slices.DeleteFunc(r.held, func(h heldFanout) bool { return h.ID == id })
+	return nil
+}
@@ -0,0 +1,60 @@
+package testsupport
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// Reactions is an in-memory domain.ReactionRepository. Safe for concurrent use.
+type Reactions struct {
+	mu        sync.Mutex
+	reactions []domain.Reaction
+}
+
+// NewReactions creates an empty Reactions store.
+func NewReactions() *Reactions {
+	return &Reactions{}
+}
+
+// Create stores r; nil when the user has already reacted to the notification.
+func (r *Reactions) Create(_ context.Context, reaction domain.Reaction) (*domain.Reaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, x := range r.reactions {
+		if x.NotificationID == reaction.NotificationID && x.UserID == reaction.UserID {
+			return nil, nil
+		}
+	}
+	reaction.ID = uuid.New()
+	r.reactions = append(r.reactions, reaction)
+	return &reaction, nil
+}
+
+// ListByNotification returns the reactions to a notification.
+func (r *Reactions) ListByNotification(_ context.Context, notificationID uuid.UUID) ([]domain.Reaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []domain.Reaction
+	for _, x := range r.reactions {
+		if x.NotificationID == notificationID {
+			out = append(out, x)
+		}
+	}
+	return out, nil
+}
+
+// ListBySourceEvent returns a tenant's reactions to notifications of a source event.
+func (r *Reactions) ListBySourceEvent(_ context.Context, tenantKey, sourceEventID string) ([]domain.Reaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []domain.Reaction
+	for _, x := range r.reactions {
+		if x.TenantKey == tenantKey && x.SourceEventID == sourceEventID {
+			out = append(out, x)
+		}
+	}
+	return out, nil
+}
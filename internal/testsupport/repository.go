@@ -0,0 +1,744 @@
+package testsupport
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// Repository is an in-memory domain.Repository. It keeps per-user
+// notifications, fan-out-on-read broadcasts with per-user read state, and the
+// delivery outbox. Safe for concurrent use.
+//
+// It differs from the postgres repository where the difference does not matter
+// to the service: there is no archive tier (ArchiveOverflow moves nothing),
+// partitions are not modelled, retention policies are ignored by
+// PurgeOlderThan, List does not support AsOf, and templated text is stored as
+// given.
+type Repository struct {
+	mu         sync.Mutex
+	clock      domain.Clock
+	records    []*record
+	outbox     []*outboxEntry
+	nextOutbox int64
+	batches    []int
+}
+
+// record is a stored notification; per-user rows have exactly one recipient
+// state, broadcasts gain one per user who touched them.
+type record struct {
+	n         domain.Notification
+	broadcast bool
+	states    map[recipient]*recipientState
+}
+
+type recipient struct{ tenantKey, userID string }
+
+type recipientState struct {
+	readAt       *time.Time
+	deleted      bool
+	pinned       bool
+	snoozedUntil *time.Time
+}
+
+type outboxEntry struct {
+	id       int64
+	attempts int
+	due      time.Time
+	n        domain.Notification
+}
+
+// NewRepository creates an empty Repository using the system clock.
+func NewRepository() *Repository {
+	return &Repository{clock: domain.SystemClock{}}
+}
+
+// SetClock replaces the clock used for read times, outbox leases, snoozes and
+// purge cutoffs.
+func (r *Repository) SetClock(c domain.Clock) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clock = c
+}
+
+// Notifications returns copies of the stored per-user notifications in
+// insertion order. Broadcasts are not included.
+func (r *Repository) Notifications() []*domain.Notification {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*domain.Notification
+	for _, rec := range r.records {
+		if !rec.broadcast {
+			out = append(out, rec.view(rec.n.TenantKey, rec.n.UserID))
+		}
+	}
+	return out
+}
+
+// Broadcasts returns copies of the stored broadcasts in insertion order.
+func (r *Repository) Broadcasts() []*domain.Notification {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*domain.Notification
+	for _, rec := range r.records {
+		if rec.broadcast {
+			n := rec.n
+			out = append(out, &n)
+		}
+	}
+	return out
+}
+
+// BatchSizes returns the number of inputs of each BatchCreate call, in order.
+func (r *Repository) BatchSizes() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.batches)
+}
+
+// OutboxLen returns the number of unacknowledged outbox entries.
+func (r *Repository) OutboxLen() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.outbox)
+}
+
+// Create stores a notification with its outbox entry.
+func (r *Repository) Create(_ context.Context, input domain.CreateNotificationInput) (*domain.Notification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.insertLocked(input), nil
+}
+
+// BatchCreate stores notifications with their outbox entries.
+func (r *Repository) BatchCreate(_ context.Context, inputs []domain.CreateNotificationInput) (domain.BatchResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, len(inputs))
+	var result domain.BatchResult
+	for i, in := range inputs {
+		if n := r.insertLocked(in); n != nil {
+			result.Inserted = append(result.Inserted, n)
+		} else {
+			result.Duplicates = append(result.Duplicates, i)
+		}
+	}
+	return result, nil
+}
+
+// insertLocked stores in, or returns nil when its SourceEventID is already
+// stored for the same tenant and user.
+func (r *Repository) insertLocked(in domain.CreateNotificationInput) *domain.Notification {
+	if in.SourceEventID != "" {
+		for _, rec := range r.records {
+			if !rec.broadcast && rec.n.SourceEventID == in.SourceEventID &&
+				rec.n.TenantKey == in.TenantKey && rec.n.UserID == in.UserID {
+				return nil
+			}
+		}
+	}
+	rec := &record{
+		n: domain.Notification{
+			ID:            uuid.New(),
+			TenantKey:     in.TenantKey,
+			UserID:        in.UserID,
+			Type:          in.Type,
+			Category:      in.Category,
+			Priority:      in.Priority.OrDefault(),
+			Title:         in.Title,
+			Body:          in.Body,
+			Metadata:      in.Metadata,
+			CreatedAt:     r.clock.Now(),
+			SourceEventID: in.SourceEventID,
+		},
+		states: map[recipient]*recipientState{{in.TenantKey, in.UserID}: {}},
+	}
+	r.records = append(r.records, rec)
+	r.nextOutbox++
+	r.outbox = append(r.outbox, &outboxEntry{id: r.nextOutbox, due: rec.n.CreatedAt, n: rec.n})
+	return rec.view(in.TenantKey, in.UserID)
+}
+
+// CreateBroadcast stores a broadcast once for its tenant, or for the platform.
+func (r *Repository) CreateBroadcast(_ context.Context, input domain.BroadcastInput) (*domain.Notification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if input.SourceEventID != "" {
+		for _, rec := range r.records {
+			if rec.broadcast && rec.n.SourceEventID == input.SourceEventID && rec.n.TenantKey == input.TenantKey {
+				return nil, nil
+			}
+		}
+	}
+	rec := &record{
+		n: domain.Notification{
+			ID:            uuid.New(),
+			TenantKey:     input.TenantKey,
+			Type:          input.Type,
+			Category:      input.Category,
+			Priority:      input.Priority.OrDefault(),
+			Title:         input.Title,
+			Body:          input.Body,
+			Metadata:      input.Metadata,
+			CreatedAt:     r.clock.Now(),
+			SourceEventID: input.SourceEventID,
+		},
+		broadcast: true,
+		states:    make(map[recipient]*recipientState),
+	}
+	r.records = append(r.records, rec)
+	n := rec.n
+	return &n, nil
+}
+
+// ClaimOutbox leases up to limit due outbox entries, oldest first.
+func (r *Repository) ClaimOutbox(_ context.Context, limit int, lease time.Duration) ([]domain.OutboxEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.clock.Now()
+	var out []domain.OutboxEntry
+	for _, e := range r.outbox {
+		if len(out) == limit {
+			break
+		}
+		if e.due.After(now) {
+			continue
+		}
+		e.attempts++
+		e.due = now.Add(lease)
+		n := e.n
+		out = append(out, domain.OutboxEntry{ID: e.id, Attempts: e.attempts, Notification: &n})
+	}
+	return out, nil
+}
+
+// AckOutbox removes dispatched outbox entries.
+func (r *Repository) AckOutbox(_ context.Context, ids []int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.outbox = slices.DeleteFunc(r.outbox, func(e *outboxEntry) bool { return slices.Contains(ids, e.id) })
+	return nil
+}
+
+// List returns the user's visible notifications matching filter, pinned first,
+// then newest first.
+func (r *Repository) List(_ context.Context, filter domain.NotificationFilter) ([]*domain.Notification, error) {
+	if filter.AsOf != nil {
+		return nil, errors.New("testsupport: as-of listing is not supported")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*domain.Notification
+	for _, n := range r.inboxLocked(filter.TenantKey, filter.UserID) {
+		if matches(n, filter) {
+			out = append(out, n)
+		}
+	}
+	slices.SortStableFunc(out, func(a, b *domain.Notification) int {
+		if a.Pinned != b.Pinned {
+			if a.Pinned {
+				return -1
+			}
+			return 1
+		}
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	if filter.Offset > 0 {
+		out = out[min(filter.Offset, len(out)):]
+	}
+	if filter.Limit > 0 && len(out) > filter.Limit {
+		out = out[:filter.Limit]
+	}
+	return out, nil
+}
+
+func matches(n *domain.Notification, f domain.NotificationFilter) bool {
+	switch {
+	case f.IsRead != nil && n.IsRead != *f.IsRead:
+		return false
+	case f.Pinned != nil && n.Pinned != *f.Pinned:
+		return false
+	case f.Type != "" && n.Type != f.Type:
+		return false
+	case f.EntityType != "" && (n.Metadata["entityType"] != f.EntityType || n.Metadata["entityId"] != f.EntityID):
+		return false
+	}
+	if prefix, ok := strings.CutSuffix(f.Category, ".*"); ok {
+		return strings.HasPrefix(n.Category, prefix+".")
+	}
+	return f.Category == "" || n.Category == f.Category
+}
+
+// GetByID returns a per-user notification, or domain.ErrNotificationNotFound.
+func (r *Repository) GetByID(_ context.Context, id uuid.UUID) (*domain.Notification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rec := range r.records {
+		if !rec.broadcast && rec.n.ID == id {
+			return rec.view(rec.n.TenantKey, rec.n.UserID), nil
+		}
+	}
+	return nil, domain.ErrNotificationNotFound
+}
+
+// MarkRead marks a notification, or a broadcast for this user, as read.
+func (r *Repository) MarkRead(_ context.Context, id uuid.UUID, tenantKey, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st := r.stateLocked(id, tenantKey, userID)
+	if st == nil || st.readAt != nil {
+		return fmt.Errorf("notification not found or already read")
+	}
+	now := r.clock.Now()
+	st.readAt = &now
+	return nil
+}
+
+// ApplyReadStates records offline reads; the earliest read time wins.
+func (r *Repository) ApplyReadStates(_ context.Context, tenantKey, userID string, states []domain.ReadState) ([]uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var newlyRead []uuid.UUID
+	for _, rs := range states {
+		st := r.stateLocked(rs.ID, tenantKey, userID)
+		if st == nil {
+			continue
+		}
+		readAt := rs.ReadAt
+		if st.readAt == nil {
+			newlyRead = append(newlyRead, rs.ID)
+		} else if !readAt.Before(*st.readAt) {
+			continue
+		}
+		st.readAt = &readAt
+	}
+	return newlyRead, nil
+}
+
+// MarkAllRead marks every unread notification of the user as read.
+func (r *Repository) MarkAllRead(_ context.Context, tenantKey, userID string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.clock.Now()
+	var marked int64
+	for _, rec := range r.records {
+		if st := rec.state(tenantKey, userID, true); st != nil && st.readAt == nil {
+			st.readAt = &now
+			marked++
+		}
+	}
+	return marked, nil
+}
+
+// Delete removes a notification, or hides a broadcast from this user.
+func (r *Repository) Delete(_ context.Context, id uuid.UUID, tenantKey, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, rec := range r.records {
+		if rec.n.ID != id {
+			continue
+		}
+		st := rec.state(tenantKey, userID, true)
+		if st == nil {
+			break
+		}
+		if !rec.broadcast {
+			r.records = slices.Delete(r.records, i, i+1)
+			return nil
+		}
+		st.deleted = true
+		if st.readAt == nil {
+			now := r.clock.Now()
+			st.readAt = &now
+		}
+		return nil
+	}
+	return fmt.Errorf("notification not found")
+}
+
+// CountUnread counts the user's unread, unsnoozed notifications.
+func (r *Repository) CountUnread(_ context.Context, tenantKey, userID string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var unread int64
+	for _, n := range r.inboxLocked(tenantKey, userID) {
+		if !n.IsRead {
+			unread++
+		}
+	}
+	return unread, nil
+}
+
+// Export streams the selected notifications oldest first. Broadcasts are only
+// included when f.UserID is set.
+func (r *Repository) Export(_ context.Context, f domain.ExportFilter, fn func(*domain.Notification) error) error {
+	r.mu.Lock()
+	var out []*domain.Notification
+	for _, rec := range r.records {
+		if rec.broadcast {
+			if f.UserID == "" || !rec.visibleTo(f.TenantKey) {
+				continue
+			}
+			if st := rec.states[recipient{f.TenantKey, f.UserID}]; st != nil && st.deleted {
+				continue
+			}
+			out = append(out, rec.view(f.TenantKey, f.UserID))
+			continue
+		}
+		if rec.n.TenantKey != f.TenantKey || (f.UserID != "" && rec.n.UserID != f.UserID) {
+			continue
+		}
+		out = append(out, rec.view(rec.n.TenantKey, rec.n.UserID))
+	}
+	r.mu.Unlock()
+
+	slices.SortStableFunc(out, func(a, b *domain.Notification) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	for _, n := range out {
+		if (f.From != nil && n.CreatedAt.Before(*f.From)) || (f.To != nil && !n.CreatedAt.Before(*f.To)) {
+			continue
+		}
+		if err := fn(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CountGroups counts the user's inbox per type and priority.
+func (r *Repository) CountGroups(_ context.Context, tenantKey, userID string) ([]domain.CountGroup, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	type key struct {
+		t domain.NotificationType
+		p domain.Priority
+	}
+	groups := make(map[key]*domain.CountGroup)
+	for _, n := range r.inboxLocked(tenantKey, userID) {
+		k := key{n.Type, n.Priority}
+		g := groups[k]
+		if g == nil {
+			g = &domain.CountGroup{Type: n.Type, Priority: n.Priority}
+			groups[k] = g
+		}
+		g.Total++
+		if !n.IsRead {
+			g.Unread++
+		}
+	}
+	out := make([]domain.CountGroup, 0, len(groups))
+	for _, k := range slices.SortedFunc(maps.Keys(groups), func(a, b key) int {
+		return cmp.Or(cmp.Compare(a.t, b.t), cmp.Compare(a.p, b.p))
+	}) {
+		out = append(out, *groups[k])
+	}
+	return out, nil
+}
+
+// Snooze hides a notification from the user's inbox until until.
+func (r *Repository) Snooze(_ context.Context, id uuid.UUID, tenantKey, userID string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st := r.stateLocked(id, tenantKey, userID)
+	if st == nil {
+		return domain.ErrNotificationNotFound
+	}
+	st.snoozedUntil = &until
+	return nil
+}
+
+// ListSnoozed returns the user's snoozed notifications, soonest wake-up first.
+func (r *Repository) ListSnoozed(_ context.Context, tenantKey, userID string) ([]*domain.Notification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*domain.Notification
+	for _, rec := range r.records {
+		if st := rec.state(tenantKey, userID, false); st != nil && !st.deleted && st.snoozedUntil != nil {
+			out = append(out, rec.view(tenantKey, userID))
+		}
+	}
+	slices.SortStableFunc(out, func(a, b *domain.Notification) int { return a.SnoozedUntil.Compare(*b.SnoozedUntil) })
+	return out, nil
+}
+
+// WakeSnoozed clears up to limit snoozes that are due and returns the woken
+// notifications.
+func (r *Repository) WakeSnoozed(_ context.Context, limit int) ([]*domain.Notification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.clock.Now()
+	var out []*domain.Notification
+	for _, rec := range r.records {
+		for who, st := range rec.states {
+			if len(out) == limit {
+				return out, nil
+			}
+			if st.deleted || st.snoozedUntil == nil || st.snoozedUntil.After(now) {
+				continue
+			}
+			st.snoozedUntil = nil
+			out = append(out, rec.view(who.tenantKey, who.userID))
+		}
+	}
+	return out, nil
+}
+
+// SetPinned pins or unpins a notification for the user.
+func (r *Repository) SetPinned(_ context.Context, id uuid.UUID, tenantKey, userID string, pinned bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st := r.stateLocked(id, tenantKey, userID)
+	if st == nil {
+		return domain.ErrNotificationNotFound
+	}
+	st.pinned = pinned
+	return nil
+}
+
+// PurgeOlderThan deletes unpinned notifications created more than days ago.
+// Retention policies are not applied.
+func (r *Repository) PurgeOlderThan(_ context.Context, days int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cutoff := r.clock.Now().AddDate(0, 0, -days)
+	return r.deleteLocked(func(rec *record) bool { return rec.n.CreatedAt.Before(cutoff) }), nil
+}
+
+// EnsurePartitions does nothing: there are no partitions.
+func (r *Repository) EnsurePartitions(context.Context, int) (int, error) {
+	return 0, nil
+}
+
+// PurgeExpired deletes unpinned notifications whose expiry has passed.
+func (r *Repository) PurgeExpired(context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.clock.Now()
+	return r.deleteLocked(func(rec *record) bool {
+		at, ok := domain.ExpiresAt(rec.n.Metadata)
+		return ok && !at.After(now)
+	}), nil
+}
+
+// CountPurgeable counts what PurgeOlderThan(days) and PurgeExpired would delete.
+func (r *Repository) CountPurgeable(_ context.Context, days int) (retention, expired int64, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.clock.Now()
+	cutoff := now.AddDate(0, 0, -days)
+records:
+	for _, rec := range r.records {
+		for _, st := range rec.states {
+			if st.pinned {
+				continue records
+			}
+		}
+		if rec.n.CreatedAt.Before(cutoff) {
+			retention++
+		}
+		if at, ok := domain.ExpiresAt(rec.n.Metadata); ok && !at.After(now) {
+			expired++
+		}
+	}
+	return retention, expired, nil
+}
+
+// ArchiveOverflow moves nothing: there is no archive tier.
+func (r *Repository) ArchiveOverflow(context.Context, int, int) (int64, error) {
+	return 0, nil
+}
+
+// EvictOverCap deletes the oldest read, unpinned notifications of users holding
+// more than capacity, at most limit in total.
+func (r *Repository) EvictOverCap(_ context.Context, capacity, limit int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	held := make(map[recipient]int)
+	for _, rec := range r.records {
+		if !rec.broadcast {
+			held[recipient{rec.n.TenantKey, rec.n.UserID}]++
+		}
+	}
+	var evicted int64
+	r.records = slices.DeleteFunc(r.records, func(rec *record) bool {
+		who := recipient{rec.n.TenantKey, rec.n.UserID}
+		st := rec.states[who]
+		if rec.broadcast || evicted == int64(limit) || held[who] <= capacity || st.readAt == nil || st.pinned {
+			return false
+		}
+		held[who]--
+		evicted++
+		return true
+	})
+	return evicted, nil
+}
+
+// FindCompactionRuns returns, per user, runs of consecutive read, unpinned
+// LOW-priority notifications created before cutoff holding at least minRun items.
+func (r *Repository) FindCompactionRuns(_ context.Context, cutoff time.Time, minRun int) ([]domain.CompactionRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	byUser := make(map[recipient][]*record)
+	var users []recipient
+	for _, rec := range r.records {
+		if rec.broadcast || !rec.n.CreatedAt.Before(cutoff) {
+			continue
+		}
+		who := recipient{rec.n.TenantKey, rec.n.UserID}
+		if byUser[who] == nil {
+			users = append(users, who)
+		}
+		byUser[who] = append(byUser[who], rec)
+	}
+
+	var runs []domain.CompactionRun
+	for _, who := range users {
+		recs := byUser[who]
+		slices.SortStableFunc(recs, func(a, b *record) int { return a.n.CreatedAt.Compare(b.n.CreatedAt) })
+		var run []*record
+		flush := func() {
+			if len(run) >= minRun {
+				c := domain.CompactionRun{TenantKey: who.tenantKey, UserID: who.userID,
+					From: run[0].n.CreatedAt, To: run[len(run)-1].n.CreatedAt, TypeCounts: make(map[domain.NotificationType]int)}
+				for _, rec := range run {
+					c.IDs = append(c.IDs, rec.n.ID)
+					c.TypeCounts[rec.n.Type]++
+				}
+				runs = append(runs, c)
+			}
+			run = nil
+		}
+		for _, rec := range recs {
+			st := rec.states[who]
+			if rec.n.Priority == domain.PriorityLow && st.readAt != nil && !st.pinned {
+				run = append(run, rec)
+			} else {
+				flush()
+			}
+		}
+		flush()
+	}
+	return runs, nil
+}
+
+// CompactRun replaces the notifications of run with an already-read summary.
+func (r *Repository) CompactRun(_ context.Context, run domain.CompactionRun, summary domain.CreateNotificationInput) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = slices.DeleteFunc(r.records, func(rec *record) bool {
+		return !rec.broadcast && rec.n.TenantKey == run.TenantKey && rec.n.UserID == run.UserID && slices.Contains(run.IDs, rec.n.ID)
+	})
+	rec := &record{
+		n: domain.Notification{
+			ID:        uuid.New(),
+			TenantKey: summary.TenantKey,
+			UserID:    summary.UserID,
+			Type:      summary.Type,
+			Category:  summary.Category,
+			Priority:  summary.Priority.OrDefault(),
+			Title:     summary.Title,
+			Body:      summary.Body,
+			Metadata:  summary.Metadata,
+			CreatedAt: run.To,
+		},
+		states: make(map[recipient]*recipientState),
+	}
+	now := r.clock.Now()
+	rec.states[recipient{summary.TenantKey, summary.UserID}] = &recipientState{readAt: &now}
+	r.records = append(r.records, rec)
+	return nil
+}
+
+// inboxLocked returns the user's undeleted, unsnoozed notifications and
+// broadcasts.
+func (r *Repository) inboxLocked(tenantKey, userID string) []*domain.Notification {
+	now := r.clock.Now()
+	var out []*domain.Notification
+	for _, rec := range r.records {
+		if rec.broadcast && !rec.visibleTo(tenantKey) {
+			continue
+		}
+		st := rec.state(tenantKey, userID, false)
+		if !rec.broadcast && st == nil {
+			continue
+		}
+		if st != nil && (st.deleted || (st.snoozedUntil != nil && st.snoozedUntil.After(now))) {
+			continue
+		}
+		out = append(out, rec.view(tenantKey, userID))
+	}
+	return out
+}
+
+// stateLocked returns the user's state of notification id, or nil when the
+// user cannot see it.
+func (r *Repository) stateLocked(id uuid.UUID, tenantKey, userID string) *recipientState {
+	for _, rec := range r.records {
+		if rec.n.ID == id {
+			if st := rec.state(tenantKey, userID, true); st != nil && !st.deleted {
+				return st
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// deleteLocked deletes the unpinned per-user notifications and broadcasts
+// matching del and returns how many were deleted. A broadcast pinned by any
+// user is kept.
+func (r *Repository) deleteLocked(del func(*record) bool) int64 {
+	var deleted int64
+	r.records = slices.DeleteFunc(r.records, func(rec *record) bool {
+		for _, st := range rec.states {
+			if st.pinned {
+				return false
+			}
+		}
+		if del(rec) {
+			deleted++
+			return true
+		}
+		return false
+	})
+	return deleted
+}
+
+func (rec *record) visibleTo(tenantKey string) bool {
+	return rec.n.TenantKey == "" || rec.n.TenantKey == tenantKey
+}
+
+// state returns the user's state of rec. For broadcasts visible to the user, a
+// missing state is created when create is set.
+func (rec *record) state(tenantKey, userID string, create bool) *recipientState {
+	who := recipient{tenantKey, userID}
+	st := rec.states[who]
+	if st == nil && create && rec.broadcast && rec.visibleTo(tenantKey) {
+		st = &recipientState{}
+		rec.states[who] = st
+	}
+	return st
+}
+
+// view returns a copy of rec as the user sees it.
+func (rec *record) view(tenantKey, userID string) *domain.Notification {
+	n := rec.n
+	n.TenantKey, n.UserID = tenantKey, userID
+	if st := rec.states[recipient{tenantKey, userID}]; st != nil {
+		n.IsRead = st.readAt != nil
+		n.ReadAt = st.readAt
+		n.Pinned = st.pinned
+		n.SnoozedUntil = st.snoozedUntil
+	}
+	return &n
+}
+
+var _ domain.Repository = (*Repository)(nil)
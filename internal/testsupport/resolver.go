@@ -0,0 +1,119 @@
+// Package testsupport provides in-memory implementations of the service's
+// ports for tests: a notification Repository, an SSE Hub that records what it
+// pushes, an IAM Resolver and a preference store backed by maps.
+//
+// They satisfy application.SSEHub and application.IAMResolver structurally;
+// the package only imports domain so that tests of the application package can
+// use it.
+package testsupport
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// Resolver is an in-memory IAM resolver. Users, roles and groups are
+// registered per tenant; lookups of unknown tenants, roles or groups return no
+// users. Safe for concurrent use.
+type Resolver struct {
+	mu      sync.Mutex
+	tenants map[string][]string
+	roles   map[string]map[string][]string
+	groups  map[string]map[string][]string
+	err     error
+}
+
+// NewResolver creates an empty Resolver.
+func NewResolver() *Resolver {
+	return &Resolver{
+		tenants: make(map[string][]string),
+		roles:   make(map[string]map[string][]string),
+		groups:  make(map[string]map[string][]string),
+	}
+}
+
+// AddUsers registers active users of a tenant.
+func (r *Resolver) AddUsers(tenantKey string, userIDs ...string) *Resolver {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tenants[tenantKey] = append(r.tenants[tenantKey], userIDs...)
+	return r
+}
+
+// AddRole registers holders of role within a tenant.
+func (r *Resolver) AddRole(tenantKey, role string, userIDs ...string) *Resolver {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	addMember(r.roles, tenantKey, role, userIDs)
+	return r
+}
+
+// AddGroup registers members of group within a tenant; group is matched
+// verbatim, so register a group under both its ID and its path to resolve
+// either.
+func (r *Resolver) AddGroup(tenantKey, group string, userIDs ...string) *Resolver {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	addMember(r.groups, tenantKey, group, userIDs)
+	return r
+}
+
+// SetError makes every lookup fail with err; nil restores normal lookups.
+func (r *Resolver) SetError(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.err = err
+}
+
+// UsersByTenant returns the users registered with AddUsers.
+func (r *Resolver) UsersByTenant(_ context.Context, tenantKey string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return nil, r.err
+	}
+	return slices.Clone(r.tenants[tenantKey]), nil
+}
+
+// UsersByRole returns the users registered with AddRole.
+func (r *Resolver) UsersByRole(_ context.Context, tenantKey, roleName string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return nil, r.err
+	}
+	return slices.Clone(r.roles[tenantKey][roleName]), nil
+}
+
+// UsersByGroup returns the users registered with AddGroup.
+func (r *Resolver) UsersByGroup(_ context.Context, tenantKey, group string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return nil, r.err
+	}
+	return slices.Clone(r.groups[tenantKey][group]), nil
+}
+
+// AllActiveUsers returns the users registered with AddUsers, by tenant.
+func (r *Resolver) AllActiveUsers(context.Context) (map[string][]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return nil, r.err
+	}
+	all := maps.Clone(r.tenants)
+	for tk, uids := range all {
+		all[tk] = slices.Clone(uids)
+	}
+	return all, nil
+}
+
+func addMember(m map[string]map[string][]string, tenantKey, name string, userIDs []string) {
+	if m[tenantKey] == nil {
+		m[tenantKey] = make(map[string][]string)
+	}
+	m[tenantKey][name] = append(m[tenantKey][name], userIDs...)
+}
@@ -0,0 +1,50 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"vn.io.arda/notification/internal/transport/mw"
+)
+
+// roleNames are the roles gating the admin routes; NewRouter sets them from
+// SecurityConfig.
+type roleNames struct {
+	admin, auditor, platformAdmin string
+}
+
+// isPlatformAdmin reports whether the caller may act on any tenant.
+func (h *Handler) isPlatformAdmin(c echo.Context) bool {
+	return mw.HasRole(c, h.roles.platformAdmin)
+}
+
+// authorizeTenant checks that the caller may administer tenantKey: its own
+// tenant with the admin role, another tenant, or every tenant when tenantKey
+// is empty, with the platform admin role.
+func (h *Handler) authorizeTenant(c echo.Context, tenantKey string) error {
+	own, _ := c.Get("tenantKey").(string)
+	if tenantKey != "" && tenantKey == own {
+		if mw.HasRole(c, h.roles.admin, h.roles.platformAdmin) {
+			return nil
+		}
+		return echo.NewHTTPError(http.StatusForbidden, "missing role "+h.roles.admin)
+	}
+	if h.isPlatformAdmin(c) {
+		return nil
+	}
+	return echo.NewHTTPError(http.StatusForbidden, "missing role "+h.roles.platformAdmin+" to act on other tenants")
+}
+
+// tenantFilter returns the tenant an admin read is restricted to: tenantKey
+// as requested (empty for every tenant) for platform admins, the caller's own
+// tenant for others, who may not name another one.
+func (h *Handler) tenantFilter(c echo.Context, tenantKey string) (string, error) {
+	if h.isPlatformAdmin(c) {
+		return tenantKey, nil
+	}
+	own, _ := c.Get("tenantKey").(string)
+	if tenantKey != "" && tenantKey != own {
+		return "", echo.NewHTTPError(http.StatusForbidden, "missing role "+h.roles.platformAdmin+" to read other tenants")
+	}
+	return own, nil
+}
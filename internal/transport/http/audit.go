@@ -0,0 +1,54 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// ListAudit GET /notifications/admin/audit?notification_id=&user=&source_event_id=&action=&from=&to=&limit=&offset=
+// Returns the tenant's notification lifecycle audit log, newest first.
+func (h *Handler) ListAudit(c echo.Context) error {
+	tenantKey, requester := mustClaims(c)
+
+	f := domain.AuditFilter{
+		TenantKey:     tenantKey,
+		UserID:        c.QueryParam("user"),
+		SourceEventID: c.QueryParam("source_event_id"),
+		Action:        domain.AuditAction(c.QueryParam("action")),
+		Limit:         parseIntQuery(c, "limit", 50),
+		Offset:        parseIntQuery(c, "offset", 0),
+	}
+	if v := c.QueryParam("notification_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid notification_id")
+		}
+		f.NotificationID = &id
+	}
+	var err error
+	if f.From, err = parseTimeQuery(c, "from"); err != nil {
+		return err
+	}
+	if f.To, err = parseTimeQuery(c, "to"); err != nil {
+		return err
+	}
+	if err := f.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	entries, err := h.svc.ListAudit(c.Request().Context(), f, requester)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if entries == nil {
+		entries = []domain.AuditEntry{}
+	}
+	return c.JSON(http.StatusOK, map[string]any{
+		"data":   entries,
+		"limit":  f.Limit,
+		"offset": f.Offset,
+	})
+}
@@ -0,0 +1,94 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// KafkaConsumer is the Kafka consumer of this instance, controlled by the
+// /notifications/admin/consumer endpoints. Implemented by kafka.Consumer.
+type KafkaConsumer interface {
+	Topics() []string
+	PausedTopics() []string
+	// SyncPauses applies the stored pauses right away; other instances pick
+	// them up on their next sync.
+	SyncPauses(ctx context.Context) error
+}
+
+// SetKafkaConsumer enables the consumer status of this instance and applies
+// pauses to it immediately.
+func (h *Handler) SetKafkaConsumer(kc KafkaConsumer) {
+	h.consumer = kc
+}
+
+// syncConsumer applies pause changes to this instance's consumer, best-effort.
+func (h *Handler) syncConsumer(ctx context.Context) {
+	if h.consumer == nil {
+		return
+	}
+	if err := h.consumer.SyncPauses(ctx); err != nil {
+		log.Warn().Err(err).Msg("kafka pause sync after admin change failed")
+	}
+}
+
+// PauseConsumer POST /notifications/admin/consumer/pause
+// Body: { "topic": "crm-events", "reason": "upstream bug INC-42" }
+// Pauses consumption of the topic on every instance; its records stay in Kafka.
+func (h *Handler) PauseConsumer(c echo.Context) error {
+	_, requester := mustClaims(c)
+	var body struct {
+		Topic  string `json:"topic"`
+		Reason string `json:"reason"`
+	}
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	p, err := h.svc.PauseConsumer(c.Request().Context(), body.Topic, body.Reason, requester)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	h.syncConsumer(c.Request().Context())
+	return c.JSON(http.StatusOK, map[string]any{"data": p})
+}
+
+// ResumeConsumer POST /notifications/admin/consumer/resume
+// Body: { "topic": "crm-events" }
+func (h *Handler) ResumeConsumer(c echo.Context) error {
+	_, requester := mustClaims(c)
+	var body struct {
+		Topic string `json:"topic"`
+	}
+	if err := c.Bind(&body); err != nil || body.Topic == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "topic is required")
+	}
+	if err := h.svc.ResumeConsumer(c.Request().Context(), body.Topic, requester); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	h.syncConsumer(c.Request().Context())
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ConsumerStatus GET /notifications/admin/consumer/status
+// Returns the paused topics and, for the instance serving the request, its
+// subscription and the pauses it has applied.
+func (h *Handler) ConsumerStatus(c echo.Context) error {
+	pauses, err := h.svc.ListConsumerPauses(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if pauses == nil {
+		pauses = []domain.ConsumerPause{}
+	}
+	data := map[string]any{"pauses": pauses}
+	if h.consumer != nil {
+		data["instance"] = map[string]any{
+			"topics":        h.consumer.Topics(),
+			"paused_topics": h.consumer.PausedTopics(),
+		}
+	}
+	return c.JSON(http.StatusOK, map[string]any{"region": h.region, "data": data})
+}
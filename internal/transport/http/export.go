@@ -0,0 +1,194 @@
+package http
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// exportFlushEvery is how many exported rows are written between flushes, so
+// large exports reach the client as a steady chunked stream.
+const exportFlushEvery = 500
+
+// Export GET /notifications/export?format=csv|json&from=&to=
+// Streams the caller's notification history, oldest first.
+func (h *Handler) Export(c echo.Context) error {
+	tenantKey, userID := mustClaims(c)
+	return h.export(c, domain.ExportFilter{TenantKey: tenantKey, UserID: userID}, userID)
+}
+
+// AdminExport GET /notifications/admin/export?format=csv|json&from=&to=&user=
+// Streams the tenant's notification history, or one user's with ?user=.
+func (h *Handler) AdminExport(c echo.Context) error {
+	tenantKey, requester := mustClaims(c)
+	return h.export(c, domain.ExportFilter{TenantKey: tenantKey, UserID: c.QueryParam("user")}, requester)
+}
+
+func (h *Handler) export(c echo.Context, f domain.ExportFilter, requester string) error {
+	format := c.QueryParam("format")
+	if format == "" {
+		format = "json"
+	}
+	w := c.Response()
+	var enc exportEncoder
+	switch format {
+	case "csv":
+		enc = &csvExport{w: csv.NewWriter(w)}
+	case "json":
+		enc = &jsonExport{w: w}
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "format must be csv or json")
+	}
+	var err error
+	if f.From, err = parseTimeQuery(c, "from"); err != nil {
+		return err
+	}
+	if f.To, err = parseTimeQuery(c, "to"); err != nil {
+		return err
+	}
+	if err := f.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// The response is committed with the first row, so a failing query can still
+	// be reported with a proper status.
+	rows := 0
+	begin := func() error {
+		contentType := echo.MIMEApplicationJSON
+		if format == "csv" {
+			contentType = "text/csv; charset=utf-8"
+		}
+		w.Header().Set(echo.HeaderContentType, contentType)
+		w.Header().Set(echo.HeaderContentDisposition,
+			fmt.Sprintf("attachment; filename=%q", "notifications-"+f.TenantKey+"."+format))
+		w.WriteHeader(http.StatusOK)
+		return enc.begin()
+	}
+	err = h.svc.Export(c.Request().Context(), f, requestLocale(c), requester, func(n *domain.Notification) error {
+		if rows == 0 {
+			if err := begin(); err != nil {
+				return err
+			}
+		}
+		if err := enc.write(n); err != nil {
+			return err
+		}
+		rows++
+		if rows%exportFlushEvery == 0 {
+			enc.flush()
+			w.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		if !w.Committed {
+			return echo.ErrInternalServerError
+		}
+		// Too late for a status: cut the stream short so the client sees a truncated body.
+		log.Error().Err(err).Str("tenant", f.TenantKey).Int("rows", rows).Msg("notification export aborted")
+		return err
+	}
+	if rows == 0 {
+		if err := begin(); err != nil {
+			return err
+		}
+	}
+	return enc.end()
+}
+
+// parseTimeQuery parses an optional RFC 3339 query parameter.
+func parseTimeQuery(c echo.Context, name string) (*time.Time, error) {
+	v := c.QueryParam(name)
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, name+" must be an RFC 3339 timestamp")
+	}
+	return &t, nil
+}
+
+// exportEncoder writes exported notifications in one format.
+type exportEncoder interface {
+	begin() error
+	write(n *domain.Notification) error
+	flush()
+	end() error
+}
+
+// csvExportHeader lists the exported columns; metadata is a JSON object.
+var csvExportHeader = []string{"id", "tenant_key", "user_id", "type", "category", "priority",
+	"title", "body", "is_read", "read_at", "created_at", "source_event_id", "metadata"}
+
+type csvExport struct {
+	w *csv.Writer
+}
+
+func (e *csvExport) begin() error {
+	return e.w.Write(csvExportHeader)
+}
+
+func (e *csvExport) write(n *domain.Notification) error {
+	var readAt, metadata string
+	if n.ReadAt != nil {
+		readAt = n.ReadAt.UTC().Format(time.RFC3339Nano)
+	}
+	if n.Metadata != nil {
+		b, _ := json.Marshal(n.Metadata)
+		metadata = string(b)
+	}
+	return e.w.Write([]string{
+		domain.FormatID(n.ID), n.TenantKey, n.UserID, string(n.Type), n.Category, string(n.Priority),
+		n.Title, n.Body, strconv.FormatBool(n.IsRead), readAt, n.CreatedAt.UTC().Format(time.RFC3339Nano),
+		n.SourceEventID, metadata,
+	})
+}
+
+func (e *csvExport) flush() {
+	e.w.Flush()
+}
+
+func (e *csvExport) end() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// jsonExport writes a JSON array, one notification at a time.
+type jsonExport struct {
+	w    io.Writer
+	rows int
+}
+
+func (e *jsonExport) begin() error {
+	_, err := e.w.Write([]byte{'['})
+	return err
+}
+
+func (e *jsonExport) write(n *domain.Notification) error {
+	b, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	if e.rows > 0 {
+		b = append([]byte{','}, b...)
+	}
+	e.rows++
+	_, err = e.w.Write(b)
+	return err
+}
+
+func (e *jsonExport) flush() {}
+
+func (e *jsonExport) end() error {
+	_, err := e.w.Write([]byte{']'})
+	return err
+}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"vn.io.arda/notification/internal/domain"
+)
+
+func exportSample() []*domain.Notification {
+	readAt := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	return []*domain.Notification{
+		{ID: uuid.New(), TenantKey: "acme", UserID: "u1", Type: domain.TypeCRM, Priority: domain.PriorityHigh,
+			Title: "Deal, \"won\"", Body: "line1\nline2", IsRead: true, ReadAt: &readAt,
+			CreatedAt: readAt.Add(-time.Hour), Metadata: map[string]any{"entityId": "d1"}},
+		{ID: uuid.New(), TenantKey: "acme", UserID: "u1", Type: domain.TypeSystem, Priority: domain.PriorityNormal,
+			Title: "Hello", CreatedAt: readAt},
+	}
+}
+
+func TestCSVExport_RoundTrips(t *testing.T) {
+	var buf bytes.Buffer
+	enc := &csvExport{w: csv.NewWriter(&buf)}
+	if err := enc.begin(); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range exportSample() {
+		if err := enc.write(n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := enc.end(); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("export is not valid CSV: %v", err)
+	}
+	if len(records) != 3 || len(records[0]) != len(csvExportHeader) {
+		t.Fatalf("records = %q", records)
+	}
+	first := records[1]
+	if first[6] != "Deal, \"won\"" || first[7] != "line1\nline2" || first[8] != "true" ||
+		first[9] != "2026-03-01T09:30:00Z" || first[12] != `{"entityId":"d1"}` {
+		t.Fatalf("first row = %q", first)
+	}
+	if records[2][9] != "" || records[2][12] != "" {
+		t.Fatalf("second row = %q", records[2])
+	}
+}
+
+func TestJSONExport_IsArray(t *testing.T) {
+	for _, ns := range [][]*domain.Notification{nil, exportSample()} {
+		var buf bytes.Buffer
+		enc := &jsonExport{w: &buf}
+		if err := enc.begin(); err != nil {
+			t.Fatal(err)
+		}
+		for _, n := range ns {
+			if err := enc.write(n); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := enc.end(); err != nil {
+			t.Fatal(err)
+		}
+		var out []domain.Notification
+		if err := json.Unmarshal(buf.Bytes(), &out); err != nil || len(out) != len(ns) {
+			t.Fatalf("export %q: %v (%d rows)", buf.String(), err, len(out))
+		}
+	}
+}
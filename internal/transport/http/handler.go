@@ -1,15 +1,23 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/rs/zerolog/log"
 	"vn.io.arda/notification/internal/application"
 	"vn.io.arda/notification/internal/domain"
+	"vn.io.arda/notification/internal/kafka/registry"
+	"vn.io.arda/notification/internal/transport/mw"
 )
 
 // Suppress unused import warnings.
@@ -19,11 +27,51 @@ var _ = application.PreferenceUpdateInput{}
 type Handler struct {
 	svc *application.Service
 	hub *Hub
+
+	// draining is set on graceful shutdown so /readyz reports not-ready.
+	draining atomic.Bool
+	// probes are the dependency checks of /health/ready.
+	probes probeSet
+	// region labels responses, metrics and SSE frames of this instance.
+	region string
+
+	// widgetTokens enables the embedded widget token flow; nil disables it.
+	widgetTokens     *mw.WidgetTokens
+	widgetIssuerRole string
+
+	// consumer is this instance's Kafka consumer; nil until SetKafkaConsumer.
+	consumer KafkaConsumer
+	// queryStats reports this instance's database query stats; nil until SetQueryStats.
+	queryStats QueryStatsReporter
+	// retentionDays returns the configured TTL for on-demand purges; nil until SetRetentionDays.
+	retentionDays func() int
+	// replayer re-consumes a topic range; nil until SetReplayer.
+	replayer Replayer
+	// serviceAccounts authenticates the /internal routes; nil disables them.
+	serviceAccounts *mw.ServiceAccounts
+	// roles gate the admin routes that check the tenant they act on.
+	roles roleNames
 }
 
 // NewHandler creates a new Handler.
 func NewHandler(svc *application.Service, hub *Hub) *Handler {
-	return &Handler{svc: svc, hub: hub}
+	return &Handler{svc: svc, hub: hub, probes: probeSet{timeout: DefaultProbeTimeout, cacheFor: DefaultProbeCacheFor}}
+}
+
+// SetRegion labels health/metrics responses and SSE "connected" frames with the deployment region.
+func (h *Handler) SetRegion(region string) {
+	h.region = region
+}
+
+// SetWidgetTokens enables POST /widget-token and the read-only /widget routes.
+// Only callers holding issuerRole may mint tokens; the routes stay disabled
+// without one.
+func (h *Handler) SetWidgetTokens(wt *mw.WidgetTokens, issuerRole string) {
+	if issuerRole == "" {
+		return
+	}
+	h.widgetTokens = wt
+	h.widgetIssuerRole = issuerRole
 }
 
 // --- REST Handlers ---
@@ -37,20 +85,62 @@ func (h *Handler) ListNotifications(c echo.Context) error {
 		UserID:    userID,
 		Limit:     parseIntQuery(c, "limit", 20),
 		Offset:    parseIntQuery(c, "offset", 0),
+		Locale:    requestLocale(c),
 	}
 
 	if t := c.QueryParam("type"); t != "" {
 		filter.Type = domain.NotificationType(t)
 	}
+	if cat := c.QueryParam("category"); cat != "" {
+		prefix, _ := domain.CategoryPrefix(cat)
+		if !domain.ValidCategory(prefix) {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid category")
+		}
+		filter.Category = cat
+	}
 	if r := c.QueryParam("is_read"); r != "" {
 		isRead := r == "true"
 		filter.IsRead = &isRead
 	}
+	if p := c.QueryParam("pinned"); p != "" {
+		pinned := p == "true"
+		filter.Pinned = &pinned
+	}
+
+	notifications, err := h.svc.List(c.Request().Context(), filter)
+	if err != nil {
+		return echo.ErrInternalServerError
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"data":   notifications,
+		"limit":  filter.Limit,
+		"offset": filter.Offset,
+	})
+}
+
+// ListByEntity GET /notifications/by-entity/:type/:id
+// The user's notifications about one business entity (e.g. deal/123), including archived ones.
+func (h *Handler) ListByEntity(c echo.Context) error {
+	tenantKey, userID := mustClaims(c)
+
+	filter := domain.NotificationFilter{
+		TenantKey:  tenantKey,
+		UserID:     userID,
+		EntityType: c.Param("type"),
+		EntityID:   c.Param("id"),
+		Limit:      parseIntQuery(c, "limit", 20),
+		Offset:     parseIntQuery(c, "offset", 0),
+		Locale:     requestLocale(c),
+	}
 
 	notifications, err := h.svc.List(c.Request().Context(), filter)
 	if err != nil {
 		return echo.ErrInternalServerError
 	}
+	if notifications == nil {
+		notifications = []*domain.Notification{}
+	}
 
 	return c.JSON(http.StatusOK, map[string]any{
 		"data":   notifications,
@@ -70,12 +160,23 @@ func (h *Handler) GetUnreadCount(c echo.Context) error {
 	return c.JSON(http.StatusOK, map[string]int64{"count": count})
 }
 
+// GetCounts GET /notifications/counts — totals by type, priority and read state.
+func (h *Handler) GetCounts(c echo.Context) error {
+	tenantKey, userID := mustClaims(c)
+
+	counts, err := h.svc.Counts(c.Request().Context(), tenantKey, userID)
+	if err != nil {
+		return echo.ErrInternalServerError
+	}
+	return c.JSON(http.StatusOK, counts)
+}
+
 // MarkRead PATCH /notifications/:id/read
 func (h *Handler) MarkRead(c echo.Context) error {
 	tenantKey, userID := mustClaims(c)
 	id := c.Param("id")
 
-	if err := h.svc.MarkRead(c.Request().Context(), id, tenantKey, userID); err != nil {
+	if err := h.svc.MarkRead(originContext(c), id, tenantKey, userID); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 	return c.NoContent(http.StatusNoContent)
@@ -85,30 +186,237 @@ func (h *Handler) MarkRead(c echo.Context) error {
 func (h *Handler) MarkAllRead(c echo.Context) error {
 	tenantKey, userID := mustClaims(c)
 
-	count, err := h.svc.MarkAllRead(c.Request().Context(), tenantKey, userID)
+	count, err := h.svc.MarkAllRead(originContext(c), tenantKey, userID)
 	if err != nil {
 		return echo.ErrInternalServerError
 	}
 	return c.JSON(http.StatusOK, map[string]int64{"marked": count})
 }
 
+// SyncReadState POST /notifications/read-state
+// Body: { "items": [{ "id": "...", "read_at": "2026-01-02T15:04:05Z" }] } — reads recorded offline.
+func (h *Handler) SyncReadState(c echo.Context) error {
+	tenantKey, userID := mustClaims(c)
+
+	var body struct {
+		Items []domain.ReadState `json:"items"`
+	}
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	newlyRead, err := h.svc.SyncReadStates(originContext(c), tenantKey, userID, body.Items)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, map[string]any{"received": len(body.Items), "marked": len(newlyRead)})
+}
+
 // Delete DELETE /notifications/:id
 func (h *Handler) Delete(c echo.Context) error {
 	tenantKey, userID := mustClaims(c)
 	id := c.Param("id")
 
-	if err := h.svc.Delete(c.Request().Context(), id, tenantKey, userID); err != nil {
+	if err := h.svc.Delete(originContext(c), id, tenantKey, userID); err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// MarkSeen POST /notifications/seen
+// Body: { "ids": ["...", "..."] } — notifications rendered on this device.
+func (h *Handler) MarkSeen(c echo.Context) error {
+	tenantKey, userID := mustClaims(c)
+
+	var body struct {
+		IDs []string `json:"ids"`
+	}
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	seen, err := h.svc.MarkSeen(originContext(c), tenantKey, userID, body.IDs)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, map[string]any{"received": len(body.IDs), "marked": len(seen)})
+}
+
+// StateEvents GET /notifications/events?after=&limit=
+// Returns the user's state events after the cursor; pass next_cursor as after to resume.
+func (h *Handler) StateEvents(c echo.Context) error {
+	tenantKey, userID := mustClaims(c)
+
+	after, _ := strconv.ParseInt(c.QueryParam("after"), 10, 64)
+	events, next, err := h.svc.StateEventsSince(c.Request().Context(), tenantKey, userID, after, parseIntQuery(c, "limit", 100))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if events == nil {
+		events = []domain.StateEvent{}
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": events, "next_cursor": next})
+}
+
+// NotificationHistory GET /notifications/:id/history
+func (h *Handler) NotificationHistory(c echo.Context) error {
+	tenantKey, userID := mustClaims(c)
+
+	history, err := h.svc.NotificationHistory(c.Request().Context(), c.Param("id"), tenantKey, userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": history})
+}
+
+// Undo POST /notifications/:id/undo — reverts the latest read or delete.
+func (h *Handler) Undo(c echo.Context) error {
+	tenantKey, userID := mustClaims(c)
+
+	kind, err := h.svc.Undo(originContext(c), c.Param("id"), tenantKey, userID)
+	if errors.Is(err, domain.ErrNothingToUndo) {
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, map[string]any{"undone": kind})
+}
+
+// Pin PATCH /notifications/:id/pin
+// Body (optional): { "pinned": false } to unpin; pins by default.
+func (h *Handler) Pin(c echo.Context) error {
+	tenantKey, userID := mustClaims(c)
+
+	body := struct {
+		Pinned *bool `json:"pinned"`
+	}{}
+	if c.Request().ContentLength != 0 {
+		if err := c.Bind(&body); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+		}
+	}
+	pinned := body.Pinned == nil || *body.Pinned
+
+	err := h.svc.SetPinned(originContext(c), c.Param("id"), tenantKey, userID, pinned)
+	if errors.Is(err, domain.ErrNotificationNotFound) {
 		return echo.NewHTTPError(http.StatusNotFound, err.Error())
 	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
 	return c.NoContent(http.StatusNoContent)
 }
 
+// Snooze POST /notifications/:id/snooze
+// Body: { "duration": "2h" } or { "until": "2026-01-02T08:00:00Z" } — hides the notification
+// from the inbox and unread count until then; it is pushed over SSE again when it wakes.
+func (h *Handler) Snooze(c echo.Context) error {
+	tenantKey, userID := mustClaims(c)
+
+	var body struct {
+		Duration string     `json:"duration"`
+		Until    *time.Time `json:"until"`
+	}
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	var until time.Time
+	switch {
+	case body.Duration != "" && body.Until != nil:
+		return echo.NewHTTPError(http.StatusBadRequest, "set either duration or until, not both")
+	case body.Duration != "":
+		d, err := time.ParseDuration(body.Duration)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid duration")
+		}
+		until = time.Now().Add(d)
+	case body.Until != nil:
+		until = *body.Until
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "duration or until is required")
+	}
+
+	err := h.svc.Snooze(originContext(c), c.Param("id"), tenantKey, userID, until)
+	if errors.Is(err, domain.ErrNotificationNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, map[string]any{"snoozed_until": until})
+}
+
+// ListSnoozed GET /notifications/snoozed — the user's snoozed notifications, soonest wake-up first.
+func (h *Handler) ListSnoozed(c echo.Context) error {
+	tenantKey, userID := mustClaims(c)
+
+	ns, err := h.svc.ListSnoozed(c.Request().Context(), tenantKey, userID, requestLocale(c))
+	if err != nil {
+		return echo.ErrInternalServerError
+	}
+	if ns == nil {
+		ns = []*domain.Notification{}
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": ns})
+}
+
+// SendTestNotification POST /notifications/test
+// Sends the caller a test notification through the normal delivery path and
+// reports what each channel did with it.
+func (h *Handler) SendTestNotification(c echo.Context) error {
+	tenantKey, userID := mustClaims(c)
+
+	result, err := h.svc.SendTestNotification(c.Request().Context(), tenantKey, userID, requestLocale(c))
+	if errors.Is(err, application.ErrTestNotificationCooldown) {
+		return echo.NewHTTPError(http.StatusTooManyRequests, err.Error())
+	}
+	if err != nil {
+		return echo.ErrInternalServerError
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": result})
+}
+
+// SendDirectMessage POST /notifications/send
+// Body: { "recipients": ["u2", "u3"], "mention": true, "title": "An mentioned you", "entity_type": "deal", "entity_id": "D-1" }
+func (h *Handler) SendDirectMessage(c echo.Context) error {
+	tenantKey, userID := mustClaims(c)
+	roles, _ := c.Get("roles").([]string)
+
+	var body application.DirectMessageInput
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	result, err := h.svc.SendDirectMessage(c.Request().Context(), tenantKey, userID, roles, body)
+	switch {
+	case errors.Is(err, application.ErrDirectMessageForbidden):
+		return echo.NewHTTPError(http.StatusForbidden, err.Error())
+	case errors.Is(err, application.ErrDirectMessageRateLimited):
+		return echo.NewHTTPError(http.StatusTooManyRequests, err.Error())
+	case err != nil:
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusAccepted, map[string]any{"data": result})
+}
+
 // --- SSE Handler ---
 
 // Stream GET /notifications/stream — SSE endpoint
 func (h *Handler) Stream(c echo.Context) error {
 	tenantKey, userID := mustClaims(c)
 
+	// Register client
+	sendCh := make(chan []byte, 32)
+	client, err := h.hub.Register(tenantKey, userID, sendCh)
+	if errors.Is(err, ErrShuttingDown) {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, err.Error())
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusTooManyRequests, err.Error())
+	}
+	defer h.hub.Unregister(client)
+	client.SetExpiresAt(tokenExpiry(c))
+
 	// SSE headers
 	w := c.Response()
 	w.Header().Set("Content-Type", "text/event-stream")
@@ -116,17 +424,48 @@ func (h *Handler) Stream(c echo.Context) error {
 	w.Header().Set("Connection", "keep-alive")
 	w.Header().Set("X-Accel-Buffering", "no") // Disable Nginx/APISIX buffering
 
-	// Register client
-	sendCh := make(chan []byte, 32)
-	client := h.hub.Register(tenantKey, userID, sendCh)
-	defer h.hub.Unregister(client)
-
 	// Send initial "connected" event
-	fmt.Fprintf(w, "event: connected\ndata: {\"status\":\"ok\"}\n\n")
+	fmt.Fprintf(w, "event: connected\ndata: {\"status\":\"ok\",\"client_id\":%q,\"region\":%q}\n\n", client.ID(), h.region)
 	w.Flush()
 
 	log.Info().Str("tenant", tenantKey).Str("user", userID).Msg("SSE stream opened")
 
+	// Heartbeat keeps proxies from closing idle streams and surfaces dead
+	// connections as write failures.
+	var heartbeat <-chan time.Time
+	if interval := h.hub.HeartbeatInterval(); interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
+	// Reauth: ask the client for a fresh token shortly before the bound one expires
+	// and close the stream if no refresh arrives within the grace period.
+	var warnTimer, expireTimer *time.Timer
+	var warnC, expireC <-chan time.Time
+	schedule := func() {
+		if warnTimer != nil {
+			warnTimer.Stop()
+			expireTimer.Stop()
+		}
+		warnC, expireC = nil, nil
+		exp := client.ExpiresAt()
+		if exp.IsZero() {
+			return
+		}
+		lead, grace := h.hub.ReauthWindow()
+		warnTimer = time.NewTimer(max(time.Until(exp.Add(-lead)), 0))
+		expireTimer = time.NewTimer(max(time.Until(exp.Add(grace)), 0))
+		warnC, expireC = warnTimer.C, expireTimer.C
+	}
+	schedule()
+	defer func() {
+		if warnTimer != nil {
+			warnTimer.Stop()
+			expireTimer.Stop()
+		}
+	}()
+
 	ctx := c.Request().Context()
 	for {
 		select {
@@ -137,7 +476,57 @@ func (h *Handler) Stream(c echo.Context) error {
 			if _, err := w.Write(msg); err != nil {
 				return nil
 			}
+			if len(sendCh) == 0 {
+				for _, spilled := range client.TakeSpilled() {
+					if _, err := w.Write(spilled); err != nil {
+						return nil
+					}
+				}
+			}
+			w.Flush()
+			client.Touch()
+
+		case <-heartbeat:
+			if _, err := w.Write([]byte(": keep-alive\n\n")); err != nil {
+				log.Debug().Str("user", userID).Err(err).Msg("SSE heartbeat failed, closing stream")
+				return nil
+			}
+			w.Flush()
+			client.Touch()
+
+		case <-warnC:
+			warnC = nil
+			fmt.Fprintf(w, "event: reauth\ndata: {\"client_id\":%q,\"expires_at\":%q}\n\n",
+				client.ID(), client.ExpiresAt().UTC().Format(time.RFC3339))
+			w.Flush()
+
+		case <-client.Refreshed():
+			schedule()
+
+		case <-expireC:
+			fmt.Fprintf(w, "event: reauth_expired\ndata: {\"client_id\":%q}\n\n", client.ID())
+			w.Flush()
+			log.Info().Str("user", userID).Msg("SSE stream closed: token expired without refresh")
+			return nil
+
+		case <-client.Done():
+			// Flush what is already queued (e.g. server_shutdown) before closing.
+			for pending := true; pending; {
+				select {
+				case msg, ok := <-sendCh:
+					if pending = ok; ok {
+						w.Write(msg)
+					}
+				default:
+					pending = false
+				}
+			}
+			for _, spilled := range client.TakeSpilled() {
+				w.Write(spilled)
+			}
 			w.Flush()
+			log.Info().Str("user", userID).Msg("SSE stream closed by server")
+			return nil
 
 		case <-ctx.Done():
 			log.Info().Str("user", userID).Msg("SSE stream closed by client")
@@ -146,16 +535,90 @@ func (h *Handler) Stream(c echo.Context) error {
 	}
 }
 
+// RefreshStream POST /notifications/stream/refresh
+// Binds the caller's current token to an open stream (X-SSE-Client-ID header or
+// body client_id), extending it past the previous token's expiry.
+func (h *Handler) RefreshStream(c echo.Context) error {
+	tenantKey, userID := mustClaims(c)
+
+	clientID := c.Request().Header.Get("X-SSE-Client-ID")
+	if clientID == "" {
+		var req struct {
+			ClientID string `json:"client_id"`
+		}
+		if err := c.Bind(&req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+		}
+		clientID = req.ClientID
+	}
+	if clientID == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "client_id is required")
+	}
+
+	expiresAt := tokenExpiry(c)
+	if !h.hub.RefreshClient(tenantKey, userID, clientID, expiresAt) {
+		return echo.NewHTTPError(http.StatusNotFound, "stream not found")
+	}
+	return c.JSON(http.StatusOK, map[string]any{"client_id": clientID, "expires_at": expiresAt})
+}
+
+// tokenExpiry returns the expiry of the token that authenticated the request.
+func tokenExpiry(c echo.Context) time.Time {
+	exp, _ := c.Get("tokenExpiresAt").(time.Time)
+	return exp
+}
+
 // --- Healthcheck ---
 
 // Health GET /health
 func (h *Handler) Health(c echo.Context) error {
 	return c.JSON(http.StatusOK, map[string]any{
 		"status":           "ok",
+		"region":           h.region,
 		"sse_clients":       h.hub.ConnectedCount(),
 	})
 }
 
+// SSELatency GET /notifications/admin/sse/latency?slow_ms=
+// Per-tenant broadcast latency histograms; slow_ms limits the report to slow tenants.
+func (h *Handler) SSELatency(c echo.Context) error {
+	slow := time.Duration(parseIntQuery(c, "slow_ms", 0)) * time.Millisecond
+	return c.JSON(http.StatusOK, map[string]any{"region": h.region, "data": h.hub.LatencyReport(slow)})
+}
+
+// FanoutStats GET /notifications/admin/fanout/stats
+// Cumulative chunked fan-out counters and insert latency since startup.
+func (h *Handler) FanoutStats(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]any{"region": h.region, "data": h.svc.FanoutStats()})
+}
+
+// IAMCacheStats GET /notifications/admin/iam/cache
+// Occupancy and per-class hit/miss counters of the IAM resolver cache.
+func (h *Handler) IAMCacheStats(c echo.Context) error {
+	report, ok := h.svc.IAMCacheStats()
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotFound, "iam resolver does not cache lookups")
+	}
+	return c.JSON(http.StatusOK, map[string]any{"region": h.region, "data": report})
+}
+
+// EventHandlerHealth GET /notifications/admin/handlers/health
+// Per topic:eventType record outcomes and error-budget status, so upstream teams can
+// check whether their events turn into notifications.
+func (h *Handler) EventHandlerHealth(c echo.Context) error {
+	handlers := registry.Health()
+	summary := map[string]int{}
+	for _, hh := range handlers {
+		summary[hh.Status]++
+	}
+	return c.JSON(http.StatusOK, map[string]any{"region": h.region, "summary": summary, "data": handlers})
+}
+
+// SetDraining marks the instance as draining; readiness starts failing immediately.
+func (h *Handler) SetDraining() {
+	h.draining.Store(true)
+}
+
 // --- Preferences Handlers ---
 
 // GetPreferences GET /notifications/preferences
@@ -212,68 +675,1203 @@ func (h *Handler) ExecuteAction(c echo.Context) error {
 	return c.JSON(http.StatusOK, result)
 }
 
-// --- Helpers ---
+// TakeAction POST /notifications/:id/actions/:actionId
+// Body (optional): { "comment": "..." } — records the chosen action button.
+func (h *Handler) TakeAction(c echo.Context) error {
+	tenantKey, userID := mustClaims(c)
 
-func mustClaims(c echo.Context) (tenantKey, userID string) {
-	tenantKey = c.Get("tenantKey").(string)
-	userID = c.Get("userID").(string)
-	return
-}
+	var body application.ActionInput
+	if c.Request().ContentLength != 0 {
+		if err := c.Bind(&body); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+		}
+	}
 
-func parseIntQuery(c echo.Context, key string, def int) int {
-	v, err := strconv.Atoi(c.QueryParam(key))
-	if err != nil || v < 0 {
-		return def
+	record, err := h.svc.TakeAction(originContext(c), c.Param("id"), tenantKey, userID, c.Param("actionId"), body)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
-	return v
+	return c.JSON(http.StatusCreated, map[string]any{"data": record})
 }
 
-// buildSSEMessage formats a notification as an SSE data frame.
-func buildSSEMessage(n any) []byte {
-	b, _ := json.Marshal(n)
-	return []byte("event: notification\ndata: " + string(b) + "\n\n")
-}
+// --- Reaction Handlers ---
 
-// --- Template Admin Handlers ---
+// React POST /notifications/:id/reaction
+func (h *Handler) React(c echo.Context) error {
+	tenantKey, userID := mustClaims(c)
+	id := c.Param("id")
 
-// ListTemplates GET /notifications/admin/templates
-func (h *Handler) ListTemplates(c echo.Context) error {
-	locale := c.QueryParam("locale")
-	if locale == "" {
-		locale = "vi"
+	var body application.ReactionInput
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
 	}
-	templates, err := h.svc.ListTemplates(c.Request().Context(), locale)
+
+	reaction, err := h.svc.React(c.Request().Context(), id, tenantKey, userID, body)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
-	}
-	if templates == nil {
-		templates = []domain.Template{}
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
-	return c.JSON(http.StatusOK, map[string]any{"data": templates})
+	return c.JSON(http.StatusCreated, map[string]any{"data": reaction})
 }
 
-// UpsertTemplate PUT /notifications/admin/templates
-func (h *Handler) UpsertTemplate(c echo.Context) error {
-	var t domain.Template
-	if err := c.Bind(&t); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+// ListReactions GET /notifications/:id/reactions
+func (h *Handler) ListReactions(c echo.Context) error {
+	tenantKey, userID := mustClaims(c)
+	id := c.Param("id")
+
+	reactions, err := h.svc.ListReactions(c.Request().Context(), id, tenantKey, userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
 	}
-	if t.TemplateKey == "" || t.Locale == "" {
-		return echo.NewHTTPError(http.StatusBadRequest, "template_key and locale are required")
+	if reactions == nil {
+		reactions = []domain.Reaction{}
 	}
-	saved, err := h.svc.UpsertTemplate(c.Request().Context(), t)
+	return c.JSON(http.StatusOK, map[string]any{"data": reactions})
+}
+
+// ListReactionsBySourceEvent GET /notifications/admin/reactions?source_event_id=
+func (h *Handler) ListReactionsBySourceEvent(c echo.Context) error {
+	tenantKey, _ := mustClaims(c)
+
+	reactions, err := h.svc.ListReactionsBySourceEvent(c.Request().Context(), tenantKey, c.QueryParam("source_event_id"))
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
-	return c.JSON(http.StatusOK, map[string]any{"data": saved})
+	if reactions == nil {
+		reactions = []domain.Reaction{}
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": reactions})
 }
 
-// DeleteTemplate DELETE /notifications/admin/templates/:key/:locale
-func (h *Handler) DeleteTemplate(c echo.Context) error {
-	key := c.Param("key")
+// AuditInbox GET /notifications/admin/users/:user/inbox?as_of=&type=&category=&limit=&offset=
+// Reconstructs what the user's inbox contained at as_of (RFC 3339), including
+// notifications deleted or compacted since and with later reads undone.
+func (h *Handler) AuditInbox(c echo.Context) error {
+	tenantKey, auditor := mustClaims(c)
+
+	asOf, err := time.Parse(time.RFC3339, c.QueryParam("as_of"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "as_of must be an RFC 3339 timestamp")
+	}
+	filter := domain.NotificationFilter{
+		TenantKey: tenantKey,
+		UserID:    c.Param("user"),
+		Type:      domain.NotificationType(c.QueryParam("type")),
+		Category:  c.QueryParam("category"),
+		AsOf:      &asOf,
+		Limit:     parseIntQuery(c, "limit", 20),
+		Offset:    parseIntQuery(c, "offset", 0),
+		Locale:    requestLocale(c),
+	}
+
+	notifications, err := h.svc.ListAsOf(c.Request().Context(), filter, auditor)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if notifications == nil {
+		notifications = []*domain.Notification{}
+	}
+	return c.JSON(http.StatusOK, map[string]any{
+		"data":   notifications,
+		"as_of":  asOf,
+		"limit":  filter.Limit,
+		"offset": filter.Offset,
+	})
+}
+
+// EventTrace GET /notifications/admin/events/:id/trace
+func (h *Handler) EventTrace(c echo.Context) error {
+	tenantKey, _ := mustClaims(c)
+
+	trace, err := h.svc.GetEventTrace(c.Request().Context(), tenantKey, c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": trace})
+}
+
+// --- Webhook Admin Handlers ---
+
+// webhookBody is the request body of webhook create/update.
+type webhookBody struct {
+	URL    string                `json:"url"`
+	Secret string                `json:"secret"`
+	Events []domain.WebhookEvent `json:"events"`
+	Active *bool                 `json:"active"`
+}
+
+func (b webhookBody) webhook(tenantKey string) domain.Webhook {
+	w := domain.Webhook{TenantKey: tenantKey, URL: b.URL, Secret: b.Secret, Events: b.Events, Active: true}
+	if b.Active != nil {
+		w.Active = *b.Active
+	}
+	return w
+}
+
+// ListWebhooks GET /notifications/admin/webhooks
+func (h *Handler) ListWebhooks(c echo.Context) error {
+	tenantKey, _ := mustClaims(c)
+
+	webhooks, err := h.svc.ListWebhooks(c.Request().Context(), tenantKey)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if webhooks == nil {
+		webhooks = []domain.Webhook{}
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": webhooks})
+}
+
+// CreateWebhook POST /notifications/admin/webhooks
+// Body: { "url": "https://...", "events": ["notification.created"], "secret": "optional" }
+func (h *Handler) CreateWebhook(c echo.Context) error {
+	tenantKey, _ := mustClaims(c)
+
+	var body webhookBody
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	saved, err := h.svc.CreateWebhook(c.Request().Context(), body.webhook(tenantKey))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusCreated, map[string]any{"data": saved})
+}
+
+// UpdateWebhook PUT /notifications/admin/webhooks/:id
+func (h *Handler) UpdateWebhook(c echo.Context) error {
+	tenantKey, _ := mustClaims(c)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid webhook id")
+	}
+	var body webhookBody
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	w := body.webhook(tenantKey)
+	w.ID = id
+	saved, err := h.svc.UpdateWebhook(c.Request().Context(), w)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": saved})
+}
+
+// DeleteWebhook DELETE /notifications/admin/webhooks/:id
+func (h *Handler) DeleteWebhook(c echo.Context) error {
+	tenantKey, _ := mustClaims(c)
+
+	if err := h.svc.DeleteWebhook(c.Request().Context(), tenantKey, c.Param("id")); err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ListWebhookDeliveries GET /notifications/admin/webhooks/:id/deliveries?status=&limit=
+func (h *Handler) ListWebhookDeliveries(c echo.Context) error {
+	tenantKey, _ := mustClaims(c)
+
+	deliveries, err := h.svc.ListWebhookDeliveries(c.Request().Context(), tenantKey, c.Param("id"),
+		domain.WebhookDeliveryStatus(c.QueryParam("status")), parseIntQuery(c, "limit", 50))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if deliveries == nil {
+		deliveries = []domain.WebhookDelivery{}
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": deliveries})
+}
+
+// RetryWebhookDelivery POST /notifications/admin/webhooks/deliveries/:delivery/retry
+func (h *Handler) RetryWebhookDelivery(c echo.Context) error {
+	tenantKey, _ := mustClaims(c)
+
+	id, err := strconv.ParseInt(c.Param("delivery"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid delivery id")
+	}
+	if err := h.svc.RetryWebhookDelivery(c.Request().Context(), tenantKey, id); err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return c.NoContent(http.StatusAccepted)
+}
+
+// --- Chat Connector Admin Handlers ---
+
+// chatConnectorBody is the request body of chat connector create/update.
+type chatConnectorBody struct {
+	Name     string                    `json:"name"`
+	Provider domain.ChatProvider       `json:"provider"`
+	URL      string                    `json:"url"`
+	Types    []domain.NotificationType `json:"types"`
+	Template string                    `json:"template"`
+	Active   *bool                     `json:"active"`
+}
+
+func (b chatConnectorBody) connector(tenantKey string) domain.ChatConnector {
+	c := domain.ChatConnector{TenantKey: tenantKey, Name: b.Name, Provider: b.Provider, URL: b.URL,
+		Types: b.Types, Template: b.Template, Active: true}
+	if b.Active != nil {
+		c.Active = *b.Active
+	}
+	return c
+}
+
+// ListChatConnectors GET /notifications/admin/chat-connectors
+func (h *Handler) ListChatConnectors(c echo.Context) error {
+	tenantKey, _ := mustClaims(c)
+
+	connectors, err := h.svc.ListChatConnectors(c.Request().Context(), tenantKey)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if connectors == nil {
+		connectors = []domain.ChatConnector{}
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": connectors})
+}
+
+// CreateChatConnector POST /notifications/admin/chat-connectors
+// Body: { "name": "ops", "provider": "slack", "url": "https://hooks.slack.com/...", "types": ["SYSTEM"] }
+func (h *Handler) CreateChatConnector(c echo.Context) error {
+	tenantKey, _ := mustClaims(c)
+
+	var body chatConnectorBody
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	saved, err := h.svc.CreateChatConnector(c.Request().Context(), body.connector(tenantKey))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusCreated, map[string]any{"data": saved})
+}
+
+// UpdateChatConnector PUT /notifications/admin/chat-connectors/:id
+func (h *Handler) UpdateChatConnector(c echo.Context) error {
+	tenantKey, _ := mustClaims(c)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid connector id")
+	}
+	var body chatConnectorBody
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	conn := body.connector(tenantKey)
+	conn.ID = id
+	saved, err := h.svc.UpdateChatConnector(c.Request().Context(), conn)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": saved})
+}
+
+// DeleteChatConnector DELETE /notifications/admin/chat-connectors/:id
+func (h *Handler) DeleteChatConnector(c echo.Context) error {
+	tenantKey, _ := mustClaims(c)
+
+	if err := h.svc.DeleteChatConnector(c.Request().Context(), tenantKey, c.Param("id")); err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// TestChatConnector POST /notifications/admin/chat-connectors/:id/test
+func (h *Handler) TestChatConnector(c echo.Context) error {
+	tenantKey, _ := mustClaims(c)
+
+	if err := h.svc.TestChatConnector(c.Request().Context(), tenantKey, c.Param("id")); err != nil {
+		return echo.NewHTTPError(http.StatusBadGateway, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// --- Escalation Rule Admin Handlers ---
+
+// escalationRuleBody is the request body of escalation rule create/update.
+type escalationRuleBody struct {
+	Type         domain.NotificationType `json:"type"`
+	Priority     domain.Priority         `json:"priority"`
+	AfterSeconds int                     `json:"after_seconds"`
+	Action       domain.EscalationAction `json:"action"`
+	Role         string                  `json:"role"`
+	Active       *bool                   `json:"active"`
+}
+
+func (b escalationRuleBody) rule(tenantKey string) domain.EscalationRule {
+	r := domain.EscalationRule{TenantKey: tenantKey, Type: b.Type, Priority: b.Priority, AfterSeconds: b.AfterSeconds,
+		Action: b.Action, Role: b.Role, Active: true}
+	if b.Active != nil {
+		r.Active = *b.Active
+	}
+	return r
+}
+
+// ListEscalationRules GET /notifications/admin/escalation-rules
+func (h *Handler) ListEscalationRules(c echo.Context) error {
+	tenantKey, _ := mustClaims(c)
+
+	rules, err := h.svc.ListEscalationRules(c.Request().Context(), tenantKey)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if rules == nil {
+		rules = []domain.EscalationRule{}
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": rules})
+}
+
+// CreateEscalationRule POST /notifications/admin/escalation-rules
+// Body: { "priority": "URGENT", "after_seconds": 900, "action": "role", "role": "ONCALL" }
+func (h *Handler) CreateEscalationRule(c echo.Context) error {
+	tenantKey, _ := mustClaims(c)
+
+	var body escalationRuleBody
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	saved, err := h.svc.CreateEscalationRule(c.Request().Context(), body.rule(tenantKey))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusCreated, map[string]any{"data": saved})
+}
+
+// UpdateEscalationRule PUT /notifications/admin/escalation-rules/:id
+func (h *Handler) UpdateEscalationRule(c echo.Context) error {
+	tenantKey, _ := mustClaims(c)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid rule id")
+	}
+	var body escalationRuleBody
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	rule := body.rule(tenantKey)
+	rule.ID = id
+	saved, err := h.svc.UpdateEscalationRule(c.Request().Context(), rule)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": saved})
+}
+
+// DeleteEscalationRule DELETE /notifications/admin/escalation-rules/:id
+func (h *Handler) DeleteEscalationRule(c echo.Context) error {
+	tenantKey, _ := mustClaims(c)
+
+	if err := h.svc.DeleteEscalationRule(c.Request().Context(), tenantKey, c.Param("id")); err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// --- Announcement Handlers ---
+
+// ListAnnouncements GET /announcements — active banners the caller has not dismissed
+func (h *Handler) ListAnnouncements(c echo.Context) error {
+	tenantKey, userID := mustClaims(c)
+
+	announcements, err := h.svc.ListAnnouncements(c.Request().Context(), tenantKey, userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if announcements == nil {
+		announcements = []domain.Announcement{}
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": announcements})
+}
+
+// DismissAnnouncement POST /announcements/:id/dismiss
+func (h *Handler) DismissAnnouncement(c echo.Context) error {
+	tenantKey, userID := mustClaims(c)
+
+	if err := h.svc.DismissAnnouncement(originContext(c), tenantKey, userID, c.Param("id")); err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// announcementBody is the request body of announcement create/update.
+type announcementBody struct {
+	TenantKey string                      `json:"tenant_key"`
+	Title     string                      `json:"title"`
+	Body      string                      `json:"body"`
+	Severity  domain.AnnouncementSeverity `json:"severity"`
+	StartsAt  *time.Time                  `json:"starts_at"`
+	EndsAt    *time.Time                  `json:"ends_at"`
+}
+
+func (b announcementBody) announcement() domain.Announcement {
+	a := domain.Announcement{TenantKey: b.TenantKey, Title: b.Title, Body: b.Body, Severity: b.Severity, EndsAt: b.EndsAt}
+	if a.Severity == "" {
+		a.Severity = domain.SeverityInfo
+	}
+	if b.StartsAt != nil {
+		a.StartsAt = *b.StartsAt
+	}
+	return a
+}
+
+// ListAllAnnouncements GET /notifications/admin/announcements?tenant_key=acme
+func (h *Handler) ListAllAnnouncements(c echo.Context) error {
+	tenantKey, err := h.tenantFilter(c, c.QueryParam("tenant_key"))
+	if err != nil {
+		return err
+	}
+	announcements, err := h.svc.ListAllAnnouncements(c.Request().Context(), tenantKey)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if announcements == nil {
+		announcements = []domain.Announcement{}
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": announcements})
+}
+
+// CreateAnnouncement POST /notifications/admin/announcements
+// Body: { "tenant_key": "" (= every tenant), "title": "...", "severity": "warning", "starts_at": "...", "ends_at": "..." }
+func (h *Handler) CreateAnnouncement(c echo.Context) error {
+	_, userID := mustClaims(c)
+
+	var body announcementBody
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	a := body.announcement()
+	if err := h.authorizeTenant(c, a.TenantKey); err != nil {
+		return err
+	}
+	a.CreatedBy = userID
+	saved, err := h.svc.CreateAnnouncement(c.Request().Context(), a)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusCreated, map[string]any{"data": saved})
+}
+
+// UpdateAnnouncement PUT /notifications/admin/announcements/:id — tenant_key is ignored
+func (h *Handler) UpdateAnnouncement(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid announcement id")
+	}
+	if err := h.authorizeAnnouncement(c); err != nil {
+		return err
+	}
+	var body announcementBody
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	a := body.announcement()
+	a.ID = id
+	saved, err := h.svc.UpdateAnnouncement(c.Request().Context(), a)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": saved})
+}
+
+// DeleteAnnouncement DELETE /notifications/admin/announcements/:id
+func (h *Handler) DeleteAnnouncement(c echo.Context) error {
+	if err := h.authorizeAnnouncement(c); err != nil {
+		return err
+	}
+	if err := h.svc.DeleteAnnouncement(c.Request().Context(), c.Param("id")); err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// authorizeAnnouncement checks that the caller may change the banner :id of
+// its tenant, or a platform-wide or other tenant's one as platform admin.
+func (h *Handler) authorizeAnnouncement(c echo.Context) error {
+	a, err := h.svc.GetAnnouncement(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return h.authorizeTenant(c, a.TenantKey)
+}
+
+// --- Direct Message Rule Admin Handlers ---
+
+// GetDirectMessageRule GET /notifications/admin/direct-message-rule — the caller tenant's rule, or the defaults
+func (h *Handler) GetDirectMessageRule(c echo.Context) error {
+	tenantKey, _ := mustClaims(c)
+
+	rule, err := h.svc.GetDirectMessageRule(c.Request().Context(), tenantKey)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": rule})
+}
+
+// UpsertDirectMessageRule PUT /notifications/admin/direct-message-rule
+// Body: { "enabled": true, "sender_roles": ["SALES"], "max_recipients": 10, "per_minute": 20 }
+func (h *Handler) UpsertDirectMessageRule(c echo.Context) error {
+	tenantKey, _ := mustClaims(c)
+
+	var body domain.DirectMessageRule
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	body.TenantKey = tenantKey
+	saved, err := h.svc.UpsertDirectMessageRule(c.Request().Context(), body)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": saved})
+}
+
+// DeleteDirectMessageRule DELETE /notifications/admin/direct-message-rule — back to the defaults
+func (h *Handler) DeleteDirectMessageRule(c echo.Context) error {
+	tenantKey, _ := mustClaims(c)
+
+	if err := h.svc.DeleteDirectMessageRule(c.Request().Context(), tenantKey); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// --- Maintenance Window Admin Handlers ---
+
+// maintenanceWindowBody is the request body of maintenance window create/update.
+type maintenanceWindowBody struct {
+	TenantKey string                 `json:"tenant_key"`
+	Reason    string                 `json:"reason"`
+	Mode      domain.MaintenanceMode `json:"mode"`
+	StartsAt  *time.Time             `json:"starts_at"`
+	EndsAt    time.Time              `json:"ends_at"`
+}
+
+func (b maintenanceWindowBody) window() domain.MaintenanceWindow {
+	w := domain.MaintenanceWindow{TenantKey: b.TenantKey, Reason: b.Reason, Mode: b.Mode, EndsAt: b.EndsAt}
+	if b.StartsAt != nil {
+		w.StartsAt = *b.StartsAt
+	}
+	return w
+}
+
+// ListMaintenanceWindows GET /notifications/admin/maintenance-windows?tenant_key=acme
+func (h *Handler) ListMaintenanceWindows(c echo.Context) error {
+	tenantKey, err := h.tenantFilter(c, c.QueryParam("tenant_key"))
+	if err != nil {
+		return err
+	}
+	windows, err := h.svc.ListMaintenanceWindows(c.Request().Context(), tenantKey)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if windows == nil {
+		windows = []domain.MaintenanceWindow{}
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": windows})
+}
+
+// CreateMaintenanceWindow POST /notifications/admin/maintenance-windows
+// Body: { "tenant_key": "acme", "mode": "batch", "ends_at": "2026-01-01T02:00:00Z", "reason": "DB upgrade" };
+// an empty tenant_key covers every tenant, starts_at defaults to now.
+func (h *Handler) CreateMaintenanceWindow(c echo.Context) error {
+	_, userID := mustClaims(c)
+
+	var body maintenanceWindowBody
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	w := body.window()
+	if err := h.authorizeTenant(c, w.TenantKey); err != nil {
+		return err
+	}
+	w.CreatedBy = userID
+	saved, err := h.svc.CreateMaintenanceWindow(c.Request().Context(), w)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusCreated, map[string]any{"data": saved})
+}
+
+// UpdateMaintenanceWindow PUT /notifications/admin/maintenance-windows/:id — tenant_key is ignored
+func (h *Handler) UpdateMaintenanceWindow(c echo.Context) error {
+	id, err := h.authorizeMaintenanceWindow(c)
+	if err != nil {
+		return err
+	}
+	var body maintenanceWindowBody
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	w := body.window()
+	w.ID = id
+	saved, err := h.svc.UpdateMaintenanceWindow(c.Request().Context(), w)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": saved})
+}
+
+// DeleteMaintenanceWindow DELETE /notifications/admin/maintenance-windows/:id
+func (h *Handler) DeleteMaintenanceWindow(c echo.Context) error {
+	if _, err := h.authorizeMaintenanceWindow(c); err != nil {
+		return err
+	}
+	if err := h.svc.DeleteMaintenanceWindow(c.Request().Context(), c.Param("id")); err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// authorizeMaintenanceWindow checks that the caller may change the window :id
+// of its tenant, or of any tenant as platform admin, and returns its ID.
+func (h *Handler) authorizeMaintenanceWindow(c echo.Context) (uuid.UUID, error) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return uuid.Nil, echo.NewHTTPError(http.StatusBadRequest, "invalid window id")
+	}
+	w, err := h.svc.GetMaintenanceWindow(c.Request().Context(), id)
+	if err != nil {
+		return uuid.Nil, echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return id, h.authorizeTenant(c, w.TenantKey)
+}
+
+// --- Scope Admin Handlers ---
+
+// ResolveScope POST /notifications/admin/scopes/resolve — dry-run of fan-out resolution
+func (h *Handler) ResolveScope(c echo.Context) error {
+	var body application.ScopeResolveInput
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	if body.TenantKey == "" {
+		body.TenantKey, _ = mustClaims(c)
+	}
+	// PLATFORM reaches every tenant's users; the other scopes stay within TenantKey.
+	platform := domain.TargetScope(body.TargetScope) == domain.ScopePlatform
+	for _, t := range body.Targets {
+		platform = platform || t.Scope == domain.ScopePlatform
+	}
+	if platform && !h.isPlatformAdmin(c) {
+		return echo.NewHTTPError(http.StatusForbidden, "missing role "+h.roles.platformAdmin+" to resolve the PLATFORM scope")
+	}
+	if !platform {
+		if err := h.authorizeTenant(c, body.TenantKey); err != nil {
+			return err
+		}
+	}
+
+	result, err := h.svc.ResolveScope(c.Request().Context(), body)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": result})
+}
+
+// --- Rollout Admin Handlers ---
+
+// ListRollouts GET /notifications/admin/rollouts
+func (h *Handler) ListRollouts(c echo.Context) error {
+	rollouts, err := h.svc.ListPendingRollouts(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if rollouts == nil {
+		rollouts = []domain.Rollout{}
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": rollouts})
+}
+
+// ReleaseRollout POST /notifications/admin/rollouts/:id/release
+func (h *Handler) ReleaseRollout(c echo.Context) error {
+	if err := h.svc.ReleaseRollout(c.Request().Context(), c.Param("id")); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// CancelRollout POST /notifications/admin/rollouts/:id/cancel
+func (h *Handler) CancelRollout(c echo.Context) error {
+	if err := h.svc.CancelRollout(c.Request().Context(), c.Param("id")); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// --- Helpers ---
+
+func mustClaims(c echo.Context) (tenantKey, userID string) {
+	tenantKey = c.Get("tenantKey").(string)
+	userID = c.Get("userID").(string)
+	return
+}
+
+// trackTenantActivity records the caller's tenant as active for idle-tenant reclamation.
+func (h *Handler) trackTenantActivity(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if tenantKey, ok := c.Get("tenantKey").(string); ok {
+			h.svc.TouchTenant(tenantKey)
+		}
+		return next(c)
+	}
+}
+
+// originContext tags the request context with the caller's SSE client ID (if sent),
+// so state-change events are not echoed back to the stream that caused them.
+func originContext(c echo.Context) context.Context {
+	return application.WithOriginClient(c.Request().Context(), c.Request().Header.Get("X-SSE-Client-ID"))
+}
+
+func parseIntQuery(c echo.Context, key string, def int) int {
+	v, err := strconv.Atoi(c.QueryParam(key))
+	if err != nil || v < 0 {
+		return def
+	}
+	return v
+}
+
+// requestLocale returns the ?locale= query parameter, else the primary language of
+// the first Accept-Language entry ("en-US,en;q=0.9" → "en"). Empty means the default locale.
+func requestLocale(c echo.Context) string {
+	if l := c.QueryParam("locale"); l != "" {
+		return l
+	}
+	first, _, _ := strings.Cut(c.Request().Header.Get("Accept-Language"), ",")
+	first, _, _ = strings.Cut(first, ";")
+	lang, _, _ := strings.Cut(strings.TrimSpace(first), "-")
+	if lang == "*" {
+		return ""
+	}
+	return strings.ToLower(lang)
+}
+
+// buildSSEMessage formats a notification as an SSE data frame.
+func buildSSEMessage(n any) []byte {
+	return buildSSEEvent("notification", n)
+}
+
+// buildSSEEvent formats an arbitrary named event as an SSE data frame.
+func buildSSEEvent(event string, data any) []byte {
+	b, _ := json.Marshal(data)
+	return []byte("event: " + event + "\ndata: " + string(b) + "\n\n")
+}
+
+// --- Template Admin Handlers ---
+
+// ListTemplates GET /notifications/admin/templates
+func (h *Handler) ListTemplates(c echo.Context) error {
+	locale := c.QueryParam("locale")
+	if locale == "" {
+		locale = "vi"
+	}
+	templates, err := h.svc.ListTemplates(c.Request().Context(), locale)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if templates == nil {
+		templates = []domain.Template{}
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": templates})
+}
+
+// UpsertTemplate PUT /notifications/admin/templates
+func (h *Handler) UpsertTemplate(c echo.Context) error {
+	var t domain.Template
+	if err := c.Bind(&t); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	if t.TemplateKey == "" || t.Locale == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "template_key and locale are required")
+	}
+	saved, err := h.svc.UpsertTemplate(c.Request().Context(), t)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": saved})
+}
+
+// DeleteTemplate DELETE /notifications/admin/templates/:key/:locale
+func (h *Handler) DeleteTemplate(c echo.Context) error {
+	key := c.Param("key")
 	locale := c.Param("locale")
 	if err := h.svc.DeleteTemplate(c.Request().Context(), key, locale); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 	return c.NoContent(http.StatusNoContent)
 }
+
+// --- Tenant Template Override Handlers ---
+
+// ListTemplateOverrides GET /notifications/admin/template-overrides
+func (h *Handler) ListTemplateOverrides(c echo.Context) error {
+	tenantKey, _ := mustClaims(c)
+	locale := c.QueryParam("locale")
+	if locale == "" {
+		locale = "vi"
+	}
+	overrides, err := h.svc.ListTemplateOverrides(c.Request().Context(), tenantKey, locale)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if overrides == nil {
+		overrides = []domain.TemplateOverride{}
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": overrides})
+}
+
+// UpsertTemplateOverride PUT /notifications/admin/template-overrides
+func (h *Handler) UpsertTemplateOverride(c echo.Context) error {
+	tenantKey, _ := mustClaims(c)
+	var o domain.TemplateOverride
+	if err := c.Bind(&o); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	if o.TemplateKey == "" || o.Locale == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "template_key and locale are required")
+	}
+	o.TenantKey = tenantKey
+	saved, err := h.svc.UpsertTemplateOverride(c.Request().Context(), o)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": saved})
+}
+
+// DeleteTemplateOverride DELETE /notifications/admin/template-overrides/:key/:locale
+func (h *Handler) DeleteTemplateOverride(c echo.Context) error {
+	tenantKey, _ := mustClaims(c)
+	if err := h.svc.DeleteTemplateOverride(c.Request().Context(), tenantKey, c.Param("key"), c.Param("locale")); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// TemplateDiff GET /notifications/admin/template-overrides/diff
+func (h *Handler) TemplateDiff(c echo.Context) error {
+	tenantKey, _ := mustClaims(c)
+	diffs, err := h.svc.TemplateDiff(c.Request().Context(), tenantKey, c.QueryParam("locale"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": diffs})
+}
+
+// SetTemplateParent PUT /notifications/admin/template-inheritance/:tenant
+func (h *Handler) SetTemplateParent(c echo.Context) error {
+	var body struct {
+		InheritsFrom string `json:"inherits_from"`
+	}
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	tenantKey := c.Param("tenant")
+	if err := h.svc.SetTemplateParent(c.Request().Context(), tenantKey, body.InheritsFrom); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": map[string]string{
+		"tenant_key": tenantKey, "inherits_from": body.InheritsFrom,
+	}})
+}
+
+// --- Notification Type Handlers ---
+
+// ListNotificationTypes GET /notifications/types
+func (h *Handler) ListNotificationTypes(c echo.Context) error {
+	tenantKey, _ := mustClaims(c)
+	types, err := h.svc.ListNotificationTypes(c.Request().Context(), tenantKey)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": types})
+}
+
+// UpsertCustomType PUT /notifications/admin/types/:type
+func (h *Handler) UpsertCustomType(c echo.Context) error {
+	tenantKey, _ := mustClaims(c)
+	var body struct {
+		Name            string `json:"name"`
+		Icon            string `json:"icon"`
+		DefaultPriority string `json:"default_priority"`
+		RetentionDays   int    `json:"retention_days"`
+	}
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	saved, err := h.svc.UpsertCustomType(c.Request().Context(), domain.CustomType{
+		TenantKey:       tenantKey,
+		Type:            domain.NotificationType(c.Param("type")),
+		Name:            body.Name,
+		Icon:            body.Icon,
+		DefaultPriority: domain.Priority(body.DefaultPriority),
+		RetentionDays:   body.RetentionDays,
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": saved})
+}
+
+// DeleteCustomType DELETE /notifications/admin/types/:type
+func (h *Handler) DeleteCustomType(c echo.Context) error {
+	tenantKey, _ := mustClaims(c)
+	if err := h.svc.DeleteCustomType(c.Request().Context(), tenantKey, domain.NotificationType(c.Param("type"))); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// --- Delivery Policy Admin Handlers ---
+
+// ListPolicies GET /notifications/admin/policies
+func (h *Handler) ListPolicies(c echo.Context) error {
+	policies, err := h.svc.ListPolicies(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if policies == nil {
+		policies = []domain.DeliveryPolicy{}
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": policies})
+}
+
+// GetPolicy GET /notifications/admin/policies/:tenant
+func (h *Handler) GetPolicy(c echo.Context) error {
+	p, err := h.svc.GetPolicy(c.Request().Context(), c.Param("tenant"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if p == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "policy not found")
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": p})
+}
+
+// UpsertPolicy PUT /notifications/admin/policies/:tenant
+// Body: { "module": "package arda.delivery ...", "enabled": true }
+func (h *Handler) UpsertPolicy(c echo.Context) error {
+	var body struct {
+		Module  string `json:"module"`
+		Enabled *bool  `json:"enabled"`
+	}
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	if body.Module == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "module is required")
+	}
+	p := domain.DeliveryPolicy{TenantKey: c.Param("tenant"), Module: body.Module, Enabled: true}
+	if body.Enabled != nil {
+		p.Enabled = *body.Enabled
+	}
+	saved, err := h.svc.UpsertPolicy(c.Request().Context(), p)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": saved})
+}
+
+// DeletePolicy DELETE /notifications/admin/policies/:tenant
+func (h *Handler) DeletePolicy(c echo.Context) error {
+	if err := h.svc.DeletePolicy(c.Request().Context(), c.Param("tenant")); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// --- Event Type Defaults Admin Handlers ---
+
+// ListEventDefaults GET /notifications/admin/event-defaults
+// Event keys of the registered handlers are listed by /notifications/admin/handlers/health.
+func (h *Handler) ListEventDefaults(c echo.Context) error {
+	defaults, err := h.svc.ListEventDefaults(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if defaults == nil {
+		defaults = []domain.EventDefaults{}
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": defaults})
+}
+
+// UpsertEventDefaults PUT /notifications/admin/event-defaults/:key
+// :key is "topic:eventType". Body: { "priority": "HIGH", "category": "crm.deal", "ttl_seconds": 86400, "channels": ["in_app"] }
+func (h *Handler) UpsertEventDefaults(c echo.Context) error {
+	var body struct {
+		Priority   domain.Priority  `json:"priority"`
+		Category   string           `json:"category"`
+		TTLSeconds int              `json:"ttl_seconds"`
+		Channels   []domain.Channel `json:"channels"`
+	}
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	saved, err := h.svc.UpsertEventDefaults(c.Request().Context(), domain.EventDefaults{
+		EventKey:   c.Param("key"),
+		Priority:   body.Priority,
+		Category:   body.Category,
+		TTLSeconds: body.TTLSeconds,
+		Channels:   body.Channels,
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": saved})
+}
+
+// DeleteEventDefaults DELETE /notifications/admin/event-defaults/:key
+func (h *Handler) DeleteEventDefaults(c echo.Context) error {
+	if err := h.svc.DeleteEventDefaults(c.Request().Context(), c.Param("key")); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// --- Retention Policy Admin Handlers ---
+
+// ListRetentionPolicies GET /notifications/admin/retention-policies?tenant_key=acme
+// Without tenant_key every policy is listed; with it, the tenant's own and those for all tenants.
+func (h *Handler) ListRetentionPolicies(c echo.Context) error {
+	tenantKey, err := h.tenantFilter(c, c.QueryParam("tenant_key"))
+	if err != nil {
+		return err
+	}
+	policies, err := h.svc.ListRetentionPolicies(c.Request().Context(), tenantKey)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if policies == nil {
+		policies = []domain.RetentionPolicy{}
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": policies})
+}
+
+// UpsertRetentionPolicy PUT /notifications/admin/retention-policies
+// Body: { "tenant_key": "acme", "type": "SECURITY", "retention_days": 365 }; an empty
+// tenant_key / type applies to all tenants / types, retention_days 0 keeps forever.
+func (h *Handler) UpsertRetentionPolicy(c echo.Context) error {
+	var body struct {
+		TenantKey     string `json:"tenant_key"`
+		Type          string `json:"type"`
+		RetentionDays *int   `json:"retention_days"`
+	}
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	if body.RetentionDays == nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "retention_days is required")
+	}
+	if err := h.authorizeTenant(c, body.TenantKey); err != nil {
+		return err
+	}
+	saved, err := h.svc.UpsertRetentionPolicy(c.Request().Context(), domain.RetentionPolicy{
+		TenantKey:     body.TenantKey,
+		Type:          domain.NotificationType(body.Type),
+		RetentionDays: *body.RetentionDays,
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": saved})
+}
+
+// DeleteRetentionPolicy DELETE /notifications/admin/retention-policies?tenant_key=acme&type=SECURITY
+func (h *Handler) DeleteRetentionPolicy(c echo.Context) error {
+	if err := h.authorizeTenant(c, c.QueryParam("tenant_key")); err != nil {
+		return err
+	}
+	if err := h.svc.DeleteRetentionPolicy(c.Request().Context(), c.QueryParam("tenant_key"),
+		domain.NotificationType(c.QueryParam("type"))); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// --- Encryption Key (BYOK) Admin Handlers ---
+
+// ListEncryptionKeys GET /notifications/admin/encryption-keys
+func (h *Handler) ListEncryptionKeys(c echo.Context) error {
+	keys, err := h.svc.ListEncryptionKeys(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if keys == nil {
+		keys = []domain.TenantEncryptionKey{}
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": keys})
+}
+
+// RegisterEncryptionKey PUT /notifications/admin/encryption-keys/:tenant
+// Body: { "key_ref": "arn:aws:kms:..." }
+func (h *Handler) RegisterEncryptionKey(c echo.Context) error {
+	var body struct {
+		KeyRef string `json:"key_ref"`
+	}
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	if body.KeyRef == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "key_ref is required")
+	}
+	saved, err := h.svc.RegisterEncryptionKey(c.Request().Context(), c.Param("tenant"), body.KeyRef)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": saved})
+}
+
+// DeleteEncryptionKey DELETE /notifications/admin/encryption-keys/:tenant
+func (h *Handler) DeleteEncryptionKey(c echo.Context) error {
+	if err := h.svc.DeleteEncryptionKey(c.Request().Context(), c.Param("tenant")); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RotateEncryptionKey POST /notifications/admin/encryption-keys/:tenant/rotate
+// Re-encrypts the tenant's stored notifications with its current key; the request
+// lasts until every row is rewritten.
+func (h *Handler) RotateEncryptionKey(c echo.Context) error {
+	tenant := c.Param("tenant")
+	_, requester := mustClaims(c)
+	log.Info().Str("requester", requester).Str("tenant", tenant).Msg("encryption key rotation requested")
+	rewritten, err := h.svc.RotateEncryptionKey(c.Request().Context(), tenant)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": map[string]any{"tenant": tenant, "rewritten": rewritten}})
+}
+
+// --- Embedded Widget Handlers ---
+
+// IssueWidgetToken POST /widget-token
+// Called by a tenant backend to mint a short-lived, read-only token for one of its users.
+// Body: { "user_id": "...", "ttl_seconds": 900 }
+func (h *Handler) IssueWidgetToken(c echo.Context) error {
+	if !hasRole(c, h.widgetIssuerRole) {
+		return echo.NewHTTPError(http.StatusForbidden, "missing role "+h.widgetIssuerRole)
+	}
+	var body struct {
+		UserID     string `json:"user_id"`
+		TTLSeconds int    `json:"ttl_seconds"`
+	}
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	if body.UserID == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "user_id is required")
+	}
+
+	tenantKey, _ := mustClaims(c)
+	token, expires, err := h.widgetTokens.Issue(tenantKey, body.UserID, time.Duration(body.TTLSeconds)*time.Second)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, map[string]any{"token": token, "expires_at": expires})
+}
+
+func hasRole(c echo.Context, role string) bool {
+	return mw.HasRole(c, role)
+}
@@ -0,0 +1,165 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// Probe checks that a dependency is reachable and usable.
+type Probe func(ctx context.Context) error
+
+// Default probe settings; see SetProbeOptions.
+const (
+	DefaultProbeTimeout  = 2 * time.Second
+	DefaultProbeCacheFor = 5 * time.Second
+)
+
+// ProbeResult is the outcome of one dependency probe.
+type ProbeResult struct {
+	Status    string `json:"status"` // "up" or "down"
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+type namedProbe struct {
+	name  string
+	probe Probe
+}
+
+// probeSet runs the registered probes concurrently and caches the outcome briefly,
+// so several kubelet / load balancer checks do not each hit every dependency.
+type probeSet struct {
+	probes   []namedProbe
+	timeout  time.Duration
+	cacheFor time.Duration
+
+	mu       sync.Mutex
+	cachedAt time.Time
+	cached   map[string]ProbeResult
+
+	// alerter is told when a probe goes down or recovers.
+	alerter domain.Alerter
+}
+
+// AddProbe registers a dependency checked by GET /health/ready.
+func (h *Handler) AddProbe(name string, probe Probe) {
+	h.probes.probes = append(h.probes.probes, namedProbe{name: name, probe: probe})
+}
+
+// SetProbeOptions sets the per-probe timeout and how long results are reused.
+func (h *Handler) SetProbeOptions(timeout, cacheFor time.Duration) {
+	h.probes.timeout, h.probes.cacheFor = timeout, cacheFor
+}
+
+// SetProbeAlerter raises a critical alert when a dependency probe goes down and
+// resolves it when the probe recovers.
+func (h *Handler) SetProbeAlerter(a domain.Alerter) {
+	h.probes.alerter = a
+}
+
+// WatchProbes runs the dependency probes every interval, so outages are alerted
+// even when nothing polls /health/ready. Blocks until ctx is cancelled.
+func (h *Handler) WatchProbes(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			h.probes.run(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// run returns every probe's result and whether all are up.
+func (p *probeSet) run(ctx context.Context) (map[string]ProbeResult, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cached == nil || time.Since(p.cachedAt) >= p.cacheFor {
+		previous := p.cached
+		p.cached = p.check(ctx)
+		p.cachedAt = time.Now()
+		p.alertTransitions(ctx, previous)
+	}
+	ok := true
+	for _, r := range p.cached {
+		ok = ok && r.Status == "up"
+	}
+	return p.cached, ok
+}
+
+// alertTransitions alerts on probes that went down or recovered since previous.
+// A probe down on the first check counts as a transition.
+func (p *probeSet) alertTransitions(ctx context.Context, previous map[string]ProbeResult) {
+	if p.alerter == nil {
+		return
+	}
+	for name, r := range p.cached {
+		was, seen := previous[name]
+		if seen && was.Status == r.Status || !seen && r.Status == "up" {
+			continue
+		}
+		alert := domain.Alert{Key: "dependency." + name, Source: "dependency." + name}
+		if r.Status == "up" {
+			alert.Resolved = true
+		} else {
+			alert.Severity = domain.AlertCritical
+			alert.Summary = "dependency " + name + " is down"
+			alert.Details = map[string]any{"error": r.Error}
+		}
+		p.alerter.Alert(ctx, alert)
+	}
+}
+
+func (p *probeSet) check(ctx context.Context) map[string]ProbeResult {
+	results := make(map[string]ProbeResult, len(p.probes))
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, np := range p.probes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, p.timeout)
+			defer cancel()
+
+			started := time.Now()
+			err := np.probe(ctx)
+			r := ProbeResult{Status: "up", LatencyMS: time.Since(started).Milliseconds()}
+			if err != nil {
+				r.Status, r.Error = "down", err.Error()
+			}
+			mu.Lock()
+			results[np.name] = r
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// Live GET /health/live — the process is up and serving HTTP. Dependencies are not checked,
+// so a broken database does not get the pod restarted.
+func (h *Handler) Live(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]any{"status": "ok", "region": h.region})
+}
+
+// Ready GET /health/ready (and /readyz) — 503 while draining or when any dependency probe fails.
+func (h *Handler) Ready(c echo.Context) error {
+	if h.draining.Load() {
+		return c.JSON(http.StatusServiceUnavailable, map[string]any{"status": "draining", "region": h.region})
+	}
+	checks, ok := h.probes.run(c.Request().Context())
+	status, code := "ready", http.StatusOK
+	if !ok {
+		status, code = "not_ready", http.StatusServiceUnavailable
+	}
+	return c.JSON(code, map[string]any{"status": status, "region": h.region, "checks": checks})
+}
@@ -0,0 +1,93 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func ready(t *testing.T, h *Handler) (int, map[string]any) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/health/ready", nil), rec)
+	if err := h.Ready(c); err != nil {
+		t.Fatal(err)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	return rec.Code, body
+}
+
+func TestReadyProbes(t *testing.T) {
+	h := NewHandler(nil, nil)
+	h.SetProbeOptions(time.Second, 0)
+	calls := 0
+	var kafkaErr error
+	h.AddProbe("postgres", func(context.Context) error { calls++; return nil })
+	h.AddProbe("kafka", func(context.Context) error { return kafkaErr })
+
+	if code, body := ready(t, h); code != http.StatusOK || body["status"] != "ready" {
+		t.Fatalf("all up: %d %v", code, body)
+	}
+
+	kafkaErr = errors.New("not a member of consumer group")
+	code, body := ready(t, h)
+	if code != http.StatusServiceUnavailable || body["status"] != "not_ready" {
+		t.Fatalf("kafka down: %d %v", code, body)
+	}
+	checks := body["checks"].(map[string]any)
+	if k := checks["kafka"].(map[string]any); k["status"] != "down" || k["error"] == nil {
+		t.Errorf("kafka check = %v", k)
+	}
+	if p := checks["postgres"].(map[string]any); p["status"] != "up" {
+		t.Errorf("postgres check = %v", p)
+	}
+
+	h.SetDraining()
+	if code, body := ready(t, h); code != http.StatusServiceUnavailable || body["status"] != "draining" {
+		t.Fatalf("draining: %d %v", code, body)
+	}
+	if calls != 2 {
+		t.Errorf("postgres probed %d times, want 2 (draining skips probes)", calls)
+	}
+}
+
+func TestReadyCachesResults(t *testing.T) {
+	h := NewHandler(nil, nil)
+	h.SetProbeOptions(time.Second, time.Minute)
+	calls := 0
+	h.AddProbe("postgres", func(context.Context) error { calls++; return nil })
+
+	ready(t, h)
+	ready(t, h)
+	if calls != 1 {
+		t.Fatalf("probe ran %d times within the cache window, want 1", calls)
+	}
+}
+
+func TestHealthReportsRegion(t *testing.T) {
+	h := NewHandler(nil, NewHub(HubConfig{}))
+	h.SetRegion("hn")
+	rec := httptest.NewRecorder()
+	if err := h.Health(echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/health", nil), rec)); err != nil {
+		t.Fatal(err)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body["region"] != "hn" {
+		t.Fatalf("health = %v, want region hn", body)
+	}
+	if _, body := ready(t, h); body["region"] != "hn" {
+		t.Fatalf("ready = %v, want region hn", body)
+	}
+}
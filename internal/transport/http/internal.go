@@ -0,0 +1,62 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/application"
+	"vn.io.arda/notification/internal/domain"
+	"vn.io.arda/notification/internal/kafka/registry"
+	"vn.io.arda/notification/internal/transport/mw"
+)
+
+// commandsTopic is the topic whose command format the internal endpoint accepts.
+const commandsTopic = "notification-commands"
+
+// maxCommandBytes bounds the body of POST /internal/notifications.
+const maxCommandBytes = 1 << 20
+
+// SetServiceAccounts enables the /internal routes, callable only with a
+// service account token of a registered client.
+func (h *Handler) SetServiceAccounts(sa *mw.ServiceAccounts) {
+	h.serviceAccounts = sa
+}
+
+// InternalNotify POST /internal/notifications
+// Body: a notification-commands command (see the README); commandId is required
+// and makes retries idempotent. Creates the notification for a single user
+// (targetScope USER) or fans it out, synchronously, like the Kafka topic would.
+func (h *Handler) InternalNotify(c echo.Context) error {
+	data, err := io.ReadAll(io.LimitReader(c.Request().Body, maxCommandBytes))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	_, fanout, err := registry.Route(commandsTopic, registry.Headers{}, data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if fanout == nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid command: a target is required")
+	}
+	if fanout.SourceEventID == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "commandId is required")
+	}
+
+	client, _ := c.Get("serviceClient").(string)
+	ctx := application.WithSourceTopic(c.Request().Context(), "internal:"+client)
+	err = h.svc.Fanout(ctx, *fanout)
+	switch {
+	case errors.Is(err, application.ErrRateLimited), errors.Is(err, application.ErrQuotaExceeded):
+		return echo.NewHTTPError(http.StatusTooManyRequests, err.Error())
+	case errors.Is(err, domain.ErrUnknownType), errors.Is(err, application.ErrRecipientCapExceeded),
+		errors.Is(err, domain.ErrInvalidMetadata), errors.Is(err, application.ErrMetadataTooLarge):
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	case err != nil:
+		log.Error().Err(err).Str("client", client).Str("command_id", fanout.SourceEventID).Msg("internal notify failed")
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusAccepted, map[string]any{"command_id": fanout.SourceEventID, "tenant": fanout.TenantKey})
+}
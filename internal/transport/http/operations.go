@@ -0,0 +1,99 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+	kafkaconsumer "vn.io.arda/notification/internal/kafka"
+)
+
+// Replayer re-consumes a topic range and fans its events out again.
+// Implemented by kafka.Replay bound to the service.
+type Replayer func(ctx context.Context, cfg kafkaconsumer.ReplayConfig) (kafkaconsumer.ReplayStats, error)
+
+// SetRetentionDays enables POST /notifications/admin/purge; days returns the
+// current TTL, which follows config reloads.
+func (h *Handler) SetRetentionDays(days func() int) {
+	h.retentionDays = days
+}
+
+// SetReplayer enables POST /notifications/admin/replay.
+func (h *Handler) SetReplayer(r Replayer) {
+	h.replayer = r
+}
+
+// Purge POST /notifications/admin/purge
+// Body: { "dry_run": true, "older_than_days": 30 }; older_than_days defaults to the configured TTL.
+// Runs the notification part of the retention job now, or with dry_run counts what it would delete.
+func (h *Handler) Purge(c echo.Context) error {
+	if h.retentionDays == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "on-demand purge is not enabled")
+	}
+	var body struct {
+		DryRun        bool `json:"dry_run"`
+		OlderThanDays *int `json:"older_than_days"`
+	}
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	days := h.retentionDays()
+	if body.OlderThanDays != nil {
+		if *body.OlderThanDays <= 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "older_than_days must be positive")
+		}
+		days = *body.OlderThanDays
+	}
+	if !body.DryRun {
+		_, requester := mustClaims(c)
+		log.Warn().Str("requester", requester).Int("older_than_days", days).Msg("on-demand purge requested")
+	}
+	result, err := h.svc.Purge(c.Request().Context(), days, body.DryRun)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": result})
+}
+
+// Replay POST /notifications/admin/replay
+// Body: { "topic": "crm-events", "from": "2026-03-01T08:00:00Z", "to": "2026-03-01T12:00:00Z", "dry_run": true }
+// Same as the replay subcommand, run by this instance; the request lasts until the replay ends.
+func (h *Handler) Replay(c echo.Context) error {
+	if h.replayer == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "replay is not enabled")
+	}
+	var body struct {
+		Topic      string    `json:"topic"`
+		Partitions []int32   `json:"partitions"`
+		From       time.Time `json:"from"`
+		To         time.Time `json:"to"`
+		FromOffset *int64    `json:"from_offset"`
+		ToOffset   *int64    `json:"to_offset"`
+		DryRun     bool      `json:"dry_run"`
+		Force      bool      `json:"force"`
+	}
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	if body.Topic == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "topic is required")
+	}
+	rc := kafkaconsumer.ReplayConfig{Topic: body.Topic, Partitions: body.Partitions, From: body.From, To: body.To,
+		FromOffset: -1, ToOffset: -1, DryRun: body.DryRun, Force: body.Force}
+	if body.FromOffset != nil {
+		rc.FromOffset = *body.FromOffset
+	}
+	if body.ToOffset != nil {
+		rc.ToOffset = *body.ToOffset
+	}
+	_, requester := mustClaims(c)
+	log.Info().Str("requester", requester).Str("topic", rc.Topic).Bool("dry_run", rc.DryRun).Msg("replay requested")
+
+	stats, err := h.replayer(c.Request().Context(), rc)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]any{"error": err.Error(), "data": stats})
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": stats})
+}
@@ -0,0 +1,46 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"vn.io.arda/notification/internal/metrics"
+)
+
+// defaultQueryStatsLimit is the number of query shapes DBQueryStats returns by default.
+const defaultQueryStatsLimit = 50
+
+// QueryStatsReporter reports per-query-shape database stats. Implemented by
+// postgres.QueryTracer.
+type QueryStatsReporter interface {
+	QueryStats() []metrics.QueryStat
+}
+
+// SetQueryStats enables GET /notifications/admin/db/queries.
+func (h *Handler) SetQueryStats(q QueryStatsReporter) {
+	h.queryStats = q
+}
+
+// DBQueryStats GET /notifications/admin/db/queries?limit=50
+// Calls, rows, errors, slow calls and latency per query shape on this instance,
+// by total time spent, highest first.
+func (h *Handler) DBQueryStats(c echo.Context) error {
+	if h.queryStats == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "query instrumentation is not enabled")
+	}
+	limit := defaultQueryStatsLimit
+	if v := c.QueryParam("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "limit must be a positive integer")
+		}
+		limit = n
+	}
+	stats := h.queryStats.QueryStats()
+	total := len(stats)
+	if len(stats) > limit {
+		stats = stats[:limit]
+	}
+	return c.JSON(http.StatusOK, map[string]any{"region": h.region, "data": stats, "total": total})
+}
@@ -0,0 +1,65 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// ListQuotas GET /notifications/admin/quotas — tenants not listed use the default quota
+func (h *Handler) ListQuotas(c echo.Context) error {
+	quotas, err := h.svc.ListQuotas(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if quotas == nil {
+		quotas = []domain.TenantQuota{}
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": quotas})
+}
+
+// UpsertQuota PUT /notifications/admin/quotas/:tenant
+// Body: { "monthly_limit": 100000, "overage": "reject" | "queue" }; a limit of 0
+// means unlimited.
+func (h *Handler) UpsertQuota(c echo.Context) error {
+	var body struct {
+		MonthlyLimit int64               `json:"monthly_limit"`
+		Overage      domain.QuotaOverage `json:"overage"`
+	}
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	saved, err := h.svc.UpsertQuota(c.Request().Context(), domain.TenantQuota{
+		TenantKey:    c.Param("tenant"),
+		MonthlyLimit: body.MonthlyLimit,
+		Overage:      body.Overage,
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": saved})
+}
+
+// DeleteQuota DELETE /notifications/admin/quotas/:tenant — back to the default quota
+func (h *Handler) DeleteQuota(c echo.Context) error {
+	if err := h.svc.DeleteQuota(c.Request().Context(), c.Param("tenant")); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// TenantUsage GET /notifications/admin/tenants/:key/usage?months=6
+// The tenant's quota, this month's usage and remaining quota, the fan-outs held
+// over quota and the usage of the last months.
+func (h *Handler) TenantUsage(c echo.Context) error {
+	months := parseIntQuery(c, "months", 6)
+	if months < 1 || months > 24 {
+		return echo.NewHTTPError(http.StatusBadRequest, "months must be between 1 and 24")
+	}
+	report, err := h.svc.TenantUsage(c.Request().Context(), c.Param("key"), months)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": report})
+}
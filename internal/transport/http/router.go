@@ -1,45 +1,165 @@
 package http
 
 import (
+	"math"
+	"net/netip"
+	"strings"
+
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"golang.org/x/time/rate"
 	"vn.io.arda/notification/internal/transport/mw"
 )
 
+// adminPrefix starts the admin routes, which may act on any tenant.
+const adminPrefix = "/notifications/admin/"
+
+// SecurityConfig configures authentication and the global CORS, security
+// header and rate limit middleware.
+type SecurityConfig struct {
+	// TrustedHeaders authenticates API requests from the identity headers of
+	// the gateway (mw.TrustedHeaderAuth) instead of X-Internal-Token, accepting
+	// them only from TrustedProxies when that is set.
+	TrustedHeaders bool
+	TrustedProxies []netip.Prefix
+
+	// AdminRole, AuditorRole and PlatformAdminRole gate the admin routes;
+	// only PlatformAdminRole may act on tenants other than the caller's.
+	AdminRole         string
+	AuditorRole       string
+	PlatformAdminRole string
+
+	// CORS enables the CORS middleware. Turn it off when the API gateway
+	// answers preflight requests itself.
+	CORS bool
+	// CORSOrigins lists the allowed origins ("*" allows any). With none,
+	// no CORS headers are sent and browsers refuse cross-origin calls.
+	CORSOrigins []string
+	// SecureHeaders sets X-Content-Type-Options, X-Frame-Options and, on
+	// HTTPS requests with HSTSMaxAge > 0, Strict-Transport-Security.
+	SecureHeaders bool
+	HSTSMaxAge    int
+	// RatePerSecond limits requests per client IP; 0 disables the limit.
+	// Health endpoints are never limited.
+	RatePerSecond float64
+	RateBurst     int
+}
+
 // NewRouter sets up all Echo routes and middleware.
 // keycloakBaseURL is kept for backward compatibility but no longer used for auth.
 // Authentication is now handled via X-Internal-Token from APISIX Gateway.
-func NewRouter(h *Handler, keycloakBaseURL string) *echo.Echo {
+func NewRouter(h *Handler, keycloakBaseURL string, sec SecurityConfig) *echo.Echo {
 	e := echo.New()
 	e.HideBanner = true
+	h.roles = roleNames{admin: sec.AdminRole, auditor: sec.AuditorRole, platformAdmin: sec.PlatformAdminRole}
 
 	// Global middleware
 	e.Use(middleware.Recover())
 	e.Use(middleware.RequestID())
 	e.Use(middleware.Logger())
-	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: []string{"*"},
-		AllowHeaders: []string{"Authorization", "Content-Type", "X-Tenant-ID", "X-Internal-Token"},
-		AllowMethods: []string{"GET", "POST", "PATCH", "DELETE", "OPTIONS"},
-	}))
+	if sec.SecureHeaders {
+		e.Use(middleware.SecureWithConfig(middleware.SecureConfig{
+			ContentTypeNosniff: "nosniff",
+			XFrameOptions:      "DENY",
+			HSTSMaxAge:         sec.HSTSMaxAge,
+		}))
+	}
+	if sec.CORS && len(sec.CORSOrigins) > 0 {
+		e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+			AllowOrigins: sec.CORSOrigins,
+			AllowHeaders: []string{"Authorization", "Content-Type", "X-Tenant-ID", "X-Internal-Token", "X-SSE-Client-ID"},
+			AllowMethods: []string{"GET", "POST", "PATCH", "PUT", "DELETE", "OPTIONS"},
+		}))
+	}
+	if sec.RatePerSecond > 0 {
+		burst := sec.RateBurst
+		if burst <= 0 {
+			burst = int(math.Ceil(sec.RatePerSecond))
+		}
+		e.Use(middleware.RateLimiterWithConfig(middleware.RateLimiterConfig{
+			Skipper: isHealthPath,
+			Store: middleware.NewRateLimiterMemoryStoreWithConfig(middleware.RateLimiterMemoryStoreConfig{
+				Rate:  rate.Limit(sec.RatePerSecond),
+				Burst: burst,
+			}),
+		}))
+	}
 
 	// Health (no auth required)
 	e.GET("/health", h.Health)
+	e.GET("/health/live", h.Live)
+	e.GET("/health/ready", h.Ready)
+	e.GET("/readyz", h.Ready)
+
+	// Embedded widget — read-only subset authenticated by a widget token instead of Keycloak
+	if h.widgetTokens != nil {
+		w := e.Group("/widget", h.widgetTokens.Auth(), mw.TenantScope(adminPrefix, sec.PlatformAdminRole), h.trackTenantActivity)
+		w.GET("/notifications", h.ListNotifications)
+		w.GET("/notifications/unread-count", h.GetUnreadCount)
+		w.GET("/notifications/stream", h.Stream)
+		w.POST("/notifications/stream/refresh", h.RefreshStream)
+	}
+
+	// Service-to-service endpoints — client-credentials tokens of registered services only
+	if h.serviceAccounts != nil {
+		in := e.Group("/internal", h.serviceAccounts.Auth())
+		in.POST("/notifications", h.InternalNotify)
+	}
 
 	// API — requires authentication via APISIX Internal JWT (X-Internal-Token)
 	v1 := e.Group("")
-	v1.Use(mw.InternalJWTAuth())
+	if sec.TrustedHeaders {
+		v1.Use(mw.TrustedHeaderAuth(sec.TrustedProxies))
+	} else {
+		v1.Use(mw.InternalJWTAuth())
+	}
 	v1.Use(mw.TenantResolver())
+	v1.Use(mw.TenantScope(adminPrefix, sec.PlatformAdminRole))
+	v1.Use(h.trackTenantActivity)
+	platformAdmin := mw.RequireRole(sec.PlatformAdminRole)
+	auditor := mw.RequireRole(sec.AuditorRole, sec.AdminRole, sec.PlatformAdminRole)
+	admin := mw.RequireRole(sec.AdminRole, sec.PlatformAdminRole)
 
 	// REST endpoints
 	v1.GET("/notifications", h.ListNotifications)
 	v1.GET("/notifications/unread-count", h.GetUnreadCount)
+	v1.GET("/notifications/counts", h.GetCounts)
+	v1.GET("/notifications/export", h.Export)
+	v1.GET("/notifications/by-entity/:type/:id", h.ListByEntity)
 	v1.PATCH("/notifications/:id/read", h.MarkRead)
+	v1.PATCH("/notifications/:id/pin", h.Pin)
 	v1.POST("/notifications/read-all", h.MarkAllRead)
+	v1.POST("/notifications/read-state", h.SyncReadState)
 	v1.DELETE("/notifications/:id", h.Delete)
 
+	// State stream: seen tracking, cross-device sync, history and undo
+	v1.POST("/notifications/seen", h.MarkSeen)
+	v1.GET("/notifications/events", h.StateEvents)
+	v1.GET("/notifications/:id/history", h.NotificationHistory)
+	v1.POST("/notifications/:id/undo", h.Undo)
+
+	// Snooze
+	v1.POST("/notifications/:id/snooze", h.Snooze)
+	v1.GET("/notifications/snoozed", h.ListSnoozed)
+
+	// End-to-end delivery check for the caller
+	v1.POST("/notifications/test", h.SendTestNotification)
+
+	// User-to-user notifications (@mentions)
+	v1.POST("/notifications/send", h.SendDirectMessage)
+
 	// SSE endpoint
 	v1.GET("/notifications/stream", h.Stream)
+	v1.POST("/notifications/stream/refresh", h.RefreshStream)
+
+	// Widget token issuance (tenant backend)
+	if h.widgetTokens != nil {
+		v1.POST("/widget-token", h.IssueWidgetToken)
+	}
+
+	// Announcement banners
+	v1.GET("/announcements", h.ListAnnouncements)
+	v1.POST("/announcements/:id/dismiss", h.DismissAnnouncement)
 
 	// Preference endpoints
 	v1.GET("/notifications/preferences", h.GetPreferences)
@@ -47,11 +167,144 @@ func NewRouter(h *Handler, keycloakBaseURL string) *echo.Echo {
 
 	// Action endpoint
 	v1.POST("/notifications/:id/action", h.ExecuteAction)
+	v1.POST("/notifications/:id/actions/:actionId", h.TakeAction)
+
+	// Reaction endpoints
+	v1.POST("/notifications/:id/reaction", h.React)
+	v1.GET("/notifications/:id/reactions", h.ListReactions)
+	v1.GET("/notifications/admin/reactions", h.ListReactionsBySourceEvent, auditor)
+	v1.GET("/notifications/admin/events/:id/trace", h.EventTrace, auditor)
+	v1.GET("/notifications/admin/users/:user/inbox", h.AuditInbox, auditor)
+	v1.GET("/notifications/admin/export", h.AdminExport, admin)
+	v1.GET("/notifications/admin/audit", h.ListAudit, auditor)
 
 	// Template admin endpoints
 	v1.GET("/notifications/admin/templates", h.ListTemplates)
 	v1.PUT("/notifications/admin/templates", h.UpsertTemplate)
 	v1.DELETE("/notifications/admin/templates/:key/:locale", h.DeleteTemplate)
 
+	// Tenant template overrides (white-label copy) and their inheritance chain
+	v1.GET("/notifications/admin/template-overrides", h.ListTemplateOverrides)
+	v1.PUT("/notifications/admin/template-overrides", h.UpsertTemplateOverride, admin)
+	v1.GET("/notifications/admin/template-overrides/diff", h.TemplateDiff)
+	v1.DELETE("/notifications/admin/template-overrides/:key/:locale", h.DeleteTemplateOverride, admin)
+	v1.PUT("/notifications/admin/template-inheritance/:tenant", h.SetTemplateParent, platformAdmin)
+
+	// Notification types (built-in + tenant-registered)
+	v1.GET("/notifications/types", h.ListNotificationTypes)
+	v1.PUT("/notifications/admin/types/:type", h.UpsertCustomType, admin)
+	v1.DELETE("/notifications/admin/types/:type", h.DeleteCustomType, admin)
+
+	// Delivery policy (Rego) admin endpoints
+	v1.GET("/notifications/admin/policies", h.ListPolicies, platformAdmin)
+	v1.GET("/notifications/admin/policies/:tenant", h.GetPolicy, platformAdmin)
+	v1.PUT("/notifications/admin/policies/:tenant", h.UpsertPolicy, platformAdmin)
+	v1.DELETE("/notifications/admin/policies/:tenant", h.DeletePolicy, platformAdmin)
+
+	// Per-event-type defaults (priority, category, TTL, channels)
+	v1.GET("/notifications/admin/event-defaults", h.ListEventDefaults)
+	v1.PUT("/notifications/admin/event-defaults/:key", h.UpsertEventDefaults, platformAdmin)
+	v1.DELETE("/notifications/admin/event-defaults/:key", h.DeleteEventDefaults, platformAdmin)
+
+	// Retention policies per tenant and type
+	// (own tenant with the admin role, others or all tenants with the platform admin role)
+	v1.GET("/notifications/admin/retention-policies", h.ListRetentionPolicies, admin)
+	v1.PUT("/notifications/admin/retention-policies", h.UpsertRetentionPolicy, admin)
+	v1.DELETE("/notifications/admin/retention-policies", h.DeleteRetentionPolicy, admin)
+
+	// Tenant encryption key (BYOK) admin endpoints
+	v1.GET("/notifications/admin/encryption-keys", h.ListEncryptionKeys, platformAdmin)
+	v1.PUT("/notifications/admin/encryption-keys/:tenant", h.RegisterEncryptionKey, platformAdmin)
+	v1.DELETE("/notifications/admin/encryption-keys/:tenant", h.DeleteEncryptionKey, platformAdmin)
+	v1.POST("/notifications/admin/encryption-keys/:tenant/rotate", h.RotateEncryptionKey, platformAdmin)
+
+	// Webhook admin endpoints
+	v1.GET("/notifications/admin/webhooks", h.ListWebhooks, admin)
+	v1.POST("/notifications/admin/webhooks", h.CreateWebhook, admin)
+	v1.PUT("/notifications/admin/webhooks/:id", h.UpdateWebhook, admin)
+	v1.DELETE("/notifications/admin/webhooks/:id", h.DeleteWebhook, admin)
+	v1.GET("/notifications/admin/webhooks/:id/deliveries", h.ListWebhookDeliveries, admin)
+	v1.POST("/notifications/admin/webhooks/deliveries/:delivery/retry", h.RetryWebhookDelivery, admin)
+
+	// Slack / Teams connector admin endpoints
+	v1.GET("/notifications/admin/chat-connectors", h.ListChatConnectors, admin)
+	v1.POST("/notifications/admin/chat-connectors", h.CreateChatConnector, admin)
+	v1.PUT("/notifications/admin/chat-connectors/:id", h.UpdateChatConnector, admin)
+	v1.DELETE("/notifications/admin/chat-connectors/:id", h.DeleteChatConnector, admin)
+	v1.POST("/notifications/admin/chat-connectors/:id/test", h.TestChatConnector, admin)
+
+	// Escalation rule admin endpoints
+	v1.GET("/notifications/admin/escalation-rules", h.ListEscalationRules, admin)
+	v1.POST("/notifications/admin/escalation-rules", h.CreateEscalationRule, admin)
+	v1.PUT("/notifications/admin/escalation-rules/:id", h.UpdateEscalationRule, admin)
+	v1.DELETE("/notifications/admin/escalation-rules/:id", h.DeleteEscalationRule, admin)
+
+	// Announcement admin endpoints
+	v1.GET("/notifications/admin/announcements", h.ListAllAnnouncements, admin)
+	v1.POST("/notifications/admin/announcements", h.CreateAnnouncement, admin)
+	v1.PUT("/notifications/admin/announcements/:id", h.UpdateAnnouncement, admin)
+	v1.DELETE("/notifications/admin/announcements/:id", h.DeleteAnnouncement, admin)
+
+	// Direct message rule admin endpoints
+	v1.GET("/notifications/admin/direct-message-rule", h.GetDirectMessageRule)
+	v1.PUT("/notifications/admin/direct-message-rule", h.UpsertDirectMessageRule, admin)
+	v1.DELETE("/notifications/admin/direct-message-rule", h.DeleteDirectMessageRule, admin)
+
+	// Maintenance window admin endpoints
+	v1.GET("/notifications/admin/maintenance-windows", h.ListMaintenanceWindows, admin)
+	v1.POST("/notifications/admin/maintenance-windows", h.CreateMaintenanceWindow, admin)
+	v1.PUT("/notifications/admin/maintenance-windows/:id", h.UpdateMaintenanceWindow, admin)
+	v1.DELETE("/notifications/admin/maintenance-windows/:id", h.DeleteMaintenanceWindow, admin)
+
+	// SSE hub instrumentation
+	v1.GET("/notifications/admin/sse/latency", h.SSELatency, platformAdmin)
+	v1.GET("/notifications/admin/sse/clients", h.SSEClients, admin)
+	v1.DELETE("/notifications/admin/sse/clients", h.DisconnectSSEClients, admin)
+
+	// Fan-out instrumentation
+	v1.GET("/notifications/admin/fanout/stats", h.FanoutStats, platformAdmin)
+
+	// Engagement stats (nightly rollup)
+	v1.GET("/notifications/admin/stats", h.EngagementStats, admin)
+
+	// Tenant quota and usage admin endpoints
+	v1.GET("/notifications/admin/quotas", h.ListQuotas, platformAdmin)
+	v1.PUT("/notifications/admin/quotas/:tenant", h.UpsertQuota, platformAdmin)
+	v1.DELETE("/notifications/admin/quotas/:tenant", h.DeleteQuota, platformAdmin)
+	v1.GET("/notifications/admin/tenants/:key/usage", h.TenantUsage, platformAdmin)
+
+	// Kafka event handler health
+	v1.GET("/notifications/admin/handlers/health", h.EventHandlerHealth, platformAdmin)
+
+	// Kafka consumption controls
+	v1.GET("/notifications/admin/consumer/status", h.ConsumerStatus, platformAdmin)
+	v1.POST("/notifications/admin/consumer/pause", h.PauseConsumer, platformAdmin)
+	v1.POST("/notifications/admin/consumer/resume", h.ResumeConsumer, platformAdmin)
+
+	// On-demand operations (used by ardanotif)
+	v1.POST("/notifications/admin/purge", h.Purge, platformAdmin)
+	v1.POST("/notifications/admin/replay", h.Replay, platformAdmin)
+
+	// Database query instrumentation
+	v1.GET("/notifications/admin/db/queries", h.DBQueryStats, platformAdmin)
+
+	// IAM resolver cache instrumentation
+	v1.GET("/notifications/admin/iam/cache", h.IAMCacheStats, platformAdmin)
+
+	// Scope resolution dry-run
+	v1.POST("/notifications/admin/scopes/resolve", h.ResolveScope, admin)
+
+	// Staged platform rollout admin endpoints
+	v1.GET("/notifications/admin/rollouts", h.ListRollouts, platformAdmin)
+	v1.POST("/notifications/admin/rollouts/:id/release", h.ReleaseRollout, platformAdmin)
+	v1.POST("/notifications/admin/rollouts/:id/cancel", h.CancelRollout, platformAdmin)
+
 	return e
 }
+
+// isHealthPath reports whether the request targets a probe endpoint, which
+// the rate limiter must never reject.
+func isHealthPath(c echo.Context) bool {
+	p := c.Request().URL.Path
+	return p == "/readyz" || p == "/health" || strings.HasPrefix(p, "/health/")
+}
@@ -0,0 +1,274 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"vn.io.arda/notification/internal/application"
+	"vn.io.arda/notification/internal/domain"
+	"vn.io.arda/notification/internal/testsupport"
+	"vn.io.arda/notification/internal/transport/mw"
+)
+
+func serve(sec SecurityConfig, method, path string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	NewRouter(NewHandler(nil, nil), "", sec).ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRouterSecurity(t *testing.T) {
+	preflight := http.Header{
+		"Origin":                        {"https://app.arda.vn"},
+		"Access-Control-Request-Method": {"GET"},
+	}
+
+	rec := serve(SecurityConfig{CORS: true, CORSOrigins: []string{"https://app.arda.vn"}}, http.MethodOptions, "/notifications", preflight)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://app.arda.vn" {
+		t.Errorf("allowed origin: Access-Control-Allow-Origin = %q", got)
+	}
+	rec = serve(SecurityConfig{CORS: true, CORSOrigins: []string{"https://admin.arda.vn"}}, http.MethodOptions, "/notifications", preflight)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("other origin: Access-Control-Allow-Origin = %q", got)
+	}
+	rec = serve(SecurityConfig{CORS: false, CORSOrigins: []string{"*"}}, http.MethodOptions, "/notifications", preflight)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("CORS disabled: Access-Control-Allow-Origin = %q", got)
+	}
+
+	rec = serve(SecurityConfig{SecureHeaders: true, HSTSMaxAge: 3600}, http.MethodGet, "/health/live",
+		http.Header{"X-Forwarded-Proto": {"https"}})
+	if got := rec.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Errorf("X-Content-Type-Options = %q", got)
+	}
+	if got := rec.Header().Get("Strict-Transport-Security"); got != "max-age=3600; includeSubdomains" {
+		t.Errorf("Strict-Transport-Security = %q", got)
+	}
+}
+
+func TestRouterRateLimit(t *testing.T) {
+	sec := SecurityConfig{RatePerSecond: 0.001, RateBurst: 2}
+	e := NewRouter(NewHandler(nil, nil), "", sec)
+	do := func(path string) int {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+
+	for i := range 2 {
+		if code := do("/notifications"); code == http.StatusTooManyRequests {
+			t.Fatalf("request %d rejected within the burst", i)
+		}
+	}
+	if code := do("/notifications"); code != http.StatusTooManyRequests {
+		t.Fatalf("request over the limit: %d, want 429", code)
+	}
+	if code := do("/health/live"); code != http.StatusOK {
+		t.Fatalf("health probe: %d, want 200", code)
+	}
+}
+
+func TestAdminRoutesRequireRoles(t *testing.T) {
+	sec := SecurityConfig{TrustedHeaders: true, AdminRole: "ADMIN", AuditorRole: "AUDITOR", PlatformAdminRole: "PLATFORM_ADMIN"}
+	ctx := context.Background()
+	svc := application.NewService(testsupport.NewRepository(), testsupport.NewHub(), testsupport.NewResolver(),
+		application.WithMaintenance(testsupport.NewMaintenance()), application.WithAnnouncements(testsupport.NewAnnouncements()))
+	window := func(tenantKey string) string {
+		w, err := svc.CreateMaintenanceWindow(ctx, domain.MaintenanceWindow{
+			TenantKey: tenantKey, Mode: domain.MaintenanceSuppress, EndsAt: time.Now().Add(time.Hour),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return "/notifications/admin/maintenance-windows/" + w.ID.String()
+	}
+	acmeWindow, globexWindow, globalWindow := window("acme"), window("globex"), window("")
+	announcement := func(tenantKey string) string {
+		a, err := svc.CreateAnnouncement(ctx, domain.Announcement{TenantKey: tenantKey, Title: "t", Severity: domain.SeverityInfo})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return "/notifications/admin/announcements/" + a.ID.String()
+	}
+	acmeBanner, globexBanner, platformBanner := announcement("acme"), announcement("globex"), announcement("")
+	windowBody := `{"mode":"suppress","ends_at":"` + time.Now().Add(time.Hour).Format(time.RFC3339) + `"}`
+
+	e := NewRouter(NewHandler(svc, NewHub(HubConfig{})), "", sec)
+	do := func(method, path, body, roles string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set("X-User-ID", "u1")
+		req.Header.Set("X-Tenant-Key", "acme")
+		req.Header.Set("X-Roles", roles)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// The caller is an acme user.
+	tests := []struct {
+		method, path, body string
+		// allowed holds the least privileged role accepted; the roles below it get 403.
+		allowed string
+	}{
+		{http.MethodGet, "/notifications/admin/reactions?source_event_id=evt-1", "", "AUDITOR"},
+		{http.MethodGet, "/notifications/admin/events/evt-1/trace", "", "AUDITOR"},
+		{http.MethodGet, "/notifications/admin/audit", "", "AUDITOR"},
+		{http.MethodGet, "/notifications/admin/users/u2/inbox?as_of=2026-03-01T09:00:00Z", "", "AUDITOR"},
+		{http.MethodPost, "/notifications/admin/purge", "", "PLATFORM_ADMIN"},
+		{http.MethodPost, "/notifications/admin/replay", "", "PLATFORM_ADMIN"},
+		{http.MethodGet, "/notifications/admin/sse/latency", "", "PLATFORM_ADMIN"},
+		{http.MethodGet, "/notifications/admin/fanout/stats", "", "PLATFORM_ADMIN"},
+		{http.MethodGet, "/notifications/admin/handlers/health", "", "PLATFORM_ADMIN"},
+		{http.MethodGet, "/notifications/admin/db/queries", "", "PLATFORM_ADMIN"},
+		{http.MethodGet, "/notifications/admin/iam/cache", "", "PLATFORM_ADMIN"},
+		{http.MethodGet, "/notifications/admin/consumer/status", "", "PLATFORM_ADMIN"},
+		{http.MethodPost, "/notifications/admin/consumer/pause", `{"topic":"crm-events"}`, "PLATFORM_ADMIN"},
+		{http.MethodPost, "/notifications/admin/consumer/resume", `{"topic":"crm-events"}`, "PLATFORM_ADMIN"},
+		{http.MethodGet, "/notifications/admin/rollouts", "", "PLATFORM_ADMIN"},
+		{http.MethodPost, "/notifications/admin/rollouts/" + uuid.NewString() + "/release", "", "PLATFORM_ADMIN"},
+		{http.MethodPost, "/notifications/admin/rollouts/" + uuid.NewString() + "/cancel", "", "PLATFORM_ADMIN"},
+		{http.MethodPut, "/notifications/admin/template-inheritance/acme", `{"inherits_from":"reseller"}`, "PLATFORM_ADMIN"},
+		{http.MethodPut, "/notifications/admin/event-defaults/crm-events:DEAL_UPDATED", `{"priority":"HIGH"}`, "PLATFORM_ADMIN"},
+		{http.MethodDelete, "/notifications/admin/event-defaults/crm-events:DEAL_UPDATED", "", "PLATFORM_ADMIN"},
+		{http.MethodGet, "/notifications/admin/retention-policies", "", "ADMIN"},
+		{http.MethodGet, "/notifications/admin/retention-policies?tenant_key=globex", "", "PLATFORM_ADMIN"},
+		{http.MethodPut, "/notifications/admin/retention-policies", `{"tenant_key":"acme","retention_days":30}`, "ADMIN"},
+		{http.MethodPut, "/notifications/admin/retention-policies", `{"tenant_key":"globex","retention_days":1}`, "PLATFORM_ADMIN"},
+		{http.MethodPut, "/notifications/admin/retention-policies", `{"retention_days":1}`, "PLATFORM_ADMIN"},
+		{http.MethodDelete, "/notifications/admin/retention-policies?tenant_key=acme", "", "ADMIN"},
+		{http.MethodDelete, "/notifications/admin/retention-policies?tenant_key=globex", "", "PLATFORM_ADMIN"},
+		{http.MethodGet, "/notifications/admin/maintenance-windows", "", "ADMIN"},
+		{http.MethodGet, "/notifications/admin/maintenance-windows?tenant_key=globex", "", "PLATFORM_ADMIN"},
+		{http.MethodPost, "/notifications/admin/maintenance-windows", `{"tenant_key":"acme","mode":"batch"}`, "ADMIN"},
+		{http.MethodPost, "/notifications/admin/maintenance-windows", `{"mode":"suppress"}`, "PLATFORM_ADMIN"},
+		{http.MethodPost, "/notifications/admin/maintenance-windows", `{"tenant_key":"globex","mode":"suppress"}`, "PLATFORM_ADMIN"},
+		{http.MethodPut, acmeWindow, windowBody, "ADMIN"},
+		{http.MethodPut, globexWindow, windowBody, "PLATFORM_ADMIN"},
+		{http.MethodPut, globalWindow, windowBody, "PLATFORM_ADMIN"},
+		{http.MethodDelete, acmeWindow, "", "ADMIN"},
+		{http.MethodDelete, globexWindow, "", "PLATFORM_ADMIN"},
+		{http.MethodDelete, globalWindow, "", "PLATFORM_ADMIN"},
+		{http.MethodGet, "/notifications/admin/announcements", "", "ADMIN"},
+		{http.MethodGet, "/notifications/admin/announcements?tenant_key=globex", "", "PLATFORM_ADMIN"},
+		{http.MethodPost, "/notifications/admin/announcements", `{"tenant_key":"acme","title":"t"}`, "ADMIN"},
+		{http.MethodPost, "/notifications/admin/announcements", `{"title":"t"}`, "PLATFORM_ADMIN"},
+		{http.MethodPost, "/notifications/admin/announcements", `{"tenant_key":"globex","title":"t"}`, "PLATFORM_ADMIN"},
+		{http.MethodPut, acmeBanner, `{"title":"t2"}`, "ADMIN"},
+		{http.MethodPut, globexBanner, `{"title":"t2"}`, "PLATFORM_ADMIN"},
+		{http.MethodPut, platformBanner, `{"title":"t2"}`, "PLATFORM_ADMIN"},
+		{http.MethodDelete, acmeBanner, "", "ADMIN"},
+		{http.MethodDelete, globexBanner, "", "PLATFORM_ADMIN"},
+		{http.MethodDelete, platformBanner, "", "PLATFORM_ADMIN"},
+		{http.MethodGet, "/notifications/admin/webhooks", "", "ADMIN"},
+		{http.MethodPost, "/notifications/admin/webhooks", `{"url":"https://203.0.113.10/hook","events":["notification.created"]}`, "ADMIN"},
+		{http.MethodPut, "/notifications/admin/types/INVOICE_DUE", `{"name":"Invoice due"}`, "ADMIN"},
+		{http.MethodDelete, "/notifications/admin/types/INVOICE_DUE", "", "ADMIN"},
+		{http.MethodPut, "/notifications/admin/template-overrides", `{"template_key":"bpm.task_assigned","locale":"vi","title_template":"t"}`, "ADMIN"},
+		{http.MethodDelete, "/notifications/admin/template-overrides/bpm.task_assigned/vi", "", "ADMIN"},
+		{http.MethodPut, "/notifications/admin/direct-message-rule", `{"enabled":true,"max_recipients":5,"per_minute":10}`, "ADMIN"},
+		{http.MethodDelete, "/notifications/admin/direct-message-rule", "", "ADMIN"},
+		{http.MethodPost, "/notifications/admin/scopes/resolve", `{"targetScope":"TENANT"}`, "ADMIN"},
+		{http.MethodPost, "/notifications/admin/scopes/resolve", `{"targetScope":"TENANT","tenantKey":"globex"}`, "PLATFORM_ADMIN"},
+		{http.MethodPost, "/notifications/admin/scopes/resolve", `{"targetScope":"PLATFORM"}`, "PLATFORM_ADMIN"},
+		{http.MethodPost, "/notifications/admin/scopes/resolve", `{"targets":[{"scope":"USER","id":"u2"},{"scope":"PLATFORM"}]}`, "PLATFORM_ADMIN"},
+		{http.MethodGet, "/notifications/admin/stats", "", "ADMIN"},
+		{http.MethodGet, "/notifications/admin/stats?tenant_key=globex", "", "PLATFORM_ADMIN"},
+		{http.MethodGet, "/notifications/admin/sse/clients", "", "ADMIN"},
+		{http.MethodGet, "/notifications/admin/sse/clients?tenant=acme", "", "ADMIN"},
+		{http.MethodGet, "/notifications/admin/sse/clients?tenant=globex", "", "PLATFORM_ADMIN"},
+		{http.MethodDelete, "/notifications/admin/sse/clients?user=u2", "", "ADMIN"},
+		{http.MethodDelete, "/notifications/admin/sse/clients?tenant=globex&user=u2", "", "PLATFORM_ADMIN"},
+		{http.MethodGet, "/notifications/admin/escalation-rules", "", "ADMIN"},
+		{http.MethodPost, "/notifications/admin/escalation-rules", `{"priority":"URGENT","after_seconds":3600,"action":"renotify"}`, "ADMIN"},
+		{http.MethodPut, "/notifications/admin/escalation-rules/" + uuid.NewString(), `{"priority":"URGENT","after_seconds":3600,"action":"renotify"}`, "ADMIN"},
+		{http.MethodDelete, "/notifications/admin/escalation-rules/" + uuid.NewString(), "", "ADMIN"},
+		{http.MethodGet, "/notifications/admin/chat-connectors", "", "ADMIN"},
+		{http.MethodPost, "/notifications/admin/chat-connectors", `{"name":"ops","provider":"slack","url":"https://hooks.slack.com/services/x"}`, "ADMIN"},
+	}
+	below := map[string][]string{
+		"AUDITOR":        {"USER"},
+		"ADMIN":          {"USER", "AUDITOR"},
+		"PLATFORM_ADMIN": {"USER", "AUDITOR", "ADMIN"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path+" "+tt.body, func(t *testing.T) {
+			for _, role := range below[tt.allowed] {
+				if code := do(tt.method, tt.path, tt.body, role); code != http.StatusForbidden {
+					t.Errorf("%s: %d, want 403", role, code)
+				}
+			}
+			if code := do(tt.method, tt.path, tt.body, tt.allowed); code == http.StatusForbidden {
+				t.Errorf("%s: 403", tt.allowed)
+			}
+		})
+	}
+}
+
+func TestWidgetRoutes(t *testing.T) {
+	sec := SecurityConfig{TrustedHeaders: true}
+	svc := application.NewService(testsupport.NewRepository(), testsupport.NewHub(), testsupport.NewResolver())
+	wt := mw.NewWidgetTokens("widget-secret", time.Hour)
+
+	token, _, err := wt.Issue("acme", "u2", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without an issuer role the widget stays disabled and its tokens are not accepted.
+	h := NewHandler(svc, NewHub(HubConfig{}))
+	h.SetWidgetTokens(wt, "")
+	rec := httptest.NewRecorder()
+	NewRouter(h, "", sec).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/widget/notifications?token="+token, nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("widget route without an issuer role: %d, want 401", rec.Code)
+	}
+
+	h = NewHandler(svc, NewHub(HubConfig{}))
+	h.SetWidgetTokens(wt, "WIDGET_ISSUER")
+	e := NewRouter(h, "", sec)
+	issue := func(roles string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/widget-token", strings.NewReader(`{"user_id":"u2"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set("X-User-ID", "backend")
+		req.Header.Set("X-Tenant-Key", "acme")
+		req.Header.Set("X-Roles", roles)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := issue("ADMIN"); rec.Code != http.StatusForbidden {
+		t.Fatalf("issue without the issuer role: %d, want 403", rec.Code)
+	}
+	rec = issue("WIDGET_ISSUER")
+	var issued struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &issued); rec.Code != http.StatusOK || err != nil || issued.Token == "" {
+		t.Fatalf("issue: %d %s", rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/widget/notifications/unread-count?token="+issued.Token, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unread count with a widget token: %d %s", rec.Code, rec.Body)
+	}
+	// The widget token is read-only: write routes are not mounted under /widget.
+	req := httptest.NewRequest(http.MethodPost, "/widget/notifications/read-all", nil)
+	req.Header.Set("Authorization", "Bearer "+issued.Token)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("write route under /widget: %d, want 404", rec.Code)
+	}
+}
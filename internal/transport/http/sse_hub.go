@@ -1,54 +1,217 @@
 package http
 
 import (
+	"context"
+	"errors"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 	"vn.io.arda/notification/internal/domain"
+	"vn.io.arda/notification/internal/metrics"
 )
 
 // Client represents a connected SSE client.
 type Client struct {
+	id        string
 	tenantKey string
 	userID    string
 	send      chan []byte
+
+	// done is closed when the client is unregistered (by the handler or the reaper).
+	done      chan struct{}
+	closeOnce sync.Once
+	// lastActive holds the unix-nano timestamp of the last successful write.
+	lastActive atomic.Int64
+
+	// spill holds messages that overflowed send under DropPolicySpill, oldest first.
+	spillMu sync.Mutex
+	spill   [][]byte
+
+	// connectedAt is when the client registered; dropped counts messages lost to
+	// the DropPolicy. Both are reported by Inspect.
+	connectedAt time.Time
+	dropped     atomic.Int64
+
+	// expiresAt holds the unix-nano expiry of the token bound to the stream (0 = none).
+	expiresAt atomic.Int64
+	// refreshed is signalled when a fresh token is bound via RefreshClient.
+	refreshed chan struct{}
+}
+
+// ID returns the per-connection identifier announced in the "connected" event.
+// Clients echo it in X-SSE-Client-ID so their own actions are not echoed back.
+func (c *Client) ID() string {
+	return c.id
+}
+
+// Done returns a channel that is closed once the client has been unregistered.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Touch records a successful write to the client, keeping it alive for the reaper.
+func (c *Client) Touch() {
+	c.lastActive.Store(time.Now().UnixNano())
+}
+
+// ExpiresAt returns the expiry of the token bound to the stream (zero when unbounded).
+func (c *Client) ExpiresAt() time.Time {
+	if ns := c.expiresAt.Load(); ns != 0 {
+		return time.Unix(0, ns)
+	}
+	return time.Time{}
+}
+
+// SetExpiresAt binds the stream to a token expiring at t.
+func (c *Client) SetExpiresAt(t time.Time) {
+	if t.IsZero() {
+		c.expiresAt.Store(0)
+		return
+	}
+	c.expiresAt.Store(t.UnixNano())
+}
+
+// Refreshed is signalled when the stream's token has been refreshed.
+func (c *Client) Refreshed() <-chan struct{} {
+	return c.refreshed
+}
+
+func (c *Client) close() {
+	c.closeOnce.Do(func() { close(c.done) })
+}
+
+// TakeSpilled returns and clears messages queued under DropPolicySpill.
+// The handler calls it once the send channel has drained so order is preserved.
+func (c *Client) TakeSpilled() [][]byte {
+	c.spillMu.Lock()
+	defer c.spillMu.Unlock()
+	msgs := c.spill
+	c.spill = nil
+	return msgs
+}
+
+// DropPolicy decides what happens when a client's send buffer is full.
+type DropPolicy string
+
+const (
+	// DropPolicyNewest discards the incoming message (legacy behaviour).
+	DropPolicyNewest DropPolicy = "drop-newest"
+	// DropPolicyOldest discards the oldest buffered message to make room.
+	DropPolicyOldest DropPolicy = "drop-oldest"
+	// DropPolicyDisconnect closes the slow client; it reconnects and refetches via REST.
+	DropPolicyDisconnect DropPolicy = "disconnect"
+	// DropPolicySpill queues overflow in an unbounded-until-SpillLimit per-client queue.
+	DropPolicySpill DropPolicy = "spill"
+)
+
+// ErrTooManyConnections is returned by Register when a user is at MaxConnsPerUser.
+var ErrTooManyConnections = errors.New("too many SSE connections for user")
+
+// ErrShuttingDown is returned by Register once Shutdown has been called.
+var ErrShuttingDown = errors.New("SSE hub is shutting down")
+
+// HubConfig tunes SSE connection liveness.
+type HubConfig struct {
+	// HeartbeatInterval is how often a ": keep-alive" comment is written to each stream.
+	// Zero disables heartbeats.
+	HeartbeatInterval time.Duration
+	// IdleTimeout is how long a client may go without a successful write before
+	// the reaper unregisters it. Zero disables reaping.
+	IdleTimeout time.Duration
+	// MaxConnsPerUser caps simultaneous streams per (tenant, user). Zero means unlimited.
+	MaxConnsPerUser int
+	// DropPolicy applies when a client's send buffer is full. Defaults to DropPolicyOldest.
+	DropPolicy DropPolicy
+	// SpillLimit bounds the spill queue under DropPolicySpill; beyond it the client is disconnected.
+	SpillLimit int
+	// ReauthLead is how long before token expiry an "event: reauth" frame is sent.
+	ReauthLead time.Duration
+	// ReauthGrace is how long after token expiry an unrefreshed stream stays open.
+	ReauthGrace time.Duration
 }
 
 // Hub manages all active SSE client connections.
 // Single-instance model: all broadcast is in-process.
 // For multi-instance: replace with Redis Pub/Sub.
 type Hub struct {
+	cfg     HubConfig
 	mu      sync.RWMutex
 	clients map[string]map[string][]*Client // tenant -> userID -> clients
+	// closed is set by Shutdown; later registrations are refused.
+	closed bool
+
+	// latency tracks time from a Broadcast call to each channel write, per tenant.
+	latencyMu sync.RWMutex
+	latency   map[string]*metrics.Histogram
 }
 
 // NewHub creates a new SSE Hub.
-func NewHub() *Hub {
+func NewHub(cfg HubConfig) *Hub {
+	switch cfg.DropPolicy {
+	case DropPolicyNewest, DropPolicyOldest, DropPolicyDisconnect, DropPolicySpill:
+	default:
+		cfg.DropPolicy = DropPolicyOldest
+	}
 	return &Hub{
+		cfg:     cfg,
 		clients: make(map[string]map[string][]*Client),
+		latency: make(map[string]*metrics.Histogram),
 	}
 }
 
+// HeartbeatInterval returns the configured keep-alive interval (zero when disabled).
+func (h *Hub) HeartbeatInterval() time.Duration {
+	return h.cfg.HeartbeatInterval
+}
+
+// ReauthWindow returns how long before token expiry clients are asked to re-authenticate,
+// and how long after expiry an unrefreshed stream is closed.
+func (h *Hub) ReauthWindow() (lead, grace time.Duration) {
+	return h.cfg.ReauthLead, h.cfg.ReauthGrace
+}
+
 // Register adds a new SSE client.
-func (h *Hub) Register(tenantKey, userID string, send chan []byte) *Client {
-	c := &Client{tenantKey: tenantKey, userID: userID, send: send}
+// Returns ErrTooManyConnections when the user already holds MaxConnsPerUser streams.
+func (h *Hub) Register(tenantKey, userID string, send chan []byte) (*Client, error) {
+	c := &Client{
+		id: uuid.NewString(), tenantKey: tenantKey, userID: userID,
+		send: send, done: make(chan struct{}), refreshed: make(chan struct{}, 1),
+		connectedAt: time.Now(),
+	}
+	c.Touch()
 
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
+	if h.closed {
+		return nil, ErrShuttingDown
+	}
 	if h.clients[tenantKey] == nil {
 		h.clients[tenantKey] = make(map[string][]*Client)
 	}
+	if max := h.cfg.MaxConnsPerUser; max > 0 && len(h.clients[tenantKey][userID]) >= max {
+		log.Warn().Str("tenant", tenantKey).Str("user", userID).Int("max", max).Msg("SSE connection limit reached")
+		return nil, ErrTooManyConnections
+	}
 	h.clients[tenantKey][userID] = append(h.clients[tenantKey][userID], c)
 
 	log.Debug().Str("tenant", tenantKey).Str("user", userID).Msg("SSE client connected")
-	return c
+	return c, nil
 }
 
-// Unregister removes an SSE client.
+// Unregister removes an SSE client. Safe to call more than once.
 func (h *Hub) Unregister(c *Client) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
+	h.unregisterLocked(c)
+}
+
+func (h *Hub) unregisterLocked(c *Client) {
+	c.close()
 
 	users := h.clients[c.tenantKey]
 	if users == nil {
@@ -62,19 +225,97 @@ func (h *Hub) Unregister(c *Client) {
 			updated = append(updated, existing)
 		}
 	}
+	if len(updated) == len(clients) {
+		return
+	}
 
 	if len(updated) == 0 {
 		delete(users, c.userID)
 	} else {
 		users[c.userID] = updated
 	}
+	if len(users) == 0 {
+		delete(h.clients, c.tenantKey)
+	}
 
 	log.Debug().Str("tenant", c.tenantKey).Str("user", c.userID).Msg("SSE client disconnected")
 }
 
+// Shutdown sends a "server_shutdown" event to every SSE client, then unregisters
+// it and closes its send channel so the stream handler writes what is buffered
+// and returns. Later Register calls fail with ErrShuttingDown. Returns the
+// number of clients closed.
+func (h *Hub) Shutdown() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return 0
+	}
+	h.closed = true
+
+	msg := buildSSEEvent("server_shutdown", map[string]any{"reconnect": true})
+	var all []*Client
+	for _, users := range h.clients {
+		for _, clients := range users {
+			all = append(all, clients...)
+		}
+	}
+	for _, c := range all {
+		h.deliver(c, msg)
+		h.unregisterLocked(c)
+		// Safe: c is no longer registered and every sender holds h.mu.
+		close(c.send)
+	}
+	return len(all)
+}
+
+// RunReaper periodically unregisters clients that have not been written to
+// successfully within IdleTimeout. Blocks until ctx is cancelled.
+func (h *Hub) RunReaper(ctx context.Context) {
+	if h.cfg.IdleTimeout <= 0 {
+		return
+	}
+	interval := h.cfg.IdleTimeout / 2
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if n := h.reapIdle(time.Now().Add(-h.cfg.IdleTimeout)); n > 0 {
+				log.Info().Int("reaped", n).Msg("SSE idle clients reaped")
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// reapIdle unregisters all clients whose last successful write is before cutoff.
+func (h *Hub) reapIdle(cutoff time.Time) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var stale []*Client
+	for _, users := range h.clients {
+		for _, clients := range users {
+			for _, c := range clients {
+				if c.lastActive.Load() < cutoff.UnixNano() {
+					stale = append(stale, c)
+				}
+			}
+		}
+	}
+	for _, c := range stale {
+		h.unregisterLocked(c)
+	}
+	return len(stale)
+}
+
 // Broadcast sends a notification to all connected SSE clients for a user.
 // This satisfies the application.SSEHub interface.
 func (h *Hub) Broadcast(tenantKey, userID string, n *domain.Notification) {
+	start := time.Now()
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
@@ -91,14 +332,242 @@ func (h *Hub) Broadcast(tenantKey, userID string, n *domain.Notification) {
 	// Build SSE message: "data: {...}\n\n"
 	msg := buildSSEMessage(n)
 
+	hist := h.tenantLatency(tenantKey)
+	for _, c := range clients {
+		h.deliver(c, msg)
+		hist.Observe(time.Since(start))
+	}
+}
+
+// BroadcastScope sends a notification to every connected client of a tenant, or of
+// all tenants when tenantKey is empty. This satisfies the application.SSEHub interface.
+func (h *Hub) BroadcastScope(tenantKey string, n *domain.Notification) {
+	start := time.Now()
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for tk, users := range h.clients {
+		if tenantKey != "" && tk != tenantKey {
+			continue
+		}
+		// Platform broadcasts carry the recipient tenant, as in the REST inbox.
+		scoped := *n
+		scoped.TenantKey = tk
+		msg := buildSSEMessage(&scoped)
+		hist := h.tenantLatency(tk)
+		for _, clients := range users {
+			for _, c := range clients {
+				h.deliver(c, msg)
+				hist.Observe(time.Since(start))
+			}
+		}
+	}
+}
+
+// BroadcastScopeEvent sends a named SSE event to every connected client of a
+// tenant, or of all tenants when tenantKey is empty. This satisfies the
+// application.SSEHub interface.
+func (h *Hub) BroadcastScopeEvent(tenantKey, event string, data any) {
+	start := time.Now()
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	msg := buildSSEEvent(event, data)
+	for tk, users := range h.clients {
+		if tenantKey != "" && tk != tenantKey {
+			continue
+		}
+		hist := h.tenantLatency(tk)
+		for _, clients := range users {
+			for _, c := range clients {
+				h.deliver(c, msg)
+				hist.Observe(time.Since(start))
+			}
+		}
+	}
+}
+
+// BroadcastEvent sends a named SSE event (e.g. "unread_count") to all connected
+// clients of a user. This satisfies the application.SSEHub interface.
+func (h *Hub) BroadcastEvent(tenantKey, userID, event string, data any) {
+	h.BroadcastEventExcept(tenantKey, userID, "", event, data)
+}
+
+// BroadcastEventExcept is BroadcastEvent, skipping the client with exceptClientID
+// (the stream of the device that triggered the change).
+func (h *Hub) BroadcastEventExcept(tenantKey, userID, exceptClientID, event string, data any) {
+	start := time.Now()
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	clients := h.clients[tenantKey][userID]
+	if len(clients) == 0 {
+		return
+	}
+
+	msg := buildSSEEvent(event, data)
+	hist := h.tenantLatency(tenantKey)
 	for _, c := range clients {
+		if exceptClientID != "" && c.id == exceptClientID {
+			continue
+		}
+		h.deliver(c, msg)
+		hist.Observe(time.Since(start))
+	}
+}
+
+// tenantLatency returns (creating on first use) the latency histogram for a tenant.
+func (h *Hub) tenantLatency(tenantKey string) *metrics.Histogram {
+	h.latencyMu.RLock()
+	hist, ok := h.latency[tenantKey]
+	h.latencyMu.RUnlock()
+	if ok {
+		return hist
+	}
+
+	h.latencyMu.Lock()
+	defer h.latencyMu.Unlock()
+	if hist, ok = h.latency[tenantKey]; !ok {
+		hist = metrics.NewHistogram(metrics.DefaultLatencyBuckets)
+		h.latency[tenantKey] = hist
+	}
+	return hist
+}
+
+// ReclaimTenants drops latency histograms of idle tenants without open streams.
+// This satisfies the application.TenantReclaimer interface.
+func (h *Hub) ReclaimTenants(isIdle func(tenantKey string) bool) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	h.latencyMu.Lock()
+	defer h.latencyMu.Unlock()
+
+	n := 0
+	for tk := range h.latency {
+		if len(h.clients[tk]) == 0 && isIdle(tk) {
+			delete(h.latency, tk)
+			n++
+		}
+	}
+	return n
+}
+
+// TenantLatency is the broadcast latency snapshot of one tenant.
+type TenantLatency struct {
+	TenantKey   string           `json:"tenant_key"`
+	Connections int              `json:"connections"`
+	Latency     metrics.Snapshot `json:"latency"`
+}
+
+// LatencyReport returns broadcast latency per tenant, slowest p95 first.
+// When slowThreshold > 0, only tenants whose p95 exceeds it are included.
+func (h *Hub) LatencyReport(slowThreshold time.Duration) []TenantLatency {
+	h.latencyMu.RLock()
+	snaps := make(map[string]metrics.Snapshot, len(h.latency))
+	for tk, hist := range h.latency {
+		snaps[tk] = hist.Snapshot()
+	}
+	h.latencyMu.RUnlock()
+
+	h.mu.RLock()
+	report := make([]TenantLatency, 0, len(snaps))
+	for tk, snap := range snaps {
+		if slowThreshold > 0 && snap.P95MS <= float64(slowThreshold)/float64(time.Millisecond) {
+			continue
+		}
+		conns := 0
+		for _, clients := range h.clients[tk] {
+			conns += len(clients)
+		}
+		report = append(report, TenantLatency{TenantKey: tk, Connections: conns, Latency: snap})
+	}
+	h.mu.RUnlock()
+
+	sort.Slice(report, func(i, j int) bool { return report[i].Latency.P95MS > report[j].Latency.P95MS })
+	return report
+}
+
+// RefreshClient binds a fresh token expiry to an open stream of (tenantKey, userID).
+// Returns false when no such stream exists on this instance.
+func (h *Hub) RefreshClient(tenantKey, userID, clientID string, expiresAt time.Time) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, c := range h.clients[tenantKey][userID] {
+		if c.id != clientID {
+			continue
+		}
+		c.SetExpiresAt(expiresAt)
+		select {
+		case c.refreshed <- struct{}{}:
+		default:
+		}
+		return true
+	}
+	return false
+}
+
+// IsConnected reports whether the user has at least one open stream.
+func (h *Hub) IsConnected(tenantKey, userID string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients[tenantKey][userID]) > 0
+}
+
+// deliver enqueues msg for a client, applying the configured DropPolicy when its buffer is full.
+func (h *Hub) deliver(c *Client, msg []byte) {
+	if h.cfg.DropPolicy == DropPolicySpill {
+		h.spillOrSend(c, msg)
+		return
+	}
+
+	select {
+	case c.send <- msg:
+		return
+	default:
+	}
+
+	c.dropped.Add(1)
+	switch h.cfg.DropPolicy {
+	case DropPolicyOldest:
+		select {
+		case <-c.send:
+		default:
+		}
 		select {
 		case c.send <- msg:
+			log.Warn().Str("user", c.userID).Msg("SSE client send buffer full, dropped oldest message")
 		default:
-			// Client is slow/disconnected, skip
-			log.Warn().Str("user", userID).Msg("SSE client send buffer full, skipping")
+			log.Warn().Str("user", c.userID).Msg("SSE client send buffer full, skipping")
 		}
+	case DropPolicyDisconnect:
+		log.Warn().Str("user", c.userID).Msg("SSE client send buffer full, disconnecting")
+		c.close()
+	default:
+		log.Warn().Str("user", c.userID).Msg("SSE client send buffer full, skipping")
+	}
+}
+
+// spillOrSend keeps ordering: once anything is spilled, later messages spill too
+// until the handler drains the queue.
+func (h *Hub) spillOrSend(c *Client, msg []byte) {
+	c.spillMu.Lock()
+	defer c.spillMu.Unlock()
+
+	if len(c.spill) == 0 {
+		select {
+		case c.send <- msg:
+			return
+		default:
+		}
+	}
+	if h.cfg.SpillLimit > 0 && len(c.spill) >= h.cfg.SpillLimit {
+		c.dropped.Add(1)
+		log.Warn().Str("user", c.userID).Int("spilled", len(c.spill)).Msg("SSE spill queue full, disconnecting")
+		c.close()
+		return
 	}
+	c.spill = append(c.spill, msg)
 }
 
 // ConnectedCount returns the total number of connected SSE clients.
@@ -0,0 +1,211 @@
+package http
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReapIdle_RemovesStaleClients(t *testing.T) {
+	hub := NewHub(HubConfig{IdleTimeout: time.Minute})
+	stale, _ := hub.Register("acme", "u1", make(chan []byte, 1))
+	fresh, _ := hub.Register("acme", "u2", make(chan []byte, 1))
+
+	stale.lastActive.Store(time.Now().Add(-2 * time.Minute).UnixNano())
+
+	if n := hub.reapIdle(time.Now().Add(-time.Minute)); n != 1 {
+		t.Fatalf("expected 1 reaped client, got %d", n)
+	}
+	if hub.ConnectedCount() != 1 {
+		t.Fatalf("expected 1 connected client, got %d", hub.ConnectedCount())
+	}
+
+	select {
+	case <-stale.Done():
+	default:
+		t.Fatal("stale client was not closed")
+	}
+	select {
+	case <-fresh.Done():
+		t.Fatal("fresh client should stay open")
+	default:
+	}
+}
+
+func TestUnregister_Idempotent(t *testing.T) {
+	hub := NewHub(HubConfig{})
+	c, _ := hub.Register("acme", "u1", make(chan []byte, 1))
+
+	hub.Unregister(c)
+	hub.Unregister(c)
+
+	if hub.ConnectedCount() != 0 {
+		t.Fatalf("expected 0 connected clients, got %d", hub.ConnectedCount())
+	}
+}
+
+func TestRegister_ConnectionLimit(t *testing.T) {
+	hub := NewHub(HubConfig{MaxConnsPerUser: 1})
+	if _, err := hub.Register("acme", "u1", make(chan []byte, 1)); err != nil {
+		t.Fatalf("first register failed: %v", err)
+	}
+	if _, err := hub.Register("acme", "u1", make(chan []byte, 1)); err != ErrTooManyConnections {
+		t.Fatalf("expected ErrTooManyConnections, got %v", err)
+	}
+}
+
+func TestDeliver_DropOldest(t *testing.T) {
+	hub := NewHub(HubConfig{DropPolicy: DropPolicyOldest})
+	c, _ := hub.Register("acme", "u1", make(chan []byte, 1))
+
+	hub.deliver(c, []byte("first"))
+	hub.deliver(c, []byte("second"))
+
+	if got := string(<-c.send); got != "second" {
+		t.Fatalf("expected newest message to survive, got %q", got)
+	}
+}
+
+func TestDeliver_SpillPreservesOrder(t *testing.T) {
+	hub := NewHub(HubConfig{DropPolicy: DropPolicySpill, SpillLimit: 2})
+	c, _ := hub.Register("acme", "u1", make(chan []byte, 1))
+
+	hub.deliver(c, []byte("1"))
+	hub.deliver(c, []byte("2"))
+	hub.deliver(c, []byte("3"))
+	hub.deliver(c, []byte("4"))
+
+	if got := string(<-c.send); got != "1" {
+		t.Fatalf("expected first message in channel, got %q", got)
+	}
+	spilled := c.TakeSpilled()
+	if len(spilled) != 2 || string(spilled[0]) != "2" || string(spilled[1]) != "3" {
+		t.Fatalf("unexpected spill contents: %q", spilled)
+	}
+	select {
+	case <-c.Done():
+	default:
+		t.Fatal("client should be disconnected once spill limit is exceeded")
+	}
+}
+
+func TestRefreshClient_BoundToOwner(t *testing.T) {
+	hub := NewHub(HubConfig{})
+	c, _ := hub.Register("acme", "u1", make(chan []byte, 1))
+	exp := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	if hub.RefreshClient("acme", "u2", c.ID(), exp) {
+		t.Fatal("refresh by another user must not find the stream")
+	}
+	if !hub.RefreshClient("acme", "u1", c.ID(), exp) {
+		t.Fatal("expected refresh to find the stream")
+	}
+	if !c.ExpiresAt().Equal(exp) {
+		t.Fatalf("expected expiry %v, got %v", exp, c.ExpiresAt())
+	}
+	select {
+	case <-c.Refreshed():
+	default:
+		t.Fatal("expected refresh signal")
+	}
+}
+
+func TestShutdown_SendsEventAndClosesClients(t *testing.T) {
+	hub := NewHub(HubConfig{})
+	send := make(chan []byte, 2)
+	c, _ := hub.Register("acme", "u1", send)
+
+	if n := hub.Shutdown(); n != 1 {
+		t.Fatalf("expected 1 closed client, got %d", n)
+	}
+	select {
+	case <-c.Done():
+	default:
+		t.Fatal("client was not closed")
+	}
+	if msg := <-send; !strings.HasPrefix(string(msg), "event: server_shutdown\n") {
+		t.Fatalf("expected server_shutdown event, got %q", msg)
+	}
+	if _, ok := <-send; ok {
+		t.Fatal("send channel should be closed")
+	}
+	if _, err := hub.Register("acme", "u2", make(chan []byte, 1)); err != ErrShuttingDown {
+		t.Fatalf("expected ErrShuttingDown, got %v", err)
+	}
+	if n := hub.Shutdown(); n != 0 {
+		t.Fatalf("second Shutdown should be a no-op, got %d", n)
+	}
+}
+
+func TestInspect_ReportsBufferAndDrops(t *testing.T) {
+	hub := NewHub(HubConfig{DropPolicy: DropPolicyNewest})
+	full, _ := hub.Register("acme", "u1", make(chan []byte, 2))
+	hub.Register("acme", "u2", make(chan []byte, 4))
+	hub.Register("other", "u3", make(chan []byte, 4))
+
+	for i := 0; i < 3; i++ {
+		hub.deliver(full, []byte("x"))
+	}
+
+	state := hub.Inspect("acme", "")
+	if len(state.Clients) != 2 || state.Tenants != 1 || state.Users != 2 {
+		t.Fatalf("unexpected snapshot: %+v", state)
+	}
+	got := state.Clients[0]
+	if got.UserID != "u1" || got.Buffered != 2 || got.Capacity != 2 || got.Utilization != 1 || got.Dropped != 1 {
+		t.Fatalf("unexpected client state: %+v", got)
+	}
+	if state.Dropped != 1 {
+		t.Fatalf("expected 1 dropped in total, got %d", state.Dropped)
+	}
+	if n := len(hub.Inspect("", "u3").Clients); n != 1 {
+		t.Fatalf("expected 1 client for u3, got %d", n)
+	}
+}
+
+func TestBroadcastEventExcept_SkipsOriginClient(t *testing.T) {
+	hub := NewHub(HubConfig{})
+	origin, _ := hub.Register("acme", "u1", make(chan []byte, 4))
+	other, _ := hub.Register("acme", "u1", make(chan []byte, 4))
+
+	hub.BroadcastEventExcept("acme", "u1", origin.ID(), "notification_read", map[string]any{"all": true})
+
+	if len(origin.send) != 0 {
+		t.Fatal("the originating client got its own sync event")
+	}
+	if msg := string(<-other.send); !strings.Contains(msg, "event: notification_read") {
+		t.Fatalf("expected a notification_read event, got %q", msg)
+	}
+}
+
+func TestDisconnect_ClosesUserStreams(t *testing.T) {
+	hub := NewHub(HubConfig{})
+	a, _ := hub.Register("acme", "u1", make(chan []byte, 4))
+	b, _ := hub.Register("acme", "u1", make(chan []byte, 4))
+	other, _ := hub.Register("acme", "u2", make(chan []byte, 4))
+
+	if n := hub.Disconnect("acme", "u1", b.ID()); n != 1 {
+		t.Fatalf("expected 1 stream closed by client ID, got %d", n)
+	}
+	if n := hub.Disconnect("acme", "u1", ""); n != 1 {
+		t.Fatalf("expected the remaining stream closed, got %d", n)
+	}
+	for _, c := range []*Client{a, b} {
+		select {
+		case <-c.Done():
+		default:
+			t.Fatal("disconnected client still open")
+		}
+		if msg := string(<-c.send); !strings.Contains(msg, "event: disconnected") {
+			t.Fatalf("expected a disconnected event, got %q", msg)
+		}
+	}
+	if !hub.IsConnected("acme", "u2") || len(other.send) != 0 {
+		t.Fatal("other user was affected")
+	}
+
+	groups := hub.Inspect("", "").Groups(false)
+	if len(groups) != 1 || groups[0].TenantKey != "acme" || groups[0].Users != 1 || groups[0].Clients != 1 {
+		t.Fatalf("unexpected groups: %+v", groups)
+	}
+}
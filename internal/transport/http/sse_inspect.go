@@ -0,0 +1,259 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// inspectFlushEvery is how many clients SSEClients writes between flushes.
+const inspectFlushEvery = 256
+
+// ClientState is a read-only copy of one SSE client's state.
+type ClientState struct {
+	ID          string     `json:"id"`
+	TenantKey   string     `json:"tenant_key"`
+	UserID      string     `json:"user_id"`
+	ConnectedAt time.Time  `json:"connected_at"`
+	AgeSeconds  float64    `json:"age_seconds"`
+	LastActive  time.Time  `json:"last_active"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	// Buffered / Capacity is the send buffer fill; Utilization is their ratio.
+	Buffered    int     `json:"buffered"`
+	Capacity    int     `json:"capacity"`
+	Utilization float64 `json:"utilization"`
+	Spilled     int     `json:"spilled"`
+	Dropped     int64   `json:"dropped"`
+}
+
+// ClientGroup sums the clients of one tenant, or of one user of a tenant.
+type ClientGroup struct {
+	TenantKey string `json:"tenant_key"`
+	UserID    string `json:"user_id,omitempty"`
+	Users     int    `json:"users,omitempty"`
+	Clients   int    `json:"clients"`
+	Buffered  int    `json:"buffered"`
+	Dropped   int64  `json:"dropped"`
+}
+
+// HubState is a point-in-time copy of the hub, oldest connection first.
+type HubState struct {
+	CapturedAt time.Time     `json:"captured_at"`
+	DropPolicy DropPolicy    `json:"drop_policy"`
+	Tenants    int           `json:"tenants"`
+	Users      int           `json:"users"`
+	Dropped    int64         `json:"dropped"`
+	Clients    []ClientState `json:"clients"`
+}
+
+// Groups sums the clients per tenant or, with byUser, per tenant and user,
+// busiest first.
+func (s HubState) Groups(byUser bool) []ClientGroup {
+	index := map[[2]string]int{}
+	users := map[[2]string]bool{}
+	var groups []ClientGroup
+	for _, c := range s.Clients {
+		key := [2]string{c.TenantKey, ""}
+		if byUser {
+			key[1] = c.UserID
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, ClientGroup{TenantKey: key[0], UserID: key[1]})
+		}
+		if user := [2]string{c.TenantKey, c.UserID}; !byUser && !users[user] {
+			users[user] = true
+			groups[i].Users++
+		}
+		groups[i].Clients++
+		groups[i].Buffered += c.Buffered
+		groups[i].Dropped += c.Dropped
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].Clients > groups[j].Clients })
+	return groups
+}
+
+// Inspect copies the state of the connected clients of tenantKey (all tenants
+// when empty), optionally limited to userID. The hub lock is held only while
+// copying, so callers may take their time with the result.
+func (h *Hub) Inspect(tenantKey, userID string) HubState {
+	state := HubState{CapturedAt: time.Now(), DropPolicy: h.cfg.DropPolicy}
+
+	h.mu.RLock()
+	for tk, users := range h.clients {
+		if tenantKey != "" && tk != tenantKey {
+			continue
+		}
+		state.Tenants++
+		for uid, clients := range users {
+			if userID != "" && uid != userID {
+				continue
+			}
+			state.Users++
+			for _, c := range clients {
+				cs := c.state(state.CapturedAt)
+				state.Dropped += cs.Dropped
+				state.Clients = append(state.Clients, cs)
+			}
+		}
+	}
+	h.mu.RUnlock()
+
+	sort.Slice(state.Clients, func(i, j int) bool {
+		return state.Clients[i].ConnectedAt.Before(state.Clients[j].ConnectedAt)
+	})
+	return state
+}
+
+func (c *Client) state(now time.Time) ClientState {
+	cs := ClientState{
+		ID:          c.id,
+		TenantKey:   c.tenantKey,
+		UserID:      c.userID,
+		ConnectedAt: c.connectedAt,
+		AgeSeconds:  now.Sub(c.connectedAt).Seconds(),
+		LastActive:  time.Unix(0, c.lastActive.Load()),
+		Buffered:    len(c.send),
+		Capacity:    cap(c.send),
+		Dropped:     c.dropped.Load(),
+	}
+	if exp := c.ExpiresAt(); !exp.IsZero() {
+		cs.ExpiresAt = &exp
+	}
+	if cs.Capacity > 0 {
+		cs.Utilization = float64(cs.Buffered) / float64(cs.Capacity)
+	}
+	c.spillMu.Lock()
+	cs.Spilled = len(c.spill)
+	c.spillMu.Unlock()
+	return cs
+}
+
+// LogState dumps the hub state to the log, one line per client, for postmortem
+// debugging of connection leaks (see SIGUSR1 in cmd/server).
+func (h *Hub) LogState() {
+	state := h.Inspect("", "")
+	log.Info().
+		Int("clients", len(state.Clients)).
+		Int("tenants", state.Tenants).
+		Int("users", state.Users).
+		Int64("dropped", state.Dropped).
+		Str("drop_policy", string(state.DropPolicy)).
+		Msg("SSE hub state dump")
+	for _, c := range state.Clients {
+		log.Info().
+			Str("client_id", c.ID).
+			Str("tenant", c.TenantKey).
+			Str("user", c.UserID).
+			Time("connected_at", c.ConnectedAt).
+			Dur("idle", state.CapturedAt.Sub(c.LastActive)).
+			Int("buffered", c.Buffered).
+			Int("capacity", c.Capacity).
+			Int("spilled", c.Spilled).
+			Int64("dropped", c.Dropped).
+			Msg("SSE client")
+	}
+}
+
+// Disconnect closes the streams of (tenantKey, userID), or only the one with
+// clientID when set, after sending them a "disconnected" event. Clients
+// reconnect and refetch, which resets a stream stuck in a bad state. Returns
+// the number of streams closed.
+func (h *Hub) Disconnect(tenantKey, userID, clientID string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var matched []*Client
+	for _, c := range h.clients[tenantKey][userID] {
+		if clientID == "" || c.id == clientID {
+			matched = append(matched, c)
+		}
+	}
+	msg := buildSSEEvent("disconnected", map[string]any{"reason": "admin", "reconnect": true})
+	for _, c := range matched {
+		h.deliver(c, msg)
+		h.unregisterLocked(c)
+	}
+	return len(matched)
+}
+
+// SSEClients GET /notifications/admin/sse/clients?tenant=&user=
+// Read-only snapshot of the connected SSE clients of this instance, with totals
+// per tenant (per user when tenant is given). The client list is streamed, so
+// large hubs do not have to be rendered in memory at once. Callers other than
+// platform admins only see their own tenant.
+func (h *Handler) SSEClients(c echo.Context) error {
+	tenantKey, err := h.tenantFilter(c, c.QueryParam("tenant"))
+	if err != nil {
+		return err
+	}
+	state := h.hub.Inspect(tenantKey, c.QueryParam("user"))
+
+	w := c.Response()
+	w.Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	w.WriteHeader(http.StatusOK)
+
+	header, err := json.Marshal(map[string]any{
+		"region":      h.region,
+		"captured_at": state.CapturedAt,
+		"drop_policy": state.DropPolicy,
+		"total":       len(state.Clients),
+		"tenants":     state.Tenants,
+		"users":       state.Users,
+		"dropped":     state.Dropped,
+		"groups":      state.Groups(tenantKey != ""),
+	})
+	if err != nil {
+		return err
+	}
+	// Reopen the summary object to append the streamed client list.
+	if _, err := w.Write(append(header[:len(header)-1], `,"clients":[`...)); err != nil {
+		return err
+	}
+	for i, cs := range state.Clients {
+		if i > 0 {
+			if _, err := w.Write([]byte{','}); err != nil {
+				return err
+			}
+		}
+		b, err := json.Marshal(cs)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+		if (i+1)%inspectFlushEvery == 0 {
+			w.Flush()
+		}
+	}
+	_, err = w.Write([]byte("]}"))
+	return err
+}
+
+// DisconnectSSEClients DELETE /notifications/admin/sse/clients?tenant=&user=&client_id=
+// Force-closes a user's streams on this instance, or one stream with client_id.
+// tenant defaults to the caller's own; another tenant needs the platform admin role.
+func (h *Handler) DisconnectSSEClients(c echo.Context) error {
+	tenantKey, userID := c.QueryParam("tenant"), c.QueryParam("user")
+	if tenantKey == "" {
+		tenantKey, _ = c.Get("tenantKey").(string)
+	}
+	if tenantKey == "" || userID == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "tenant and user are required")
+	}
+	if err := h.authorizeTenant(c, tenantKey); err != nil {
+		return err
+	}
+	n := h.hub.Disconnect(tenantKey, userID, c.QueryParam("client_id"))
+	_, actor := mustClaims(c)
+	log.Info().Str("tenant", tenantKey).Str("user", userID).Str("client_id", c.QueryParam("client_id")).
+		Str("by", actor).Int("disconnected", n).Msg("SSE streams disconnected by admin")
+	return c.JSON(http.StatusOK, map[string]any{"region": h.region, "disconnected": n})
+}
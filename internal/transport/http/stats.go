@@ -0,0 +1,61 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"vn.io.arda/notification/internal/application"
+	"vn.io.arda/notification/internal/domain"
+)
+
+// EngagementStats GET /notifications/admin/stats?tenant_key=&type=&from=&to=&min_delivered=&limit=
+// Delivery volume, read rate and median time to read per tenant and type from the
+// nightly rollup, and the most ignored types. from / to are days (YYYY-MM-DD,
+// both included); the default is the 30 days up to yesterday (UTC). Callers
+// other than platform admins only see their own tenant.
+func (h *Handler) EngagementStats(c echo.Context) error {
+	tenantKey, err := h.tenantFilter(c, c.QueryParam("tenant_key"))
+	if err != nil {
+		return err
+	}
+	yesterday := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+	q := application.EngagementQuery{
+		StatsFilter: domain.StatsFilter{
+			TenantKey: tenantKey,
+			Type:      domain.NotificationType(c.QueryParam("type")),
+			To:        yesterday,
+		},
+		MinDelivered: int64(parseIntQuery(c, "min_delivered", 20)),
+		IgnoredLimit: parseIntQuery(c, "limit", 5),
+	}
+	if q.To, err = parseDayQuery(c, "to", q.To); err != nil {
+		return err
+	}
+	if q.From, err = parseDayQuery(c, "from", q.To.AddDate(0, 0, -29)); err != nil {
+		return err
+	}
+	if err := q.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	report, err := h.svc.EngagementStats(c.Request().Context(), q)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, map[string]any{"data": report})
+}
+
+// parseDayQuery parses the YYYY-MM-DD query parameter name, or returns def when
+// it is absent.
+func parseDayQuery(c echo.Context, name string, def time.Time) (time.Time, error) {
+	v := c.QueryParam(name)
+	if v == "" {
+		return def, nil
+	}
+	t, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		return time.Time{}, echo.NewHTTPError(http.StatusBadRequest, name+" must be a date (YYYY-MM-DD)")
+	}
+	return t, nil
+}
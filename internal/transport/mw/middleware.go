@@ -13,6 +13,7 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 	"github.com/rs/zerolog/log"
+	"vn.io.arda/notification/internal/domain"
 )
 
 // InternalJWTClaims are the claims set by APISIX Lua signer.
@@ -127,6 +128,7 @@ func InternalJWTAuth() echo.MiddlewareFunc {
 			c.Set("username", claims.Username)
 			c.Set("email", claims.Email)
 			c.Set("roles", claims.Roles)
+			c.Set("tokenExpiresAt", claims.ExpiresAt.Time)
 
 			log.Trace().
 				Str("userID", claims.Sub).
@@ -155,3 +157,22 @@ func TenantResolver() echo.MiddlewareFunc {
 		}
 	}
 }
+
+// TenantScope restricts the request context to the resolved tenant
+// (domain.WithTenantScope), so repositories enforcing row-level security
+// cannot touch another tenant's rows. Admin routes under adminPrefix are left
+// unscoped for callers holding platformRole, because platform operations span
+// tenants.
+func TenantScope(adminPrefix, platformRole string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			tenantKey, _ := c.Get("tenantKey").(string)
+			platform := strings.HasPrefix(c.Path(), adminPrefix) && HasRole(c, platformRole)
+			if tenantKey != "" && !platform {
+				req := c.Request()
+				c.SetRequest(req.WithContext(domain.WithTenantScope(req.Context(), tenantKey)))
+			}
+			return next(c)
+		}
+	}
+}
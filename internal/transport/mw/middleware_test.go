@@ -0,0 +1,43 @@
+package mw
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"vn.io.arda/notification/internal/domain"
+)
+
+func TestTenantScope(t *testing.T) {
+	tests := []struct {
+		name  string
+		path  string
+		roles []string
+		want  string
+	}{
+		{name: "user route", path: "/notifications", roles: []string{"PLATFORM_ADMIN"}, want: "acme"},
+		{name: "admin route, tenant admin", path: "/notifications/admin/export", roles: []string{"ADMIN"}, want: "acme"},
+		{name: "admin route, platform admin", path: "/notifications/admin/quotas", roles: []string{"PLATFORM_ADMIN"}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, tt.path, nil), httptest.NewRecorder())
+			c.SetPath(tt.path)
+			c.Set("tenantKey", "acme")
+			c.Set("roles", tt.roles)
+
+			var got string
+			err := TenantScope("/notifications/admin/", "PLATFORM_ADMIN")(func(c echo.Context) error {
+				got = domain.TenantScope(c.Request().Context())
+				return nil
+			})(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("scope = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
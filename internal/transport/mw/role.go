@@ -0,0 +1,44 @@
+package mw
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HasRole reports whether the authenticated caller holds one of roles.
+// Empty role names never match.
+func HasRole(c echo.Context, roles ...string) bool {
+	held, _ := c.Get("roles").([]string)
+	for _, r := range held {
+		for _, want := range roles {
+			if want != "" && r == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RequireRole rejects with 403 callers holding none of roles.
+func RequireRole(roles ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !HasRole(c, roles...) {
+				return echo.NewHTTPError(http.StatusForbidden, "missing role "+strings.Join(nonEmpty(roles), " or "))
+			}
+			return next(c)
+		}
+	}
+}
+
+func nonEmpty(roles []string) []string {
+	out := make([]string, 0, len(roles))
+	for _, r := range roles {
+		if r != "" {
+			out = append(out, r)
+		}
+	}
+	return out
+}
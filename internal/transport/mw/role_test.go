@@ -0,0 +1,43 @@
+package mw
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestRequireRole(t *testing.T) {
+	tests := []struct {
+		name    string
+		roles   []string
+		require []string
+		wantErr bool
+	}{
+		{name: "held", roles: []string{"USER", "PLATFORM_ADMIN"}, require: []string{"PLATFORM_ADMIN"}},
+		{name: "any of", roles: []string{"AUDITOR"}, require: []string{"ADMIN", "AUDITOR"}},
+		{name: "missing", roles: []string{"ADMIN"}, require: []string{"PLATFORM_ADMIN"}, wantErr: true},
+		{name: "no roles", require: []string{"ADMIN"}, wantErr: true},
+		{name: "empty role never matches", roles: []string{""}, require: []string{""}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+			if tt.roles != nil {
+				c.Set("roles", tt.roles)
+			}
+			called := false
+			err := RequireRole(tt.require...)(func(echo.Context) error { called = true; return nil })(c)
+			if tt.wantErr {
+				if he, ok := err.(*echo.HTTPError); !ok || he.Code != http.StatusForbidden || called {
+					t.Fatalf("err = %v, called = %v", err, called)
+				}
+				return
+			}
+			if err != nil || !called {
+				t.Fatalf("err = %v, called = %v", err, called)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package mw
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// serviceAccountPrefix starts the preferred_username Keycloak gives the
+// service account of a client; user tokens never carry it.
+const serviceAccountPrefix = "service-account-"
+
+// jwksRefreshInterval bounds how often an unknown key ID triggers a JWKS fetch.
+const jwksRefreshInterval = 30 * time.Second
+
+// ServiceAccountClaims are the claims of a Keycloak client-credentials token.
+type ServiceAccountClaims struct {
+	// ClientID is set by Keycloak 20+; older versions only set Azp.
+	ClientID          string `json:"client_id"`
+	Azp               string `json:"azp"`
+	PreferredUsername string `json:"preferred_username"`
+	jwt.RegisteredClaims
+}
+
+// client returns the client the token was issued to.
+func (c *ServiceAccountClaims) client() string {
+	if c.ClientID != "" {
+		return c.ClientID
+	}
+	return c.Azp
+}
+
+// ServiceAccountConfig configures ServiceAccounts.
+type ServiceAccountConfig struct {
+	// JWKSURL serves the signing keys of the realm issuing the tokens.
+	JWKSURL string
+	// Issuer is the expected iss claim; empty skips the check.
+	Issuer string
+	// Audience must be one of the token's aud values.
+	Audience string
+	// Clients are the client IDs allowed to call the internal endpoints.
+	Clients []string
+}
+
+// ServiceAccounts verifies client-credentials tokens sent directly by other
+// services (Authorization: Bearer), bypassing the gateway. Unlike user tokens,
+// such a token must name this service in its audience and be issued to a
+// registered client's service account.
+type ServiceAccounts struct {
+	cfg  ServiceAccountConfig
+	http *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+// NewServiceAccounts creates a verifier; keys are fetched on first use.
+func NewServiceAccounts(cfg ServiceAccountConfig) *ServiceAccounts {
+	return &ServiceAccounts{cfg: cfg, http: &http.Client{Timeout: 5 * time.Second}}
+}
+
+// Auth rejects requests without a valid service account token and stores the
+// calling client ID as "serviceClient" in the context.
+func (s *ServiceAccounts) Auth() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			tokenStr, ok := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+			if !ok || tokenStr == "" {
+				return echo.NewHTTPError(http.StatusUnauthorized, "missing service account token")
+			}
+			client, err := s.Verify(c.Request().Context(), tokenStr)
+			if err != nil {
+				log.Warn().
+					Err(err).
+					Str("uri", c.Request().RequestURI).
+					Msg("Service account token rejected")
+				return echo.NewHTTPError(http.StatusUnauthorized, "invalid service account token")
+			}
+			c.Set("serviceClient", client)
+			return next(c)
+		}
+	}
+}
+
+// Verify checks the signature, expiry, issuer and audience of tokenStr and
+// that it belongs to the service account of a registered client, which it
+// returns.
+func (s *ServiceAccounts) Verify(ctx context.Context, tokenStr string) (string, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithAudience(s.cfg.Audience),
+	}
+	if s.cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(s.cfg.Issuer))
+	}
+	claims := &ServiceAccountClaims{}
+	_, err := jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return s.key(ctx, kid)
+	}, opts...)
+	if err != nil {
+		return "", err
+	}
+	client := claims.client()
+	if !strings.HasPrefix(claims.PreferredUsername, serviceAccountPrefix) {
+		return "", fmt.Errorf("token of %q is not a service account token", claims.PreferredUsername)
+	}
+	if !slices.Contains(s.cfg.Clients, client) {
+		return "", fmt.Errorf("client %q is not registered", client)
+	}
+	return client, nil
+}
+
+// key returns the signing key kid, refetching the JWKS when it is unknown
+// (key rotation) at most once per jwksRefreshInterval.
+func (s *ServiceAccounts) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if k, ok := s.keys[kid]; ok {
+		return k, nil
+	}
+	if time.Since(s.fetchedAt) < jwksRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	keys, err := s.fetchKeys(ctx)
+	s.fetchedAt = time.Now()
+	if err != nil {
+		return nil, fmt.Errorf("fetch JWKS: %w", err)
+	}
+	s.keys = keys
+	if k, ok := keys[kid]; ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (s *ServiceAccounts) fetchKeys(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.cfg.JWKSURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: HTTP %d", s.cfg.JWKSURL, resp.StatusCode)
+	}
+	var set struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("decode JWKS: %w", err)
+	}
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil {
+			return nil, fmt.Errorf("decode JWKS key %q", k.Kid)
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	return keys, nil
+}
@@ -0,0 +1,91 @@
+package mw
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestServiceAccountsVerify(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kid": "k1", "kty": "RSA", "use": "sig",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	defer jwks.Close()
+
+	sa := NewServiceAccounts(ServiceAccountConfig{
+		JWKSURL:  jwks.URL,
+		Issuer:   "https://sso.arda.vn/realms/master",
+		Audience: "arda-notification",
+		Clients:  []string{"crm-service"},
+	})
+	sign := func(kid string, edit func(*ServiceAccountClaims)) string {
+		claims := &ServiceAccountClaims{
+			ClientID:          "crm-service",
+			PreferredUsername: "service-account-crm-service",
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    "https://sso.arda.vn/realms/master",
+				Audience:  jwt.ClaimStrings{"account", "arda-notification"},
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			},
+		}
+		if edit != nil {
+			edit(claims)
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = kid
+		s, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "service account", token: sign("k1", nil)},
+		{name: "azp only", token: sign("k1", func(c *ServiceAccountClaims) { c.ClientID, c.Azp = "", "crm-service" })},
+		{name: "other audience", token: sign("k1", func(c *ServiceAccountClaims) { c.Audience = jwt.ClaimStrings{"account"} }), wantErr: true},
+		{name: "other issuer", token: sign("k1", func(c *ServiceAccountClaims) { c.Issuer = "https://evil.example/realms/master" }), wantErr: true},
+		{name: "user token", token: sign("k1", func(c *ServiceAccountClaims) { c.PreferredUsername = "alice" }), wantErr: true},
+		{name: "unregistered client", token: sign("k1", func(c *ServiceAccountClaims) { c.ClientID = "billing" }), wantErr: true},
+		{name: "expired", token: sign("k1", func(c *ServiceAccountClaims) { c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute)) }), wantErr: true},
+		{name: "unknown key", token: sign("k2", nil), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := sa.Verify(context.Background(), tt.token)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected the token to be rejected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if client != "crm-service" {
+				t.Fatalf("client = %q", client)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package mw
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// Identity headers injected by APISIX after it has validated the caller's JWT.
+const (
+	HeaderUserID    = "X-User-ID"
+	HeaderTenantKey = "X-Tenant-Key"
+	HeaderRoles     = "X-Roles"
+)
+
+// ParseTrustedProxies parses a list of CIDRs or single IP addresses.
+func ParseTrustedProxies(list []string) ([]netip.Prefix, error) {
+	var out []netip.Prefix
+	for _, s := range list {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if p, err := netip.ParsePrefix(s); err == nil {
+			out = append(out, p.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q: not an IP address or CIDR", s)
+		}
+		out = append(out, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return out, nil
+}
+
+// TrustedHeaderAuth authenticates requests from the identity headers set by
+// the API gateway (X-User-ID, X-Tenant-Key and comma-separated X-Roles)
+// instead of verifying X-Internal-Token. Only use it when every request
+// reaches the service through the gateway, which must strip these headers
+// from client requests. When proxies is not empty, requests whose direct peer
+// is outside it are rejected.
+func TrustedHeaderAuth(proxies []netip.Prefix) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if len(proxies) > 0 && !fromTrustedProxy(req.RemoteAddr, proxies) {
+				log.Warn().
+					Str("remote_addr", req.RemoteAddr).
+					Str("uri", req.RequestURI).
+					Msg("Identity headers from an untrusted peer")
+				return echo.NewHTTPError(http.StatusUnauthorized, "untrusted peer")
+			}
+
+			userID := req.Header.Get(HeaderUserID)
+			if userID == "" {
+				log.Warn().
+					Str("uri", req.RequestURI).
+					Msg("Missing X-User-ID header (expected from APISIX Gateway)")
+				return echo.NewHTTPError(http.StatusUnauthorized, "missing user identity")
+			}
+			var roles []string
+			for _, r := range strings.Split(req.Header.Get(HeaderRoles), ",") {
+				if r = strings.TrimSpace(r); r != "" {
+					roles = append(roles, r)
+				}
+			}
+
+			c.Set("userID", userID)
+			c.Set("tenantID", req.Header.Get(HeaderTenantKey))
+			c.Set("roles", roles)
+			return next(c)
+		}
+	}
+}
+
+// fromTrustedProxy reports whether remoteAddr (host:port) is within proxies.
+func fromTrustedProxy(remoteAddr string, proxies []netip.Prefix) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range proxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package mw
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestTrustedHeaderAuth(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseTrustedProxies([]string{"gateway"}); err == nil {
+		t.Fatal("expected an error for a host name")
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     map[string]string
+		wantErr    bool
+	}{
+		{name: "gateway", remoteAddr: "10.1.2.3:4000",
+			header: map[string]string{HeaderUserID: "u1", HeaderTenantKey: "acme", HeaderRoles: "ADMIN, USER"}},
+		{name: "single proxy address", remoteAddr: "192.168.1.5:4000", header: map[string]string{HeaderUserID: "u1"}},
+		{name: "untrusted peer", remoteAddr: "203.0.113.9:4000", header: map[string]string{HeaderUserID: "u1"}, wantErr: true},
+		{name: "no user", remoteAddr: "10.1.2.3:4000", header: map[string]string{HeaderTenantKey: "acme"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/notifications", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			c := echo.New().NewContext(req, httptest.NewRecorder())
+
+			called := false
+			err := TrustedHeaderAuth(proxies)(func(echo.Context) error { called = true; return nil })(c)
+			if tt.wantErr {
+				if err == nil || called {
+					t.Fatalf("accepted the request (err %v)", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Get("userID") != tt.header[HeaderUserID] || c.Get("tenantID") != tt.header[HeaderTenantKey] {
+				t.Fatalf("userID = %v, tenantID = %v", c.Get("userID"), c.Get("tenantID"))
+			}
+			if tt.name == "gateway" && !slices.Equal(c.Get("roles").([]string), []string{"ADMIN", "USER"}) {
+				t.Fatalf("roles = %v", c.Get("roles"))
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package mw
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+func TestWidgetTokens(t *testing.T) {
+	wt := NewWidgetTokens("widget-secret", time.Hour)
+	token, expires, err := wt.Issue("acme", "u1", 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Until(expires); d > time.Hour || d < 59*time.Minute {
+		t.Fatalf("expires in %v, want the one-hour maximum", d)
+	}
+
+	sign := func(secret string, claims WidgetClaims) string {
+		s, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	valid := jwt.RegisteredClaims{Subject: "u1", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))}
+
+	tests := []struct {
+		name    string
+		header  string
+		query   string
+		wantErr bool
+	}{
+		{name: "bearer header", header: "Bearer " + token},
+		{name: "query parameter", query: token},
+		{name: "missing", wantErr: true},
+		{name: "other secret", header: "Bearer " + sign("other", WidgetClaims{TenantID: "acme", Scope: widgetScope, RegisteredClaims: valid}), wantErr: true},
+		{name: "other scope", header: "Bearer " + sign("widget-secret", WidgetClaims{TenantID: "acme", Scope: "openid", RegisteredClaims: valid}), wantErr: true},
+		{name: "no tenant", header: "Bearer " + sign("widget-secret", WidgetClaims{Scope: widgetScope, RegisteredClaims: valid}), wantErr: true},
+		{name: "no expiry", header: "Bearer " + sign("widget-secret", WidgetClaims{TenantID: "acme", Scope: widgetScope,
+			RegisteredClaims: jwt.RegisteredClaims{Subject: "u1"}}), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := "/widget/notifications"
+			if tt.query != "" {
+				target += "?token=" + tt.query
+			}
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			c := echo.New().NewContext(req, httptest.NewRecorder())
+
+			called := false
+			err := wt.Auth()(func(echo.Context) error { called = true; return nil })(c)
+			if tt.wantErr {
+				if err == nil || called {
+					t.Fatalf("accepted the request (err %v)", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Get("userID") != "u1" || c.Get("tenantKey") != "acme" {
+				t.Fatalf("userID = %v, tenantKey = %v", c.Get("userID"), c.Get("tenantKey"))
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 -- Migration: 001_create_notifications_table.sql
 -- Creates the central notifications table for arda-notification service.
 
+-- +goose Up
 CREATE TABLE IF NOT EXISTS notifications (
     id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
     tenant_key      VARCHAR(100) NOT NULL,
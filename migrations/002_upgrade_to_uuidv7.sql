@@ -6,6 +6,7 @@
 --   2. Reduces index fragmentation for high-insert tables
 --   3. Still globally unique (random component)
 --   4. Existing UUIDv4 rows remain valid (backward compatible)
+-- +goose Up
 -- Change default for new rows to use PG18's native uuidv7()
 ALTER TABLE notifications
 ALTER COLUMN id
@@ -1,6 +1,7 @@
 -- Migration: 003_create_notification_preferences.sql
 -- Stores per-user notification channel preferences and quiet hours.
 
+-- +goose Up
 CREATE TABLE IF NOT EXISTS notification_preferences (
     id                UUID PRIMARY KEY DEFAULT uuidv7(),
     tenant_key        VARCHAR(100) NOT NULL,
@@ -1,6 +1,7 @@
 -- Migration: 004_create_notification_templates.sql
 -- Stores localised notification templates that replace hardcoded messages.
 
+-- +goose Up
 CREATE TABLE IF NOT EXISTS notification_templates (
     id              UUID PRIMARY KEY DEFAULT uuidv7(),
     template_key    VARCHAR(100) NOT NULL,  -- e.g. "bpm.task.assigned"
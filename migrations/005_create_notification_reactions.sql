@@ -0,0 +1,21 @@
+-- Migration: 005_create_notification_reactions.sql
+-- Stores structured user responses (acknowledge / reject with comment) to notifications.
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS notification_reactions (
+    id              UUID PRIMARY KEY DEFAULT uuidv7(),
+    notification_id UUID         NOT NULL REFERENCES notifications(id) ON DELETE CASCADE,
+    tenant_key      VARCHAR(100) NOT NULL,
+    user_id         VARCHAR(255) NOT NULL,
+    response        VARCHAR(20)  NOT NULL CHECK (response IN ('ACK', 'REJECT')),
+    comment         TEXT         NOT NULL DEFAULT '',
+    source_event_id VARCHAR(255),
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+
+    UNIQUE(notification_id, user_id)
+);
+
+-- Lookup by the originating service (source event) within a tenant
+CREATE INDEX IF NOT EXISTS idx_reaction_source_event
+    ON notification_reactions (tenant_key, source_event_id)
+    WHERE source_event_id IS NOT NULL;
@@ -0,0 +1,18 @@
+-- Migration: 006_create_platform_rollouts.sql
+-- Holds back the remaining tenants of staged PLATFORM broadcasts until released.
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS platform_rollouts (
+    id              UUID PRIMARY KEY DEFAULT uuidv7(),
+    input           JSONB        NOT NULL,           -- serialized FanoutInput
+    pending_tenants TEXT[]       NOT NULL,
+    release_at      TIMESTAMPTZ,                     -- NULL = waits for manual confirmation
+    status          VARCHAR(20)  NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'RELEASED', 'CANCELLED')),
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+-- Index for the scheduler picking up due rollouts
+CREATE INDEX IF NOT EXISTS idx_rollout_due
+    ON platform_rollouts (release_at)
+    WHERE status = 'PENDING';
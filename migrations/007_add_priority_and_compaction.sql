@@ -0,0 +1,13 @@
+-- Migration: 007_add_priority_and_compaction.sql
+-- Adds notification priority and supports the compaction job that collapses
+-- runs of old, read, LOW-priority notifications into a single summary row.
+
+-- +goose Up
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS priority VARCHAR(10) NOT NULL DEFAULT 'NORMAL'
+        CHECK (priority IN ('LOW', 'NORMAL', 'HIGH', 'URGENT'));
+
+-- Compaction scans read LOW-priority rows per user in creation order
+CREATE INDEX IF NOT EXISTS idx_notif_compaction
+    ON notifications (tenant_key, user_id, created_at)
+    WHERE is_read = TRUE AND priority = 'LOW';
@@ -0,0 +1,16 @@
+-- Migration: 008_create_delivery_outbox.sql
+-- Delivery outbox: written in the same statement as the notification so a crash
+-- between insert and real-time delivery never loses the SSE/email push.
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS delivery_outbox (
+    id              BIGSERIAL    PRIMARY KEY,
+    notification_id UUID         NOT NULL REFERENCES notifications(id) ON DELETE CASCADE,
+    attempts        INT          NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+-- Dispatcher claims due entries in insertion order
+CREATE INDEX IF NOT EXISTS idx_outbox_due
+    ON delivery_outbox (next_attempt_at, id);
@@ -0,0 +1,12 @@
+-- Migration: 009_create_delivery_policies.sql
+-- Per-tenant Rego policies evaluated at fan-out time to allow/deny delivery
+-- or narrow the channel set of a notification.
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS delivery_policies (
+    tenant_key  VARCHAR(100) PRIMARY KEY,
+    module      TEXT         NOT NULL,           -- Rego source, package arda.delivery
+    enabled     BOOLEAN      NOT NULL DEFAULT TRUE,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,13 @@
+-- Migration: 010_create_tenant_encryption_keys.sql
+-- Bring-your-own-key: per-tenant KMS key reference used to encrypt notification content.
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS tenant_encryption_keys (
+    tenant_key  VARCHAR(100) PRIMARY KEY,
+    key_ref     VARCHAR(512) NOT NULL,           -- KMS key reference (ARN, resource name, alias)
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+-- Ciphertext (nonce, tag, base64, key reference) outgrows VARCHAR(255).
+ALTER TABLE notifications ALTER COLUMN title TYPE TEXT;
@@ -0,0 +1,34 @@
+-- Migration: 011_create_broadcast_notifications.sql
+-- Fan-out on read: TENANT/PLATFORM notifications stored once and joined with
+-- per-user read state at query time instead of one row per recipient.
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS broadcast_notifications (
+    id              UUID PRIMARY KEY DEFAULT uuidv7(),
+    tenant_key      VARCHAR(100),                    -- NULL = every tenant (PLATFORM)
+    type            VARCHAR(50)  NOT NULL CHECK (type IN ('SYSTEM', 'WORKFLOW', 'CRM', 'IAM', 'CUSTOM')),
+    priority        VARCHAR(10)  NOT NULL DEFAULT 'NORMAL'
+        CHECK (priority IN ('LOW', 'NORMAL', 'HIGH', 'URGENT')),
+    title           TEXT         NOT NULL,
+    body            TEXT         NOT NULL DEFAULT '',
+    metadata        JSONB,
+    source_event_id VARCHAR(255) UNIQUE,             -- idempotency key
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_broadcast_tenant_created
+    ON broadcast_notifications (tenant_key, created_at DESC);
+
+-- Per-user state; a missing row means unread and not deleted.
+CREATE TABLE IF NOT EXISTS broadcast_read_state (
+    broadcast_id UUID         NOT NULL REFERENCES broadcast_notifications(id) ON DELETE CASCADE,
+    tenant_key   VARCHAR(100) NOT NULL,
+    user_id      VARCHAR(255) NOT NULL,
+    read_at      TIMESTAMPTZ,
+    deleted_at   TIMESTAMPTZ,
+
+    PRIMARY KEY (broadcast_id, tenant_key, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_broadcast_state_user
+    ON broadcast_read_state (tenant_key, user_id);
@@ -0,0 +1,18 @@
+-- Migration: 012_create_event_traces.sql
+-- Processing ledger: one row per pipeline stage of a source event, used to
+-- assemble the per-event trace exposed to admins.
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS event_traces (
+    id              BIGSERIAL    PRIMARY KEY,
+    source_event_id VARCHAR(255) NOT NULL,
+    stage           VARCHAR(40)  NOT NULL,
+    detail          JSONB,
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_traces_source
+    ON event_traces (source_event_id, id);
+
+CREATE INDEX IF NOT EXISTS idx_event_traces_created
+    ON event_traces (created_at);
@@ -0,0 +1,20 @@
+-- Migration: 013_create_user_directory.sql
+-- Database-backed user directory used by IAM_PROVIDER=db (on-prem deployments
+-- without Keycloak). Kept in sync by the deployment's own provisioning.
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS user_directory (
+    tenant_key VARCHAR(100) NOT NULL,
+    user_id    VARCHAR(255) NOT NULL,
+    active     BOOLEAN      NOT NULL DEFAULT TRUE,
+    roles      TEXT[]       NOT NULL DEFAULT '{}',
+    groups     TEXT[]       NOT NULL DEFAULT '{}',   -- group IDs or paths ("/Finance")
+    updated_at TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_key, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_directory_roles
+    ON user_directory USING GIN (roles);
+
+CREATE INDEX IF NOT EXISTS idx_user_directory_groups
+    ON user_directory USING GIN (groups);
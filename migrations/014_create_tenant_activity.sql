@@ -0,0 +1,12 @@
+-- Migration: 014_create_tenant_activity.sql
+-- Last user-facing activity (REST/SSE) per tenant. Tenants without recent activity
+-- are skipped by background jobs and have their in-memory caches reclaimed.
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS tenant_activity (
+    tenant_key     VARCHAR(100) PRIMARY KEY,
+    last_active_at TIMESTAMPTZ  NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_tenant_activity_last_active
+    ON tenant_activity (last_active_at);
@@ -0,0 +1,25 @@
+-- Migration: 015_add_notification_categories.sql
+-- Adds a category dimension finer than type (e.g. "crm.deal", "bpm.approval").
+-- Notifications carry it for filtering; preferences may target a single category,
+-- overriding the type-level preference ('' = the whole type).
+
+-- +goose Up
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS category VARCHAR(100) NOT NULL DEFAULT '';
+
+ALTER TABLE broadcast_notifications
+    ADD COLUMN IF NOT EXISTS category VARCHAR(100) NOT NULL DEFAULT '';
+
+-- List API filter: category within a user's inbox
+CREATE INDEX IF NOT EXISTS idx_notif_user_category
+    ON notifications (tenant_key, user_id, category, created_at DESC)
+    WHERE category <> '';
+
+ALTER TABLE notification_preferences
+    ADD COLUMN IF NOT EXISTS category VARCHAR(100) NOT NULL DEFAULT '';
+
+ALTER TABLE notification_preferences
+    DROP CONSTRAINT IF EXISTS notification_preferences_tenant_key_user_id_type_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_pref_user_type_category
+    ON notification_preferences (tenant_key, user_id, type, category);
@@ -0,0 +1,30 @@
+-- Migration: 016_create_notification_tombstones.sql
+-- Keeps a copy of notifications removed by the user (deleted) or by compaction
+-- (compacted) so a user's inbox can be reconstructed as of a past timestamp for
+-- audits. Tombstones follow the notification retention (TTL purge).
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS notification_tombstones (
+    id              UUID PRIMARY KEY,
+    tenant_key      VARCHAR(100) NOT NULL,
+    user_id         VARCHAR(255) NOT NULL,
+    type            VARCHAR(50)  NOT NULL,
+    category        VARCHAR(100) NOT NULL DEFAULT '',
+    priority        VARCHAR(10)  NOT NULL DEFAULT 'NORMAL',
+    title           TEXT         NOT NULL,
+    body            TEXT         NOT NULL DEFAULT '',
+    metadata        JSONB,
+    read_at         TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ  NOT NULL,
+    source_event_id VARCHAR(255),
+    deleted_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    reason          VARCHAR(20)  NOT NULL CHECK (reason IN ('deleted', 'compacted'))
+);
+
+-- As-of listing: a user's tombstones alive at a timestamp
+CREATE INDEX IF NOT EXISTS idx_tombstone_user_created
+    ON notification_tombstones (tenant_key, user_id, created_at DESC);
+
+-- TTL purge
+CREATE INDEX IF NOT EXISTS idx_tombstone_created_at
+    ON notification_tombstones (created_at);
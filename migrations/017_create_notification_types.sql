@@ -0,0 +1,30 @@
+-- Migration: 017_create_notification_types.sql
+-- Tenant-registered notification types with display metadata. The fixed type
+-- CHECK constraints are relaxed to a format check; ingest validates custom types
+-- against this table instead of coercing unknown types to CUSTOM.
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS notification_types (
+    tenant_key       VARCHAR(100) NOT NULL,
+    type             VARCHAR(50)  NOT NULL CHECK (type ~ '^[A-Z][A-Z0-9_]*$'),
+    name             VARCHAR(255) NOT NULL,
+    icon             VARCHAR(255) NOT NULL DEFAULT '',
+    default_priority VARCHAR(10)  NOT NULL DEFAULT 'NORMAL'
+        CHECK (default_priority IN ('LOW', 'NORMAL', 'HIGH', 'URGENT')),
+    retention_days   INT          NOT NULL DEFAULT 0,  -- 0 = global TTL
+    created_at       TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_key, type)
+);
+
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_type_check;
+ALTER TABLE notifications
+    ADD CONSTRAINT notifications_type_check CHECK (type ~ '^[A-Z][A-Z0-9_]*$');
+
+ALTER TABLE broadcast_notifications DROP CONSTRAINT IF EXISTS broadcast_notifications_type_check;
+ALTER TABLE broadcast_notifications
+    ADD CONSTRAINT broadcast_notifications_type_check CHECK (type ~ '^[A-Z][A-Z0-9_]*$');
+
+ALTER TABLE notification_preferences DROP CONSTRAINT IF EXISTS notification_preferences_type_check;
+ALTER TABLE notification_preferences
+    ADD CONSTRAINT notification_preferences_type_check CHECK (type ~ '^[A-Z][A-Z0-9_]*$');
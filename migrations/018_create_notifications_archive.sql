@@ -0,0 +1,30 @@
+-- Migration: 018_create_notifications_archive.sql
+-- Cold tier for a user's notifications beyond the per-user hot cap (newest N rows).
+-- The list API reads it only when paging past the hot rows. Archived rows follow
+-- the notification retention (TTL purge).
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS notifications_archive (
+    id              UUID PRIMARY KEY,
+    tenant_key      VARCHAR(100) NOT NULL,
+    user_id         VARCHAR(255) NOT NULL,
+    type            VARCHAR(50)  NOT NULL,
+    category        VARCHAR(100) NOT NULL DEFAULT '',
+    priority        VARCHAR(10)  NOT NULL DEFAULT 'NORMAL',
+    title           TEXT         NOT NULL,
+    body            TEXT         NOT NULL DEFAULT '',
+    metadata        JSONB,
+    is_read         BOOLEAN      NOT NULL DEFAULT FALSE,
+    read_at         TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ  NOT NULL,
+    source_event_id VARCHAR(255),
+    archived_at     TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+-- Deep pagination of a user's inbox
+CREATE INDEX IF NOT EXISTS idx_archive_user_created
+    ON notifications_archive (tenant_key, user_id, created_at DESC);
+
+-- TTL purge
+CREATE INDEX IF NOT EXISTS idx_archive_created_at
+    ON notifications_archive (created_at);
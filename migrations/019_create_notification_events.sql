@@ -0,0 +1,29 @@
+-- Migration: 019_create_notification_events.sql
+-- Append-only per-user state stream of notifications (seen, read, deleted, ...).
+-- is_read/read_at and broadcast_read_state remain as the projection used by list
+-- queries; they are updated in the same statement that appends the event.
+-- "created" is derived from the notification row and never stored.
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS notification_events (
+    seq             BIGSERIAL    PRIMARY KEY,
+    notification_id UUID         NOT NULL,  -- no FK: events outlive deleted notifications
+    tenant_key      VARCHAR(100) NOT NULL,
+    user_id         VARCHAR(255) NOT NULL,
+    kind            VARCHAR(20)  NOT NULL
+        CHECK (kind IN ('seen', 'read', 'unread', 'snoozed', 'deleted', 'restored', 'recalled')),
+    occurred_at     TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    data            JSONB
+);
+
+-- Cross-device sync: a user's events after a cursor
+CREATE INDEX IF NOT EXISTS idx_notification_events_user_seq
+    ON notification_events (tenant_key, user_id, seq);
+
+-- History of one notification
+CREATE INDEX IF NOT EXISTS idx_notification_events_notification
+    ON notification_events (notification_id, seq);
+
+-- TTL purge
+CREATE INDEX IF NOT EXISTS idx_notification_events_occurred_at
+    ON notification_events (occurred_at);
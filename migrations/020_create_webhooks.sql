@@ -0,0 +1,46 @@
+-- Migration: 020_create_webhooks.sql
+-- Tenant-registered HTTPS endpoints receiving notification events, and the queue of
+-- deliveries to them with retry state. Each request is signed with the webhook secret.
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS webhooks (
+    id          UUID         PRIMARY KEY DEFAULT uuidv7(),
+    tenant_key  VARCHAR(100) NOT NULL,
+    url         TEXT         NOT NULL,
+    secret      VARCHAR(255) NOT NULL,
+    events      TEXT[]       NOT NULL,
+    active      BOOLEAN      NOT NULL DEFAULT TRUE,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhooks_tenant
+    ON webhooks (tenant_key) WHERE active;
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id               BIGSERIAL    PRIMARY KEY,
+    webhook_id       UUID         NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    tenant_key       VARCHAR(100) NOT NULL,
+    event            VARCHAR(50)  NOT NULL,
+    payload          JSONB        NOT NULL,
+    status           VARCHAR(20)  NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'delivered', 'failed')),
+    attempts         INT          NOT NULL DEFAULT 0,
+    next_attempt_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    last_status_code INT,
+    last_error       TEXT,
+    created_at       TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    delivered_at     TIMESTAMPTZ
+);
+
+-- Dispatcher claims due pending deliveries in insertion order
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due
+    ON webhook_deliveries (next_attempt_at, id) WHERE status = 'pending';
+
+-- Delivery status per webhook
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook
+    ON webhook_deliveries (webhook_id, id DESC);
+
+-- Purge
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_created_at
+    ON webhook_deliveries (created_at);
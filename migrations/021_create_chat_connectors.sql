@@ -0,0 +1,20 @@
+-- Migration: 021_create_chat_connectors.sql
+-- Slack / Microsoft Teams incoming webhooks receiving a tenant's notifications of
+-- selected types (e.g. SYSTEM for ops channels).
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS chat_connectors (
+    id          UUID         PRIMARY KEY DEFAULT uuidv7(),
+    tenant_key  VARCHAR(100) NOT NULL,
+    name        VARCHAR(100) NOT NULL,
+    provider    VARCHAR(20)  NOT NULL CHECK (provider IN ('slack', 'teams')),
+    url         TEXT         NOT NULL,
+    types       TEXT[]       NOT NULL,
+    template    TEXT         NOT NULL DEFAULT '',
+    active      BOOLEAN      NOT NULL DEFAULT TRUE,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_connectors_tenant
+    ON chat_connectors (tenant_key) WHERE active;
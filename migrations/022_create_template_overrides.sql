@@ -0,0 +1,22 @@
+-- Migration: 022_create_template_overrides.sql
+-- Tenant-level overrides of platform notification templates. A white-label tenant
+-- changes only the fields it needs; the rest is inherited from its parent tenant
+-- (template_inheritance) and finally from notification_templates.
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS template_overrides (
+    tenant_key      VARCHAR(100) NOT NULL,
+    template_key    VARCHAR(100) NOT NULL,
+    locale          VARCHAR(10)  NOT NULL,
+    title_template  TEXT         NOT NULL DEFAULT '',  -- '' = inherit
+    body_template   TEXT         NOT NULL DEFAULT '',  -- '' = inherit
+    updated_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_key, template_key, locale)
+);
+
+-- A tenant inherits overrides from at most one parent (e.g. a reseller's sub-tenants).
+CREATE TABLE IF NOT EXISTS template_inheritance (
+    tenant_key     VARCHAR(100) PRIMARY KEY,
+    inherits_from  VARCHAR(100) NOT NULL CHECK (inherits_from <> tenant_key),
+    updated_at     TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
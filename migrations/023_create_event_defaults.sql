@@ -0,0 +1,14 @@
+-- Migration: 023_create_event_defaults.sql
+-- Per-event-type defaults applied to Kafka events whose handler leaves priority,
+-- category, TTL or channels unset. event_key is the handler key "topic:eventType"
+-- ("topic:" for direct topics such as notification-commands).
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS event_defaults (
+    event_key   VARCHAR(200) PRIMARY KEY,
+    priority    VARCHAR(10)  NOT NULL DEFAULT '',
+    category    VARCHAR(100) NOT NULL DEFAULT '',
+    ttl_seconds INTEGER      NOT NULL DEFAULT 0 CHECK (ttl_seconds >= 0),
+    channels    TEXT[],
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,14 @@
+-- Migration: 024_add_snoozed_until.sql
+-- Snoozed notifications are hidden from the inbox and unread count until
+-- snoozed_until; the wake-up scheduler clears the column and re-broadcasts them.
+-- Broadcasts are snoozed per user in broadcast_read_state.
+
+-- +goose Up
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS snoozed_until TIMESTAMPTZ;
+ALTER TABLE broadcast_read_state ADD COLUMN IF NOT EXISTS snoozed_until TIMESTAMPTZ;
+
+-- Wake-up scheduler
+CREATE INDEX IF NOT EXISTS idx_notifications_snoozed_until
+    ON notifications (snoozed_until) WHERE snoozed_until IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_broadcast_state_snoozed_until
+    ON broadcast_read_state (snoozed_until) WHERE snoozed_until IS NOT NULL;
@@ -0,0 +1,13 @@
+-- Migration: 025_add_pinned.sql
+-- Pinned notifications are listed first and are never purged, expired, archived
+-- or compacted while pinned. Broadcasts are pinned per user in broadcast_read_state.
+
+-- +goose Up
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS pinned BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE broadcast_read_state ADD COLUMN IF NOT EXISTS pinned BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- pinned=true filter
+CREATE INDEX IF NOT EXISTS idx_notifications_pinned
+    ON notifications (tenant_key, user_id, created_at DESC) WHERE pinned;
+CREATE INDEX IF NOT EXISTS idx_broadcast_state_pinned
+    ON broadcast_read_state (broadcast_id) WHERE pinned;
@@ -0,0 +1,19 @@
+-- Migration: 026_create_notification_actions.sql
+-- Records the action button a user chose on a notification (one per user).
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS notification_actions (
+    id              UUID PRIMARY KEY DEFAULT uuidv7(),
+    notification_id UUID         NOT NULL REFERENCES notifications(id) ON DELETE CASCADE,
+    tenant_key      VARCHAR(100) NOT NULL,
+    user_id         VARCHAR(255) NOT NULL,
+    action_id       VARCHAR(100) NOT NULL,
+    type            VARCHAR(20)  NOT NULL CHECK (type IN ('link', 'http', 'command')),
+    command         VARCHAR(255) NOT NULL DEFAULT '',
+    payload         JSONB,
+    comment         TEXT         NOT NULL DEFAULT '',
+    source_event_id VARCHAR(255),
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+
+    UNIQUE(notification_id, user_id)
+);
@@ -0,0 +1,28 @@
+-- Migration: 027_add_notification_entity.sql
+-- Links notifications to the business entity they are about (a deal, a task, a
+-- tenant), so its notification history can be listed. The columns are derived
+-- from metadata.entityType / metadata.entityId, which event handlers set.
+
+-- +goose Up
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS entity_type TEXT GENERATED ALWAYS AS (metadata->>'entityType') STORED,
+    ADD COLUMN IF NOT EXISTS entity_id   TEXT GENERATED ALWAYS AS (metadata->>'entityId') STORED;
+
+ALTER TABLE notifications_archive
+    ADD COLUMN IF NOT EXISTS entity_type TEXT GENERATED ALWAYS AS (metadata->>'entityType') STORED,
+    ADD COLUMN IF NOT EXISTS entity_id   TEXT GENERATED ALWAYS AS (metadata->>'entityId') STORED;
+
+ALTER TABLE broadcast_notifications
+    ADD COLUMN IF NOT EXISTS entity_type TEXT GENERATED ALWAYS AS (metadata->>'entityType') STORED,
+    ADD COLUMN IF NOT EXISTS entity_id   TEXT GENERATED ALWAYS AS (metadata->>'entityId') STORED;
+
+-- by-entity listing within a user's inbox
+CREATE INDEX IF NOT EXISTS idx_notif_user_entity
+    ON notifications (tenant_key, user_id, entity_type, entity_id, created_at DESC)
+    WHERE entity_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_archive_user_entity
+    ON notifications_archive (tenant_key, user_id, entity_type, entity_id, created_at DESC)
+    WHERE entity_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_broadcast_entity
+    ON broadcast_notifications (entity_type, entity_id)
+    WHERE entity_id IS NOT NULL;
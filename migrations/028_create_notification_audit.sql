@@ -0,0 +1,54 @@
+-- Migration: 028_create_notification_audit.sql
+-- Append-only lifecycle log of notifications (created, delivered, read, deleted,
+-- purged, ...) with actor and timestamp, kept as proof of notification.
+-- Unlike notification_events it is not purged with the notification TTL, only by
+-- its own retention. Rows are never updated.
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS notification_audit (
+    id              BIGSERIAL    PRIMARY KEY,
+    notification_id UUID         NOT NULL,  -- no FK: the audit outlives the notification
+    tenant_key      VARCHAR(100) NOT NULL,  -- '' for platform broadcasts
+    user_id         VARCHAR(255) NOT NULL DEFAULT '',  -- '' for broadcasts
+    action          VARCHAR(20)  NOT NULL
+        CHECK (action IN ('created', 'delivered', 'read', 'unread', 'deleted', 'restored', 'recalled', 'purged')),
+    actor           VARCHAR(255) NOT NULL,
+    channel         VARCHAR(20)  NOT NULL DEFAULT '',
+    source_event_id VARCHAR(255),
+    data            JSONB,
+    occurred_at     TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+-- History of one notification
+CREATE INDEX IF NOT EXISTS idx_notification_audit_notification
+    ON notification_audit (tenant_key, notification_id, occurred_at);
+
+-- Per-user and per-source-event queries
+CREATE INDEX IF NOT EXISTS idx_notification_audit_user
+    ON notification_audit (tenant_key, user_id, occurred_at);
+CREATE INDEX IF NOT EXISTS idx_notification_audit_source_event
+    ON notification_audit (tenant_key, source_event_id) WHERE source_event_id IS NOT NULL;
+
+-- Retention purge
+CREATE INDEX IF NOT EXISTS idx_notification_audit_occurred_at
+    ON notification_audit (occurred_at);
+
+-- User state changes are mirrored from notification_events, which every read /
+-- delete / restore path already appends to in the same statement.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION notification_audit_state_event() RETURNS trigger AS $$
+BEGIN
+    IF NEW.kind IN ('read', 'unread', 'deleted', 'restored', 'recalled') THEN
+        INSERT INTO notification_audit (notification_id, tenant_key, user_id, action, actor, occurred_at)
+        VALUES (NEW.notification_id, NEW.tenant_key, NEW.user_id, NEW.kind,
+                CASE WHEN NEW.kind = 'recalled' THEN 'system' ELSE NEW.user_id END, NEW.occurred_at);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+DROP TRIGGER IF EXISTS trg_notification_audit_state_event ON notification_events;
+CREATE TRIGGER trg_notification_audit_state_event
+    AFTER INSERT ON notification_events
+    FOR EACH ROW EXECUTE FUNCTION notification_audit_state_event();
@@ -0,0 +1,11 @@
+-- Migration: 029_create_consumer_pauses.sql
+-- Kafka topics whose consumption is paused by an admin, e.g. while an upstream
+-- bug floods bogus events. Every instance syncs its consumer with this table.
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS consumer_pauses (
+    topic     VARCHAR(255) PRIMARY KEY,
+    reason    TEXT         NOT NULL DEFAULT '',
+    paused_by VARCHAR(255) NOT NULL DEFAULT '',
+    paused_at TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,20 @@
+-- Migration: 030_scope_source_event_uniqueness.sql
+-- source_event_id was unique across all notifications, so a fan-out to N users
+-- stored only the first row and two tenants reusing an event ID collided. It is
+-- now unique per recipient: (source_event_id, tenant_key, user_id) for
+-- notifications and (source_event_id, tenant_key) for broadcasts, where a NULL
+-- tenant (PLATFORM) counts as one tenant.
+
+-- +goose Up
+DROP INDEX IF EXISTS idx_notif_source_event;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notif_source_event_recipient
+    ON notifications (source_event_id, tenant_key, user_id)
+    WHERE source_event_id IS NOT NULL;
+
+ALTER TABLE broadcast_notifications
+    DROP CONSTRAINT IF EXISTS broadcast_notifications_source_event_id_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_broadcast_source_event_tenant
+    ON broadcast_notifications (source_event_id, tenant_key) NULLS NOT DISTINCT
+    WHERE source_event_id IS NOT NULL;
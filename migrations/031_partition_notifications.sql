@@ -0,0 +1,91 @@
+-- Migration: 031_partition_notifications.sql
+-- Range-partitions notifications by created_at month (UTC) so the TTL purge drops
+-- whole partitions instead of deleting rows. The existing table becomes the
+-- notifications_legacy partition (everything before next month) without copying
+-- rows; monthly partitions ahead are created by the service (EnsurePartitions)
+-- and notifications_default catches rows outside them.
+--
+-- Unique indexes on a partitioned table must include created_at, so:
+--   * the primary key becomes (id, created_at);
+--   * source_event_id idempotency is enforced per partition, by a unique index
+--     that EnsurePartitions creates on every partition;
+--   * the delivery_outbox / reactions / actions foreign keys are replaced by a
+--     delete trigger (dropped partitions clean up their dependents explicitly).
+
+-- +goose Up
+ALTER TABLE delivery_outbox DROP CONSTRAINT IF EXISTS delivery_outbox_notification_id_fkey;
+ALTER TABLE notification_reactions DROP CONSTRAINT IF EXISTS notification_reactions_notification_id_fkey;
+ALTER TABLE notification_actions DROP CONSTRAINT IF EXISTS notification_actions_notification_id_fkey;
+
+-- Dependents are now deleted by id lookups instead of the foreign key cascade
+CREATE INDEX IF NOT EXISTS idx_outbox_notification
+    ON delivery_outbox (notification_id);
+
+-- +goose StatementBegin
+DO $$
+DECLARE
+    idx TEXT;
+BEGIN
+    ALTER TABLE notifications RENAME TO notifications_legacy;
+    FOR idx IN
+        SELECT c.relname FROM pg_index i JOIN pg_class c ON c.oid = i.indexrelid
+        WHERE i.indrelid = 'notifications_legacy'::regclass
+    LOOP
+        EXECUTE format('ALTER INDEX %I RENAME TO %I', idx, 'legacy_' || idx);
+    END LOOP;
+END;
+$$;
+-- +goose StatementEnd
+
+CREATE TABLE notifications (
+    LIKE notifications_legacy INCLUDING DEFAULTS INCLUDING CONSTRAINTS INCLUDING GENERATED,
+    PRIMARY KEY (id, created_at)
+) PARTITION BY RANGE (created_at);
+
+CREATE INDEX IF NOT EXISTS idx_notif_user_tenant
+    ON notifications (tenant_key, user_id, is_read, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_notif_created_at
+    ON notifications (created_at);
+CREATE INDEX IF NOT EXISTS idx_notif_compaction
+    ON notifications (tenant_key, user_id, created_at)
+    WHERE is_read = TRUE AND priority = 'LOW';
+CREATE INDEX IF NOT EXISTS idx_notif_user_category
+    ON notifications (tenant_key, user_id, category, created_at DESC)
+    WHERE category <> '';
+CREATE INDEX IF NOT EXISTS idx_notifications_snoozed_until
+    ON notifications (snoozed_until) WHERE snoozed_until IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_notifications_pinned
+    ON notifications (tenant_key, user_id, created_at DESC) WHERE pinned;
+CREATE INDEX IF NOT EXISTS idx_notif_user_entity
+    ON notifications (tenant_key, user_id, entity_type, entity_id, created_at DESC)
+    WHERE entity_id IS NOT NULL;
+
+-- +goose StatementBegin
+DO $$
+BEGIN
+    EXECUTE format(
+        'ALTER TABLE notifications ATTACH PARTITION notifications_legacy FOR VALUES FROM (MINVALUE) TO (%L)',
+        date_trunc('month', NOW(), 'UTC') + INTERVAL '1 month');
+END;
+$$;
+-- +goose StatementEnd
+
+CREATE TABLE IF NOT EXISTS notifications_default PARTITION OF notifications DEFAULT;
+CREATE UNIQUE INDEX IF NOT EXISTS notifications_default_source_event_recipient
+    ON notifications_default (source_event_id, tenant_key, user_id)
+    WHERE source_event_id IS NOT NULL;
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION notifications_delete_dependents() RETURNS trigger AS $$
+BEGIN
+    DELETE FROM delivery_outbox WHERE notification_id = OLD.id;
+    DELETE FROM notification_reactions WHERE notification_id = OLD.id;
+    DELETE FROM notification_actions WHERE notification_id = OLD.id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER trg_notifications_delete_dependents
+    AFTER DELETE ON notifications
+    FOR EACH ROW EXECUTE FUNCTION notifications_delete_dependents();
@@ -0,0 +1,31 @@
+-- Migration: 032_create_retention_policies.sql
+-- Retention per tenant and per notification type, replacing the single global
+-- ttl.retention_days (now only the fallback). An empty tenant_key applies to all
+-- tenants and an empty type to all types; retention_days = 0 keeps forever.
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS retention_policies (
+    tenant_key     VARCHAR(100) NOT NULL DEFAULT '',
+    type           VARCHAR(50)  NOT NULL DEFAULT '' CHECK (type = '' OR type ~ '^[A-Z][A-Z0-9_]*$'),
+    retention_days INT          NOT NULL CHECK (retention_days >= 0),
+    updated_at     TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_key, type)
+);
+
+-- Resolves the retention of a notification, most specific first: the tenant's
+-- policy for the type, the tenant custom type's retention_days, the type's policy
+-- for all tenants, the tenant's policy for all types, the policy for everything,
+-- then fallback_days. Platform broadcasts pass an empty tenant.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION notification_retention_days(p_tenant TEXT, p_type TEXT, fallback_days INT)
+RETURNS INT LANGUAGE sql STABLE AS $$
+    SELECT COALESCE(
+        (SELECT retention_days FROM retention_policies WHERE tenant_key = p_tenant AND type = p_type),
+        (SELECT retention_days FROM notification_types
+            WHERE tenant_key = p_tenant AND type = p_type AND retention_days > 0),
+        (SELECT retention_days FROM retention_policies WHERE tenant_key = '' AND type = p_type),
+        (SELECT retention_days FROM retention_policies WHERE tenant_key = p_tenant AND type = ''),
+        (SELECT retention_days FROM retention_policies WHERE tenant_key = '' AND type = ''),
+        fallback_days)
+$$;
+-- +goose StatementEnd
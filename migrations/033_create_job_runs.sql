@@ -0,0 +1,11 @@
+-- Migration: 033_create_job_runs.sql
+-- Last completed run of each scheduled maintenance job, so a restarted instance
+-- knows whether it missed a run and catches up instead of waiting a full period.
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS job_runs (
+    name        VARCHAR(100) PRIMARY KEY,
+    last_run_at TIMESTAMPTZ  NOT NULL,
+    duration_ms BIGINT       NOT NULL DEFAULT 0,
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
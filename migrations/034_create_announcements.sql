@@ -0,0 +1,31 @@
+-- Migration: 034_create_announcements.sql
+-- In-app banners for a tenant or the whole platform. One row per announcement
+-- plus one row per user who dismissed it, instead of a PLATFORM fan-out writing
+-- a notification for every user.
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS announcements (
+    id          UUID         PRIMARY KEY DEFAULT uuidv7(),
+    tenant_key  VARCHAR(100) NOT NULL DEFAULT '',     -- '' = every tenant
+    title       TEXT         NOT NULL,
+    body        TEXT         NOT NULL DEFAULT '',
+    severity    VARCHAR(10)  NOT NULL DEFAULT 'info'
+        CHECK (severity IN ('info', 'warning', 'critical')),
+    starts_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    ends_at     TIMESTAMPTZ  CHECK (ends_at IS NULL OR ends_at > starts_at),
+    created_by  VARCHAR(255) NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_announcements_tenant_starts
+    ON announcements (tenant_key, starts_at DESC);
+
+CREATE TABLE IF NOT EXISTS announcement_dismissals (
+    announcement_id UUID         NOT NULL REFERENCES announcements(id) ON DELETE CASCADE,
+    tenant_key      VARCHAR(100) NOT NULL,
+    user_id         VARCHAR(255) NOT NULL,
+    dismissed_at    TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (announcement_id, tenant_key, user_id)
+);
@@ -0,0 +1,38 @@
+-- Migration: 035_create_escalations.sql
+-- Escalation of HIGH / URGENT notifications left unread: tenant rules, and one
+-- pending row per (notification, rule) written when the notification is
+-- delivered. Reads drop the pending rows; the scheduler claims the due ones.
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS escalation_rules (
+    id             UUID         PRIMARY KEY DEFAULT uuidv7(),
+    tenant_key     VARCHAR(100) NOT NULL,
+    type           VARCHAR(50)  NOT NULL DEFAULT '',             -- '' = every type
+    priority       VARCHAR(10)  NOT NULL CHECK (priority IN ('HIGH', 'URGENT')),
+    after_seconds  INT          NOT NULL CHECK (after_seconds > 0),
+    action         VARCHAR(20)  NOT NULL CHECK (action IN ('renotify', 'email', 'role')),
+    role           VARCHAR(255) NOT NULL DEFAULT '',
+    active         BOOLEAN      NOT NULL DEFAULT TRUE,
+    created_at     TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    CHECK (action <> 'role' OR role <> '')
+);
+
+CREATE INDEX IF NOT EXISTS idx_escalation_rules_tenant
+    ON escalation_rules (tenant_key) WHERE active;
+
+CREATE TABLE IF NOT EXISTS notification_escalations (
+    notification_id UUID         NOT NULL,
+    rule_id         UUID         NOT NULL REFERENCES escalation_rules(id) ON DELETE CASCADE,
+    tenant_key      VARCHAR(100) NOT NULL,
+    user_id         VARCHAR(255) NOT NULL,
+    due_at          TIMESTAMPTZ  NOT NULL,
+
+    PRIMARY KEY (notification_id, rule_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_escalations_due
+    ON notification_escalations (due_at);
+
+CREATE INDEX IF NOT EXISTS idx_notification_escalations_user
+    ON notification_escalations (tenant_key, user_id);
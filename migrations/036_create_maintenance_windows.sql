@@ -0,0 +1,31 @@
+-- Migration: 036_create_maintenance_windows.sql
+-- Planned maintenance windows: a tenant's SYSTEM notifications are dropped or
+-- held during the window, so the tenant-status events of a maintenance do not
+-- reach every user one by one. Held fan-out inputs are delivered as digests
+-- when the window ends.
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS maintenance_windows (
+    id          UUID         PRIMARY KEY DEFAULT uuidv7(),
+    tenant_key  VARCHAR(100) NOT NULL DEFAULT '',     -- '' = every tenant
+    reason      TEXT         NOT NULL DEFAULT '',
+    mode        VARCHAR(10)  NOT NULL CHECK (mode IN ('suppress', 'batch')),
+    starts_at   TIMESTAMPTZ  NOT NULL,
+    ends_at     TIMESTAMPTZ  NOT NULL CHECK (ends_at > starts_at),
+    flushed_at  TIMESTAMPTZ,
+    created_by  VARCHAR(255) NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_maintenance_windows_tenant_ends
+    ON maintenance_windows (tenant_key, ends_at);
+
+CREATE TABLE IF NOT EXISTS maintenance_held_notifications (
+    id          BIGSERIAL    PRIMARY KEY,
+    window_id   UUID         NOT NULL REFERENCES maintenance_windows(id) ON DELETE CASCADE,
+    input       JSONB        NOT NULL,
+    held_at     TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_maintenance_held_window
+    ON maintenance_held_notifications (window_id, id);
@@ -0,0 +1,13 @@
+-- Migration: 037_create_direct_message_rules.sql
+-- Per-tenant rules for user-to-user notifications (POST /notifications/send).
+-- Tenants without a row use the service defaults.
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS direct_message_rules (
+    tenant_key     VARCHAR(100) PRIMARY KEY,
+    enabled        BOOLEAN      NOT NULL DEFAULT TRUE,
+    sender_roles   TEXT[]       NOT NULL DEFAULT '{}',   -- empty = every user
+    max_recipients INT          NOT NULL CHECK (max_recipients > 0),
+    per_minute     INT          NOT NULL CHECK (per_minute > 0),
+    updated_at     TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
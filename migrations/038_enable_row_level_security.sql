@@ -0,0 +1,37 @@
+-- Migration: 038_enable_row_level_security.sql
+-- Tenant isolation as defense in depth: with DB_RLS=true the service sets
+-- app.tenant_key on every connection used for a user request, and these
+-- policies hide (and refuse to write) rows of other tenants even if a query
+-- forgets its tenant filter. An unset or empty app.tenant_key sees every row,
+-- so background jobs, consumers and deployments without DB_RLS are unaffected.
+-- FORCE applies the policies to the table owner too; superusers and roles
+-- with BYPASSRLS are still exempt.
+
+-- +goose Up
+-- +goose StatementBegin
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    FOREACH t IN ARRAY ARRAY[
+        'notifications', 'notifications_archive', 'notification_tombstones', 'notification_events',
+        'notification_audit', 'notification_reactions', 'notification_preferences', 'broadcast_read_state'
+    ] LOOP
+        EXECUTE format('ALTER TABLE %I ENABLE ROW LEVEL SECURITY', t);
+        EXECUTE format('ALTER TABLE %I FORCE ROW LEVEL SECURITY', t);
+        EXECUTE format('DROP POLICY IF EXISTS tenant_isolation ON %I', t);
+        EXECUTE format($p$CREATE POLICY tenant_isolation ON %I
+            USING (COALESCE(current_setting('app.tenant_key', true), '') IN ('', tenant_key))$p$, t);
+    END LOOP;
+END
+$$;
+-- +goose StatementEnd
+
+-- PLATFORM broadcasts (tenant_key NULL) are visible to every tenant but only
+-- written by unscoped connections.
+ALTER TABLE broadcast_notifications ENABLE ROW LEVEL SECURITY;
+ALTER TABLE broadcast_notifications FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON broadcast_notifications;
+CREATE POLICY tenant_isolation ON broadcast_notifications
+    USING (tenant_key IS NULL OR COALESCE(current_setting('app.tenant_key', true), '') IN ('', tenant_key))
+    WITH CHECK (COALESCE(current_setting('app.tenant_key', true), '') IN ('', tenant_key));
@@ -0,0 +1,21 @@
+-- Migration: 039_create_notification_stats.sql
+-- Nightly engagement rollup of per-user notifications (hot and archived) by
+-- day of delivery, tenant and type. Recent days are recomputed each night, as
+-- reads keep arriving after delivery; older rows stay after the notifications
+-- they count are purged.
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS notification_stats (
+    day                 DATE             NOT NULL,  -- delivery day in the stats job's timezone
+    tenant_key          VARCHAR(100)     NOT NULL,
+    type                VARCHAR(50)      NOT NULL,
+    delivered           BIGINT           NOT NULL,
+    read                BIGINT           NOT NULL,
+    median_read_seconds DOUBLE PRECISION,           -- NULL when none was read
+    computed_at         TIMESTAMPTZ      NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (day, tenant_key, type)
+);
+
+-- Stats of one tenant over a range of days
+CREATE INDEX IF NOT EXISTS idx_notification_stats_tenant_day
+    ON notification_stats (tenant_key, day);
@@ -0,0 +1,33 @@
+-- Migration: 040_create_tenant_quotas.sql
+-- Per-tenant monthly quota on created notifications, usage per calendar month
+-- (UTC) and the fan-outs held while a "queue" tenant is over its quota.
+-- Tenants without a quota row use the service default.
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS tenant_quotas (
+    tenant_key    VARCHAR(100) PRIMARY KEY,
+    monthly_limit BIGINT       NOT NULL CHECK (monthly_limit >= 0),   -- 0 = unlimited
+    overage       VARCHAR(10)  NOT NULL CHECK (overage IN ('reject', 'queue')),
+    updated_at    TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS tenant_usage (
+    tenant_key  VARCHAR(100) NOT NULL,
+    month       DATE         NOT NULL,  -- first day of the month
+    created     BIGINT       NOT NULL DEFAULT 0,
+    rejected    BIGINT       NOT NULL DEFAULT 0,
+    queued      BIGINT       NOT NULL DEFAULT 0,
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_key, month)
+);
+
+CREATE TABLE IF NOT EXISTS quota_held_notifications (
+    id          BIGSERIAL    PRIMARY KEY,
+    tenant_key  VARCHAR(100) NOT NULL,
+    input       JSONB        NOT NULL,
+    recipients  INT          NOT NULL,
+    held_at     TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_quota_held_tenant
+    ON quota_held_notifications (tenant_key, id);
@@ -0,0 +1,10 @@
+// Package migrations embeds the versioned SQL schema of the notification service.
+// Files are goose migrations: NNN_description.sql with a "-- +goose Up" section.
+package migrations
+
+import "embed"
+
+// FS holds every migration file, applied in version order.
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func TestMigrationsAreSequentialGooseFiles(t *testing.T) {
+	names, err := fs.Glob(FS, "*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+	for i, name := range names {
+		if want := fmt.Sprintf("%03d_", i+1); !strings.HasPrefix(name, want) {
+			t.Errorf("%s: expected version prefix %s", name, want)
+		}
+		b, err := FS.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(b), "\n-- +goose Up\n") {
+			t.Errorf("%s: missing -- +goose Up annotation", name)
+		}
+	}
+}
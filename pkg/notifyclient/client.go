@@ -0,0 +1,106 @@
+package notifyclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// DefaultTopic is the topic the notification service reads commands from.
+const DefaultTopic = "notification-commands"
+
+// Producer writes one record to Kafka.
+type Producer interface {
+	Produce(ctx context.Context, topic string, key, value []byte) error
+}
+
+// kafkaProducer adapts a franz-go client to Producer.
+type kafkaProducer struct {
+	client *kgo.Client
+}
+
+// NewKafkaProducer returns a Producer writing synchronously through client.
+func NewKafkaProducer(client *kgo.Client) Producer {
+	return kafkaProducer{client: client}
+}
+
+func (p kafkaProducer) Produce(ctx context.Context, topic string, key, value []byte) error {
+	return p.client.ProduceSync(ctx, &kgo.Record{Topic: topic, Key: key, Value: value}).FirstErr()
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithTopic overrides DefaultTopic.
+func WithTopic(topic string) Option {
+	return func(c *Client) { c.topic = topic }
+}
+
+// WithRetries sets how often a failed publish is retried and the first backoff,
+// doubled after each attempt. The default is 3 retries from 200ms.
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(c *Client) { c.retries, c.backoff = max(retries, 0), backoff }
+}
+
+// Client publishes notification commands.
+type Client struct {
+	producer Producer
+	topic    string
+	retries  int
+	backoff  time.Duration
+}
+
+// New creates a Client publishing through producer.
+func New(producer Producer, opts ...Option) *Client {
+	c := &Client{producer: producer, topic: DefaultTopic, retries: 3, backoff: 200 * time.Millisecond}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Send validates cmd and publishes it, retrying failed attempts with the same
+// command ID so the service creates its notifications once. A missing CommandID
+// is generated; Send returns the one used. Invalid commands are not published
+// and return an error wrapping ErrInvalidCommand; commands the service refuses
+// are not retried and return an error wrapping ErrRejected.
+//
+// Records are keyed by tenant, which keeps a tenant's commands in order.
+func (c *Client) Send(ctx context.Context, cmd Command) (string, error) {
+	if cmd.CommandID == "" {
+		cmd.CommandID = uuid.NewString()
+	}
+	if err := cmd.Validate(); err != nil {
+		return cmd.CommandID, err
+	}
+	value, err := json.Marshal(cmd)
+	if err != nil {
+		return cmd.CommandID, fmt.Errorf("encode notification command: %w", err)
+	}
+	key := cmd.TenantKey
+	if key == "" {
+		key = cmd.CommandID
+	}
+
+	backoff := c.backoff
+	for attempt := 0; ; attempt++ {
+		err = c.producer.Produce(ctx, c.topic, []byte(key), value)
+		if err == nil {
+			return cmd.CommandID, nil
+		}
+		if attempt == c.retries || ctx.Err() != nil || errors.Is(err, ErrRejected) {
+			return cmd.CommandID, fmt.Errorf("publish notification command %s: %w", cmd.CommandID, err)
+		}
+		select {
+		case <-ctx.Done():
+			return cmd.CommandID, fmt.Errorf("publish notification command %s: %w", cmd.CommandID, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,138 @@
+package notifyclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// recorder is a Producer failing the first failures calls.
+type recorder struct {
+	failures int
+	calls    int
+	key      string
+	value    []byte
+}
+
+func (r *recorder) Produce(_ context.Context, _ string, key, value []byte) error {
+	r.calls++
+	if r.calls <= r.failures {
+		return errors.New("broker unavailable")
+	}
+	r.key, r.value = string(key), value
+	return nil
+}
+
+func TestSend(t *testing.T) {
+	p := &recorder{failures: 2}
+	c := New(p, WithRetries(3, time.Millisecond))
+	id, err := c.Send(context.Background(), Command{
+		TenantKey:   "acme",
+		TargetScope: ScopeRole,
+		TargetID:    "MANAGER",
+		Exclude:     []Target{{Scope: ScopeUser, ID: "u-origin"}},
+		Type:        TypeCRM,
+		Category:    "crm.deal",
+		Title:       "Deal won",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id == "" || p.calls != 3 || p.key != "acme" {
+		t.Fatalf("id = %q, calls = %d, key = %q", id, p.calls, p.key)
+	}
+	var wire map[string]any
+	if err := json.Unmarshal(p.value, &wire); err != nil {
+		t.Fatal(err)
+	}
+	if wire["commandId"] != id || wire["targetScope"] != "ROLE" || wire["targetId"] != "MANAGER" || wire["priority"] != nil {
+		t.Fatalf("wire = %s", p.value)
+	}
+
+	p = &recorder{failures: 5}
+	if _, err := New(p, WithRetries(1, time.Millisecond)).Send(context.Background(), Command{TargetScope: ScopePlatform, Title: "t"}); err == nil || p.calls != 2 {
+		t.Fatalf("err = %v, calls = %d", err, p.calls)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  Command
+		ok   bool
+	}{
+		{"user", Command{TenantKey: "acme", TargetScope: ScopeUser, TargetID: "u1", Title: "t"}, true},
+		{"platform without tenant", Command{TargetScope: ScopePlatform, Template: &Template{Key: "system.maintenance"}}, true},
+		{"composite", Command{TenantKey: "acme", Targets: []Target{{Scope: ScopeGroup, ID: "/Finance"}}, Title: "t"}, true},
+		{"no target", Command{TenantKey: "acme", Title: "t"}, false},
+		{"user without id", Command{TenantKey: "acme", TargetScope: ScopeUser, Title: "t"}, false},
+		{"tenant missing", Command{TargetScope: ScopeTenant, Title: "t"}, false},
+		{"bad type", Command{TenantKey: "acme", TargetScope: ScopeTenant, Type: "invoice", Title: "t"}, false},
+		{"bad category", Command{TenantKey: "acme", TargetScope: ScopeTenant, Category: "CRM.Deal", Title: "t"}, false},
+		{"bad priority", Command{TenantKey: "acme", TargetScope: ScopeTenant, Priority: "MEDIUM", Title: "t"}, false},
+		{"no title", Command{TenantKey: "acme", TargetScope: ScopeTenant}, false},
+		{"external link", Command{TenantKey: "acme", TargetScope: ScopeTenant, Title: "t", Link: "https://docs.arda.vn/x"}, true},
+		{"protocol-relative link", Command{TenantKey: "acme", TargetScope: ScopeTenant, Title: "t", Link: "//evil.example"}, false},
+		{"sender without id", Command{TenantKey: "acme", TargetScope: ScopeTenant, Title: "t", Sender: &Sender{Type: SenderUser}}, false},
+		{"rollout on tenant", Command{TenantKey: "acme", TargetScope: ScopeTenant, Title: "t", Rollout: &Rollout{InitialPercent: 10}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cmd.Validate()
+			if (err == nil) != tt.ok {
+				t.Fatalf("Validate() = %v", err)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidCommand) {
+				t.Fatalf("err = %v, want ErrInvalidCommand", err)
+			}
+		})
+	}
+
+	p := &recorder{}
+	if _, err := New(p).Send(context.Background(), Command{TenantKey: "acme"}); !errors.Is(err, ErrInvalidCommand) || p.calls != 0 {
+		t.Fatalf("err = %v, calls = %d", err, p.calls)
+	}
+}
+
+func TestHTTPProducer(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path != "/internal/notifications" || r.Header.Get("Authorization") != "Bearer tok" {
+			t.Errorf("%s %s, auth %q", r.Method, r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var cmd Command
+		if err := json.NewDecoder(r.Body).Decode(&cmd); err != nil {
+			t.Error(err)
+		}
+		switch {
+		case cmd.Type == "UNKNOWN":
+			http.Error(w, `{"message":"unknown notification type"}`, http.StatusUnprocessableEntity)
+		case calls == 1:
+			http.Error(w, `{"message":"rate limited"}`, http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer srv.Close()
+
+	token := func(context.Context) (string, error) { return "tok", nil }
+	c := New(NewHTTPProducer(srv.URL+"/", token, nil), WithRetries(3, time.Millisecond))
+	cmd := Command{
+		TenantKey: "acme", TargetScope: ScopeUser, TargetID: "u1", Title: "t",
+		Link: "/crm/deals/D-1", Sender: &Sender{Type: SenderService, ID: "crm"},
+	}
+	if _, err := c.Send(context.Background(), cmd); err != nil || calls != 2 {
+		t.Fatalf("err = %v, calls = %d", err, calls)
+	}
+
+	calls = 0
+	cmd.Type = "UNKNOWN"
+	if _, err := c.Send(context.Background(), cmd); !errors.Is(err, ErrRejected) || calls != 1 {
+		t.Fatalf("err = %v, calls = %d", err, calls)
+	}
+}
@@ -0,0 +1,251 @@
+// Package notifyclient publishes notification commands to the notification
+// service, for other arda services: to the notification-commands topic or the
+// internal REST API. It is a Go module of its own, depending only on the
+// standard library, franz-go and uuid, and mirrors the command wire format so
+// producers do not hand-roll the JSON.
+package notifyclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Scope selects who a command is fanned out to.
+type Scope string
+
+const (
+	ScopeUser     Scope = "USER"     // TargetID is a Keycloak user ID
+	ScopeTenant   Scope = "TENANT"   // every user of the tenant
+	ScopePlatform Scope = "PLATFORM" // every active user of every tenant
+	ScopeRole     Scope = "ROLE"     // TargetID is a role name
+	ScopeGroup    Scope = "GROUP"    // TargetID is a Keycloak group ID or path ("/Finance")
+)
+
+// Priority of a notification; empty lets the service apply the type's default.
+type Priority string
+
+const (
+	PriorityLow    Priority = "LOW"
+	PriorityNormal Priority = "NORMAL"
+	PriorityHigh   Priority = "HIGH"
+	PriorityUrgent Priority = "URGENT"
+)
+
+// Built-in notification types. Other types must be registered by the tenant.
+const (
+	TypeSystem   = "SYSTEM"
+	TypeWorkflow = "WORKFLOW"
+	TypeCRM      = "CRM"
+	TypeIAM      = "IAM"
+	TypeCustom   = "CUSTOM"
+)
+
+// Limits checked by Validate, the same as the service's.
+const (
+	MaxCommandIDLen = 255
+	MaxTypeLen      = 50
+	MaxCategoryLen  = 100
+	MaxLinkLen      = 2048
+	MaxIconLen      = 512
+	MaxSenderIDLen  = 255
+)
+
+// ErrInvalidCommand is returned by Validate and Client.Send for a command the
+// service would reject.
+var ErrInvalidCommand = errors.New("invalid notification command")
+
+// Target is one recipient set of a composite fan-out.
+type Target struct {
+	Scope Scope  `json:"scope"`
+	ID    string `json:"id,omitempty"`
+}
+
+// Template renders Title and Body from a template registered in the service.
+type Template struct {
+	Key    string            `json:"key"`
+	Params map[string]string `json:"params,omitempty"`
+}
+
+// SenderType tells whether a notification was triggered by a service or a user.
+type SenderType string
+
+const (
+	SenderService SenderType = "service"
+	SenderUser    SenderType = "user"
+)
+
+// Sender identifies who or what triggered a notification: a service ("crm", ...)
+// or a user (Keycloak user ID).
+type Sender struct {
+	Type SenderType `json:"type"`
+	ID   string     `json:"id"`
+	Name string     `json:"name,omitempty"`
+}
+
+// Rollout stages a PLATFORM command across tenants.
+type Rollout struct {
+	InitialPercent      int  `json:"initialPercent"`
+	DelaySeconds        int  `json:"delaySeconds,omitempty"`
+	RequireConfirmation bool `json:"requireConfirmation,omitempty"`
+}
+
+// Command is a message of the notification-commands topic.
+type Command struct {
+	// CommandID is the idempotency key: the service creates a command's
+	// notifications once however often it is delivered. Client.Send generates
+	// one when empty.
+	CommandID   string         `json:"commandId"`
+	TenantKey   string         `json:"tenantKey,omitempty"`
+	TargetScope Scope          `json:"targetScope,omitempty"`
+	TargetID    string         `json:"targetId,omitempty"`
+	Targets     []Target       `json:"targets,omitempty"`
+	Exclude     []Target       `json:"exclude,omitempty"`
+	Type        string         `json:"type,omitempty"`
+	Category    string         `json:"category,omitempty"`
+	Priority    Priority       `json:"priority,omitempty"`
+	Title       string         `json:"title,omitempty"`
+	Body        string         `json:"body,omitempty"`
+	Link        string         `json:"link,omitempty"` // app path ("/crm/deals/D-1") or http(s) URL opened on click
+	Icon        string         `json:"icon,omitempty"` // icon name or URL
+	Sender      *Sender        `json:"sender,omitempty"`
+	Metadata    map[string]any `json:"metadata,omitempty"`
+	Template    *Template      `json:"template,omitempty"`
+	Rollout     *Rollout       `json:"rollout,omitempty"`
+}
+
+// Validate checks c against the rules the service applies on ingest.
+func (c Command) Validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+	if len(c.CommandID) > MaxCommandIDLen {
+		fail("commandId: at most %d characters", MaxCommandIDLen)
+	}
+	if c.TargetScope == "" && len(c.Targets) == 0 {
+		fail("targetScope or targets is required")
+	}
+	if c.TargetScope != "" {
+		if err := checkTarget(Target{Scope: c.TargetScope, ID: c.TargetID}); err != nil {
+			fail("targetScope: %v", err)
+		}
+	}
+	if c.TenantKey == "" && c.TargetScope != ScopePlatform {
+		fail("tenantKey is required unless targetScope is %s", ScopePlatform)
+	}
+	for i, t := range c.Targets {
+		if err := checkTarget(t); err != nil {
+			fail("targets[%d]: %v", i, err)
+		}
+	}
+	for i, t := range c.Exclude {
+		if err := checkTarget(t); err != nil {
+			fail("exclude[%d]: %v", i, err)
+		}
+	}
+	if c.Type != "" && !validType(c.Type) {
+		fail("type %q: want an uppercase letter followed by [A-Z0-9_], at most %d characters", c.Type, MaxTypeLen)
+	}
+	if !validCategory(c.Category) {
+		fail("category %q: want dot-separated [a-z0-9_-] segments, at most %d characters", c.Category, MaxCategoryLen)
+	}
+	switch c.Priority {
+	case "", PriorityLow, PriorityNormal, PriorityHigh, PriorityUrgent:
+	default:
+		fail("priority %q: want LOW, NORMAL, HIGH or URGENT", c.Priority)
+	}
+	if c.Link != "" && !validLink(c.Link) {
+		fail("link: want an app path or an http(s) URL of at most %d characters", MaxLinkLen)
+	}
+	if len(c.Icon) > MaxIconLen {
+		fail("icon: at most %d characters", MaxIconLen)
+	}
+	if s := c.Sender; s != nil {
+		if s.Type != SenderService && s.Type != SenderUser {
+			fail("sender: type %q: want %s or %s", s.Type, SenderService, SenderUser)
+		}
+		if s.ID == "" || len(s.ID) > MaxSenderIDLen {
+			fail("sender: want an id of 1 to %d characters", MaxSenderIDLen)
+		}
+	}
+	if c.Template != nil && c.Template.Key == "" {
+		fail("template: key is required")
+	}
+	if strings.TrimSpace(c.Title) == "" && c.Template == nil {
+		fail("title or template is required")
+	}
+	if c.Rollout != nil {
+		if c.TargetScope != ScopePlatform {
+			fail("rollout: only for targetScope %s", ScopePlatform)
+		}
+		if c.Rollout.InitialPercent < 0 || c.Rollout.InitialPercent > 100 || c.Rollout.DelaySeconds < 0 {
+			fail("rollout: want initialPercent in 0..100 and a non-negative delaySeconds")
+		}
+	}
+	if _, err := json.Marshal(c.Metadata); err != nil {
+		fail("metadata: %v", err)
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrInvalidCommand, errors.Join(errs...))
+}
+
+func checkTarget(t Target) error {
+	switch t.Scope {
+	case ScopeUser, ScopeRole, ScopeGroup:
+		if t.ID == "" {
+			return fmt.Errorf("scope %s needs an id", t.Scope)
+		}
+	case ScopeTenant, ScopePlatform:
+	default:
+		return fmt.Errorf("unknown scope %q", t.Scope)
+	}
+	return nil
+}
+
+func validLink(s string) bool {
+	if len(s) > MaxLinkLen {
+		return false
+	}
+	if strings.HasPrefix(s, "/") {
+		return !strings.HasPrefix(s, "//")
+	}
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "https" || u.Scheme == "http") && u.Host != ""
+}
+
+func validType(t string) bool {
+	if len(t) > MaxTypeLen || t[0] < 'A' || t[0] > 'Z' {
+		return false
+	}
+	for _, r := range t {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+func validCategory(c string) bool {
+	if c == "" {
+		return true
+	}
+	if len(c) > MaxCategoryLen {
+		return false
+	}
+	for _, seg := range strings.Split(c, ".") {
+		if seg == "" {
+			return false
+		}
+		for _, r := range seg {
+			if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '_' && r != '-' {
+				return false
+			}
+		}
+	}
+	return true
+}
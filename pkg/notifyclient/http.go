@@ -0,0 +1,73 @@
+package notifyclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// internalPath is the internal REST endpoint accepting commands.
+const internalPath = "/internal/notifications"
+
+// maxErrorBody bounds the response body kept in an error.
+const maxErrorBody = 512
+
+// ErrRejected is returned when the service refuses a command it received: the
+// command is invalid, its type is unknown or it exceeds a limit. Client.Send
+// does not retry such errors; 429 (rate limit, quota) and 5xx are retried.
+var ErrRejected = errors.New("notification command rejected")
+
+// TokenSource returns the bearer token of a request, typically a Keycloak
+// client-credentials token cached until it expires.
+type TokenSource func(ctx context.Context) (string, error)
+
+// httpProducer posts commands to POST /internal/notifications.
+type httpProducer struct {
+	url    string
+	token  TokenSource
+	client *http.Client
+}
+
+// NewHTTPProducer returns a Producer calling the internal REST API at baseURL
+// (e.g. "http://arda-notification:8090") instead of Kafka: the notifications
+// exist when Send returns. A nil client uses http.DefaultClient. The topic and
+// key are ignored.
+func NewHTTPProducer(baseURL string, token TokenSource, client *http.Client) Producer {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return httpProducer{url: strings.TrimSuffix(baseURL, "/") + internalPath, token: token, client: client}
+}
+
+func (p httpProducer) Produce(ctx context.Context, _ string, _, value []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(value))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.token != nil {
+		token, err := p.token(ctx)
+		if err != nil {
+			return fmt.Errorf("service token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	switch {
+	case resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	default:
+		return fmt.Errorf("%w: status %d: %s", ErrRejected, resp.StatusCode, bytes.TrimSpace(body))
+	}
+}